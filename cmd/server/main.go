@@ -3,48 +3,95 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // 容器镜像可能没有时区数据，publish.timezone 依赖内置的时区数据库
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
 
 	"gin-mysql-api/internal/repository"
 	"gin-mysql-api/internal/router"
 	"gin-mysql-api/internal/service"
 	"gin-mysql-api/pkg/config"
 	"gin-mysql-api/pkg/database"
+	"gin-mysql-api/pkg/health"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/metrics"
+	"gin-mysql-api/pkg/notify"
+	"gin-mysql-api/pkg/signing"
+	"gin-mysql-api/pkg/storage"
+	"gin-mysql-api/pkg/tracing"
+	"gin-mysql-api/pkg/transcode"
 	"gin-mysql-api/pkg/utils"
+	"gin-mysql-api/pkg/version"
 )
 
+// configFile 基础配置文件，APP_ENV 指定的 profile 文件（如 configs/config.production.yaml）会叠加在其上
+const configFile = "configs/config.yaml"
+
 func main() {
-	log.Println("Gin MySQL API Server - 启动中...")
+	// 加载并校验配置
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		fatal("加载配置失败", err)
+	}
 
-	// 加载配置
-	cfg, err := config.LoadConfig("configs/config.yaml")
+	// 设置日志，之后所有组件都通过 slog 输出结构化日志
+	appLogger, logCloser, err := logger.New(&cfg.Logging)
 	if err != nil {
-		log.Fatalf("加载配置失败: %v", err)
+		fatal("初始化日志失败", err)
 	}
+	defer logCloser.Close()
+	slog.SetDefault(appLogger)
 
-	// 设置日志
-	setupLogging(cfg)
+	info := version.Get()
+	slog.Info("Gin MySQL API Server 启动中", slog.String("version", info.Version), slog.String("commit", info.Commit), slog.String("profile", cfg.Profile))
 
 	// 设置Gin模式
 	gin.SetMode(cfg.Server.Mode)
 
+	// 初始化链路追踪
+	shutdownTracing, err := tracing.Init(&cfg.Tracing)
+	if err != nil {
+		fatal("初始化链路追踪失败", err)
+	}
+
 	// 连接数据库
 	db, err := database.NewConnection(cfg)
 	if err != nil {
-		log.Fatalf("数据库连接失败: %v", err)
+		fatal("数据库连接失败", err)
 	}
 
-	// 连接Redis
-	redisClient, err := database.NewRedisConnection(cfg)
-	if err != nil {
-		log.Fatalf("Redis连接失败: %v", err)
+	// SQLite 没有独立的建表脚本，启动时自动迁移并写入种子数据
+	if cfg.Database.GetDriver() == database.DriverSQLite {
+		if err := database.InitDatabase(db); err != nil {
+			fatal("数据库初始化失败", err)
+		}
+	}
+
+	// 注册数据库连接池指标
+	if sqlDB, err := db.DB(); err == nil {
+		if err := metrics.RegisterDBStats(sqlDB, cfg.Database.GetDriver()); err != nil {
+			slog.Warn("注册数据库连接池指标失败", slog.String("error", err.Error()))
+		}
+	}
+
+	// 连接Redis（缓存驱动为 memory 时不需要 Redis）
+	var redisClient *redis.Client
+	if cfg.Cache.GetDriver() != service.CacheDriverMemory {
+		redisClient, err = database.NewRedisConnection(cfg)
+		if err != nil {
+			// Redis 不可用时不退出，缓存服务会降级为本地缓存并在后台重连
+			slog.Warn("Redis连接失败，将使用本地缓存运行", slog.String("error", err.Error()))
+			redisClient = database.NewRedisClient(&cfg.Redis)
+		}
 	}
 
 	// 初始化仓储层
@@ -52,31 +99,138 @@ func main() {
 	adminRepo := repository.NewAdminRepository(db)
 	dramaRepo := repository.NewDramaRepository(db)
 	episodeRepo := repository.NewEpisodeRepository(db)
+	uploadRepo := repository.NewUploadSessionRepository(db)
+	mediaRepo := repository.NewMediaAssetRepository(db)
+	transcodeRepo := repository.NewTranscodeRepository(db)
+	reviewRepo := repository.NewReviewRepository(db)
+	trashRepo := repository.NewTrashRepository(db)
+	revisionRepo := repository.NewRevisionRepository(db)
 
 	// 初始化JWT管理器
 	jwtManager := utils.NewJWTManager(cfg.JWT.Secret, cfg.JWT.Expiration)
 
 	// 初始化缓存服务
-	cacheService := service.NewCacheService(redisClient)
+	cacheService := service.NewCacheServiceWithConfig(&cfg.Cache, redisClient, appLogger)
+	if closer, ok := cacheService.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	// 初始化对象存储
+	store, err := storage.New(&cfg.Storage, cfg.Upload.UploadPath)
+	if err != nil {
+		fatal("初始化对象存储失败", err)
+	}
 
 	// 初始化服务层
-	userService := service.NewUserService(userRepo, jwtManager)
-	adminService := service.NewAdminService(adminRepo, dramaRepo, episodeRepo, jwtManager, cacheService)
-	dramaService := service.NewDramaService(dramaRepo, episodeRepo, cacheService)
-	fileService := service.NewFileService(cfg.Upload.UploadPath, "http://localhost:1800", int64(cfg.Upload.MaxSize*1024*1024), cfg.Upload.AllowedTypes)
-	authService := service.NewAuthService(userRepo, adminRepo, jwtManager)
+	imageService := service.NewImageService(mediaRepo, store, service.NewImageServiceConfig(cfg), appLogger)
+	if closer, ok := imageService.(io.Closer); ok {
+		defer closer.Close()
+	}
+	mediaService := service.NewMediaService(mediaRepo, store, imageService, cfg.Upload.GC.GetRetention(), appLogger)
+	fileService := service.NewFileService(store, mediaRepo, imageService, service.NewFileServiceConfig(cfg), appLogger)
+	contentKeyService := service.NewContentKeyService(transcodeRepo, service.NewContentKeyring(cfg), cfg.Server.GetBaseURL(), appLogger)
+	var transcodeService service.TranscodeService
+	if cfg.Transcode.Enabled {
+		executor := transcode.NewFFmpeg(cfg.Transcode.GetFFmpegPath(), cfg.Transcode.GetSegmentSeconds())
+		transcodeService = service.NewTranscodeService(transcodeRepo, episodeRepo, mediaRepo, store, executor, contentKeyService, cacheService, service.NewTranscodeServiceConfig(cfg), appLogger)
+	}
+	playbackSigner := service.NewPlaybackSigner(cfg)
+	playbackService := service.NewPlaybackService(transcodeRepo, episodeRepo, mediaRepo, fileService, contentKeyService, cacheService, playbackSigner, service.NewPlaybackServiceConfig(cfg), appLogger)
+	userService := service.NewUserService(userRepo, jwtManager, mediaService, appLogger)
+	adminService := service.NewAdminService(adminRepo, dramaRepo, episodeRepo, jwtManager, cacheService, mediaService, transcodeService, revisionRepo, appLogger)
+	dramaService := service.NewDramaService(dramaRepo, episodeRepo, cacheService, playbackService, appLogger)
+	authService := service.NewAuthService(userRepo, adminRepo, jwtManager, appLogger)
+	uploadService := service.NewUploadService(uploadRepo, store, fileService, service.NewUploadServiceConfig(cfg), appLogger)
+	publishService := service.NewPublishService(adminRepo, dramaRepo, episodeRepo, reviewRepo, cacheService, notify.New(&cfg.Publish.Webhook), cfg.Publish.GetLocation(), appLogger)
+	reviewService := service.NewReviewService(adminRepo, dramaRepo, episodeRepo, reviewRepo, cacheService, appLogger)
+	previewService := service.NewPreviewService(dramaRepo, episodeRepo, jwtManager, cfg.Server.GetBaseURL(), cfg.Playback.GetPreviewTTL(), appLogger)
+	trashService := service.NewTrashService(trashRepo, dramaRepo, episodeRepo, mediaService, store, cacheService, cfg.Trash.GetRetention(), appLogger)
+	revisionService := service.NewRevisionService(revisionRepo, adminService, appLogger)
 
 	// 初始化服务容器
 	serviceContainer := &service.Container{
-		UserService:  userService,
-		AdminService: adminService,
-		DramaService: dramaService,
-		FileService:  fileService,
-		AuthService:  authService,
+		UserService:       userService,
+		AdminService:      adminService,
+		DramaService:      dramaService,
+		FileService:       fileService,
+		UploadService:     uploadService,
+		MediaService:      mediaService,
+		ImageService:      imageService,
+		AuthService:       authService,
+		TranscodeService:  transcodeService,
+		PlaybackService:   playbackService,
+		ContentKeyService: contentKeyService,
+		PublishService:    publishService,
+		ReviewService:     reviewService,
+		PreviewService:    previewService,
+		TrashService:      trashService,
+		RevisionService:   revisionService,
+	}
+
+	// 定期清理过期的分片上传会话，回收长期未被引用的媒体文件
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	go runPeriodically(cleanupCtx, cfg.Upload.Resumable.GetCleanupInterval(), "清理过期上传会话失败", func(ctx context.Context) error {
+		_, err := uploadService.WithContext(ctx).CleanupExpired()
+		return err
+	})
+	go runPeriodically(cleanupCtx, cfg.Upload.GC.GetInterval(), "回收媒体文件失败", func(ctx context.Context) error {
+		_, err := mediaService.WithContext(ctx).CollectGarbage()
+		return err
+	})
+
+	// 定时发布到期的短剧与剧集，多个实例部署时通过状态条件更新保证只发布一次
+	go runPeriodically(cleanupCtx, cfg.Publish.GetInterval(), "定时发布失败", func(ctx context.Context) error {
+		_, err := publishService.WithContext(ctx).PublishDue()
+		return err
+	})
+
+	// 永久删除回收站中超过保留期的短剧与剧集
+	go runPeriodically(cleanupCtx, cfg.Trash.GetInterval(), "清理回收站失败", func(ctx context.Context) error {
+		_, err := trashService.WithContext(ctx).PurgeExpired()
+		return err
+	})
+
+	// 轮换主密钥后，使用新主密钥重新加密已保存的内容密钥
+	go func() {
+		if _, err := contentKeyService.WithContext(cleanupCtx).Rewrap(); err != nil {
+			slog.Error("重新加密内容密钥失败", slog.String("error", err.Error()))
+		}
+	}()
+
+	// 后台处理视频转码任务，多个实例部署时任务通过数据库领取，不会重复处理
+	if transcodeService != nil {
+		for i := 0; i < cfg.Transcode.GetWorkers(); i++ {
+			go runPeriodically(cleanupCtx, cfg.Transcode.GetPollInterval(), "处理转码任务失败", func(ctx context.Context) error {
+				_, err := transcodeService.WithContext(ctx).ProcessPending()
+				return err
+			})
+		}
 	}
 
 	// 设置路由
-	r := router.NewRouter(jwtManager, serviceContainer).Setup()
+	appRouter := router.NewRouter(jwtManager, serviceContainer).
+		WithConfig(cfg).
+		WithMetrics(cfg.Metrics).
+		WithHealth(setupHealthChecks(cfg, db, redisClient, store))
+	r := appRouter.Setup()
+
+	// 监听配置文件变化，热更新日志级别、CORS 来源与限流设置
+	if watcher, err := config.NewWatcher(configFile, cfg); err != nil {
+		slog.Warn("配置热更新不可用", slog.String("error", err.Error()))
+	} else {
+		defer watcher.Close()
+		watcher.OnChange(func(newCfg *config.Config) {
+			if err := logger.SetLevel(newCfg.Logging.Level); err != nil {
+				slog.Warn("更新日志级别失败", slog.String("error", err.Error()))
+			}
+			appRouter.Reload(newCfg)
+			// 轮换播放地址签名密钥，开关签名需要重启服务
+			if playbackSigner != nil && newCfg.Playback.Signed {
+				playbackSigner.SetKeys(signing.Keys(&newCfg.Playback))
+			}
+		})
+	}
 
 	// 创建HTTP服务器
 	server := &http.Server{
@@ -86,9 +240,9 @@ func main() {
 
 	// 启动服务器
 	go func() {
-		log.Printf("服务器启动在 %s:%d", cfg.Server.Host, cfg.Server.Port)
+		slog.Info("服务器启动", slog.String("addr", server.Addr))
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("服务器启动失败: %v", err)
+			fatal("服务器启动失败", err)
 		}
 	}()
 
@@ -96,14 +250,14 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("正在关闭服务器...")
+	slog.Info("正在关闭服务器...")
 
 	// 优雅关闭服务器，等待5秒钟完成现有请求
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("服务器强制关闭: %v", err)
+		slog.Error("服务器强制关闭", slog.String("error", err.Error()))
 	}
 
 	// 关闭数据库连接
@@ -111,23 +265,72 @@ func main() {
 	sqlDB.Close()
 
 	// 关闭Redis连接
-	redisClient.Close()
+	if redisClient != nil {
+		redisClient.Close()
+	}
+
+	// 上报剩余的链路数据
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("关闭链路追踪失败", slog.String("error", err.Error()))
+	}
 
-	log.Println("服务器已退出")
+	slog.Info("服务器已退出")
 }
 
-// setupLogging 设置日志配置
-func setupLogging(cfg *config.Config) {
-	// 根据配置设置日志级别和格式
-	// 这里可以集成更复杂的日志库如logrus或zap
-	if cfg.Logging.Output == "file" {
-		// 确保日志目录存在
-		if err := os.MkdirAll("logs", 0755); err != nil {
-			log.Printf("创建日志目录失败: %v", err)
-		}
+// setupHealthChecks 注册依赖探测
+// 只有数据库是关键依赖；Redis 不可用时缓存会降级，存储异常只影响上传与文件访问
+func setupHealthChecks(cfg *config.Config, db *gorm.DB, redisClient *redis.Client, store storage.Storage) *health.Registry {
+	registry := health.NewRegistry(cfg.Health.GetCacheTTL(), cfg.Health.GetTimeout())
 
-		// 这里可以设置日志文件输出
-		log.Printf("日志配置: 级别=%s, 格式=%s, 输出=%s",
-			cfg.Logging.Level, cfg.Logging.Format, cfg.Logging.Output)
+	registry.Register(health.Probe{
+		Name:     "database",
+		Critical: true,
+		Check:    health.DatabaseCheck(db),
+	})
+	if redisClient != nil {
+		registry.Register(health.Probe{
+			Name:  "redis",
+			Check: health.RedisCheck(redisClient),
+		})
+	}
+	if cfg.Storage.GetDriver() == storage.DriverLocal {
+		registry.Register(health.Probe{
+			Name:  "upload_dir",
+			Check: health.WritableDirCheck(cfg.Upload.UploadPath),
+		})
+		registry.Register(health.Probe{
+			Name:  "disk_space",
+			Check: health.DiskSpaceCheck(cfg.Upload.UploadPath, cfg.Health.GetMinFreeDiskBytes()),
+		})
+	} else {
+		registry.Register(health.Probe{
+			Name:  "storage",
+			Check: health.StorageCheck(store),
+		})
 	}
+
+	return registry
+}
+
+// runPeriodically 按间隔执行后台任务，失败时记录日志，ctx 取消后退出
+func runPeriodically(ctx context.Context, interval time.Duration, errMsg string, task func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := task(ctx); err != nil {
+				slog.Warn(errMsg, slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// fatal 记录错误日志并以非零状态退出
+func fatal(msg string, err error) {
+	slog.Error(msg, slog.String("error", err.Error()))
+	os.Exit(1)
 }
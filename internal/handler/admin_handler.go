@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -13,16 +14,20 @@ import (
 // AdminHandler 管理员处理器
 type AdminHandler struct {
 	*BaseHandler
-	adminService service.AdminService
-	userService  service.UserService
+	adminService    service.AdminService
+	userService     service.UserService
+	playbackService service.PlaybackService
+	publishService  service.PublishService
 }
 
 // NewAdminHandler 创建管理员处理器
-func NewAdminHandler(adminService service.AdminService, userService service.UserService) *AdminHandler {
+func NewAdminHandler(adminService service.AdminService, userService service.UserService, playbackService service.PlaybackService, publishService service.PublishService) *AdminHandler {
 	return &AdminHandler{
-		BaseHandler:  NewBaseHandler(),
-		adminService: adminService,
-		userService:  userService,
+		BaseHandler:     NewBaseHandler(),
+		adminService:    adminService,
+		userService:     userService,
+		playbackService: playbackService,
+		publishService:  publishService,
 	}
 }
 
@@ -47,7 +52,7 @@ func (h *AdminHandler) CreateDrama(c *gin.Context) {
 		return
 	}
 
-	drama, err := h.adminService.CreateDrama(req)
+	drama, err := h.adminService.WithContext(c.Request.Context()).CreateDrama(req)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
@@ -58,7 +63,7 @@ func (h *AdminHandler) CreateDrama(c *gin.Context) {
 
 // UpdateDrama 更新短剧
 // @Summary 更新短剧
-// @Description 管理员更新短剧信息
+// @Description 管理员按 PATCH 语义更新短剧：未提供的字段保持不变，空字符串清空该字段（标题与分类不能清空）；每次更新保存一个修订版本。PUT 与 PATCH 相同
 // @Tags 管理员
 // @Security BearerAuth
 // @Accept json
@@ -68,6 +73,7 @@ func (h *AdminHandler) CreateDrama(c *gin.Context) {
 // @Success 200 {object} models.APIResponse{data=models.Drama}
 // @Failure 400 {object} models.APIResponse
 // @Failure 404 {object} models.APIResponse
+// @Router /api/admin/dramas/{id} [patch]
 // @Router /api/admin/dramas/{id} [put]
 func (h *AdminHandler) UpdateDrama(c *gin.Context) {
 	idStr := c.Param("id")
@@ -83,7 +89,12 @@ func (h *AdminHandler) UpdateDrama(c *gin.Context) {
 		return
 	}
 
-	drama, err := h.adminService.UpdateDrama(uint(id), req)
+	adminID, _ := h.GetUserIDFromContext(c)
+	drama, err := h.adminService.WithContext(c.Request.Context()).UpdateDrama(uint(id), adminID, req)
+	if errors.Is(err, service.ErrContentNotFound) {
+		h.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
 	if err != nil {
 		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
@@ -94,7 +105,7 @@ func (h *AdminHandler) UpdateDrama(c *gin.Context) {
 
 // DeleteDrama 删除短剧
 // @Summary 删除短剧
-// @Description 管理员删除短剧
+// @Description 管理员删除短剧，短剧与其剧集一起移入回收站，可以在回收站中恢复
 // @Tags 管理员
 // @Security BearerAuth
 // @Produce json
@@ -111,7 +122,11 @@ func (h *AdminHandler) DeleteDrama(c *gin.Context) {
 		return
 	}
 
-	err = h.adminService.DeleteDrama(uint(id))
+	err = h.adminService.WithContext(c.Request.Context()).DeleteDrama(uint(id))
+	if errors.Is(err, service.ErrContentNotFound) {
+		h.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
 	if err != nil {
 		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
@@ -122,7 +137,7 @@ func (h *AdminHandler) DeleteDrama(c *gin.Context) {
 
 // CreateEpisode 创建剧集
 // @Summary 创建剧集
-// @Description 管理员创建新的剧集
+// @Description 管理员创建新的剧集，未填写 duration 时使用上传视频时解析出的时长
 // @Tags 管理员
 // @Security BearerAuth
 // @Accept json
@@ -130,6 +145,7 @@ func (h *AdminHandler) DeleteDrama(c *gin.Context) {
 // @Param request body models.CreateEpisodeRequest true "剧集信息"
 // @Success 200 {object} models.APIResponse{data=models.Episode}
 // @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
 // @Router /api/admin/episodes [post]
 func (h *AdminHandler) CreateEpisode(c *gin.Context) {
 	var req models.CreateEpisodeRequest
@@ -139,7 +155,11 @@ func (h *AdminHandler) CreateEpisode(c *gin.Context) {
 		return
 	}
 
-	episode, err := h.adminService.CreateEpisode(req)
+	episode, err := h.adminService.WithContext(c.Request.Context()).CreateEpisode(req)
+	if errors.Is(err, service.ErrContentNotFound) {
+		h.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
 	if err != nil {
 		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
@@ -150,7 +170,7 @@ func (h *AdminHandler) CreateEpisode(c *gin.Context) {
 
 // UpdateEpisode 更新剧集
 // @Summary 更新剧集
-// @Description 管理员更新剧集信息
+// @Description 管理员按 PATCH 语义更新剧集：未提供的字段保持不变，空字符串清空视频或缩略图（标题、剧集号与时长不能清空）；每次更新保存一个修订版本。PUT 与 PATCH 相同
 // @Tags 管理员
 // @Security BearerAuth
 // @Accept json
@@ -160,6 +180,7 @@ func (h *AdminHandler) CreateEpisode(c *gin.Context) {
 // @Success 200 {object} models.APIResponse{data=models.Episode}
 // @Failure 400 {object} models.APIResponse
 // @Failure 404 {object} models.APIResponse
+// @Router /api/admin/episodes/{id} [patch]
 // @Router /api/admin/episodes/{id} [put]
 func (h *AdminHandler) UpdateEpisode(c *gin.Context) {
 	idStr := c.Param("id")
@@ -175,7 +196,12 @@ func (h *AdminHandler) UpdateEpisode(c *gin.Context) {
 		return
 	}
 
-	episode, err := h.adminService.UpdateEpisode(uint(id), req)
+	adminID, _ := h.GetUserIDFromContext(c)
+	episode, err := h.adminService.WithContext(c.Request.Context()).UpdateEpisode(uint(id), adminID, req)
+	if errors.Is(err, service.ErrContentNotFound) {
+		h.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
 	if err != nil {
 		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
@@ -186,7 +212,7 @@ func (h *AdminHandler) UpdateEpisode(c *gin.Context) {
 
 // DeleteEpisode 删除剧集
 // @Summary 删除剧集
-// @Description 管理员删除剧集
+// @Description 管理员删除剧集，剧集移入回收站，可以在回收站中恢复
 // @Tags 管理员
 // @Security BearerAuth
 // @Produce json
@@ -203,7 +229,11 @@ func (h *AdminHandler) DeleteEpisode(c *gin.Context) {
 		return
 	}
 
-	err = h.adminService.DeleteEpisode(uint(id))
+	err = h.adminService.WithContext(c.Request.Context()).DeleteEpisode(uint(id))
+	if errors.Is(err, service.ErrContentNotFound) {
+		h.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
 	if err != nil {
 		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
@@ -212,6 +242,127 @@ func (h *AdminHandler) DeleteEpisode(c *gin.Context) {
 	h.SuccessResponseWithMessage(c, "剧集删除成功", nil)
 }
 
+// RotateEpisodeKey 轮换剧集内容密钥
+// @Summary 轮换剧集内容密钥
+// @Description 重新转码受保护的剧集并生成新的内容密钥，转码完成前继续使用当前密钥播放，完成后旧密钥失效
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "剧集ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /api/admin/episodes/{id}/rotate-key [post]
+func (h *AdminHandler) RotateEpisodeKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的剧集ID")
+		return
+	}
+
+	err = h.adminService.WithContext(c.Request.Context()).RotateEpisodeKey(uint(id))
+	if errors.Is(err, service.ErrContentNotFound) {
+		h.ErrorResponse(c, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.SuccessResponseWithMessage(c, "已开始轮换剧集密钥", nil)
+}
+
+// PreviewEpisode 生成剧集预览地址
+// @Summary 生成剧集预览地址
+// @Description 管理员获取剧集的播放源，未发布的剧集同样可以预览；开启播放地址签名时预览地址按 playback.previewTTL 长期有效且不绑定 IP
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "剧集ID"
+// @Success 200 {object} models.APIResponse{data=[]models.PlaybackSource}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /api/admin/episodes/{id}/preview [get]
+func (h *AdminHandler) PreviewEpisode(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的剧集ID")
+		return
+	}
+
+	adminID, _ := h.GetUserIDFromContext(c)
+	sources, err := h.playbackService.WithContext(c.Request.Context()).Preview(uint(id), adminID)
+	if err != nil {
+		if errors.Is(err, service.ErrEpisodeNotFound) {
+			h.ErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		h.ErrorResponse(c, http.StatusInternalServerError, "生成预览地址失败")
+		return
+	}
+
+	h.SuccessResponse(c, sources)
+}
+
+// ScheduleEpisodes 批量排期剧集
+// @Summary 批量排期剧集
+// @Description 按每天固定时间批量排期短剧中审核通过的剧集，例如第 11-80 集从指定日期起每天 20:00 发布 3 集；时间按 publish.timezone 解析，已排期的剧集重新排期。需要 admin 及以上角色
+// @Tags 管理员
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "短剧ID"
+// @Param request body models.ScheduleEpisodesRequest true "排期规则"
+// @Success 200 {object} models.APIResponse{data=[]models.Episode}
+// @Failure 400 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Router /api/admin/dramas/{id}/schedule [post]
+func (h *AdminHandler) ScheduleEpisodes(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的短剧ID")
+		return
+	}
+
+	var req models.ScheduleEpisodesRequest
+	if err := h.ValidateRequest(c, &req); err != nil {
+		h.ValidationErrorResponse(c, err)
+		return
+	}
+
+	adminID, _ := h.GetUserIDFromContext(c)
+	episodes, err := h.publishService.WithContext(c.Request.Context()).ScheduleEpisodes(uint(id), adminID, req)
+	if err != nil {
+		h.ErrorResponse(c, reviewErrorStatus(err), err.Error())
+		return
+	}
+
+	h.SuccessResponseWithMessage(c, "剧集排期成功", episodes)
+}
+
+// GetReleaseCalendar 获取发布日历
+// @Summary 获取发布日历
+// @Description 按日期分组返回已排期与已发布的短剧和剧集，日期按 publish.timezone 计算，一次最多查询 92 天
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param from query string false "开始日期，默认今天" example(2024-06-01)
+// @Param to query string false "结束日期（包含），默认开始日期后 6 天" example(2024-06-07)
+// @Success 200 {object} models.APIResponse{data=models.ReleaseCalendar}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/admin/calendar [get]
+func (h *AdminHandler) GetReleaseCalendar(c *gin.Context) {
+	calendar, err := h.publishService.WithContext(c.Request.Context()).Calendar(c.Query("from"), c.Query("to"))
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.SuccessResponse(c, calendar)
+}
+
 // GetDramaList 获取短剧列表（管理员视图）
 // @Summary 获取短剧列表（管理员）
 // @Description 管理员获取所有短剧列表，包括未激活的
@@ -225,7 +376,7 @@ func (h *AdminHandler) DeleteEpisode(c *gin.Context) {
 func (h *AdminHandler) GetDramaList(c *gin.Context) {
 	page, pageSize := h.GetPaginationParams(c)
 
-	dramas, err := h.adminService.GetDramaList(page, pageSize)
+	dramas, err := h.adminService.WithContext(c.Request.Context()).GetDramaList(page, pageSize)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusInternalServerError, "获取短剧列表失败")
 		return
@@ -255,7 +406,7 @@ func (h *AdminHandler) GetEpisodeList(c *gin.Context) {
 
 	page, pageSize := h.GetPaginationParams(c)
 
-	episodes, err := h.adminService.GetEpisodeList(uint(dramaID), page, pageSize)
+	episodes, err := h.adminService.WithContext(c.Request.Context()).GetEpisodeList(uint(dramaID), page, pageSize)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
@@ -277,7 +428,7 @@ func (h *AdminHandler) GetEpisodeList(c *gin.Context) {
 func (h *AdminHandler) GetAllEpisodeList(c *gin.Context) {
 	page, pageSize := h.GetPaginationParams(c)
 
-	episodes, err := h.adminService.GetAllEpisodeList(page, pageSize)
+	episodes, err := h.adminService.WithContext(c.Request.Context()).GetAllEpisodeList(page, pageSize)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusInternalServerError, "获取剧集列表失败")
 		return
@@ -299,7 +450,7 @@ func (h *AdminHandler) GetAllEpisodeList(c *gin.Context) {
 func (h *AdminHandler) GetUserList(c *gin.Context) {
 	page, pageSize := h.GetPaginationParams(c)
 
-	users, err := h.userService.GetUserList(page, pageSize)
+	users, err := h.userService.WithContext(c.Request.Context()).GetUserList(page, pageSize)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusInternalServerError, "获取用户列表失败")
 		return
@@ -326,7 +477,7 @@ func (h *AdminHandler) ActivateUser(c *gin.Context) {
 		return
 	}
 
-	err = h.userService.ActivateUser(uint(id))
+	err = h.userService.WithContext(c.Request.Context()).ActivateUser(uint(id))
 	if err != nil {
 		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
@@ -353,7 +504,7 @@ func (h *AdminHandler) DeactivateUser(c *gin.Context) {
 		return
 	}
 
-	err = h.userService.DeactivateUser(uint(id))
+	err = h.userService.WithContext(c.Request.Context()).DeactivateUser(uint(id))
 	if err != nil {
 		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
@@ -43,7 +43,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.authService.RegisterUser(req)
+	user, err := h.authService.WithContext(c.Request.Context()).RegisterUser(req)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
@@ -71,7 +71,7 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.LoginUser(req)
+	response, err := h.authService.WithContext(c.Request.Context()).LoginUser(req)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusUnauthorized, err.Error())
 		return
@@ -102,7 +102,7 @@ func (h *AuthHandler) AdminLogin(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.LoginAdmin(req)
+	response, err := h.authService.WithContext(c.Request.Context()).LoginAdmin(req)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusUnauthorized, err.Error())
 		return
@@ -141,7 +141,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	}
 
 	// 刷新 token
-	newToken, err := h.authService.RefreshToken(tokenString)
+	newToken, err := h.authService.WithContext(c.Request.Context()).RefreshToken(tokenString)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusUnauthorized, "令牌刷新失败")
 		return
@@ -2,12 +2,14 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/service"
 	"gin-mysql-api/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -20,6 +22,10 @@ type MockAuthService struct {
 	mock.Mock
 }
 
+func (m *MockAuthService) WithContext(ctx context.Context) service.AuthService {
+	return m
+}
+
 func (m *MockAuthService) RegisterUser(req models.RegisterRequest) (*models.User, error) {
 	args := m.Called(req)
 	if args.Get(0) == nil {
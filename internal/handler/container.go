@@ -12,16 +12,26 @@ type Container struct {
 	DramaHandler  *DramaHandler
 	AdminHandler  *AdminHandler
 	FileHandler   *FileHandler
+	UploadHandler *UploadHandler
+	MediaHandler  *MediaHandler
+	ReviewHandler *ReviewHandler
+	TrashHandler  *TrashHandler
+	RevisionHandler *RevisionHandler
 }
 
 // NewContainer 创建处理器容器
 func NewContainer(services *service.Container) *Container {
 	return &Container{
-		HealthHandler: NewHealthHandler(),
+		HealthHandler: NewHealthHandler(nil),
 		AuthHandler:   NewAuthHandler(services.AuthService),
 		UserHandler:   NewUserHandler(services.UserService),
-		DramaHandler:  NewDramaHandler(services.DramaService),
-		AdminHandler:  NewAdminHandler(services.AdminService, services.UserService),
-		FileHandler:   NewFileHandler(services.FileService),
+		DramaHandler:  NewDramaHandler(services.DramaService, services.PlaybackService, services.PreviewService),
+		AdminHandler:  NewAdminHandler(services.AdminService, services.UserService, services.PlaybackService, services.PublishService),
+		FileHandler:   NewFileHandler(services.FileService, services.PlaybackService),
+		UploadHandler: NewUploadHandler(services.UploadService),
+		MediaHandler:  NewMediaHandler(services.ImageService, services.FileService, services.PlaybackService),
+		ReviewHandler: NewReviewHandler(services.ReviewService, services.PreviewService),
+		TrashHandler:  NewTrashHandler(services.TrashService),
+		RevisionHandler: NewRevisionHandler(services.RevisionService),
 	}
 }
\ No newline at end of file
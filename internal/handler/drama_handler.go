@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -12,14 +13,18 @@ import (
 // DramaHandler 短剧处理器
 type DramaHandler struct {
 	*BaseHandler
-	dramaService service.DramaService
+	dramaService    service.DramaService
+	playbackService service.PlaybackService
+	previewService  service.PreviewService
 }
 
 // NewDramaHandler 创建短剧处理器
-func NewDramaHandler(dramaService service.DramaService) *DramaHandler {
+func NewDramaHandler(dramaService service.DramaService, playbackService service.PlaybackService, previewService service.PreviewService) *DramaHandler {
 	return &DramaHandler{
-		BaseHandler:  NewBaseHandler(),
-		dramaService: dramaService,
+		BaseHandler:     NewBaseHandler(),
+		dramaService:    dramaService,
+		playbackService: playbackService,
+		previewService:  previewService,
 	}
 }
 
@@ -37,7 +42,7 @@ func (h *DramaHandler) GetDramas(c *gin.Context) {
 	page, pageSize := h.GetPaginationParams(c)
 	category := c.Query("category")
 
-	dramas, err := h.dramaService.GetDramas(page, pageSize, category)
+	dramas, err := h.dramaService.WithContext(c.Request.Context()).GetDramas(page, pageSize, category)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusInternalServerError, "获取短剧列表失败")
 		return
@@ -48,11 +53,13 @@ func (h *DramaHandler) GetDramas(c *gin.Context) {
 
 // GetDramaByID 获取短剧详情
 // @Summary 获取短剧详情
-// @Description 根据ID获取短剧详细信息
+// @Description 根据ID获取短剧详细信息，只返回已发布的短剧；管理员或携带该短剧的预览令牌时可以查看未发布的短剧
 // @Tags 短剧
 // @Produce json
 // @Param id path int true "短剧ID"
+// @Param preview_token query string false "预览令牌"
 // @Success 200 {object} models.APIResponse{data=models.Drama}
+// @Failure 403 {object} models.APIResponse
 // @Failure 404 {object} models.APIResponse
 // @Router /api/dramas/{id} [get]
 func (h *DramaHandler) GetDramaByID(c *gin.Context) {
@@ -63,25 +70,34 @@ func (h *DramaHandler) GetDramaByID(c *gin.Context) {
 		return
 	}
 
-	drama, err := h.dramaService.GetDramaByID(uint(id))
+	visibility, ok := h.visibility(c)
+	if !ok {
+		return
+	}
+	dramaService := h.dramaService.WithContext(c.Request.Context()).WithVisibility(visibility)
+	drama, err := dramaService.GetDramaByID(uint(id))
 	if err != nil {
 		h.ErrorResponse(c, http.StatusNotFound, "短剧不存在")
 		return
 	}
 
-	// 增加观看次数
-	go h.dramaService.IncrementDramaViewCount(uint(id))
+	// 增加观看次数，预览不计入
+	if visibility.Public() {
+		go dramaService.IncrementDramaViewCount(uint(id))
+	}
 
 	h.SuccessResponse(c, drama)
 }
 
 // GetDramaWithEpisodes 获取短剧及其剧集
 // @Summary 获取短剧及其剧集
-// @Description 获取短剧详情以及所有剧集信息
+// @Description 获取短剧详情以及所有已发布的剧集；管理员或携带该短剧的预览令牌时返回所有状态的剧集
 // @Tags 短剧
 // @Produce json
 // @Param id path int true "短剧ID"
+// @Param preview_token query string false "预览令牌"
 // @Success 200 {object} models.APIResponse{data=models.Drama}
+// @Failure 403 {object} models.APIResponse
 // @Failure 404 {object} models.APIResponse
 // @Router /api/dramas/{id}/episodes [get]
 func (h *DramaHandler) GetDramaWithEpisodes(c *gin.Context) {
@@ -92,7 +108,11 @@ func (h *DramaHandler) GetDramaWithEpisodes(c *gin.Context) {
 		return
 	}
 
-	drama, err := h.dramaService.GetDramaWithEpisodes(uint(id))
+	visibility, ok := h.visibility(c)
+	if !ok {
+		return
+	}
+	drama, err := h.dramaService.WithContext(c.Request.Context()).WithVisibility(visibility).GetDramaWithEpisodes(uint(id))
 	if err != nil {
 		h.ErrorResponse(c, http.StatusNotFound, "短剧不存在")
 		return
@@ -103,13 +123,15 @@ func (h *DramaHandler) GetDramaWithEpisodes(c *gin.Context) {
 
 // GetEpisodesByDramaID 获取短剧的剧集列表
 // @Summary 获取短剧的剧集列表
-// @Description 分页获取指定短剧的剧集列表
+// @Description 分页获取指定短剧中已发布的剧集；管理员或携带该短剧的预览令牌时返回所有状态的剧集
 // @Tags 短剧
 // @Produce json
 // @Param id path int true "短剧ID"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param preview_token query string false "预览令牌"
 // @Success 200 {object} models.APIResponse{data=models.PaginatedEpisodes}
+// @Failure 403 {object} models.APIResponse
 // @Failure 404 {object} models.APIResponse
 // @Router /api/dramas/{id}/episodes/list [get]
 func (h *DramaHandler) GetEpisodesByDramaID(c *gin.Context) {
@@ -121,8 +143,12 @@ func (h *DramaHandler) GetEpisodesByDramaID(c *gin.Context) {
 	}
 
 	page, pageSize := h.GetPaginationParams(c)
+	visibility, ok := h.visibility(c)
+	if !ok {
+		return
+	}
 
-	episodes, err := h.dramaService.GetEpisodesByDramaID(uint(dramaID), page, pageSize)
+	episodes, err := h.dramaService.WithContext(c.Request.Context()).WithVisibility(visibility).GetEpisodesByDramaID(uint(dramaID), page, pageSize)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusNotFound, err.Error())
 		return
@@ -133,11 +159,17 @@ func (h *DramaHandler) GetEpisodesByDramaID(c *gin.Context) {
 
 // GetEpisodeByID 获取剧集详情
 // @Summary 获取剧集详情
-// @Description 根据ID获取剧集详细信息和播放地址
+// @Description 根据ID获取剧集详细信息和播放源，视频已转码时首先返回 HLS 自适应码率播放源，其次是原始 MP4
 // @Tags 剧集
 // @Produce json
+// @Description 开启播放地址签名时只为已发布的剧集签发限时有效的播放地址，配置 playback.requireLogin 时需要登录
+// @Description 管理员或携带该剧集（或所属短剧）的预览令牌时可以查看未发布的剧集，播放源为管理员预览地址
+// @Security BearerAuth
 // @Param id path int true "剧集ID"
-// @Success 200 {object} models.APIResponse{data=models.Episode}
+// @Param preview_token query string false "预览令牌"
+// @Success 200 {object} models.APIResponse{data=models.EpisodeDetail}
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
 // @Failure 404 {object} models.APIResponse
 // @Router /api/episodes/{id} [get]
 func (h *DramaHandler) GetEpisodeByID(c *gin.Context) {
@@ -148,18 +180,167 @@ func (h *DramaHandler) GetEpisodeByID(c *gin.Context) {
 		return
 	}
 
-	episode, err := h.dramaService.GetEpisodeByID(uint(id))
+	visibility, ok := h.visibility(c)
+	if !ok {
+		return
+	}
+	dramaService := h.dramaService.WithContext(c.Request.Context()).WithVisibility(visibility)
+	episode, err := dramaService.GetEpisodeByID(uint(id), h.playbackViewer(c))
 	if err != nil {
+		if errors.Is(err, service.ErrPlaybackLoginRequired) {
+			h.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrPlaybackForbidden) {
+			h.ErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
 		h.ErrorResponse(c, http.StatusNotFound, "剧集不存在")
 		return
 	}
 
-	// 增加观看次数
-	go h.dramaService.IncrementEpisodeViewCount(uint(id))
+	// 增加观看次数，预览不计入
+	if visibility.Public() {
+		go dramaService.IncrementEpisodeViewCount(uint(id))
+	}
 
 	h.SuccessResponse(c, episode)
 }
 
+// GetMasterPlaylist 获取剧集的 HLS 主播放列表
+// @Summary 获取 HLS 主播放列表
+// @Description 根据剧集的转码结果生成 HLS 主播放列表，包含各码率档位的媒体播放列表地址
+// @Tags 剧集
+// @Produce application/vnd.apple.mpegurl
+// @Description 开启播放地址签名时需要携带剧集详情返回的 token，媒体播放列表地址沿用同一令牌；未开启签名时与剧集详情的可见范围相同，未发布的剧集只对管理员与预览令牌可见
+// @Param id path int true "剧集ID"
+// @Param token query string false "播放令牌"
+// @Param preview_token query string false "预览令牌"
+// @Success 200 {string} string "HLS 主播放列表"
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /api/episodes/{id}/master.m3u8 [get]
+func (h *DramaHandler) GetMasterPlaylist(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的剧集ID")
+		return
+	}
+
+	visibility, ok := h.visibility(c)
+	if !ok {
+		return
+	}
+
+	playlist, err := h.playbackService.WithContext(c.Request.Context()).MasterPlaylist(uint(id), c.Query("token"), c.ClientIP(), visibility)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPlaybackToken) {
+			h.ErrorResponse(c, http.StatusForbidden, service.ErrInvalidPlaybackToken.Error())
+			return
+		}
+		if errors.Is(err, service.ErrContentNotFound) {
+			h.ErrorResponse(c, http.StatusNotFound, "剧集不存在")
+			return
+		}
+		if errors.Is(err, service.ErrPlaylistNotFound) {
+			h.ErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		h.ErrorResponse(c, http.StatusInternalServerError, "生成播放列表失败")
+		return
+	}
+
+	// 播放列表中的地址可能是限时有效的预签名 URL，不允许缓存
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "application/vnd.apple.mpegurl", []byte(playlist))
+}
+
+// GetContentKey 获取受保护剧集的 HLS 内容密钥
+// @Summary 获取 HLS 内容密钥
+// @Description 受保护剧集的媒体播放列表通过 #EXT-X-KEY 引用该地址，检查观看权限后返回 16 字节的 AES-128 密钥；管理员与携带预览令牌的审核人员可以获取未发布剧集的密钥
+// @Tags 剧集
+// @Security BearerAuth
+// @Produce application/octet-stream
+// @Param id path int true "剧集ID"
+// @Param key_id path int true "密钥ID"
+// @Param preview_token query string false "预览令牌，也可以通过 X-Preview-Token 请求头传递"
+// @Success 200 {string} string "AES-128 密钥"
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /api/episodes/{id}/keys/{key_id} [get]
+func (h *DramaHandler) GetContentKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的剧集ID")
+		return
+	}
+	keyID, err := strconv.ParseUint(c.Param("key_id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的密钥ID")
+		return
+	}
+
+	visibility, ok := h.visibility(c)
+	if !ok {
+		return
+	}
+
+	key, err := h.playbackService.WithContext(c.Request.Context()).ContentKey(uint(id), uint(keyID), h.playbackViewer(c), visibility)
+	if err != nil {
+		if errors.Is(err, service.ErrPlaybackLoginRequired) {
+			h.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrPlaybackForbidden) {
+			h.ErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrContentKeyNotFound) {
+			h.ErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		h.ErrorResponse(c, http.StatusInternalServerError, "获取密钥失败")
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "application/octet-stream", key)
+}
+
+// visibility 当前请求可以查看的内容范围：管理员可以查看所有内容，携带预览令牌时可以查看令牌指定的内容
+// 预览令牌无效时返回 403 并返回 false
+func (h *DramaHandler) visibility(c *gin.Context) (service.Visibility, bool) {
+	if role, _ := h.GetUserRoleFromContext(c); role == "admin" {
+		adminID, _ := h.GetUserIDFromContext(c)
+		return service.AdminVisibility(adminID), true
+	}
+
+	token := c.Query("preview_token")
+	if token == "" {
+		token = c.GetHeader("X-Preview-Token")
+	}
+	if token == "" {
+		return service.Visibility{}, true
+	}
+	visibility, err := h.previewService.Visibility(token)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusForbidden, service.ErrInvalidPreviewToken.Error())
+		return service.Visibility{}, false
+	}
+	return visibility, true
+}
+
+// playbackViewer 当前请求的观看者，未登录时只有客户端 IP
+func (h *DramaHandler) playbackViewer(c *gin.Context) service.PlaybackViewer {
+	viewer := service.PlaybackViewer{IP: c.ClientIP()}
+	if userID, ok := h.GetUserIDFromContext(c); ok {
+		viewer.UserID = userID
+		viewer.Role, _ = h.GetUserRoleFromContext(c)
+	}
+	return viewer
+}
+
 // SearchDramas 搜索短剧
 // @Summary 搜索短剧
 // @Description 根据关键词搜索短剧
@@ -179,7 +360,7 @@ func (h *DramaHandler) SearchDramas(c *gin.Context) {
 
 	page, pageSize := h.GetPaginationParams(c)
 
-	dramas, err := h.dramaService.SearchDramas(keyword, page, pageSize)
+	dramas, err := h.dramaService.WithContext(c.Request.Context()).SearchDramas(keyword, page, pageSize)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusInternalServerError, "搜索失败")
 		return
@@ -200,7 +381,7 @@ func (h *DramaHandler) SearchDramas(c *gin.Context) {
 func (h *DramaHandler) GetPopularDramas(c *gin.Context) {
 	page, pageSize := h.GetPaginationParams(c)
 
-	dramas, err := h.dramaService.GetPopularDramas(page, pageSize)
+	dramas, err := h.dramaService.WithContext(c.Request.Context()).GetPopularDramas(page, pageSize)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusInternalServerError, "获取热门短剧失败")
 		return
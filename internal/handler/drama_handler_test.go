@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/middleware"
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/internal/service"
+	"gin-mysql-api/internal/testutil"
+	"gin-mysql-api/pkg/storage"
+	"gin-mysql-api/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDramaHandler_GetMasterPlaylist(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := testutil.SetupTestDB()
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	repos := repository.NewRepository(db)
+	jwtManager := utils.NewJWTManager("test-secret", time.Hour)
+	files := service.NewFileService(storage.NewLocal(t.TempDir()), repos.Media, nil, service.FileServiceConfig{BaseURL: "https://api.example.com"}, nil)
+	// 未开启播放地址签名（配置文件的默认值）
+	playback := service.NewPlaybackService(repos.Transcode, repos.Episode, repos.Media, files, nil, nil, nil, service.PlaybackServiceConfig{BaseURL: "https://api.example.com"}, nil)
+	preview := service.NewPreviewService(repos.Drama, repos.Episode, jwtManager, "https://api.example.com", time.Hour, nil)
+	dramaHandler := NewDramaHandler(nil, playback, preview)
+
+	router := gin.New()
+	router.GET("/api/episodes/:id/master.m3u8", middleware.OptionalAuthMiddleware(jwtManager), dramaHandler.GetMasterPlaylist)
+
+	drama := testutil.NewDramaFactory().CreateDrama(func(d *models.Drama) { d.Status = models.StatusPublished })
+	require.NoError(t, repos.Drama.Create(drama))
+	episode := testutil.NewEpisodeFactory().CreateEpisode(drama.ID, func(e *models.Episode) { e.Status = models.StatusDraft })
+	require.NoError(t, repos.Episode.Create(episode))
+	_, err := repos.Transcode.ReplaceRenditions(episode.ID, []models.EpisodeRendition{{
+		EpisodeID: episode.ID, Name: "720p", PlaylistKey: fmt.Sprintf("hls/%d/1/720p/index.m3u8", episode.ID),
+		Width: 1280, Height: 720, Bitrate: 2800000, Codecs: "avc1.64001f,mp4a.40.2",
+	}})
+	require.NoError(t, err)
+
+	get := func(query string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/episodes/%d/master.m3u8%s", episode.ID, query), nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("未发布的剧集对公开访问不可见", func(t *testing.T) {
+		w := get("", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.NotContains(t, w.Body.String(), "#EXTM3U")
+	})
+
+	t.Run("管理员与预览令牌可以访问未发布的剧集", func(t *testing.T) {
+		adminToken, err := jwtManager.GenerateToken(1, "admin", "admin")
+		require.NoError(t, err)
+		w := get("", map[string]string{"Authorization": "Bearer " + adminToken})
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), "720p/index.m3u8")
+
+		token, err := preview.IssueToken(1, models.ReviewEntityEpisode, episode.ID)
+		require.NoError(t, err)
+		w = get("?preview_token="+token.Token, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = get("?preview_token=invalid", nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("已发布的剧集公开可见，删除后不可见", func(t *testing.T) {
+		require.NoError(t, db.Model(episode).Update("status", models.StatusPublished).Error)
+		w := get("", nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		require.NoError(t, repos.Drama.Delete(drama.ID))
+		w = get("", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
 	"gin-mysql-api/internal/service"
 
@@ -11,14 +13,16 @@ import (
 // FileHandler 文件处理器
 type FileHandler struct {
 	*BaseHandler
-	fileService service.FileService
+	fileService     service.FileService
+	playbackService service.PlaybackService
 }
 
 // NewFileHandler 创建文件处理器
-func NewFileHandler(fileService service.FileService) *FileHandler {
+func NewFileHandler(fileService service.FileService, playbackService service.PlaybackService) *FileHandler {
 	return &FileHandler{
-		BaseHandler: NewBaseHandler(),
-		fileService: fileService,
+		BaseHandler:     NewBaseHandler(),
+		fileService:     fileService,
+		playbackService: playbackService,
 	}
 }
 
@@ -34,8 +38,16 @@ func NewFileHandler(fileService service.FileService) *FileHandler {
 // @Success 200 {object} models.APIResponse{data=models.FileUploadResponse}
 // @Failure 400 {object} models.APIResponse
 // @Failure 401 {object} models.APIResponse
+// @Failure 415 {object} models.APIResponse
+// @Failure 422 {object} models.APIResponse
 // @Router /api/upload [post]
 func (h *FileHandler) UploadFile(c *gin.Context) {
+	owner, ok := getUploadOwner(h.BaseHandler, c)
+	if !ok {
+		h.ErrorResponse(c, http.StatusUnauthorized, "用户未认证")
+		return
+	}
+
 	// 获取上传的文件
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
@@ -51,8 +63,16 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 	}
 
 	// 上传文件
-	response, err := h.fileService.UploadFile(file, header, uploadType)
+	response, err := h.fileService.WithContext(c.Request.Context()).UploadFile(owner, file, header, uploadType)
 	if err != nil {
+		if errors.Is(err, service.ErrUnsupportedContent) {
+			h.ErrorResponse(c, http.StatusUnsupportedMediaType, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrInvalidVideo) {
+			h.ErrorResponse(c, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
 		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
@@ -62,7 +82,7 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 
 // DeleteFile 删除文件
 // @Summary 删除文件
-// @Description 删除已上传的文件
+// @Description 删除已上传的文件，普通用户只能删除自己上传的文件，仍被短剧、剧集或用户引用的文件不能删除
 // @Tags 文件
 // @Security BearerAuth
 // @Produce json
@@ -70,19 +90,115 @@ func (h *FileHandler) UploadFile(c *gin.Context) {
 // @Success 200 {object} models.APIResponse
 // @Failure 400 {object} models.APIResponse
 // @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
 // @Router /api/upload [delete]
 func (h *FileHandler) DeleteFile(c *gin.Context) {
+	owner, ok := getUploadOwner(h.BaseHandler, c)
+	if !ok {
+		h.ErrorResponse(c, http.StatusUnauthorized, "用户未认证")
+		return
+	}
+
 	filePath := c.Query("path")
 	if filePath == "" {
 		h.ErrorResponse(c, http.StatusBadRequest, "文件路径不能为空")
 		return
 	}
 
-	err := h.fileService.DeleteFile(filePath)
+	err := h.fileService.WithContext(c.Request.Context()).DeleteFile(owner, filePath)
 	if err != nil {
+		if errors.Is(err, service.ErrFileForbidden) {
+			h.ErrorResponse(c, http.StatusForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrFileInUse) {
+			h.ErrorResponse(c, http.StatusConflict, err.Error())
+			return
+		}
 		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	h.SuccessResponseWithMessage(c, "文件删除成功", nil)
-}
\ No newline at end of file
+}
+
+// uploadCacheControl 上传文件的缓存策略，文件名随机生成且不会被覆盖，过期后凭 ETag 重新验证
+const uploadCacheControl = "public, max-age=86400"
+
+// ServeFile 读取上传的文件
+// @Summary 读取上传的文件
+// @Description 从存储读取文件，支持 Range 断点续传与 If-None-Match、If-Modified-Since、If-Range 条件请求
+// @Description 开启播放地址签名时视频与 HLS 文件只能通过 /media/{token}/{path} 访问，受保护剧集的原始视频始终不能直接访问
+// @Tags 文件
+// @Param path path string true "文件路径" example(videos/1_abc.mp4)
+// @Param Range header string false "读取范围" example(bytes=0-1023)
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Success 304
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 416
+// @Failure 500 {object} models.APIResponse
+// @Router /uploads/{path} [get]
+func (h *FileHandler) ServeFile(c *gin.Context) {
+	filePath := strings.TrimPrefix(c.Param("path"), "/")
+	if h.playbackService != nil {
+		playbackService := h.playbackService.WithContext(c.Request.Context())
+		if playbackService.RequiresToken(filePath) {
+			h.ErrorResponse(c, http.StatusForbidden, "请通过播放地址访问该文件")
+			return
+		}
+		protected, err := playbackService.ProtectedSource(filePath)
+		if err != nil {
+			h.ErrorResponse(c, http.StatusInternalServerError, "读取文件失败")
+			return
+		}
+		if protected {
+			h.ErrorResponse(c, http.StatusForbidden, "受保护的剧集只能通过加密的 HLS 播放")
+			return
+		}
+	}
+	serveFile(h.BaseHandler, c, h.fileService, filePath, uploadCacheControl)
+}
+
+// serveFile 从存储读取文件并写入响应，ServeContent 处理 Range 与条件请求，只读取请求的范围
+func serveFile(h *BaseHandler, c *gin.Context, fileService service.FileService, filePath, cacheControl string) {
+	file, info, err := fileService.WithContext(c.Request.Context()).OpenFile(filePath)
+	if err != nil {
+		if errors.Is(err, service.ErrFileNotFound) {
+			h.ErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		h.ErrorResponse(c, http.StatusInternalServerError, "读取文件失败")
+		return
+	}
+	defer file.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", info.ContentType)
+	header.Set("Cache-Control", cacheControl)
+	header.Set("X-Content-Type-Options", "nosniff")
+	if info.ETag != "" {
+		header.Set("ETag", quoteETag(info.ETag))
+	}
+	http.ServeContent(c.Writer, c.Request, "", info.LastModified, file)
+}
+
+// quoteETag 为存储返回的 ETag 加上引号，已是带引号或弱 ETag 时原样返回
+func quoteETag(etag string) string {
+	if strings.HasPrefix(etag, `"`) || strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// getUploadOwner 从上下文获取当前用户作为文件的上传者
+func getUploadOwner(h *BaseHandler, c *gin.Context) (service.UploadOwner, bool) {
+	userID, ok := h.GetUserIDFromContext(c)
+	if !ok {
+		return service.UploadOwner{}, false
+	}
+	role, _ := h.GetUserRoleFromContext(c)
+	return service.UploadOwner{ID: userID, Role: role}, true
+}
@@ -1,20 +1,28 @@
 package handler
 
 import (
+	"net/http"
 	"time"
 
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/pkg/health"
+	"gin-mysql-api/pkg/version"
+
 	"github.com/gin-gonic/gin"
 )
 
 // HealthHandler 健康检查处理器
 type HealthHandler struct {
 	*BaseHandler
+	registry *health.Registry
 }
 
 // NewHealthHandler 创建健康检查处理器
-func NewHealthHandler() *HealthHandler {
+// registry 为 nil 时就绪检查不探测任何依赖
+func NewHealthHandler(registry *health.Registry) *HealthHandler {
 	return &HealthHandler{
 		BaseHandler: NewBaseHandler(),
+		registry:    registry,
 	}
 }
 
@@ -26,11 +34,15 @@ func NewHealthHandler() *HealthHandler {
 // @Success 200 {object} models.APIResponse
 // @Router /health [get]
 func (h *HealthHandler) HealthCheck(c *gin.Context) {
+	info := version.Get()
 	data := gin.H{
 		"status":    "ok",
 		"timestamp": time.Now().Unix(),
 		"service":   "gin-mysql-api",
-		"version":   "1.0.0",
+		"version":   info.Version,
+		"commit":    info.Commit,
+		"buildTime": info.BuildTime,
+		"goVersion": info.GoVersion,
 	}
 
 	h.SuccessResponseWithMessage(c, "服务运行正常", data)
@@ -38,20 +50,37 @@ func (h *HealthHandler) HealthCheck(c *gin.Context) {
 
 // ReadinessCheck 就绪检查
 // @Summary 就绪检查
-// @Description 检查服务是否准备好接收请求
+// @Description 探测数据库、Redis 等依赖，关键依赖不可用时返回 503
 // @Tags 系统
 // @Produce json
 // @Success 200 {object} models.APIResponse
+// @Failure 503 {object} models.APIResponse
 // @Router /ready [get]
 func (h *HealthHandler) ReadinessCheck(c *gin.Context) {
-	// 这里可以添加数据库连接检查、Redis 连接检查等
+	report := health.Report{
+		Status:    health.StatusReady,
+		Checks:    map[string]health.Result{},
+		CheckedAt: time.Now(),
+	}
+	if h.registry != nil {
+		report = h.registry.Check(c.Request.Context())
+	}
+
 	data := gin.H{
-		"status":    "ready",
+		"status":    report.Status,
 		"timestamp": time.Now().Unix(),
-		"checks": gin.H{
-			"database": "ok",
-			"redis":    "ok",
-		},
+		"checkedAt": report.CheckedAt.Unix(),
+		"checks":    report.Checks,
+	}
+
+	if !report.Ready() {
+		c.JSON(http.StatusServiceUnavailable, models.APIResponse{
+			Success: false,
+			Message: "服务未就绪",
+			Data:    data,
+			Error:   "关键依赖不可用",
+		})
+		return
 	}
 
 	h.SuccessResponseWithMessage(c, "服务已就绪", data)
@@ -66,10 +95,12 @@ func (h *HealthHandler) ReadinessCheck(c *gin.Context) {
 // @Router /live [get]
 func (h *HealthHandler) LivenessCheck(c *gin.Context) {
 	data := gin.H{
-		"status":    "alive",
-		"timestamp": time.Now().Unix(),
-		"uptime":    time.Since(time.Now()).String(), // 这里应该记录实际的启动时间
+		"status":        "alive",
+		"timestamp":     time.Now().Unix(),
+		"startedAt":     version.StartTime().Unix(),
+		"uptime":        version.Uptime().Round(time.Second).String(),
+		"uptimeSeconds": int64(version.Uptime().Seconds()),
 	}
 
 	h.SuccessResponseWithMessage(c, "服务存活", data)
-}
\ No newline at end of file
+}
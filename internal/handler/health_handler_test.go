@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"gin-mysql-api/internal/models"
+	"gin-mysql-api/pkg/health"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -15,7 +19,10 @@ import (
 func TestHealthHandler(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	
-	healthHandler := NewHealthHandler()
+	registry := health.NewRegistry(0, time.Second)
+	registry.Register(health.Probe{Name: "database", Critical: true, Check: func(context.Context) error { return nil }})
+	registry.Register(health.Probe{Name: "redis", Check: func(context.Context) error { return nil }})
+	healthHandler := NewHealthHandler(registry)
 
 	t.Run("健康检查", func(t *testing.T) {
 		w := httptest.NewRecorder()
@@ -41,6 +48,8 @@ func TestHealthHandler(t *testing.T) {
 		assert.Equal(t, "gin-mysql-api", data["service"])
 		assert.Equal(t, "1.0.0", data["version"])
 		assert.NotNil(t, data["timestamp"])
+		assert.NotEmpty(t, data["commit"])
+		assert.NotEmpty(t, data["goVersion"])
 	})
 
 	t.Run("就绪检查", func(t *testing.T) {
@@ -67,8 +76,50 @@ func TestHealthHandler(t *testing.T) {
 		
 		checks, ok := data["checks"].(map[string]interface{})
 		assert.True(t, ok)
-		assert.Equal(t, "ok", checks["database"])
-		assert.Equal(t, "ok", checks["redis"])
+		database := checks["database"].(map[string]interface{})
+		assert.Equal(t, "ok", database["status"])
+		assert.Equal(t, true, database["critical"])
+		redis := checks["redis"].(map[string]interface{})
+		assert.Equal(t, "ok", redis["status"])
+	})
+
+	t.Run("非关键依赖失败时降级但仍就绪", func(t *testing.T) {
+		registry := health.NewRegistry(0, time.Second)
+		registry.Register(health.Probe{Name: "database", Critical: true, Check: func(context.Context) error { return nil }})
+		registry.Register(health.Probe{Name: "redis", Check: func(context.Context) error { return errors.New("connection refused") }})
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/ready", nil)
+
+		NewHealthHandler(registry).ReadinessCheck(c)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response models.APIResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, "degraded", data["status"])
+	})
+
+	t.Run("关键依赖失败时返回503", func(t *testing.T) {
+		registry := health.NewRegistry(0, time.Second)
+		registry.Register(health.Probe{Name: "database", Critical: true, Check: func(context.Context) error { return errors.New("connection refused") }})
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/ready", nil)
+
+		NewHealthHandler(registry).ReadinessCheck(c)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var response models.APIResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.False(t, response.Success)
+		data := response.Data.(map[string]interface{})
+		assert.Equal(t, "not_ready", data["status"])
+		database := data["checks"].(map[string]interface{})["database"].(map[string]interface{})
+		assert.Equal(t, "fail", database["status"])
+		assert.Contains(t, database["error"], "connection refused")
 	})
 
 	t.Run("存活检查", func(t *testing.T) {
@@ -94,5 +145,7 @@ func TestHealthHandler(t *testing.T) {
 		assert.Equal(t, "alive", data["status"])
 		assert.NotNil(t, data["timestamp"])
 		assert.NotNil(t, data["uptime"])
+		assert.NotNil(t, data["startedAt"])
+		assert.GreaterOrEqual(t, data["uptimeSeconds"], float64(0))
 	})
 }
\ No newline at end of file
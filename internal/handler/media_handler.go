@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"gin-mysql-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MediaHandler 媒体文件处理器
+type MediaHandler struct {
+	*BaseHandler
+	imageService    service.ImageService
+	fileService     service.FileService
+	playbackService service.PlaybackService
+}
+
+// NewMediaHandler 创建媒体文件处理器
+func NewMediaHandler(imageService service.ImageService, fileService service.FileService, playbackService service.PlaybackService) *MediaHandler {
+	return &MediaHandler{
+		BaseHandler:     NewBaseHandler(),
+		imageService:    imageService,
+		fileService:     fileService,
+		playbackService: playbackService,
+	}
+}
+
+// GetVariant 获取图片规格图
+// @Summary 获取图片规格图
+// @Description 重定向到图片的指定规格图，规格图尚未生成时同步生成
+// @Tags 文件
+// @Param name path string true "规格名称" example(small)
+// @Param path path string true "原图路径" example(covers/1_abc.jpg)
+// @Success 302
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Router /api/media/variants/{name}/{path} [get]
+func (h *MediaHandler) GetVariant(c *gin.Context) {
+	ctx := c.Request.Context()
+	filePath := strings.TrimPrefix(c.Param("path"), "/")
+
+	key, err := h.imageService.WithContext(ctx).VariantKey(filePath, c.Param("name"))
+	if err != nil {
+		if errors.Is(err, service.ErrVariantNotFound) {
+			h.ErrorResponse(c, http.StatusNotFound, err.Error())
+			return
+		}
+		h.ErrorResponse(c, http.StatusInternalServerError, "生成规格图失败")
+		return
+	}
+
+	c.Redirect(http.StatusFound, h.fileService.WithContext(ctx).GetFileURL(key))
+}
+
+// signedMediaCacheControl 签名地址的缓存策略，地址绑定观看者且会过期，不允许共享缓存
+const signedMediaCacheControl = "private, max-age=3600"
+
+// ServeMedia 通过签名地址读取视频与 HLS 文件
+// @Summary 通过签名地址读取视频
+// @Description 校验播放地址的签名、有效期与绑定的客户端 IP 后读取文件，支持 Range 与条件请求
+// @Description 令牌位于路径中，HLS 播放列表中相对路径的分片地址会带上同一令牌
+// @Tags 文件
+// @Param token path string true "播放令牌"
+// @Param path path string true "文件路径" example(hls/1/2/720p/index.m3u8)
+// @Success 200 {file} file
+// @Success 206 {file} file
+// @Success 304
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /media/{token}/{path} [get]
+func (h *MediaHandler) ServeMedia(c *gin.Context) {
+	filePath := strings.TrimPrefix(c.Param("path"), "/")
+	if err := h.playbackService.WithContext(c.Request.Context()).AuthorizeMedia(c.Param("token"), filePath, c.ClientIP()); err != nil {
+		h.ErrorResponse(c, http.StatusForbidden, service.ErrInvalidPlaybackToken.Error())
+		return
+	}
+	serveFile(h.BaseHandler, c, h.fileService, filePath, signedMediaCacheControl)
+}
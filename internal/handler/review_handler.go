@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReviewHandler 审核流程处理器
+type ReviewHandler struct {
+	*BaseHandler
+	reviewService  service.ReviewService
+	previewService service.PreviewService
+}
+
+// NewReviewHandler 创建审核流程处理器
+func NewReviewHandler(reviewService service.ReviewService, previewService service.PreviewService) *ReviewHandler {
+	return &ReviewHandler{
+		BaseHandler:    NewBaseHandler(),
+		reviewService:  reviewService,
+		previewService: previewService,
+	}
+}
+
+// TransitionDrama 变更短剧状态
+// @Summary 变更短剧状态
+// @Description 按审核流程变更短剧状态：编辑可以提交审核（draft→in_review）与撤回，admin 及以上角色可以审核（approved/rejected）、发布、排期与下架；审核不通过时必须填写审核意见，排期时必须填写 publish_at
+// @Tags 管理员
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "短剧ID"
+// @Param request body models.TransitionRequest true "目标状态与审核意见"
+// @Success 200 {object} models.APIResponse{data=models.Drama}
+// @Failure 400 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Router /api/admin/dramas/{id}/transitions [post]
+func (h *ReviewHandler) TransitionDrama(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的短剧ID")
+		return
+	}
+
+	var req models.TransitionRequest
+	if err := h.ValidateRequest(c, &req); err != nil {
+		h.ValidationErrorResponse(c, err)
+		return
+	}
+
+	adminID, _ := h.GetUserIDFromContext(c)
+	drama, err := h.reviewService.WithContext(c.Request.Context()).TransitionDrama(uint(id), adminID, req)
+	if err != nil {
+		h.ErrorResponse(c, reviewErrorStatus(err), err.Error())
+		return
+	}
+
+	h.SuccessResponseWithMessage(c, "短剧状态已变更", drama)
+}
+
+// GetDramaTransitions 获取短剧状态流转记录
+// @Summary 获取短剧状态流转记录
+// @Description 按时间顺序返回短剧的状态变更、操作人与审核意见，定时发布由系统执行时没有操作人
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param drama_id path int true "短剧ID"
+// @Success 200 {object} models.APIResponse{data=[]models.StatusTransition}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/admin/dramas/{drama_id}/transitions [get]
+func (h *ReviewHandler) GetDramaTransitions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("drama_id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的短剧ID")
+		return
+	}
+	h.history(c, models.ReviewEntityDrama, uint(id))
+}
+
+// TransitionEpisode 变更剧集状态
+// @Summary 变更剧集状态
+// @Description 按审核流程变更剧集状态，规则与短剧相同
+// @Tags 管理员
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "剧集ID"
+// @Param request body models.TransitionRequest true "目标状态与审核意见"
+// @Success 200 {object} models.APIResponse{data=models.Episode}
+// @Failure 400 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Router /api/admin/episodes/{id}/transitions [post]
+func (h *ReviewHandler) TransitionEpisode(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的剧集ID")
+		return
+	}
+
+	var req models.TransitionRequest
+	if err := h.ValidateRequest(c, &req); err != nil {
+		h.ValidationErrorResponse(c, err)
+		return
+	}
+
+	adminID, _ := h.GetUserIDFromContext(c)
+	episode, err := h.reviewService.WithContext(c.Request.Context()).TransitionEpisode(uint(id), adminID, req)
+	if err != nil {
+		h.ErrorResponse(c, reviewErrorStatus(err), err.Error())
+		return
+	}
+
+	h.SuccessResponseWithMessage(c, "剧集状态已变更", episode)
+}
+
+// GetEpisodeTransitions 获取剧集状态流转记录
+// @Summary 获取剧集状态流转记录
+// @Description 按时间顺序返回剧集的状态变更、操作人与审核意见，定时发布由系统执行时没有操作人
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "剧集ID"
+// @Success 200 {object} models.APIResponse{data=[]models.StatusTransition}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/admin/episodes/{id}/transitions [get]
+func (h *ReviewHandler) GetEpisodeTransitions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的剧集ID")
+		return
+	}
+	h.history(c, models.ReviewEntityEpisode, uint(id))
+}
+
+// CreateDramaPreviewToken 签发短剧预览令牌
+// @Summary 签发短剧预览令牌
+// @Description 签发限时有效的预览令牌，持有者无需登录即可在前台接口（preview_token 查询参数或 X-Preview-Token 请求头）中查看未发布的短剧及其所有剧集；有效期为 playback.previewTTL
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "短剧ID"
+// @Success 200 {object} models.APIResponse{data=models.PreviewTokenResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /api/admin/dramas/{id}/preview-token [post]
+func (h *ReviewHandler) CreateDramaPreviewToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的短剧ID")
+		return
+	}
+	h.previewToken(c, models.ReviewEntityDrama, uint(id))
+}
+
+// CreateEpisodePreviewToken 签发剧集预览令牌
+// @Summary 签发剧集预览令牌
+// @Description 签发限时有效的预览令牌，持有者无需登录即可在剧集详情接口中查看未发布的剧集；有效期为 playback.previewTTL
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "剧集ID"
+// @Success 200 {object} models.APIResponse{data=models.PreviewTokenResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /api/admin/episodes/{id}/preview-token [post]
+func (h *ReviewHandler) CreateEpisodePreviewToken(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的剧集ID")
+		return
+	}
+	h.previewToken(c, models.ReviewEntityEpisode, uint(id))
+}
+
+// previewToken 签发预览令牌
+func (h *ReviewHandler) previewToken(c *gin.Context, entityType string, id uint) {
+	adminID, _ := h.GetUserIDFromContext(c)
+	token, err := h.previewService.WithContext(c.Request.Context()).IssueToken(adminID, entityType, id)
+	if err != nil {
+		h.ErrorResponse(c, reviewErrorStatus(err), err.Error())
+		return
+	}
+	h.SuccessResponse(c, token)
+}
+
+// history 返回状态流转记录
+func (h *ReviewHandler) history(c *gin.Context, entityType string, id uint) {
+	records, err := h.reviewService.WithContext(c.Request.Context()).History(entityType, id)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusInternalServerError, "获取状态流转记录失败")
+		return
+	}
+	h.SuccessResponse(c, records)
+}
+
+// reviewErrorStatus 审核流程错误对应的状态码
+func reviewErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrReviewForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrContentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrStatusConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
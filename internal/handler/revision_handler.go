@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RevisionHandler 修订版本处理器
+type RevisionHandler struct {
+	*BaseHandler
+	revisionService service.RevisionService
+}
+
+// NewRevisionHandler 创建修订版本处理器
+func NewRevisionHandler(revisionService service.RevisionService) *RevisionHandler {
+	return &RevisionHandler{
+		BaseHandler:     NewBaseHandler(),
+		revisionService: revisionService,
+	}
+}
+
+// GetDramaRevisions 获取短剧修订版本
+// @Summary 获取短剧修订版本
+// @Description 按版本号倒序分页返回短剧每次更新后的内容快照、作者与时间；首次更新前已有的内容保存为没有作者的第一个版本
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param drama_id path int true "短剧ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} models.APIResponse{data=models.PaginatedRevisions}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/admin/dramas/{drama_id}/revisions [get]
+func (h *RevisionHandler) GetDramaRevisions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("drama_id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的短剧ID")
+		return
+	}
+	h.list(c, models.ReviewEntityDrama, uint(id))
+}
+
+// GetEpisodeRevisions 获取剧集修订版本
+// @Summary 获取剧集修订版本
+// @Description 按版本号倒序分页返回剧集每次更新后的内容快照、作者与时间
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "剧集ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} models.APIResponse{data=models.PaginatedRevisions}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/admin/episodes/{id}/revisions [get]
+func (h *RevisionHandler) GetEpisodeRevisions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的剧集ID")
+		return
+	}
+	h.list(c, models.ReviewEntityEpisode, uint(id))
+}
+
+// DiffDramaRevisions 比较短剧的两个修订版本
+// @Summary 比较短剧的两个修订版本
+// @Description 返回从 from 版本到 to 版本有变化的字段及其新旧值
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param drama_id path int true "短剧ID"
+// @Param from query int true "起始版本号"
+// @Param to query int true "目标版本号"
+// @Success 200 {object} models.APIResponse{data=models.RevisionDiff}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /api/admin/dramas/{drama_id}/revisions/diff [get]
+func (h *RevisionHandler) DiffDramaRevisions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("drama_id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的短剧ID")
+		return
+	}
+	h.diff(c, models.ReviewEntityDrama, uint(id))
+}
+
+// DiffEpisodeRevisions 比较剧集的两个修订版本
+// @Summary 比较剧集的两个修订版本
+// @Description 返回从 from 版本到 to 版本有变化的字段及其新旧值
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "剧集ID"
+// @Param from query int true "起始版本号"
+// @Param to query int true "目标版本号"
+// @Success 200 {object} models.APIResponse{data=models.RevisionDiff}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /api/admin/episodes/{id}/revisions/diff [get]
+func (h *RevisionHandler) DiffEpisodeRevisions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的剧集ID")
+		return
+	}
+	h.diff(c, models.ReviewEntityEpisode, uint(id))
+}
+
+// RollbackDrama 回滚短剧到指定版本
+// @Summary 回滚短剧到指定版本
+// @Description 将短剧的标题、简介、封面、导演、演员与分类恢复为指定版本的内容，并保存为新的修订版本；不改变状态
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "短剧ID"
+// @Param version path int true "版本号"
+// @Success 200 {object} models.APIResponse{data=models.Drama}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /api/admin/dramas/{id}/revisions/{version}/rollback [post]
+func (h *RevisionHandler) RollbackDrama(c *gin.Context) {
+	id, version, ok := h.rollbackTarget(c, "无效的短剧ID")
+	if !ok {
+		return
+	}
+
+	adminID, _ := h.GetUserIDFromContext(c)
+	drama, err := h.revisionService.WithContext(c.Request.Context()).RollbackDrama(id, version, adminID)
+	if err != nil {
+		h.ErrorResponse(c, revisionErrorStatus(err), err.Error())
+		return
+	}
+
+	h.SuccessResponseWithMessage(c, "短剧已回滚", drama)
+}
+
+// RollbackEpisode 回滚剧集到指定版本
+// @Summary 回滚剧集到指定版本
+// @Description 将剧集的标题、剧集号、时长、视频、缩略图与加密设置恢复为指定版本的内容，并保存为新的修订版本；视频或加密设置变化时重新转码，剧集号已被使用时不能回滚
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "剧集ID"
+// @Param version path int true "版本号"
+// @Success 200 {object} models.APIResponse{data=models.Episode}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /api/admin/episodes/{id}/revisions/{version}/rollback [post]
+func (h *RevisionHandler) RollbackEpisode(c *gin.Context) {
+	id, version, ok := h.rollbackTarget(c, "无效的剧集ID")
+	if !ok {
+		return
+	}
+
+	adminID, _ := h.GetUserIDFromContext(c)
+	episode, err := h.revisionService.WithContext(c.Request.Context()).RollbackEpisode(id, version, adminID)
+	if err != nil {
+		h.ErrorResponse(c, revisionErrorStatus(err), err.Error())
+		return
+	}
+
+	h.SuccessResponseWithMessage(c, "剧集已回滚", episode)
+}
+
+// list 分页返回修订版本
+func (h *RevisionHandler) list(c *gin.Context, entityType string, id uint) {
+	page, pageSize := h.GetPaginationParams(c)
+	revisions, err := h.revisionService.WithContext(c.Request.Context()).List(entityType, id, page, pageSize)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusInternalServerError, "获取修订版本失败")
+		return
+	}
+	h.SuccessResponse(c, revisions)
+}
+
+// diff 解析 from 与 to 版本号并返回两个版本的差异
+func (h *RevisionHandler) diff(c *gin.Context, entityType string, id uint) {
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from < 1 {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的起始版本号")
+		return
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil || to < 1 {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的目标版本号")
+		return
+	}
+
+	diff, err := h.revisionService.WithContext(c.Request.Context()).Diff(entityType, id, from, to)
+	if err != nil {
+		h.ErrorResponse(c, revisionErrorStatus(err), err.Error())
+		return
+	}
+	h.SuccessResponse(c, diff)
+}
+
+// rollbackTarget 解析路径中的内容 ID 与版本号，无效时返回 400
+func (h *RevisionHandler) rollbackTarget(c *gin.Context, invalidID string) (uint, int, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, invalidID)
+		return 0, 0, false
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的版本号")
+		return 0, 0, false
+	}
+	return uint(id), version, true
+}
+
+// revisionErrorStatus 修订版本错误对应的状态码，回滚时的其他错误（如剧集号已被使用）与更新接口一样返回 400
+func revisionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrRevisionNotFound), errors.Is(err, service.ErrContentNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusBadRequest
+	}
+}
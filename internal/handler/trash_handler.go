@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TrashHandler 回收站处理器
+type TrashHandler struct {
+	*BaseHandler
+	trashService service.TrashService
+}
+
+// NewTrashHandler 创建回收站处理器
+func NewTrashHandler(trashService service.TrashService) *TrashHandler {
+	return &TrashHandler{
+		BaseHandler:  NewBaseHandler(),
+		trashService: trashService,
+	}
+}
+
+// GetTrashList 获取回收站列表
+// @Summary 获取回收站列表
+// @Description 按删除时间倒序分页返回已删除的短剧或剧集及其永久删除时间（删除时间加 trash.retentionDays）；随短剧删除的剧集不单独列出，恢复短剧时一起恢复
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param type query string false "内容类型：drama（默认）或 episode"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} models.APIResponse{data=models.PaginatedTrash}
+// @Failure 400 {object} models.APIResponse
+// @Router /api/admin/trash [get]
+func (h *TrashHandler) GetTrashList(c *gin.Context) {
+	entityType := c.DefaultQuery("type", models.ReviewEntityDrama)
+	if !validTrashType(entityType) {
+		h.ErrorResponse(c, http.StatusBadRequest, "type 只能是 drama 或 episode")
+		return
+	}
+
+	page, pageSize := h.GetPaginationParams(c)
+	list, err := h.trashService.WithContext(c.Request.Context()).List(entityType, page, pageSize)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusInternalServerError, "获取回收站列表失败")
+		return
+	}
+
+	h.SuccessResponse(c, list)
+}
+
+// RestoreTrashItem 恢复回收站中的内容
+// @Summary 恢复回收站中的内容
+// @Description 恢复短剧时一起恢复与其一同删除的剧集，删除短剧之前单独删除的剧集仍留在回收站；所属短剧仍在回收站或剧集号已被使用的剧集不能恢复
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param type path string true "内容类型：drama 或 episode"
+// @Param id path int true "短剧或剧集ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Router /api/admin/trash/{type}/{id}/restore [post]
+func (h *TrashHandler) RestoreTrashItem(c *gin.Context) {
+	entityType, id, ok := h.trashItem(c)
+	if !ok {
+		return
+	}
+
+	if err := h.trashService.WithContext(c.Request.Context()).Restore(entityType, id); err != nil {
+		h.ErrorResponse(c, trashErrorStatus(err), err.Error())
+		return
+	}
+
+	h.SuccessResponseWithMessage(c, "已恢复", nil)
+}
+
+// PurgeTrashItem 永久删除回收站中的内容
+// @Summary 永久删除回收站中的内容
+// @Description 永久删除短剧（含所有剧集）或剧集，同时删除转码结果、内容密钥、状态流转记录、修订版本以及不再被引用的封面、视频与缩略图，无法恢复
+// @Tags 管理员
+// @Security BearerAuth
+// @Produce json
+// @Param type path string true "内容类型：drama 或 episode"
+// @Param id path int true "短剧或剧集ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /api/admin/trash/{type}/{id} [delete]
+func (h *TrashHandler) PurgeTrashItem(c *gin.Context) {
+	entityType, id, ok := h.trashItem(c)
+	if !ok {
+		return
+	}
+
+	if err := h.trashService.WithContext(c.Request.Context()).Purge(entityType, id); err != nil {
+		h.ErrorResponse(c, trashErrorStatus(err), err.Error())
+		return
+	}
+
+	h.SuccessResponseWithMessage(c, "已永久删除", nil)
+}
+
+// trashItem 解析路径中的内容类型与 ID，无效时返回 400
+func (h *TrashHandler) trashItem(c *gin.Context) (string, uint, bool) {
+	entityType := c.Param("type")
+	if !validTrashType(entityType) {
+		h.ErrorResponse(c, http.StatusBadRequest, "type 只能是 drama 或 episode")
+		return "", 0, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的ID")
+		return "", 0, false
+	}
+	return entityType, uint(id), true
+}
+
+// validTrashType 是否为回收站支持的内容类型
+func validTrashType(entityType string) bool {
+	return entityType == models.ReviewEntityDrama || entityType == models.ReviewEntityEpisode
+}
+
+// trashErrorStatus 回收站错误对应的状态码
+func trashErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrContentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrRestoreConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
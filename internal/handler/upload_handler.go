@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadHandler 分片上传处理器
+type UploadHandler struct {
+	*BaseHandler
+	uploadService service.UploadService
+}
+
+// NewUploadHandler 创建分片上传处理器
+func NewUploadHandler(uploadService service.UploadService) *UploadHandler {
+	return &UploadHandler{
+		BaseHandler:   NewBaseHandler(),
+		uploadService: uploadService,
+	}
+}
+
+// CreateSession 创建分片上传会话
+// @Summary 创建分片上传会话
+// @Description 大文件（如剧集视频）断点续传：创建会话后按 chunk_size 切分文件逐个上传分片
+// @Tags 文件
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body models.CreateUploadSessionRequest true "文件信息"
+// @Success 200 {object} models.APIResponse{data=models.UploadSessionResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Router /api/upload/sessions [post]
+func (h *UploadHandler) CreateSession(c *gin.Context) {
+	owner, ok := getUploadOwner(h.BaseHandler, c)
+	if !ok {
+		h.ErrorResponse(c, http.StatusUnauthorized, "用户未认证")
+		return
+	}
+
+	var req models.CreateUploadSessionRequest
+	if err := h.ValidateRequest(c, &req); err != nil {
+		h.ValidationErrorResponse(c, err)
+		return
+	}
+
+	session, err := h.uploadService.WithContext(c.Request.Context()).CreateSession(owner, &req)
+	if err != nil {
+		h.uploadErrorResponse(c, err)
+		return
+	}
+
+	h.SuccessResponseWithMessage(c, "上传会话创建成功", session)
+}
+
+// GetSession 获取分片上传会话
+// @Summary 获取分片上传会话
+// @Description 获取会话状态与已上传的分片，断线后据此续传缺失的分片
+// @Tags 文件
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "会话ID"
+// @Success 200 {object} models.APIResponse{data=models.UploadSessionResponse}
+// @Failure 404 {object} models.APIResponse
+// @Router /api/upload/sessions/{id} [get]
+func (h *UploadHandler) GetSession(c *gin.Context) {
+	owner, ok := getUploadOwner(h.BaseHandler, c)
+	if !ok {
+		h.ErrorResponse(c, http.StatusUnauthorized, "用户未认证")
+		return
+	}
+
+	session, err := h.uploadService.WithContext(c.Request.Context()).GetSession(owner, c.Param("id"))
+	if err != nil {
+		h.uploadErrorResponse(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, session)
+}
+
+// UploadPart 上传分片
+// @Summary 上传分片
+// @Description 请求体为分片的原始字节，需要设置 Content-Length 与 X-Chunk-SHA256（分片的十六进制 SHA-256），重复上传同一分片会覆盖
+// @Tags 文件
+// @Security BearerAuth
+// @Accept octet-stream
+// @Produce json
+// @Param id path string true "会话ID"
+// @Param part path int true "分片号（从 1 开始）"
+// @Param X-Chunk-SHA256 header string true "分片的 SHA-256"
+// @Success 200 {object} models.APIResponse{data=models.UploadSessionResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 411 {object} models.APIResponse
+// @Failure 415 {object} models.APIResponse
+// @Failure 422 {object} models.APIResponse
+// @Router /api/upload/sessions/{id}/parts/{part} [put]
+func (h *UploadHandler) UploadPart(c *gin.Context) {
+	owner, ok := getUploadOwner(h.BaseHandler, c)
+	if !ok {
+		h.ErrorResponse(c, http.StatusUnauthorized, "用户未认证")
+		return
+	}
+
+	partNumber, err := strconv.Atoi(c.Param("part"))
+	if err != nil {
+		h.ErrorResponse(c, http.StatusBadRequest, "无效的分片号")
+		return
+	}
+	if c.Request.ContentLength < 0 {
+		h.ErrorResponse(c, http.StatusLengthRequired, "请设置 Content-Length")
+		return
+	}
+
+	session, err := h.uploadService.WithContext(c.Request.Context()).UploadPart(
+		owner, c.Param("id"), partNumber, c.Request.Body, c.Request.ContentLength, c.GetHeader("X-Chunk-SHA256"),
+	)
+	if err != nil {
+		h.uploadErrorResponse(c, err)
+		return
+	}
+
+	h.SuccessResponse(c, session)
+}
+
+// CompleteSession 合并分片
+// @Summary 完成分片上传
+// @Description 所有分片上传后按顺序合并为最终文件，创建会话时提供了 sha256 会校验完整文件
+// @Tags 文件
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "会话ID"
+// @Success 200 {object} models.APIResponse{data=models.UploadSessionResponse}
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Failure 422 {object} models.APIResponse
+// @Router /api/upload/sessions/{id}/complete [post]
+func (h *UploadHandler) CompleteSession(c *gin.Context) {
+	owner, ok := getUploadOwner(h.BaseHandler, c)
+	if !ok {
+		h.ErrorResponse(c, http.StatusUnauthorized, "用户未认证")
+		return
+	}
+
+	session, err := h.uploadService.WithContext(c.Request.Context()).Complete(owner, c.Param("id"))
+	if err != nil {
+		h.uploadErrorResponse(c, err)
+		return
+	}
+
+	h.SuccessResponseWithMessage(c, "文件上传成功", session)
+}
+
+// AbortSession 取消分片上传
+// @Summary 取消分片上传
+// @Description 删除会话及已上传的分片
+// @Tags 文件
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "会话ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Router /api/upload/sessions/{id} [delete]
+func (h *UploadHandler) AbortSession(c *gin.Context) {
+	owner, ok := getUploadOwner(h.BaseHandler, c)
+	if !ok {
+		h.ErrorResponse(c, http.StatusUnauthorized, "用户未认证")
+		return
+	}
+
+	if err := h.uploadService.WithContext(c.Request.Context()).Abort(owner, c.Param("id")); err != nil {
+		h.uploadErrorResponse(c, err)
+		return
+	}
+
+	h.SuccessResponseWithMessage(c, "上传已取消", nil)
+}
+
+// uploadErrorResponse 根据分片上传错误类型返回对应的状态码
+func (h *UploadHandler) uploadErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUploadSessionNotFound):
+		h.ErrorResponse(c, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrUploadSessionClosed), errors.Is(err, service.ErrUploadIncomplete):
+		h.ErrorResponse(c, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrChecksumMismatch), errors.Is(err, service.ErrInvalidVideo):
+		h.ErrorResponse(c, http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, service.ErrUnsupportedContent):
+		h.ErrorResponse(c, http.StatusUnsupportedMediaType, err.Error())
+	default:
+		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+}
@@ -39,7 +39,7 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetProfile(userID)
+	user, err := h.userService.WithContext(c.Request.Context()).GetProfile(userID)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusNotFound, "用户不存在")
 		return
@@ -73,7 +73,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.UpdateProfile(userID, req)
+	user, err := h.userService.WithContext(c.Request.Context()).UpdateProfile(userID, req)
 	if err != nil {
 		h.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"log/slog"
 	"net/http"
 	"strings"
 
+	"gin-mysql-api/pkg/logger"
 	"gin-mysql-api/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -45,9 +47,7 @@ func AuthMiddleware(jwtManager *utils.JWTManager) gin.HandlerFunc {
 		}
 
 		// 将用户信息存储到上下文中
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("role", claims.Role)
+		setUserContext(c, claims)
 
 		c.Next()
 	}
@@ -95,9 +95,7 @@ func AdminAuthMiddleware(jwtManager *utils.JWTManager) gin.HandlerFunc {
 		}
 
 		// 将用户信息存储到上下文中
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("role", claims.Role)
+		setUserContext(c, claims)
 
 		c.Next()
 	}
@@ -129,10 +127,18 @@ func OptionalAuthMiddleware(jwtManager *utils.JWTManager) gin.HandlerFunc {
 		}
 
 		// 设置用户信息到上下文
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("role", claims.Role)
+		setUserContext(c, claims)
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// setUserContext 保存用户信息，并把用户 ID 附加到之后输出的请求日志中
+func setUserContext(c *gin.Context, claims *utils.JWTClaims) {
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", claims.Role)
+
+	ctx := logger.WithAttrs(c.Request.Context(), slog.Any("user_id", claims.UserID))
+	c.Request = c.Request.WithContext(ctx)
+}
@@ -2,7 +2,9 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 )
@@ -53,13 +55,46 @@ func CORS(config ...CORSConfig) gin.HandlerFunc {
 		conf = config[0]
 	}
 
+	return NewCORSPolicy(conf).Handler()
+}
+
+// CORSPolicy 允许来源可在运行时更新的 CORS 策略，用于配置热更新
+type CORSPolicy struct {
+	mu   sync.RWMutex
+	conf CORSConfig
+}
+
+// NewCORSPolicy 创建 CORS 策略
+func NewCORSPolicy(config CORSConfig) *CORSPolicy {
+	return &CORSPolicy{conf: config}
+}
+
+// SetAllowOrigins 更新允许的来源，对之后的请求生效
+func (p *CORSPolicy) SetAllowOrigins(origins []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.conf.AllowOrigins = append([]string(nil), origins...)
+}
+
+// allowOrigins 获取当前允许的来源
+func (p *CORSPolicy) allowOrigins() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.conf.AllowOrigins
+}
+
+// Handler 返回 CORS 中间件
+func (p *CORSPolicy) Handler() gin.HandlerFunc {
+	conf := p.conf
+
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
+		allowOrigins := p.allowOrigins()
 		
 		// 检查是否允许该来源
-		if len(conf.AllowOrigins) == 1 && conf.AllowOrigins[0] == "*" {
+		if len(allowOrigins) == 1 && allowOrigins[0] == "*" {
 			c.Header("Access-Control-Allow-Origin", "*")
-		} else if isOriginAllowed(origin, conf.AllowOrigins) {
+		} else if isOriginAllowed(origin, allowOrigins) {
 			c.Header("Access-Control-Allow-Origin", origin)
 		}
 
@@ -76,7 +111,7 @@ func CORS(config ...CORSConfig) gin.HandlerFunc {
 		}
 		
 		if conf.MaxAge > 0 {
-			c.Header("Access-Control-Max-Age", string(rune(conf.MaxAge)))
+			c.Header("Access-Control-Max-Age", strconv.Itoa(conf.MaxAge))
 		}
 
 		// 处理预检请求
@@ -97,8 +132,14 @@ func isOriginAllowed(origin string, allowedOrigins []string) bool {
 		}
 		// 支持通配符匹配
 		if strings.Contains(allowed, "*") {
-			// 简单的通配符匹配，支持 *.example.com 格式
-			if strings.HasPrefix(allowed, "*.") {
+			// 简单的通配符匹配，支持 *.example.com 与 https://*.example.com 格式
+			if idx := strings.Index(allowed, "://*."); idx >= 0 {
+				scheme := allowed[:idx+3]
+				domain := allowed[idx+5:]
+				if strings.HasPrefix(origin, scheme) && strings.HasSuffix(origin, "."+domain) {
+					return true
+				}
+			} else if strings.HasPrefix(allowed, "*.") {
 				domain := strings.TrimPrefix(allowed, "*.")
 				if strings.HasSuffix(origin, "."+domain) || strings.HasSuffix(origin, "://"+domain) {
 					return true
@@ -74,6 +74,30 @@ func TestCORS(t *testing.T) {
 		assert.Equal(t, http.StatusNoContent, w.Code)
 		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
 	})
+
+	t.Run("运行时更新允许的来源", func(t *testing.T) {
+		policy := NewCORSPolicy(CORSConfig{AllowOrigins: []string{"http://localhost:3000"}})
+
+		router := gin.New()
+		router.Use(policy.Handler())
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(200, gin.H{"message": "ok"})
+		})
+
+		allowOrigin := func(origin string) string {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.Header.Set("Origin", origin)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w.Header().Get("Access-Control-Allow-Origin")
+		}
+
+		assert.Empty(t, allowOrigin("https://example.com"))
+
+		policy.SetAllowOrigins([]string{"https://example.com"})
+		assert.Equal(t, "https://example.com", allowOrigin("https://example.com"))
+		assert.Empty(t, allowOrigin("http://localhost:3000"))
+	})
 }
 
 func TestIsOriginAllowed(t *testing.T) {
@@ -87,6 +111,10 @@ func TestIsOriginAllowed(t *testing.T) {
 		{"http://example.com", []string{"http://other.com"}, false},
 		{"http://sub.example.com", []string{"http://*.example.com"}, true},
 		{"http://example.com", []string{"http://*.example.com"}, false},
+		{"https://a.b.example.com", []string{"https://*.example.com"}, true},
+		{"http://sub.example.com", []string{"https://*.example.com"}, false},
+		{"https://sub.example.org", []string{"https://*.example.com"}, false},
+		{"https://sub.example.com", []string{"*.example.com"}, true},
 	}
 
 	for _, test := range tests {
@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"runtime/debug"
 
@@ -13,7 +15,8 @@ import (
 
 // ErrorHandler 全局错误处理中间件
 func ErrorHandler() gin.HandlerFunc {
-	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
+	// 堆栈由结构化日志记录，不再让 gin 额外输出一份
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered interface{}) {
 		if err, ok := recovered.(string); ok {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Success: false,
@@ -35,7 +38,10 @@ func ErrorHandler() gin.HandlerFunc {
 		}
 		
 		// 记录错误堆栈
-		fmt.Printf("Panic recovered: %v\n%s\n", recovered, debug.Stack())
+		slog.ErrorContext(c.Request.Context(), "panic recovered",
+			slog.Any("panic", recovered),
+			slog.String("stack", string(debug.Stack())),
+		)
 		c.Abort()
 	})
 }
@@ -81,6 +81,7 @@ func TestMethodNotAllowedHandler(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	router := gin.New()
+	router.HandleMethodNotAllowed = true
 	router.NoMethod(MethodNotAllowedHandler())
 	router.GET("/test", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "ok"})
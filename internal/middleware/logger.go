@@ -5,8 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
+	"sort"
 	"time"
 
+	"gin-mysql-api/pkg/logger"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -20,6 +24,8 @@ type LoggerConfig struct {
 	LogResponseBody bool
 	// MaxBodySize 最大记录的请求/响应体大小
 	MaxBodySize int64
+	// Logger 日志输出目标，为 nil 时使用全局默认 Logger
+	Logger *slog.Logger
 }
 
 // DefaultLoggerConfig 默认日志配置
@@ -38,50 +44,67 @@ func DefaultLoggerConfig() LoggerConfig {
 }
 
 // Logger 请求日志记录中间件
+// 请求结束后输出一条结构化日志，5xx 为 error 级别，4xx 为 warn 级别
 func Logger(config ...LoggerConfig) gin.HandlerFunc {
 	conf := DefaultLoggerConfig()
 	if len(config) > 0 {
 		conf = config[0]
 	}
 
-	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
+	return func(c *gin.Context) {
 		// 检查是否跳过此路径
-		for _, skipPath := range conf.SkipPaths {
-			if param.Path == skipPath {
-				return ""
-			}
+		if shouldSkipPath(conf.SkipPaths, c.Request.URL.Path) {
+			c.Next()
+			return
 		}
 
-		// 构建日志信息
-		logData := map[string]interface{}{
-			"timestamp":    param.TimeStamp.Format(time.RFC3339),
-			"status":       param.StatusCode,
-			"latency":      param.Latency.String(),
-			"client_ip":    param.ClientIP,
-			"method":       param.Method,
-			"path":         param.Path,
-			"user_agent":   param.Request.UserAgent(),
-			"error":        param.ErrorMessage,
-			"body_size":    param.BodySize,
-		}
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
 
-		// 添加请求 ID（如果存在）
-		if requestID := param.Request.Header.Get("X-Request-ID"); requestID != "" {
-			logData["request_id"] = requestID
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.Int("status", status),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Float64("latency_ms", float64(latency.Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("user_agent", c.Request.UserAgent()),
+			slog.Int("body_size", c.Writer.Size()),
 		}
-
-		// 添加用户信息（如果存在）
-		if userID := param.Keys["user_id"]; userID != nil {
-			logData["user_id"] = userID
+		if username, exists := c.Get("username"); exists {
+			attrs = append(attrs, slog.Any("username", username))
 		}
-		if username := param.Keys["username"]; username != nil {
-			logData["username"] = username
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
 		}
 
-		// 序列化为 JSON
-		jsonData, _ := json.Marshal(logData)
-		return string(jsonData) + "\n"
-	})
+		// 请求 ID 与用户 ID 由上下文中的日志字段补充
+		logger.OrDefault(conf.Logger).LogAttrs(c.Request.Context(), statusLevel(status), "request completed", attrs...)
+	}
+}
+
+// statusLevel 根据响应状态码选择日志级别
+func statusLevel(status int) slog.Level {
+	switch {
+	case status >= 500:
+		return slog.LevelError
+	case status >= 400:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// shouldSkipPath 是否跳过该路径的日志
+func shouldSkipPath(skipPaths []string, path string) bool {
+	for _, skipPath := range skipPaths {
+		if path == skipPath {
+			return true
+		}
+	}
+	return false
 }
 
 // DetailedLogger 详细日志记录中间件（包含请求/响应体）
@@ -93,11 +116,9 @@ func DetailedLogger(config ...LoggerConfig) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		// 检查是否跳过此路径
-		for _, skipPath := range conf.SkipPaths {
-			if c.Request.URL.Path == skipPath {
-				c.Next()
-				return
-			}
+		if shouldSkipPath(conf.SkipPaths, c.Request.URL.Path) {
+			c.Next()
+			return
 		}
 
 		start := time.Now()
@@ -135,16 +156,15 @@ func DetailedLogger(config ...LoggerConfig) gin.HandlerFunc {
 		}
 
 		// 构建日志数据
+		status := c.Writer.Status()
 		logData := map[string]interface{}{
-			"timestamp":   start.Format(time.RFC3339),
-			"status":      c.Writer.Status(),
-			"latency":     latency.String(),
-			"latency_ms":  float64(latency.Nanoseconds()) / 1000000,
-			"client_ip":   c.ClientIP(),
-			"method":      c.Request.Method,
-			"path":        path,
-			"user_agent":  c.Request.UserAgent(),
-			"body_size":   c.Writer.Size(),
+			"status":     status,
+			"latency_ms": float64(latency.Nanoseconds()) / 1000000,
+			"client_ip":  c.ClientIP(),
+			"method":     c.Request.Method,
+			"path":       path,
+			"user_agent": c.Request.UserAgent(),
+			"body_size":  c.Writer.Size(),
 		}
 
 		// 添加请求头信息
@@ -193,10 +213,7 @@ func DetailedLogger(config ...LoggerConfig) gin.HandlerFunc {
 			logData["errors"] = c.Errors.Errors()
 		}
 
-		// 添加用户信息
-		if userID, exists := c.Get("user_id"); exists {
-			logData["user_id"] = userID
-		}
+		// 添加用户信息（用户 ID 由上下文中的日志字段补充）
 		if username, exists := c.Get("username"); exists {
 			logData["username"] = username
 		}
@@ -205,8 +222,16 @@ func DetailedLogger(config ...LoggerConfig) gin.HandlerFunc {
 		}
 
 		// 输出日志
-		jsonData, _ := json.Marshal(logData)
-		fmt.Println(string(jsonData))
+		keys := make([]string, 0, len(logData))
+		for key := range logData {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		attrs := make([]slog.Attr, 0, len(keys))
+		for _, key := range keys {
+			attrs = append(attrs, slog.Any(key, logData[key]))
+		}
+		logger.OrDefault(conf.Logger).LogAttrs(c.Request.Context(), statusLevel(status), "request completed", attrs...)
 	}
 }
 
@@ -236,6 +261,10 @@ func RequestIDMiddleware() gin.HandlerFunc {
 		
 		c.Header("X-Request-ID", requestID)
 		c.Set("request_id", requestID)
+
+		// 处理该请求期间输出的日志都带上请求 ID
+		ctx := logger.WithAttrs(c.Request.Context(), slog.String("request_id", requestID))
+		c.Request = c.Request.WithContext(ctx)
 		c.Next()
 	}
 }
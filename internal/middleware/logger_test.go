@@ -2,24 +2,35 @@ package middleware
 
 import (
 	"bytes"
+	"encoding/json"
+	"log/slog"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"gin-mysql-api/pkg/logger"
+
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// newTestLogger 创建输出到缓冲区的 JSON Logger
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(logger.NewHandler(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+}
+
 func TestLogger(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	t.Run("正常请求日志记录", func(t *testing.T) {
 		// 捕获日志输出
 		var buf bytes.Buffer
-		gin.DefaultWriter = &buf
+		config := DefaultLoggerConfig()
+		config.Logger = newTestLogger(&buf)
 		
 		router := gin.New()
-		router.Use(Logger())
+		router.Use(Logger(config))
 		router.GET("/test", func(c *gin.Context) {
 			c.JSON(200, gin.H{"message": "success"})
 		})
@@ -42,10 +53,10 @@ func TestLogger(t *testing.T) {
 
 	t.Run("跳过指定路径", func(t *testing.T) {
 		var buf bytes.Buffer
-		gin.DefaultWriter = &buf
 
 		config := LoggerConfig{
 			SkipPaths: []string{"/health"},
+			Logger:    newTestLogger(&buf),
 		}
 
 		router := gin.New()
@@ -68,10 +79,11 @@ func TestLogger(t *testing.T) {
 
 	t.Run("记录请求ID", func(t *testing.T) {
 		var buf bytes.Buffer
-		gin.DefaultWriter = &buf
+		config := DefaultLoggerConfig()
+		config.Logger = newTestLogger(&buf)
 
 		router := gin.New()
-		router.Use(Logger())
+		router.Use(RequestIDMiddleware(), Logger(config))
 		router.GET("/test", func(c *gin.Context) {
 			c.JSON(200, gin.H{"message": "success"})
 		})
@@ -85,8 +97,46 @@ func TestLogger(t *testing.T) {
 		assert.Equal(t, 200, w.Code)
 		
 		// 检查日志输出包含请求ID
-		logOutput := buf.String()
-		assert.Contains(t, logOutput, "test-request-id")
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "test-request-id", entry["request_id"])
+		assert.Equal(t, "INFO", entry["level"])
+	})
+
+	t.Run("请求处理中的日志带上请求ID和用户ID", func(t *testing.T) {
+		var buf bytes.Buffer
+		log := newTestLogger(&buf)
+		config := DefaultLoggerConfig()
+		config.Logger = log
+
+		router := gin.New()
+		router.Use(RequestIDMiddleware(), Logger(config))
+		router.GET("/test", func(c *gin.Context) {
+			// 模拟认证中间件写入用户信息
+			c.Request = c.Request.WithContext(logger.WithAttrs(c.Request.Context(), slog.Any("user_id", uint(7))))
+			log.InfoContext(c.Request.Context(), "handling")
+			c.JSON(500, gin.H{"message": "failed"})
+		})
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-Request-ID", "req-42")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 2)
+		for _, line := range lines {
+			var entry map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			assert.Equal(t, "req-42", entry["request_id"])
+			assert.Equal(t, float64(7), entry["user_id"])
+		}
+
+		var completed map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &completed))
+		assert.Equal(t, "ERROR", completed["level"])
+		assert.Equal(t, float64(500), completed["status"])
 	})
 }
 
@@ -94,13 +144,13 @@ func TestDetailedLogger(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	t.Run("记录请求和响应体", func(t *testing.T) {
-		// 重定向标准输出以捕获日志
 		var buf bytes.Buffer
 		
 		config := LoggerConfig{
 			LogRequestBody:  true,
 			LogResponseBody: true,
 			MaxBodySize:     1024,
+			Logger:          newTestLogger(&buf),
 		}
 
 		router := gin.New()
@@ -114,19 +164,14 @@ func TestDetailedLogger(t *testing.T) {
 		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
 
-		// 临时重定向标准输出
-		originalStdout := gin.DefaultWriter
-		gin.DefaultWriter = &buf
-		defer func() {
-			gin.DefaultWriter = originalStdout
-		}()
-
 		router.ServeHTTP(w, req)
 
 		assert.Equal(t, 200, w.Code)
-		
-		// 注意：DetailedLogger 使用 fmt.Println 输出到标准输出
-		// 在测试环境中可能需要不同的方法来捕获输出
+
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, map[string]interface{}{"name": "test"}, entry["request_body"])
+		assert.Equal(t, map[string]interface{}{"message": "success"}, entry["response_body"])
 	})
 
 	t.Run("跳过指定路径", func(t *testing.T) {
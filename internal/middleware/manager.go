@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"net/http"
+	"strings"
 	"time"
 
 	"gin-mysql-api/pkg/config"
@@ -8,9 +10,21 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// Manager 中间件管理器
+// probePaths 健康检查路径，IP 白名单、User-Agent 过滤与限流对其不生效，保证负载均衡与探针始终可以访问
+var probePaths = []string{"/health", "/ready", "/live"}
+
+// uploadPathPrefix 文件上传接口前缀，请求体上限使用 upload.maxSize
+const uploadPathPrefix = "/api/upload"
+
+// mediaPathPrefixes 上传文件与签名播放地址的访问路径前缀，下载视频的耗时取决于文件大小与网速，不受请求超时限制
+var mediaPathPrefixes = []string{"/uploads/", "/media/"}
+
+// Manager 中间件管理器，根据配置组装唯一的全局中间件链
+// 各环境之间的差异（CORS 来源、CSP、IP 白名单、User-Agent 过滤、请求体与限流上限）全部来自配置，由 profile 选择
 type Manager struct {
-	config *config.Config
+	config      *config.Config
+	cors        *CORSPolicy
+	rateLimiter *RateLimiter
 }
 
 // NewManager 创建中间件管理器
@@ -20,130 +34,119 @@ func NewManager(cfg *config.Config) *Manager {
 	}
 }
 
-// SetupMiddlewares 设置所有中间件
+// SetupMiddlewares 按顺序注册全局中间件
 func (m *Manager) SetupMiddlewares(engine *gin.Engine) {
+	cfg := m.config
+
+	// 受信任的代理：IP 白名单、限流与播放地址的 IP 绑定都依赖 c.ClientIP()
+	// gin 默认信任所有代理，未配置时任何客户端都可以通过 X-Forwarded-For 伪造 IP；配置已在启动时校验，这里不会出错
+	_ = engine.SetTrustedProxies(cfg.Server.TrustedProxies)
+
 	// 错误处理中间件（最先设置）
 	engine.Use(ErrorHandler())
 
 	// 请求 ID 中间件
 	engine.Use(RequestIDMiddleware())
 
+	// 链路追踪中间件（依赖请求 ID）
+	engine.Use(Tracing())
+
+	// 请求指标中间件
+	engine.Use(Metrics())
+
 	// 日志中间件
-	if m.config.Logging.Level == "debug" {
-		// 开发环境使用详细日志
-		engine.Use(DetailedLogger(LoggerConfig{
-			SkipPaths:       []string{"/health", "/ready", "/live", "/metrics"},
-			LogRequestBody:  true,
-			LogResponseBody: false,
-			MaxBodySize:     1024 * 10, // 10KB
-		}))
-	} else {
-		// 生产环境使用简单日志
-		engine.Use(Logger(LoggerConfig{
-			SkipPaths: []string{"/health", "/ready", "/live", "/metrics"},
-		}))
-	}
+	engine.Use(Logger())
 
-	// 安全中间件
-	securityConfig := SecurityConfig{
-		XSSProtection:         "1; mode=block",
-		ContentTypeNosniff:    true,
-		XFrameOptions:         "DENY",
-		HSTSMaxAge:            31536000, // 1 year
-		HSTSIncludeSubdomains: true,
-		ContentSecurityPolicy: "default-src 'self'; script-src 'self' 'unsafe-inline' https://cdn.jsdelivr.net https://code.jquery.com https://cdnjs.cloudflare.com; style-src 'self' 'unsafe-inline' https://cdn.jsdelivr.net https://cdnjs.cloudflare.com; font-src 'self' https://cdnjs.cloudflare.com; img-src 'self' data: https:;",
-		ReferrerPolicy:        "strict-origin-when-cross-origin",
-	}
+	// 安全头中间件
+	securityConfig := DefaultSecurityConfig()
+	securityConfig.XFrameOptions = cfg.Security.GetFrameOptions()
+	securityConfig.HSTSMaxAge = cfg.Security.HSTSMaxAge
+	securityConfig.ContentSecurityPolicy = cfg.Security.GetContentSecurityPolicy()
 	engine.Use(Security(securityConfig))
 
 	// CORS 中间件
-	corsConfig := CORSConfig{
-		AllowOrigins: []string{"*"}, // 生产环境应该配置具体的域名
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
+	m.cors = NewCORSPolicy(CORSConfig{
+		AllowOrigins: cfg.CORS.GetAllowOrigins(),
+		AllowMethods: []string{
+			http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+			http.MethodDelete, http.MethodHead, http.MethodOptions,
+		},
 		AllowHeaders: []string{
 			"Origin", "Content-Type", "Content-Length",
 			"Accept-Encoding", "X-CSRF-Token", "Authorization",
-			"X-Request-ID", "X-Requested-With",
+			"X-Request-ID", "X-Requested-With", "traceparent", "tracestate",
+			"X-Chunk-SHA256",
 		},
-		ExposeHeaders:    []string{"X-Request-ID"},
-		AllowCredentials: false,
+		ExposeHeaders:    []string{"X-Request-ID", "X-Trace-ID"},
+		AllowCredentials: cfg.CORS.AllowCredentials,
 		MaxAge:           86400, // 24 hours
+	})
+	engine.Use(m.cors.Handler())
+
+	// IP 白名单（如果配置了）
+	if len(cfg.Server.AllowedIPs) > 0 {
+		engine.Use(exceptPaths(IPWhitelist(cfg.Server.AllowedIPs), probePaths...))
 	}
-	engine.Use(CORS(corsConfig))
 
-	// 请求大小限制中间件
-	maxRequestSize := int64(10 * 1024 * 1024) // 10MB
-	if m.config.Upload.MaxSize > 0 {
-		maxRequestSize = int64(m.config.Upload.MaxSize) * 1024 * 1024
+	// User-Agent 过滤（如果配置了）
+	if len(cfg.Security.BlockedUserAgents) > 0 {
+		engine.Use(exceptPaths(UserAgentFilter(cfg.Security.BlockedUserAgents), probePaths...))
 	}
-	engine.Use(RequestSizeLimit(maxRequestSize))
 
-	// 限流中间件
-	rateLimitConfig := RateLimitConfig{
-		MaxRequests: 1000, // 每分钟最多 1000 个请求
-		WindowSize:  60,   // 1 minute
-		KeyFunc: func(c *gin.Context) string {
-			// 对认证用户使用用户 ID，对未认证用户使用 IP
-			if userID, exists := c.Get("user_id"); exists {
-				return "user:" + string(rune(userID.(uint)))
-			}
-			return "ip:" + c.ClientIP()
-		},
+	// 请求大小限制中间件，上传接口使用上传大小上限
+	bodyLimits := map[string]int64{}
+	if cfg.Upload.MaxSize > 0 {
+		bodyLimits[uploadPathPrefix] = int64(cfg.Upload.MaxSize) * 1024 * 1024
 	}
-	engine.Use(SimpleRateLimit(rateLimitConfig))
+	engine.Use(RequestSizeLimitByPath(cfg.Security.GetMaxBodySizeBytes(), bodyLimits))
 
-	// 请求超时中间件
-	engine.Use(Timeout(30 * time.Second))
+	// 限流中间件
+	m.rateLimiter = NewRateLimiter(RateLimitConfig{
+		MaxRequests: cfg.RateLimit.GetMaxRequests(),
+		WindowSize:  int(cfg.RateLimit.GetWindow() / time.Second),
+	})
+	engine.Use(exceptPaths(m.rateLimiter.Handler(), probePaths...))
+
+	// 请求超时中间件（如果配置了）
+	if cfg.Security.RequestTimeout > 0 {
+		engine.Use(exceptPathPrefix(Timeout(cfg.Security.RequestTimeout), mediaPathPrefixes...))
+	}
 
 	// 404 和 405 处理
 	engine.NoRoute(NotFoundHandler())
 	engine.NoMethod(MethodNotAllowedHandler())
 }
 
-// SetupProductionMiddlewares 设置生产环境中间件
-func (m *Manager) SetupProductionMiddlewares(engine *gin.Engine) {
-	// 基础中间件
-	m.SetupMiddlewares(engine)
-
-	// 生产环境特有的中间件
-
-	// IP 白名单（如果配置了）
-	if len(m.config.Server.AllowedIPs) > 0 {
-		engine.Use(IPWhitelist(m.config.Server.AllowedIPs))
+// Reload 应用热更新的配置（CORS 来源与限流），需在 SetupMiddlewares 之后调用
+func (m *Manager) Reload(cfg *config.Config) {
+	if m.cors != nil {
+		m.cors.SetAllowOrigins(cfg.CORS.GetAllowOrigins())
 	}
-
-	// User-Agent 过滤
-	blockedUserAgents := []string{
-		"bot", "crawler", "spider", "scraper",
+	if m.rateLimiter != nil {
+		m.rateLimiter.SetLimit(cfg.RateLimit.GetMaxRequests(), int(cfg.RateLimit.GetWindow()/time.Second))
 	}
-	engine.Use(UserAgentFilter(blockedUserAgents))
-
-	// 更严格的限流
-	strictRateLimitConfig := RateLimitConfig{
-		MaxRequests: 100, // 每分钟最多 100 个请求
-		WindowSize:  60,  // 1 minute
-		KeyFunc: func(c *gin.Context) string {
-			return c.ClientIP()
-		},
+}
+
+// exceptPaths 对指定路径跳过中间件
+func exceptPaths(handler gin.HandlerFunc, paths ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if shouldSkipPath(paths, c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		handler(c)
 	}
-	engine.Use(SimpleRateLimit(strictRateLimitConfig))
 }
 
-// SetupDevelopmentMiddlewares 设置开发环境中间件
-func (m *Manager) SetupDevelopmentMiddlewares(engine *gin.Engine) {
-	// 基础中间件
-	m.SetupMiddlewares(engine)
-
-	// 开发环境特有的中间件
-
-	// 更宽松的 CORS 配置
-	devCorsConfig := CORSConfig{
-		AllowOrigins:     []string{"http://localhost:3000", "http://localhost:1800", "http://127.0.0.1:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"*"},
-		ExposeHeaders:    []string{"*"},
-		AllowCredentials: true,
-		MaxAge:           86400,
+// exceptPathPrefix 对指定前缀下的路径跳过中间件
+func exceptPathPrefix(handler gin.HandlerFunc, prefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) {
+				c.Next()
+				return
+			}
+		}
+		handler(c)
 	}
-	engine.Use(CORS(devCorsConfig))
 }
@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-mysql-api/pkg/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newManagedRouter 使用中间件管理器创建测试路由
+func newManagedRouter(cfg *config.Config) (*gin.Engine, *Manager) {
+	engine := gin.New()
+	manager := NewManager(cfg)
+	manager.SetupMiddlewares(engine)
+
+	handler := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	}
+	engine.GET("/health", handler)
+	engine.GET("/api/dramas", handler)
+	engine.POST("/api/dramas", handler)
+	engine.POST("/api/upload/video", handler)
+	return engine, manager
+}
+
+// sendRequest 发送测试请求
+func sendRequest(engine *gin.Engine, method, path string, setup func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "192.168.1.10:12345"
+	if setup != nil {
+		setup(req)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestManager(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("默认配置", func(t *testing.T) {
+		engine, _ := newManagedRouter(&config.Config{})
+
+		w := sendRequest(engine, "GET", "/api/dramas", func(req *http.Request) {
+			req.Header.Set("Origin", "http://example.com")
+		})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+		assert.Equal(t, config.DefaultContentSecurityPolicy, w.Header().Get("Content-Security-Policy"))
+		assert.NotContains(t, w.Header().Get("Content-Security-Policy"), "unsafe-eval")
+		assert.NotEmpty(t, w.Header().Get("X-Request-ID"))
+	})
+
+	t.Run("安全头与 CORS 来自配置", func(t *testing.T) {
+		cfg := &config.Config{
+			CORS: config.CORSConfig{AllowOrigins: []string{"https://app.example.com"}, AllowCredentials: true},
+			Security: config.SecurityConfig{
+				ContentSecurityPolicy: "default-src 'none'",
+				FrameOptions:          "sameorigin",
+				HSTSMaxAge:            3600,
+			},
+		}
+		engine, _ := newManagedRouter(cfg)
+
+		w := sendRequest(engine, "GET", "/api/dramas", func(req *http.Request) {
+			req.Header.Set("Origin", "https://app.example.com")
+		})
+
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "default-src 'none'", w.Header().Get("Content-Security-Policy"))
+		assert.Equal(t, "SAMEORIGIN", w.Header().Get("X-Frame-Options"))
+
+		w = sendRequest(engine, "GET", "/api/dramas", func(req *http.Request) {
+			req.Header.Set("Origin", "https://evil.example.com")
+		})
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("IP 白名单与 User-Agent 过滤不影响健康检查", func(t *testing.T) {
+		cfg := &config.Config{
+			Server:   config.ServerConfig{AllowedIPs: []string{"10.0.0.0/8"}},
+			Security: config.SecurityConfig{BlockedUserAgents: []string{"scraper"}},
+		}
+		engine, _ := newManagedRouter(cfg)
+
+		assert.Equal(t, http.StatusForbidden, sendRequest(engine, "GET", "/api/dramas", nil).Code)
+		assert.Equal(t, http.StatusOK, sendRequest(engine, "GET", "/health", nil).Code)
+
+		fromIntranet := func(req *http.Request) { req.RemoteAddr = "10.1.2.3:12345" }
+		assert.Equal(t, http.StatusOK, sendRequest(engine, "GET", "/api/dramas", fromIntranet).Code)
+
+		w := sendRequest(engine, "GET", "/api/dramas", func(req *http.Request) {
+			fromIntranet(req)
+			req.Header.Set("User-Agent", "Mozilla/5.0 Scraper/1.0")
+		})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("只有受信任的代理可以通过 X-Forwarded-For 指定客户端 IP", func(t *testing.T) {
+		spoofed := func(req *http.Request) { req.Header.Set("X-Forwarded-For", "10.1.2.3") }
+
+		engine, _ := newManagedRouter(&config.Config{
+			Server: config.ServerConfig{AllowedIPs: []string{"10.0.0.0/8"}},
+		})
+		assert.Equal(t, http.StatusForbidden, sendRequest(engine, "GET", "/api/dramas", spoofed).Code)
+
+		engine, _ = newManagedRouter(&config.Config{
+			Server: config.ServerConfig{AllowedIPs: []string{"10.0.0.0/8"}, TrustedProxies: []string{"192.168.1.0/24"}},
+		})
+		assert.Equal(t, http.StatusOK, sendRequest(engine, "GET", "/api/dramas", spoofed).Code)
+	})
+
+	t.Run("上传接口使用上传大小上限", func(t *testing.T) {
+		cfg := &config.Config{
+			Upload:   config.UploadConfig{MaxSize: 2},
+			Security: config.SecurityConfig{MaxBodySizeMB: 1},
+		}
+		engine, _ := newManagedRouter(cfg)
+
+		body := strings.Repeat("a", 1536*1024)
+		withBody := func(req *http.Request) {
+			req.Body = http.NoBody
+			req.ContentLength = int64(len(body))
+		}
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, sendRequest(engine, "POST", "/api/dramas", withBody).Code)
+		assert.Equal(t, http.StatusOK, sendRequest(engine, "POST", "/api/upload/video", withBody).Code)
+	})
+
+	t.Run("上传文件不受请求超时限制", func(t *testing.T) {
+		engine, _ := newManagedRouter(&config.Config{Security: config.SecurityConfig{RequestTimeout: time.Second}})
+		hasDeadline := func(c *gin.Context) {
+			_, ok := c.Request.Context().Deadline()
+			c.JSON(http.StatusOK, gin.H{"deadline": ok})
+		}
+		engine.GET("/uploads/*path", hasDeadline)
+		engine.GET("/media/:token/*path", hasDeadline)
+		engine.GET("/api/deadline", hasDeadline)
+
+		assert.JSONEq(t, `{"deadline": false}`, sendRequest(engine, "GET", "/uploads/videos/a.mp4", nil).Body.String())
+		assert.JSONEq(t, `{"deadline": false}`, sendRequest(engine, "GET", "/media/k1.abc.def/videos/a.mp4", nil).Body.String())
+		assert.JSONEq(t, `{"deadline": true}`, sendRequest(engine, "GET", "/api/deadline", nil).Body.String())
+	})
+
+	t.Run("热更新 CORS 来源与限流", func(t *testing.T) {
+		cfg := &config.Config{
+			CORS:      config.CORSConfig{AllowOrigins: []string{"https://a.example.com"}},
+			RateLimit: config.RateLimitConfig{MaxRequests: 100, Window: time.Minute},
+		}
+		engine, manager := newManagedRouter(cfg)
+
+		manager.Reload(&config.Config{
+			CORS:      config.CORSConfig{AllowOrigins: []string{"https://b.example.com"}},
+			RateLimit: config.RateLimitConfig{MaxRequests: 1, Window: time.Minute},
+		})
+
+		fromB := func(req *http.Request) { req.Header.Set("Origin", "https://b.example.com") }
+		w := sendRequest(engine, "GET", "/api/dramas", fromB)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://b.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+		assert.Equal(t, http.StatusTooManyRequests, sendRequest(engine, "GET", "/api/dramas", fromB).Code)
+		assert.Equal(t, http.StatusOK, sendRequest(engine, "GET", "/health", nil).Code, "健康检查不受限流影响")
+	})
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics HTTP 请求指标中间件
+// 按路由模板而不是实际路径记录，未匹配的路由统一记为 unmatched，避免标签基数失控
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// MetricsAuth 指标接口访问控制中间件
+// 客户端 IP 在 AllowedIPs 中或 Basic Auth 校验通过即可访问，两者都未配置时不做限制
+func MetricsAuth(cfg config.MetricsConfig) gin.HandlerFunc {
+	basicAuthEnabled := cfg.Username != "" && cfg.Password != ""
+
+	return func(c *gin.Context) {
+		if len(cfg.AllowedIPs) == 0 && !basicAuthEnabled {
+			c.Next()
+			return
+		}
+
+		if matchIP(cfg.AllowedIPs, c.ClientIP()) {
+			c.Next()
+			return
+		}
+
+		if basicAuthEnabled {
+			username, password, ok := c.Request.BasicAuth()
+			if ok && secureCompare(username, cfg.Username) && secureCompare(password, cfg.Password) {
+				c.Next()
+				return
+			}
+
+			c.Header("WWW-Authenticate", `Basic realm="metrics"`)
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "未授权访问",
+				"error":   "invalid credentials",
+			})
+			c.Abort()
+			return
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "访问被拒绝",
+			"error":   "IP not in whitelist",
+		})
+		c.Abort()
+	}
+}
+
+// secureCompare 常量时间比较字符串，避免时序攻击
+func secureCompare(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
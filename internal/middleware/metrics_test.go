@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"gin-mysql-api/pkg/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(cfg config.MetricsConfig) *gin.Engine {
+		router := gin.New()
+		router.GET("/metrics", MetricsAuth(cfg), func(c *gin.Context) {
+			c.String(200, "ok")
+		})
+		return router
+	}
+
+	t.Run("未配置限制时允许访问", func(t *testing.T) {
+		router := newRouter(config.MetricsConfig{})
+
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, 200, w.Code)
+	})
+
+	t.Run("IP 白名单", func(t *testing.T) {
+		router := newRouter(config.MetricsConfig{AllowedIPs: []string{"127.0.0.1", "10.0.0.0/8"}})
+
+		tests := []struct {
+			remoteAddr string
+			expected   int
+		}{
+			{"127.0.0.1:1234", 200},
+			{"10.20.30.40:1234", 200},
+			{"192.168.1.1:1234", 403},
+		}
+
+		for _, test := range tests {
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			req.RemoteAddr = test.remoteAddr
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, test.expected, w.Code, "RemoteAddr: %s", test.remoteAddr)
+		}
+	})
+
+	t.Run("Basic Auth", func(t *testing.T) {
+		router := newRouter(config.MetricsConfig{
+			AllowedIPs: []string{"127.0.0.1"},
+			Username:   "prometheus",
+			Password:   "secret",
+		})
+
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		req.SetBasicAuth("prometheus", "secret")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+
+		req = httptest.NewRequest("GET", "/metrics", nil)
+		req.SetBasicAuth("prometheus", "wrong")
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, 401, w.Code)
+		assert.Equal(t, `Basic realm="metrics"`, w.Header().Get("WWW-Authenticate"))
+
+		// 白名单内的 IP 无需认证
+		req = httptest.NewRequest("GET", "/metrics", nil)
+		req.RemoteAddr = "127.0.0.1:1234"
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+	})
+}
+
+func TestMatchIP(t *testing.T) {
+	tests := []struct {
+		allowed  []string
+		ip       string
+		expected bool
+	}{
+		{[]string{"*"}, "1.2.3.4", true},
+		{[]string{"1.2.3.4"}, "1.2.3.4", true},
+		{[]string{"1.2.3.4"}, "1.2.3.5", false},
+		{[]string{"192.168.0.0/16"}, "192.168.10.1", true},
+		{[]string{"192.168.0.0/16"}, "192.169.0.1", false},
+		{[]string{"::1"}, "::1", true},
+		{[]string{"fd00::/8"}, "fd12::1", true},
+		{[]string{"invalid/cidr"}, "1.2.3.4", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, matchIP(test.allowed, test.ip), "Allowed: %v, IP: %s", test.allowed, test.ip)
+	}
+}
@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"context"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -114,27 +117,70 @@ func SimpleRateLimit(config ...RateLimitConfig) gin.HandlerFunc {
 		conf = config[0]
 	}
 
-	// 简单的内存存储（生产环境应使用 Redis）
-	requests := make(map[string][]time.Time)
+	return NewRateLimiter(conf).Handler()
+}
 
-	return func(c *gin.Context) {
-		key := conf.KeyFunc(c)
-		now := time.Now()
-		windowStart := now.Add(-time.Duration(conf.WindowSize) * time.Second)
-
-		// 清理过期的请求记录
-		if times, exists := requests[key]; exists {
-			validTimes := make([]time.Time, 0)
-			for _, t := range times {
-				if t.After(windowStart) {
-					validTimes = append(validTimes, t)
-				}
+// RateLimiter 基于内存的滑动窗口限流器，限额可在运行时调整，用于配置热更新
+type RateLimiter struct {
+	mu   sync.Mutex
+	conf RateLimitConfig
+	// 简单的内存存储（多实例部署应使用 Redis）
+	requests map[string][]time.Time
+}
+
+// NewRateLimiter 创建限流器，未设置 KeyFunc 时按客户端 IP 限流
+func NewRateLimiter(config RateLimitConfig) *RateLimiter {
+	if config.KeyFunc == nil {
+		config.KeyFunc = DefaultRateLimitConfig().KeyFunc
+	}
+	return &RateLimiter{
+		conf:     config,
+		requests: make(map[string][]time.Time),
+	}
+}
+
+// SetLimit 调整限额与时间窗口（秒），已记录的请求按新窗口重新计算
+func (l *RateLimiter) SetLimit(maxRequests, windowSize int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.conf.MaxRequests = maxRequests
+	l.conf.WindowSize = windowSize
+}
+
+// allow 记录一次请求，超过限额时返回 false
+func (l *RateLimiter) allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	windowStart := now.Add(-time.Duration(l.conf.WindowSize) * time.Second)
+
+	// 清理过期的请求记录
+	if times, exists := l.requests[key]; exists {
+		validTimes := make([]time.Time, 0, len(times))
+		for _, t := range times {
+			if t.After(windowStart) {
+				validTimes = append(validTimes, t)
 			}
-			requests[key] = validTimes
 		}
+		l.requests[key] = validTimes
+	}
+
+	// 检查是否超过限制
+	if len(l.requests[key]) >= l.conf.MaxRequests {
+		return false
+	}
 
-		// 检查是否超过限制
-		if len(requests[key]) >= conf.MaxRequests {
+	// 记录当前请求
+	l.requests[key] = append(l.requests[key], now)
+	return true
+}
+
+// Handler 返回限流中间件
+func (l *RateLimiter) Handler() gin.HandlerFunc {
+	keyFunc := l.conf.KeyFunc
+
+	return func(c *gin.Context) {
+		if !l.allow(keyFunc(c), time.Now()) {
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"success": false,
 				"message": "请求过于频繁，请稍后再试",
@@ -144,37 +190,14 @@ func SimpleRateLimit(config ...RateLimitConfig) gin.HandlerFunc {
 			return
 		}
 
-		// 记录当前请求
-		requests[key] = append(requests[key], now)
-
 		c.Next()
 	}
 }
 
-// IPWhitelist IP 白名单中间件
+// IPWhitelist IP 白名单中间件，支持精确 IP、CIDR 网段（如 10.0.0.0/8）与通配符 *
 func IPWhitelist(allowedIPs []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
-		
-		// 检查是否在白名单中
-		allowed := false
-		for _, ip := range allowedIPs {
-			if ip == clientIP || ip == "*" {
-				allowed = true
-				break
-			}
-			// 支持 CIDR 格式（简化版）
-			if strings.Contains(ip, "/") {
-				// 这里应该使用更完整的 CIDR 匹配逻辑
-				// 为简化，暂时只支持精确匹配
-				if ip == clientIP {
-					allowed = true
-					break
-				}
-			}
-		}
-
-		if !allowed {
+		if !matchIP(allowedIPs, c.ClientIP()) {
 			c.JSON(http.StatusForbidden, gin.H{
 				"success": false,
 				"message": "访问被拒绝",
@@ -188,6 +211,30 @@ func IPWhitelist(allowedIPs []string) gin.HandlerFunc {
 	}
 }
 
+// matchIP 检查 IP 是否匹配列表中的地址或 CIDR 网段，* 匹配任意地址
+func matchIP(allowed []string, clientIP string) bool {
+	ip := net.ParseIP(clientIP)
+	for _, entry := range allowed {
+		entry = strings.TrimSpace(entry)
+		if entry == "*" || entry == clientIP {
+			return true
+		}
+		if ip == nil {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if allowedIP := net.ParseIP(entry); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // UserAgentFilter User-Agent 过滤中间件
 func UserAgentFilter(blockedPatterns []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -212,7 +259,21 @@ func UserAgentFilter(blockedPatterns []string) gin.HandlerFunc {
 
 // RequestSizeLimit 请求大小限制中间件
 func RequestSizeLimit(maxSize int64) gin.HandlerFunc {
+	return RequestSizeLimitByPath(maxSize, nil)
+}
+
+// RequestSizeLimitByPath 按路径前缀设置请求体大小上限，未匹配的路径使用 defaultSize
+// 没有 Content-Length 的请求（分块传输）在读取超过上限时报错
+func RequestSizeLimitByPath(defaultSize int64, prefixLimits map[string]int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		maxSize := defaultSize
+		matched := ""
+		for prefix, limit := range prefixLimits {
+			if strings.HasPrefix(c.Request.URL.Path, prefix) && len(prefix) > len(matched) {
+				maxSize, matched = limit, prefix
+			}
+		}
+
 		if c.Request.ContentLength > maxSize {
 			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
 				"success": false,
@@ -222,6 +283,7 @@ func RequestSizeLimit(maxSize int64) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize)
 
 		c.Next()
 	}
@@ -231,9 +293,9 @@ func RequestSizeLimit(maxSize int64) gin.HandlerFunc {
 func Timeout(timeout time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 设置请求超时
-		ctx, cancel := c.Request.Context(), func() {}
+		ctx, cancel := c.Request.Context(), context.CancelFunc(func() {})
 		if timeout > 0 {
-			ctx, cancel = c.Request.Context(), cancel
+			ctx, cancel = context.WithTimeout(c.Request.Context(), timeout)
 		}
 		defer cancel()
 
@@ -1,7 +1,8 @@
 package middleware
 
 import (
-	"net/http"
+	"context"
+	"io"
 	"net/http/httptest"
 	"strings"
 	"testing"
@@ -129,6 +130,30 @@ func TestSimpleRateLimit(t *testing.T) {
 		assert.Equal(t, 429, w.Code)
 		assert.Contains(t, w.Body.String(), "请求过于频繁")
 	})
+
+	t.Run("运行时调整限额", func(t *testing.T) {
+		limiter := NewRateLimiter(RateLimitConfig{MaxRequests: 1, WindowSize: 60})
+
+		router := gin.New()
+		router.Use(limiter.Handler())
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(200, gin.H{"message": "success"})
+		})
+
+		send := func() int {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/test", nil))
+			return w.Code
+		}
+
+		assert.Equal(t, 200, send())
+		assert.Equal(t, 429, send())
+
+		limiter.SetLimit(3, 60)
+		assert.Equal(t, 200, send())
+		assert.Equal(t, 200, send())
+		assert.Equal(t, 429, send())
+	})
 }
 
 func TestIPWhitelist(t *testing.T) {
@@ -171,6 +196,30 @@ func TestIPWhitelist(t *testing.T) {
 		assert.Contains(t, w.Body.String(), "访问被拒绝")
 	})
 
+	t.Run("CIDR 网段匹配", func(t *testing.T) {
+		router := gin.New()
+		router.Use(IPWhitelist([]string{"10.0.0.0/8", "2001:db8::/32"}))
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(200, gin.H{"message": "success"})
+		})
+
+		cases := map[string]int{
+			"10.1.2.3:12345":      200,
+			"[2001:db8::1]:12345": 200,
+			"11.0.0.1:12345":      403,
+			"[2001:db9::1]:12345": 403,
+		}
+		for remoteAddr, expected := range cases {
+			req := httptest.NewRequest("GET", "/test", nil)
+			req.RemoteAddr = remoteAddr
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, expected, w.Code, remoteAddr)
+		}
+	})
+
 	t.Run("通配符允许所有IP", func(t *testing.T) {
 		allowedIPs := []string{"*"}
 
@@ -290,6 +339,50 @@ func TestRequestSizeLimit(t *testing.T) {
 		assert.Equal(t, 413, w.Code)
 		assert.Contains(t, w.Body.String(), "请求体过大")
 	})
+
+	t.Run("按路径前缀放宽上限", func(t *testing.T) {
+		router := gin.New()
+		router.Use(RequestSizeLimitByPath(10, map[string]int64{"/api/upload": 100}))
+		handler := func(c *gin.Context) {
+			c.JSON(200, gin.H{"message": "success"})
+		}
+		router.POST("/api/upload", handler)
+		router.POST("/api/other", handler)
+
+		send := func(path string, size int) int {
+			body := strings.Repeat("a", size)
+			req := httptest.NewRequest("POST", path, strings.NewReader(body))
+			req.ContentLength = int64(size)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w.Code
+		}
+
+		assert.Equal(t, 200, send("/api/upload", 50))
+		assert.Equal(t, 413, send("/api/upload", 150))
+		assert.Equal(t, 413, send("/api/other", 50))
+	})
+
+	t.Run("未声明长度的请求体读取超限时报错", func(t *testing.T) {
+		router := gin.New()
+		router.Use(RequestSizeLimit(10))
+		router.POST("/test", func(c *gin.Context) {
+			_, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				c.JSON(413, gin.H{"message": "too large"})
+				return
+			}
+			c.JSON(200, gin.H{"message": "success"})
+		})
+
+		req := httptest.NewRequest("POST", "/test", strings.NewReader(strings.Repeat("a", 50)))
+		req.ContentLength = -1
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, 413, w.Code)
+	})
 }
 
 func TestTimeout(t *testing.T) {
@@ -310,8 +403,31 @@ func TestTimeout(t *testing.T) {
 		assert.Equal(t, 200, w.Code)
 	})
 
-	// 注意：测试超时场景比较复杂，因为需要模拟长时间运行的处理器
-	// 在实际项目中，可能需要更复杂的测试设置
+	// serve 返回处理器收到的请求上下文
+	serve := func(timeout time.Duration) context.Context {
+		var ctx context.Context
+		router := gin.New()
+		router.Use(Timeout(timeout))
+		router.GET("/test", func(c *gin.Context) {
+			ctx = c.Request.Context()
+			c.Status(200)
+		})
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+		return ctx
+	}
+
+	t.Run("处理器的上下文带有超时时间，请求结束后取消", func(t *testing.T) {
+		ctx := serve(time.Minute)
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	})
+
+	t.Run("超时为 0 时不设置超时时间", func(t *testing.T) {
+		_, ok := serve(0).Deadline()
+		assert.False(t, ok)
+	})
 }
 
 func TestDefaultSecurityConfig(t *testing.T) {
@@ -13,7 +13,7 @@ type Admin struct {
 	Email     string         `gorm:"uniqueIndex;size:100;not null" json:"email" validate:"required,email"`
 	Password  string         `gorm:"size:255;not null" json:"-" validate:"required,min=6"`
 	Role      string         `gorm:"size:20;default:'admin'" json:"role" validate:"oneof=admin super_admin editor"`
-	Status    string         `gorm:"type:enum('active','inactive');default:'active'" json:"status"`
+	Status    string         `gorm:"size:20;default:'active'" json:"status" validate:"omitempty,oneof=active inactive"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -8,20 +8,22 @@ import (
 
 // Drama 短剧模型
 type Drama struct {
-	ID          uint           `gorm:"primaryKey" json:"id"`
-	Title       string         `gorm:"size:200;not null" json:"title" validate:"required,max=200"`
-	Description string         `gorm:"type:text" json:"description"`
-	CoverImage  string         `gorm:"size:255" json:"cover_image"`
-	Category    string         `gorm:"size:50;index" json:"category"`
-	Director    string         `gorm:"size:100" json:"director"`
-	Actors      string         `gorm:"type:json" json:"actors"`
-	Status      string         `gorm:"type:enum('draft','published','archived');default:'draft';index" json:"status" validate:"oneof=draft published archived"`
-	ViewCount   int64          `gorm:"default:0" json:"view_count"`
-	LikeCount   int64          `gorm:"default:0" json:"like_count"`
-	Rating      float64        `gorm:"type:decimal(3,2);default:0.00" json:"rating"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	Title        string         `gorm:"size:200;not null" json:"title" validate:"required,max=200"`
+	Description  string         `gorm:"type:text" json:"description"`
+	CoverImage   string         `gorm:"size:255" json:"cover_image"`
+	CoverAssetID *uint          `gorm:"index" json:"cover_asset_id,omitempty"` // 封面对应的媒体文件，引用外部地址时为空
+	Category     string         `gorm:"size:50;index" json:"category"`
+	Director     string         `gorm:"size:100" json:"director"`
+	Actors       string         `gorm:"type:json" json:"actors"`
+	Status       string         `gorm:"size:20;default:'draft';index" json:"status" validate:"oneof=draft in_review approved rejected scheduled published archived"`
+	PublishAt    *time.Time     `gorm:"index" json:"publish_at,omitempty"` // 定时发布时间，发布后为实际发布时间
+	ViewCount    int64          `gorm:"default:0" json:"view_count"`
+	LikeCount    int64          `gorm:"default:0" json:"like_count"`
+	Rating       float64        `gorm:"type:decimal(3,2);default:0.00" json:"rating"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// 关联关系
 	Episodes []Episode `gorm:"foreignKey:DramaID;constraint:OnDelete:CASCADE" json:"episodes,omitempty"`
@@ -43,6 +45,7 @@ func (d *Drama) ToJSON() map[string]interface{} {
 		"director":    d.Director,
 		"actors":      d.Actors,
 		"status":      d.Status,
+		"publish_at":  d.PublishAt,
 		"view_count":  d.ViewCount,
 		"like_count":  d.LikeCount,
 		"rating":      d.Rating,
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // 用户相关 DTO
 
 // RegisterRequest 用户注册请求
@@ -33,18 +35,19 @@ type CreateDramaRequest struct {
 	Director    string `json:"director" validate:"max=100"`
 	Actors      string `json:"actors" validate:"max=500"`
 	Category    string `json:"category" validate:"required,max=100"`
-	Status      string `json:"status" validate:"omitempty,oneof=draft published archived"`
+	Status      string `json:"status" validate:"omitempty,oneof=draft"` // 新建的短剧均为草稿，通过状态流转接口提交审核与发布
 }
 
-// UpdateDramaRequest 更新短剧请求
+// UpdateDramaRequest 更新短剧请求（PATCH 语义）
+// 未提供的字段保持不变，提供空字符串会清空该字段；标题与分类不能清空
 type UpdateDramaRequest struct {
-	Title       string `json:"title" validate:"omitempty,max=200"`
-	Description string `json:"description"`
-	CoverImage  string `json:"cover_image"`
-	Director    string `json:"director" validate:"omitempty,max=100"`
-	Actors      string `json:"actors" validate:"omitempty,max=500"`
-	Category    string `json:"category" validate:"omitempty,max=100"`
-	Status      string `json:"status" validate:"omitempty,oneof=draft published archived"`
+	Title       *string `json:"title" validate:"omitempty,min=1,max=200"`
+	Description *string `json:"description"`
+	CoverImage  *string `json:"cover_image"`
+	Director    *string `json:"director" validate:"omitempty,max=100"`
+	Actors      *string `json:"actors" validate:"omitempty,max=500"`
+	Category    *string `json:"category" validate:"omitempty,min=1,max=100"`
+	Status      *string `json:"status" validate:"omitempty,oneof=draft in_review approved rejected scheduled published archived"` // 只能为当前状态，变更状态使用状态流转接口
 }
 
 // 剧集相关 DTO
@@ -54,20 +57,138 @@ type CreateEpisodeRequest struct {
 	DramaID    uint   `json:"drama_id" validate:"required"`
 	Title      string `json:"title" validate:"required,max=200"`
 	EpisodeNum int    `json:"episode_num" validate:"required,min=1"`
-	Duration   int    `json:"duration" validate:"required,min=1"`
+	Duration   int    `json:"duration" validate:"omitempty,min=1"` // 时长（秒），为空时使用上传视频时解析出的时长
 	VideoURL   string `json:"video_url"`
 	Thumbnail  string `json:"thumbnail"`
-	Status     string `json:"status" validate:"omitempty,oneof=draft published archived"`
+	Status     string `json:"status" validate:"omitempty,oneof=draft"` // 新建的剧集均为草稿，通过状态流转接口提交审核与发布
+	Protected  bool   `json:"protected"`                               // 受保护的剧集只能通过加密的 HLS 播放
 }
 
-// UpdateEpisodeRequest 更新剧集请求
+// UpdateEpisodeRequest 更新剧集请求（PATCH 语义）
+// 未提供的字段保持不变，提供空字符串会清空视频或缩略图；标题、剧集号与时长不能清空
 type UpdateEpisodeRequest struct {
-	Title      string `json:"title" validate:"omitempty,max=200"`
-	EpisodeNum int    `json:"episode_num" validate:"omitempty,min=1"`
-	Duration   int    `json:"duration" validate:"omitempty,min=1"`
-	VideoURL   string `json:"video_url"`
-	Thumbnail  string `json:"thumbnail"`
-	Status     string `json:"status" validate:"omitempty,oneof=draft published archived"`
+	Title      *string `json:"title" validate:"omitempty,min=1,max=200"`
+	EpisodeNum *int    `json:"episode_num" validate:"omitempty,min=1"`
+	Duration   *int    `json:"duration" validate:"omitempty,min=1"`
+	VideoURL   *string `json:"video_url"`
+	Thumbnail  *string `json:"thumbnail"`
+	Status     *string `json:"status" validate:"omitempty,oneof=draft in_review approved rejected scheduled published archived"` // 只能为当前状态，变更状态使用状态流转接口
+	Protected  *bool   `json:"protected"`
+}
+
+// 审核流程相关 DTO
+
+// TransitionRequest 变更短剧或剧集状态请求
+type TransitionRequest struct {
+	Status    string     `json:"status" validate:"required,oneof=draft in_review approved rejected scheduled published archived"`
+	Comment   string     `json:"comment" validate:"max=500"` // 审核意见，审核不通过时必填
+	PublishAt *time.Time `json:"publish_at"`                 // 定时发布时间（RFC 3339），status 为 scheduled 时必填
+}
+
+// PreviewTokenResponse 内容预览令牌，持有者可以在前台接口中查看未发布的短剧或剧集
+type PreviewTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	URL       string    `json:"url"` // 携带预览令牌的前台地址
+}
+
+// 定时发布相关 DTO
+
+// ScheduleEpisodesRequest 批量排期请求：从 start_date 起每隔 interval_days 天，在 time 发布 per_day 集
+type ScheduleEpisodesRequest struct {
+	FromEpisode  int    `json:"from_episode" validate:"required,min=1"`
+	ToEpisode    int    `json:"to_episode" validate:"required,gtefield=FromEpisode"`
+	StartDate    string `json:"start_date" validate:"required,datetime=2006-01-02"`
+	Time         string `json:"time" validate:"required,datetime=15:04"`
+	PerDay       int    `json:"per_day" validate:"required,min=1,max=100"`
+	IntervalDays int    `json:"interval_days" validate:"omitempty,min=1,max=30"` // 默认每天发布
+}
+
+// 发布日历条目类型
+const (
+	ReleaseTypeDrama   = "drama"
+	ReleaseTypeEpisode = "episode"
+)
+
+// ReleaseCalendar 发布日历，按发布日期分组
+type ReleaseCalendar struct {
+	Timezone string       `json:"timezone"`
+	Days     []ReleaseDay `json:"days"`
+}
+
+// ReleaseDay 发布日历中的一天，条目按发布时间排列
+type ReleaseDay struct {
+	Date  string        `json:"date"`
+	Items []ReleaseItem `json:"items"`
+}
+
+// ReleaseItem 已排期或已发布的短剧、剧集
+type ReleaseItem struct {
+	Type       string    `json:"type"`
+	ID         uint      `json:"id"`
+	DramaID    uint      `json:"drama_id"`
+	DramaTitle string    `json:"drama_title"`
+	Title      string    `json:"title"`
+	EpisodeNum int       `json:"episode_num,omitempty"`
+	Status     string    `json:"status"`
+	PublishAt  time.Time `json:"publish_at"`
+}
+
+// 播放源类型
+const (
+	PlaybackTypeHLS = "hls"
+	PlaybackTypeMP4 = "mp4"
+)
+
+// PlaybackSource 剧集播放源，HLS 主播放列表包含所有码率档位，客户端不支持 HLS 时使用 MP4
+type PlaybackSource struct {
+	Type     string `json:"type"`
+	MimeType string `json:"mime_type"`
+	URL      string `json:"url"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	Bitrate  int64  `json:"bitrate,omitempty"` // bit/s，HLS 为最高档位的码率
+}
+
+// EpisodeDetail 剧集详情，以播放源列表代替原始视频地址
+type EpisodeDetail struct {
+	ID         uint             `json:"id"`
+	DramaID    uint             `json:"drama_id"`
+	Title      string           `json:"title"`
+	EpisodeNum int              `json:"episode_num"`
+	Duration   int              `json:"duration"`
+	Thumbnail  string           `json:"thumbnail"`
+	Status     string           `json:"status"`
+	ViewCount  int64            `json:"view_count"`
+	Sources    []PlaybackSource `json:"sources"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+	Drama      *Drama           `json:"drama,omitempty"`
+}
+
+// NewEpisodeDetail 根据剧集与播放源生成剧集详情
+func NewEpisodeDetail(episode *Episode, sources []PlaybackSource) *EpisodeDetail {
+	detail := &EpisodeDetail{
+		ID:         episode.ID,
+		DramaID:    episode.DramaID,
+		Title:      episode.Title,
+		EpisodeNum: episode.EpisodeNum,
+		Duration:   episode.Duration,
+		Thumbnail:  episode.Thumbnail,
+		Status:     episode.Status,
+		ViewCount:  episode.ViewCount,
+		Sources:    sources,
+		CreatedAt:  episode.CreatedAt,
+		UpdatedAt:  episode.UpdatedAt,
+	}
+	if detail.Sources == nil {
+		detail.Sources = []PlaybackSource{}
+	}
+	if episode.Drama.ID != 0 {
+		drama := episode.Drama
+		detail.Drama = &drama
+	}
+	return detail
 }
 
 // 管理员相关 DTO
@@ -106,8 +227,36 @@ type LoginResponse struct {
 // FileUploadResponse 文件上传响应
 type FileUploadResponse struct {
 	URL      string `json:"url"`
+	Path     string `json:"path"`
 	Filename string `json:"filename"`
 	Size     int64  `json:"size"`
+	// Variants 图片各规格（如 small、large）的访问地址，只有头像、封面、缩略图才有
+	Variants map[string]string `json:"variants,omitempty"`
+	// Deduplicated 已上传过内容相同的文件，返回的是已有文件
+	Deduplicated bool `json:"deduplicated,omitempty"`
+}
+
+// CreateUploadSessionRequest 创建分片上传会话请求
+type CreateUploadSessionRequest struct {
+	Filename string `json:"filename" validate:"required,max=255"`
+	Size     int64  `json:"size" validate:"required,min=1"`
+	Type     string `json:"type" validate:"omitempty,oneof=avatar cover video thumbnail others"`
+	SHA256   string `json:"sha256" validate:"omitempty,len=64,hexadecimal"` // 完整文件的 SHA-256，合并后校验
+}
+
+// UploadSessionResponse 分片上传会话状态，断线后通过 UploadedParts 确定需要续传的分片
+type UploadSessionResponse struct {
+	ID            string              `json:"id"`
+	Filename      string              `json:"filename"`
+	Type          string              `json:"type"`
+	Status        string              `json:"status"`
+	Size          int64               `json:"size"`
+	ChunkSize     int64               `json:"chunk_size"`
+	TotalChunks   int                 `json:"total_chunks"`
+	UploadedParts []int               `json:"uploaded_parts"`
+	UploadedSize  int64               `json:"uploaded_size"`
+	ExpiresAt     time.Time           `json:"expires_at"`
+	File          *FileUploadResponse `json:"file,omitempty"`
 }
 
 // 分页相关 DTO
@@ -156,6 +305,58 @@ type PaginatedAdmins struct {
 	HasPrevious bool    `json:"has_previous"`
 }
 
+// TrashItem 回收站中的短剧或剧集
+type TrashItem struct {
+	Type       string    `json:"type"` // drama | episode
+	ID         uint      `json:"id"`
+	DramaID    uint      `json:"drama_id,omitempty"`
+	Title      string    `json:"title"`
+	EpisodeNum int       `json:"episode_num,omitempty"`
+	Status     string    `json:"status"`
+	DeletedAt  time.Time `json:"deleted_at"`
+	PurgeAt    time.Time `json:"purge_at"` // 超过保留期后永久删除的时间
+}
+
+// PaginatedTrash 分页回收站响应
+type PaginatedTrash struct {
+	Items       []TrashItem `json:"items"`
+	Total       int64       `json:"total"`
+	Page        int         `json:"page"`
+	PageSize    int         `json:"page_size"`
+	TotalPages  int         `json:"total_pages"`
+	HasNext     bool        `json:"has_next"`
+	HasPrevious bool        `json:"has_previous"`
+}
+
+// 修订版本相关 DTO
+
+// PaginatedRevisions 分页修订版本响应
+type PaginatedRevisions struct {
+	Items       []ContentRevision `json:"items"`
+	Total       int64             `json:"total"`
+	Page        int               `json:"page"`
+	PageSize    int               `json:"page_size"`
+	TotalPages  int               `json:"total_pages"`
+	HasNext     bool              `json:"has_next"`
+	HasPrevious bool              `json:"has_previous"`
+}
+
+// FieldChange 两个修订版本之间一个字段的变化
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// RevisionDiff 两个修订版本之间的差异，只包含有变化的字段
+type RevisionDiff struct {
+	EntityType  string        `json:"entity_type"`
+	EntityID    uint          `json:"entity_id"`
+	FromVersion int           `json:"from_version"`
+	ToVersion   int           `json:"to_version"`
+	Changes     []FieldChange `json:"changes"`
+}
+
 // JWTClaims JWT 声明结构（从 utils 包导入）
 type JWTClaims struct {
 	UserID   uint   `json:"user_id"`
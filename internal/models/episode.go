@@ -9,18 +9,22 @@ import (
 
 // Episode 剧集模型
 type Episode struct {
-	ID         uint           `gorm:"primaryKey" json:"id"`
-	DramaID    uint           `gorm:"not null;index" json:"drama_id" validate:"required"`
-	Title      string         `gorm:"size:200;not null" json:"title" validate:"required,max=200"`
-	EpisodeNum int            `gorm:"not null;index" json:"episode_num" validate:"required,min=1"`
-	Duration   int            `gorm:"not null" json:"duration" validate:"required,min=1"` // 时长（秒）
-	VideoURL   string         `gorm:"size:500" json:"video_url"`
-	Thumbnail  string         `gorm:"size:255" json:"thumbnail"`
-	Status     string         `gorm:"type:enum('draft','published','archived');default:'draft';index" json:"status" validate:"oneof=draft published archived"`
-	ViewCount  int64          `gorm:"default:0" json:"view_count"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	DramaID          uint           `gorm:"not null;index" json:"drama_id" validate:"required"`
+	Title            string         `gorm:"size:200;not null" json:"title" validate:"required,max=200"`
+	EpisodeNum       int            `gorm:"not null;index" json:"episode_num" validate:"required,min=1"`
+	Duration         int            `gorm:"not null" json:"duration" validate:"required,min=1"` // 时长（秒）
+	VideoURL         string         `gorm:"size:500" json:"video_url"`
+	Thumbnail        string         `gorm:"size:255" json:"thumbnail"`
+	VideoAssetID     *uint          `gorm:"index" json:"video_asset_id,omitempty"` // 视频对应的媒体文件，引用外部地址时为空
+	ThumbnailAssetID *uint          `gorm:"index" json:"thumbnail_asset_id,omitempty"`
+	Status           string         `gorm:"size:20;default:'draft';index" json:"status" validate:"oneof=draft in_review approved rejected scheduled published archived"`
+	PublishAt        *time.Time     `gorm:"index" json:"publish_at,omitempty"` // 定时发布时间，发布后为实际发布时间
+	ViewCount        int64          `gorm:"default:0" json:"view_count"`
+	Protected        bool           `gorm:"default:false" json:"protected"` // 是否使用 AES-128 加密 HLS 分片
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// 关联关系
 	Drama Drama `gorm:"foreignKey:DramaID;constraint:OnDelete:CASCADE" json:"drama,omitempty"`
@@ -42,7 +46,9 @@ func (e *Episode) ToJSON() map[string]interface{} {
 		"video_url":   e.VideoURL,
 		"thumbnail":   e.Thumbnail,
 		"status":      e.Status,
+		"publish_at":  e.PublishAt,
 		"view_count":  e.ViewCount,
+		"protected":   e.Protected,
 		"created_at":  e.CreatedAt,
 		"updated_at":  e.UpdatedAt,
 	}
@@ -11,6 +11,14 @@ func AllModels() []interface{} {
 		&Drama{},
 		&Episode{},
 		&Admin{},
+		&UploadSession{},
+		&UploadPart{},
+		&MediaAsset{},
+		&TranscodeJob{},
+		&EpisodeRendition{},
+		&EpisodeKey{},
+		&StatusTransition{},
+		&ContentRevision{},
 	}
 }
 
@@ -21,14 +29,18 @@ func AutoMigrate(db *gorm.DB) error {
 
 // CreateIndexes 创建额外的索引
 func CreateIndexes(db *gorm.DB) error {
-	// 为 dramas 表创建复合索引
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_dramas_genre_status ON dramas(genre, status)").Error; err != nil {
-		return err
+	// 为 dramas 表创建复合索引（CREATE INDEX IF NOT EXISTS 不被 MySQL 支持）
+	if !db.Migrator().HasIndex(&Drama{}, "idx_dramas_category_status") {
+		if err := db.Exec("CREATE INDEX idx_dramas_category_status ON dramas(category, status)").Error; err != nil {
+			return err
+		}
 	}
 
 	// 为 episodes 表创建复合索引
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_episodes_drama_episode ON episodes(drama_id, episode_num)").Error; err != nil {
-		return err
+	if !db.Migrator().HasIndex(&Episode{}, "idx_episodes_drama_episode") {
+		if err := db.Exec("CREATE INDEX idx_episodes_drama_episode ON episodes(drama_id, episode_num)").Error; err != nil {
+			return err
+		}
 	}
 
 	return nil
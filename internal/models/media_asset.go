@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// MediaAsset 已上传的媒体文件，记录文件的上传者，普通用户只能删除自己上传的文件
+// 短剧封面、剧集视频与用户头像通过 *AssetID 引用媒体文件
+type MediaAsset struct {
+	ID          uint   `gorm:"primaryKey" json:"id"`
+	OwnerID     uint   `gorm:"not null;index:idx_media_assets_owner" json:"owner_id"`
+	OwnerRole   string `gorm:"size:20;not null;index:idx_media_assets_owner" json:"owner_role"`
+	Type        string `gorm:"size:20;not null" json:"type"`
+	StorageKey  string `gorm:"size:500;not null;uniqueIndex" json:"path"`
+	Filename    string `gorm:"size:255" json:"filename"` // 客户端上传时的原始文件名
+	Size        int64  `gorm:"not null" json:"size"`
+	ContentType string `gorm:"size:100" json:"content_type"`
+	Checksum    string `gorm:"size:64;index" json:"checksum"` // 文件内容的十六进制 SHA-256，用于去重
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	Duration    int    `json:"duration,omitempty"` // 视频时长（秒）
+	VideoCodec  string `gorm:"size:20" json:"video_codec,omitempty"`
+	AudioCodec  string `gorm:"size:20" json:"audio_codec,omitempty"`
+	Bitrate     int64  `json:"bitrate,omitempty"` // 平均码率（bit/s）
+	// UnreferencedSince 文件不再被剧集、用户等引用的时间，为空表示仍被引用，超过保留期后由垃圾回收删除
+	UnreferencedSince *time.Time `gorm:"index" json:"-"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (MediaAsset) TableName() string {
+	return "media_assets"
+}
+
+// IsOwnedBy 文件是否由指定用户上传，用户与管理员的 ID 相互独立，需要同时比较角色
+func (a *MediaAsset) IsOwnedBy(ownerID uint, role string) bool {
+	return a.OwnerID == ownerID && a.OwnerRole == role
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+// 短剧与剧集的状态
+// 审核流程：draft → in_review → approved → published（或 scheduled 到期后发布），审核不通过为 rejected
+const (
+	StatusDraft     = "draft"
+	StatusInReview  = "in_review"
+	StatusApproved  = "approved"
+	StatusRejected  = "rejected"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
+// 管理员角色，权限从低到高
+const (
+	AdminRoleEditor     = "editor"
+	AdminRoleAdmin      = "admin"
+	AdminRoleSuperAdmin = "super_admin"
+)
+
+// 状态流转记录的内容类型
+const (
+	ReviewEntityDrama   = "drama"
+	ReviewEntityEpisode = "episode"
+)
+
+// StatusTransition 短剧或剧集的一次状态流转记录
+// AdminID 为空表示由系统执行（如定时发布）
+type StatusTransition struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	EntityType string     `gorm:"size:20;not null;index:idx_status_transitions_entity" json:"entity_type"`
+	EntityID   uint       `gorm:"not null;index:idx_status_transitions_entity" json:"entity_id"`
+	FromStatus string     `gorm:"size:20;not null" json:"from_status"`
+	ToStatus   string     `gorm:"size:20;not null" json:"to_status"`
+	Comment    string     `gorm:"size:500" json:"comment,omitempty"`
+	PublishAt  *time.Time `json:"publish_at,omitempty"`
+	AdminID    *uint      `gorm:"index" json:"admin_id,omitempty"`
+	AdminName  string     `gorm:"size:50" json:"admin_name,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName 指定表名
+func (StatusTransition) TableName() string {
+	return "status_transitions"
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// ContentRevision 短剧或剧集的一个修订版本，保存更新后可编辑字段的完整快照
+// Version 在同一内容内从 1 递增；AdminID 为空表示首次更新前已有的内容（作者未知）
+type ContentRevision struct {
+	ID         uint                   `gorm:"primaryKey" json:"id"`
+	EntityType string                 `gorm:"size:20;not null;uniqueIndex:idx_content_revisions_version,priority:1" json:"entity_type"`
+	EntityID   uint                   `gorm:"not null;uniqueIndex:idx_content_revisions_version,priority:2" json:"entity_id"`
+	Version    int                    `gorm:"not null;uniqueIndex:idx_content_revisions_version,priority:3" json:"version"`
+	Snapshot   map[string]interface{} `gorm:"type:text;not null;serializer:json" json:"snapshot"`
+	AdminID    *uint                  `gorm:"index" json:"admin_id,omitempty"`
+	AdminName  string                 `gorm:"size:50" json:"admin_name,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// TableName 指定表名
+func (ContentRevision) TableName() string {
+	return "content_revisions"
+}
+
+// DramaSnapshot 短剧可编辑字段的快照
+type DramaSnapshot struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	CoverImage  string `json:"cover_image"`
+	Director    string `json:"director"`
+	Actors      string `json:"actors"`
+	Category    string `json:"category"`
+}
+
+// EpisodeSnapshot 剧集可编辑字段的快照
+type EpisodeSnapshot struct {
+	Title      string `json:"title"`
+	EpisodeNum int    `json:"episode_num"`
+	Duration   int    `json:"duration"`
+	VideoURL   string `json:"video_url"`
+	Thumbnail  string `json:"thumbnail"`
+	Protected  bool   `json:"protected"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+)
+
+// 转码任务状态
+const (
+	TranscodeStatusPending   = "pending"
+	TranscodeStatusRunning   = "running"
+	TranscodeStatusCompleted = "completed"
+	TranscodeStatusFailed    = "failed"
+)
+
+// TranscodeJob 剧集视频转码任务
+// 剧集创建或更换视频后入队，由后台任务领取并按码率阶梯转码为 HLS，失败后重试到最大尝试次数
+type TranscodeJob struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	EpisodeID     uint       `gorm:"not null;index" json:"episode_id"`
+	SourceAssetID uint       `gorm:"not null" json:"source_asset_id"`
+	SourceKey     string     `gorm:"size:500;not null" json:"source_key"`
+	Status        string     `gorm:"size:20;default:'pending';index" json:"status"`
+	Attempts      int        `gorm:"default:0" json:"attempts"`
+	Error         string     `gorm:"size:1000" json:"error,omitempty"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (TranscodeJob) TableName() string {
+	return "transcode_jobs"
+}
+
+// EpisodeRendition 剧集视频的一档 HLS 转码结果，PlaylistKey 为媒体播放列表的存储路径，分片与播放列表位于同一目录
+type EpisodeRendition struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	EpisodeID   uint      `gorm:"not null;index" json:"episode_id"`
+	JobID       uint      `gorm:"not null" json:"job_id"`
+	Name        string    `gorm:"size:20;not null" json:"name"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Bitrate     int64     `json:"bitrate"` // 峰值码率（bit/s）
+	Codecs      string    `gorm:"size:100" json:"codecs"`
+	PlaylistKey string    `gorm:"size:500;not null" json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (EpisodeRendition) TableName() string {
+	return "episode_renditions"
+}
+
+// EpisodeKey 加密剧集 HLS 分片的 AES-128 内容密钥，每个转码任务生成一个
+// 密钥使用主密钥加密后保存，MasterKeyID 为加密所用的主密钥，轮换主密钥后重新加密
+type EpisodeKey struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	EpisodeID    uint      `gorm:"not null;index" json:"episode_id"`
+	JobID        uint      `gorm:"not null;index" json:"job_id"`
+	MasterKeyID  string    `gorm:"size:32;not null;index" json:"-"`
+	EncryptedKey string    `gorm:"size:128;not null" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (EpisodeKey) TableName() string {
+	return "episode_keys"
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+)
+
+// 上传会话状态
+const (
+	UploadStatusUploading  = "uploading"
+	UploadStatusCompleting = "completing"
+	UploadStatusCompleted  = "completed"
+)
+
+// UploadSession 分片上传会话
+// 分片先写入存储的临时目录，全部上传后按顺序合并为最终文件；会话过期后由后台任务清理
+type UploadSession struct {
+	ID          string    `gorm:"primaryKey;size:32" json:"id"`
+	OwnerID     uint      `gorm:"not null;index:idx_upload_sessions_owner" json:"-"`
+	OwnerRole   string    `gorm:"size:20;not null;index:idx_upload_sessions_owner" json:"-"`
+	Filename    string    `gorm:"size:255;not null" json:"filename"`
+	UploadType  string    `gorm:"size:20;not null" json:"type"`
+	Size        int64     `gorm:"not null" json:"size"`
+	ChunkSize   int64     `gorm:"not null" json:"chunk_size"`
+	TotalChunks int       `gorm:"not null" json:"total_chunks"`
+	Checksum    string    `gorm:"size:64" json:"sha256,omitempty"` // 完整文件的 SHA-256（可选）
+	Status      string    `gorm:"size:20;default:'uploading';index" json:"status"`
+	Path        string    `gorm:"size:500" json:"path,omitempty"` // 合并后的文件路径
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// 关联关系
+	Parts []UploadPart `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TableName 指定表名
+func (UploadSession) TableName() string {
+	return "upload_sessions"
+}
+
+// PartSize 获取指定分片（从 1 开始）应有的大小，最后一片为剩余大小
+func (s *UploadSession) PartSize(partNumber int) int64 {
+	if partNumber < s.TotalChunks {
+		return s.ChunkSize
+	}
+	return s.Size - int64(s.TotalChunks-1)*s.ChunkSize
+}
+
+// IsExpired 会话是否已过期
+func (s *UploadSession) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+// UploadPart 已上传的分片
+type UploadPart struct {
+	SessionID  string    `gorm:"primaryKey;size:32" json:"-"`
+	PartNumber int       `gorm:"primaryKey;autoIncrement:false" json:"part_number"`
+	Size       int64     `gorm:"not null" json:"size"`
+	Checksum   string    `gorm:"size:64;not null" json:"sha256"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (UploadPart) TableName() string {
+	return "upload_parts"
+}
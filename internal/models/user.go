@@ -13,6 +13,7 @@ type User struct {
 	Password  string         `gorm:"size:255;not null" json:"-" validate:"required,min=6"`
 	Phone     string         `gorm:"size:20" json:"phone" validate:"omitempty,len=11"`
 	Avatar    string         `gorm:"size:255" json:"avatar"`
+	AvatarAssetID *uint      `gorm:"index" json:"avatar_asset_id,omitempty"` // 头像对应的媒体文件，引用外部地址时为空
 	IsActive  bool           `gorm:"default:true" json:"is_active"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -86,6 +86,10 @@ func getErrorMessage(err validator.FieldError) string {
 		return fmt.Sprintf("%s 长度必须为 %s", field, param)
 	case "oneof":
 		return fmt.Sprintf("%s 必须是以下值之一: %s", field, param)
+	case "datetime":
+		return fmt.Sprintf("%s 格式必须为 %s", field, param)
+	case "gtefield":
+		return fmt.Sprintf("%s 不能小于 %s", field, param)
 	default:
 		return fmt.Sprintf("%s 验证失败", field)
 	}
@@ -273,4 +273,22 @@ func TestRealModelValidation(t *testing.T) {
 		errors := ValidateStruct(req)
 		assert.Empty(t, errors)
 	})
+	t.Run("批量排期请求验证", func(t *testing.T) {
+		req := ScheduleEpisodesRequest{
+			FromEpisode: 11,
+			ToEpisode:   80,
+			StartDate:   "2026-10-20",
+			Time:        "20:00",
+			PerDay:      3,
+		}
+		assert.Empty(t, ValidateStruct(req))
+
+		req.ToEpisode = 10
+		req.Time = "8pm"
+		errors := ValidateStruct(req)
+		assert.Len(t, errors, 2)
+		assert.Equal(t, "to_episode", errors[0].Field)
+		assert.Equal(t, "gtefield", errors[0].Tag)
+		assert.Equal(t, "time 格式必须为 15:04", errors[1].Message)
+	})
 }
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"gorm.io/gorm"
 	"gin-mysql-api/internal/models"
@@ -16,6 +17,11 @@ func NewAdminRepository(db *gorm.DB) AdminRepository {
 	return &adminRepository{db: db}
 }
 
+// WithContext 返回绑定上下文的管理员仓库
+func (r *adminRepository) WithContext(ctx context.Context) AdminRepository {
+	return &adminRepository{db: r.db.WithContext(ctx)}
+}
+
 // Create 创建管理员
 func (r *adminRepository) Create(admin *models.Admin) error {
 	return r.db.Create(admin).Error
@@ -198,7 +198,7 @@ func (suite *AdminRepositoryTestSuite) TestExistsByUsername() {
 	assert.True(suite.T(), exists)
 
 	// 测试不存在的用户名
-	exists, err := suite.repo.ExistsByUsername("nonexistent")
+	exists, err = suite.repo.ExistsByUsername("nonexistent")
 	assert.NoError(suite.T(), err)
 	assert.False(suite.T(), exists)
 }
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"gin-mysql-api/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -17,6 +19,11 @@ func NewDramaRepository(db *gorm.DB) DramaRepository {
 	return &dramaRepository{db: db}
 }
 
+// WithContext 返回绑定上下文的短剧仓库
+func (r *dramaRepository) WithContext(ctx context.Context) DramaRepository {
+	return &dramaRepository{db: r.db.WithContext(ctx)}
+}
+
 // Create 创建短剧
 func (r *dramaRepository) Create(drama *models.Drama) error {
 	return r.db.Create(drama).Error
@@ -34,10 +41,24 @@ func (r *dramaRepository) GetByID(id uint) (*models.Drama, error) {
 	return &drama, nil
 }
 
-// GetByIDWithEpisodes 根据ID获取短剧（包含剧集信息）
+// GetPublishedByID 根据ID获取已发布的短剧
+func (r *dramaRepository) GetPublishedByID(id uint) (*models.Drama, error) {
+	var drama models.Drama
+	if err := r.db.Where("status = ?", models.StatusPublished).First(&drama, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &drama, nil
+}
+
+// GetByIDWithEpisodes 根据ID获取已发布的短剧（包含已发布的剧集）
 func (r *dramaRepository) GetByIDWithEpisodes(id uint) (*models.Drama, error) {
 	var drama models.Drama
-	if err := r.db.Preload("Episodes", "status = ?", "published").First(&drama, id).Error; err != nil {
+	if err := r.db.Preload("Episodes", func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ?", models.StatusPublished).Order("episode_num ASC")
+	}).Where("status = ?", models.StatusPublished).First(&drama, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -55,7 +76,7 @@ func (r *dramaRepository) GetList(offset, limit int, genre string) ([]models.Dra
 
 	// 如果指定了类型，添加筛选条件
 	if genre != "" {
-		query = query.Where("genre = ?", genre)
+		query = query.Where("category = ?", genre)
 	}
 
 	// 获取总数
@@ -76,9 +97,15 @@ func (r *dramaRepository) Update(drama *models.Drama) error {
 	return r.db.Save(drama).Error
 }
 
-// Delete 删除短剧（软删除）
+// Delete 在一个事务中软删除短剧及其剧集，使用相同的删除时间，从回收站恢复短剧时据此恢复一同删除的剧集
 func (r *dramaRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Drama{}, id).Error
+	now := r.db.NowFunc()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Episode{}).Where("drama_id = ?", id).Update("deleted_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Drama{}).Where("id = ?", id).Update("deleted_at", now).Error
+	})
 }
 
 // IncrementViewCount 增加观看次数
@@ -128,3 +155,29 @@ func (r *dramaRepository) GetActiveList(offset, limit int) ([]models.Drama, int6
 
 	return dramas, total, nil
 }
+
+// ListDue 获取到期的定时发布短剧，按发布时间排序
+// 发布时间以 UTC 保存，SQLite 以文本比较时间，查询参数同样转换为 UTC
+func (r *dramaRepository) ListDue(now time.Time, limit int) ([]models.Drama, error) {
+	var dramas []models.Drama
+	if err := r.db.Where("status = ? AND publish_at <= ?", "scheduled", now.UTC()).
+		Order("publish_at ASC, id ASC").Limit(limit).Find(&dramas).Error; err != nil {
+		return nil, err
+	}
+	return dramas, nil
+}
+
+// Publish 按状态条件更新，多个实例同时发布时只有一个成功
+func (r *dramaRepository) Publish(id uint) (bool, error) {
+	return publishScheduled(r.db, models.ReviewEntityDrama, id)
+}
+
+// ListPublishBetween 获取时间范围内已排期与已发布的短剧
+func (r *dramaRepository) ListPublishBetween(from, to time.Time) ([]models.Drama, error) {
+	var dramas []models.Drama
+	if err := r.db.Where("status IN ? AND publish_at >= ? AND publish_at < ?", []string{"scheduled", "published"}, from.UTC(), to.UTC()).
+		Order("publish_at ASC, id ASC").Find(&dramas).Error; err != nil {
+		return nil, err
+	}
+	return dramas, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"testing"
+	"time"
 
 	"gin-mysql-api/internal/models"
 	"gin-mysql-api/internal/testutil"
@@ -59,11 +60,12 @@ func (suite *DramaRepositoryTestSuite) TestGetByID() {
 	foundDrama, err := suite.repo.GetByID(drama.ID)
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), drama.Title, foundDrama.Title)
-	assert.Equal(suite.T(), drama.Genre, foundDrama.Genre)
+	assert.Equal(suite.T(), drama.Category, foundDrama.Category)
 
 	// 测试不存在的短剧
-	_, err = suite.repo.GetByID(999)
-	assert.Error(suite.T(), err)
+	foundDrama, err = suite.repo.GetByID(999)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), foundDrama)
 }
 
 // TestGetByIDWithEpisodes 测试获取短剧及其剧集
@@ -86,6 +88,30 @@ func (suite *DramaRepositoryTestSuite) TestGetByIDWithEpisodes() {
 	assert.NoError(suite.T(), err)
 	assert.Equal(suite.T(), drama.Title, foundDrama.Title)
 	assert.Len(suite.T(), foundDrama.Episodes, 3)
+
+	// 未发布的短剧视为不存在
+	suite.Require().NoError(suite.db.Model(drama).Update("status", "in_review").Error)
+	foundDrama, err = suite.repo.GetByIDWithEpisodes(drama.ID)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), foundDrama)
+}
+
+// TestGetPublishedByID 测试获取已发布的短剧
+func (suite *DramaRepositoryTestSuite) TestGetPublishedByID() {
+	published := suite.factory.Drama.CreateDrama()
+	approved := suite.factory.Drama.CreateDrama(func(d *models.Drama) { d.Status = "approved" })
+	for _, drama := range []*models.Drama{published, approved} {
+		suite.Require().NoError(suite.repo.Create(drama))
+	}
+
+	found, err := suite.repo.GetPublishedByID(published.ID)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(found)
+	assert.Equal(suite.T(), published.ID, found.ID)
+
+	found, err = suite.repo.GetPublishedByID(approved.ID)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), found, "审核通过但未发布")
 }
 
 // TestGetList 测试获取短剧列表
@@ -114,11 +140,11 @@ func (suite *DramaRepositoryTestSuite) TestGetList() {
 func (suite *DramaRepositoryTestSuite) TestGetByGenre() {
 	// 创建不同类型的短剧
 	comedyDrama := suite.factory.Drama.CreateDrama(func(d *models.Drama) {
-		d.Genre = "喜剧"
+		d.Category = "喜剧"
 		d.Title = "喜剧短剧"
 	})
 	actionDrama := suite.factory.Drama.CreateDrama(func(d *models.Drama) {
-		d.Genre = "动作"
+		d.Category = "动作"
 		d.Title = "动作短剧"
 	})
 
@@ -146,11 +172,11 @@ func (suite *DramaRepositoryTestSuite) TestGetByGenre() {
 func (suite *DramaRepositoryTestSuite) TestGetActiveList() {
 	// 创建不同状态的短剧
 	activeDrama := suite.factory.Drama.CreateDrama(func(d *models.Drama) {
-		d.Status = "active"
+		d.Status = "published"
 		d.Title = "激活短剧"
 	})
 	inactiveDrama := suite.factory.Drama.CreateDrama(func(d *models.Drama) {
-		d.Status = "inactive"
+		d.Status = "draft"
 		d.Title = "禁用短剧"
 	})
 
@@ -199,8 +225,9 @@ func (suite *DramaRepositoryTestSuite) TestDelete() {
 	assert.NoError(suite.T(), err)
 
 	// 验证删除
-	_, err = suite.repo.GetByID(drama.ID)
-	assert.Error(suite.T(), err)
+	foundDrama, err := suite.repo.GetByID(drama.ID)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), foundDrama)
 }
 
 // TestIncrementViewCount 测试增加观看次数
@@ -222,6 +249,35 @@ func (suite *DramaRepositoryTestSuite) TestIncrementViewCount() {
 	assert.Equal(suite.T(), initialViewCount+1, updatedDrama.ViewCount)
 }
 
+// TestScheduledPublishing 测试定时发布相关查询
+func (suite *DramaRepositoryTestSuite) TestScheduledPublishing() {
+	now := time.Now().UTC()
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	due := suite.factory.Drama.CreateDrama(func(d *models.Drama) { d.Status, d.PublishAt = "scheduled", &past })
+	later := suite.factory.Drama.CreateDrama(func(d *models.Drama) { d.Status, d.PublishAt = "scheduled", &future })
+	draft := suite.factory.Drama.CreateDrama(func(d *models.Drama) { d.Status, d.PublishAt = "draft", &past })
+	for _, drama := range []*models.Drama{due, later, draft} {
+		suite.Require().NoError(suite.repo.Create(drama))
+	}
+
+	dramas, err := suite.repo.ListDue(now, 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(dramas, 1)
+	assert.Equal(suite.T(), due.ID, dramas[0].ID)
+
+	published, err := suite.repo.Publish(due.ID)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), published)
+	published, err = suite.repo.Publish(draft.ID)
+	suite.Require().NoError(err)
+	assert.False(suite.T(), published, "只发布定时发布的短剧")
+
+	dramas, err = suite.repo.ListPublishBetween(now.Add(-time.Hour), now.Add(2*time.Hour))
+	suite.Require().NoError(err)
+	suite.Require().Len(dramas, 2)
+	assert.Equal(suite.T(), []uint{due.ID, later.ID}, []uint{dramas[0].ID, dramas[1].ID})
+}
+
 // TestDramaRepositoryTestSuite 运行短剧仓库测试套件
 func TestDramaRepositoryTestSuite(t *testing.T) {
 	suite.Run(t, new(DramaRepositoryTestSuite))
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"gin-mysql-api/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -17,6 +19,11 @@ func NewEpisodeRepository(db *gorm.DB) EpisodeRepository {
 	return &episodeRepository{db: db}
 }
 
+// WithContext 返回绑定上下文的剧集仓库
+func (r *episodeRepository) WithContext(ctx context.Context) EpisodeRepository {
+	return &episodeRepository{db: r.db.WithContext(ctx)}
+}
+
 // Create 创建剧集
 func (r *episodeRepository) Create(episode *models.Episode) error {
 	return r.db.Create(episode).Error
@@ -46,6 +53,20 @@ func (r *episodeRepository) GetByIDWithDrama(id uint) (*models.Episode, error) {
 	return &episode, nil
 }
 
+// GetPublishedByIDWithDrama 根据ID获取已发布的剧集（包含短剧信息），所属短剧未发布时同样视为不存在
+func (r *episodeRepository) GetPublishedByIDWithDrama(id uint) (*models.Episode, error) {
+	var episode models.Episode
+	if err := r.db.Joins("Drama").
+		Where("episodes.status = ? AND Drama.status = ?", models.StatusPublished, models.StatusPublished).
+		First(&episode, "episodes.id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &episode, nil
+}
+
 // GetByDramaID 根据短剧ID获取所有剧集
 func (r *episodeRepository) GetByDramaID(dramaID uint) ([]models.Episode, error) {
 	var episodes []models.Episode
@@ -77,6 +98,23 @@ func (r *episodeRepository) GetByDramaIDPaginated(dramaID uint, offset, limit in
 	return episodes, total, nil
 }
 
+// GetPublishedByDramaIDPaginated 根据短剧ID获取已发布的剧集列表（分页）
+func (r *episodeRepository) GetPublishedByDramaIDPaginated(dramaID uint, offset, limit int) ([]models.Episode, int64, error) {
+	var episodes []models.Episode
+	var total int64
+
+	query := r.db.Model(&models.Episode{}).Where("drama_id = ? AND status = ?", dramaID, models.StatusPublished)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("episode_num ASC").
+		Offset(offset).Limit(limit).Find(&episodes).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return episodes, total, nil
+}
+
 // GetList 获取所有剧集列表（分页）
 func (r *episodeRepository) GetList(offset, limit int) ([]models.Episode, int64, error) {
 	var episodes []models.Episode
@@ -136,3 +174,60 @@ func (r *episodeRepository) ExistsByDramaIDAndEpisodeNum(dramaID uint, episodeNu
 	}
 	return count > 0, nil
 }
+
+// ExistsProtectedByVideoAssetID 是否有受保护的剧集使用该媒体文件作为视频，回收站中的剧集同样计入
+func (r *episodeRepository) ExistsProtectedByVideoAssetID(assetID uint) (bool, error) {
+	var count int64
+	if err := r.db.Unscoped().Model(&models.Episode{}).
+		Where("video_asset_id = ? AND protected = ?", assetID, true).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ListByDramaID 按剧集号顺序获取短剧中所有状态的剧集
+func (r *episodeRepository) ListByDramaID(dramaID uint) ([]models.Episode, error) {
+	var episodes []models.Episode
+	if err := r.db.Where("drama_id = ?", dramaID).Order("episode_num ASC").Find(&episodes).Error; err != nil {
+		return nil, err
+	}
+	return episodes, nil
+}
+
+// ListByEpisodeNumRange 获取剧集号范围内的剧集
+func (r *episodeRepository) ListByEpisodeNumRange(dramaID uint, from, to int) ([]models.Episode, error) {
+	var episodes []models.Episode
+	if err := r.db.Where("drama_id = ? AND episode_num BETWEEN ? AND ?", dramaID, from, to).
+		Order("episode_num ASC").Find(&episodes).Error; err != nil {
+		return nil, err
+	}
+	return episodes, nil
+}
+
+// ListDue 获取到期的定时发布剧集（包含短剧信息），按发布时间与剧集号排序
+// 发布时间以 UTC 保存，SQLite 以文本比较时间，查询参数同样转换为 UTC
+func (r *episodeRepository) ListDue(now time.Time, limit int) ([]models.Episode, error) {
+	var episodes []models.Episode
+	if err := r.db.Preload("Drama").Where("status = ? AND publish_at <= ?", "scheduled", now.UTC()).
+		Order("publish_at ASC, episode_num ASC").Limit(limit).Find(&episodes).Error; err != nil {
+		return nil, err
+	}
+	return episodes, nil
+}
+
+// Publish 按状态条件更新，多个实例同时发布时只有一个成功
+func (r *episodeRepository) Publish(id uint) (bool, error) {
+	return publishScheduled(r.db, models.ReviewEntityEpisode, id)
+}
+
+// ListPublishBetween 获取时间范围内已排期与已发布的剧集
+func (r *episodeRepository) ListPublishBetween(from, to time.Time) ([]models.Episode, error) {
+	var episodes []models.Episode
+	if err := r.db.Preload("Drama").
+		Where("status IN ? AND publish_at >= ? AND publish_at < ?", []string{"scheduled", "published"}, from.UTC(), to.UTC()).
+		Order("publish_at ASC, drama_id ASC, episode_num ASC").Find(&episodes).Error; err != nil {
+		return nil, err
+	}
+	return episodes, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"testing"
+	"time"
 
 	"gin-mysql-api/internal/models"
 	"gin-mysql-api/internal/testutil"
@@ -239,6 +240,110 @@ func (suite *EpisodeRepositoryTestSuite) TestExistsByDramaIDAndEpisodeNum() {
 	assert.False(suite.T(), exists)
 }
 
+// TestExistsProtectedByVideoAssetID 测试检查媒体文件是否被受保护的剧集使用
+func (suite *EpisodeRepositoryTestSuite) TestExistsProtectedByVideoAssetID() {
+	protectedAsset, plainAsset := uint(10), uint(20)
+	protected := suite.factory.Episode.CreateEpisode(suite.testDrama.ID, func(e *models.Episode) {
+		e.EpisodeNum = 1
+		e.VideoAssetID = &protectedAsset
+		e.Protected = true
+	})
+	assert.NoError(suite.T(), suite.repo.Create(protected))
+	plain := suite.factory.Episode.CreateEpisode(suite.testDrama.ID, func(e *models.Episode) {
+		e.EpisodeNum = 2
+		e.VideoAssetID = &plainAsset
+	})
+	assert.NoError(suite.T(), suite.repo.Create(plain))
+
+	exists, err := suite.repo.ExistsProtectedByVideoAssetID(protectedAsset)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), exists)
+
+	exists, err = suite.repo.ExistsProtectedByVideoAssetID(plainAsset)
+	assert.NoError(suite.T(), err)
+	assert.False(suite.T(), exists)
+
+	// 回收站中的受保护剧集同样计入
+	assert.NoError(suite.T(), suite.repo.Delete(protected.ID))
+	exists, err = suite.repo.ExistsProtectedByVideoAssetID(protectedAsset)
+	assert.NoError(suite.T(), err)
+	assert.True(suite.T(), exists)
+}
+
+// TestScheduledPublishing 测试定时发布相关查询
+func (suite *EpisodeRepositoryTestSuite) TestScheduledPublishing() {
+	for _, episode := range suite.factory.Episode.CreateEpisodes(suite.testDrama.ID, 4) {
+		episode.Status = "draft"
+		suite.Require().NoError(suite.repo.Create(episode))
+	}
+
+	episodes, err := suite.repo.ListByEpisodeNumRange(suite.testDrama.ID, 2, 3)
+	suite.Require().NoError(err)
+	suite.Require().Len(episodes, 2)
+	assert.Equal(suite.T(), 2, episodes[0].EpisodeNum)
+
+	// 第 2 集已到期，第 3 集明天发布
+	now := time.Now().UTC()
+	past, future := now.Add(-time.Minute), now.Add(24*time.Hour)
+	suite.Require().NoError(suite.db.Model(&episodes[0]).Updates(map[string]interface{}{"status": "scheduled", "publish_at": past}).Error)
+	suite.Require().NoError(suite.db.Model(&episodes[1]).Updates(map[string]interface{}{"status": "scheduled", "publish_at": future}).Error)
+
+	due, err := suite.repo.ListDue(now, 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(due, 1)
+	assert.Equal(suite.T(), episodes[0].ID, due[0].ID)
+
+	published, err := suite.repo.Publish(due[0].ID)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), published)
+	published, err = suite.repo.Publish(due[0].ID)
+	suite.Require().NoError(err)
+	assert.False(suite.T(), published, "已发布的剧集不会重复发布")
+	due, err = suite.repo.ListDue(now, 10)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), due)
+
+	calendar, err := suite.repo.ListPublishBetween(now.Add(-time.Hour), now.Add(48*time.Hour))
+	suite.Require().NoError(err)
+	suite.Require().Len(calendar, 2)
+	assert.Equal(suite.T(), "published", calendar[0].Status)
+	assert.Equal(suite.T(), "scheduled", calendar[1].Status)
+	assert.Equal(suite.T(), suite.testDrama.Title, calendar[1].Drama.Title)
+
+	var transitions []models.StatusTransition
+	suite.Require().NoError(suite.db.Where("entity_type = ? AND entity_id = ?", "episode", episodes[0].ID).Find(&transitions).Error)
+	suite.Require().Len(transitions, 1, "定时发布记录状态流转")
+	assert.Nil(suite.T(), transitions[0].AdminID)
+}
+
+// TestPublishedQueries 测试只返回已发布剧集的查询
+func (suite *EpisodeRepositoryTestSuite) TestPublishedQueries() {
+	episodes := suite.factory.Episode.CreateEpisodes(suite.testDrama.ID, 3)
+	episodes[1].Status = "in_review"
+	for _, episode := range episodes {
+		suite.Require().NoError(suite.repo.Create(episode))
+	}
+
+	list, total, err := suite.repo.GetPublishedByDramaIDPaginated(suite.testDrama.ID, 0, 10)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(2), total)
+	assert.Equal(suite.T(), []int{1, 3}, []int{list[0].EpisodeNum, list[1].EpisodeNum})
+
+	found, err := suite.repo.GetPublishedByIDWithDrama(episodes[0].ID)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(found)
+	assert.Equal(suite.T(), suite.testDrama.Title, found.Drama.Title)
+
+	found, err = suite.repo.GetPublishedByIDWithDrama(episodes[1].ID)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), found, "未发布的剧集")
+
+	suite.Require().NoError(suite.db.Model(suite.testDrama).Update("status", "archived").Error)
+	found, err = suite.repo.GetPublishedByIDWithDrama(episodes[0].ID)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), found, "所属短剧已下架")
+}
+
 // TestEpisodeRepositoryTestSuite 运行剧集仓库测试套件
 func TestEpisodeRepositoryTestSuite(t *testing.T) {
 	suite.Run(t, new(EpisodeRepositoryTestSuite))
@@ -1,11 +1,16 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"gin-mysql-api/internal/models"
 )
 
 // UserRepository 用户数据访问接口
 type UserRepository interface {
+	// WithContext 返回绑定上下文的仓库，查询会继承上下文中的链路信息与日志字段
+	WithContext(ctx context.Context) UserRepository
 	Create(user *models.User) error
 	GetByID(id uint) (*models.User, error)
 	GetByEmail(email string) (*models.User, error)
@@ -19,34 +24,66 @@ type UserRepository interface {
 
 // DramaRepository 短剧数据访问接口
 type DramaRepository interface {
+	// WithContext 返回绑定上下文的仓库，查询会继承上下文中的链路信息与日志字段
+	WithContext(ctx context.Context) DramaRepository
 	Create(drama *models.Drama) error
 	GetByID(id uint) (*models.Drama, error)
+	// GetPublishedByID 获取已发布的短剧，不存在或未发布时返回 nil
+	GetPublishedByID(id uint) (*models.Drama, error)
+	// GetByIDWithEpisodes 获取已发布的短剧及其已发布的剧集，不存在或未发布时返回 nil
 	GetByIDWithEpisodes(id uint) (*models.Drama, error)
 	GetList(offset, limit int, genre string) ([]models.Drama, int64, error)
 	Update(drama *models.Drama) error
+	// Delete 软删除短剧及其剧集
 	Delete(id uint) error
 	IncrementViewCount(id uint) error
 	GetByGenre(genre string, offset, limit int) ([]models.Drama, int64, error)
 	GetActiveList(offset, limit int) ([]models.Drama, int64, error)
+	// ListDue 获取发布时间不晚于 now 的定时发布短剧
+	ListDue(now time.Time, limit int) ([]models.Drama, error)
+	// Publish 将定时发布的短剧改为已发布并保存流转记录，短剧已被其他实例发布或取消排期时返回 false
+	Publish(id uint) (bool, error)
+	// ListPublishBetween 获取发布时间在 [from, to) 内的定时发布与已发布短剧
+	ListPublishBetween(from, to time.Time) ([]models.Drama, error)
 }
 
 // EpisodeRepository 剧集数据访问接口
 type EpisodeRepository interface {
+	// WithContext 返回绑定上下文的仓库，查询会继承上下文中的链路信息与日志字段
+	WithContext(ctx context.Context) EpisodeRepository
 	Create(episode *models.Episode) error
 	GetByID(id uint) (*models.Episode, error)
 	GetByIDWithDrama(id uint) (*models.Episode, error)
+	// GetPublishedByIDWithDrama 获取已发布短剧中已发布的剧集（包含短剧信息），不存在或未发布时返回 nil
+	GetPublishedByIDWithDrama(id uint) (*models.Episode, error)
 	GetByDramaID(dramaID uint) ([]models.Episode, error)
 	GetByDramaIDPaginated(dramaID uint, offset, limit int) ([]models.Episode, int64, error)
+	// GetPublishedByDramaIDPaginated 分页获取短剧中已发布的剧集
+	GetPublishedByDramaIDPaginated(dramaID uint, offset, limit int) ([]models.Episode, int64, error)
 	GetList(offset, limit int) ([]models.Episode, int64, error)
 	Update(episode *models.Episode) error
 	Delete(id uint) error
 	IncrementViewCount(id uint) error
 	GetMaxEpisodeNum(dramaID uint) (int, error)
 	ExistsByDramaIDAndEpisodeNum(dramaID uint, episodeNum int) (bool, error)
+	// ExistsProtectedByVideoAssetID 是否有受保护的剧集（包括回收站中的剧集）使用该媒体文件作为视频
+	ExistsProtectedByVideoAssetID(assetID uint) (bool, error)
+	// ListByDramaID 按剧集号顺序获取短剧中所有状态的剧集
+	ListByDramaID(dramaID uint) ([]models.Episode, error)
+	// ListByEpisodeNumRange 按剧集号顺序获取短剧中剧集号在 [from, to] 内的剧集
+	ListByEpisodeNumRange(dramaID uint, from, to int) ([]models.Episode, error)
+	// ListDue 获取发布时间不晚于 now 的定时发布剧集（包含短剧信息）
+	ListDue(now time.Time, limit int) ([]models.Episode, error)
+	// Publish 将定时发布的剧集改为已发布并保存流转记录，剧集已被其他实例发布或取消排期时返回 false
+	Publish(id uint) (bool, error)
+	// ListPublishBetween 获取发布时间在 [from, to) 内的定时发布与已发布剧集（包含短剧信息）
+	ListPublishBetween(from, to time.Time) ([]models.Episode, error)
 }
 
 // AdminRepository 管理员数据访问接口
 type AdminRepository interface {
+	// WithContext 返回绑定上下文的仓库，查询会继承上下文中的链路信息与日志字段
+	WithContext(ctx context.Context) AdminRepository
 	Create(admin *models.Admin) error
 	GetByID(id uint) (*models.Admin, error)
 	GetByEmail(email string) (*models.Admin, error)
@@ -57,3 +94,111 @@ type AdminRepository interface {
 	ExistsByEmail(email string) (bool, error)
 	ExistsByUsername(username string) (bool, error)
 }
+
+// ReviewRepository 审核流程数据访问接口
+type ReviewRepository interface {
+	// WithContext 返回绑定上下文的仓库，查询会继承上下文中的链路信息与日志字段
+	WithContext(ctx context.Context) ReviewRepository
+	// Transition 在一个事务中将每条记录对应的短剧或剧集从 FromStatus 更新为 ToStatus 与 PublishAt，并保存流转记录
+	// 任一条目的状态已被其他请求变更时不做任何修改，返回 false
+	Transition(records []models.StatusTransition) (bool, error)
+	// ListTransitions 按时间顺序获取短剧或剧集的状态流转记录
+	ListTransitions(entityType string, entityID uint) ([]models.StatusTransition, error)
+}
+
+// TrashRepository 回收站数据访问接口，只操作已软删除的短剧与剧集
+type TrashRepository interface {
+	// WithContext 返回绑定上下文的仓库，查询会继承上下文中的链路信息与日志字段
+	WithContext(ctx context.Context) TrashRepository
+	// ListDramas 按删除时间倒序分页获取已删除的短剧
+	ListDramas(offset, limit int) ([]models.Drama, int64, error)
+	// ListEpisodes 按删除时间倒序分页获取单独删除的剧集，所属短剧已删除的剧集随短剧恢复或永久删除，不单独列出
+	ListEpisodes(offset, limit int) ([]models.Episode, int64, error)
+	// GetDrama 获取已删除的短剧，不存在或未删除时返回 nil
+	GetDrama(id uint) (*models.Drama, error)
+	// GetEpisode 获取已删除的剧集，不存在或未删除时返回 nil
+	GetEpisode(id uint) (*models.Episode, error)
+	// ListEpisodesByDramaID 获取短剧的所有剧集，包括已删除的剧集
+	ListEpisodesByDramaID(dramaID uint) ([]models.Episode, error)
+	// RestoreDrama 恢复短剧及与其一同删除的剧集，短剧不在回收站中时返回 false
+	RestoreDrama(id uint) (bool, error)
+	// RestoreEpisode 恢复剧集，剧集不在回收站中时返回 false
+	RestoreEpisode(id uint) (bool, error)
+	// PurgeDrama 在一个事务中永久删除回收站中的短剧及其所有剧集，连同转码任务、转码结果、内容密钥、状态流转记录与修订版本
+	// 返回被删除的转码结果，调用方负责删除存储中的文件；短剧不在回收站中时不做任何修改
+	PurgeDrama(id uint) ([]models.EpisodeRendition, error)
+	// PurgeEpisode 永久删除回收站中的剧集，规则与 PurgeDrama 相同
+	PurgeEpisode(id uint) ([]models.EpisodeRendition, error)
+	// ListDramasDeletedBefore 获取在 cutoff 之前删除的短剧
+	ListDramasDeletedBefore(cutoff time.Time, limit int) ([]models.Drama, error)
+	// ListEpisodesDeletedBefore 获取在 cutoff 之前删除的剧集（包括随短剧删除的剧集）
+	ListEpisodesDeletedBefore(cutoff time.Time, limit int) ([]models.Episode, error)
+}
+
+// RevisionRepository 短剧与剧集修订版本数据访问接口
+type RevisionRepository interface {
+	// WithContext 返回绑定上下文的仓库，查询会继承上下文中的链路信息与日志字段
+	WithContext(ctx context.Context) RevisionRepository
+	// Create 保存修订版本，版本号为该内容当前最大版本号加一
+	Create(revision *models.ContentRevision) error
+	// SaveWithRevision 在一个事务中保存短剧或剧集（content 为 nil 时不保存）与修订版本，内容还没有修订版本时先保存 baseline 作为第一个版本
+	// 并发保存导致版本号冲突时重试
+	SaveWithRevision(content interface{}, baseline, revision *models.ContentRevision) error
+	// Get 获取指定版本，不存在时返回 nil
+	Get(entityType string, entityID uint, version int) (*models.ContentRevision, error)
+	// Latest 获取最新版本，没有修订版本时返回 nil
+	Latest(entityType string, entityID uint) (*models.ContentRevision, error)
+	// List 按版本号倒序分页获取修订版本
+	List(entityType string, entityID uint, offset, limit int) ([]models.ContentRevision, int64, error)
+}
+
+// UploadSessionRepository 分片上传会话数据访问接口
+type UploadSessionRepository interface {
+	// WithContext 返回绑定上下文的仓库，查询会继承上下文中的链路信息与日志字段
+	WithContext(ctx context.Context) UploadSessionRepository
+	Create(session *models.UploadSession) error
+	GetByID(id string) (*models.UploadSession, error)
+	Update(session *models.UploadSession) error
+	UpdateStatus(id, from, to string) (bool, error)
+	Delete(id string) error
+	SavePart(part *models.UploadPart) error
+	ListExpired(before time.Time, limit int) ([]models.UploadSession, error)
+}
+
+// MediaAssetRepository 媒体文件数据访问接口
+type MediaAssetRepository interface {
+	// WithContext 返回绑定上下文的仓库，查询会继承上下文中的链路信息与日志字段
+	WithContext(ctx context.Context) MediaAssetRepository
+	Create(asset *models.MediaAsset) error
+	GetByID(id uint) (*models.MediaAsset, error)
+	GetByKey(key string) (*models.MediaAsset, error)
+	GetByKeys(keys []string) ([]models.MediaAsset, error)
+	FindDuplicate(ownerID uint, ownerRole, uploadType, checksum string) (*models.MediaAsset, error)
+	Update(asset *models.MediaAsset) error
+	Delete(id uint) error
+	// IsReferenced 媒体文件是否被短剧封面、剧集视频与缩略图或用户头像引用
+	IsReferenced(id uint) (bool, error)
+	SyncReferences(now time.Time) error
+	ListUnreferencedBefore(cutoff time.Time, limit int) ([]models.MediaAsset, error)
+}
+
+// TranscodeRepository 视频转码任务与转码结果数据访问接口
+type TranscodeRepository interface {
+	// WithContext 返回绑定上下文的仓库，查询会继承上下文中的链路信息与日志字段
+	WithContext(ctx context.Context) TranscodeRepository
+	CreateJob(job *models.TranscodeJob) error
+	UpdateJob(job *models.TranscodeJob) error
+	// ClaimNextJob 领取最早的待处理任务（含超时未完成的任务）并标记为处理中，没有任务时返回 nil
+	ClaimNextJob(now time.Time, timeout time.Duration, maxAttempts int) (*models.TranscodeJob, error)
+	ListRenditions(episodeID uint) ([]models.EpisodeRendition, error)
+	// ReplaceRenditions 替换剧集的转码结果，返回被替换的旧结果
+	ReplaceRenditions(episodeID uint, renditions []models.EpisodeRendition) ([]models.EpisodeRendition, error)
+	CreateKey(key *models.EpisodeKey) error
+	UpdateKey(key *models.EpisodeKey) error
+	// GetKey 获取剧集的内容密钥，不存在时返回 nil
+	GetKey(episodeID, id uint) (*models.EpisodeKey, error)
+	// ListKeysNotSealedWith 按 ID 顺序获取 afterID 之后不是由 masterKeyID 加密的内容密钥，用于轮换主密钥
+	ListKeysNotSealedWith(masterKeyID string, afterID uint, limit int) ([]models.EpisodeKey, error)
+	// DeleteKeys 删除剧集除 keepJobID 任务以外的内容密钥，keepJobID 为 0 时全部删除
+	DeleteKeys(episodeID, keepJobID uint) error
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gin-mysql-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// mediaAssetRepository 媒体文件仓库实现
+type mediaAssetRepository struct {
+	db *gorm.DB
+}
+
+// NewMediaAssetRepository 创建媒体文件仓库实例
+func NewMediaAssetRepository(db *gorm.DB) MediaAssetRepository {
+	return &mediaAssetRepository{db: db}
+}
+
+// WithContext 返回绑定上下文的媒体文件仓库
+func (r *mediaAssetRepository) WithContext(ctx context.Context) MediaAssetRepository {
+	return &mediaAssetRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create 登记媒体文件
+func (r *mediaAssetRepository) Create(asset *models.MediaAsset) error {
+	return r.db.Create(asset).Error
+}
+
+// GetByID 根据 ID 获取媒体文件，不存在时返回 nil
+func (r *mediaAssetRepository) GetByID(id uint) (*models.MediaAsset, error) {
+	var asset models.MediaAsset
+	err := r.db.First(&asset, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// GetByKey 根据存储路径获取媒体文件
+func (r *mediaAssetRepository) GetByKey(key string) (*models.MediaAsset, error) {
+	var asset models.MediaAsset
+	err := r.db.Where("storage_key = ?", key).First(&asset).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// Delete 删除媒体文件记录
+func (r *mediaAssetRepository) Delete(id uint) error {
+	return r.db.Delete(&models.MediaAsset{}, id).Error
+}
+
+// GetByKeys 根据多个存储路径批量获取媒体文件
+func (r *mediaAssetRepository) GetByKeys(keys []string) ([]models.MediaAsset, error) {
+	var assets []models.MediaAsset
+	if len(keys) == 0 {
+		return assets, nil
+	}
+	err := r.db.Where("storage_key IN ?", keys).Find(&assets).Error
+	return assets, err
+}
+
+// FindDuplicate 查找同一上传者、同一用途下内容相同的媒体文件
+func (r *mediaAssetRepository) FindDuplicate(ownerID uint, ownerRole, uploadType, checksum string) (*models.MediaAsset, error) {
+	var asset models.MediaAsset
+	err := r.db.Where("owner_id = ? AND owner_role = ? AND type = ? AND checksum = ?", ownerID, ownerRole, uploadType, checksum).
+		Order("id").First(&asset).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &asset, nil
+}
+
+// Update 更新媒体文件记录
+func (r *mediaAssetRepository) Update(asset *models.MediaAsset) error {
+	return r.db.Save(asset).Error
+}
+
+// mediaReferenceCondition 媒体文件被引用的条件，软删除的短剧、剧集与用户仍然保留引用，恢复后文件可用
+const mediaReferenceCondition = `(EXISTS (SELECT 1 FROM dramas WHERE dramas.cover_asset_id = media_assets.id)
+	OR EXISTS (SELECT 1 FROM episodes WHERE episodes.video_asset_id = media_assets.id OR episodes.thumbnail_asset_id = media_assets.id)
+	OR EXISTS (SELECT 1 FROM users WHERE users.avatar_asset_id = media_assets.id))`
+
+// IsReferenced 媒体文件是否被短剧、剧集或用户引用
+func (r *mediaAssetRepository) IsReferenced(id uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.MediaAsset{}).Where("id = ? AND "+mediaReferenceCondition, id).Count(&count).Error
+	return count > 0, err
+}
+
+// SyncReferences 根据当前引用关系刷新 unreferenced_since：重新被引用的文件清空，新失去引用的文件记为 now
+func (r *mediaAssetRepository) SyncReferences(now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.MediaAsset{}).
+			Where("unreferenced_since IS NOT NULL AND "+mediaReferenceCondition).
+			Update("unreferenced_since", nil).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.MediaAsset{}).
+			Where("unreferenced_since IS NULL AND NOT "+mediaReferenceCondition).
+			Update("unreferenced_since", now).Error
+	})
+}
+
+// ListUnreferencedBefore 列出在 cutoff 之前就已不被引用的媒体文件
+func (r *mediaAssetRepository) ListUnreferencedBefore(cutoff time.Time, limit int) ([]models.MediaAsset, error) {
+	var assets []models.MediaAsset
+	err := r.db.Where("unreferenced_since IS NOT NULL AND unreferenced_since < ?", cutoff).
+		Order("unreferenced_since").Limit(limit).Find(&assets).Error
+	return assets, err
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// MediaAssetRepositoryTestSuite 媒体文件仓库测试套件
+type MediaAssetRepositoryTestSuite struct {
+	suite.Suite
+	db   *gorm.DB
+	repo MediaAssetRepository
+}
+
+// SetupSuite 设置测试套件
+func (suite *MediaAssetRepositoryTestSuite) SetupSuite() {
+	suite.db = testutil.SetupTestDB()
+	suite.repo = NewMediaAssetRepository(suite.db)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *MediaAssetRepositoryTestSuite) TearDownSuite() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *MediaAssetRepositoryTestSuite) SetupTest() {
+	testutil.CleanupTestDB(suite.db)
+}
+
+// TestCreateAndGetByKey 测试登记并按路径查询
+func (suite *MediaAssetRepositoryTestSuite) TestCreateAndGetByKey() {
+	asset := &models.MediaAsset{
+		OwnerID:     1,
+		OwnerRole:   "user",
+		Type:        "cover",
+		StorageKey:  "covers/a.jpg",
+		Size:        10,
+		ContentType: "image/jpeg",
+	}
+	suite.Require().NoError(suite.repo.Create(asset))
+
+	found, err := suite.repo.GetByKey("covers/a.jpg")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(found)
+	assert.Equal(suite.T(), asset.ID, found.ID)
+	assert.True(suite.T(), found.IsOwnedBy(1, "user"))
+	assert.False(suite.T(), found.IsOwnedBy(1, "admin"))
+
+	// 存储路径唯一
+	duplicate := *asset
+	duplicate.ID = 0
+	assert.Error(suite.T(), suite.repo.Create(&duplicate))
+
+	missing, err := suite.repo.GetByKey("covers/missing.jpg")
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), missing)
+
+	found, err = suite.repo.GetByID(asset.ID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), "covers/a.jpg", found.StorageKey)
+	missing, err = suite.repo.GetByID(asset.ID + 1)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), missing)
+}
+
+// TestDelete 测试删除
+func (suite *MediaAssetRepositoryTestSuite) TestDelete() {
+	asset := &models.MediaAsset{OwnerID: 1, OwnerRole: "user", Type: "cover", StorageKey: "covers/b.jpg", Size: 1}
+	suite.Require().NoError(suite.repo.Create(asset))
+	suite.Require().NoError(suite.repo.Delete(asset.ID))
+
+	found, err := suite.repo.GetByKey("covers/b.jpg")
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), found)
+}
+
+// TestGetByKeysAndFindDuplicate 测试批量查询与按摘要查找重复文件
+func (suite *MediaAssetRepositoryTestSuite) TestGetByKeysAndFindDuplicate() {
+	first := &models.MediaAsset{OwnerID: 1, OwnerRole: "user", Type: "avatar", StorageKey: "avatars/a.jpg", Size: 1, Checksum: "abc"}
+	second := &models.MediaAsset{OwnerID: 1, OwnerRole: "user", Type: "avatar", StorageKey: "avatars/b.jpg", Size: 1, Checksum: "def"}
+	suite.Require().NoError(suite.repo.Create(first))
+	suite.Require().NoError(suite.repo.Create(second))
+
+	assets, err := suite.repo.GetByKeys([]string{"avatars/a.jpg", "avatars/b.jpg", "avatars/c.jpg"})
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), assets, 2)
+
+	found, err := suite.repo.FindDuplicate(1, "user", "avatar", "abc")
+	suite.Require().NoError(err)
+	suite.Require().NotNil(found)
+	assert.Equal(suite.T(), first.ID, found.ID)
+
+	// 上传者、用途不同时不算重复
+	for _, args := range []struct {
+		ownerID    uint
+		role, kind string
+	}{{2, "user", "avatar"}, {1, "admin", "avatar"}, {1, "user", "cover"}} {
+		found, err = suite.repo.FindDuplicate(args.ownerID, args.role, args.kind, "abc")
+		suite.Require().NoError(err)
+		assert.Nil(suite.T(), found)
+	}
+}
+
+// TestReferences 测试引用状态刷新与未引用文件查询
+func (suite *MediaAssetRepositoryTestSuite) TestReferences() {
+	cover := &models.MediaAsset{OwnerID: 1, OwnerRole: "admin", Type: "cover", StorageKey: "covers/c.jpg", Size: 1}
+	video := &models.MediaAsset{OwnerID: 1, OwnerRole: "admin", Type: "video", StorageKey: "videos/v.mp4", Size: 1}
+	orphan := &models.MediaAsset{OwnerID: 1, OwnerRole: "user", Type: "avatar", StorageKey: "avatars/o.jpg", Size: 1}
+	for _, asset := range []*models.MediaAsset{cover, video, orphan} {
+		suite.Require().NoError(suite.repo.Create(asset))
+	}
+
+	drama := &models.Drama{Title: "短剧", CoverAssetID: &cover.ID}
+	suite.Require().NoError(suite.db.Create(drama).Error)
+	episode := &models.Episode{DramaID: drama.ID, Title: "第一集", EpisodeNum: 1, Duration: 60, VideoAssetID: &video.ID}
+	suite.Require().NoError(suite.db.Create(episode).Error)
+
+	referenced, err := suite.repo.IsReferenced(cover.ID)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), referenced)
+	referenced, err = suite.repo.IsReferenced(orphan.ID)
+	suite.Require().NoError(err)
+	assert.False(suite.T(), referenced)
+
+	now := time.Now()
+	suite.Require().NoError(suite.repo.SyncReferences(now))
+	assets, err := suite.repo.ListUnreferencedBefore(now.Add(time.Second), 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(assets, 1)
+	assert.Equal(suite.T(), orphan.ID, assets[0].ID)
+
+	// 已记录的未引用时间不会被后续刷新覆盖
+	suite.Require().NoError(suite.repo.SyncReferences(now.Add(time.Hour)))
+	assets, err = suite.repo.ListUnreferencedBefore(now.Add(time.Second), 10)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), assets, 1)
+
+	// 剧集软删除后仍然保留引用，剧集换用其他视频后原视频失去引用
+	suite.Require().NoError(suite.db.Delete(episode).Error)
+	referenced, err = suite.repo.IsReferenced(video.ID)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), referenced)
+
+	suite.Require().NoError(suite.db.Unscoped().Model(episode).Update("video_asset_id", nil).Error)
+	suite.Require().NoError(suite.db.Create(&models.User{
+		Username: "u", Email: "u@example.com", Password: "secret", AvatarAssetID: &orphan.ID,
+	}).Error)
+	suite.Require().NoError(suite.repo.SyncReferences(now.Add(2 * time.Hour)))
+
+	assets, err = suite.repo.ListUnreferencedBefore(now.Add(3*time.Hour), 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(assets, 1)
+	assert.Equal(suite.T(), video.ID, assets[0].ID)
+}
+
+// TestMediaAssetRepositoryTestSuite 运行媒体文件仓库测试套件
+func TestMediaAssetRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(MediaAssetRepositoryTestSuite))
+}
@@ -6,18 +6,30 @@ import (
 
 // Repository 仓库管理器，包含所有仓库接口
 type Repository struct {
-	User    UserRepository
-	Drama   DramaRepository
-	Episode EpisodeRepository
-	Admin   AdminRepository
+	User      UserRepository
+	Drama     DramaRepository
+	Episode   EpisodeRepository
+	Admin     AdminRepository
+	Upload    UploadSessionRepository
+	Media     MediaAssetRepository
+	Transcode TranscodeRepository
+	Review    ReviewRepository
+	Trash     TrashRepository
+	Revision  RevisionRepository
 }
 
 // NewRepository 创建仓库管理器实例
 func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{
-		User:    NewUserRepository(db),
-		Drama:   NewDramaRepository(db),
-		Episode: NewEpisodeRepository(db),
-		Admin:   NewAdminRepository(db),
+		User:      NewUserRepository(db),
+		Drama:     NewDramaRepository(db),
+		Episode:   NewEpisodeRepository(db),
+		Admin:     NewAdminRepository(db),
+		Upload:    NewUploadSessionRepository(db),
+		Media:     NewMediaAssetRepository(db),
+		Transcode: NewTranscodeRepository(db),
+		Review:    NewReviewRepository(db),
+		Trash:     NewTrashRepository(db),
+		Revision:  NewRevisionRepository(db),
 	}
-}
\ No newline at end of file
+}
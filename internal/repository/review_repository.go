@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gin-mysql-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// errTransitionConflict 条目状态已被其他请求变更，用于回滚事务，不返回给调用方
+var errTransitionConflict = errors.New("status changed")
+
+// reviewRepository 审核流程仓库实现
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+// NewReviewRepository 创建审核流程仓库实例
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+// WithContext 返回绑定上下文的审核流程仓库
+func (r *reviewRepository) WithContext(ctx context.Context) ReviewRepository {
+	return &reviewRepository{db: r.db.WithContext(ctx)}
+}
+
+// Transition 按状态条件逐条更新并保存流转记录，任一条目的状态已被其他请求变更时全部回滚
+func (r *reviewRepository) Transition(records []models.StatusTransition) (bool, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range records {
+			ok, err := applyTransition(tx, &records[i])
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errTransitionConflict
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errTransitionConflict) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ListTransitions 按时间顺序获取状态流转记录
+func (r *reviewRepository) ListTransitions(entityType string, entityID uint) ([]models.StatusTransition, error) {
+	var records []models.StatusTransition
+	if err := r.db.Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Order("id ASC").Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// applyTransition 在事务中将状态为 record.FromStatus 的条目更新为 record.ToStatus 与 record.PublishAt，并保存流转记录
+// 条目状态已不是 FromStatus 时返回 false，调用方需要回滚事务
+func applyTransition(tx *gorm.DB, record *models.StatusTransition) (bool, error) {
+	var model interface{}
+	switch record.EntityType {
+	case models.ReviewEntityDrama:
+		model = &models.Drama{}
+	case models.ReviewEntityEpisode:
+		model = &models.Episode{}
+	default:
+		return false, fmt.Errorf("unknown entity type %q", record.EntityType)
+	}
+
+	result := tx.Model(model).Where("id = ? AND status = ?", record.EntityID, record.FromStatus).
+		Updates(map[string]interface{}{"status": record.ToStatus, "publish_at": record.PublishAt})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	if err := tx.Create(record).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// publishScheduled 将定时发布的短剧或剧集改为已发布，保留发布时间并以系统身份保存流转记录
+func publishScheduled(db *gorm.DB, entityType string, id uint) (bool, error) {
+	published := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var model interface{} = &models.Drama{}
+		if entityType == models.ReviewEntityEpisode {
+			model = &models.Episode{}
+		}
+		result := tx.Model(model).Where("id = ? AND status = ?", id, models.StatusScheduled).Update("status", models.StatusPublished)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		published = true
+		return tx.Create(&models.StatusTransition{
+			EntityType: entityType,
+			EntityID:   id,
+			FromStatus: models.StatusScheduled,
+			ToStatus:   models.StatusPublished,
+			Comment:    "定时发布",
+		}).Error
+	})
+	return published && err == nil, err
+}
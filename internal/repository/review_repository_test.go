@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// ReviewRepositoryTestSuite 审核流程仓库测试套件
+type ReviewRepositoryTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	repo    ReviewRepository
+	factory *testutil.Factory
+}
+
+// SetupSuite 设置测试套件
+func (suite *ReviewRepositoryTestSuite) SetupSuite() {
+	suite.db = testutil.SetupTestDB()
+	suite.repo = NewReviewRepository(suite.db)
+	suite.factory = testutil.NewFactory()
+}
+
+// TearDownSuite 清理测试套件
+func (suite *ReviewRepositoryTestSuite) TearDownSuite() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *ReviewRepositoryTestSuite) SetupTest() {
+	testutil.CleanupTestDB(suite.db)
+}
+
+// TestTransition 测试按状态条件变更状态并保存流转记录
+func (suite *ReviewRepositoryTestSuite) TestTransition() {
+	drama := suite.factory.Drama.CreateDrama(func(d *models.Drama) { d.Status = models.StatusApproved })
+	suite.Require().NoError(suite.db.Create(drama).Error)
+	adminID := uint(7)
+	publishAt := time.Now().Add(time.Hour).UTC()
+
+	ok, err := suite.repo.Transition([]models.StatusTransition{{
+		EntityType: models.ReviewEntityDrama, EntityID: drama.ID,
+		FromStatus: models.StatusApproved, ToStatus: models.StatusScheduled,
+		PublishAt: &publishAt, AdminID: &adminID, AdminName: "editor",
+	}})
+	suite.Require().NoError(err)
+	assert.True(suite.T(), ok)
+
+	var saved models.Drama
+	suite.Require().NoError(suite.db.First(&saved, drama.ID).Error)
+	assert.Equal(suite.T(), models.StatusScheduled, saved.Status)
+	suite.Require().NotNil(saved.PublishAt)
+	assert.True(suite.T(), publishAt.Equal(*saved.PublishAt))
+
+	// 状态已变更时不修改
+	ok, err = suite.repo.Transition([]models.StatusTransition{{
+		EntityType: models.ReviewEntityDrama, EntityID: drama.ID,
+		FromStatus: models.StatusApproved, ToStatus: models.StatusPublished,
+	}})
+	suite.Require().NoError(err)
+	assert.False(suite.T(), ok)
+
+	records, err := suite.repo.ListTransitions(models.ReviewEntityDrama, drama.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(records, 1)
+	assert.Equal(suite.T(), models.StatusApproved, records[0].FromStatus)
+	assert.Equal(suite.T(), models.StatusScheduled, records[0].ToStatus)
+	assert.Equal(suite.T(), adminID, *records[0].AdminID)
+}
+
+// TestTransitionRollback 测试批量变更中任一条目冲突时全部回滚
+func (suite *ReviewRepositoryTestSuite) TestTransitionRollback() {
+	drama := suite.factory.Drama.CreateDrama()
+	suite.Require().NoError(suite.db.Create(drama).Error)
+	episodes := suite.factory.Episode.CreateEpisodes(drama.ID, 2)
+	for _, episode := range episodes {
+		episode.Status = models.StatusApproved
+		suite.Require().NoError(suite.db.Create(episode).Error)
+	}
+	suite.Require().NoError(suite.db.Model(episodes[1]).Update("status", models.StatusDraft).Error)
+
+	records := make([]models.StatusTransition, 0, len(episodes))
+	for _, episode := range episodes {
+		records = append(records, models.StatusTransition{
+			EntityType: models.ReviewEntityEpisode, EntityID: episode.ID,
+			FromStatus: models.StatusApproved, ToStatus: models.StatusPublished,
+		})
+	}
+	ok, err := suite.repo.Transition(records)
+	suite.Require().NoError(err)
+	assert.False(suite.T(), ok)
+
+	var saved models.Episode
+	suite.Require().NoError(suite.db.First(&saved, episodes[0].ID).Error)
+	assert.Equal(suite.T(), models.StatusApproved, saved.Status, "第一个剧集的变更已回滚")
+	history, err := suite.repo.ListTransitions(models.ReviewEntityEpisode, episodes[0].ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), history)
+}
+
+// TestReviewRepositoryTestSuite 运行审核流程仓库测试套件
+func TestReviewRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(ReviewRepositoryTestSuite))
+}
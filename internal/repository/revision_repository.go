@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gin-mysql-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// maxRevisionAttempts 并发保存同一内容的修订版本时，版本号冲突的最大尝试次数
+const maxRevisionAttempts = 5
+
+// revisionRepository 修订版本仓库实现
+type revisionRepository struct {
+	db *gorm.DB
+}
+
+// NewRevisionRepository 创建修订版本仓库实例
+func NewRevisionRepository(db *gorm.DB) RevisionRepository {
+	return &revisionRepository{db: db}
+}
+
+// WithContext 返回绑定上下文的修订版本仓库
+func (r *revisionRepository) WithContext(ctx context.Context) RevisionRepository {
+	return &revisionRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create 在事务中取当前最大版本号加一作为新版本号并保存
+func (r *revisionRepository) Create(revision *models.ContentRevision) error {
+	return r.SaveWithRevision(nil, nil, revision)
+}
+
+// SaveWithRevision 在一个事务中保存内容与修订版本，内容还没有修订版本时先保存 baseline
+// 并发写入同一版本号时唯一索引拒绝后写入的事务，回滚后重新读取版本号重试，不丢失修订版本
+func (r *revisionRepository) SaveWithRevision(content interface{}, baseline, revision *models.ContentRevision) error {
+	var err error
+	for attempt := 0; attempt < maxRevisionAttempts; attempt++ {
+		err = r.db.Transaction(func(tx *gorm.DB) error {
+			if content != nil {
+				if err := tx.Save(content).Error; err != nil {
+					return err
+				}
+			}
+			var latest int
+			if err := tx.Model(&models.ContentRevision{}).
+				Where("entity_type = ? AND entity_id = ?", revision.EntityType, revision.EntityID).
+				Select("COALESCE(MAX(version), 0)").Scan(&latest).Error; err != nil {
+				return err
+			}
+			if latest == 0 && baseline != nil {
+				baseline.ID, baseline.Version = 0, 1
+				if err := tx.Create(baseline).Error; err != nil {
+					return err
+				}
+				latest = baseline.Version
+			}
+			revision.ID, revision.Version = 0, latest+1
+			return tx.Create(revision).Error
+		})
+		if !isUniqueViolation(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// isUniqueViolation 是否违反唯一索引，兼容 MySQL 与 SQLite 驱动的错误信息
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	message := err.Error()
+	return strings.Contains(message, "Duplicate entry") || strings.Contains(message, "UNIQUE constraint failed")
+}
+
+// Get 获取指定版本
+func (r *revisionRepository) Get(entityType string, entityID uint, version int) (*models.ContentRevision, error) {
+	var revision models.ContentRevision
+	err := r.db.Where("entity_type = ? AND entity_id = ? AND version = ?", entityType, entityID, version).
+		First(&revision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// Latest 获取最新版本
+func (r *revisionRepository) Latest(entityType string, entityID uint) (*models.ContentRevision, error) {
+	var revision models.ContentRevision
+	err := r.db.Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Order("version DESC").First(&revision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// List 按版本号倒序分页获取修订版本
+func (r *revisionRepository) List(entityType string, entityID uint, offset, limit int) ([]models.ContentRevision, int64, error) {
+	var revisions []models.ContentRevision
+	var total int64
+
+	query := r.db.Model(&models.ContentRevision{}).Where("entity_type = ? AND entity_id = ?", entityType, entityID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("version DESC").Offset(offset).Limit(limit).Find(&revisions).Error; err != nil {
+		return nil, 0, err
+	}
+	return revisions, total, nil
+}
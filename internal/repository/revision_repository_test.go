@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// RevisionRepositoryTestSuite 修订版本仓库测试套件
+type RevisionRepositoryTestSuite struct {
+	suite.Suite
+	db   *gorm.DB
+	repo RevisionRepository
+}
+
+// SetupSuite 设置测试套件
+func (suite *RevisionRepositoryTestSuite) SetupSuite() {
+	suite.db = testutil.SetupTestDB()
+	suite.repo = NewRevisionRepository(suite.db)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *RevisionRepositoryTestSuite) TearDownSuite() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *RevisionRepositoryTestSuite) SetupTest() {
+	testutil.CleanupTestDB(suite.db)
+}
+
+// TestCreate 测试版本号按内容递增以及快照的读写
+func (suite *RevisionRepositoryTestSuite) TestCreate() {
+	adminID := uint(3)
+	for _, title := range []string{"第一版", "第二版"} {
+		suite.Require().NoError(suite.repo.Create(&models.ContentRevision{
+			EntityType: models.ReviewEntityDrama, EntityID: 1,
+			Snapshot: map[string]interface{}{"title": title}, AdminID: &adminID, AdminName: "editor",
+		}))
+	}
+	other := &models.ContentRevision{EntityType: models.ReviewEntityEpisode, EntityID: 1, Snapshot: map[string]interface{}{"episode_num": 1}}
+	suite.Require().NoError(suite.repo.Create(other))
+	assert.Equal(suite.T(), 1, other.Version, "不同内容的版本号各自从 1 开始")
+
+	latest, err := suite.repo.Latest(models.ReviewEntityDrama, 1)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(latest)
+	assert.Equal(suite.T(), 2, latest.Version)
+	assert.Equal(suite.T(), "第二版", latest.Snapshot["title"])
+	assert.Equal(suite.T(), "editor", latest.AdminName)
+
+	first, err := suite.repo.Get(models.ReviewEntityDrama, 1, 1)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(first)
+	assert.Equal(suite.T(), "第一版", first.Snapshot["title"])
+
+	missing, err := suite.repo.Get(models.ReviewEntityDrama, 1, 3)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), missing)
+	missing, err = suite.repo.Latest(models.ReviewEntityDrama, 2)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), missing)
+
+	revisions, total, err := suite.repo.List(models.ReviewEntityDrama, 1, 0, 1)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(2), total)
+	suite.Require().Len(revisions, 1)
+	assert.Equal(suite.T(), 2, revisions[0].Version, "按版本号倒序")
+}
+
+// onCreateRevision 在保存修订版本前调用 fn，返回移除回调的函数
+func (suite *RevisionRepositoryTestSuite) onCreateRevision(fn func(db *gorm.DB)) func() {
+	const name = "test:before_create_revision"
+	suite.Require().NoError(suite.db.Callback().Create().Before("gorm:create").Register(name, func(db *gorm.DB) {
+		if _, ok := db.Statement.Model.(*models.ContentRevision); ok {
+			fn(db)
+		}
+	}))
+	return func() { suite.Require().NoError(suite.db.Callback().Create().Remove(name)) }
+}
+
+// TestSaveWithRevision 测试内容与修订版本在同一事务中保存
+func (suite *RevisionRepositoryTestSuite) TestSaveWithRevision() {
+	drama := &models.Drama{Title: "原标题"}
+	suite.Require().NoError(suite.db.Create(drama).Error)
+	newRevision := func(title string) (*models.ContentRevision, *models.ContentRevision) {
+		baseline := &models.ContentRevision{EntityType: models.ReviewEntityDrama, EntityID: drama.ID,
+			Snapshot: map[string]interface{}{"title": "原标题"}, CreatedAt: time.Now().Add(-time.Hour)}
+		revision := &models.ContentRevision{EntityType: models.ReviewEntityDrama, EntityID: drama.ID, Snapshot: map[string]interface{}{"title": title}}
+		return baseline, revision
+	}
+
+	drama.Title = "第二版"
+	baseline, revision := newRevision(drama.Title)
+	suite.Require().NoError(suite.repo.SaveWithRevision(drama, baseline, revision))
+	assert.Equal(suite.T(), 1, baseline.Version, "还没有修订版本时先保存更新前的内容")
+	assert.Equal(suite.T(), 2, revision.Version)
+
+	drama.Title = "第三版"
+	baseline, revision = newRevision(drama.Title)
+	suite.Require().NoError(suite.repo.SaveWithRevision(drama, baseline, revision))
+	assert.Zero(suite.T(), baseline.ID, "已有修订版本时不再保存 baseline")
+	assert.Equal(suite.T(), 3, revision.Version)
+
+	suite.Run("修订版本保存失败时内容一起回滚", func() {
+		remove := suite.onCreateRevision(func(db *gorm.DB) { db.AddError(errors.New("disk full")) })
+		defer remove()
+
+		drama.Title = "第四版"
+		_, revision := newRevision(drama.Title)
+		assert.Error(suite.T(), suite.repo.SaveWithRevision(drama, nil, revision))
+		var saved models.Drama
+		suite.Require().NoError(suite.db.First(&saved, drama.ID).Error)
+		assert.Equal(suite.T(), "第三版", saved.Title)
+	})
+
+	suite.Run("版本号冲突时重试", func() {
+		attempts := 0
+		remove := suite.onCreateRevision(func(db *gorm.DB) {
+			attempts++
+			if attempts == 1 {
+				// 模拟其他请求同时保存了同一版本号
+				db.Session(&gorm.Session{NewDB: true}).Exec(
+					"INSERT INTO content_revisions (entity_type, entity_id, version, snapshot, created_at) VALUES (?, ?, ?, ?, ?)",
+					models.ReviewEntityDrama, drama.ID, 4, "{}", time.Now())
+			}
+		})
+		defer remove()
+
+		drama.Title = "第五版"
+		_, revision := newRevision(drama.Title)
+		suite.Require().NoError(suite.repo.SaveWithRevision(drama, nil, revision))
+		assert.Equal(suite.T(), 2, attempts)
+		assert.Equal(suite.T(), 4, revision.Version)
+		latest, err := suite.repo.Latest(models.ReviewEntityDrama, drama.ID)
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), "第五版", latest.Snapshot["title"])
+	})
+}
+
+// TestRevisionRepositoryTestSuite 运行修订版本仓库测试套件
+func TestRevisionRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(RevisionRepositoryTestSuite))
+}
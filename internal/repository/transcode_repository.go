@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gin-mysql-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// transcodeRepository 视频转码仓库实现
+type transcodeRepository struct {
+	db *gorm.DB
+}
+
+// NewTranscodeRepository 创建视频转码仓库实例
+func NewTranscodeRepository(db *gorm.DB) TranscodeRepository {
+	return &transcodeRepository{db: db}
+}
+
+// WithContext 返回绑定上下文的视频转码仓库
+func (r *transcodeRepository) WithContext(ctx context.Context) TranscodeRepository {
+	return &transcodeRepository{db: r.db.WithContext(ctx)}
+}
+
+// CreateJob 创建转码任务
+func (r *transcodeRepository) CreateJob(job *models.TranscodeJob) error {
+	return r.db.Create(job).Error
+}
+
+// UpdateJob 更新转码任务
+func (r *transcodeRepository) UpdateJob(job *models.TranscodeJob) error {
+	return r.db.Save(job).Error
+}
+
+// ClaimNextJob 领取待处理任务
+// 处理中的任务超过 timeout 仍未结束视为处理它的实例已退出，可以被重新领取；
+// 已达到最大尝试次数的超时任务标记为失败。通过比较状态与尝试次数更新，多个实例不会领取到同一个任务
+func (r *transcodeRepository) ClaimNextJob(now time.Time, timeout time.Duration, maxAttempts int) (*models.TranscodeJob, error) {
+	staleBefore := now.Add(-timeout)
+	err := r.db.Model(&models.TranscodeJob{}).
+		Where("status = ? AND started_at < ? AND attempts >= ?", models.TranscodeStatusRunning, staleBefore, maxAttempts).
+		Updates(map[string]interface{}{"status": models.TranscodeStatusFailed, "error": "任务超时", "finished_at": now}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var job models.TranscodeJob
+		err := r.db.Where("attempts < ? AND (status = ? OR (status = ? AND started_at < ?))",
+			maxAttempts, models.TranscodeStatusPending, models.TranscodeStatusRunning, staleBefore).
+			Order("id ASC").First(&job).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		result := r.db.Model(&models.TranscodeJob{}).
+			Where("id = ? AND status = ? AND attempts = ?", job.ID, job.Status, job.Attempts).
+			Updates(map[string]interface{}{
+				"status":     models.TranscodeStatusRunning,
+				"attempts":   job.Attempts + 1,
+				"started_at": now,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue // 已被其他实例领取
+		}
+		job.Status = models.TranscodeStatusRunning
+		job.Attempts++
+		job.StartedAt = &now
+		return &job, nil
+	}
+}
+
+// ListRenditions 获取剧集的转码结果，按码率从低到高排序
+func (r *transcodeRepository) ListRenditions(episodeID uint) ([]models.EpisodeRendition, error) {
+	var renditions []models.EpisodeRendition
+	if err := r.db.Where("episode_id = ?", episodeID).Order("bitrate ASC").Find(&renditions).Error; err != nil {
+		return nil, err
+	}
+	return renditions, nil
+}
+
+// ReplaceRenditions 在事务中删除剧集的旧转码结果并写入新结果
+func (r *transcodeRepository) ReplaceRenditions(episodeID uint, renditions []models.EpisodeRendition) ([]models.EpisodeRendition, error) {
+	var old []models.EpisodeRendition
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("episode_id = ?", episodeID).Find(&old).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("episode_id = ?", episodeID).Delete(&models.EpisodeRendition{}).Error; err != nil {
+			return err
+		}
+		if len(renditions) == 0 {
+			return nil
+		}
+		return tx.Create(&renditions).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return old, nil
+}
+
+// CreateKey 创建内容密钥
+func (r *transcodeRepository) CreateKey(key *models.EpisodeKey) error {
+	return r.db.Create(key).Error
+}
+
+// UpdateKey 更新内容密钥
+func (r *transcodeRepository) UpdateKey(key *models.EpisodeKey) error {
+	return r.db.Save(key).Error
+}
+
+// GetKey 获取剧集的内容密钥
+func (r *transcodeRepository) GetKey(episodeID, id uint) (*models.EpisodeKey, error) {
+	var key models.EpisodeKey
+	err := r.db.Where("id = ? AND episode_id = ?", id, episodeID).First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListKeysNotSealedWith 获取由其他主密钥加密的内容密钥
+func (r *transcodeRepository) ListKeysNotSealedWith(masterKeyID string, afterID uint, limit int) ([]models.EpisodeKey, error) {
+	var keys []models.EpisodeKey
+	err := r.db.Where("master_key_id <> ? AND id > ?", masterKeyID, afterID).Order("id ASC").Limit(limit).Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// DeleteKeys 删除剧集的内容密钥
+func (r *transcodeRepository) DeleteKeys(episodeID, keepJobID uint) error {
+	return r.db.Where("episode_id = ? AND job_id <> ?", episodeID, keepJobID).Delete(&models.EpisodeKey{}).Error
+}
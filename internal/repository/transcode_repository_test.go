@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// TranscodeRepositoryTestSuite 视频转码仓库测试套件
+type TranscodeRepositoryTestSuite struct {
+	suite.Suite
+	db   *gorm.DB
+	repo TranscodeRepository
+}
+
+// SetupSuite 设置测试套件
+func (suite *TranscodeRepositoryTestSuite) SetupSuite() {
+	suite.db = testutil.SetupTestDB()
+	suite.repo = NewTranscodeRepository(suite.db)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *TranscodeRepositoryTestSuite) TearDownSuite() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *TranscodeRepositoryTestSuite) SetupTest() {
+	testutil.CleanupTestDB(suite.db)
+}
+
+// newJob 创建测试任务
+func (suite *TranscodeRepositoryTestSuite) newJob(episodeID uint) *models.TranscodeJob {
+	job := &models.TranscodeJob{EpisodeID: episodeID, SourceAssetID: 1, SourceKey: "videos/a.mp4", Status: models.TranscodeStatusPending}
+	suite.Require().NoError(suite.repo.CreateJob(job))
+	return job
+}
+
+// TestClaimNextJob 测试按创建顺序领取任务
+func (suite *TranscodeRepositoryTestSuite) TestClaimNextJob() {
+	now := time.Now()
+	first := suite.newJob(1)
+	second := suite.newJob(2)
+
+	job, err := suite.repo.ClaimNextJob(now, time.Hour, 3)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(job)
+	assert.Equal(suite.T(), first.ID, job.ID)
+	assert.Equal(suite.T(), models.TranscodeStatusRunning, job.Status)
+	assert.Equal(suite.T(), 1, job.Attempts)
+
+	job, err = suite.repo.ClaimNextJob(now, time.Hour, 3)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), second.ID, job.ID)
+
+	job, err = suite.repo.ClaimNextJob(now, time.Hour, 3)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), job)
+}
+
+// TestClaimStaleJob 测试重新领取超时的任务
+func (suite *TranscodeRepositoryTestSuite) TestClaimStaleJob() {
+	now := time.Now()
+	stale := suite.newJob(1)
+	exhausted := suite.newJob(2)
+	started := now.Add(-2 * time.Hour)
+	suite.db.Model(stale).Updates(map[string]interface{}{"status": models.TranscodeStatusRunning, "attempts": 1, "started_at": started})
+	suite.db.Model(exhausted).Updates(map[string]interface{}{"status": models.TranscodeStatusRunning, "attempts": 3, "started_at": started})
+
+	job, err := suite.repo.ClaimNextJob(now, time.Hour, 3)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(job)
+	assert.Equal(suite.T(), stale.ID, job.ID)
+	assert.Equal(suite.T(), 2, job.Attempts)
+
+	var failed models.TranscodeJob
+	suite.Require().NoError(suite.db.First(&failed, exhausted.ID).Error)
+	assert.Equal(suite.T(), models.TranscodeStatusFailed, failed.Status)
+
+	// 未超时的任务不会被重新领取
+	job, err = suite.repo.ClaimNextJob(now, time.Hour, 3)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), job)
+}
+
+// TestReplaceRenditions 测试替换转码结果
+func (suite *TranscodeRepositoryTestSuite) TestReplaceRenditions() {
+	old, err := suite.repo.ReplaceRenditions(1, []models.EpisodeRendition{
+		{EpisodeID: 1, JobID: 1, Name: "720p", Bitrate: 2800000, PlaylistKey: "hls/1/1/720p/index.m3u8"},
+		{EpisodeID: 1, JobID: 1, Name: "360p", Bitrate: 800000, PlaylistKey: "hls/1/1/360p/index.m3u8"},
+	})
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), old)
+
+	renditions, err := suite.repo.ListRenditions(1)
+	suite.Require().NoError(err)
+	suite.Require().Len(renditions, 2)
+	assert.Equal(suite.T(), "360p", renditions[0].Name)
+
+	old, err = suite.repo.ReplaceRenditions(1, nil)
+	suite.Require().NoError(err)
+	assert.Len(suite.T(), old, 2)
+
+	renditions, err = suite.repo.ListRenditions(1)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), renditions)
+}
+
+// TestEpisodeKeys 测试内容密钥的查询、轮换与清理
+func (suite *TranscodeRepositoryTestSuite) TestEpisodeKeys() {
+	keys := []*models.EpisodeKey{
+		{EpisodeID: 1, JobID: 1, MasterKeyID: "m1", EncryptedKey: "a"},
+		{EpisodeID: 1, JobID: 2, MasterKeyID: "m2", EncryptedKey: "b"},
+		{EpisodeID: 2, JobID: 3, MasterKeyID: "m1", EncryptedKey: "c"},
+	}
+	for _, key := range keys {
+		suite.Require().NoError(suite.repo.CreateKey(key))
+	}
+
+	found, err := suite.repo.GetKey(1, keys[1].ID)
+	suite.Require().NoError(err)
+	suite.Require().NotNil(found)
+	assert.Equal(suite.T(), "b", found.EncryptedKey)
+	found, err = suite.repo.GetKey(2, keys[1].ID)
+	suite.Require().NoError(err)
+	assert.Nil(suite.T(), found, "密钥属于其他剧集")
+
+	stale, err := suite.repo.ListKeysNotSealedWith("m2", 0, 1)
+	suite.Require().NoError(err)
+	suite.Require().Len(stale, 1)
+	assert.Equal(suite.T(), keys[0].ID, stale[0].ID)
+	stale, err = suite.repo.ListKeysNotSealedWith("m2", stale[0].ID, 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(stale, 1)
+	assert.Equal(suite.T(), keys[2].ID, stale[0].ID)
+
+	suite.Require().NoError(suite.repo.DeleteKeys(1, 2))
+	found, _ = suite.repo.GetKey(1, keys[0].ID)
+	assert.Nil(suite.T(), found)
+	found, _ = suite.repo.GetKey(1, keys[1].ID)
+	assert.NotNil(suite.T(), found, "保留当前任务的密钥")
+
+	suite.Require().NoError(suite.repo.DeleteKeys(1, 0))
+	found, _ = suite.repo.GetKey(1, keys[1].ID)
+	assert.Nil(suite.T(), found)
+	found, _ = suite.repo.GetKey(2, keys[2].ID)
+	assert.NotNil(suite.T(), found, "不影响其他剧集")
+}
+
+// TestTranscodeRepositoryTestSuite 运行视频转码仓库测试套件
+func TestTranscodeRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(TranscodeRepositoryTestSuite))
+}
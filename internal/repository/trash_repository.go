@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gin-mysql-api/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// trashRepository 回收站仓库实现
+type trashRepository struct {
+	db *gorm.DB
+}
+
+// NewTrashRepository 创建回收站仓库实例
+func NewTrashRepository(db *gorm.DB) TrashRepository {
+	return &trashRepository{db: db}
+}
+
+// WithContext 返回绑定上下文的回收站仓库
+func (r *trashRepository) WithContext(ctx context.Context) TrashRepository {
+	return &trashRepository{db: r.db.WithContext(ctx)}
+}
+
+// ListDramas 按删除时间倒序分页获取已删除的短剧
+func (r *trashRepository) ListDramas(offset, limit int) ([]models.Drama, int64, error) {
+	var dramas []models.Drama
+	var total int64
+
+	query := r.db.Unscoped().Model(&models.Drama{}).Where("deleted_at IS NOT NULL")
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("deleted_at DESC, id DESC").Offset(offset).Limit(limit).Find(&dramas).Error; err != nil {
+		return nil, 0, err
+	}
+	return dramas, total, nil
+}
+
+// ListEpisodes 按删除时间倒序分页获取单独删除的剧集
+func (r *trashRepository) ListEpisodes(offset, limit int) ([]models.Episode, int64, error) {
+	var episodes []models.Episode
+	var total int64
+
+	query := r.db.Unscoped().Model(&models.Episode{}).
+		Where("deleted_at IS NOT NULL AND drama_id IN (SELECT id FROM dramas WHERE deleted_at IS NULL)")
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("deleted_at DESC, id DESC").Offset(offset).Limit(limit).Find(&episodes).Error; err != nil {
+		return nil, 0, err
+	}
+	return episodes, total, nil
+}
+
+// GetDrama 获取已删除的短剧
+func (r *trashRepository) GetDrama(id uint) (*models.Drama, error) {
+	var drama models.Drama
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&drama, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &drama, nil
+}
+
+// GetEpisode 获取已删除的剧集
+func (r *trashRepository) GetEpisode(id uint) (*models.Episode, error) {
+	var episode models.Episode
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&episode, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &episode, nil
+}
+
+// ListEpisodesByDramaID 获取短剧的所有剧集，包括已删除的剧集
+func (r *trashRepository) ListEpisodesByDramaID(dramaID uint) ([]models.Episode, error) {
+	var episodes []models.Episode
+	err := r.db.Unscoped().Where("drama_id = ?", dramaID).Order("episode_num ASC").Find(&episodes).Error
+	return episodes, err
+}
+
+// RestoreDrama 在一个事务中恢复短剧及删除时间与短剧相同的剧集，删除短剧之前单独删除的剧集仍留在回收站
+func (r *trashRepository) RestoreDrama(id uint) (bool, error) {
+	restored := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&models.Episode{}).
+			Where("drama_id = ? AND deleted_at = (SELECT deleted_at FROM dramas WHERE id = ? AND deleted_at IS NOT NULL)", id, id).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return result.Error
+		}
+		result = tx.Unscoped().Model(&models.Drama{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+		restored = result.RowsAffected > 0
+		return result.Error
+	})
+	return restored && err == nil, err
+}
+
+// RestoreEpisode 恢复剧集
+func (r *trashRepository) RestoreEpisode(id uint) (bool, error) {
+	result := r.db.Unscoped().Model(&models.Episode{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	return result.RowsAffected > 0, result.Error
+}
+
+// PurgeDrama 永久删除回收站中的短剧及其所有剧集
+func (r *trashRepository) PurgeDrama(id uint) ([]models.EpisodeRendition, error) {
+	var renditions []models.EpisodeRendition
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var drama models.Drama
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&drama, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		var episodeIDs []uint
+		if err := tx.Unscoped().Model(&models.Episode{}).Where("drama_id = ?", id).Pluck("id", &episodeIDs).Error; err != nil {
+			return err
+		}
+		var err error
+		if renditions, err = purgeEpisodes(tx, episodeIDs); err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&models.StatusTransition{}, &models.ContentRevision{}} {
+			if err := tx.Where("entity_type = ? AND entity_id = ?", models.ReviewEntityDrama, id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(&drama).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return renditions, nil
+}
+
+// PurgeEpisode 永久删除回收站中的剧集
+func (r *trashRepository) PurgeEpisode(id uint) ([]models.EpisodeRendition, error) {
+	var renditions []models.EpisodeRendition
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var episodeIDs []uint
+		if err := tx.Unscoped().Model(&models.Episode{}).Where("id = ? AND deleted_at IS NOT NULL", id).Pluck("id", &episodeIDs).Error; err != nil {
+			return err
+		}
+		var err error
+		renditions, err = purgeEpisodes(tx, episodeIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return renditions, nil
+}
+
+// ListDramasDeletedBefore 获取在 cutoff 之前删除的短剧
+func (r *trashRepository) ListDramasDeletedBefore(cutoff time.Time, limit int) ([]models.Drama, error) {
+	var dramas []models.Drama
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Order("deleted_at").Limit(limit).Find(&dramas).Error
+	return dramas, err
+}
+
+// ListEpisodesDeletedBefore 获取在 cutoff 之前删除的剧集
+func (r *trashRepository) ListEpisodesDeletedBefore(cutoff time.Time, limit int) ([]models.Episode, error) {
+	var episodes []models.Episode
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Order("deleted_at").Limit(limit).Find(&episodes).Error
+	return episodes, err
+}
+
+// purgeEpisodes 在事务中永久删除剧集及其转码任务、转码结果、内容密钥、状态流转记录与修订版本，返回被删除的转码结果
+func purgeEpisodes(tx *gorm.DB, episodeIDs []uint) ([]models.EpisodeRendition, error) {
+	if len(episodeIDs) == 0 {
+		return nil, nil
+	}
+
+	var renditions []models.EpisodeRendition
+	if err := tx.Where("episode_id IN ?", episodeIDs).Find(&renditions).Error; err != nil {
+		return nil, err
+	}
+	for _, model := range []interface{}{&models.EpisodeRendition{}, &models.EpisodeKey{}, &models.TranscodeJob{}} {
+		if err := tx.Where("episode_id IN ?", episodeIDs).Delete(model).Error; err != nil {
+			return nil, err
+		}
+	}
+	for _, model := range []interface{}{&models.StatusTransition{}, &models.ContentRevision{}} {
+		if err := tx.Where("entity_type = ? AND entity_id IN ?", models.ReviewEntityEpisode, episodeIDs).Delete(model).Error; err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Unscoped().Delete(&models.Episode{}, episodeIDs).Error; err != nil {
+		return nil, err
+	}
+	return renditions, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// TrashRepositoryTestSuite 回收站仓库测试套件
+type TrashRepositoryTestSuite struct {
+	suite.Suite
+	db      *gorm.DB
+	repo    TrashRepository
+	dramas  DramaRepository
+	factory *testutil.Factory
+}
+
+// SetupSuite 设置测试套件
+func (suite *TrashRepositoryTestSuite) SetupSuite() {
+	suite.db = testutil.SetupTestDB()
+	suite.repo = NewTrashRepository(suite.db)
+	suite.dramas = NewDramaRepository(suite.db)
+	suite.factory = testutil.NewFactory()
+}
+
+// TearDownSuite 清理测试套件
+func (suite *TrashRepositoryTestSuite) TearDownSuite() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *TrashRepositoryTestSuite) SetupTest() {
+	testutil.CleanupTestDB(suite.db)
+}
+
+// createDrama 创建包含 count 集的短剧
+func (suite *TrashRepositoryTestSuite) createDrama(count int) (*models.Drama, []*models.Episode) {
+	drama := suite.factory.Drama.CreateDrama()
+	suite.Require().NoError(suite.db.Create(drama).Error)
+	episodes := suite.factory.Episode.CreateEpisodes(drama.ID, count)
+	for _, episode := range episodes {
+		suite.Require().NoError(suite.db.Create(episode).Error)
+	}
+	return drama, episodes
+}
+
+// TestDeleteAndRestoreDrama 测试删除短剧时一并删除剧集，恢复时只恢复一同删除的剧集
+func (suite *TrashRepositoryTestSuite) TestDeleteAndRestoreDrama() {
+	drama, episodes := suite.createDrama(3)
+	// 先单独删除第三集
+	suite.Require().NoError(suite.db.Delete(episodes[2]).Error)
+	suite.Require().NoError(suite.db.Unscoped().Model(episodes[2]).Update("deleted_at", time.Now().Add(-time.Hour)).Error)
+
+	suite.Require().NoError(suite.dramas.Delete(drama.ID))
+	var active int64
+	suite.Require().NoError(suite.db.Model(&models.Episode{}).Where("drama_id = ?", drama.ID).Count(&active).Error)
+	assert.Zero(suite.T(), active, "剧集随短剧一起删除")
+
+	dramas, total, err := suite.repo.ListDramas(0, 10)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), int64(1), total)
+	assert.Equal(suite.T(), drama.ID, dramas[0].ID)
+	_, total, err = suite.repo.ListEpisodes(0, 10)
+	suite.Require().NoError(err)
+	assert.Zero(suite.T(), total, "所属短剧已删除的剧集不单独列出")
+
+	ok, err := suite.repo.RestoreDrama(drama.ID)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), ok)
+	suite.Require().NoError(suite.db.Model(&models.Episode{}).Where("drama_id = ?", drama.ID).Count(&active).Error)
+	assert.Equal(suite.T(), int64(2), active)
+
+	deleted, total, err := suite.repo.ListEpisodes(0, 10)
+	suite.Require().NoError(err)
+	suite.Require().Equal(int64(1), total, "单独删除的剧集仍在回收站")
+	assert.Equal(suite.T(), episodes[2].ID, deleted[0].ID)
+
+	ok, err = suite.repo.RestoreDrama(drama.ID)
+	suite.Require().NoError(err)
+	assert.False(suite.T(), ok, "短剧不在回收站中")
+	ok, err = suite.repo.RestoreEpisode(episodes[2].ID)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), ok)
+}
+
+// TestPurgeDrama 测试永久删除短剧及其剧集的关联数据
+func (suite *TrashRepositoryTestSuite) TestPurgeDrama() {
+	drama, episodes := suite.createDrama(2)
+	suite.Require().NoError(suite.db.Create(&models.EpisodeRendition{EpisodeID: episodes[0].ID, Name: "720p", PlaylistKey: "hls/1/1/720p/index.m3u8"}).Error)
+	suite.Require().NoError(suite.db.Create(&models.TranscodeJob{EpisodeID: episodes[0].ID, SourceKey: "videos/a.mp4", Status: models.TranscodeStatusCompleted}).Error)
+	suite.Require().NoError(suite.db.Create(&models.StatusTransition{EntityType: models.ReviewEntityEpisode, EntityID: episodes[1].ID, FromStatus: models.StatusDraft, ToStatus: models.StatusInReview}).Error)
+	suite.Require().NoError(suite.db.Create(&models.ContentRevision{EntityType: models.ReviewEntityDrama, EntityID: drama.ID, Version: 1, Snapshot: map[string]interface{}{"title": drama.Title}}).Error)
+
+	renditions, err := suite.repo.PurgeDrama(drama.ID)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), renditions, "不在回收站中的短剧不会被删除")
+
+	suite.Require().NoError(suite.dramas.Delete(drama.ID))
+	renditions, err = suite.repo.PurgeDrama(drama.ID)
+	suite.Require().NoError(err)
+	suite.Require().Len(renditions, 1)
+	assert.Equal(suite.T(), "hls/1/1/720p/index.m3u8", renditions[0].PlaylistKey)
+
+	for _, model := range []interface{}{&models.Drama{}, &models.Episode{}, &models.EpisodeRendition{}, &models.TranscodeJob{}, &models.StatusTransition{}, &models.ContentRevision{}} {
+		var count int64
+		suite.Require().NoError(suite.db.Unscoped().Model(model).Count(&count).Error)
+		assert.Zero(suite.T(), count, "%T", model)
+	}
+}
+
+// TestListDeletedBefore 测试获取超过保留期的短剧与剧集
+func (suite *TrashRepositoryTestSuite) TestListDeletedBefore() {
+	drama, episodes := suite.createDrama(2)
+	suite.Require().NoError(suite.db.Delete(episodes[0]).Error)
+
+	expired, err := suite.repo.ListEpisodesDeletedBefore(time.Now().Add(time.Minute), 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(expired, 1)
+	assert.Equal(suite.T(), episodes[0].ID, expired[0].ID)
+	expired, err = suite.repo.ListEpisodesDeletedBefore(time.Now().Add(-time.Minute), 10)
+	suite.Require().NoError(err)
+	assert.Empty(suite.T(), expired)
+
+	suite.Require().NoError(suite.dramas.Delete(drama.ID))
+	dramas, err := suite.repo.ListDramasDeletedBefore(time.Now().Add(time.Minute), 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(dramas, 1)
+	assert.Equal(suite.T(), drama.ID, dramas[0].ID)
+}
+
+// TestTrashRepositoryTestSuite 运行回收站仓库测试套件
+func TestTrashRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(TrashRepositoryTestSuite))
+}
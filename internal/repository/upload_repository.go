@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gin-mysql-api/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// uploadSessionRepository 分片上传会话仓库实现
+type uploadSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewUploadSessionRepository 创建分片上传会话仓库实例
+func NewUploadSessionRepository(db *gorm.DB) UploadSessionRepository {
+	return &uploadSessionRepository{db: db}
+}
+
+// WithContext 返回绑定上下文的分片上传会话仓库
+func (r *uploadSessionRepository) WithContext(ctx context.Context) UploadSessionRepository {
+	return &uploadSessionRepository{db: r.db.WithContext(ctx)}
+}
+
+// Create 创建上传会话
+func (r *uploadSessionRepository) Create(session *models.UploadSession) error {
+	return r.db.Create(session).Error
+}
+
+// GetByID 根据ID获取上传会话（包含已上传的分片，按分片号排序）
+func (r *uploadSessionRepository) GetByID(id string) (*models.UploadSession, error) {
+	var session models.UploadSession
+	err := r.db.Preload("Parts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("part_number ASC")
+	}).First(&session, "id = ?", id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Update 更新上传会话
+func (r *uploadSessionRepository) Update(session *models.UploadSession) error {
+	return r.db.Omit(clause.Associations).Save(session).Error
+}
+
+// UpdateStatus 仅当会话处于 from 状态时更新为 to，返回是否更新成功，用于防止重复合并
+func (r *uploadSessionRepository) UpdateStatus(id, from, to string) (bool, error) {
+	result := r.db.Model(&models.UploadSession{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Delete 删除上传会话及其分片记录
+func (r *uploadSessionRepository) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", id).Delete(&models.UploadPart{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.UploadSession{}, "id = ?", id).Error
+	})
+}
+
+// SavePart 保存分片记录，重复上传的分片覆盖原记录
+func (r *uploadSessionRepository) SavePart(part *models.UploadPart) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}, {Name: "part_number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"size", "checksum", "created_at"}),
+	}).Create(part).Error
+}
+
+// ListExpired 获取在 before 之前过期的上传会话
+func (r *uploadSessionRepository) ListExpired(before time.Time, limit int) ([]models.UploadSession, error) {
+	var sessions []models.UploadSession
+	if err := r.db.Where("expires_at <= ?", before).
+		Order("expires_at ASC").Limit(limit).Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// UploadSessionRepositoryTestSuite 分片上传会话仓库测试套件
+type UploadSessionRepositoryTestSuite struct {
+	suite.Suite
+	db   *gorm.DB
+	repo UploadSessionRepository
+}
+
+// SetupSuite 设置测试套件
+func (suite *UploadSessionRepositoryTestSuite) SetupSuite() {
+	suite.db = testutil.SetupTestDB()
+	suite.repo = NewUploadSessionRepository(suite.db)
+}
+
+// TearDownSuite 清理测试套件
+func (suite *UploadSessionRepositoryTestSuite) TearDownSuite() {
+	sqlDB, _ := suite.db.DB()
+	sqlDB.Close()
+}
+
+// SetupTest 每个测试前的设置
+func (suite *UploadSessionRepositoryTestSuite) SetupTest() {
+	testutil.CleanupTestDB(suite.db)
+}
+
+// newSession 创建测试会话
+func (suite *UploadSessionRepositoryTestSuite) newSession(id string, expiresAt time.Time) *models.UploadSession {
+	session := &models.UploadSession{
+		ID:          id,
+		OwnerID:     1,
+		OwnerRole:   "user",
+		Filename:    "episode.mp4",
+		UploadType:  "videos",
+		Size:        10,
+		ChunkSize:   4,
+		TotalChunks: 3,
+		Status:      models.UploadStatusUploading,
+		ExpiresAt:   expiresAt,
+	}
+	suite.Require().NoError(suite.repo.Create(session))
+	return session
+}
+
+// TestGetByID 测试获取会话及分片
+func (suite *UploadSessionRepositoryTestSuite) TestGetByID() {
+	suite.newSession("s1", time.Now().Add(time.Hour))
+	suite.Require().NoError(suite.repo.SavePart(&models.UploadPart{SessionID: "s1", PartNumber: 2, Size: 4, Checksum: "a"}))
+	suite.Require().NoError(suite.repo.SavePart(&models.UploadPart{SessionID: "s1", PartNumber: 1, Size: 4, Checksum: "b"}))
+
+	// 重复上传的分片覆盖原记录
+	suite.Require().NoError(suite.repo.SavePart(&models.UploadPart{SessionID: "s1", PartNumber: 2, Size: 4, Checksum: "c"}))
+
+	session, err := suite.repo.GetByID("s1")
+	suite.Require().NoError(err)
+	suite.Require().Len(session.Parts, 2)
+	assert.Equal(suite.T(), 1, session.Parts[0].PartNumber)
+	assert.Equal(suite.T(), "c", session.Parts[1].Checksum)
+
+	missing, err := suite.repo.GetByID("missing")
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), missing)
+}
+
+// TestUpdateStatus 测试按状态条件更新
+func (suite *UploadSessionRepositoryTestSuite) TestUpdateStatus() {
+	suite.newSession("s1", time.Now().Add(time.Hour))
+
+	ok, err := suite.repo.UpdateStatus("s1", models.UploadStatusUploading, models.UploadStatusCompleting)
+	suite.Require().NoError(err)
+	assert.True(suite.T(), ok)
+
+	ok, err = suite.repo.UpdateStatus("s1", models.UploadStatusUploading, models.UploadStatusCompleting)
+	suite.Require().NoError(err)
+	assert.False(suite.T(), ok)
+}
+
+// TestListExpiredAndDelete 测试查询过期会话并删除
+func (suite *UploadSessionRepositoryTestSuite) TestListExpiredAndDelete() {
+	now := time.Now()
+	suite.newSession("expired", now.Add(-time.Minute))
+	suite.newSession("active", now.Add(time.Hour))
+	suite.Require().NoError(suite.repo.SavePart(&models.UploadPart{SessionID: "expired", PartNumber: 1, Size: 4, Checksum: "a"}))
+
+	sessions, err := suite.repo.ListExpired(now, 10)
+	suite.Require().NoError(err)
+	suite.Require().Len(sessions, 1)
+	assert.Equal(suite.T(), "expired", sessions[0].ID)
+
+	suite.Require().NoError(suite.repo.Delete("expired"))
+	var parts int64
+	suite.db.Model(&models.UploadPart{}).Where("session_id = ?", "expired").Count(&parts)
+	assert.Zero(suite.T(), parts)
+}
+
+// TestUploadSessionRepositoryTestSuite 运行分片上传会话仓库测试套件
+func TestUploadSessionRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(UploadSessionRepositoryTestSuite))
+}
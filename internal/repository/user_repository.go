@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"errors"
 	"gorm.io/gorm"
 	"gin-mysql-api/internal/models"
@@ -16,6 +17,11 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+// WithContext 返回绑定上下文的用户仓库
+func (r *userRepository) WithContext(ctx context.Context) UserRepository {
+	return &userRepository{db: r.db.WithContext(ctx)}
+}
+
 // Create 创建用户
 func (r *userRepository) Create(user *models.User) error {
 	if err := r.db.Create(user).Error; err != nil {
@@ -3,7 +3,6 @@ package repository
 import (
 	"testing"
 
-	"gin-mysql-api/internal/models"
 	"gin-mysql-api/internal/testutil"
 
 	"github.com/stretchr/testify/assert"
@@ -62,8 +61,9 @@ func (suite *UserRepositoryTestSuite) TestGetByID() {
 	assert.Equal(suite.T(), user.Email, foundUser.Email)
 
 	// 测试不存在的用户
-	_, err = suite.repo.GetByID(999)
-	assert.Error(suite.T(), err)
+	foundUser, err = suite.repo.GetByID(999)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), foundUser)
 }
 
 // TestGetByEmail 测试根据邮箱获取用户
@@ -80,8 +80,9 @@ func (suite *UserRepositoryTestSuite) TestGetByEmail() {
 	assert.Equal(suite.T(), user.ID, foundUser.ID)
 
 	// 测试不存在的邮箱
-	_, err = suite.repo.GetByEmail("nonexistent@example.com")
-	assert.Error(suite.T(), err)
+	foundUser, err = suite.repo.GetByEmail("nonexistent@example.com")
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), foundUser)
 }
 
 // TestGetByUsername 测试根据用户名获取用户
@@ -98,8 +99,9 @@ func (suite *UserRepositoryTestSuite) TestGetByUsername() {
 	assert.Equal(suite.T(), user.ID, foundUser.ID)
 
 	// 测试不存在的用户名
-	_, err = suite.repo.GetByUsername("nonexistent")
-	assert.Error(suite.T(), err)
+	foundUser, err = suite.repo.GetByUsername("nonexistent")
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), foundUser)
 }
 
 // TestUpdate 测试更新用户
@@ -134,8 +136,9 @@ func (suite *UserRepositoryTestSuite) TestDelete() {
 	assert.NoError(suite.T(), err)
 
 	// 验证删除
-	_, err = suite.repo.GetByID(user.ID)
-	assert.Error(suite.T(), err)
+	foundUser, err := suite.repo.GetByID(user.ID)
+	assert.NoError(suite.T(), err)
+	assert.Nil(suite.T(), foundUser)
 }
 
 // TestList 测试获取用户列表
@@ -4,6 +4,9 @@ import (
 	"gin-mysql-api/internal/handler"
 	"gin-mysql-api/internal/middleware"
 	"gin-mysql-api/internal/service"
+	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/health"
+	"gin-mysql-api/pkg/metrics"
 	"gin-mysql-api/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -11,9 +14,13 @@ import (
 
 // Router 路由配置
 type Router struct {
-	engine     *gin.Engine
-	jwtManager *utils.JWTManager
-	services   *service.Container
+	engine        *gin.Engine
+	jwtManager    *utils.JWTManager
+	services      *service.Container
+	metricsConfig *config.MetricsConfig
+	health        *health.Registry
+	config        *config.Config
+	middleware    *middleware.Manager
 }
 
 // NewRouter 创建新的路由器
@@ -27,6 +34,31 @@ func NewRouter(jwtManager *utils.JWTManager, services *service.Container) *Route
 	}
 }
 
+// WithMetrics 启用 Prometheus 指标接口
+func (r *Router) WithMetrics(cfg config.MetricsConfig) *Router {
+	r.metricsConfig = &cfg
+	return r
+}
+
+// WithConfig 使用应用配置组装中间件链，未设置时使用各配置项的默认值
+func (r *Router) WithConfig(cfg *config.Config) *Router {
+	r.config = cfg
+	return r
+}
+
+// Reload 应用热更新的配置（CORS 来源与限流），需在 Setup 之后调用
+func (r *Router) Reload(cfg *config.Config) {
+	if r.middleware != nil {
+		r.middleware.Reload(cfg)
+	}
+}
+
+// WithHealth 使用依赖探测注册表进行就绪检查
+func (r *Router) WithHealth(registry *health.Registry) *Router {
+	r.health = registry
+	return r
+}
+
 // Setup 设置路由
 func (r *Router) Setup() *gin.Engine {
 	// 设置中间件
@@ -38,67 +70,42 @@ func (r *Router) Setup() *gin.Engine {
 	return r.engine
 }
 
-// setupMiddleware 设置中间件
+// setupMiddleware 设置中间件，中间件链由配置驱动，见 middleware.Manager
 func (r *Router) setupMiddleware() {
-	// 错误处理中间件
-	r.engine.Use(middleware.ErrorHandler())
-
-	// 请求 ID 中间件
-	r.engine.Use(middleware.RequestIDMiddleware())
-
-	// 日志中间件
-	r.engine.Use(middleware.Logger())
-
-	// 安全中间件
-	r.engine.Use(middleware.Security())
-
-	// CORS 中间件
-	corsConfig := middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders: []string{
-			"Origin", "Content-Type", "Content-Length",
-			"Accept-Encoding", "X-CSRF-Token", "Authorization",
-			"X-Request-ID",
-		},
-		ExposeHeaders:    []string{"X-Request-ID"},
-		AllowCredentials: false,
-		MaxAge:           86400,
+	cfg := r.config
+	if cfg == nil {
+		cfg = &config.Config{}
 	}
-	r.engine.Use(middleware.CORS(corsConfig))
-
-	// 设置CSP头部，允许加载外部图片和视频
-	r.engine.Use(func(c *gin.Context) {
-		c.Header("Content-Security-Policy", "default-src 'self'; img-src 'self' data: https: http:; media-src 'self' https: http:; script-src 'self' 'unsafe-inline' 'unsafe-eval'; style-src 'self' 'unsafe-inline'; font-src 'self' data: https:;")
-		c.Next()
-	})
-
-	// 请求大小限制中间件
-	r.engine.Use(middleware.RequestSizeLimit(10 * 1024 * 1024)) // 10MB
 
-	// 简单限流中间件
-	r.engine.Use(middleware.SimpleRateLimit())
-
-	// 404 和 405 处理
-	r.engine.NoRoute(middleware.NotFoundHandler())
-	r.engine.NoMethod(middleware.MethodNotAllowedHandler())
+	r.middleware = middleware.NewManager(cfg)
+	r.middleware.SetupMiddlewares(r.engine)
 }
 
 // setupRoutes 设置路由
 func (r *Router) setupRoutes() {
 	// 创建处理器
-	healthHandler := handler.NewHealthHandler()
+	healthHandler := handler.NewHealthHandler(r.health)
 	authHandler := handler.NewAuthHandler(r.services.AuthService)
 	userHandler := handler.NewUserHandler(r.services.UserService)
-	dramaHandler := handler.NewDramaHandler(r.services.DramaService)
-	adminHandler := handler.NewAdminHandler(r.services.AdminService, r.services.UserService)
-	fileHandler := handler.NewFileHandler(r.services.FileService)
+	dramaHandler := handler.NewDramaHandler(r.services.DramaService, r.services.PlaybackService, r.services.PreviewService)
+	adminHandler := handler.NewAdminHandler(r.services.AdminService, r.services.UserService, r.services.PlaybackService, r.services.PublishService)
+	fileHandler := handler.NewFileHandler(r.services.FileService, r.services.PlaybackService)
+	uploadHandler := handler.NewUploadHandler(r.services.UploadService)
+	mediaHandler := handler.NewMediaHandler(r.services.ImageService, r.services.FileService, r.services.PlaybackService)
+	reviewHandler := handler.NewReviewHandler(r.services.ReviewService, r.services.PreviewService)
+	trashHandler := handler.NewTrashHandler(r.services.TrashService)
+	revisionHandler := handler.NewRevisionHandler(r.services.RevisionService)
 
 	// 健康检查路由
 	r.engine.GET("/health", healthHandler.HealthCheck)
 	r.engine.GET("/ready", healthHandler.ReadinessCheck)
 	r.engine.GET("/live", healthHandler.LivenessCheck)
 
+	// 监控指标路由
+	if r.metricsConfig != nil && r.metricsConfig.Enabled {
+		r.engine.GET(r.metricsConfig.GetPath(), middleware.MetricsAuth(*r.metricsConfig), gin.WrapH(metrics.Handler()))
+	}
+
 	// API 路由组
 	api := r.engine.Group("/api")
 	{
@@ -131,15 +138,26 @@ func (r *Router) setupRoutes() {
 			dramas.GET("", dramaHandler.GetDramas)
 			dramas.GET("/search", dramaHandler.SearchDramas)
 			dramas.GET("/popular", dramaHandler.GetPopularDramas)
-			dramas.GET("/:id", dramaHandler.GetDramaByID)
-			dramas.GET("/:id/episodes", dramaHandler.GetDramaWithEpisodes)
-			dramas.GET("/:id/episodes/list", dramaHandler.GetEpisodesByDramaID)
+			// 管理员登录时可以查看未发布的内容
+			dramas.GET("/:id", middleware.OptionalAuthMiddleware(r.jwtManager), dramaHandler.GetDramaByID)
+			dramas.GET("/:id/episodes", middleware.OptionalAuthMiddleware(r.jwtManager), dramaHandler.GetDramaWithEpisodes)
+			dramas.GET("/:id/episodes/list", middleware.OptionalAuthMiddleware(r.jwtManager), dramaHandler.GetEpisodesByDramaID)
 		}
 
 		// 剧集路由（公开）
 		episodes := api.Group("/episodes")
 		{
-			episodes.GET("/:id", dramaHandler.GetEpisodeByID)
+			// 登录用户的播放地址绑定用户，配置 playback.requireLogin 时匿名用户不能获取播放地址
+			episodes.GET("/:id", middleware.OptionalAuthMiddleware(r.jwtManager), dramaHandler.GetEpisodeByID)
+			episodes.GET("/:id/master.m3u8", middleware.OptionalAuthMiddleware(r.jwtManager), dramaHandler.GetMasterPlaylist)
+			// 受保护剧集的 HLS 内容密钥，播放器需要携带 Authorization 请求头，预览时可以用 X-Preview-Token 请求头代替
+			episodes.GET("/:id/keys/:key_id", middleware.OptionalAuthMiddleware(r.jwtManager), dramaHandler.GetContentKey)
+		}
+
+		// 图片规格图路由（公开）
+		media := api.Group("/media")
+		{
+			media.GET("/variants/:name/*path", mediaHandler.GetVariant)
 		}
 
 		// 文件上传路由
@@ -148,6 +166,13 @@ func (r *Router) setupRoutes() {
 		{
 			upload.POST("", fileHandler.UploadFile)
 			upload.DELETE("", fileHandler.DeleteFile)
+
+			// 分片断点续传
+			upload.POST("/sessions", uploadHandler.CreateSession)
+			upload.GET("/sessions/:id", uploadHandler.GetSession)
+			upload.PUT("/sessions/:id/parts/:part", uploadHandler.UploadPart)
+			upload.POST("/sessions/:id/complete", uploadHandler.CompleteSession)
+			upload.DELETE("/sessions/:id", uploadHandler.AbortSession)
 		}
 
 		// 管理员路由
@@ -159,18 +184,48 @@ func (r *Router) setupRoutes() {
 			{
 				adminDramas.GET("", adminHandler.GetDramaList)
 				adminDramas.POST("", adminHandler.CreateDrama)
-				adminDramas.PUT("/:id", adminHandler.UpdateDrama)
+				adminDramas.PATCH("/:id", adminHandler.UpdateDrama)
+				adminDramas.PUT("/:id", adminHandler.UpdateDrama) // 与 PATCH 相同，保留兼容
 				adminDramas.DELETE("/:id", adminHandler.DeleteDrama)
 				adminDramas.GET("/:drama_id/episodes", adminHandler.GetEpisodeList)
+				adminDramas.POST("/:id/schedule", adminHandler.ScheduleEpisodes)
+				// 审核流程：状态只能通过状态流转变更
+				adminDramas.POST("/:id/transitions", reviewHandler.TransitionDrama)
+				adminDramas.GET("/:drama_id/transitions", reviewHandler.GetDramaTransitions)
+				adminDramas.POST("/:id/preview-token", reviewHandler.CreateDramaPreviewToken)
+				// 修订版本：每次更新保存内容快照，可以比较与回滚
+				adminDramas.GET("/:drama_id/revisions", revisionHandler.GetDramaRevisions)
+				adminDramas.GET("/:drama_id/revisions/diff", revisionHandler.DiffDramaRevisions)
+				adminDramas.POST("/:id/revisions/:version/rollback", revisionHandler.RollbackDrama)
 			}
 
+			// 发布日历
+			admin.GET("/calendar", adminHandler.GetReleaseCalendar)
+
 			// 剧集管理
 			adminEpisodes := admin.Group("/episodes")
 			{
 				adminEpisodes.GET("", adminHandler.GetAllEpisodeList)
 				adminEpisodes.POST("", adminHandler.CreateEpisode)
-				adminEpisodes.PUT("/:id", adminHandler.UpdateEpisode)
+				adminEpisodes.PATCH("/:id", adminHandler.UpdateEpisode)
+				adminEpisodes.PUT("/:id", adminHandler.UpdateEpisode) // 与 PATCH 相同，保留兼容
 				adminEpisodes.DELETE("/:id", adminHandler.DeleteEpisode)
+				adminEpisodes.GET("/:id/preview", adminHandler.PreviewEpisode)
+				adminEpisodes.POST("/:id/rotate-key", adminHandler.RotateEpisodeKey)
+				adminEpisodes.POST("/:id/transitions", reviewHandler.TransitionEpisode)
+				adminEpisodes.GET("/:id/transitions", reviewHandler.GetEpisodeTransitions)
+				adminEpisodes.POST("/:id/preview-token", reviewHandler.CreateEpisodePreviewToken)
+				adminEpisodes.GET("/:id/revisions", revisionHandler.GetEpisodeRevisions)
+				adminEpisodes.GET("/:id/revisions/diff", revisionHandler.DiffEpisodeRevisions)
+				adminEpisodes.POST("/:id/revisions/:version/rollback", revisionHandler.RollbackEpisode)
+			}
+
+			// 回收站：删除的短剧与剧集可以恢复，超过保留期后永久删除
+			adminTrash := admin.Group("/trash")
+			{
+				adminTrash.GET("", trashHandler.GetTrashList)
+				adminTrash.POST("/:type/:id/restore", trashHandler.RestoreTrashItem)
+				adminTrash.DELETE("/:type/:id", trashHandler.PurgeTrashItem)
 			}
 
 			// 用户管理
@@ -183,8 +238,13 @@ func (r *Router) setupRoutes() {
 		}
 	}
 
-	// 静态文件服务
-	r.engine.Static("/uploads", "./uploads")
+	// 上传文件由服务从存储读取后提供访问，支持 Range 与条件请求；对象存储配置了 CDN 或预签名时文件 URL 不经过服务
+	r.engine.GET("/uploads/*path", fileHandler.ServeFile)
+	r.engine.HEAD("/uploads/*path", fileHandler.ServeFile)
+
+	// 开启播放地址签名时视频与 HLS 文件通过签名地址访问
+	r.engine.GET("/media/:token/*path", mediaHandler.ServeMedia)
+	r.engine.HEAD("/media/:token/*path", mediaHandler.ServeMedia)
 
 	// Vue 前端静态文件服务
 	r.engine.Static("/assets", "./web/dist/assets")
@@ -1,23 +1,33 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
 	"gin-mysql-api/internal/models"
 	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/logger"
 	"gin-mysql-api/pkg/utils"
 )
 
 // AdminService 管理服务接口
 type AdminService interface {
+	// WithContext 返回绑定请求上下文的服务，日志与查询会带上请求的链路信息
+	WithContext(ctx context.Context) AdminService
 	Login(req models.AdminLoginRequest) (*models.LoginResponse, error)
 	CreateDrama(req models.CreateDramaRequest) (*models.Drama, error)
-	UpdateDrama(id uint, req models.UpdateDramaRequest) (*models.Drama, error)
+	// UpdateDrama 按 PATCH 语义更新短剧并以 adminID 为作者保存修订版本
+	UpdateDrama(id, adminID uint, req models.UpdateDramaRequest) (*models.Drama, error)
 	DeleteDrama(id uint) error
 	CreateEpisode(req models.CreateEpisodeRequest) (*models.Episode, error)
-	UpdateEpisode(id uint, req models.UpdateEpisodeRequest) (*models.Episode, error)
+	// UpdateEpisode 按 PATCH 语义更新剧集并以 adminID 为作者保存修订版本
+	UpdateEpisode(id, adminID uint, req models.UpdateEpisodeRequest) (*models.Episode, error)
 	DeleteEpisode(id uint) error
+	// RotateEpisodeKey 重新转码受保护的剧集以更换内容密钥
+	RotateEpisodeKey(id uint) error
 	GetDramaList(page, pageSize int) (*models.PaginatedDramas, error)
 	GetEpisodeList(dramaID uint, page, pageSize int) (*models.PaginatedEpisodes, error)
 	GetAllEpisodeList(page, pageSize int) (*models.PaginatedEpisodes, error)
@@ -25,6 +35,9 @@ type AdminService interface {
 	GetAdminList(page, pageSize int) (*models.PaginatedAdmins, error)
 }
 
+// errStatusViaTransition 更新短剧或剧集时修改了状态
+var errStatusViaTransition = errors.New("状态只能通过状态流转接口变更")
+
 // adminService 管理服务实现
 type adminService struct {
 	adminRepo    repository.AdminRepository
@@ -32,15 +45,25 @@ type adminService struct {
 	episodeRepo  repository.EpisodeRepository
 	jwtManager   *utils.JWTManager
 	cacheService CacheService
+	mediaService MediaService
+	transcoder   TranscodeService
+	revisionRepo repository.RevisionRepository
+	logger       *slog.Logger
+	ctx          context.Context
 }
 
-// NewAdminService 创建新的管理服务
+// NewAdminService 创建新的管理服务，log 为 nil 时使用全局默认 Logger
+// mediaService 为 nil 时封面、视频等地址不关联媒体文件，transcoder 为 nil 时剧集视频不转码，revisionRepo 为 nil 时不保存修订版本
 func NewAdminService(
 	adminRepo repository.AdminRepository,
 	dramaRepo repository.DramaRepository,
 	episodeRepo repository.EpisodeRepository,
 	jwtManager *utils.JWTManager,
 	cacheService CacheService,
+	mediaService MediaService,
+	transcoder TranscodeService,
+	revisionRepo repository.RevisionRepository,
+	log *slog.Logger,
 ) AdminService {
 	return &adminService{
 		adminRepo:    adminRepo,
@@ -48,6 +71,99 @@ func NewAdminService(
 		episodeRepo:  episodeRepo,
 		jwtManager:   jwtManager,
 		cacheService: cacheService,
+		mediaService: mediaService,
+		transcoder:   transcoder,
+		revisionRepo: revisionRepo,
+		logger:       logger.OrDefault(log),
+		ctx:          context.Background(),
+	}
+}
+
+// WithContext 返回绑定请求上下文的管理服务
+func (s *adminService) WithContext(ctx context.Context) AdminService {
+	scoped := &adminService{
+		adminRepo:   s.adminRepo.WithContext(ctx),
+		dramaRepo:   s.dramaRepo.WithContext(ctx),
+		episodeRepo: s.episodeRepo.WithContext(ctx),
+		jwtManager:  s.jwtManager,
+		logger:      s.logger,
+		ctx:         ctx,
+	}
+	if s.cacheService != nil {
+		scoped.cacheService = s.cacheService.WithContext(ctx)
+	}
+	if s.mediaService != nil {
+		scoped.mediaService = s.mediaService.WithContext(ctx)
+	}
+	if s.transcoder != nil {
+		scoped.transcoder = s.transcoder.WithContext(ctx)
+	}
+	if s.revisionRepo != nil {
+		scoped.revisionRepo = s.revisionRepo.WithContext(ctx)
+	}
+	return scoped
+}
+
+// resolveMedia 查找地址引用的媒体文件，管理员可以引用任何用户上传的文件
+func (s *adminService) resolveMedia(ref, usage string) (*uint, error) {
+	if s.mediaService == nil {
+		return nil, nil
+	}
+	return s.mediaService.ResolveReference(UploadOwner{Role: "admin"}, ref, usage)
+}
+
+// videoDuration 获取关联的视频文件上传时解析出的时长（秒），未关联文件或没有时长时返回 0
+func (s *adminService) videoDuration(assetID *uint) (int, error) {
+	if s.mediaService == nil || assetID == nil {
+		return 0, nil
+	}
+	asset, err := s.mediaService.GetAsset(*assetID)
+	if err != nil || asset == nil {
+		return 0, err
+	}
+	return asset.Duration, nil
+}
+
+// enqueueTranscode 剧集视频变更后创建转码任务，失败只记录日志：剧集仍可通过原始视频播放
+func (s *adminService) enqueueTranscode(episode *models.Episode) {
+	if s.transcoder == nil {
+		return
+	}
+	if err := s.transcoder.Enqueue(episode); err != nil {
+		s.logger.ErrorContext(s.ctx, "创建转码任务失败", slog.Any("episode_id", episode.ID), slog.String("error", err.Error()))
+	}
+}
+
+// saveWithRevision 保存更新后的内容，内容有变化时在同一事务中保存更新后的快照，快照保存失败时更新一起回滚
+// 内容还没有修订版本时先以更新前的快照作为第一个版本（作者未知，时间为上次更新时间），使首次更新前的内容也能回滚
+func (s *adminService) saveWithRevision(entityType string, id, adminID uint, content, before, after interface{}, lastUpdated time.Time, save func() error) error {
+	if s.revisionRepo == nil || before == after {
+		return save()
+	}
+
+	baselineSnapshot, err := snapshotFields(before)
+	if err != nil {
+		return err
+	}
+	snapshot, err := snapshotFields(after)
+	if err != nil {
+		return err
+	}
+	baseline := &models.ContentRevision{EntityType: entityType, EntityID: id, Snapshot: baselineSnapshot, CreatedAt: lastUpdated}
+	revision := &models.ContentRevision{EntityType: entityType, EntityID: id, Snapshot: snapshot, AdminID: &adminID}
+	if admin, err := s.adminRepo.GetByID(adminID); err == nil && admin != nil {
+		revision.AdminName = admin.Username
+	}
+	return s.revisionRepo.SaveWithRevision(content, baseline, revision)
+}
+
+// invalidateCache 失效缓存标签，失败只记录日志：缓存会在 TTL 到期后自然过期
+func (s *adminService) invalidateCache(tags ...string) {
+	if s.cacheService == nil {
+		return
+	}
+	if err := s.cacheService.InvalidateTag(tags...); err != nil {
+		s.logger.WarnContext(s.ctx, "缓存失效失败", slog.Any("tags", tags), slog.String("error", err.Error()))
 	}
 }
 
@@ -56,24 +172,33 @@ func (s *adminService) Login(req models.AdminLoginRequest) (*models.LoginRespons
 	// 根据用户名查找管理员
 	admin, err := s.adminRepo.GetByUsername(req.Username)
 	if err != nil {
+		s.logger.ErrorContext(s.ctx, "查询登录管理员失败", slog.String("error", err.Error()))
+		return nil, errors.New("用户名或密码错误")
+	}
+	if admin == nil {
+		s.logger.WarnContext(s.ctx, "管理员登录失败", slog.String("reason", "admin_not_found"), slog.String("username", req.Username))
 		return nil, errors.New("用户名或密码错误")
 	}
 
 	// 检查管理员是否激活
 	if !admin.IsActive() {
+		s.logger.WarnContext(s.ctx, "管理员登录失败", slog.String("reason", "inactive"), slog.Any("admin_id", admin.ID))
 		return nil, errors.New("管理员账户已被禁用")
 	}
 
 	// 验证密码
 	if !utils.VerifyPassword(admin.Password, req.Password) {
+		s.logger.WarnContext(s.ctx, "管理员登录失败", slog.String("reason", "invalid_password"), slog.Any("admin_id", admin.ID))
 		return nil, errors.New("用户名或密码错误")
 	}
 
 	// 生成 JWT token
 	token, err := s.jwtManager.GenerateToken(admin.ID, admin.Username, "admin")
 	if err != nil {
+		s.logger.ErrorContext(s.ctx, "生成登录令牌失败", slog.String("error", err.Error()))
 		return nil, fmt.Errorf("令牌生成失败: %w", err)
 	}
+	s.logger.InfoContext(s.ctx, "管理员登录成功", slog.Any("admin_id", admin.ID))
 
 	// 清除密码字段
 	admin.Password = ""
@@ -94,107 +219,120 @@ func (s *adminService) CreateDrama(req models.CreateDramaRequest) (*models.Drama
 		Director:    req.Director,
 		Actors:      req.Actors,
 		Category:    req.Category,
-		Status:      req.Status,
 	}
 
-	// 设置默认状态
-	if drama.Status == "" {
-		drama.Status = "draft"
+	// 新建的短剧均为草稿，通过审核流程发布
+	drama.Status = models.StatusDraft
+
+	coverAssetID, err := s.resolveMedia(drama.CoverImage, "cover")
+	if err != nil {
+		return nil, err
 	}
+	drama.CoverAssetID = coverAssetID
 
-	err := s.dramaRepo.Create(drama)
+	err = s.dramaRepo.Create(drama)
 	if err != nil {
 		return nil, fmt.Errorf("创建短剧失败: %w", err)
 	}
 
-	// 清除相关缓存
-	if s.cacheService != nil {
-		s.cacheService.DeletePattern("dramas:*")
-		s.cacheService.DeletePattern("popular_dramas:*")
-	}
+	// 清除相关缓存（包括该 ID 此前可能存在的不存在结果缓存）
+	s.invalidateCache(TagDrama(drama.ID), TagDramaList, TagCategory(drama.Category))
+
+	s.logger.InfoContext(s.ctx, "短剧已创建", slog.Any("drama_id", drama.ID), slog.String("title", drama.Title))
 
 	return drama, nil
 }
 
-// UpdateDrama 更新短剧
-func (s *adminService) UpdateDrama(id uint, req models.UpdateDramaRequest) (*models.Drama, error) {
+// UpdateDrama 更新短剧，只修改请求中提供的字段，空字符串清空该字段
+func (s *adminService) UpdateDrama(id, adminID uint, req models.UpdateDramaRequest) (*models.Drama, error) {
 	// 获取现有短剧
 	drama, err := s.dramaRepo.GetByID(id)
 	if err != nil {
 		return nil, fmt.Errorf("短剧不存在: %w", err)
 	}
+	if drama == nil {
+		return nil, ErrContentNotFound
+	}
+
+	before, lastUpdated := dramaSnapshot(drama), drama.UpdatedAt
 
 	// 更新字段
-	if req.Title != "" {
-		drama.Title = req.Title
+	if req.Title != nil {
+		drama.Title = *req.Title
 	}
-	if req.Description != "" {
-		drama.Description = req.Description
+	if req.Description != nil {
+		drama.Description = *req.Description
 	}
-	if req.CoverImage != "" {
-		drama.CoverImage = req.CoverImage
+	if req.CoverImage != nil {
+		coverAssetID, err := s.resolveMedia(*req.CoverImage, "cover")
+		if err != nil {
+			return nil, err
+		}
+		drama.CoverImage = *req.CoverImage
+		drama.CoverAssetID = coverAssetID
 	}
-	if req.Director != "" {
-		drama.Director = req.Director
+	if req.Director != nil {
+		drama.Director = *req.Director
 	}
-	if req.Actors != "" {
-		drama.Actors = req.Actors
+	if req.Actors != nil {
+		drama.Actors = *req.Actors
 	}
-	if req.Category != "" {
-		drama.Category = req.Category
+	if req.Category != nil {
+		drama.Category = *req.Category
 	}
-	if req.Status != "" {
-		drama.Status = req.Status
+	if req.Status != nil && *req.Status != drama.Status {
+		return nil, errStatusViaTransition
 	}
 
-	err = s.dramaRepo.Update(drama)
+	err = s.saveWithRevision(models.ReviewEntityDrama, id, adminID, drama, before, dramaSnapshot(drama), lastUpdated, func() error {
+		return s.dramaRepo.Update(drama)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("更新短剧失败: %w", err)
 	}
 
-	// 清除相关缓存
-	if s.cacheService != nil {
-		s.cacheService.Delete(fmt.Sprintf("drama:%d", id))
-		s.cacheService.Delete(fmt.Sprintf("drama_with_episodes:%d", id))
-		s.cacheService.DeletePattern("dramas:*")
-		s.cacheService.DeletePattern("popular_dramas:*")
-	}
+	// 清除相关缓存（状态或分类变化可能影响所在列表，因此同时失效列表标签）
+	s.invalidateCache(TagDrama(id), TagDramaList, TagCategory(before.Category), TagCategory(drama.Category))
+
+	s.logger.InfoContext(s.ctx, "短剧已更新", slog.Any("drama_id", id))
 
 	return drama, nil
 }
 
-// DeleteDrama 删除短剧
+// DeleteDrama 删除短剧，短剧与其剧集一起移入回收站
 func (s *adminService) DeleteDrama(id uint) error {
 	// 检查短剧是否存在
-	_, err := s.dramaRepo.GetByID(id)
+	drama, err := s.dramaRepo.GetByID(id)
 	if err != nil {
 		return fmt.Errorf("短剧不存在: %w", err)
 	}
+	if drama == nil {
+		return ErrContentNotFound
+	}
 
 	err = s.dramaRepo.Delete(id)
 	if err != nil {
 		return fmt.Errorf("删除短剧失败: %w", err)
 	}
 
-	// 清除相关缓存
-	if s.cacheService != nil {
-		s.cacheService.Delete(fmt.Sprintf("drama:%d", id))
-		s.cacheService.Delete(fmt.Sprintf("drama_with_episodes:%d", id))
-		s.cacheService.DeletePattern("dramas:*")
-		s.cacheService.DeletePattern("episodes:drama:*")
-		s.cacheService.DeletePattern("popular_dramas:*")
-	}
+	// 清除相关缓存（详情、剧集列表及包含该短剧的列表页均登记在短剧标签下）
+	s.invalidateCache(TagDrama(id))
+
+	s.logger.InfoContext(s.ctx, "短剧已删除", slog.Any("drama_id", id))
 
 	return nil
 }
 
 // CreateEpisode 创建剧集
 func (s *adminService) CreateEpisode(req models.CreateEpisodeRequest) (*models.Episode, error) {
-	// 检查短剧是否存在
-	_, err := s.dramaRepo.GetByID(req.DramaID)
+	// 检查短剧是否存在，回收站中的短剧下不能创建剧集
+	drama, err := s.dramaRepo.GetByID(req.DramaID)
 	if err != nil {
 		return nil, fmt.Errorf("短剧不存在: %w", err)
 	}
+	if drama == nil {
+		return nil, ErrContentNotFound
+	}
 
 	// 检查剧集编号是否已存在
 	exists, err := s.episodeRepo.ExistsByDramaIDAndEpisodeNum(req.DramaID, req.EpisodeNum)
@@ -212,12 +350,27 @@ func (s *adminService) CreateEpisode(req models.CreateEpisodeRequest) (*models.E
 		Duration:   req.Duration,
 		VideoURL:   req.VideoURL,
 		Thumbnail:  req.Thumbnail,
-		Status:     req.Status,
+		Protected:  req.Protected,
 	}
 
-	// 设置默认状态
-	if episode.Status == "" {
-		episode.Status = "draft"
+	// 新建的剧集均为草稿，通过审核流程发布
+	episode.Status = models.StatusDraft
+
+	if episode.VideoAssetID, err = s.resolveMedia(episode.VideoURL, "video"); err != nil {
+		return nil, err
+	}
+	if episode.ThumbnailAssetID, err = s.resolveMedia(episode.Thumbnail, "thumbnail"); err != nil {
+		return nil, err
+	}
+
+	// 未填写时长时使用上传视频时解析出的时长
+	if episode.Duration == 0 {
+		if episode.Duration, err = s.videoDuration(episode.VideoAssetID); err != nil {
+			return nil, err
+		}
+		if episode.Duration == 0 {
+			return nil, errors.New("无法从视频获取时长，请填写 duration")
+		}
 	}
 
 	err = s.episodeRepo.Create(episode)
@@ -225,74 +378,136 @@ func (s *adminService) CreateEpisode(req models.CreateEpisodeRequest) (*models.E
 		return nil, fmt.Errorf("创建剧集失败: %w", err)
 	}
 
-	// 清除相关缓存
-	if s.cacheService != nil {
-		s.cacheService.Delete(fmt.Sprintf("drama_with_episodes:%d", req.DramaID))
-		s.cacheService.DeletePattern(fmt.Sprintf("episodes:drama:%d:*", req.DramaID))
+	// 清除相关缓存（包括该 ID 此前可能存在的不存在结果缓存）
+	s.invalidateCache(TagEpisode(episode.ID), TagDrama(req.DramaID))
+	if episode.VideoAssetID != nil {
+		s.enqueueTranscode(episode)
 	}
 
+	s.logger.InfoContext(s.ctx, "剧集已创建", slog.Any("episode_id", episode.ID), slog.Any("drama_id", episode.DramaID))
+
 	return episode, nil
 }
 
-// UpdateEpisode 更新剧集
-func (s *adminService) UpdateEpisode(id uint, req models.UpdateEpisodeRequest) (*models.Episode, error) {
+// UpdateEpisode 更新剧集，只修改请求中提供的字段，空字符串清空视频或缩略图
+func (s *adminService) UpdateEpisode(id, adminID uint, req models.UpdateEpisodeRequest) (*models.Episode, error) {
 	// 获取现有剧集
 	episode, err := s.episodeRepo.GetByID(id)
 	if err != nil {
 		return nil, fmt.Errorf("剧集不存在: %w", err)
 	}
+	if episode == nil {
+		return nil, ErrContentNotFound
+	}
+
+	before, lastUpdated := episodeSnapshot(episode), episode.UpdatedAt
 
 	// 如果要更新剧集编号，检查是否已存在
-	if req.EpisodeNum != 0 && req.EpisodeNum != episode.EpisodeNum {
-		exists, err := s.episodeRepo.ExistsByDramaIDAndEpisodeNum(episode.DramaID, req.EpisodeNum)
+	if req.EpisodeNum != nil && *req.EpisodeNum != episode.EpisodeNum {
+		exists, err := s.episodeRepo.ExistsByDramaIDAndEpisodeNum(episode.DramaID, *req.EpisodeNum)
 		if err != nil {
 			return nil, fmt.Errorf("检查剧集编号失败: %w", err)
 		}
 		if exists {
 			return nil, errors.New("该剧集编号已存在")
 		}
-		episode.EpisodeNum = req.EpisodeNum
+		episode.EpisodeNum = *req.EpisodeNum
 	}
 
 	// 更新其他字段
-	if req.Title != "" {
-		episode.Title = req.Title
+	if req.Title != nil {
+		episode.Title = *req.Title
+	}
+	if req.Duration != nil {
+		episode.Duration = *req.Duration
 	}
-	if req.Duration != 0 {
-		episode.Duration = req.Duration
+	videoChanged := req.VideoURL != nil && *req.VideoURL != episode.VideoURL
+	if req.VideoURL != nil {
+		if episode.VideoAssetID, err = s.resolveMedia(*req.VideoURL, "video"); err != nil {
+			return nil, err
+		}
+		episode.VideoURL = *req.VideoURL
+
+		// 更换视频且未填写时长时，使用新视频的时长
+		if req.Duration == nil {
+			duration, err := s.videoDuration(episode.VideoAssetID)
+			if err != nil {
+				return nil, err
+			}
+			if duration > 0 {
+				episode.Duration = duration
+			}
+		}
 	}
-	if req.VideoURL != "" {
-		episode.VideoURL = req.VideoURL
+	if req.Thumbnail != nil {
+		if episode.ThumbnailAssetID, err = s.resolveMedia(*req.Thumbnail, "thumbnail"); err != nil {
+			return nil, err
+		}
+		episode.Thumbnail = *req.Thumbnail
 	}
-	if req.Thumbnail != "" {
-		episode.Thumbnail = req.Thumbnail
+	if req.Status != nil && *req.Status != episode.Status {
+		return nil, errStatusViaTransition
 	}
-	if req.Status != "" {
-		episode.Status = req.Status
+	// 切换加密需要重新转码
+	protectionChanged := req.Protected != nil && *req.Protected != episode.Protected
+	if req.Protected != nil {
+		episode.Protected = *req.Protected
 	}
 
-	err = s.episodeRepo.Update(episode)
+	err = s.saveWithRevision(models.ReviewEntityEpisode, id, adminID, episode, before, episodeSnapshot(episode), lastUpdated, func() error {
+		return s.episodeRepo.Update(episode)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("更新剧集失败: %w", err)
 	}
 
 	// 清除相关缓存
-	if s.cacheService != nil {
-		s.cacheService.Delete(fmt.Sprintf("episode:%d", id))
-		s.cacheService.Delete(fmt.Sprintf("drama_with_episodes:%d", episode.DramaID))
-		s.cacheService.DeletePattern(fmt.Sprintf("episodes:drama:%d:*", episode.DramaID))
+	s.invalidateCache(TagEpisode(id), TagDrama(episode.DramaID))
+	if videoChanged || (protectionChanged && episode.VideoAssetID != nil) {
+		s.enqueueTranscode(episode)
 	}
 
+	s.logger.InfoContext(s.ctx, "剧集已更新", slog.Any("episode_id", id))
+
 	return episode, nil
 }
 
-// DeleteEpisode 删除剧集
+// RotateEpisodeKey 创建新的转码任务，任务完成后替换转码结果并删除旧密钥，此前仍使用当前密钥播放
+func (s *adminService) RotateEpisodeKey(id uint) error {
+	episode, err := s.episodeRepo.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("剧集不存在: %w", err)
+	}
+	if episode == nil {
+		return ErrContentNotFound
+	}
+	if !episode.Protected {
+		return errors.New("剧集未开启加密")
+	}
+	if s.transcoder == nil {
+		return errors.New("未启用视频转码")
+	}
+	if episode.VideoAssetID == nil {
+		return errors.New("剧集视频未上传到本服务，无法加密")
+	}
+	if err := s.transcoder.Retranscode(episode); err != nil {
+		return fmt.Errorf("创建转码任务失败: %w", err)
+	}
+
+	s.logger.InfoContext(s.ctx, "剧集密钥轮换已开始", slog.Any("episode_id", id))
+	return nil
+}
+
+// DeleteEpisode 删除剧集，剧集移入回收站
 func (s *adminService) DeleteEpisode(id uint) error {
 	// 获取剧集信息
 	episode, err := s.episodeRepo.GetByID(id)
 	if err != nil {
 		return fmt.Errorf("剧集不存在: %w", err)
 	}
+	if episode == nil {
+		return ErrContentNotFound
+	}
 
 	err = s.episodeRepo.Delete(id)
 	if err != nil {
@@ -300,11 +515,9 @@ func (s *adminService) DeleteEpisode(id uint) error {
 	}
 
 	// 清除相关缓存
-	if s.cacheService != nil {
-		s.cacheService.Delete(fmt.Sprintf("episode:%d", id))
-		s.cacheService.Delete(fmt.Sprintf("drama_with_episodes:%d", episode.DramaID))
-		s.cacheService.DeletePattern(fmt.Sprintf("episodes:drama:%d:*", episode.DramaID))
-	}
+	s.invalidateCache(TagEpisode(id), TagDrama(episode.DramaID))
+
+	s.logger.InfoContext(s.ctx, "剧集已删除", slog.Any("episode_id", id), slog.Any("drama_id", episode.DramaID))
 
 	return nil
 }
@@ -443,6 +656,8 @@ func (s *adminService) CreateAdmin(req models.CreateAdminRequest) (*models.Admin
 		return nil, fmt.Errorf("创建管理员失败: %w", err)
 	}
 
+	s.logger.InfoContext(s.ctx, "管理员已创建", slog.Any("admin_id", admin.ID), slog.String("username", admin.Username))
+
 	// 清除密码字段
 	admin.Password = ""
 	return admin, nil
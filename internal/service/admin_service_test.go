@@ -1,11 +1,13 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
 	"gin-mysql-api/pkg/utils"
 
 	"github.com/stretchr/testify/assert"
@@ -17,6 +19,10 @@ type MockAdminRepository struct {
 	mock.Mock
 }
 
+func (m *MockAdminRepository) WithContext(ctx context.Context) repository.AdminRepository {
+	return m
+}
+
 func (m *MockAdminRepository) Create(admin *models.Admin) error {
 	args := m.Called(admin)
 	return args.Error(0)
@@ -76,6 +82,10 @@ type MockDramaRepository struct {
 	mock.Mock
 }
 
+func (m *MockDramaRepository) WithContext(ctx context.Context) repository.DramaRepository {
+	return m
+}
+
 func (m *MockDramaRepository) Create(drama *models.Drama) error {
 	args := m.Called(drama)
 	return args.Error(0)
@@ -89,6 +99,14 @@ func (m *MockDramaRepository) GetByID(id uint) (*models.Drama, error) {
 	return args.Get(0).(*models.Drama), args.Error(1)
 }
 
+func (m *MockDramaRepository) GetPublishedByID(id uint) (*models.Drama, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Drama), args.Error(1)
+}
+
 func (m *MockDramaRepository) GetByIDWithEpisodes(id uint) (*models.Drama, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
@@ -127,11 +145,30 @@ func (m *MockDramaRepository) GetActiveList(offset, limit int) ([]models.Drama,
 	return args.Get(0).([]models.Drama), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockDramaRepository) ListDue(now time.Time, limit int) ([]models.Drama, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]models.Drama), args.Error(1)
+}
+
+func (m *MockDramaRepository) Publish(id uint) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockDramaRepository) ListPublishBetween(from, to time.Time) ([]models.Drama, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]models.Drama), args.Error(1)
+}
+
 // MockEpisodeRepository 模拟剧集仓库
 type MockEpisodeRepository struct {
 	mock.Mock
 }
 
+func (m *MockEpisodeRepository) WithContext(ctx context.Context) repository.EpisodeRepository {
+	return m
+}
+
 func (m *MockEpisodeRepository) Create(episode *models.Episode) error {
 	args := m.Called(episode)
 	return args.Error(0)
@@ -153,6 +190,14 @@ func (m *MockEpisodeRepository) GetByIDWithDrama(id uint) (*models.Episode, erro
 	return args.Get(0).(*models.Episode), args.Error(1)
 }
 
+func (m *MockEpisodeRepository) GetPublishedByIDWithDrama(id uint) (*models.Episode, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Episode), args.Error(1)
+}
+
 func (m *MockEpisodeRepository) GetByDramaID(dramaID uint) ([]models.Episode, error) {
 	args := m.Called(dramaID)
 	return args.Get(0).([]models.Episode), args.Error(1)
@@ -163,6 +208,16 @@ func (m *MockEpisodeRepository) GetByDramaIDPaginated(dramaID uint, offset, limi
 	return args.Get(0).([]models.Episode), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockEpisodeRepository) GetPublishedByDramaIDPaginated(dramaID uint, offset, limit int) ([]models.Episode, int64, error) {
+	args := m.Called(dramaID, offset, limit)
+	return args.Get(0).([]models.Episode), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEpisodeRepository) GetList(offset, limit int) ([]models.Episode, int64, error) {
+	args := m.Called(offset, limit)
+	return args.Get(0).([]models.Episode), args.Get(1).(int64), args.Error(2)
+}
+
 func (m *MockEpisodeRepository) Update(episode *models.Episode) error {
 	args := m.Called(episode)
 	return args.Error(0)
@@ -188,6 +243,36 @@ func (m *MockEpisodeRepository) ExistsByDramaIDAndEpisodeNum(dramaID uint, episo
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockEpisodeRepository) ExistsProtectedByVideoAssetID(assetID uint) (bool, error) {
+	args := m.Called(assetID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEpisodeRepository) ListByDramaID(dramaID uint) ([]models.Episode, error) {
+	args := m.Called(dramaID)
+	return args.Get(0).([]models.Episode), args.Error(1)
+}
+
+func (m *MockEpisodeRepository) ListByEpisodeNumRange(dramaID uint, from, to int) ([]models.Episode, error) {
+	args := m.Called(dramaID, from, to)
+	return args.Get(0).([]models.Episode), args.Error(1)
+}
+
+func (m *MockEpisodeRepository) ListDue(now time.Time, limit int) ([]models.Episode, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]models.Episode), args.Error(1)
+}
+
+func (m *MockEpisodeRepository) Publish(id uint) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEpisodeRepository) ListPublishBetween(from, to time.Time) ([]models.Episode, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]models.Episode), args.Error(1)
+}
+
 // MockCacheService 模拟缓存服务
 type MockCacheService struct {
 	mock.Mock
@@ -238,6 +323,20 @@ func (m *MockCacheService) Expire(key string, expiration time.Duration) error {
 	return args.Error(0)
 }
 
+func (m *MockCacheService) SetJSONWithTags(key string, value interface{}, expiration time.Duration, tags ...string) error {
+	args := m.Called(key, value, expiration, tags)
+	return args.Error(0)
+}
+
+func (m *MockCacheService) InvalidateTag(tags ...string) error {
+	args := m.Called(tags)
+	return args.Error(0)
+}
+
+func (m *MockCacheService) WithContext(ctx context.Context) CacheService {
+	return m
+}
+
 func TestAdminService_Login(t *testing.T) {
 	mockAdminRepo := new(MockAdminRepository)
 	mockDramaRepo := new(MockDramaRepository)
@@ -245,7 +344,7 @@ func TestAdminService_Login(t *testing.T) {
 	mockCacheService := new(MockCacheService)
 	jwtManager := utils.NewJWTManager("test-secret", time.Hour)
 
-	adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService)
+	adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService, nil, nil, nil, nil)
 
 	t.Run("成功登录", func(t *testing.T) {
 		req := models.AdminLoginRequest{
@@ -275,7 +374,7 @@ func TestAdminService_Login(t *testing.T) {
 
 	t.Run("管理员不存在", func(t *testing.T) {
 		mockAdminRepo := new(MockAdminRepository)
-		adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService)
+		adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService, nil, nil, nil, nil)
 
 		req := models.AdminLoginRequest{
 			Username: "nonexistent",
@@ -295,7 +394,7 @@ func TestAdminService_Login(t *testing.T) {
 
 	t.Run("管理员已被禁用", func(t *testing.T) {
 		mockAdminRepo := new(MockAdminRepository)
-		adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService)
+		adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService, nil, nil, nil, nil)
 
 		req := models.AdminLoginRequest{
 			Username: "admin",
@@ -329,7 +428,7 @@ func TestAdminService_CreateDrama(t *testing.T) {
 	mockCacheService := new(MockCacheService)
 	jwtManager := utils.NewJWTManager("test-secret", time.Hour)
 
-	adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService)
+	adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService, nil, nil, nil, nil)
 
 	t.Run("成功创建短剧", func(t *testing.T) {
 		req := models.CreateDramaRequest{
@@ -338,9 +437,10 @@ func TestAdminService_CreateDrama(t *testing.T) {
 			Category:    "喜剧",
 		}
 
-		mockDramaRepo.On("Create", mock.AnythingOfType("*models.Drama")).Return(nil)
-		mockCacheService.On("DeletePattern", "dramas:*").Return(nil)
-		mockCacheService.On("DeletePattern", "popular_dramas:*").Return(nil)
+		mockDramaRepo.On("Create", mock.AnythingOfType("*models.Drama")).Run(func(args mock.Arguments) {
+			args.Get(0).(*models.Drama).ID = 1
+		}).Return(nil)
+		mockCacheService.On("InvalidateTag", []string{TagDrama(1), TagDramaList, TagCategory("喜剧")}).Return(nil)
 
 		drama, err := adminService.CreateDrama(req)
 
@@ -362,7 +462,7 @@ func TestAdminService_CreateEpisode(t *testing.T) {
 	mockCacheService := new(MockCacheService)
 	jwtManager := utils.NewJWTManager("test-secret", time.Hour)
 
-	adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService)
+	adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService, nil, nil, nil, nil)
 
 	t.Run("成功创建剧集", func(t *testing.T) {
 		req := models.CreateEpisodeRequest{
@@ -376,9 +476,10 @@ func TestAdminService_CreateEpisode(t *testing.T) {
 
 		mockDramaRepo.On("GetByID", req.DramaID).Return(drama, nil)
 		mockEpisodeRepo.On("ExistsByDramaIDAndEpisodeNum", req.DramaID, req.EpisodeNum).Return(false, nil)
-		mockEpisodeRepo.On("Create", mock.AnythingOfType("*models.Episode")).Return(nil)
-		mockCacheService.On("Delete", "drama_with_episodes:1").Return(nil)
-		mockCacheService.On("DeletePattern", "episodes:drama:1:*").Return(nil)
+		mockEpisodeRepo.On("Create", mock.AnythingOfType("*models.Episode")).Run(func(args mock.Arguments) {
+			args.Get(0).(*models.Episode).ID = 10
+		}).Return(nil)
+		mockCacheService.On("InvalidateTag", []string{TagEpisode(10), TagDrama(1)}).Return(nil)
 
 		episode, err := adminService.CreateEpisode(req)
 
@@ -386,7 +487,7 @@ func TestAdminService_CreateEpisode(t *testing.T) {
 		assert.NotNil(t, episode)
 		assert.Equal(t, req.Title, episode.Title)
 		assert.Equal(t, req.DramaID, episode.DramaID)
-		assert.Equal(t, "active", episode.Status) // 默认状态
+		assert.Equal(t, "draft", episode.Status) // 默认状态
 
 		mockDramaRepo.AssertExpectations(t)
 		mockEpisodeRepo.AssertExpectations(t)
@@ -396,7 +497,7 @@ func TestAdminService_CreateEpisode(t *testing.T) {
 	t.Run("短剧不存在", func(t *testing.T) {
 		mockDramaRepo := new(MockDramaRepository)
 		mockEpisodeRepo := new(MockEpisodeRepository)
-		adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService)
+		adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService, nil, nil, nil, nil)
 
 		req := models.CreateEpisodeRequest{
 			DramaID:    999,
@@ -415,10 +516,25 @@ func TestAdminService_CreateEpisode(t *testing.T) {
 		mockDramaRepo.AssertExpectations(t)
 	})
 
+	t.Run("短剧已删除", func(t *testing.T) {
+		mockDramaRepo := new(MockDramaRepository)
+		mockEpisodeRepo := new(MockEpisodeRepository)
+		adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService, nil, nil, nil, nil)
+
+		// 回收站中的短剧查询结果为空
+		mockDramaRepo.On("GetByID", uint(998)).Return((*models.Drama)(nil), nil)
+
+		episode, err := adminService.CreateEpisode(models.CreateEpisodeRequest{DramaID: 998, Title: "测试剧集", EpisodeNum: 1})
+
+		assert.ErrorIs(t, err, ErrContentNotFound)
+		assert.Nil(t, episode)
+		mockEpisodeRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
 	t.Run("剧集编号已存在", func(t *testing.T) {
 		mockDramaRepo := new(MockDramaRepository)
 		mockEpisodeRepo := new(MockEpisodeRepository)
-		adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService)
+		adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService, nil, nil, nil, nil)
 
 		req := models.CreateEpisodeRequest{
 			DramaID:    1,
@@ -440,4 +556,61 @@ func TestAdminService_CreateEpisode(t *testing.T) {
 		mockDramaRepo.AssertExpectations(t)
 		mockEpisodeRepo.AssertExpectations(t)
 	})
+
+	t.Run("未填写时长时使用视频的时长", func(t *testing.T) {
+		mockDramaRepo := new(MockDramaRepository)
+		mockEpisodeRepo := new(MockEpisodeRepository)
+		mockCacheService := new(MockCacheService)
+		mediaService, repo, store, _ := newTestMediaService(t)
+		adminService := NewAdminService(mockAdminRepo, mockDramaRepo, mockEpisodeRepo, jwtManager, mockCacheService, mediaService, nil, nil, nil)
+
+		video := createTestAsset(t, repo, store, &models.MediaAsset{
+			OwnerRole: "admin", Type: "video", StorageKey: "videos/1_abc.mp4", ContentType: "video/mp4", Duration: 95,
+		})
+		mockDramaRepo.On("GetByID", uint(1)).Return(&models.Drama{ID: 1}, nil)
+		mockEpisodeRepo.On("ExistsByDramaIDAndEpisodeNum", uint(1), mock.Anything).Return(false, nil)
+		mockEpisodeRepo.On("Create", mock.AnythingOfType("*models.Episode")).Return(nil)
+		mockCacheService.On("InvalidateTag", mock.Anything).Return(nil)
+
+		episode, err := adminService.CreateEpisode(models.CreateEpisodeRequest{
+			DramaID: 1, Title: "第一集", EpisodeNum: 1, VideoURL: video.StorageKey,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 95, episode.Duration)
+		assert.Equal(t, video.ID, *episode.VideoAssetID)
+
+		// 填写的时长优先
+		episode, err = adminService.CreateEpisode(models.CreateEpisodeRequest{
+			DramaID: 1, Title: "第二集", EpisodeNum: 2, VideoURL: video.StorageKey, Duration: 90,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 90, episode.Duration)
+
+		// 外部视频无法获取时长
+		_, err = adminService.CreateEpisode(models.CreateEpisodeRequest{
+			DramaID: 1, Title: "第三集", EpisodeNum: 3, VideoURL: "https://example.com/3.mp4",
+		})
+		assert.ErrorContains(t, err, "无法从视频获取时长")
+	})
+}
+
+func TestAdminService_RotateEpisodeKey(t *testing.T) {
+	mockEpisodeRepo := new(MockEpisodeRepository)
+	adminService := NewAdminService(new(MockAdminRepository), new(MockDramaRepository), mockEpisodeRepo, nil, nil, nil, nil, nil, nil)
+
+	t.Run("剧集不存在", func(t *testing.T) {
+		mockEpisodeRepo.On("GetByID", uint(99999)).Return(nil, nil).Once()
+
+		err := adminService.RotateEpisodeKey(99999)
+		assert.ErrorIs(t, err, ErrContentNotFound)
+	})
+
+	t.Run("剧集未开启加密", func(t *testing.T) {
+		mockEpisodeRepo.On("GetByID", uint(1)).Return(&models.Episode{ID: 1}, nil).Once()
+
+		err := adminService.RotateEpisodeKey(1)
+		assert.EqualError(t, err, "剧集未开启加密")
+	})
+
+	mockEpisodeRepo.AssertExpectations(t)
 }
@@ -1,16 +1,23 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 	"time"
 
 	"gin-mysql-api/internal/models"
 	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/metrics"
 	"gin-mysql-api/pkg/utils"
 )
 
 // AuthService 认证服务接口
 type AuthService interface {
+	// WithContext 返回绑定请求上下文的服务，日志与查询会带上请求的链路信息
+	WithContext(ctx context.Context) AuthService
+
 	// 用户认证
 	RegisterUser(req models.RegisterRequest) (*models.User, error)
 	LoginUser(req models.LoginRequest) (*models.LoginResponse, error)
@@ -28,18 +35,34 @@ type authService struct {
 	userRepo   repository.UserRepository
 	adminRepo  repository.AdminRepository
 	jwtManager *utils.JWTManager
+	logger     *slog.Logger
+	ctx        context.Context
 }
 
-// NewAuthService 创建新的认证服务
+// NewAuthService 创建新的认证服务，log 为 nil 时使用全局默认 Logger
 func NewAuthService(
 	userRepo repository.UserRepository,
 	adminRepo repository.AdminRepository,
 	jwtManager *utils.JWTManager,
+	log *slog.Logger,
 ) AuthService {
 	return &authService{
 		userRepo:   userRepo,
 		adminRepo:  adminRepo,
 		jwtManager: jwtManager,
+		logger:     logger.OrDefault(log),
+		ctx:        context.Background(),
+	}
+}
+
+// WithContext 返回绑定请求上下文的认证服务
+func (s *authService) WithContext(ctx context.Context) AuthService {
+	return &authService{
+		userRepo:   s.userRepo.WithContext(ctx),
+		adminRepo:  s.adminRepo.WithContext(ctx),
+		jwtManager: s.jwtManager,
+		logger:     s.logger,
+		ctx:        ctx,
 	}
 }
 
@@ -74,8 +97,11 @@ func (s *authService) RegisterUser(req models.RegisterRequest) (*models.User, er
 
 	err = s.userRepo.Create(user)
 	if err != nil {
+		s.logger.ErrorContext(s.ctx, "用户创建失败", slog.String("error", err.Error()))
 		return nil, errors.New("用户创建失败")
 	}
+	metrics.RecordRegistration()
+	s.logger.InfoContext(s.ctx, "用户注册成功", slog.Any("new_user_id", user.ID), slog.String("username", user.Username))
 
 	// 清除密码字段
 	user.Password = ""
@@ -83,28 +109,39 @@ func (s *authService) RegisterUser(req models.RegisterRequest) (*models.User, er
 }
 
 // LoginUser 用户登录
-func (s *authService) LoginUser(req models.LoginRequest) (*models.LoginResponse, error) {
+func (s *authService) LoginUser(req models.LoginRequest) (resp *models.LoginResponse, err error) {
+	defer func() { metrics.RecordLogin("user", err == nil) }()
+
 	// 根据邮箱查找用户
 	user, err := s.userRepo.GetByEmail(req.Email)
 	if err != nil {
+		s.logger.ErrorContext(s.ctx, "查询登录用户失败", slog.String("error", err.Error()))
+		return nil, errors.New("用户名或密码错误")
+	}
+	if user == nil {
+		s.logger.WarnContext(s.ctx, "用户登录失败", slog.String("reason", "user_not_found"))
 		return nil, errors.New("用户名或密码错误")
 	}
 
 	// 检查用户是否激活
 	if !user.IsActive {
+		s.logger.WarnContext(s.ctx, "用户登录失败", slog.String("reason", "inactive"), slog.Any("login_user_id", user.ID))
 		return nil, errors.New("用户账户已被禁用")
 	}
 
 	// 验证密码
 	if !utils.VerifyPassword(user.Password, req.Password) {
+		s.logger.WarnContext(s.ctx, "用户登录失败", slog.String("reason", "invalid_password"), slog.Any("login_user_id", user.ID))
 		return nil, errors.New("用户名或密码错误")
 	}
 
 	// 生成 JWT token
 	token, err := s.jwtManager.GenerateToken(user.ID, user.Username, "user")
 	if err != nil {
+		s.logger.ErrorContext(s.ctx, "生成登录令牌失败", slog.String("error", err.Error()))
 		return nil, errors.New("令牌生成失败")
 	}
+	s.logger.InfoContext(s.ctx, "用户登录成功", slog.Any("login_user_id", user.ID))
 
 	// 清除密码字段
 	user.Password = ""
@@ -117,28 +154,39 @@ func (s *authService) LoginUser(req models.LoginRequest) (*models.LoginResponse,
 }
 
 // LoginAdmin 管理员登录
-func (s *authService) LoginAdmin(req models.AdminLoginRequest) (*models.LoginResponse, error) {
+func (s *authService) LoginAdmin(req models.AdminLoginRequest) (resp *models.LoginResponse, err error) {
+	defer func() { metrics.RecordLogin("admin", err == nil) }()
+
 	// 根据用户名查找管理员
 	admin, err := s.adminRepo.GetByUsername(req.Username)
 	if err != nil {
+		s.logger.ErrorContext(s.ctx, "查询登录管理员失败", slog.String("error", err.Error()))
+		return nil, errors.New("用户名或密码错误")
+	}
+	if admin == nil {
+		s.logger.WarnContext(s.ctx, "管理员登录失败", slog.String("reason", "admin_not_found"), slog.String("username", req.Username))
 		return nil, errors.New("用户名或密码错误")
 	}
 
 	// 检查管理员是否激活
 	if !admin.IsActive() {
+		s.logger.WarnContext(s.ctx, "管理员登录失败", slog.String("reason", "inactive"), slog.Any("admin_id", admin.ID))
 		return nil, errors.New("管理员账户已被禁用")
 	}
 
 	// 验证密码
 	if !utils.VerifyPassword(admin.Password, req.Password) {
+		s.logger.WarnContext(s.ctx, "管理员登录失败", slog.String("reason", "invalid_password"), slog.Any("admin_id", admin.ID))
 		return nil, errors.New("用户名或密码错误")
 	}
 
 	// 生成 JWT token
 	token, err := s.jwtManager.GenerateToken(admin.ID, admin.Username, "admin")
 	if err != nil {
+		s.logger.ErrorContext(s.ctx, "生成登录令牌失败", slog.String("error", err.Error()))
 		return nil, errors.New("令牌生成失败")
 	}
+	s.logger.InfoContext(s.ctx, "管理员登录成功", slog.Any("admin_id", admin.ID))
 
 	// 清除密码字段
 	admin.Password = ""
@@ -152,7 +200,12 @@ func (s *authService) LoginAdmin(req models.AdminLoginRequest) (*models.LoginRes
 
 // RefreshToken 刷新令牌
 func (s *authService) RefreshToken(tokenString string) (string, error) {
-	return s.jwtManager.RefreshToken(tokenString)
+	token, err := s.jwtManager.RefreshToken(tokenString)
+	if err != nil {
+		s.logger.WarnContext(s.ctx, "刷新令牌失败", slog.String("error", err.Error()))
+		return "", err
+	}
+	return token, nil
 }
 
 // VerifyToken 验证令牌
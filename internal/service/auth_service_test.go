@@ -10,13 +10,14 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAuthService_RegisterUser(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockAdminRepo := new(MockAdminRepository)
 	jwtManager := utils.NewJWTManager("test-secret", time.Hour)
-	authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager)
+	authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager, nil)
 
 	t.Run("成功注册用户", func(t *testing.T) {
 		req := models.RegisterRequest{
@@ -45,7 +46,7 @@ func TestAuthService_RegisterUser(t *testing.T) {
 
 	t.Run("用户名已存在", func(t *testing.T) {
 		mockUserRepo := new(MockUserRepository)
-		authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager)
+		authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager, nil)
 
 		req := models.RegisterRequest{
 			Username: "existinguser",
@@ -67,7 +68,7 @@ func TestAuthService_RegisterUser(t *testing.T) {
 
 	t.Run("邮箱已存在", func(t *testing.T) {
 		mockUserRepo := new(MockUserRepository)
-		authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager)
+		authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager, nil)
 
 		req := models.RegisterRequest{
 			Username: "newuser",
@@ -93,7 +94,7 @@ func TestAuthService_LoginUser(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockAdminRepo := new(MockAdminRepository)
 	jwtManager := utils.NewJWTManager("test-secret", time.Hour)
-	authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager)
+	authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager, nil)
 
 	t.Run("成功登录", func(t *testing.T) {
 		req := models.LoginRequest{
@@ -126,7 +127,7 @@ func TestAuthService_LoginUser(t *testing.T) {
 
 	t.Run("用户不存在", func(t *testing.T) {
 		mockUserRepo := new(MockUserRepository)
-		authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager)
+		authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager, nil)
 
 		req := models.LoginRequest{
 			Email:    "nonexistent@example.com",
@@ -146,7 +147,7 @@ func TestAuthService_LoginUser(t *testing.T) {
 
 	t.Run("密码错误", func(t *testing.T) {
 		mockUserRepo := new(MockUserRepository)
-		authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager)
+		authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager, nil)
 
 		req := models.LoginRequest{
 			Email:    "test@example.com",
@@ -175,7 +176,7 @@ func TestAuthService_LoginUser(t *testing.T) {
 
 	t.Run("用户已被禁用", func(t *testing.T) {
 		mockUserRepo := new(MockUserRepository)
-		authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager)
+		authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager, nil)
 
 		req := models.LoginRequest{
 			Email:    "test@example.com",
@@ -207,7 +208,7 @@ func TestAuthService_LoginAdmin(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockAdminRepo := new(MockAdminRepository)
 	jwtManager := utils.NewJWTManager("test-secret", time.Hour)
-	authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager)
+	authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager, nil)
 
 	t.Run("成功登录", func(t *testing.T) {
 		req := models.AdminLoginRequest{
@@ -237,7 +238,7 @@ func TestAuthService_LoginAdmin(t *testing.T) {
 
 	t.Run("管理员不存在", func(t *testing.T) {
 		mockAdminRepo := new(MockAdminRepository)
-		authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager)
+		authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager, nil)
 
 		req := models.AdminLoginRequest{
 			Username: "nonexistent",
@@ -260,25 +261,28 @@ func TestAuthService_RefreshToken(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockAdminRepo := new(MockAdminRepository)
 	jwtManager := utils.NewJWTManager("test-secret", time.Hour)
-	authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager)
+	authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager, nil)
 
 	t.Run("成功刷新token", func(t *testing.T) {
-		// 生成原始 token
-		originalToken, err := jwtManager.GenerateToken(1, "testuser", "user")
-		assert.NoError(t, err)
+		// 生成即将过期的原始 token（密钥相同，有效期较短）
+		originalToken, err := utils.NewJWTManager("test-secret", time.Minute).GenerateToken(1, "testuser", "user")
+		require.NoError(t, err)
+		original, err := authService.VerifyToken(originalToken)
+		require.NoError(t, err)
 
 		// 刷新 token
 		newToken, err := authService.RefreshToken(originalToken)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.NotEmpty(t, newToken)
-		assert.NotEqual(t, originalToken, newToken)
 
-		// 验证新 token
+		// 新 token 保留用户信息并按完整有效期重新计算过期时间
 		claims, err := authService.VerifyToken(newToken)
-		assert.NoError(t, err)
+		require.NoError(t, err)
 		assert.Equal(t, uint(1), claims.UserID)
 		assert.Equal(t, "testuser", claims.Username)
 		assert.Equal(t, "user", claims.Role)
+		assert.True(t, claims.ExpiresAt.After(original.ExpiresAt.Time))
+		assert.WithinDuration(t, time.Now().Add(time.Hour), claims.ExpiresAt.Time, time.Minute)
 	})
 }
 
@@ -286,7 +290,7 @@ func TestAuthService_VerifyToken(t *testing.T) {
 	mockUserRepo := new(MockUserRepository)
 	mockAdminRepo := new(MockAdminRepository)
 	jwtManager := utils.NewJWTManager("test-secret", time.Hour)
-	authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager)
+	authService := NewAuthService(mockUserRepo, mockAdminRepo, jwtManager, nil)
 
 	t.Run("成功验证token", func(t *testing.T) {
 		userID := uint(1)
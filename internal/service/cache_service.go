@@ -19,8 +19,15 @@ type CacheService interface {
 	DeletePattern(pattern string) error
 	Increment(key string) (int64, error)
 	Expire(key string, expiration time.Duration) error
+	SetJSONWithTags(key string, value interface{}, expiration time.Duration, tags ...string) error
+	InvalidateTag(tags ...string) error
+	// WithContext 返回绑定上下文的缓存服务，Redis 命令会继承上下文中的链路信息
+	WithContext(ctx context.Context) CacheService
 }
 
+// scanBatchSize SCAN 每批返回的建议数量
+const scanBatchSize = 500
+
 // cacheService Redis 缓存服务实现
 type cacheService struct {
 	client *redis.Client
@@ -35,6 +42,14 @@ func NewCacheService(client *redis.Client) CacheService {
 	}
 }
 
+// WithContext 返回绑定上下文的缓存服务
+func (s *cacheService) WithContext(ctx context.Context) CacheService {
+	return &cacheService{
+		client: s.client,
+		ctx:    ctx,
+	}
+}
+
 // Set 设置缓存值
 func (s *cacheService) Set(key string, value interface{}, expiration time.Duration) error {
 	return s.client.Set(s.ctx, key, value, expiration).Err()
@@ -75,17 +90,26 @@ func (s *cacheService) GetJSON(key string, dest interface{}) error {
 }
 
 // DeletePattern 根据模式删除缓存
+// 使用 SCAN 增量遍历，避免 KEYS 在大数据量时阻塞 Redis
 func (s *cacheService) DeletePattern(pattern string) error {
-	keys, err := s.client.Keys(s.ctx, pattern).Result()
-	if err != nil {
-		return err
-	}
-	
-	if len(keys) > 0 {
-		return s.client.Del(s.ctx, keys...).Err()
+	var cursor uint64
+	for {
+		keys, next, err := s.client.Scan(s.ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			if err := s.client.Del(s.ctx, keys...).Err(); err != nil {
+				return err
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
 	}
-	
-	return nil
 }
 
 // Increment 递增计数器
@@ -96,4 +120,74 @@ func (s *cacheService) Increment(key string) (int64, error) {
 // Expire 设置过期时间
 func (s *cacheService) Expire(key string, expiration time.Duration) error {
 	return s.client.Expire(s.ctx, key, expiration).Err()
-}
\ No newline at end of file
+}
+
+// SetJSONWithTags 设置 JSON 格式的缓存并登记到标签集合
+// 标签集合的过期时间只会延长，保证不早于其中任一缓存键过期，因此带标签的缓存应设置过期时间
+func (s *cacheService) SetJSONWithTags(key string, value interface{}, expiration time.Duration, tags ...string) error {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	var ttlCmds []*redis.DurationCmd
+	_, err = s.client.Pipelined(s.ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(s.ctx, key, jsonData, expiration)
+		for _, tag := range tags {
+			pipe.SAdd(s.ctx, tagKey(tag), key)
+			ttlCmds = append(ttlCmds, pipe.TTL(s.ctx, tagKey(tag)))
+		}
+		return nil
+	})
+	if err != nil || expiration <= 0 {
+		return err
+	}
+
+	// 新建的标签集合（无过期时间）或剩余时间短于本次缓存时才设置过期时间
+	var extend []string
+	for i, cmd := range ttlCmds {
+		if ttl := cmd.Val(); ttl == -1 || (ttl >= 0 && ttl < expiration) {
+			extend = append(extend, tagKey(tags[i]))
+		}
+	}
+	if len(extend) == 0 {
+		return nil
+	}
+
+	_, err = s.client.Pipelined(s.ctx, func(pipe redis.Pipeliner) error {
+		for _, k := range extend {
+			pipe.Expire(s.ctx, k, expiration)
+		}
+		return nil
+	})
+	return err
+}
+
+// InvalidateTag 删除标签下登记的全部缓存
+// 在事务中读取并删除标签集合，之后新登记的键会进入新的集合，不会被遗漏
+func (s *cacheService) InvalidateTag(tags ...string) error {
+	for _, tag := range tags {
+		var members *redis.StringSliceCmd
+		_, err := s.client.TxPipelined(s.ctx, func(pipe redis.Pipeliner) error {
+			members = pipe.SMembers(s.ctx, tagKey(tag))
+			pipe.Del(s.ctx, tagKey(tag))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		keys := members.Val()
+		for len(keys) > 0 {
+			n := len(keys)
+			if n > scanBatchSize {
+				n = scanBatchSize
+			}
+			if err := s.client.Del(s.ctx, keys[:n]...).Err(); err != nil {
+				return err
+			}
+			keys = keys[n:]
+		}
+	}
+	return nil
+}
@@ -155,10 +155,12 @@ func TestCacheService_DeletePattern(t *testing.T) {
 
 	t.Run("成功删除匹配模式的缓存", func(t *testing.T) {
 		pattern := "test:*"
-		keys := []string{"test:key1", "test:key2", "test:key3"}
 
-		mock.ExpectKeys(pattern).SetVal(keys)
-		mock.ExpectDel(keys...).SetVal(int64(len(keys)))
+		// 使用 SCAN 分批遍历，直到游标归零
+		mock.ExpectScan(0, pattern, scanBatchSize).SetVal([]string{"test:key1", "test:key2"}, 42)
+		mock.ExpectDel("test:key1", "test:key2").SetVal(2)
+		mock.ExpectScan(42, pattern, scanBatchSize).SetVal([]string{"test:key3"}, 0)
+		mock.ExpectDel("test:key3").SetVal(1)
 
 		err := cacheService.DeletePattern(pattern)
 
@@ -168,9 +170,8 @@ func TestCacheService_DeletePattern(t *testing.T) {
 
 	t.Run("没有匹配的键", func(t *testing.T) {
 		pattern := "nonexistent:*"
-		keys := []string{}
 
-		mock.ExpectKeys(pattern).SetVal(keys)
+		mock.ExpectScan(0, pattern, scanBatchSize).SetVal([]string{}, 0)
 
 		err := cacheService.DeletePattern(pattern)
 
@@ -179,6 +180,60 @@ func TestCacheService_DeletePattern(t *testing.T) {
 	})
 }
 
+func TestCacheService_SetJSONWithTags(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	cacheService := NewCacheService(db)
+
+	t.Run("写入缓存并登记标签", func(t *testing.T) {
+		key := "drama:1"
+		value := map[string]interface{}{"id": 1}
+		expiration := 10 * time.Minute
+
+		jsonData, _ := json.Marshal(value)
+		mock.ExpectSet(key, jsonData, expiration).SetVal("OK")
+		mock.ExpectSAdd("tag:drama:1", key).SetVal(1)
+		mock.ExpectTTL("tag:drama:1").SetVal(-1) // 新建的标签集合
+		mock.ExpectSAdd("tag:drama-list", key).SetVal(1)
+		mock.ExpectTTL("tag:drama-list").SetVal(time.Hour) // 剩余时间足够，不需要延长
+		mock.ExpectExpire("tag:drama:1", expiration).SetVal(true)
+
+		err := cacheService.SetJSONWithTags(key, value, expiration, TagDrama(1), TagDramaList)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCacheService_InvalidateTag(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	cacheService := NewCacheService(db)
+
+	t.Run("删除标签下的全部缓存", func(t *testing.T) {
+		mock.ExpectTxPipeline()
+		mock.ExpectSMembers("tag:drama:1").SetVal([]string{"drama:1", "dramas:page:1:size:20:category:"})
+		mock.ExpectDel("tag:drama:1").SetVal(1)
+		mock.ExpectTxPipelineExec()
+		mock.ExpectDel("drama:1", "dramas:page:1:size:20:category:").SetVal(2)
+
+		err := cacheService.InvalidateTag(TagDrama(1))
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("标签下没有缓存", func(t *testing.T) {
+		mock.ExpectTxPipeline()
+		mock.ExpectSMembers("tag:category:爱情").SetVal([]string{})
+		mock.ExpectDel("tag:category:爱情").SetVal(0)
+		mock.ExpectTxPipelineExec()
+
+		err := cacheService.InvalidateTag(TagCategory("爱情"))
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestCacheService_Increment(t *testing.T) {
 	db, mock := redismock.NewClientMock()
 	cacheService := NewCacheService(db)
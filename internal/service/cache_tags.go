@@ -0,0 +1,30 @@
+package service
+
+import "fmt"
+
+// 缓存标签
+// 缓存写入时登记到标签下，数据变更时按标签批量失效，取代 KEYS/SCAN 模式删除
+const (
+	// TagDramaList 未按分类过滤的短剧列表（含热门列表）
+	TagDramaList = "drama-list"
+)
+
+// TagDrama 单个短剧相关的全部缓存（详情、剧集列表以及包含该短剧的列表页）
+func TagDrama(id uint) string {
+	return fmt.Sprintf("drama:%d", id)
+}
+
+// TagEpisode 单个剧集相关的全部缓存
+func TagEpisode(id uint) string {
+	return fmt.Sprintf("episode:%d", id)
+}
+
+// TagCategory 按分类过滤的短剧列表
+func TagCategory(category string) string {
+	return "category:" + category
+}
+
+// tagKey 标签集合在缓存中的键名
+func tagKey(tag string) string {
+	return "tag:" + tag
+}
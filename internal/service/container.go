@@ -1,8 +1,13 @@
 package service
 
 import (
+	"log/slog"
+
 	"gin-mysql-api/internal/repository"
 	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/notify"
+	"gin-mysql-api/pkg/storage"
+	"gin-mysql-api/pkg/transcode"
 	"gin-mysql-api/pkg/utils"
 
 	"github.com/go-redis/redis/v8"
@@ -15,32 +20,63 @@ type Container struct {
 	AdminService AdminService
 	AuthService  AuthService
 	CacheService CacheService
-	FileService  FileService
+	FileService   FileService
+	UploadService UploadService
+	MediaService  MediaService
+	ImageService  ImageService
+	// TranscodeService 未启用转码时为 nil
+	TranscodeService  TranscodeService
+	PlaybackService   PlaybackService
+	ContentKeyService ContentKeyService
+	PublishService    PublishService
+	ReviewService     ReviewService
+	PreviewService    PreviewService
+	TrashService      TrashService
+	RevisionService   RevisionService
 }
 
-// NewContainer 创建新的服务容器
+// NewContainer 创建新的服务容器，log 为 nil 时各服务使用全局默认 Logger
 func NewContainer(
 	cfg *config.Config,
 	repos *repository.Repository,
 	redisClient *redis.Client,
+	store storage.Storage,
 	jwtManager *utils.JWTManager,
+	log *slog.Logger,
 ) *Container {
-	// 创建缓存服务
-	cacheService := NewCacheService(redisClient)
+	// 创建缓存服务（Redis 不可用时自动降级为本地缓存）
+	cacheService := NewCacheServiceWithConfig(&cfg.Cache, redisClient, log)
+
+	// 创建图片处理服务
+	imageService := NewImageService(repos.Media, store, NewImageServiceConfig(cfg), log)
 
 	// 创建文件服务
-	fileService := NewFileService(
-		cfg.Upload.UploadPath,
-		"http://localhost:1800", // 这里应该从配置中获取
-		int64(cfg.Upload.MaxSize)*1024*1024, // 转换为字节
-		cfg.Upload.AllowedTypes,
-	)
+	fileService := NewFileService(store, repos.Media, imageService, NewFileServiceConfig(cfg), log)
+
+	// 创建分片上传服务
+	uploadService := NewUploadService(repos.Upload, store, fileService, NewUploadServiceConfig(cfg), log)
+
+	// 创建媒体文件服务
+	mediaService := NewMediaService(repos.Media, store, imageService, cfg.Upload.GC.GetRetention(), log)
 
 	// 创建用户服务
-	userService := NewUserService(repos.User, jwtManager)
+	userService := NewUserService(repos.User, jwtManager, mediaService, log)
+
+	// 创建内容密钥服务（未配置主密钥时不能加密受保护的剧集）
+	contentKeyService := NewContentKeyService(repos.Transcode, NewContentKeyring(cfg), cfg.Server.GetBaseURL(), log)
+
+	// 创建转码服务（未启用转码时剧集只提供原始视频播放源）
+	var transcodeService TranscodeService
+	if cfg.Transcode.Enabled {
+		executor := transcode.NewFFmpeg(cfg.Transcode.GetFFmpegPath(), cfg.Transcode.GetSegmentSeconds())
+		transcodeService = NewTranscodeService(repos.Transcode, repos.Episode, repos.Media, store, executor, contentKeyService, cacheService, NewTranscodeServiceConfig(cfg), log)
+	}
+
+	// 创建播放服务（未开启签名时播放地址即文件 URL）
+	playbackService := NewPlaybackService(repos.Transcode, repos.Episode, repos.Media, fileService, contentKeyService, cacheService, NewPlaybackSigner(cfg), NewPlaybackServiceConfig(cfg), log)
 
 	// 创建短剧服务
-	dramaService := NewDramaService(repos.Drama, repos.Episode, cacheService)
+	dramaService := NewDramaService(repos.Drama, repos.Episode, cacheService, playbackService, log)
 
 	// 创建管理服务
 	adminService := NewAdminService(
@@ -49,10 +85,29 @@ func NewContainer(
 		repos.Episode,
 		jwtManager,
 		cacheService,
+		mediaService,
+		transcodeService,
+		repos.Revision,
+		log,
 	)
 
 	// 创建认证服务
-	authService := NewAuthService(repos.User, repos.Admin, jwtManager)
+	authService := NewAuthService(repos.User, repos.Admin, jwtManager, log)
+
+	// 创建定时发布服务（未配置 Webhook 时不发送发布通知）
+	publishService := NewPublishService(repos.Admin, repos.Drama, repos.Episode, repos.Review, cacheService, notify.New(&cfg.Publish.Webhook), cfg.Publish.GetLocation(), log)
+
+	// 创建审核流程服务
+	reviewService := NewReviewService(repos.Admin, repos.Drama, repos.Episode, repos.Review, cacheService, log)
+
+	// 创建内容预览服务（预览令牌与管理员预览地址的有效期相同）
+	previewService := NewPreviewService(repos.Drama, repos.Episode, jwtManager, cfg.Server.GetBaseURL(), cfg.Playback.GetPreviewTTL(), log)
+
+	// 创建回收站服务（永久删除时立即回收不再被引用的媒体文件）
+	trashService := NewTrashService(repos.Trash, repos.Drama, repos.Episode, mediaService, store, cacheService, cfg.Trash.GetRetention(), log)
+
+	// 创建修订版本服务（回滚通过管理服务更新内容）
+	revisionService := NewRevisionService(repos.Revision, adminService, log)
 
 	return &Container{
 		UserService:  userService,
@@ -60,6 +115,17 @@ func NewContainer(
 		AdminService: adminService,
 		AuthService:  authService,
 		CacheService: cacheService,
-		FileService:  fileService,
+		FileService:   fileService,
+		UploadService: uploadService,
+		MediaService:  mediaService,
+		ImageService:  imageService,
+		TranscodeService:  transcodeService,
+		PlaybackService:   playbackService,
+		ContentKeyService: contentKeyService,
+		PublishService:    publishService,
+		ReviewService:     reviewService,
+		PreviewService:    previewService,
+		TrashService:      trashService,
+		RevisionService:   revisionService,
 	}
 }
\ No newline at end of file
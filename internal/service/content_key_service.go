@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/keyring"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/transcode"
+)
+
+// rewrapBatchSize 重新加密内容密钥时每批处理的数量
+const rewrapBatchSize = 100
+
+var (
+	// ErrContentKeyNotFound 内容密钥不存在或不属于该剧集
+	ErrContentKeyNotFound = errors.New("密钥不存在")
+	// ErrEncryptionDisabled 未配置主密钥，无法加密受保护的剧集
+	ErrEncryptionDisabled = errors.New("未配置 playback.encryption.masterKeys，无法加密剧集")
+)
+
+// ContentKeyService HLS 内容密钥服务接口
+// 受保护的剧集每次转码生成新的 AES-128 内容密钥，密钥使用主密钥加密后保存，播放器通过密钥接口获取
+type ContentKeyService interface {
+	// WithContext 返回绑定请求上下文的服务
+	WithContext(ctx context.Context) ContentKeyService
+	// Create 为转码任务生成内容密钥，返回传给转码器的加密参数，未配置主密钥时返回 ErrEncryptionDisabled
+	Create(episodeID, jobID uint) (*transcode.Encryption, error)
+	// Key 获取解密后的内容密钥，不存在时返回 ErrContentKeyNotFound
+	Key(episodeID, keyID uint) ([]byte, error)
+	// Prune 删除剧集中除 keepJobID 任务以外的内容密钥，keepJobID 为 0 时全部删除
+	Prune(episodeID, keepJobID uint) error
+	// Rewrap 使用当前主密钥重新加密由旧主密钥加密的内容密钥，返回重新加密的数量
+	Rewrap() (int, error)
+}
+
+// NewContentKeyring 根据配置创建主密钥集合，未配置主密钥时返回 nil
+func NewContentKeyring(cfg *config.Config) *keyring.Keyring {
+	ring, err := keyring.New(keyring.MasterKeys(&cfg.Playback.Encryption))
+	if err != nil {
+		return nil
+	}
+	return ring
+}
+
+// contentKeyService HLS 内容密钥服务实现
+type contentKeyService struct {
+	repo    repository.TranscodeRepository
+	ring    *keyring.Keyring
+	baseURL string
+	logger  *slog.Logger
+	ctx     context.Context
+}
+
+// NewContentKeyService 创建内容密钥服务，密钥地址为 <baseURL>/api/episodes/<id>/keys/<key_id>
+// ring 为 nil 时不能加密剧集；log 为 nil 时使用全局默认 Logger
+func NewContentKeyService(repo repository.TranscodeRepository, ring *keyring.Keyring, baseURL string, log *slog.Logger) ContentKeyService {
+	return &contentKeyService{
+		repo:    repo,
+		ring:    ring,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		logger:  logger.OrDefault(log),
+		ctx:     context.Background(),
+	}
+}
+
+// WithContext 返回绑定请求上下文的内容密钥服务
+func (s *contentKeyService) WithContext(ctx context.Context) ContentKeyService {
+	scoped := *s
+	scoped.ctx = ctx
+	scoped.repo = s.repo.WithContext(ctx)
+	return &scoped
+}
+
+// Create 生成随机的内容密钥并加密保存
+func (s *contentKeyService) Create(episodeID, jobID uint) (*transcode.Encryption, error) {
+	if s.ring == nil {
+		return nil, ErrEncryptionDisabled
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("生成内容密钥失败: %w", err)
+	}
+	masterKeyID, sealed, err := s.ring.Seal(key)
+	if err != nil {
+		return nil, fmt.Errorf("加密内容密钥失败: %w", err)
+	}
+
+	record := &models.EpisodeKey{EpisodeID: episodeID, JobID: jobID, MasterKeyID: masterKeyID, EncryptedKey: sealed}
+	if err := s.repo.CreateKey(record); err != nil {
+		return nil, fmt.Errorf("保存内容密钥失败: %w", err)
+	}
+	return &transcode.Encryption{
+		Key:    key,
+		KeyURI: fmt.Sprintf("%s/api/episodes/%d/keys/%d", s.baseURL, episodeID, record.ID),
+	}, nil
+}
+
+// Key 查询并解密内容密钥
+func (s *contentKeyService) Key(episodeID, keyID uint) ([]byte, error) {
+	record, err := s.repo.GetKey(episodeID, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("查询内容密钥失败: %w", err)
+	}
+	if record == nil {
+		return nil, ErrContentKeyNotFound
+	}
+	if s.ring == nil {
+		return nil, ErrEncryptionDisabled
+	}
+	key, err := s.ring.Open(record.MasterKeyID, record.EncryptedKey)
+	if err != nil {
+		return nil, fmt.Errorf("解密内容密钥失败: %w", err)
+	}
+	return key, nil
+}
+
+// Prune 删除不再被转码结果引用的内容密钥
+func (s *contentKeyService) Prune(episodeID, keepJobID uint) error {
+	if err := s.repo.DeleteKeys(episodeID, keepJobID); err != nil {
+		return fmt.Errorf("删除内容密钥失败: %w", err)
+	}
+	return nil
+}
+
+// Rewrap 分批重新加密，使用已移除的主密钥加密的内容密钥无法解密，只记录日志
+func (s *contentKeyService) Rewrap() (int, error) {
+	if s.ring == nil {
+		return 0, nil
+	}
+
+	rewrapped := 0
+	var afterID uint
+	for s.ctx.Err() == nil {
+		records, err := s.repo.ListKeysNotSealedWith(s.ring.Primary(), afterID, rewrapBatchSize)
+		if err != nil {
+			return rewrapped, fmt.Errorf("查询内容密钥失败: %w", err)
+		}
+		if len(records) == 0 {
+			break
+		}
+		for i := range records {
+			record := &records[i]
+			afterID = record.ID
+			key, err := s.ring.Open(record.MasterKeyID, record.EncryptedKey)
+			if err != nil {
+				s.logger.ErrorContext(s.ctx, "解密内容密钥失败", slog.Any("key_id", record.ID),
+					slog.String("master_key_id", record.MasterKeyID), slog.String("error", err.Error()))
+				continue
+			}
+			if record.MasterKeyID, record.EncryptedKey, err = s.ring.Seal(key); err != nil {
+				return rewrapped, fmt.Errorf("加密内容密钥失败: %w", err)
+			}
+			if err := s.repo.UpdateKey(record); err != nil {
+				return rewrapped, fmt.Errorf("保存内容密钥失败: %w", err)
+			}
+			rewrapped++
+		}
+	}
+
+	if rewrapped > 0 {
+		s.logger.InfoContext(s.ctx, "内容密钥已使用新主密钥重新加密", slog.Int("count", rewrapped), slog.String("master_key_id", s.ring.Primary()))
+	}
+	return rewrapped, nil
+}
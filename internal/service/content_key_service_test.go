@@ -0,0 +1,99 @@
+package service
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/internal/testutil"
+	"gin-mysql-api/pkg/keyring"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestKeyring 使用指定标识的主密钥创建主密钥集合
+func newTestKeyring(t *testing.T, ids ...string) *keyring.Keyring {
+	t.Helper()
+	keys := make([]keyring.MasterKey, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, keyring.MasterKey{ID: id, Secret: []byte(strings.Repeat(id, 16))})
+	}
+	ring, err := keyring.New(keys)
+	require.NoError(t, err)
+	return ring
+}
+
+// keyIDFromURI 从密钥地址中解析内容密钥 ID
+func keyIDFromURI(t *testing.T, uri string) uint {
+	t.Helper()
+	id, err := strconv.ParseUint(path.Base(uri), 10, 64)
+	require.NoError(t, err, uri)
+	return uint(id)
+}
+
+func TestContentKeyService(t *testing.T) {
+	db := testutil.SetupTestDB()
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	repo := repository.NewTranscodeRepository(db)
+
+	t.Run("生成并读取内容密钥", func(t *testing.T) {
+		keys := NewContentKeyService(repo, newTestKeyring(t, "m1"), "https://api.example.com/", nil)
+		encryption, err := keys.Create(3, 7)
+		require.NoError(t, err)
+		assert.Len(t, encryption.Key, 16)
+		assert.Regexp(t, `^https://api\.example\.com/api/episodes/3/keys/\d+$`, encryption.KeyURI)
+
+		keyID := keyIDFromURI(t, encryption.KeyURI)
+		record, err := repo.GetKey(3, keyID)
+		require.NoError(t, err)
+		assert.Equal(t, "m1", record.MasterKeyID)
+		assert.NotContains(t, record.EncryptedKey, string(encryption.Key), "数据库中只保存加密后的密钥")
+		_, err = keys.Create(3, 8)
+		require.NoError(t, err)
+
+		key, err := keys.Key(3, keyID)
+		require.NoError(t, err)
+		assert.Equal(t, encryption.Key, key)
+		_, err = keys.Key(4, keyID)
+		assert.ErrorIs(t, err, ErrContentKeyNotFound)
+
+		require.NoError(t, keys.Prune(3, 8))
+		_, err = keys.Key(3, keyID)
+		assert.ErrorIs(t, err, ErrContentKeyNotFound)
+	})
+
+	t.Run("轮换主密钥后重新加密", func(t *testing.T) {
+		old := NewContentKeyService(repo, newTestKeyring(t, "m1"), "https://api.example.com", nil)
+		encryption, err := old.Create(5, 1)
+		require.NoError(t, err)
+		keyID := keyIDFromURI(t, encryption.KeyURI)
+
+		rotated := NewContentKeyService(repo, newTestKeyring(t, "m2", "m1"), "https://api.example.com", nil)
+		rewrapped, err := rotated.Rewrap()
+		require.NoError(t, err)
+		assert.Positive(t, rewrapped)
+		rewrapped, err = rotated.Rewrap()
+		require.NoError(t, err)
+		assert.Zero(t, rewrapped, "已使用新主密钥加密的密钥不再处理")
+
+		// 移除旧主密钥后仍可读取
+		key, err := NewContentKeyService(repo, newTestKeyring(t, "m2"), "https://api.example.com", nil).Key(5, keyID)
+		require.NoError(t, err)
+		assert.Equal(t, encryption.Key, key)
+	})
+
+	t.Run("未配置主密钥", func(t *testing.T) {
+		keys := NewContentKeyService(repo, nil, "https://api.example.com", nil)
+		_, err := keys.Create(1, 1)
+		assert.ErrorIs(t, err, ErrEncryptionDisabled)
+		rewrapped, err := keys.Rewrap()
+		require.NoError(t, err)
+		assert.Zero(t, rewrapped)
+	})
+}
@@ -1,44 +1,106 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
 	"time"
 
 	"gin-mysql-api/internal/models"
 	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/metrics"
 )
 
 // DramaService 短剧服务接口
 type DramaService interface {
+	// WithContext 返回绑定请求上下文的服务，数据库与缓存调用会继承上下文中的链路信息
+	WithContext(ctx context.Context) DramaService
+	// WithVisibility 返回按可见范围查询的服务，默认只能查看已发布的短剧与剧集
+	// 管理员与预览令牌可以查看未发布的内容，这些内容直接查询数据库，不读写缓存
+	WithVisibility(v Visibility) DramaService
 	GetDramas(page, pageSize int, genre string) (*models.PaginatedDramas, error)
 	GetDramaByID(id uint) (*models.Drama, error)
 	GetDramaWithEpisodes(id uint) (*models.Drama, error)
 	GetEpisodesByDramaID(dramaID uint, page, pageSize int) (*models.PaginatedEpisodes, error)
-	GetEpisodeByID(id uint) (*models.Episode, error)
+	// GetEpisodeByID 获取剧集详情与播放源，开启播放地址签名时为 viewer 签发播放地址
+	GetEpisodeByID(id uint, viewer PlaybackViewer) (*models.EpisodeDetail, error)
 	IncrementDramaViewCount(dramaID uint) error
 	IncrementEpisodeViewCount(episodeID uint) error
 	SearchDramas(keyword string, page, pageSize int) (*models.PaginatedDramas, error)
 	GetPopularDramas(page, pageSize int) (*models.PaginatedDramas, error)
 }
 
+// 读穿缓存策略
+var (
+	dramaListCachePolicy    = cachePolicy{TTL: 5 * time.Minute, StaleTTL: 2 * time.Minute, NegativeTTL: time.Minute}
+	dramaDetailCachePolicy  = cachePolicy{TTL: 10 * time.Minute, StaleTTL: 5 * time.Minute, NegativeTTL: time.Minute}
+	popularDramaCachePolicy = cachePolicy{TTL: 30 * time.Minute, StaleTTL: 10 * time.Minute}
+)
+
 // dramaService 短剧服务实现
 type dramaService struct {
 	dramaRepo    repository.DramaRepository
 	episodeRepo  repository.EpisodeRepository
 	cacheService CacheService
+	playback     PlaybackService
+	readThrough  *readThroughCache
+	visibility   Visibility
+	logger       *slog.Logger
+	ctx          context.Context
 }
 
-// NewDramaService 创建新的短剧服务
+// NewDramaService 创建新的短剧服务，log 为 nil 时使用全局默认 Logger
+// playback 为 nil 时剧集详情只返回原始视频地址作为播放源
 func NewDramaService(
 	dramaRepo repository.DramaRepository,
 	episodeRepo repository.EpisodeRepository,
 	cacheService CacheService,
+	playback PlaybackService,
+	log *slog.Logger,
 ) DramaService {
+	log = logger.OrDefault(log)
 	return &dramaService{
 		dramaRepo:    dramaRepo,
 		episodeRepo:  episodeRepo,
 		cacheService: cacheService,
+		playback:     playback,
+		readThrough:  newReadThroughCache(cacheService, log),
+		logger:       log,
+		ctx:          context.Background(),
+	}
+}
+
+// WithContext 返回绑定请求上下文的短剧服务
+// 上下文的取消信号会被忽略：读穿缓存的后台刷新与异步的观看计数可能在请求结束后才执行
+func (s *dramaService) WithContext(ctx context.Context) DramaService {
+	ctx = context.WithoutCancel(ctx)
+
+	scoped := &dramaService{
+		dramaRepo:   s.dramaRepo.WithContext(ctx),
+		episodeRepo: s.episodeRepo.WithContext(ctx),
+		readThrough: s.readThrough,
+		visibility:  s.visibility,
+		logger:      s.logger,
+		ctx:         ctx,
+	}
+	if s.cacheService != nil {
+		scoped.cacheService = s.cacheService.WithContext(ctx)
+		scoped.readThrough = s.readThrough.withContext(ctx, scoped.cacheService)
+	}
+	if s.playback != nil {
+		scoped.playback = s.playback.WithContext(ctx)
 	}
+	return scoped
+}
+
+// WithVisibility 返回按可见范围查询的短剧服务
+func (s *dramaService) WithVisibility(v Visibility) DramaService {
+	scoped := *s
+	scoped.visibility = v
+	return &scoped
 }
 
 // GetDramas 获取短剧列表
@@ -50,16 +112,14 @@ func (s *dramaService) GetDramas(page, pageSize int, category string) (*models.P
 		pageSize = 20
 	}
 
-	// 尝试从缓存获取
 	cacheKey := fmt.Sprintf("dramas:page:%d:size:%d:category:%s", page, pageSize, category)
-	var cachedResult models.PaginatedDramas
-	if s.cacheService != nil {
-		err := s.cacheService.GetJSON(cacheKey, &cachedResult)
-		if err == nil {
-			return &cachedResult, nil
-		}
-	}
+	return readThrough(s.readThrough, cacheKey, dramaListCachePolicy, func() (*models.PaginatedDramas, []string, error) {
+		return s.loadDramas(page, pageSize, category)
+	})
+}
 
+// loadDramas 从数据库加载短剧列表
+func (s *dramaService) loadDramas(page, pageSize int, category string) (*models.PaginatedDramas, []string, error) {
 	offset := (page - 1) * pageSize
 	var dramas []models.Drama
 	var total int64
@@ -72,7 +132,7 @@ func (s *dramaService) GetDramas(page, pageSize int, category string) (*models.P
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("获取短剧列表失败: %w", err)
+		return nil, nil, fmt.Errorf("获取短剧列表失败: %w", err)
 	}
 
 	totalPages := (int(total) + pageSize - 1) / pageSize
@@ -87,34 +147,27 @@ func (s *dramaService) GetDramas(page, pageSize int, category string) (*models.P
 		HasPrevious: page > 1,
 	}
 
-	// 缓存结果
-	if s.cacheService != nil {
-		s.cacheService.SetJSON(cacheKey, result, 5*time.Minute)
-	}
-
-	return result, nil
+	return result, dramaListTags(category, dramas), nil
 }
 
 // GetDramaByID 根据ID获取短剧
 func (s *dramaService) GetDramaByID(id uint) (*models.Drama, error) {
-	// 尝试从缓存获取
-	cacheKey := fmt.Sprintf("drama:%d", id)
-	var cachedDrama models.Drama
-	if s.cacheService != nil {
-		err := s.cacheService.GetJSON(cacheKey, &cachedDrama)
-		if err == nil {
-			return &cachedDrama, nil
-		}
+	var drama *models.Drama
+	var err error
+	if s.visibility.coversDrama(id) {
+		drama, err = s.dramaRepo.GetByID(id)
+	} else {
+		cacheKey := fmt.Sprintf("drama:%d", id)
+		drama, err = readThrough(s.readThrough, cacheKey, dramaDetailCachePolicy, func() (*models.Drama, []string, error) {
+			drama, err := s.dramaRepo.GetPublishedByID(id)
+			return drama, []string{TagDrama(id)}, err
+		})
 	}
-
-	drama, err := s.dramaRepo.GetByID(id)
 	if err != nil {
 		return nil, fmt.Errorf("短剧不存在: %w", err)
 	}
-
-	// 缓存结果
-	if s.cacheService != nil {
-		s.cacheService.SetJSON(cacheKey, drama, 10*time.Minute)
+	if drama == nil {
+		return nil, errors.New("短剧不存在")
 	}
 
 	return drama, nil
@@ -122,26 +175,43 @@ func (s *dramaService) GetDramaByID(id uint) (*models.Drama, error) {
 
 // GetDramaWithEpisodes 获取短剧及其剧集
 func (s *dramaService) GetDramaWithEpisodes(id uint) (*models.Drama, error) {
-	// 尝试从缓存获取
-	cacheKey := fmt.Sprintf("drama_with_episodes:%d", id)
-	var cachedDrama models.Drama
-	if s.cacheService != nil {
-		err := s.cacheService.GetJSON(cacheKey, &cachedDrama)
-		if err == nil {
-			return &cachedDrama, nil
-		}
+	var drama *models.Drama
+	var err error
+	if s.visibility.coversDrama(id) {
+		drama, err = s.loadDramaWithAllEpisodes(id)
+	} else {
+		cacheKey := fmt.Sprintf("drama_with_episodes:%d", id)
+		drama, err = readThrough(s.readThrough, cacheKey, dramaDetailCachePolicy, func() (*models.Drama, []string, error) {
+			drama, err := s.dramaRepo.GetByIDWithEpisodes(id)
+			if err != nil || drama == nil {
+				return nil, []string{TagDrama(id)}, err
+			}
+			return drama, episodeListTags(id, drama.Episodes), nil
+		})
 	}
-
-	drama, err := s.dramaRepo.GetByIDWithEpisodes(id)
 	if err != nil {
 		return nil, fmt.Errorf("短剧不存在: %w", err)
 	}
-
-	// 缓存结果
-	if s.cacheService != nil {
-		s.cacheService.SetJSON(cacheKey, drama, 10*time.Minute)
+	if drama == nil {
+		return nil, errors.New("短剧不存在")
 	}
 
+	hidden := *drama
+	hidden.Episodes = s.withoutVideoURLs(drama.Episodes)
+	return &hidden, nil
+}
+
+// loadDramaWithAllEpisodes 从数据库加载短剧及其所有状态的剧集，短剧不存在时返回 nil
+func (s *dramaService) loadDramaWithAllEpisodes(id uint) (*models.Drama, error) {
+	drama, err := s.dramaRepo.GetByID(id)
+	if err != nil || drama == nil {
+		return nil, err
+	}
+	episodes, err := s.episodeRepo.ListByDramaID(id)
+	if err != nil {
+		return nil, err
+	}
+	drama.Episodes = episodes
 	return drama, nil
 }
 
@@ -154,26 +224,62 @@ func (s *dramaService) GetEpisodesByDramaID(dramaID uint, page, pageSize int) (*
 		pageSize = 20
 	}
 
-	// 检查短剧是否存在
-	_, err := s.dramaRepo.GetByID(dramaID)
+	var result *models.PaginatedEpisodes
+	var err error
+	if s.visibility.coversDrama(dramaID) {
+		result, _, err = s.loadEpisodes(dramaID, page, pageSize)
+	} else {
+		cacheKey := fmt.Sprintf("episodes:drama:%d:page:%d:size:%d", dramaID, page, pageSize)
+		result, err = readThrough(s.readThrough, cacheKey, dramaListCachePolicy, func() (*models.PaginatedEpisodes, []string, error) {
+			return s.loadEpisodes(dramaID, page, pageSize)
+		})
+	}
 	if err != nil {
-		return nil, fmt.Errorf("短剧不存在: %w", err)
+		return nil, err
+	}
+	if result == nil {
+		return nil, errors.New("短剧不存在")
 	}
 
-	// 尝试从缓存获取
-	cacheKey := fmt.Sprintf("episodes:drama:%d:page:%d:size:%d", dramaID, page, pageSize)
-	var cachedResult models.PaginatedEpisodes
-	if s.cacheService != nil {
-		err := s.cacheService.GetJSON(cacheKey, &cachedResult)
-		if err == nil {
-			return &cachedResult, nil
+	hidden := *result
+	hidden.Episodes = s.withoutVideoURLs(result.Episodes)
+	return &hidden, nil
+}
+
+// withoutVideoURLs 复制剧集列表并清空不能公开的视频地址，不修改缓存中的数据
+// 受保护的剧集只能通过加密的 HLS 播放，始终不返回原始视频地址；开启播放地址签名时播放地址只能通过剧集详情获取
+func (s *dramaService) withoutVideoURLs(episodes []models.Episode) []models.Episode {
+	signed := s.playback != nil && s.playback.Signed()
+	hidden := slices.Clone(episodes)
+	for i := range hidden {
+		if signed || hidden[i].Protected {
+			hidden[i].VideoURL = ""
 		}
 	}
+	return hidden
+}
+
+// loadEpisodes 从数据库加载剧集列表，短剧不存在时返回 nil
+// 可见范围不覆盖该短剧时只加载已发布短剧中已发布的剧集
+func (s *dramaService) loadEpisodes(dramaID uint, page, pageSize int) (*models.PaginatedEpisodes, []string, error) {
+	getDrama, listEpisodes := s.dramaRepo.GetPublishedByID, s.episodeRepo.GetPublishedByDramaIDPaginated
+	if s.visibility.coversDrama(dramaID) {
+		getDrama, listEpisodes = s.dramaRepo.GetByID, s.episodeRepo.GetByDramaIDPaginated
+	}
+
+	// 检查短剧是否存在
+	drama, err := getDrama(dramaID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("短剧不存在: %w", err)
+	}
+	if drama == nil {
+		return nil, []string{TagDrama(dramaID)}, nil
+	}
 
 	offset := (page - 1) * pageSize
-	episodes, total, err := s.episodeRepo.GetByDramaIDPaginated(dramaID, offset, pageSize)
+	episodes, total, err := listEpisodes(dramaID, offset, pageSize)
 	if err != nil {
-		return nil, fmt.Errorf("获取剧集列表失败: %w", err)
+		return nil, nil, fmt.Errorf("获取剧集列表失败: %w", err)
 	}
 
 	totalPages := (int(total) + pageSize - 1) / pageSize
@@ -188,54 +294,91 @@ func (s *dramaService) GetEpisodesByDramaID(dramaID uint, page, pageSize int) (*
 		HasPrevious: page > 1,
 	}
 
-	// 缓存结果
-	if s.cacheService != nil {
-		s.cacheService.SetJSON(cacheKey, result, 5*time.Minute)
-	}
-
-	return result, nil
+	return result, episodeListTags(dramaID, episodes), nil
 }
 
-// GetEpisodeByID 根据ID获取剧集
-func (s *dramaService) GetEpisodeByID(id uint) (*models.Episode, error) {
-	// 尝试从缓存获取
-	cacheKey := fmt.Sprintf("episode:%d", id)
-	var cachedEpisode models.Episode
-	if s.cacheService != nil {
-		err := s.cacheService.GetJSON(cacheKey, &cachedEpisode)
-		if err == nil {
-			return &cachedEpisode, nil
-		}
+// GetEpisodeByID 根据ID获取剧集详情
+// 缓存中只保存剧集记录，播放源在每次请求时生成，避免返回已过期的预签名 URL
+func (s *dramaService) GetEpisodeByID(id uint, viewer PlaybackViewer) (*models.EpisodeDetail, error) {
+	episode, err := s.previewEpisode(id)
+	previewed := episode != nil
+	if err == nil && !previewed {
+		cacheKey := fmt.Sprintf("episode:%d", id)
+		episode, err = readThrough(s.readThrough, cacheKey, dramaDetailCachePolicy, func() (*models.Episode, []string, error) {
+			episode, err := s.episodeRepo.GetPublishedByIDWithDrama(id)
+			if err != nil || episode == nil {
+				return nil, []string{TagEpisode(id)}, err
+			}
+			return episode, []string{TagEpisode(id), TagDrama(episode.DramaID)}, nil
+		})
 	}
-
-	episode, err := s.episodeRepo.GetByIDWithDrama(id)
 	if err != nil {
 		return nil, fmt.Errorf("剧集不存在: %w", err)
 	}
+	if episode == nil {
+		return nil, errors.New("剧集不存在")
+	}
 
-	// 缓存结果
-	if s.cacheService != nil {
-		s.cacheService.SetJSON(cacheKey, episode, 10*time.Minute)
+	var sources []models.PlaybackSource
+	if previewed && s.playback != nil && !episodePublished(episode) {
+		// 未发布的剧集签发管理员预览地址
+		sources, err = s.playback.Preview(episode.ID, s.visibility.AdminID)
+	} else {
+		sources, err = s.episodeSources(episode, viewer)
 	}
+	if err != nil {
+		return nil, err
+	}
+	return models.NewEpisodeDetail(episode, sources), nil
+}
 
+// previewEpisode 可见范围覆盖剧集时不论状态从数据库获取剧集，否则返回 nil
+func (s *dramaService) previewEpisode(id uint) (*models.Episode, error) {
+	if s.visibility.Public() {
+		return nil, nil
+	}
+	episode, err := s.episodeRepo.GetByIDWithDrama(id)
+	if err != nil || episode == nil || !s.visibility.coversEpisode(episode) {
+		return nil, err
+	}
 	return episode, nil
 }
 
+// episodePublished 剧集与所属短剧是否都已发布
+func episodePublished(episode *models.Episode) bool {
+	return episode.Status == models.StatusPublished && episode.Drama.Status == models.StatusPublished
+}
+
+// episodeSources 获取剧集的播放源，没有观看权限时原样返回 ErrPlaybackLoginRequired 或 ErrPlaybackForbidden
+func (s *dramaService) episodeSources(episode *models.Episode, viewer PlaybackViewer) ([]models.PlaybackSource, error) {
+	if s.playback == nil {
+		// 受保护的剧集只能通过加密的 HLS 播放，未启用播放服务时没有可用的播放源
+		if episode.VideoURL == "" || episode.Protected {
+			return nil, nil
+		}
+		return []models.PlaybackSource{{Type: models.PlaybackTypeMP4, MimeType: "video/mp4", URL: episode.VideoURL}}, nil
+	}
+
+	sources, err := s.playback.Sources(episode, viewer)
+	if errors.Is(err, ErrPlaybackLoginRequired) || errors.Is(err, ErrPlaybackForbidden) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取播放源失败: %w", err)
+	}
+	return sources, nil
+}
+
 // IncrementDramaViewCount 增加短剧观看次数
 func (s *dramaService) IncrementDramaViewCount(dramaID uint) error {
 	err := s.dramaRepo.IncrementViewCount(dramaID)
 	if err != nil {
+		s.logger.ErrorContext(s.ctx, "更新短剧观看次数失败", slog.Any("drama_id", dramaID), slog.String("error", err.Error()))
 		return fmt.Errorf("更新短剧观看次数失败: %w", err)
 	}
+	metrics.RecordDramaView()
 
-	// 清除相关缓存
-	if s.cacheService != nil {
-		s.cacheService.Delete(fmt.Sprintf("drama:%d", dramaID))
-		s.cacheService.Delete(fmt.Sprintf("drama_with_episodes:%d", dramaID))
-		// 清除列表缓存（简单处理，实际可以更精细）
-		s.clearDramaListCache()
-	}
-
+	// 不清除缓存：每次访问都清除会让热门短剧的缓存始终失效，缓存中的观看次数在过期后更新
 	return nil
 }
 
@@ -243,19 +386,12 @@ func (s *dramaService) IncrementDramaViewCount(dramaID uint) error {
 func (s *dramaService) IncrementEpisodeViewCount(episodeID uint) error {
 	err := s.episodeRepo.IncrementViewCount(episodeID)
 	if err != nil {
+		s.logger.ErrorContext(s.ctx, "更新剧集观看次数失败", slog.Any("episode_id", episodeID), slog.String("error", err.Error()))
 		return fmt.Errorf("更新剧集观看次数失败: %w", err)
 	}
+	metrics.RecordEpisodeView()
 
-	// 清除相关缓存
-	if s.cacheService != nil {
-		s.cacheService.Delete(fmt.Sprintf("episode:%d", episodeID))
-		// 获取剧集信息以清除相关缓存
-		episode, err := s.episodeRepo.GetByID(episodeID)
-		if err == nil {
-			s.cacheService.Delete(fmt.Sprintf("drama_with_episodes:%d", episode.DramaID))
-		}
-	}
-
+	// 不清除缓存，缓存中的观看次数在过期后更新
 	return nil
 }
 
@@ -275,32 +411,38 @@ func (s *dramaService) SearchDramas(keyword string, page, pageSize int) (*models
 
 // GetPopularDramas 获取热门短剧
 func (s *dramaService) GetPopularDramas(page, pageSize int) (*models.PaginatedDramas, error) {
-	// 尝试从缓存获取
+	// 热门内容缓存时间更长
 	cacheKey := fmt.Sprintf("popular_dramas:page:%d:size:%d", page, pageSize)
-	var cachedResult models.PaginatedDramas
-	if s.cacheService != nil {
-		err := s.cacheService.GetJSON(cacheKey, &cachedResult)
-		if err == nil {
-			return &cachedResult, nil
+	return readThrough(s.readThrough, cacheKey, popularDramaCachePolicy, func() (*models.PaginatedDramas, []string, error) {
+		// 这里简化实现，实际应该按观看次数排序
+		result, err := s.GetDramas(page, pageSize, "")
+		if err != nil {
+			return nil, nil, err
 		}
-	}
+		return result, dramaListTags("", result.Dramas), nil
+	})
+}
 
-	// 这里简化实现，实际应该按观看次数排序
-	result, err := s.GetDramas(page, pageSize, "")
-	if err != nil {
-		return nil, err
+// dramaListTags 短剧列表缓存的标签：列表/分类标签加上列表中每个短剧的标签
+func dramaListTags(category string, dramas []models.Drama) []string {
+	tags := make([]string, 0, len(dramas)+1)
+	if category != "" {
+		tags = append(tags, TagCategory(category))
+	} else {
+		tags = append(tags, TagDramaList)
 	}
-
-	// 缓存结果（热门内容缓存时间更长）
-	if s.cacheService != nil {
-		s.cacheService.SetJSON(cacheKey, result, 30*time.Minute)
+	for _, drama := range dramas {
+		tags = append(tags, TagDrama(drama.ID))
 	}
-
-	return result, nil
+	return tags
 }
 
-// clearDramaListCache 清除短剧列表缓存
-func (s *dramaService) clearDramaListCache() {
-	// 这里简化处理，实际应该更精细地管理缓存
-	// 可以使用缓存标签或者模式匹配来批量清除
+// episodeListTags 剧集列表缓存的标签：所属短剧标签加上列表中每个剧集的标签
+func episodeListTags(dramaID uint, episodes []models.Episode) []string {
+	tags := make([]string, 0, len(episodes)+1)
+	tags = append(tags, TagDrama(dramaID))
+	for _, episode := range episodes {
+		tags = append(tags, TagEpisode(episode.ID))
+	}
+	return tags
 }
@@ -2,7 +2,6 @@ package service
 
 import (
 	"testing"
-	"time"
 
 	"gin-mysql-api/internal/models"
 
@@ -10,184 +9,17 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
-// MockDramaRepository 模拟短剧仓库
-type MockDramaRepository struct {
-	mock.Mock
-}
-
-func (m *MockDramaRepository) Create(drama *models.Drama) error {
-	args := m.Called(drama)
-	return args.Error(0)
-}
-
-func (m *MockDramaRepository) GetByID(id uint) (*models.Drama, error) {
-	args := m.Called(id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*models.Drama), args.Error(1)
-}
-
-func (m *MockDramaRepository) GetByIDWithEpisodes(id uint) (*models.Drama, error) {
-	args := m.Called(id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*models.Drama), args.Error(1)
-}
-
-func (m *MockDramaRepository) GetList(offset, limit int, genre string) ([]models.Drama, int64, error) {
-	args := m.Called(offset, limit, genre)
-	return args.Get(0).([]models.Drama), args.Get(1).(int64), args.Error(2)
-}
-
-func (m *MockDramaRepository) Update(drama *models.Drama) error {
-	args := m.Called(drama)
-	return args.Error(0)
-}
-
-func (m *MockDramaRepository) Delete(id uint) error {
-	args := m.Called(id)
-	return args.Error(0)
-}
-
-func (m *MockDramaRepository) IncrementViewCount(id uint) error {
-	args := m.Called(id)
-	return args.Error(0)
-}
-
-func (m *MockDramaRepository) GetByGenre(genre string, offset, limit int) ([]models.Drama, int64, error) {
-	args := m.Called(genre, offset, limit)
-	return args.Get(0).([]models.Drama), args.Get(1).(int64), args.Error(2)
-}
-
-func (m *MockDramaRepository) GetActiveList(offset, limit int) ([]models.Drama, int64, error) {
-	args := m.Called(offset, limit)
-	return args.Get(0).([]models.Drama), args.Get(1).(int64), args.Error(2)
-}
-
-// MockEpisodeRepository 模拟剧集仓库
-type MockEpisodeRepository struct {
-	mock.Mock
-}
-
-func (m *MockEpisodeRepository) Create(episode *models.Episode) error {
-	args := m.Called(episode)
-	return args.Error(0)
-}
-
-func (m *MockEpisodeRepository) GetByID(id uint) (*models.Episode, error) {
-	args := m.Called(id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*models.Episode), args.Error(1)
-}
-
-func (m *MockEpisodeRepository) GetByIDWithDrama(id uint) (*models.Episode, error) {
-	args := m.Called(id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*models.Episode), args.Error(1)
-}
-
-func (m *MockEpisodeRepository) GetByDramaID(dramaID uint) ([]models.Episode, error) {
-	args := m.Called(dramaID)
-	return args.Get(0).([]models.Episode), args.Error(1)
-}
-
-func (m *MockEpisodeRepository) GetByDramaIDPaginated(dramaID uint, offset, limit int) ([]models.Episode, int64, error) {
-	args := m.Called(dramaID, offset, limit)
-	return args.Get(0).([]models.Episode), args.Get(1).(int64), args.Error(2)
-}
-
-func (m *MockEpisodeRepository) Update(episode *models.Episode) error {
-	args := m.Called(episode)
-	return args.Error(0)
-}
-
-func (m *MockEpisodeRepository) Delete(id uint) error {
-	args := m.Called(id)
-	return args.Error(0)
-}
-
-func (m *MockEpisodeRepository) IncrementViewCount(id uint) error {
-	args := m.Called(id)
-	return args.Error(0)
-}
-
-func (m *MockEpisodeRepository) GetMaxEpisodeNum(dramaID uint) (int, error) {
-	args := m.Called(dramaID)
-	return args.Int(0), args.Error(1)
-}
-
-func (m *MockEpisodeRepository) ExistsByDramaIDAndEpisodeNum(dramaID uint, episodeNum int) (bool, error) {
-	args := m.Called(dramaID, episodeNum)
-	return args.Bool(0), args.Error(1)
-}
-
-// MockCacheService 模拟缓存服务
-type MockCacheService struct {
-	mock.Mock
-}
-
-func (m *MockCacheService) Set(key string, value interface{}, expiration time.Duration) error {
-	args := m.Called(key, value, expiration)
-	return args.Error(0)
-}
-
-func (m *MockCacheService) Get(key string) (string, error) {
-	args := m.Called(key)
-	return args.String(0), args.Error(1)
-}
-
-func (m *MockCacheService) Delete(key string) error {
-	args := m.Called(key)
-	return args.Error(0)
-}
-
-func (m *MockCacheService) Exists(key string) (bool, error) {
-	args := m.Called(key)
-	return args.Bool(0), args.Error(1)
-}
-
-func (m *MockCacheService) SetJSON(key string, value interface{}, expiration time.Duration) error {
-	args := m.Called(key, value, expiration)
-	return args.Error(0)
-}
-
-func (m *MockCacheService) GetJSON(key string, dest interface{}) error {
-	args := m.Called(key, dest)
-	return args.Error(0)
-}
-
-func (m *MockCacheService) DeletePattern(pattern string) error {
-	args := m.Called(pattern)
-	return args.Error(0)
-}
-
-func (m *MockCacheService) Increment(key string) (int64, error) {
-	args := m.Called(key)
-	return args.Get(0).(int64), args.Error(1)
-}
-
-func (m *MockCacheService) Expire(key string, expiration time.Duration) error {
-	args := m.Called(key, expiration)
-	return args.Error(0)
-}
-
 func TestDramaService_GetDramas(t *testing.T) {
 	mockDramaRepo := new(MockDramaRepository)
 	mockEpisodeRepo := new(MockEpisodeRepository)
 	mockCacheService := new(MockCacheService)
 	
-	dramaService := NewDramaService(mockDramaRepo, mockEpisodeRepo, mockCacheService)
+	dramaService := NewDramaService(mockDramaRepo, mockEpisodeRepo, mockCacheService, nil, nil)
 
 	t.Run("成功获取短剧列表", func(t *testing.T) {
 		dramas := []models.Drama{
-			{ID: 1, Title: "短剧1", Genre: "喜剧"},
-			{ID: 2, Title: "短剧2", Genre: "爱情"},
+			{ID: 1, Title: "短剧1", Category: "喜剧"},
+			{ID: 2, Title: "短剧2", Category: "爱情"},
 		}
 
 		// 设置缓存未命中
@@ -197,7 +29,7 @@ func TestDramaService_GetDramas(t *testing.T) {
 		mockDramaRepo.On("GetActiveList", 0, 20).Return(dramas, int64(2), nil)
 		
 		// 设置缓存写入
-		mockCacheService.On("SetJSON", mock.AnythingOfType("string"), mock.Anything, mock.AnythingOfType("time.Duration")).Return(nil)
+		mockCacheService.On("SetJSONWithTags", mock.AnythingOfType("string"), mock.Anything, mock.AnythingOfType("time.Duration"), mock.Anything).Return(nil)
 
 		result, err := dramaService.GetDramas(1, 20, "")
 
@@ -214,7 +46,7 @@ func TestDramaService_GetDramas(t *testing.T) {
 
 	t.Run("按类型获取短剧列表", func(t *testing.T) {
 		dramas := []models.Drama{
-			{ID: 1, Title: "喜剧短剧", Genre: "喜剧"},
+			{ID: 1, Title: "喜剧短剧", Category: "喜剧"},
 		}
 
 		// 设置缓存未命中
@@ -224,7 +56,7 @@ func TestDramaService_GetDramas(t *testing.T) {
 		mockDramaRepo.On("GetByGenre", "喜剧", 0, 20).Return(dramas, int64(1), nil)
 		
 		// 设置缓存写入
-		mockCacheService.On("SetJSON", mock.AnythingOfType("string"), mock.Anything, mock.AnythingOfType("time.Duration")).Return(nil)
+		mockCacheService.On("SetJSONWithTags", mock.AnythingOfType("string"), mock.Anything, mock.AnythingOfType("time.Duration"), mock.Anything).Return(nil)
 
 		result, err := dramaService.GetDramas(1, 20, "喜剧")
 
@@ -243,23 +75,23 @@ func TestDramaService_GetDramaByID(t *testing.T) {
 	mockEpisodeRepo := new(MockEpisodeRepository)
 	mockCacheService := new(MockCacheService)
 	
-	dramaService := NewDramaService(mockDramaRepo, mockEpisodeRepo, mockCacheService)
+	dramaService := NewDramaService(mockDramaRepo, mockEpisodeRepo, mockCacheService, nil, nil)
 
 	t.Run("成功获取短剧详情", func(t *testing.T) {
 		drama := &models.Drama{
 			ID:    1,
 			Title: "测试短剧",
-			Genre: "喜剧",
+			Category: "喜剧",
 		}
 
 		// 设置缓存未命中
 		mockCacheService.On("GetJSON", "drama:1", mock.Anything).Return(assert.AnError)
 		
 		// 设置仓库返回数据
-		mockDramaRepo.On("GetByID", uint(1)).Return(drama, nil)
+		mockDramaRepo.On("GetPublishedByID", uint(1)).Return(drama, nil)
 		
 		// 设置缓存写入
-		mockCacheService.On("SetJSON", "drama:1", drama, mock.AnythingOfType("time.Duration")).Return(nil)
+		mockCacheService.On("SetJSONWithTags", "drama:1", mock.AnythingOfType("service.cacheEnvelope"), mock.AnythingOfType("time.Duration"), []string{TagDrama(1)}).Return(nil)
 
 		result, err := dramaService.GetDramaByID(1)
 
@@ -277,7 +109,7 @@ func TestDramaService_GetDramaByID(t *testing.T) {
 		mockCacheService.On("GetJSON", "drama:999", mock.Anything).Return(assert.AnError)
 		
 		// 设置仓库返回错误
-		mockDramaRepo.On("GetByID", uint(999)).Return((*models.Drama)(nil), assert.AnError)
+		mockDramaRepo.On("GetPublishedByID", uint(999)).Return((*models.Drama)(nil), assert.AnError)
 
 		result, err := dramaService.GetDramaByID(999)
 
@@ -287,6 +119,22 @@ func TestDramaService_GetDramaByID(t *testing.T) {
 		mockDramaRepo.AssertExpectations(t)
 		mockCacheService.AssertExpectations(t)
 	})
+
+	t.Run("短剧不存在时缓存空结果", func(t *testing.T) {
+		mockCacheService.On("GetJSON", "drama:998", mock.Anything).Return(assert.AnError)
+		mockDramaRepo.On("GetPublishedByID", uint(998)).Return((*models.Drama)(nil), nil)
+		mockCacheService.On("SetJSONWithTags", "drama:998", mock.MatchedBy(func(envelope cacheEnvelope) bool {
+			return envelope.NotFound
+		}), mock.AnythingOfType("time.Duration"), []string{TagDrama(998)}).Return(nil)
+
+		result, err := dramaService.GetDramaByID(998)
+
+		assert.EqualError(t, err, "短剧不存在")
+		assert.Nil(t, result)
+
+		mockDramaRepo.AssertExpectations(t)
+		mockCacheService.AssertExpectations(t)
+	})
 }
 
 func TestDramaService_IncrementDramaViewCount(t *testing.T) {
@@ -294,21 +142,66 @@ func TestDramaService_IncrementDramaViewCount(t *testing.T) {
 	mockEpisodeRepo := new(MockEpisodeRepository)
 	mockCacheService := new(MockCacheService)
 	
-	dramaService := NewDramaService(mockDramaRepo, mockEpisodeRepo, mockCacheService)
+	dramaService := NewDramaService(mockDramaRepo, mockEpisodeRepo, mockCacheService, nil, nil)
 
 	t.Run("成功增加观看次数", func(t *testing.T) {
 		// 设置仓库更新观看次数
 		mockDramaRepo.On("IncrementViewCount", uint(1)).Return(nil)
-		
-		// 设置缓存清除
-		mockCacheService.On("Delete", "drama:1").Return(nil)
-		mockCacheService.On("Delete", "drama_with_episodes:1").Return(nil)
 
 		err := dramaService.IncrementDramaViewCount(1)
 
 		assert.NoError(t, err)
 
 		mockDramaRepo.AssertExpectations(t)
-		mockCacheService.AssertExpectations(t)
+		// 观看次数通过缓存过期更新，不清除热门短剧的缓存
+		mockCacheService.AssertNotCalled(t, "InvalidateTag", mock.Anything)
+	})
+
+	t.Run("增加剧集观看次数不清除缓存", func(t *testing.T) {
+		mockEpisodeRepo.On("IncrementViewCount", uint(1)).Return(nil)
+
+		err := dramaService.IncrementEpisodeViewCount(1)
+
+		assert.NoError(t, err)
+
+		mockEpisodeRepo.AssertExpectations(t)
+		mockCacheService.AssertNotCalled(t, "InvalidateTag", mock.Anything)
+	})
+}
+func TestDramaService_ProtectedEpisode(t *testing.T) {
+	mockDramaRepo := new(MockDramaRepository)
+	mockEpisodeRepo := new(MockEpisodeRepository)
+
+	// 未启用播放服务
+	dramaService := NewDramaService(mockDramaRepo, mockEpisodeRepo, NewMemoryCacheService(100), nil, nil)
+
+	drama := &models.Drama{ID: 1, Title: "测试短剧", Status: models.StatusPublished}
+	episodes := []models.Episode{
+		{ID: 1, DramaID: 1, EpisodeNum: 1, Status: models.StatusPublished, VideoURL: "videos/1.mp4", Protected: true},
+		{ID: 2, DramaID: 1, EpisodeNum: 2, Status: models.StatusPublished, VideoURL: "videos/2.mp4"},
+	}
+
+	t.Run("列表中不返回受保护剧集的原始视频地址", func(t *testing.T) {
+		mockDramaRepo.On("GetPublishedByID", uint(1)).Return(drama, nil)
+		mockEpisodeRepo.On("GetPublishedByDramaIDPaginated", uint(1), 0, 20).Return(episodes, int64(2), nil)
+
+		result, err := dramaService.GetEpisodesByDramaID(1, 1, 20)
+
+		assert.NoError(t, err)
+		assert.Len(t, result.Episodes, 2)
+		assert.Empty(t, result.Episodes[0].VideoURL)
+		assert.Equal(t, "videos/2.mp4", result.Episodes[1].VideoURL)
+		assert.Equal(t, "videos/1.mp4", episodes[0].VideoURL, "不修改缓存中的数据")
 	})
-}
\ No newline at end of file
+
+	t.Run("受保护的剧集没有原始视频播放源", func(t *testing.T) {
+		episode := episodes[0]
+		episode.Drama = *drama
+		mockEpisodeRepo.On("GetPublishedByIDWithDrama", uint(1)).Return(&episode, nil)
+
+		detail, err := dramaService.GetEpisodeByID(1, PlaybackViewer{})
+
+		assert.NoError(t, err)
+		assert.Empty(t, detail.Sources)
+	})
+}
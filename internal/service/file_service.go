@@ -1,123 +1,501 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"mime/multipart"
-	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/media"
+	"gin-mysql-api/pkg/metrics"
+	"gin-mysql-api/pkg/storage"
 )
 
+var (
+	// ErrUnsupportedContent 文件内容不在上传类型允许的范围内，或与扩展名不符
+	ErrUnsupportedContent = errors.New("不支持的文件内容")
+	// ErrFileForbidden 普通用户删除不是自己上传的文件
+	ErrFileForbidden = errors.New("无权删除该文件")
+	// ErrFileInUse 文件仍被短剧、剧集或用户引用
+	ErrFileInUse = errors.New("文件正在使用中")
+	// ErrInvalidVideo MP4/MOV 文件损坏或不完整，无法解析时长等元数据
+	ErrInvalidVideo = errors.New("视频文件已损坏")
+	// ErrFileNotFound 文件不存在或路径无效
+	ErrFileNotFound = errors.New("文件不存在")
+)
+
+// UploadOwner 文件的上传者，用户与管理员的 ID 相互独立，需要同时比较角色
+type UploadOwner struct {
+	ID   uint
+	Role string
+}
+
+// IsAdmin 是否为管理员，管理员可以删除任何文件
+func (o UploadOwner) IsAdmin() bool {
+	return o.Role == "admin"
+}
+
 // FileService 文件服务接口
 type FileService interface {
-	UploadFile(file multipart.File, header *multipart.FileHeader, uploadType string) (*models.FileUploadResponse, error)
-	DeleteFile(filePath string) error
+	// WithContext 返回绑定请求上下文的服务，日志会带上请求的链路信息
+	WithContext(ctx context.Context) FileService
+	UploadFile(owner UploadOwner, file multipart.File, header *multipart.FileHeader, uploadType string) (*models.FileUploadResponse, error)
+	// SaveFile 校验文件内容后流式写入存储并登记上传者，供普通上传与分片合并共用，不校验大小上限
+	// MP4/MOV 在写入的同时解析时长、分辨率、编码与码率，文件损坏或编码不受支持时拒绝上传
+	// 同一上传者以同一用途重复上传内容相同的文件时复用已有文件，响应中 Deduplicated 为 true
+	SaveFile(owner UploadOwner, r io.Reader, filename string, size int64, uploadType string) (*models.FileUploadResponse, error)
+	DeleteFile(owner UploadOwner, filePath string) error
 	GetFileURL(filePath string) string
+	// OpenFile 打开文件用于按范围读取，返回的元数据中 ContentType 根据扩展名确定；分片上传的临时分片视为不存在
+	OpenFile(filePath string) (io.ReadSeekCloser, *storage.ObjectInfo, error)
 	ValidateFileType(filename string, allowedTypes []string) bool
 	ValidateFileSize(size int64, maxSize int64) bool
+	// ValidateContent 根据文件头识别内容类型，校验是否为上传类型允许的类型且与扩展名一致
+	ValidateContent(filename, uploadType string, head []byte) (string, error)
+}
+
+// FileServiceConfig 文件服务配置
+type FileServiceConfig struct {
+	// BaseURL 服务对外访问地址，本地存储的文件通过 <BaseURL>/uploads/<key> 访问
+	BaseURL string
+	// PublicURL CDN 等公开访问地址，配置后文件 URL 为 <PublicURL>/<key>
+	PublicURL string
+	// PresignExpiry 未配置 PublicURL 且存储支持预签名时，文件 URL 的有效期
+	PresignExpiry time.Duration
+	// MaxSize 上传文件大小上限（字节）
+	MaxSize      int64
+	AllowedTypes []string
+	// ContentTypes 各上传类型允许的内容类型，未设置的上传类型使用默认值
+	ContentTypes map[string][]string
+	// VideoCodecs、AudioCodecs MP4/MOV 允许的视频与音频编码，未设置时使用默认值
+	VideoCodecs []string
+	AudioCodecs []string
+}
+
+// NewFileServiceConfig 根据应用配置生成文件服务配置
+func NewFileServiceConfig(cfg *config.Config) FileServiceConfig {
+	return FileServiceConfig{
+		BaseURL:       cfg.Server.GetBaseURL(),
+		PublicURL:     cfg.Storage.GetPublicURL(),
+		PresignExpiry: cfg.Storage.GetPresignExpiry(),
+		MaxSize:       int64(cfg.Upload.MaxSize) * 1024 * 1024, // 转换为字节
+		AllowedTypes:  cfg.Upload.AllowedTypes,
+		ContentTypes:  resolveContentTypes(&cfg.Upload),
+		VideoCodecs:   cfg.Upload.Video.GetVideoCodecs(),
+		AudioCodecs:   cfg.Upload.Video.GetAudioCodecs(),
+	}
+}
+
+// resolveContentTypes 获取所有上传类型允许的内容类型
+func resolveContentTypes(upload *config.UploadConfig) map[string][]string {
+	contentTypes := make(map[string][]string, len(config.UploadTypes))
+	for _, uploadType := range config.UploadTypes {
+		contentTypes[uploadType] = upload.GetContentTypes(uploadType)
+	}
+	return contentTypes
 }
 
 // fileService 文件服务实现
 type fileService struct {
-	uploadPath string
-	baseURL    string
-	maxSize    int64
-	allowedTypes []string
+	store         storage.Storage
+	assets        repository.MediaAssetRepository
+	images        ImageService
+	baseURL       string
+	publicURL     string
+	presignExpiry time.Duration
+	maxSize       int64
+	allowedTypes  []string
+	contentTypes  map[string][]string
+	videoCodecs   []string
+	audioCodecs   []string
+	logger        *slog.Logger
+	ctx           context.Context
 }
 
-// NewFileService 创建新的文件服务
-func NewFileService(uploadPath, baseURL string, maxSize int64, allowedTypes []string) FileService {
+// NewFileService 创建新的文件服务，文件通过 store 持久化、上传者登记在 assets 中，log 为 nil 时使用全局默认 Logger
+// images 为 nil 时不生成图片规格图
+func NewFileService(store storage.Storage, assets repository.MediaAssetRepository, images ImageService, conf FileServiceConfig, log *slog.Logger) FileService {
+	contentTypes := resolveContentTypes(&config.UploadConfig{ContentTypes: conf.ContentTypes})
+	codecs := config.VideoConfig{VideoCodecs: conf.VideoCodecs, AudioCodecs: conf.AudioCodecs}
 	return &fileService{
-		uploadPath:   uploadPath,
-		baseURL:      baseURL,
-		maxSize:      maxSize,
-		allowedTypes: allowedTypes,
+		store:         store,
+		assets:        assets,
+		images:        images,
+		baseURL:       strings.TrimSuffix(conf.BaseURL, "/"),
+		publicURL:     strings.TrimSuffix(conf.PublicURL, "/"),
+		presignExpiry: conf.PresignExpiry,
+		maxSize:       conf.MaxSize,
+		allowedTypes:  conf.AllowedTypes,
+		contentTypes:  contentTypes,
+		videoCodecs:   codecs.GetVideoCodecs(),
+		audioCodecs:   codecs.GetAudioCodecs(),
+		logger:        logger.OrDefault(log),
+		ctx:           context.Background(),
 	}
 }
 
+// WithContext 返回绑定请求上下文的文件服务
+func (s *fileService) WithContext(ctx context.Context) FileService {
+	scoped := *s
+	scoped.ctx = ctx
+	scoped.assets = s.assets.WithContext(ctx)
+	if s.images != nil {
+		scoped.images = s.images.WithContext(ctx)
+	}
+	return &scoped
+}
+
 // UploadFile 上传文件
-func (s *fileService) UploadFile(file multipart.File, header *multipart.FileHeader, uploadType string) (*models.FileUploadResponse, error) {
+func (s *fileService) UploadFile(owner UploadOwner, file multipart.File, header *multipart.FileHeader, uploadType string) (resp *models.FileUploadResponse, err error) {
+	// 根据上传类型确定子目录
+	subDir := uploadSubDir(uploadType)
+	defer func() {
+		metrics.RecordUpload(subDir, err)
+		if err != nil {
+			s.logger.WarnContext(s.ctx, "文件上传失败",
+				slog.String("type", subDir),
+				slog.String("filename", header.Filename),
+				slog.Int64("size", header.Size),
+				slog.String("error", err.Error()),
+			)
+		}
+	}()
+
 	// 验证文件大小
 	if !s.ValidateFileSize(header.Size, s.maxSize) {
 		return nil, fmt.Errorf("文件大小超过限制，最大允许 %d MB", s.maxSize/(1024*1024))
 	}
 
+	return s.SaveFile(owner, file, header.Filename, header.Size, uploadType)
+}
+
+// SaveFile 校验扩展名与文件内容，生成随机文件名写入存储并登记上传者
+func (s *fileService) SaveFile(owner UploadOwner, r io.Reader, filename string, size int64, uploadType string) (*models.FileUploadResponse, error) {
+	uploadType = normalizeUploadType(uploadType)
+
 	// 验证文件类型
-	if !s.ValidateFileType(header.Filename, s.allowedTypes) {
+	if !s.ValidateFileType(filename, s.allowedTypes) {
 		return nil, fmt.Errorf("不支持的文件类型，允许的类型: %s", strings.Join(s.allowedTypes, ", "))
 	}
 
-	// 生成唯一文件名
-	ext := filepath.Ext(header.Filename)
-	filename := fmt.Sprintf("%d_%s%s", time.Now().Unix(), generateRandomString(8), ext)
+	// 根据文件头校验内容，不信任扩展名与客户端声明的类型
+	head, body, err := media.Peek(r)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	contentType, err := s.ValidateContent(filename, uploadType, head)
+	if err != nil {
+		return nil, err
+	}
+
+	// 生成唯一文件名并写入存储，写入的同时计算摘要、截取文件头用于解析尺寸、解析视频元数据
+	key, storedName := newObjectKey(uploadSubDir(uploadType), filename)
+	counter := &hashCounter{hash: sha256.New()}
+	probe := &media.HeadBuffer{Limit: media.ProbeLen}
+	writers := []io.Writer{counter, probe}
+	var videoProbe *media.MP4Probe
+	if contentType == media.TypeMP4 || contentType == media.TypeQuickTime {
+		videoProbe = &media.MP4Probe{}
+		writers = append(writers, videoProbe)
+	}
+	if err := s.store.Put(s.ctx, key, io.TeeReader(body, io.MultiWriter(writers...)), size, contentType); err != nil {
+		return nil, fmt.Errorf("保存文件失败: %w", err)
+	}
+	if counter.n != size {
+		s.deleteObject(key)
+		return nil, fmt.Errorf("文件大小与声明的不一致: 声明 %d 字节，实际 %d 字节", size, counter.n)
+	}
+	var video *media.VideoInfo
+	if videoProbe != nil {
+		if video, err = s.checkVideo(videoProbe); err != nil {
+			s.deleteObject(key)
+			return nil, err
+		}
+	}
+	checksum := counter.sum()
+
+	// 内容相同的文件只保留一份
+	duplicate, err := s.assets.FindDuplicate(owner.ID, owner.Role, uploadType, checksum)
+	if err != nil {
+		s.deleteObject(key)
+		return nil, fmt.Errorf("查询文件失败: %w", err)
+	}
+	if duplicate != nil {
+		s.deleteObject(key)
+		return s.reuseAsset(duplicate)
+	}
+
+	// 新文件在被引用之前视为未引用，长期未被使用时由垃圾回收删除
+	now := time.Now()
+	asset := &models.MediaAsset{
+		OwnerID:           owner.ID,
+		OwnerRole:         owner.Role,
+		Type:              uploadType,
+		StorageKey:        key,
+		Filename:          originalFilename(filename),
+		Size:              size,
+		ContentType:       contentType,
+		Checksum:          checksum,
+		UnreferencedSince: &now,
+	}
+	if width, height, ok := media.ImageSize(probe.Bytes()); ok {
+		asset.Width, asset.Height = width, height
+	}
+	if video != nil {
+		asset.Width, asset.Height = video.Width, video.Height
+		asset.Duration = video.Seconds()
+		asset.VideoCodec, asset.AudioCodec = video.VideoCodec, video.AudioCodec
+		asset.Bitrate = video.Bitrate
+	}
+	if err := s.assets.Create(asset); err != nil {
+		s.deleteObject(key)
+		return nil, fmt.Errorf("登记文件失败: %w", err)
+	}
+	if s.images != nil {
+		s.images.Schedule(asset)
+	}
+
+	s.logger.InfoContext(s.ctx, "文件上传成功",
+		slog.String("type", uploadType),
+		slog.String("path", key),
+		slog.String("content_type", contentType),
+		slog.Int64("size", size),
+	)
+
+	return &models.FileUploadResponse{
+		URL:      s.GetFileURL(key),
+		Path:     key,
+		Filename: storedName,
+		Size:     size,
+		Variants: s.variantURLs(asset),
+	}, nil
+}
+
+// checkVideo 获取解析出的视频元数据，校验编码是否在允许的范围内
+func (s *fileService) checkVideo(probe *media.MP4Probe) (*media.VideoInfo, error) {
+	video, err := probe.Info()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidVideo, err)
+	}
+	if !slices.Contains(s.videoCodecs, video.VideoCodec) {
+		return nil, fmt.Errorf("%w: 视频编码 %s 不受支持，允许的编码: %s", ErrUnsupportedContent, video.VideoCodec, strings.Join(s.videoCodecs, ", "))
+	}
+	if video.AudioCodec != "" && !slices.Contains(s.audioCodecs, video.AudioCodec) {
+		return nil, fmt.Errorf("%w: 音频编码 %s 不受支持，允许的编码: %s", ErrUnsupportedContent, video.AudioCodec, strings.Join(s.audioCodecs, ", "))
+	}
+	return video, nil
+}
+
+// reuseAsset 复用内容相同的已有文件，重新计算未引用时间避免刚上传就被垃圾回收
+func (s *fileService) reuseAsset(asset *models.MediaAsset) (*models.FileUploadResponse, error) {
+	if asset.UnreferencedSince != nil {
+		now := time.Now()
+		asset.UnreferencedSince = &now
+		if err := s.assets.Update(asset); err != nil {
+			return nil, fmt.Errorf("更新文件记录失败: %w", err)
+		}
+	}
+
+	s.logger.InfoContext(s.ctx, "复用内容相同的文件",
+		slog.String("type", asset.Type),
+		slog.String("path", asset.StorageKey),
+	)
+
+	return &models.FileUploadResponse{
+		URL:          s.GetFileURL(asset.StorageKey),
+		Path:         asset.StorageKey,
+		Filename:     path.Base(asset.StorageKey),
+		Size:         asset.Size,
+		Variants:     s.variantURLs(asset),
+		Deduplicated: true,
+	}, nil
+}
 
-	// 根据上传类型创建子目录
-	var subDir string
+// variantURLs 获取图片各规格的访问地址，地址指向规格图接口，规格图尚未生成时由接口同步生成
+func (s *fileService) variantURLs(asset *models.MediaAsset) map[string]string {
+	if s.images == nil {
+		return nil
+	}
+	variants := s.images.Variants(asset.Type, asset.ContentType)
+	if len(variants) == 0 {
+		return nil
+	}
+	urls := make(map[string]string, len(variants))
+	for _, variant := range variants {
+		urls[variant.Name] = fmt.Sprintf("%s/api/media/variants/%s/%s", s.baseURL, variant.Name, asset.StorageKey)
+	}
+	return urls
+}
+
+// ValidateContent 根据文件头识别内容类型，返回识别出的类型
+func (s *fileService) ValidateContent(filename, uploadType string, head []byte) (string, error) {
+	contentType := media.DetectContentType(head)
+	allowed := s.contentTypes[normalizeUploadType(uploadType)]
+	if !slices.Contains(allowed, contentType) {
+		return "", fmt.Errorf("%w: 文件内容为 %s，允许的类型: %s", ErrUnsupportedContent, contentType, strings.Join(allowed, ", "))
+	}
+	if media.TypeByExtension(filepath.Ext(filename)) != contentType {
+		return "", fmt.Errorf("%w: 文件扩展名与内容 %s 不符", ErrUnsupportedContent, contentType)
+	}
+	return contentType, nil
+}
+
+// newObjectKey 生成唯一的对象键，返回对象键与生成的文件名
+func newObjectKey(subDir, originalName string) (string, string) {
+	ext := strings.ToLower(filepath.Ext(originalName))
+	filename := fmt.Sprintf("%d_%s%s", time.Now().Unix(), generateRandomString(16), ext)
+	return path.Join(subDir, filename), filename
+}
+
+// originalFilename 获取去掉目录的原始文件名，最多保留 255 个字符
+func originalFilename(filename string) string {
+	name := path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
+	return name
+}
+
+// normalizeUploadType 未知的上传类型按 others 处理
+func normalizeUploadType(uploadType string) string {
+	if slices.Contains(config.UploadTypes, uploadType) {
+		return uploadType
+	}
+	return "others"
+}
+
+// uploadSubDir 根据上传类型获取存储子目录
+func uploadSubDir(uploadType string) string {
 	switch uploadType {
 	case "avatar":
-		subDir = "avatars"
+		return "avatars"
 	case "cover":
-		subDir = "covers"
+		return "covers"
 	case "video":
-		subDir = "videos"
+		return "videos"
 	case "thumbnail":
-		subDir = "thumbnails"
+		return "thumbnails"
 	default:
-		subDir = "others"
+		return "others"
+	}
+}
+
+// DeleteFile 删除文件，文件不存在时认为删除成功
+// 普通用户只能删除自己上传的文件，管理员可以删除任何文件；仍被引用的文件不能删除
+func (s *fileService) DeleteFile(owner UploadOwner, filePath string) error {
+	key, err := storage.CleanKey(filePath)
+	if err != nil || strings.HasPrefix(key, uploadPartPrefix+"/") {
+		return fmt.Errorf("无效的文件路径: %s", filePath)
 	}
 
-	// 创建完整的文件路径
-	fullDir := filepath.Join(s.uploadPath, subDir)
-	err := os.MkdirAll(fullDir, 0755)
+	asset, err := s.assets.GetByKey(key)
 	if err != nil {
-		return nil, fmt.Errorf("创建目录失败: %w", err)
+		return fmt.Errorf("查询文件失败: %w", err)
+	}
+	if !owner.IsAdmin() && (asset == nil || !asset.IsOwnedBy(owner.ID, owner.Role)) {
+		return ErrFileForbidden
+	}
+	if asset != nil {
+		referenced, err := s.assets.IsReferenced(asset.ID)
+		if err != nil {
+			return fmt.Errorf("查询文件引用失败: %w", err)
+		}
+		if referenced {
+			return ErrFileInUse
+		}
 	}
 
-	filePath := filepath.Join(fullDir, filename)
+	if err := s.store.Delete(s.ctx, key); err != nil {
+		s.logger.ErrorContext(s.ctx, "删除文件失败", slog.String("path", key), slog.String("error", err.Error()))
+		return fmt.Errorf("删除文件失败: %w", err)
+	}
+	if asset != nil {
+		if s.images != nil {
+			s.images.DeleteVariants(asset)
+		}
+		if err := s.assets.Delete(asset.ID); err != nil {
+			return fmt.Errorf("删除文件记录失败: %w", err)
+		}
+	}
 
-	// 创建目标文件
-	dst, err := os.Create(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("创建文件失败: %w", err)
+	s.logger.InfoContext(s.ctx, "文件已删除", slog.String("path", key))
+	return nil
+}
+
+// deleteObject 删除存储中的对象，失败时只记录日志
+func (s *fileService) deleteObject(key string) {
+	if err := s.store.Delete(s.ctx, key); err != nil {
+		s.logger.WarnContext(s.ctx, "删除存储对象失败", slog.String("path", key), slog.String("error", err.Error()))
 	}
-	defer dst.Close()
+}
 
-	// 复制文件内容
-	_, err = io.Copy(dst, file)
+// GetFileURL 获取文件 URL
+// 配置了 CDN 地址时直接拼接；否则存储支持预签名时返回限时有效的签名 URL；本地存储返回服务自身的 /uploads 地址
+func (s *fileService) GetFileURL(filePath string) string {
+	key, err := storage.CleanKey(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("保存文件失败: %w", err)
+		return ""
 	}
 
-	// 生成文件 URL
-	relativePath := filepath.Join(subDir, filename)
-	fileURL := s.GetFileURL(relativePath)
+	if s.publicURL != "" {
+		return s.publicURL + "/" + key
+	}
 
-	return &models.FileUploadResponse{
-		URL:      fileURL,
-		Filename: filename,
-		Size:     header.Size,
-	}, nil
+	signed, err := s.store.PresignGet(s.ctx, key, s.presignExpiry)
+	if err == nil {
+		return signed
+	}
+	if !errors.Is(err, storage.ErrPresignNotSupported) {
+		s.logger.WarnContext(s.ctx, "生成预签名 URL 失败", slog.String("path", key), slog.String("error", err.Error()))
+	}
+	return fmt.Sprintf("%s/uploads/%s", s.baseURL, key)
 }
 
-// DeleteFile 删除文件
-func (s *fileService) DeleteFile(filePath string) error {
-	fullPath := filepath.Join(s.uploadPath, filePath)
-	
-	// 检查文件是否存在
-	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-		return nil // 文件不存在，认为删除成功
+// OpenFile 打开存储中的文件，读取时按 storage.DefaultChunkSize 分段从存储获取
+func (s *fileService) OpenFile(filePath string) (io.ReadSeekCloser, *storage.ObjectInfo, error) {
+	key, err := storage.CleanKey(filePath)
+	if err != nil || strings.HasPrefix(key, uploadPartPrefix+"/") {
+		return nil, nil, ErrFileNotFound
 	}
 
-	return os.Remove(fullPath)
+	file, info, err := storage.Open(s.ctx, s.store, key, storage.DefaultChunkSize)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, ErrFileNotFound
+		}
+		return nil, nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	info.ContentType = fileContentType(key, info.ContentType)
+	return file, info, nil
 }
 
-// GetFileURL 获取文件 URL
-func (s *fileService) GetFileURL(filePath string) string {
-	return fmt.Sprintf("%s/uploads/%s", s.baseURL, strings.ReplaceAll(filePath, "\\", "/"))
+// fileContentType 获取文件的内容类型
+// 上传时已校验扩展名与内容一致，优先按扩展名确定；HLS 文件使用播放器要求的类型，其余使用存储记录的类型
+func fileContentType(key, stored string) string {
+	if contentType := media.TypeByExtension(path.Ext(key)); contentType != "" {
+		return contentType
+	}
+	switch path.Ext(key) {
+	case ".m3u8", ".ts":
+		return hlsContentType(key)
+	}
+	if stored != "" {
+		return stored
+	}
+	return "application/octet-stream"
 }
 
 // ValidateFileType 验证文件类型
@@ -141,12 +519,23 @@ func (s *fileService) ValidateFileSize(size int64, maxSize int64) bool {
 	return size <= maxSize
 }
 
-// generateRandomString 生成随机字符串
+// generateRandomString 使用 crypto/rand 生成随机字符串，拒绝采样保证每个字符等概率
 func generateRandomString(length int) string {
 	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
+	const maxByte = 256 - 256%len(charset)
+
+	b := make([]byte, 0, length)
+	buf := make([]byte, length*2)
+	for len(b) < length {
+		if _, err := rand.Read(buf); err != nil {
+			// 系统随机数源不可用时无法安全地生成文件名
+			panic(fmt.Sprintf("crypto/rand: %v", err))
+		}
+		for _, c := range buf {
+			if int(c) < maxByte && len(b) < length {
+				b = append(b, charset[int(c)%len(charset)])
+			}
+		}
 	}
 	return string(b)
-}
\ No newline at end of file
+}
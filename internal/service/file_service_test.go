@@ -0,0 +1,387 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/internal/testutil"
+	"gin-mysql-api/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// presignStorage 支持预签名的存储替身，其余操作使用本地存储
+type presignStorage struct {
+	storage.Storage
+}
+
+func (s presignStorage) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "https://bucket.example.com/" + key + "?X-Amz-Expires=" + expires.String(), nil
+}
+
+// 测试用的文件内容，文件头与真实格式一致
+const jpegContent = "\xFF\xD8\xFF\xE0\x00\x10JFIF\x00fake image data"
+
+// mp4Content 只有元数据的 H.264 + AAC 视频，时长 12.5 秒
+var mp4Content = string(testutil.NewMP4(testutil.MP4Options{AudioCodec: "mp4a"}))
+
+// newTestMediaRepo 基于测试数据库创建媒体文件仓库
+func newTestMediaRepo(t *testing.T) repository.MediaAssetRepository {
+	t.Helper()
+	db := testutil.SetupTestDB()
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return repository.NewMediaAssetRepository(db)
+}
+
+// newMultipartFile 构造上传的 multipart 文件
+func newMultipartFile(t *testing.T, filename, content string) (multipart.File, *multipart.FileHeader) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	part.Write([]byte(content))
+	require.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(body, writer.Boundary()).ReadForm(1024)
+	require.NoError(t, err)
+	header := form.File["file"][0]
+	file, err := header.Open()
+	require.NoError(t, err)
+	t.Cleanup(func() { file.Close() })
+	return file, header
+}
+
+func TestFileService_UploadFile(t *testing.T) {
+	conf := FileServiceConfig{
+		BaseURL:      "http://localhost:1800/",
+		MaxSize:      1024,
+		AllowedTypes: []string{"jpg", "png", "mp4"},
+	}
+	user := UploadOwner{ID: 1, Role: "user"}
+
+	t.Run("上传到存储并登记上传者", func(t *testing.T) {
+		store := storage.NewLocal(t.TempDir())
+		assets := newTestMediaRepo(t)
+		service := NewFileService(store, assets, nil, conf, nil)
+		file, header := newMultipartFile(t, "cover.JPG", jpegContent)
+
+		resp, err := service.UploadFile(user, file, header, "cover")
+		require.NoError(t, err)
+		assert.Regexp(t, `^covers/\d+_[A-Za-z0-9]{16}\.jpg$`, resp.Path)
+		assert.Equal(t, "http://localhost:1800/uploads/"+resp.Path, resp.URL)
+		assert.Equal(t, int64(len(jpegContent)), resp.Size)
+
+		reader, info, err := store.Get(context.Background(), resp.Path)
+		require.NoError(t, err)
+		defer reader.Close()
+		data, _ := io.ReadAll(reader)
+		assert.Equal(t, jpegContent, string(data))
+		assert.Equal(t, "image/jpeg", info.ContentType)
+
+		asset, err := assets.GetByKey(resp.Path)
+		require.NoError(t, err)
+		require.NotNil(t, asset)
+		assert.True(t, asset.IsOwnedBy(1, "user"))
+		assert.Equal(t, "cover", asset.Type)
+		assert.Equal(t, "cover.JPG", asset.Filename)
+		assert.Equal(t, "image/jpeg", asset.ContentType)
+		assert.Equal(t, sha256Hex([]byte(jpegContent)), asset.Checksum)
+		assert.NotNil(t, asset.UnreferencedSince)
+	})
+
+	t.Run("内容相同的文件只保存一份", func(t *testing.T) {
+		store := storage.NewLocal(t.TempDir())
+		assets := newTestMediaRepo(t)
+		service := NewFileService(store, assets, nil, conf, nil)
+
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 40, 30))))
+		content := buf.String()
+
+		file, header := newMultipartFile(t, "a.png", content)
+		first, err := service.UploadFile(user, file, header, "cover")
+		require.NoError(t, err)
+		assert.False(t, first.Deduplicated)
+
+		asset, err := assets.GetByKey(first.Path)
+		require.NoError(t, err)
+		assert.Equal(t, 40, asset.Width)
+		assert.Equal(t, 30, asset.Height)
+
+		file, header = newMultipartFile(t, "b.png", content)
+		second, err := service.UploadFile(user, file, header, "cover")
+		require.NoError(t, err)
+		assert.True(t, second.Deduplicated)
+		assert.Equal(t, first.Path, second.Path)
+		assert.Equal(t, first.URL, second.URL)
+
+		// 其他用户或其他用途上传时单独保存
+		file, header = newMultipartFile(t, "c.png", content)
+		other, err := service.UploadFile(UploadOwner{ID: 2, Role: "user"}, file, header, "cover")
+		require.NoError(t, err)
+		assert.NotEqual(t, first.Path, other.Path)
+		file, header = newMultipartFile(t, "d.png", content)
+		avatar, err := service.UploadFile(user, file, header, "avatar")
+		require.NoError(t, err)
+		assert.False(t, avatar.Deduplicated)
+	})
+
+	t.Run("文件过大", func(t *testing.T) {
+		service := NewFileService(storage.NewLocal(t.TempDir()), newTestMediaRepo(t), nil, FileServiceConfig{MaxSize: 4, AllowedTypes: []string{"jpg"}}, nil)
+		file, header := newMultipartFile(t, "cover.jpg", jpegContent)
+
+		_, err := service.UploadFile(user, file, header, "cover")
+		assert.ErrorContains(t, err, "文件大小超过限制")
+	})
+
+	t.Run("不支持的文件类型", func(t *testing.T) {
+		service := NewFileService(storage.NewLocal(t.TempDir()), newTestMediaRepo(t), nil, conf, nil)
+		file, header := newMultipartFile(t, "script.sh", "echo")
+
+		_, err := service.UploadFile(user, file, header, "others")
+		assert.ErrorContains(t, err, "不支持的文件类型")
+	})
+
+	t.Run("按文件头校验内容", func(t *testing.T) {
+		store := storage.NewLocal(t.TempDir())
+		service := NewFileService(store, newTestMediaRepo(t), nil, conf, nil)
+
+		// 改名为 .mp4 的脚本
+		file, header := newMultipartFile(t, "episode.mp4", "#!/bin/sh\nrm -rf /\n")
+		_, err := service.UploadFile(user, file, header, "video")
+		assert.ErrorIs(t, err, ErrUnsupportedContent)
+
+		// 头像只允许图片
+		file, header = newMultipartFile(t, "avatar.mp4", mp4Content)
+		_, err = service.UploadFile(user, file, header, "avatar")
+		assert.ErrorIs(t, err, ErrUnsupportedContent)
+
+		// 扩展名与内容不符
+		file, header = newMultipartFile(t, "avatar.png", jpegContent)
+		_, err = service.UploadFile(user, file, header, "avatar")
+		assert.ErrorIs(t, err, ErrUnsupportedContent)
+
+		file, header = newMultipartFile(t, "episode.mp4", mp4Content)
+		resp, err := service.UploadFile(user, file, header, "video")
+		require.NoError(t, err)
+		info, err := store.Stat(context.Background(), resp.Path)
+		require.NoError(t, err)
+		assert.Equal(t, "video/mp4", info.ContentType)
+	})
+
+	t.Run("解析视频元数据", func(t *testing.T) {
+		root := t.TempDir()
+		store := storage.NewLocal(root)
+		assets := newTestMediaRepo(t)
+		service := NewFileService(store, assets, nil, conf, nil)
+
+		file, header := newMultipartFile(t, "episode.mp4", mp4Content)
+		resp, err := service.UploadFile(user, file, header, "video")
+		require.NoError(t, err)
+		asset, err := assets.GetByKey(resp.Path)
+		require.NoError(t, err)
+		assert.Equal(t, 13, asset.Duration)
+		assert.Equal(t, 1280, asset.Width)
+		assert.Equal(t, 720, asset.Height)
+		assert.Equal(t, "avc1", asset.VideoCodec)
+		assert.Equal(t, "mp4a", asset.AudioCodec)
+		assert.Equal(t, int64(len(mp4Content))*8*1000/12500, asset.Bitrate)
+
+		// 文件损坏时拒绝上传并删除已写入的对象
+		file, header = newMultipartFile(t, "broken.mp4", mp4Content[:len(mp4Content)-10])
+		_, err = service.UploadFile(user, file, header, "video")
+		assert.ErrorIs(t, err, ErrInvalidVideo)
+
+		// 播放器不支持的编码
+		hevc := string(testutil.NewMP4(testutil.MP4Options{VideoCodec: "hvc1"}))
+		file, header = newMultipartFile(t, "hevc.mp4", hevc)
+		_, err = service.UploadFile(user, file, header, "video")
+		assert.ErrorIs(t, err, ErrUnsupportedContent)
+		file, header = newMultipartFile(t, "opus.mp4", string(testutil.NewMP4(testutil.MP4Options{AudioCodec: "Opus"})))
+		_, err = service.UploadFile(user, file, header, "video")
+		assert.ErrorIs(t, err, ErrUnsupportedContent)
+
+		entries, err := os.ReadDir(filepath.Join(root, "videos"))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+
+		// 按配置允许的编码
+		withHEVC := conf
+		withHEVC.VideoCodecs = []string{"avc1", "hvc1"}
+		service = NewFileService(store, assets, nil, withHEVC, nil)
+		file, header = newMultipartFile(t, "hevc.mp4", hevc)
+		_, err = service.UploadFile(user, file, header, "video")
+		assert.NoError(t, err)
+	})
+
+	t.Run("按配置限制内容类型", func(t *testing.T) {
+		restricted := conf
+		restricted.ContentTypes = map[string][]string{"cover": {"image/png"}}
+		service := NewFileService(storage.NewLocal(t.TempDir()), newTestMediaRepo(t), nil, restricted, nil)
+
+		file, header := newMultipartFile(t, "cover.jpg", jpegContent)
+		_, err := service.UploadFile(user, file, header, "cover")
+		assert.ErrorContains(t, err, "允许的类型: image/png")
+	})
+}
+
+func TestFileService_DeleteFile(t *testing.T) {
+	store := storage.NewLocal(t.TempDir())
+	service := NewFileService(store, newTestMediaRepo(t), nil, FileServiceConfig{AllowedTypes: []string{"jpg"}, MaxSize: 1024}, nil)
+	owner := UploadOwner{ID: 1, Role: "user"}
+	admin := UploadOwner{ID: 1, Role: "admin"}
+
+	upload := func(t *testing.T) string {
+		file, header := newMultipartFile(t, "a.jpg", jpegContent)
+		resp, err := service.UploadFile(owner, file, header, "cover")
+		require.NoError(t, err)
+		return resp.Path
+	}
+
+	t.Run("删除自己上传的文件", func(t *testing.T) {
+		key := upload(t)
+		require.NoError(t, service.DeleteFile(owner, key))
+		_, err := store.Stat(context.Background(), key)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("不能删除其他用户的文件", func(t *testing.T) {
+		key := upload(t)
+		assert.ErrorIs(t, service.DeleteFile(UploadOwner{ID: 2, Role: "user"}, key), ErrFileForbidden)
+		_, err := store.Stat(context.Background(), key)
+		assert.NoError(t, err)
+
+		// 未登记的文件普通用户无法删除
+		require.NoError(t, store.Put(context.Background(), "covers/legacy.jpg", bytes.NewReader([]byte("x")), 1, "image/jpeg"))
+		assert.ErrorIs(t, service.DeleteFile(owner, "covers/legacy.jpg"), ErrFileForbidden)
+	})
+
+	t.Run("管理员可以删除任何文件", func(t *testing.T) {
+		key := upload(t)
+		require.NoError(t, service.DeleteFile(admin, key))
+		require.NoError(t, service.DeleteFile(admin, "covers/legacy.jpg"))
+	})
+
+	t.Run("文件不存在时认为删除成功", func(t *testing.T) {
+		assert.NoError(t, service.DeleteFile(admin, "covers/missing.jpg"))
+	})
+
+	t.Run("被引用的文件不能删除", func(t *testing.T) {
+		db := testutil.SetupTestDB()
+		t.Cleanup(func() {
+			sqlDB, _ := db.DB()
+			sqlDB.Close()
+		})
+		assets := repository.NewMediaAssetRepository(db)
+		service := NewFileService(store, assets, nil, FileServiceConfig{AllowedTypes: []string{"jpg"}, MaxSize: 1024}, nil)
+
+		file, header := newMultipartFile(t, "a.jpg", jpegContent)
+		resp, err := service.UploadFile(owner, file, header, "avatar")
+		require.NoError(t, err)
+		asset, err := assets.GetByKey(resp.Path)
+		require.NoError(t, err)
+		require.NoError(t, db.Create(&models.User{Username: "u", Email: "u@example.com", Password: "secret", AvatarAssetID: &asset.ID}).Error)
+
+		assert.ErrorIs(t, service.DeleteFile(owner, resp.Path), ErrFileInUse)
+		assert.ErrorIs(t, service.DeleteFile(admin, resp.Path), ErrFileInUse)
+		_, err = store.Stat(context.Background(), resp.Path)
+		assert.NoError(t, err)
+	})
+
+	t.Run("拒绝存储目录之外的路径", func(t *testing.T) {
+		assert.ErrorContains(t, service.DeleteFile(admin, "../config.yaml"), "无效的文件路径")
+		assert.ErrorContains(t, service.DeleteFile(admin, "covers/../../configs/config.yaml"), "无效的文件路径")
+		assert.ErrorContains(t, service.DeleteFile(admin, "_resumable/abc/00001"), "无效的文件路径")
+	})
+}
+
+func TestGenerateRandomString(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		s := generateRandomString(16)
+		assert.Regexp(t, `^[A-Za-z0-9]{16}$`, s)
+		assert.False(t, seen[s])
+		seen[s] = true
+	}
+}
+
+func TestFileService_GetFileURL(t *testing.T) {
+	local := storage.NewLocal(t.TempDir())
+
+	t.Run("本地存储使用服务地址", func(t *testing.T) {
+		service := NewFileService(local, nil, nil, FileServiceConfig{BaseURL: "https://api.example.com"}, nil)
+		assert.Equal(t, "https://api.example.com/uploads/covers/a.jpg", service.GetFileURL("covers\\a.jpg"))
+	})
+
+	t.Run("配置 CDN 时使用 CDN 地址", func(t *testing.T) {
+		service := NewFileService(presignStorage{local}, nil, nil, FileServiceConfig{
+			BaseURL:   "https://api.example.com",
+			PublicURL: "https://cdn.example.com/",
+		}, nil)
+		assert.Equal(t, "https://cdn.example.com/covers/a.jpg", service.GetFileURL("/covers/a.jpg"))
+	})
+
+	t.Run("对象存储返回预签名地址", func(t *testing.T) {
+		service := NewFileService(presignStorage{local}, nil, nil, FileServiceConfig{PresignExpiry: time.Hour}, nil)
+		assert.Equal(t, "https://bucket.example.com/covers/a.jpg?X-Amz-Expires=1h0m0s", service.GetFileURL("covers/a.jpg"))
+	})
+
+	t.Run("非法路径", func(t *testing.T) {
+		service := NewFileService(local, nil, nil, FileServiceConfig{}, nil)
+		assert.Empty(t, service.GetFileURL("../secret"))
+	})
+}
+
+func TestFileService_OpenFile(t *testing.T) {
+	store := storage.NewLocal(t.TempDir())
+	service := NewFileService(store, nil, nil, FileServiceConfig{}, nil)
+	ctx := context.Background()
+	require.NoError(t, store.Put(ctx, "covers/a.jpg", strings.NewReader(jpegContent), int64(len(jpegContent)), "image/jpeg"))
+	require.NoError(t, store.Put(ctx, "hls/1/2/360p/index.m3u8", strings.NewReader("#EXTM3U\n"), 8, hlsMimeType))
+	require.NoError(t, store.Put(ctx, "_resumable/abc/00001", strings.NewReader("part"), 4, "application/octet-stream"))
+
+	t.Run("读取文件与元数据", func(t *testing.T) {
+		file, info, err := service.OpenFile("/covers/a.jpg")
+		require.NoError(t, err)
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		require.NoError(t, err)
+
+		assert.Equal(t, jpegContent, string(data))
+		assert.Equal(t, "image/jpeg", info.ContentType)
+		assert.Equal(t, int64(len(jpegContent)), info.Size)
+		assert.NotEmpty(t, info.ETag)
+	})
+
+	t.Run("HLS 文件的内容类型", func(t *testing.T) {
+		file, info, err := service.OpenFile("hls/1/2/360p/index.m3u8")
+		require.NoError(t, err)
+		file.Close()
+		assert.Equal(t, "application/vnd.apple.mpegurl", info.ContentType)
+		assert.Equal(t, "video/mp2t", fileContentType("hls/1/2/360p/segment_0000.ts", ""))
+		assert.Equal(t, "application/octet-stream", fileContentType("others/a.bin", ""))
+	})
+
+	t.Run("文件不存在", func(t *testing.T) {
+		for _, filePath := range []string{"covers/missing.jpg", "../config.yaml", "_resumable/abc/00001"} {
+			_, _, err := service.OpenFile(filePath)
+			assert.ErrorIs(t, err, ErrFileNotFound, filePath)
+		}
+	})
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"sync"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/media"
+	"gin-mysql-api/pkg/storage"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ErrVariantNotFound 文件不存在、不是可处理的图片或没有该规格
+var ErrVariantNotFound = errors.New("图片规格不存在")
+
+// imageQueueSize 等待异步生成规格图的任务数上限
+const imageQueueSize = 256
+
+// ImageService 图片处理服务接口
+// 头像、封面、缩略图上传后异步生成配置的规格图（居中裁剪、按 EXIF 方向旋转、去除元数据），
+// 访问尚未生成的规格图时同步生成
+type ImageService interface {
+	// WithContext 返回绑定请求上下文的服务，日志会带上请求的链路信息
+	WithContext(ctx context.Context) ImageService
+	// Variants 获取文件可以生成的规格，不是可处理的图片时返回空
+	Variants(uploadType, contentType string) []config.ImageVariant
+	// Schedule 异步生成文件的所有规格图，队列已满或服务已关闭时跳过，缺失的规格图在访问时生成
+	Schedule(asset *models.MediaAsset)
+	// Generate 生成文件的所有规格图
+	Generate(asset *models.MediaAsset) error
+	// VariantKey 获取规格图的存储路径，规格图尚未生成时同步生成
+	VariantKey(filePath, name string) (string, error)
+	// DeleteVariants 删除文件的所有规格图，失败时只记录日志
+	DeleteVariants(asset *models.MediaAsset)
+}
+
+// ImageServiceConfig 图片处理配置
+type ImageServiceConfig struct {
+	// Variants 各上传类型生成的规格
+	Variants  map[string][]config.ImageVariant
+	Quality   int
+	Workers   int
+	MaxPixels int
+}
+
+// NewImageServiceConfig 根据应用配置生成图片处理配置
+func NewImageServiceConfig(cfg *config.Config) ImageServiceConfig {
+	variants := make(map[string][]config.ImageVariant, len(config.ImageUploadTypes))
+	for _, uploadType := range config.ImageUploadTypes {
+		variants[uploadType] = cfg.Upload.Image.GetVariants(uploadType)
+	}
+	return ImageServiceConfig{
+		Variants:  variants,
+		Quality:   cfg.Upload.Image.GetQuality(),
+		Workers:   cfg.Upload.Image.GetWorkers(),
+		MaxPixels: cfg.Upload.Image.GetMaxPixels(),
+	}
+}
+
+// imageQueue 异步生成规格图的任务队列，所有 WithContext 副本共享
+type imageQueue struct {
+	mu     sync.RWMutex
+	closed bool
+	jobs   chan *models.MediaAsset
+	wg     sync.WaitGroup
+}
+
+// imageService 图片处理服务实现
+type imageService struct {
+	assets repository.MediaAssetRepository
+	store  storage.Storage
+	conf   ImageServiceConfig
+	queue  *imageQueue
+	flight *singleflight.Group
+	logger *slog.Logger
+	ctx    context.Context
+}
+
+// NewImageService 创建图片处理服务并启动 conf.Workers 个后台协程，log 为 nil 时使用全局默认 Logger
+// 服务实现了 io.Closer，关闭时等待队列中的任务完成
+func NewImageService(assets repository.MediaAssetRepository, store storage.Storage, conf ImageServiceConfig, log *slog.Logger) ImageService {
+	s := &imageService{
+		assets: assets,
+		store:  store,
+		conf:   conf,
+		queue:  &imageQueue{jobs: make(chan *models.MediaAsset, imageQueueSize)},
+		flight: &singleflight.Group{},
+		logger: logger.OrDefault(log),
+		ctx:    context.Background(),
+	}
+	for i := 0; i < max(conf.Workers, 1); i++ {
+		s.queue.wg.Add(1)
+		go s.work()
+	}
+	return s
+}
+
+// WithContext 返回绑定请求上下文的图片处理服务
+func (s *imageService) WithContext(ctx context.Context) ImageService {
+	scoped := *s
+	scoped.ctx = ctx
+	scoped.assets = s.assets.WithContext(ctx)
+	return &scoped
+}
+
+// Close 停止接收新任务并等待队列中的任务完成
+func (s *imageService) Close() error {
+	s.queue.mu.Lock()
+	if !s.queue.closed {
+		s.queue.closed = true
+		close(s.queue.jobs)
+	}
+	s.queue.mu.Unlock()
+	s.queue.wg.Wait()
+	return nil
+}
+
+// work 后台协程，逐个生成队列中文件的规格图
+func (s *imageService) work() {
+	defer s.queue.wg.Done()
+	for asset := range s.queue.jobs {
+		if err := s.Generate(asset); err != nil {
+			s.logger.Warn("生成规格图失败", slog.String("path", asset.StorageKey), slog.String("error", err.Error()))
+		}
+	}
+}
+
+// Variants 获取文件可以生成的规格，只处理 JPEG、PNG、GIF
+func (s *imageService) Variants(uploadType, contentType string) []config.ImageVariant {
+	switch contentType {
+	case media.TypeJPEG, media.TypePNG, media.TypeGIF:
+		return s.conf.Variants[uploadType]
+	default:
+		return nil
+	}
+}
+
+// Schedule 把文件加入异步生成队列
+func (s *imageService) Schedule(asset *models.MediaAsset) {
+	if len(s.Variants(asset.Type, asset.ContentType)) == 0 {
+		return
+	}
+
+	s.queue.mu.RLock()
+	defer s.queue.mu.RUnlock()
+	if s.queue.closed {
+		return
+	}
+	select {
+	case s.queue.jobs <- asset:
+	default:
+		s.logger.WarnContext(s.ctx, "规格图队列已满，访问时再生成", slog.String("path", asset.StorageKey))
+	}
+}
+
+// Generate 解码一次原图后生成所有规格图
+func (s *imageService) Generate(asset *models.MediaAsset) error {
+	return s.generate(asset, s.Variants(asset.Type, asset.ContentType)...)
+}
+
+// VariantKey 获取规格图的存储路径，规格图不存在时同步生成，同一规格图的并发请求只生成一次
+func (s *imageService) VariantKey(filePath, name string) (string, error) {
+	key, err := storage.CleanKey(filePath)
+	if err != nil {
+		return "", ErrVariantNotFound
+	}
+	asset, err := s.assets.GetByKey(key)
+	if err != nil {
+		return "", fmt.Errorf("查询文件失败: %w", err)
+	}
+	if asset == nil {
+		return "", ErrVariantNotFound
+	}
+
+	for _, variant := range s.Variants(asset.Type, asset.ContentType) {
+		if variant.Name != name {
+			continue
+		}
+
+		target := variantKey(asset, name)
+		_, err := s.store.Stat(s.ctx, target)
+		if err == nil {
+			return target, nil
+		}
+		if !errors.Is(err, storage.ErrNotFound) {
+			return "", fmt.Errorf("查询规格图失败: %w", err)
+		}
+
+		_, err, _ = s.flight.Do(target, func() (interface{}, error) {
+			return nil, s.generate(asset, variant)
+		})
+		if err != nil {
+			return "", err
+		}
+		return target, nil
+	}
+	return "", ErrVariantNotFound
+}
+
+// DeleteVariants 删除文件的所有规格图
+func (s *imageService) DeleteVariants(asset *models.MediaAsset) {
+	for _, variant := range s.Variants(asset.Type, asset.ContentType) {
+		key := variantKey(asset, variant.Name)
+		if err := s.store.Delete(s.ctx, key); err != nil {
+			s.logger.WarnContext(s.ctx, "删除规格图失败", slog.String("path", key), slog.String("error", err.Error()))
+		}
+	}
+}
+
+// generate 读取原图并生成指定的规格图
+func (s *imageService) generate(asset *models.MediaAsset, variants ...config.ImageVariant) error {
+	if len(variants) == 0 {
+		return nil
+	}
+
+	reader, _, err := s.store.Get(s.ctx, asset.StorageKey)
+	if err != nil {
+		return fmt.Errorf("读取原图失败: %w", err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("读取原图失败: %w", err)
+	}
+
+	img, err := media.DecodeImage(data, s.conf.MaxPixels)
+	if err != nil {
+		return fmt.Errorf("解码图片失败: %w", err)
+	}
+
+	contentType, _ := media.VariantType(asset.ContentType)
+	for _, variant := range variants {
+		var buf bytes.Buffer
+		if err := media.EncodeImage(&buf, media.Thumbnail(img, variant.Width, variant.Height), contentType, s.conf.Quality); err != nil {
+			return fmt.Errorf("编码图片失败: %w", err)
+		}
+		key := variantKey(asset, variant.Name)
+		if err := s.store.Put(s.ctx, key, &buf, int64(buf.Len()), contentType); err != nil {
+			return fmt.Errorf("保存规格图失败: %w", err)
+		}
+	}
+
+	s.logger.DebugContext(s.ctx, "规格图已生成", slog.String("path", asset.StorageKey), slog.Int("count", len(variants)))
+	return nil
+}
+
+// variantKey 规格图的存储路径：原图路径去掉扩展名后加上 _<规格名> 与规格图格式的扩展名
+func variantKey(asset *models.MediaAsset, name string) string {
+	_, ext := media.VariantType(asset.ContentType)
+	return strings.TrimSuffix(asset.StorageKey, path.Ext(asset.StorageKey)) + "_" + name + ext
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"io"
+	"testing"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testImageConfig 封面生成两种规格，头像一种
+var testImageConfig = ImageServiceConfig{
+	Variants: map[string][]config.ImageVariant{
+		"cover":  {{Name: "small", Width: 30, Height: 40}, {Name: "large", Width: 60, Height: 80}},
+		"avatar": {{Name: "small", Width: 16, Height: 16}},
+	},
+	Quality:   85,
+	Workers:   1,
+	MaxPixels: 1_000_000,
+}
+
+// jpegImage 编码指定尺寸的 JPEG 图片
+func jpegImage(t *testing.T, width, height int) string {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height)), nil))
+	return buf.String()
+}
+
+// decodeStored 读取并解码存储中的图片
+func decodeStored(t *testing.T, store storage.Storage, key string) image.Image {
+	t.Helper()
+	reader, _, err := store.Get(context.Background(), key)
+	require.NoError(t, err)
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	img, _, err := image.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	return img
+}
+
+func TestImageService(t *testing.T) {
+	conf := FileServiceConfig{
+		BaseURL:      "http://localhost:1800",
+		MaxSize:      1 << 20,
+		AllowedTypes: []string{"jpg", "png", "mp4"},
+	}
+	admin := UploadOwner{Role: "admin"}
+
+	t.Run("上传后异步生成规格图", func(t *testing.T) {
+		store := storage.NewLocal(t.TempDir())
+		assets := newTestMediaRepo(t)
+		images := NewImageService(assets, store, testImageConfig, nil)
+		service := NewFileService(store, assets, images, conf, nil)
+
+		file, header := newMultipartFile(t, "cover.jpg", jpegImage(t, 200, 100))
+		resp, err := service.UploadFile(admin, file, header, "cover")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"small": "http://localhost:1800/api/media/variants/small/" + resp.Path,
+			"large": "http://localhost:1800/api/media/variants/large/" + resp.Path,
+		}, resp.Variants)
+
+		// 关闭服务时等待队列中的任务完成
+		require.NoError(t, images.(*imageService).Close())
+		asset, err := assets.GetByKey(resp.Path)
+		require.NoError(t, err)
+		small := decodeStored(t, store, variantKey(asset, "small"))
+		assert.Equal(t, image.Rect(0, 0, 30, 40), small.Bounds())
+		large := decodeStored(t, store, variantKey(asset, "large"))
+		assert.Equal(t, image.Rect(0, 0, 60, 80), large.Bounds())
+	})
+
+	t.Run("访问时生成缺失的规格图", func(t *testing.T) {
+		store := storage.NewLocal(t.TempDir())
+		assets := newTestMediaRepo(t)
+		images := NewImageService(assets, store, testImageConfig, nil)
+		t.Cleanup(func() { images.(*imageService).Close() })
+
+		asset := &models.MediaAsset{OwnerID: 1, OwnerRole: "user", Type: "avatar", StorageKey: "avatars/1_abc.jpg", ContentType: "image/jpeg"}
+		content := jpegImage(t, 40, 50)
+		require.NoError(t, store.Put(context.Background(), asset.StorageKey, bytes.NewReader([]byte(content)), int64(len(content)), asset.ContentType))
+		require.NoError(t, assets.Create(asset))
+
+		key, err := images.VariantKey("/"+asset.StorageKey, "small")
+		require.NoError(t, err)
+		assert.Equal(t, "avatars/1_abc_small.jpg", key)
+		assert.Equal(t, image.Rect(0, 0, 16, 16), decodeStored(t, store, key).Bounds())
+
+		// 已生成的规格图直接返回
+		key, err = images.VariantKey(asset.StorageKey, "small")
+		require.NoError(t, err)
+		assert.Equal(t, "avatars/1_abc_small.jpg", key)
+
+		images.DeleteVariants(asset)
+		_, err = store.Stat(context.Background(), key)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("规格不存在", func(t *testing.T) {
+		store := storage.NewLocal(t.TempDir())
+		assets := newTestMediaRepo(t)
+		images := NewImageService(assets, store, testImageConfig, nil)
+		t.Cleanup(func() { images.(*imageService).Close() })
+
+		require.NoError(t, assets.Create(&models.MediaAsset{Type: "video", StorageKey: "videos/1.mp4", ContentType: "video/mp4"}))
+		require.NoError(t, assets.Create(&models.MediaAsset{Type: "cover", StorageKey: "covers/1.jpg", ContentType: "image/jpeg"}))
+
+		for _, tc := range []struct{ path, name string }{
+			{"covers/missing.jpg", "small"},
+			{"covers/1.jpg", "huge"},
+			{"videos/1.mp4", "small"},
+			{"../etc/passwd", "small"},
+		} {
+			_, err := images.VariantKey(tc.path, tc.name)
+			assert.ErrorIs(t, err, ErrVariantNotFound, tc.path)
+		}
+	})
+
+	t.Run("删除原图时删除规格图", func(t *testing.T) {
+		store := storage.NewLocal(t.TempDir())
+		assets := newTestMediaRepo(t)
+		images := NewImageService(assets, store, testImageConfig, nil)
+		t.Cleanup(func() { images.(*imageService).Close() })
+		service := NewFileService(store, assets, images, conf, nil)
+
+		file, header := newMultipartFile(t, "cover.jpg", jpegImage(t, 60, 80))
+		resp, err := service.UploadFile(admin, file, header, "cover")
+		require.NoError(t, err)
+		key, err := images.VariantKey(resp.Path, "large")
+		require.NoError(t, err)
+
+		require.NoError(t, service.DeleteFile(admin, resp.Path))
+		_, err = store.Stat(context.Background(), key)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+}
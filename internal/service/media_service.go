@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/storage"
+)
+
+// ErrInvalidMediaReference 引用的文件不属于当前用户，或文件类型与用途不符
+var ErrInvalidMediaReference = errors.New("无效的媒体文件引用")
+
+// gcBatchSize 垃圾回收每批处理的文件数量
+const gcBatchSize = 100
+
+// MediaService 媒体文件引用与垃圾回收服务接口
+// 短剧封面、剧集视频与缩略图、用户头像保存时通过 ResolveReference 关联到已上传的文件，
+// 不再被引用的文件超过保留期后由 CollectGarbage 删除
+type MediaService interface {
+	// WithContext 返回绑定请求上下文的服务，日志会带上请求的链路信息
+	WithContext(ctx context.Context) MediaService
+	// ResolveReference 根据文件 URL 或存储路径查找已上传的文件，返回文件 ID
+	// 引用外部地址或空地址时返回 nil；普通用户只能引用自己上传的文件
+	ResolveReference(owner UploadOwner, ref, usage string) (*uint, error)
+	// GetAsset 获取文件记录，包含上传时解析出的尺寸、时长、编码等元数据，不存在时返回 nil
+	GetAsset(id uint) (*models.MediaAsset, error)
+	// CollectGarbage 删除超过保留期仍未被引用的文件，返回删除的数量
+	CollectGarbage() (int, error)
+	// Release 立即删除 ids 中已不再被引用的文件，仍被引用的文件保留，返回删除的数量
+	// 用于永久删除短剧或剧集后回收其封面、视频与缩略图，回收站的保留期已经覆盖了文件的保留期
+	Release(ids []uint) (int, error)
+}
+
+// mediaService 媒体文件服务实现
+type mediaService struct {
+	repo      repository.MediaAssetRepository
+	store     storage.Storage
+	images    ImageService
+	retention time.Duration
+	now       func() time.Time
+	logger    *slog.Logger
+	ctx       context.Context
+}
+
+// NewMediaService 创建媒体文件服务，retention 为文件失去引用后的保留时长，log 为 nil 时使用全局默认 Logger
+// images 不为 nil 时回收文件会同时删除图片规格图
+func NewMediaService(repo repository.MediaAssetRepository, store storage.Storage, images ImageService, retention time.Duration, log *slog.Logger) MediaService {
+	return &mediaService{
+		repo:      repo,
+		store:     store,
+		images:    images,
+		retention: retention,
+		now:       time.Now,
+		logger:    logger.OrDefault(log),
+		ctx:       context.Background(),
+	}
+}
+
+// WithContext 返回绑定请求上下文的媒体文件服务
+func (s *mediaService) WithContext(ctx context.Context) MediaService {
+	scoped := *s
+	scoped.ctx = ctx
+	scoped.repo = s.repo.WithContext(ctx)
+	if s.images != nil {
+		scoped.images = s.images.WithContext(ctx)
+	}
+	return &scoped
+}
+
+// ResolveReference 查找引用的文件
+// ref 可以是上传接口返回的 url（服务地址、CDN 地址或预签名地址）或 path，
+// 依次尝试 URL 路径的各个后缀，取最长的匹配作为存储路径
+func (s *mediaService) ResolveReference(owner UploadOwner, ref, usage string) (*uint, error) {
+	candidates := referenceKeys(ref)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	assets, err := s.repo.GetByKeys(candidates)
+	if err != nil {
+		return nil, fmt.Errorf("查询媒体文件失败: %w", err)
+	}
+	var asset *models.MediaAsset
+	for i := range assets {
+		if asset == nil || len(assets[i].StorageKey) > len(asset.StorageKey) {
+			asset = &assets[i]
+		}
+	}
+	if asset == nil {
+		return nil, nil
+	}
+
+	if !owner.IsAdmin() && !asset.IsOwnedBy(owner.ID, owner.Role) {
+		return nil, fmt.Errorf("%w: 只能使用自己上传的文件", ErrInvalidMediaReference)
+	}
+	if category := mediaCategory(usage); !strings.HasPrefix(asset.ContentType, category+"/") {
+		return nil, fmt.Errorf("%w: %s 不是%s文件", ErrInvalidMediaReference, asset.StorageKey, categoryName(category))
+	}
+	return &asset.ID, nil
+}
+
+// GetAsset 获取文件记录
+func (s *mediaService) GetAsset(id uint) (*models.MediaAsset, error) {
+	asset, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("查询媒体文件失败: %w", err)
+	}
+	return asset, nil
+}
+
+// referenceKeys 获取引用地址对应的候选存储路径
+func referenceKeys(ref string) []string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil
+	}
+	if u, err := url.Parse(ref); err == nil {
+		ref = u.Path
+	}
+	key, err := storage.CleanKey(ref)
+	if err != nil {
+		return nil
+	}
+
+	var keys []string
+	for {
+		keys = append(keys, key)
+		i := strings.Index(key, "/")
+		if i < 0 {
+			return keys
+		}
+		key = key[i+1:]
+	}
+}
+
+// mediaCategory 获取用途要求的内容类型大类
+func mediaCategory(usage string) string {
+	if usage == "video" {
+		return "video"
+	}
+	return "image"
+}
+
+// categoryName 内容类型大类的中文名称
+func categoryName(category string) string {
+	if category == "video" {
+		return "视频"
+	}
+	return "图片"
+}
+
+// CollectGarbage 刷新引用状态后删除超过保留期的未引用文件
+// 先删除存储对象再删除记录，删除对象失败时保留记录，下次回收时重试
+func (s *mediaService) CollectGarbage() (int, error) {
+	now := s.now()
+	if err := s.repo.SyncReferences(now); err != nil {
+		return 0, fmt.Errorf("刷新媒体文件引用失败: %w", err)
+	}
+
+	deleted := 0
+	cutoff := now.Add(-s.retention)
+	for {
+		assets, err := s.repo.ListUnreferencedBefore(cutoff, gcBatchSize)
+		if err != nil {
+			return deleted, fmt.Errorf("查询未引用的媒体文件失败: %w", err)
+		}
+
+		for i := range assets {
+			asset := &assets[i]
+			// 刷新引用状态后可能又被引用
+			referenced, err := s.repo.IsReferenced(asset.ID)
+			if err != nil {
+				return deleted, fmt.Errorf("查询媒体文件引用失败: %w", err)
+			}
+			if referenced {
+				asset.UnreferencedSince = nil
+				if err := s.repo.Update(asset); err != nil {
+					return deleted, fmt.Errorf("更新媒体文件失败: %w", err)
+				}
+				continue
+			}
+
+			if err := s.deleteAsset(asset); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+
+		if len(assets) < gcBatchSize {
+			break
+		}
+	}
+
+	if deleted > 0 {
+		s.logger.InfoContext(s.ctx, "已回收未引用的媒体文件", slog.Int("count", deleted))
+	}
+	return deleted, nil
+}
+
+// Release 逐个检查引用后删除，已不存在的文件跳过
+func (s *mediaService) Release(ids []uint) (int, error) {
+	deleted := 0
+	for _, id := range ids {
+		referenced, err := s.repo.IsReferenced(id)
+		if err != nil {
+			return deleted, fmt.Errorf("查询媒体文件引用失败: %w", err)
+		}
+		if referenced {
+			continue
+		}
+		asset, err := s.repo.GetByID(id)
+		if err != nil {
+			return deleted, fmt.Errorf("查询媒体文件失败: %w", err)
+		}
+		if asset == nil {
+			continue
+		}
+		if err := s.deleteAsset(asset); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	if deleted > 0 {
+		s.logger.InfoContext(s.ctx, "已删除不再引用的媒体文件", slog.Int("count", deleted))
+	}
+	return deleted, nil
+}
+
+// deleteAsset 先删除存储对象与图片规格图再删除记录，删除对象失败时保留记录
+func (s *mediaService) deleteAsset(asset *models.MediaAsset) error {
+	if err := s.store.Delete(s.ctx, asset.StorageKey); err != nil {
+		return fmt.Errorf("删除存储对象失败: %w", err)
+	}
+	if s.images != nil {
+		s.images.DeleteVariants(asset)
+	}
+	if err := s.repo.Delete(asset.ID); err != nil {
+		return fmt.Errorf("删除媒体文件记录失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/internal/testutil"
+	"gin-mysql-api/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newTestMediaService 基于测试数据库与本地存储创建媒体文件服务，保留期为 1 天
+func newTestMediaService(t *testing.T) (*mediaService, repository.MediaAssetRepository, storage.Storage, *gorm.DB) {
+	t.Helper()
+	db := testutil.SetupTestDB()
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+
+	repo := repository.NewMediaAssetRepository(db)
+	store := storage.NewLocal(t.TempDir())
+	service := NewMediaService(repo, store, nil, 24*time.Hour, nil).(*mediaService)
+	return service, repo, store, db
+}
+
+// createTestAsset 写入存储对象并登记媒体文件
+func createTestAsset(t *testing.T, repo repository.MediaAssetRepository, store storage.Storage, asset *models.MediaAsset) *models.MediaAsset {
+	t.Helper()
+	require.NoError(t, store.Put(context.Background(), asset.StorageKey, bytes.NewReader([]byte("x")), 1, asset.ContentType))
+	asset.Size = 1
+	require.NoError(t, repo.Create(asset))
+	return asset
+}
+
+func TestMediaService_ResolveReference(t *testing.T) {
+	service, repo, store, _ := newTestMediaService(t)
+	user := UploadOwner{ID: 1, Role: "user"}
+	avatar := createTestAsset(t, repo, store, &models.MediaAsset{
+		OwnerID: 1, OwnerRole: "user", Type: "avatar", StorageKey: "avatars/1_abc.jpg", ContentType: "image/jpeg",
+	})
+	video := createTestAsset(t, repo, store, &models.MediaAsset{
+		OwnerID: 1, OwnerRole: "admin", Type: "video", StorageKey: "videos/1_def.mp4", ContentType: "video/mp4",
+	})
+
+	t.Run("支持上传接口返回的各种地址", func(t *testing.T) {
+		for _, ref := range []string{
+			"avatars/1_abc.jpg",
+			"/avatars/1_abc.jpg",
+			"http://localhost:1800/uploads/avatars/1_abc.jpg",
+			"https://cdn.example.com/avatars/1_abc.jpg",
+			"https://s3.example.com/bucket/avatars/1_abc.jpg?X-Amz-Expires=900&X-Amz-Signature=abc",
+		} {
+			id, err := service.ResolveReference(user, ref, "avatar")
+			require.NoError(t, err, ref)
+			require.NotNil(t, id, ref)
+			assert.Equal(t, avatar.ID, *id, ref)
+		}
+	})
+
+	t.Run("外部地址不关联文件", func(t *testing.T) {
+		for _, ref := range []string{"", "https://example.com/images/a.jpg", "avatars/missing.jpg", "../etc/passwd"} {
+			id, err := service.ResolveReference(user, ref, "avatar")
+			assert.NoError(t, err, ref)
+			assert.Nil(t, id, ref)
+		}
+	})
+
+	t.Run("普通用户只能引用自己上传的文件", func(t *testing.T) {
+		_, err := service.ResolveReference(UploadOwner{ID: 2, Role: "user"}, avatar.StorageKey, "avatar")
+		assert.ErrorIs(t, err, ErrInvalidMediaReference)
+
+		id, err := service.ResolveReference(UploadOwner{Role: "admin"}, avatar.StorageKey, "cover")
+		require.NoError(t, err)
+		assert.Equal(t, avatar.ID, *id)
+	})
+
+	t.Run("文件类型需要与用途一致", func(t *testing.T) {
+		_, err := service.ResolveReference(UploadOwner{Role: "admin"}, video.StorageKey, "thumbnail")
+		assert.ErrorIs(t, err, ErrInvalidMediaReference)
+		_, err = service.ResolveReference(UploadOwner{Role: "admin"}, avatar.StorageKey, "video")
+		assert.ErrorIs(t, err, ErrInvalidMediaReference)
+
+		id, err := service.ResolveReference(UploadOwner{Role: "admin"}, video.StorageKey, "video")
+		require.NoError(t, err)
+		assert.Equal(t, video.ID, *id)
+	})
+}
+
+func TestMediaService_CollectGarbage(t *testing.T) {
+	service, repo, store, db := newTestMediaService(t)
+	admin := UploadOwner{Role: "admin"}
+	longAgo := time.Now().Add(-48 * time.Hour)
+	recently := time.Now().Add(-time.Hour)
+
+	cover := createTestAsset(t, repo, store, &models.MediaAsset{
+		OwnerRole: "admin", Type: "cover", StorageKey: "covers/used.jpg", ContentType: "image/jpeg", UnreferencedSince: &longAgo,
+	})
+	orphan := createTestAsset(t, repo, store, &models.MediaAsset{
+		OwnerRole: "admin", Type: "cover", StorageKey: "covers/orphan.jpg", ContentType: "image/jpeg", UnreferencedSince: &longAgo,
+	})
+	fresh := createTestAsset(t, repo, store, &models.MediaAsset{
+		OwnerRole: "admin", Type: "cover", StorageKey: "covers/fresh.jpg", ContentType: "image/jpeg", UnreferencedSince: &recently,
+	})
+
+	coverID, err := service.ResolveReference(admin, cover.StorageKey, "cover")
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&models.Drama{Title: "短剧", CoverImage: cover.StorageKey, CoverAssetID: coverID}).Error)
+
+	deleted, err := service.CollectGarbage()
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	_, err = store.Stat(context.Background(), orphan.StorageKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	found, err := repo.GetByKey(orphan.StorageKey)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+
+	// 被引用的文件与未超过保留期的文件保留
+	for _, key := range []string{cover.StorageKey, fresh.StorageKey} {
+		_, err = store.Stat(context.Background(), key)
+		assert.NoError(t, err, key)
+	}
+	found, err = repo.GetByKey(cover.StorageKey)
+	require.NoError(t, err)
+	assert.Nil(t, found.UnreferencedSince)
+
+	// 超过保留期后回收
+	service.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+	deleted, err = service.CollectGarbage()
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = store.Stat(context.Background(), fresh.StorageKey)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
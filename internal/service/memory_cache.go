@@ -0,0 +1,436 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// errNotInteger 与 Redis INCR 对非整数值的报错保持一致
+var errNotInteger = errors.New("ERR value is not an integer or out of range")
+
+// memoryEntry 本地缓存条目
+type memoryEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time // 零值表示永不过期
+	tags      []string
+}
+
+// memoryCache 进程内 LRU 缓存服务实现
+// 未命中时返回 redis.Nil，与 Redis 实现的语义保持一致，调用方无需区分
+type memoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+	tags       map[string]map[string]struct{}
+	now        func() time.Time
+}
+
+// NewMemoryCacheService 创建新的本地内存缓存服务
+// maxEntries <= 0 时不限制条目数量
+func NewMemoryCacheService(maxEntries int) CacheService {
+	return newMemoryCache(maxEntries)
+}
+
+func newMemoryCache(maxEntries int) *memoryCache {
+	return &memoryCache{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		tags:       make(map[string]map[string]struct{}),
+		now:        time.Now,
+	}
+}
+
+// Set 设置缓存值
+func (c *memoryCache) Set(key string, value interface{}, expiration time.Duration) error {
+	str, err := formatCacheValue(value)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setLocked(key, str, c.expiresAt(expiration))
+	return nil
+}
+
+// Get 获取缓存值
+func (c *memoryCache) Get(key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := c.getLocked(key)
+	if entry == nil {
+		return "", redis.Nil
+	}
+	return entry.value, nil
+}
+
+// Delete 删除缓存
+func (c *memoryCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.removeElement(elem)
+	}
+	return nil
+}
+
+// Exists 检查缓存是否存在
+func (c *memoryCache) Exists(key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.getLocked(key) != nil, nil
+}
+
+// SetJSON 设置 JSON 格式的缓存
+func (c *memoryCache) SetJSON(key string, value interface{}, expiration time.Duration) error {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.Set(key, jsonData, expiration)
+}
+
+// GetJSON 获取 JSON 格式的缓存
+func (c *memoryCache) GetJSON(key string, dest interface{}) error {
+	jsonData, err := c.Get(key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(jsonData), dest)
+}
+
+// DeletePattern 根据模式删除缓存（支持 Redis 风格的 * ? [] 通配符）
+func (c *memoryCache) DeletePattern(pattern string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, elem := range c.items {
+		if matchPattern(pattern, key) {
+			c.removeElement(elem)
+		}
+	}
+	return nil
+}
+
+// Increment 递增计数器，保留原有过期时间
+func (c *memoryCache) Increment(key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var (
+		current   int64
+		expiresAt time.Time
+	)
+	if entry := c.getLocked(key); entry != nil {
+		n, err := strconv.ParseInt(entry.value, 10, 64)
+		if err != nil {
+			return 0, errNotInteger
+		}
+		current = n
+		expiresAt = entry.expiresAt
+	}
+
+	current++
+	c.setLocked(key, strconv.FormatInt(current, 10), expiresAt)
+	return current, nil
+}
+
+// Expire 设置过期时间，非正数时立即删除
+func (c *memoryCache) Expire(key string, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+	if expiration <= 0 {
+		c.removeElement(elem)
+		return nil
+	}
+	elem.Value.(*memoryEntry).expiresAt = c.now().Add(expiration)
+	return nil
+}
+
+// WithContext 本地缓存不涉及网络调用，直接返回自身
+func (c *memoryCache) WithContext(ctx context.Context) CacheService {
+	return c
+}
+
+// Len 返回当前缓存条目数量（包含尚未清理的过期条目）
+func (c *memoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+// Flush 清空全部缓存
+func (c *memoryCache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.tags = make(map[string]map[string]struct{})
+}
+
+// SetJSONWithTags 设置 JSON 格式的缓存并登记到标签
+func (c *memoryCache) SetJSONWithTags(key string, value interface{}, expiration time.Duration, tags ...string) error {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setLocked(key, string(jsonData), c.expiresAt(expiration))
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*memoryEntry)
+	for _, tag := range tags {
+		members, ok := c.tags[tag]
+		if !ok {
+			members = make(map[string]struct{})
+			c.tags[tag] = members
+		}
+		if _, exists := members[key]; !exists {
+			members[key] = struct{}{}
+			entry.tags = append(entry.tags, tag)
+		}
+	}
+	return nil
+}
+
+// InvalidateTag 删除标签下登记的全部缓存
+func (c *memoryCache) InvalidateTag(tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if elem, ok := c.items[key]; ok {
+				c.removeElement(elem)
+			}
+		}
+		delete(c.tags, tag)
+	}
+	return nil
+}
+
+// expiresAt 计算过期时间点
+func (c *memoryCache) expiresAt(expiration time.Duration) time.Time {
+	if expiration <= 0 {
+		return time.Time{}
+	}
+	return c.now().Add(expiration)
+}
+
+// getLocked 获取未过期的条目并标记为最近使用，调用方需持有锁
+func (c *memoryCache) getLocked(key string) *memoryEntry {
+	elem, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+
+	entry := elem.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt) {
+		c.removeElement(elem)
+		return nil
+	}
+
+	c.ll.MoveToFront(elem)
+	return entry
+}
+
+// setLocked 写入条目并按 LRU 淘汰，调用方需持有锁
+func (c *memoryCache) setLocked(key, value string, expiresAt time.Time) {
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+
+	for c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// removeElement 移除条目并解除标签登记，调用方需持有锁
+func (c *memoryCache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*memoryEntry)
+	c.ll.Remove(elem)
+	delete(c.items, entry.key)
+
+	for _, tag := range entry.tags {
+		if members, ok := c.tags[tag]; ok {
+			delete(members, entry.key)
+			if len(members) == 0 {
+				delete(c.tags, tag)
+			}
+		}
+	}
+}
+
+// formatCacheValue 按 go-redis 的参数编码规则将值转换为字符串
+func formatCacheValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+// matchPattern 实现 Redis KEYS/SCAN 的 glob 匹配规则（* ? [abc] [^a] [a-z] 与 \ 转义）
+func matchPattern(pattern, str string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(str); i++ {
+				if matchPattern(pattern[1:], str[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(str) == 0 {
+				return false
+			}
+			str = str[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(str) == 0 {
+				return false
+			}
+			end := 1
+			for end < len(pattern) && pattern[end] != ']' {
+				if pattern[end] == '\\' && end+1 < len(pattern) {
+					end++
+				}
+				end++
+			}
+			if end >= len(pattern) {
+				// 未闭合的 [ 按字面量处理
+				if str[0] != '[' {
+					return false
+				}
+				str = str[1:]
+				pattern = pattern[1:]
+				continue
+			}
+			if !matchClass(pattern[1:end], str[0]) {
+				return false
+			}
+			str = str[1:]
+			pattern = pattern[end+1:]
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(str) == 0 || pattern[0] != str[0] {
+				return false
+			}
+			str = str[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(str) == 0
+}
+
+// matchClass 匹配 [] 字符集
+func matchClass(class string, c byte) bool {
+	negate := false
+	if len(class) > 0 && class[0] == '^' {
+		negate = true
+		class = class[1:]
+	}
+
+	matched := false
+	for i := 0; i < len(class); i++ {
+		switch {
+		case class[i] == '\\' && i+1 < len(class):
+			i++
+			if class[i] == c {
+				matched = true
+			}
+		case i+2 < len(class) && class[i+1] == '-':
+			lo, hi := class[i], class[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if c >= lo && c <= hi {
+				matched = true
+			}
+			i += 2
+		default:
+			if class[i] == c {
+				matched = true
+			}
+		}
+	}
+
+	if negate {
+		return !matched
+	}
+	return matched
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache_SetGet(t *testing.T) {
+	cache := newMemoryCache(10)
+
+	t.Run("成功设置和获取缓存", func(t *testing.T) {
+		err := cache.Set("test:key", "test value", time.Hour)
+		assert.NoError(t, err)
+
+		value, err := cache.Get("test:key")
+		assert.NoError(t, err)
+		assert.Equal(t, "test value", value)
+	})
+
+	t.Run("缓存不存在", func(t *testing.T) {
+		value, err := cache.Get("nonexistent:key")
+		assert.Equal(t, redis.Nil, err)
+		assert.Empty(t, value)
+	})
+
+	t.Run("非字符串值按 Redis 规则编码", func(t *testing.T) {
+		cache.Set("test:int", 42, 0)
+		cache.Set("test:bytes", []byte("raw"), 0)
+
+		value, _ := cache.Get("test:int")
+		assert.Equal(t, "42", value)
+		value, _ = cache.Get("test:bytes")
+		assert.Equal(t, "raw", value)
+	})
+}
+
+func TestMemoryCache_Expiration(t *testing.T) {
+	cache := newMemoryCache(10)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cache.Set("test:ttl", "value", time.Minute)
+	cache.Set("test:forever", "value", 0)
+
+	exists, _ := cache.Exists("test:ttl")
+	assert.True(t, exists)
+
+	now = now.Add(2 * time.Minute)
+
+	_, err := cache.Get("test:ttl")
+	assert.Equal(t, redis.Nil, err)
+
+	value, err := cache.Get("test:forever")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	t.Run("Expire 更新过期时间", func(t *testing.T) {
+		cache.Expire("test:forever", time.Second)
+		now = now.Add(2 * time.Second)
+
+		exists, _ := cache.Exists("test:forever")
+		assert.False(t, exists)
+	})
+}
+
+func TestMemoryCache_LRUEviction(t *testing.T) {
+	cache := newMemoryCache(2)
+
+	cache.Set("a", "1", 0)
+	cache.Set("b", "2", 0)
+
+	// 访问 a，使 b 成为最久未使用的条目
+	cache.Get("a")
+	cache.Set("c", "3", 0)
+
+	assert.Equal(t, 2, cache.Len())
+
+	_, err := cache.Get("b")
+	assert.Equal(t, redis.Nil, err)
+
+	value, err := cache.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", value)
+}
+
+func TestMemoryCache_JSON(t *testing.T) {
+	cache := newMemoryCache(10)
+
+	original := map[string]interface{}{
+		"name": "测试",
+		"age":  float64(25),
+	}
+	err := cache.SetJSON("test:json", original, time.Hour)
+	assert.NoError(t, err)
+
+	var result map[string]interface{}
+	err = cache.GetJSON("test:json", &result)
+	assert.NoError(t, err)
+	assert.Equal(t, original, result)
+}
+
+func TestMemoryCache_DeletePattern(t *testing.T) {
+	cache := newMemoryCache(10)
+
+	cache.Set("dramas:page:1:size:20:category:", "1", 0)
+	cache.Set("dramas:page:2:size:20:category:", "2", 0)
+	cache.Set("drama:1", "3", 0)
+
+	err := cache.DeletePattern("dramas:page:*")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, cache.Len())
+	exists, _ := cache.Exists("drama:1")
+	assert.True(t, exists)
+}
+
+func TestMemoryCache_Tags(t *testing.T) {
+	cache := newMemoryCache(10)
+
+	cache.SetJSONWithTags("drama:1", "detail", time.Hour, TagDrama(1))
+	cache.SetJSONWithTags("dramas:page:1", "list", time.Hour, TagDramaList, TagDrama(1), TagDrama(2))
+	cache.SetJSONWithTags("dramas:category:爱情", "list", time.Hour, TagCategory("爱情"), TagDrama(2))
+
+	t.Run("按标签失效关联的缓存", func(t *testing.T) {
+		err := cache.InvalidateTag(TagDrama(1))
+		assert.NoError(t, err)
+
+		exists, _ := cache.Exists("drama:1")
+		assert.False(t, exists)
+		exists, _ = cache.Exists("dramas:page:1")
+		assert.False(t, exists)
+		exists, _ = cache.Exists("dramas:category:爱情")
+		assert.True(t, exists)
+	})
+
+	t.Run("淘汰或删除的条目同步解除标签登记", func(t *testing.T) {
+		cache.Delete("dramas:category:爱情")
+
+		assert.Empty(t, cache.tags[TagCategory("爱情")])
+		assert.Empty(t, cache.tags[TagDrama(2)])
+	})
+}
+
+func TestMemoryCache_Increment(t *testing.T) {
+	cache := newMemoryCache(10)
+
+	t.Run("成功递增计数器", func(t *testing.T) {
+		value, err := cache.Increment("counter:test")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), value)
+
+		value, err = cache.Increment("counter:test")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), value)
+	})
+
+	t.Run("非整数值递增失败", func(t *testing.T) {
+		cache.Set("counter:text", "abc", 0)
+
+		_, err := cache.Increment("counter:text")
+		assert.Error(t, err)
+	})
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		key      string
+		expected bool
+	}{
+		{"*", "anything", true},
+		{"drama:*", "drama:1", true},
+		{"drama:*", "dramas:1", false},
+		{"episodes:drama:1:*", "episodes:drama:1:page:1", true},
+		{"episodes:drama:1:*", "episodes:drama:12:page:1", false},
+		{"drama:?", "drama:1", true},
+		{"drama:?", "drama:12", false},
+		{"drama:[12]", "drama:2", true},
+		{"drama:[^12]", "drama:2", false},
+		{"drama:[a-c]", "drama:b", true},
+		{"drama:\\*", "drama:*", true},
+		{"drama:\\*", "drama:1", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, matchPattern(test.pattern, test.key), "Pattern: %s, Key: %s", test.pattern, test.key)
+	}
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/signing"
+	"gin-mysql-api/pkg/storage"
+)
+
+// hlsMimeType HLS 播放列表的内容类型
+const hlsMimeType = "application/vnd.apple.mpegurl"
+
+var (
+	// ErrPlaylistNotFound 剧集不存在或视频尚未转码
+	ErrPlaylistNotFound = errors.New("播放列表不存在")
+	// ErrEpisodeNotFound 预览的剧集不存在
+	ErrEpisodeNotFound = errors.New("剧集不存在")
+	// ErrPlaybackLoginRequired 配置了 playback.requireLogin 时匿名用户不能获取播放地址
+	ErrPlaybackLoginRequired = errors.New("登录后才能观看")
+	// ErrPlaybackForbidden 剧集或所属短剧尚未发布
+	ErrPlaybackForbidden = errors.New("无权观看该剧集")
+	// ErrInvalidPlaybackToken 播放地址的签名无效、已过期或不能访问请求的文件
+	ErrInvalidPlaybackToken = errors.New("播放地址无效或已过期")
+)
+
+// playbackCachePolicy 剧集转码结果与视频文件的缓存策略，转码完成或更换视频时按剧集标签失效
+var playbackCachePolicy = cachePolicy{TTL: 10 * time.Minute, StaleTTL: 5 * time.Minute}
+
+// PlaybackService 剧集播放服务接口
+// 根据转码结果生成 HLS 主播放列表，剧集详情返回 HLS 与 MP4 两种播放源
+// 开启签名时播放地址绑定剧集、观看者与有效期，上传的视频与 HLS 文件只能通过 /media/<令牌>/<path> 访问
+type PlaybackService interface {
+	// WithContext 返回绑定请求上下文的服务，日志与查询会带上请求的链路信息
+	WithContext(ctx context.Context) PlaybackService
+	// Signed 是否签发带签名的播放地址
+	Signed() bool
+	// Sources 获取剧集的播放源：已转码时首先是 HLS 主播放列表，其次是原始视频；受保护的剧集不返回未加密的原始视频
+	// 开启签名时先检查观看权限，不满足时返回 ErrPlaybackLoginRequired 或 ErrPlaybackForbidden
+	Sources(episode *models.Episode, viewer PlaybackViewer) ([]models.PlaybackSource, error)
+	// Preview 为管理员生成长期有效的预览播放源，不检查剧集是否已发布，签发记录写入日志
+	Preview(episodeID, adminID uint) ([]models.PlaybackSource, error)
+	// MasterPlaylist 生成剧集的 HLS 主播放列表，尚未转码时返回 ErrPlaylistNotFound
+	// 开启签名时 token 需为该剧集签发的令牌，媒体播放列表地址沿用同一令牌；
+	// 未开启签名时按 visibility 检查剧集是否可见，不可见（未发布、所属短剧未发布或已删除）时返回 ErrContentNotFound
+	MasterPlaylist(episodeID uint, token, ip string, visibility Visibility) (string, error)
+	// AuthorizeMedia 校验签名地址能否访问文件，不能访问时返回 ErrInvalidPlaybackToken
+	AuthorizeMedia(token, filePath, ip string) error
+	// RequiresToken 文件是否只能通过签名地址访问
+	RequiresToken(filePath string) bool
+	// ProtectedSource 文件是否为受保护剧集的原始视频，受保护的剧集只能通过加密的 HLS 播放
+	ProtectedSource(filePath string) (bool, error)
+	// ContentKey 检查登录与观看权限后返回受保护剧集的 HLS 内容密钥，可见范围覆盖剧集时（管理员与预览令牌）不检查
+	ContentKey(episodeID, keyID uint, viewer PlaybackViewer, visibility Visibility) ([]byte, error)
+}
+
+// PlaybackViewer 获取播放地址的观看者，匿名观看时 UserID 为 0
+type PlaybackViewer struct {
+	UserID uint
+	Role   string
+	IP     string
+}
+
+// PlaybackServiceConfig 剧集播放服务配置
+type PlaybackServiceConfig struct {
+	// BaseURL 服务对外访问地址，主播放列表与签名地址以此为前缀
+	BaseURL string
+	// TTL、PreviewTTL 播放地址与管理员预览地址的有效期
+	TTL        time.Duration
+	PreviewTTL time.Duration
+	// BindIP 播放地址是否绑定客户端 IP
+	BindIP bool
+	// RequireLogin 是否只向登录用户签发播放地址
+	RequireLogin bool
+}
+
+// NewPlaybackServiceConfig 根据应用配置生成剧集播放服务配置
+func NewPlaybackServiceConfig(cfg *config.Config) PlaybackServiceConfig {
+	return PlaybackServiceConfig{
+		BaseURL:      cfg.Server.GetBaseURL(),
+		TTL:          cfg.Playback.GetTTL(),
+		PreviewTTL:   cfg.Playback.GetPreviewTTL(),
+		BindIP:       cfg.Playback.BindIP,
+		RequireLogin: cfg.Playback.RequireLogin,
+	}
+}
+
+// NewPlaybackSigner 根据配置创建播放地址签发器，未开启签名时返回 nil
+func NewPlaybackSigner(cfg *config.Config) *signing.Signer {
+	if !cfg.Playback.Signed {
+		return nil
+	}
+	return signing.NewSigner(signing.Keys(&cfg.Playback))
+}
+
+// playbackGrant 播放地址绑定的客户端 IP 与有效期
+// 令牌是持有者令牌：签发时检查观看权限，访问文件时只校验 IP 与有效期，不识别观看者
+type playbackGrant struct {
+	ip  string
+	ttl time.Duration
+}
+
+// playbackInfo 缓存的剧集播放信息
+// 只缓存存储路径，文件 URL 在每次请求时生成，避免返回已过期的预签名 URL
+type playbackInfo struct {
+	Renditions []models.EpisodeRendition `json:"renditions"`
+	Video      *models.MediaAsset        `json:"video,omitempty"`
+}
+
+// playbackService 剧集播放服务实现
+type playbackService struct {
+	repo         repository.TranscodeRepository
+	episodes     repository.EpisodeRepository
+	assets       repository.MediaAssetRepository
+	files        FileService
+	keys         ContentKeyService
+	cacheService CacheService
+	readThrough  *readThroughCache
+	signer       *signing.Signer
+	baseURL      string
+	ttl          time.Duration
+	previewTTL   time.Duration
+	bindIP       bool
+	requireLogin bool
+	logger       *slog.Logger
+	ctx          context.Context
+}
+
+// NewPlaybackService 创建剧集播放服务，文件 URL 由 files 生成，主播放列表地址为 <BaseURL>/api/episodes/<id>/master.m3u8
+// keys 提供受保护剧集的内容密钥；signer 为 nil 时不签名，播放地址即文件 URL；
+// cacheService 为 nil 时每次请求都查询数据库，log 为 nil 时使用全局默认 Logger
+func NewPlaybackService(
+	repo repository.TranscodeRepository,
+	episodes repository.EpisodeRepository,
+	assets repository.MediaAssetRepository,
+	files FileService,
+	keys ContentKeyService,
+	cacheService CacheService,
+	signer *signing.Signer,
+	conf PlaybackServiceConfig,
+	log *slog.Logger,
+) PlaybackService {
+	log = logger.OrDefault(log)
+	return &playbackService{
+		repo:         repo,
+		episodes:     episodes,
+		assets:       assets,
+		files:        files,
+		keys:         keys,
+		cacheService: cacheService,
+		readThrough:  newReadThroughCache(cacheService, log),
+		signer:       signer,
+		baseURL:      strings.TrimSuffix(conf.BaseURL, "/"),
+		ttl:          conf.TTL,
+		previewTTL:   conf.PreviewTTL,
+		bindIP:       conf.BindIP,
+		requireLogin: conf.RequireLogin,
+		logger:       log,
+		ctx:          context.Background(),
+	}
+}
+
+// WithContext 返回绑定请求上下文的剧集播放服务
+func (s *playbackService) WithContext(ctx context.Context) PlaybackService {
+	scoped := *s
+	scoped.ctx = ctx
+	scoped.repo = s.repo.WithContext(ctx)
+	scoped.episodes = s.episodes.WithContext(ctx)
+	scoped.assets = s.assets.WithContext(ctx)
+	scoped.files = s.files.WithContext(ctx)
+	if s.keys != nil {
+		scoped.keys = s.keys.WithContext(ctx)
+	}
+	if s.cacheService != nil {
+		scoped.cacheService = s.cacheService.WithContext(ctx)
+		scoped.readThrough = s.readThrough.withContext(ctx, scoped.cacheService)
+	}
+	return &scoped
+}
+
+// Signed 是否签发带签名的播放地址
+func (s *playbackService) Signed() bool {
+	return s.signer != nil
+}
+
+// Sources 获取剧集的播放源，开启签名时先检查观看权限
+func (s *playbackService) Sources(episode *models.Episode, viewer PlaybackViewer) ([]models.PlaybackSource, error) {
+	grant := playbackGrant{ttl: s.ttl}
+	if s.signer != nil {
+		if err := s.checkEntitlement(episode, viewer); err != nil {
+			return nil, err
+		}
+		if s.bindIP {
+			grant.ip = viewer.IP
+		}
+	}
+	return s.sources(episode, grant)
+}
+
+// Preview 生成管理员预览播放源，预览地址不绑定 IP，便于分享给审核人员
+func (s *playbackService) Preview(episodeID, adminID uint) ([]models.PlaybackSource, error) {
+	episode, err := s.episodes.GetByID(episodeID)
+	if err != nil {
+		return nil, fmt.Errorf("查询剧集失败: %w", err)
+	}
+	if episode == nil {
+		return nil, ErrEpisodeNotFound
+	}
+	// 预览地址是持有者令牌，记录签发的管理员以便追溯泄露的地址
+	s.logger.InfoContext(s.ctx, "签发预览地址", slog.Any("episode_id", episodeID), slog.Any("admin_id", adminID))
+	return s.sources(episode, playbackGrant{ttl: s.previewTTL})
+}
+
+// checkEntitlement 签发播放地址前检查观看权限：剧集与所属短剧需已发布，配置 requireLogin 时需要登录
+func (s *playbackService) checkEntitlement(episode *models.Episode, viewer PlaybackViewer) error {
+	if s.requireLogin && viewer.UserID == 0 {
+		return ErrPlaybackLoginRequired
+	}
+	if episode.Status != "published" || (episode.Drama.ID != 0 && episode.Drama.Status != "published") {
+		return ErrPlaybackForbidden
+	}
+	return nil
+}
+
+// sources 生成播放源，开启签名时 HLS 与上传的视频使用签名地址，引用外部地址的视频无法签名，原样返回
+func (s *playbackService) sources(episode *models.Episode, grant playbackGrant) ([]models.PlaybackSource, error) {
+	info, err := s.load(episode.ID, episode.VideoAssetID)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := make([]models.PlaybackSource, 0, 2)
+	if n := len(info.Renditions); n > 0 {
+		top := info.Renditions[n-1]
+		url := fmt.Sprintf("%s/api/episodes/%d/master.m3u8", s.baseURL, episode.ID)
+		if s.signer != nil {
+			token, err := s.sign(episode.ID, hlsScope(episode.ID), grant)
+			if err != nil {
+				return nil, err
+			}
+			url += "?token=" + token
+		}
+		sources = append(sources, models.PlaybackSource{
+			Type:     models.PlaybackTypeHLS,
+			MimeType: hlsMimeType,
+			URL:      url,
+			Width:    top.Width,
+			Height:   top.Height,
+			Bitrate:  top.Bitrate,
+		})
+	}
+
+	switch {
+	case info.Video != nil && episode.Protected:
+		// 受保护的剧集只能通过加密的 HLS 播放
+	case info.Video != nil:
+		url := s.files.GetFileURL(info.Video.StorageKey)
+		if s.signer != nil {
+			token, err := s.sign(episode.ID, info.Video.StorageKey, grant)
+			if err != nil {
+				return nil, err
+			}
+			url = s.mediaURL(token, info.Video.StorageKey)
+		}
+		sources = append(sources, models.PlaybackSource{
+			Type:     models.PlaybackTypeMP4,
+			MimeType: info.Video.ContentType,
+			URL:      url,
+			Width:    info.Video.Width,
+			Height:   info.Video.Height,
+			Bitrate:  info.Video.Bitrate,
+		})
+	case episode.VideoURL != "":
+		// 引用外部地址的视频没有元数据，原样返回
+		sources = append(sources, models.PlaybackSource{
+			Type:     models.PlaybackTypeMP4,
+			MimeType: "video/mp4",
+			URL:      episode.VideoURL,
+		})
+	}
+	return sources, nil
+}
+
+// sign 签发访问 scope 的令牌
+func (s *playbackService) sign(episodeID uint, scope string, grant playbackGrant) (string, error) {
+	token, err := s.signer.Sign(signing.Claims{
+		Episode: episodeID,
+		Scope:   scope,
+		IP:      grant.ip,
+		Expires: time.Now().Add(grant.ttl),
+	})
+	if err != nil {
+		return "", fmt.Errorf("签发播放地址失败: %w", err)
+	}
+	return token, nil
+}
+
+// mediaURL 签名地址，令牌位于路径中，HLS 播放列表中相对路径的分片地址会带上同一令牌
+func (s *playbackService) mediaURL(token, key string) string {
+	return fmt.Sprintf("%s/media/%s/%s", s.baseURL, token, key)
+}
+
+// hlsScope 剧集转码结果的存储路径前缀
+func hlsScope(episodeID uint) string {
+	return fmt.Sprintf("%s/%d/", hlsPrefix, episodeID)
+}
+
+// AuthorizeMedia 校验令牌能否访问文件，只检查访问范围、IP 与有效期
+func (s *playbackService) AuthorizeMedia(token, filePath, ip string) error {
+	if s.signer == nil {
+		return ErrInvalidPlaybackToken
+	}
+	key, err := storage.CleanKey(filePath)
+	if err != nil {
+		return ErrInvalidPlaybackToken
+	}
+	claims, err := s.signer.Verify(token, ip)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPlaybackToken, err)
+	}
+	if !claims.Allows(key) {
+		return ErrInvalidPlaybackToken
+	}
+	return nil
+}
+
+// RequiresToken 开启签名时上传的视频与 HLS 文件只能通过签名地址访问
+func (s *playbackService) RequiresToken(filePath string) bool {
+	if s.signer == nil {
+		return false
+	}
+	key, err := storage.CleanKey(filePath)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(key, uploadSubDir("video")+"/") || strings.HasPrefix(key, hlsPrefix+"/")
+}
+
+// ProtectedSource 未开启签名时同样生效，避免绕过 HLS 加密直接下载原始视频
+func (s *playbackService) ProtectedSource(filePath string) (bool, error) {
+	key, err := storage.CleanKey(filePath)
+	if err != nil || !strings.HasPrefix(key, uploadSubDir("video")+"/") {
+		return false, nil
+	}
+	asset, err := s.assets.GetByKey(key)
+	if err != nil {
+		return false, fmt.Errorf("获取媒体文件失败: %w", err)
+	}
+	if asset == nil {
+		return false, nil
+	}
+	protected, err := s.episodes.ExistsProtectedByVideoAssetID(asset.ID)
+	if err != nil {
+		return false, fmt.Errorf("检查受保护剧集失败: %w", err)
+	}
+	return protected, nil
+}
+
+// ContentKey 获取内容密钥，剧集不存在时同样返回 ErrContentKeyNotFound
+func (s *playbackService) ContentKey(episodeID, keyID uint, viewer PlaybackViewer, visibility Visibility) ([]byte, error) {
+	if s.keys == nil {
+		return nil, ErrContentKeyNotFound
+	}
+	episode, err := s.episodes.GetByIDWithDrama(episodeID)
+	if err != nil {
+		return nil, fmt.Errorf("查询剧集失败: %w", err)
+	}
+	if episode == nil {
+		return nil, ErrContentKeyNotFound
+	}
+	if episode.Drama.ID == 0 || !visibility.coversEpisode(episode) {
+		// 密钥接口始终需要登录，只有可见范围覆盖剧集的预览可以匿名获取
+		if viewer.UserID == 0 {
+			return nil, ErrPlaybackLoginRequired
+		}
+		if err := s.checkEntitlement(episode, viewer); err != nil {
+			return nil, err
+		}
+	}
+	return s.keys.Key(episodeID, keyID)
+}
+
+// MasterPlaylist 生成主播放列表，各档位按码率从低到高排列
+// 媒体播放列表中的分片使用相对路径，因此分片需要与播放列表通过同一地址前缀访问（签名地址、CDN 或本地存储）
+// 签名令牌只在剧集详情检查可见范围后签发，因此开启签名时以令牌代替可见范围检查
+func (s *playbackService) MasterPlaylist(episodeID uint, token, ip string, visibility Visibility) (string, error) {
+	if s.signer != nil {
+		claims, err := s.signer.Verify(token, ip)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrInvalidPlaybackToken, err)
+		}
+		if claims.Episode != episodeID || !claims.Allows(hlsScope(episodeID)) {
+			return "", ErrInvalidPlaybackToken
+		}
+	} else if err := s.checkVisible(episodeID, visibility); err != nil {
+		return "", err
+	}
+
+	info, err := s.load(episodeID, nil)
+	if err != nil {
+		return "", err
+	}
+	if len(info.Renditions) == 0 {
+		return "", ErrPlaylistNotFound
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for _, r := range info.Renditions {
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d,CODECS=\"%s\",NAME=\"%s\"\n",
+			r.Bitrate, r.Width, r.Height, r.Codecs, r.Name)
+		if s.signer != nil {
+			b.WriteString(s.mediaURL(token, r.PlaylistKey))
+		} else {
+			b.WriteString(s.files.GetFileURL(r.PlaylistKey))
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// checkVisible 检查剧集是否在可见范围内：已发布短剧中的已发布剧集对所有人可见，
+// 其他剧集只对管理员与对应的预览令牌可见，已删除的剧集与短剧（预加载结果为空）对所有人不可见
+func (s *playbackService) checkVisible(episodeID uint, visibility Visibility) error {
+	if !visibility.Public() {
+		episode, err := s.episodes.GetByIDWithDrama(episodeID)
+		if err != nil {
+			return fmt.Errorf("查询剧集失败: %w", err)
+		}
+		if episode != nil && episode.Drama.ID != 0 && visibility.coversEpisode(episode) {
+			return nil
+		}
+	}
+
+	episode, err := s.episodes.GetPublishedByIDWithDrama(episodeID)
+	if err != nil {
+		return fmt.Errorf("查询剧集失败: %w", err)
+	}
+	if episode == nil {
+		return ErrContentNotFound
+	}
+	return nil
+}
+
+// load 通过读穿缓存获取剧集的转码结果与视频文件，videoAssetID 为 nil 时不查询视频文件
+func (s *playbackService) load(episodeID uint, videoAssetID *uint) (*playbackInfo, error) {
+	cacheKey := fmt.Sprintf("playback:%d", episodeID)
+	if videoAssetID != nil {
+		cacheKey = fmt.Sprintf("playback:%d:video:%d", episodeID, *videoAssetID)
+	}
+
+	info, err := readThrough(s.readThrough, cacheKey, playbackCachePolicy, func() (*playbackInfo, []string, error) {
+		renditions, err := s.repo.ListRenditions(episodeID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("查询转码结果失败: %w", err)
+		}
+		info := &playbackInfo{Renditions: renditions}
+		if videoAssetID != nil {
+			if info.Video, err = s.assets.GetByID(*videoAssetID); err != nil {
+				return nil, nil, fmt.Errorf("查询视频文件失败: %w", err)
+			}
+		}
+		return info, []string{TagEpisode(episodeID)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
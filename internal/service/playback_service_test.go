@@ -0,0 +1,268 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/signing"
+	"gin-mysql-api/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPlaybackService 基于转码测试依赖创建播放服务，signer 为 nil 时不签名
+func newTestPlaybackService(f *transcodeFixture, cacheService CacheService, signer *signing.Signer, conf PlaybackServiceConfig) PlaybackService {
+	files := NewFileService(f.store, f.assets, nil, FileServiceConfig{BaseURL: "https://api.example.com"}, nil)
+	conf.BaseURL = "https://api.example.com/"
+	return NewPlaybackService(f.repo, f.episodes, f.assets, files, f.keys, cacheService, signer, conf, nil)
+}
+
+// publish 发布剧集及所属短剧，返回包含短剧的剧集
+func (f *transcodeFixture) publish(t *testing.T, episode *models.Episode) *models.Episode {
+	t.Helper()
+	require.NoError(t, f.db.Model(&models.Episode{}).Where("id = ?", episode.ID).Update("status", "published").Error)
+	require.NoError(t, f.db.Model(&models.Drama{}).Where("id = ?", episode.DramaID).Update("status", "published").Error)
+	published, err := f.episodes.GetByIDWithDrama(episode.ID)
+	require.NoError(t, err)
+	return published
+}
+
+func TestPlaybackService(t *testing.T) {
+	f := newTranscodeFixture(t)
+	playback := newTestPlaybackService(f, NewMemoryCacheService(100), nil, PlaybackServiceConfig{})
+	episode := f.createEpisode(t, 720)
+
+	t.Run("尚未转码时只返回原始视频", func(t *testing.T) {
+		assert.False(t, playback.Signed())
+		sources, err := playback.Sources(episode, PlaybackViewer{})
+		require.NoError(t, err)
+		require.Len(t, sources, 1)
+		assert.Equal(t, models.PlaybackTypeMP4, sources[0].Type)
+		assert.Equal(t, "https://api.example.com/uploads/"+episode.VideoURL, sources[0].URL)
+		assert.Equal(t, 720, sources[0].Height)
+		assert.Equal(t, int64(1000000), sources[0].Bitrate)
+
+		_, err = playback.MasterPlaylist(episode.ID, "", "", Visibility{})
+		assert.ErrorIs(t, err, ErrContentNotFound, "未开启签名时未发布的剧集不公开")
+		_, err = playback.MasterPlaylist(episode.ID, "", "", AdminVisibility(1))
+		assert.ErrorIs(t, err, ErrPlaylistNotFound)
+		assert.False(t, playback.RequiresToken(episode.VideoURL), "未开启签名时可以直接访问上传的视频")
+	})
+
+	t.Run("转码完成后返回 HLS 播放源与主播放列表", func(t *testing.T) {
+		cached := NewMemoryCacheService(100)
+		playback := newTestPlaybackService(f, cached, nil, PlaybackServiceConfig{})
+		f.service.cacheService = cached
+		require.NoError(t, f.service.Enqueue(episode))
+		_, err := playback.Sources(episode, PlaybackViewer{}) // 写入缓存，转码完成后应失效
+		require.NoError(t, err)
+		_, err = f.service.ProcessPending()
+		require.NoError(t, err)
+
+		sources, err := playback.Sources(episode, PlaybackViewer{})
+		require.NoError(t, err)
+		require.Len(t, sources, 2)
+		assert.Equal(t, models.PlaybackSource{
+			Type:     models.PlaybackTypeHLS,
+			MimeType: "application/vnd.apple.mpegurl",
+			URL:      fmt.Sprintf("https://api.example.com/api/episodes/%d/master.m3u8", episode.ID),
+			Width:    1280,
+			Height:   720,
+			Bitrate:  2800000,
+		}, sources[0])
+		assert.Equal(t, models.PlaybackTypeMP4, sources[1].Type)
+
+		playlist, err := playback.MasterPlaylist(episode.ID, "", "", AdminVisibility(1))
+		require.NoError(t, err)
+		job := f.job(t, episode.ID)
+		lines := strings.Split(strings.TrimSpace(playlist), "\n")
+		assert.Equal(t, []string{
+			"#EXTM3U",
+			"#EXT-X-VERSION:3",
+			`#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360,CODECS="avc1.4d401f",NAME="360p"`,
+			fmt.Sprintf("https://api.example.com/uploads/hls/%d/%d/360p/index.m3u8", episode.ID, job.ID),
+			`#EXT-X-STREAM-INF:BANDWIDTH=2800000,RESOLUTION=1280x720,CODECS="avc1.4d401f",NAME="720p"`,
+			fmt.Sprintf("https://api.example.com/uploads/hls/%d/%d/720p/index.m3u8", episode.ID, job.ID),
+		}, lines)
+	})
+
+	t.Run("引用外部地址的视频", func(t *testing.T) {
+		external := &models.Episode{ID: 999, VideoURL: "https://example.com/video.mp4"}
+		sources, err := playback.Sources(external, PlaybackViewer{})
+		require.NoError(t, err)
+		assert.Equal(t, []models.PlaybackSource{{Type: models.PlaybackTypeMP4, MimeType: "video/mp4", URL: external.VideoURL}}, sources)
+	})
+}
+
+func TestPlaybackService_Signed(t *testing.T) {
+	f := newTranscodeFixture(t)
+	signer := signing.NewSigner([]signing.Key{{ID: "k1", Secret: []byte(strings.Repeat("s", 32))}})
+	playback := newTestPlaybackService(f, nil, signer, PlaybackServiceConfig{
+		TTL: time.Hour, PreviewTTL: 24 * time.Hour, BindIP: true, RequireLogin: true,
+	})
+	viewer := PlaybackViewer{UserID: 5, Role: "user", IP: "10.0.0.1"}
+
+	draft := f.createEpisode(t, 720)
+	draft, err := f.episodes.GetByIDWithDrama(draft.ID)
+	require.NoError(t, err)
+	episode := f.publish(t, f.createEpisode(t, 720))
+	require.NoError(t, f.service.Enqueue(episode))
+	_, err = f.service.ProcessPending()
+	require.NoError(t, err)
+	job := f.job(t, episode.ID)
+
+	t.Run("检查观看权限", func(t *testing.T) {
+		assert.True(t, playback.Signed())
+		_, err := playback.Sources(episode, PlaybackViewer{IP: viewer.IP})
+		assert.ErrorIs(t, err, ErrPlaybackLoginRequired)
+		_, err = playback.Sources(draft, viewer)
+		assert.ErrorIs(t, err, ErrPlaybackForbidden, "未发布的剧集不签发播放地址")
+	})
+
+	t.Run("签发的地址只能访问该剧集的文件", func(t *testing.T) {
+		sources, err := playback.Sources(episode, viewer)
+		require.NoError(t, err)
+		require.Len(t, sources, 2)
+
+		hls, err := url.Parse(sources[0].URL)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("/api/episodes/%d/master.m3u8", episode.ID), hls.Path)
+		token := hls.Query().Get("token")
+		require.NotEmpty(t, token)
+
+		playlist, err := playback.MasterPlaylist(episode.ID, token, viewer.IP, Visibility{})
+		require.NoError(t, err)
+		assert.Contains(t, playlist, fmt.Sprintf("https://api.example.com/media/%s/hls/%d/%d/360p/index.m3u8", token, episode.ID, job.ID))
+		_, err = playback.MasterPlaylist(episode.ID, token, "10.0.0.2", Visibility{})
+		assert.ErrorIs(t, err, ErrInvalidPlaybackToken, "地址绑定了客户端 IP")
+		_, err = playback.MasterPlaylist(draft.ID, token, viewer.IP, Visibility{})
+		assert.ErrorIs(t, err, ErrInvalidPlaybackToken)
+		_, err = playback.MasterPlaylist(episode.ID, "", viewer.IP, Visibility{})
+		assert.ErrorIs(t, err, ErrInvalidPlaybackToken)
+
+		segment := fmt.Sprintf("hls/%d/%d/720p/segment_0000.ts", episode.ID, job.ID)
+		assert.NoError(t, playback.AuthorizeMedia(token, segment, viewer.IP))
+		assert.ErrorIs(t, playback.AuthorizeMedia(token, fmt.Sprintf("hls/%d/1/720p/index.m3u8", draft.ID), viewer.IP), ErrInvalidPlaybackToken)
+		assert.ErrorIs(t, playback.AuthorizeMedia(token, fmt.Sprintf("hls/%d/../%d/index.m3u8", episode.ID, draft.ID), viewer.IP), ErrInvalidPlaybackToken)
+		assert.ErrorIs(t, playback.AuthorizeMedia(token, episode.VideoURL, viewer.IP), ErrInvalidPlaybackToken)
+
+		prefix := "https://api.example.com/media/"
+		require.True(t, strings.HasPrefix(sources[1].URL, prefix))
+		videoToken, key, _ := strings.Cut(strings.TrimPrefix(sources[1].URL, prefix), "/")
+		assert.Equal(t, episode.VideoURL, key)
+		assert.NoError(t, playback.AuthorizeMedia(videoToken, key, viewer.IP))
+		assert.ErrorIs(t, playback.AuthorizeMedia(videoToken, draft.VideoURL, viewer.IP), ErrInvalidPlaybackToken)
+	})
+
+	t.Run("只有视频与 HLS 文件需要签名", func(t *testing.T) {
+		assert.True(t, playback.RequiresToken(episode.VideoURL))
+		assert.True(t, playback.RequiresToken(fmt.Sprintf("hls/%d/%d/360p/index.m3u8", episode.ID, job.ID)))
+		assert.False(t, playback.RequiresToken("images/cover.jpg"))
+	})
+
+	t.Run("管理员预览未发布的剧集", func(t *testing.T) {
+		sources, err := playback.Preview(draft.ID, 1)
+		require.NoError(t, err)
+		require.Len(t, sources, 1)
+		token, key, _ := strings.Cut(strings.TrimPrefix(sources[0].URL, "https://api.example.com/media/"), "/")
+		assert.Equal(t, draft.VideoURL, key)
+		assert.NoError(t, playback.AuthorizeMedia(token, key, "192.168.1.1"), "预览地址不绑定 IP")
+
+		_, err = playback.Preview(99999, 1)
+		assert.ErrorIs(t, err, ErrEpisodeNotFound)
+	})
+}
+
+func TestPlaybackService_ContentKey(t *testing.T) {
+	f := newTranscodeFixture(t)
+	playback := newTestPlaybackService(f, nil, nil, PlaybackServiceConfig{RequireLogin: true})
+	viewer := PlaybackViewer{UserID: 5, Role: "user"}
+
+	protect := func(episode *models.Episode) uint {
+		episode.Protected = true
+		require.NoError(t, f.db.Model(episode).Update("protected", true).Error)
+		require.NoError(t, f.service.Enqueue(episode))
+		_, err := f.service.ProcessPending()
+		require.NoError(t, err)
+		requests := f.executor.requests
+		return keyIDFromURI(t, requests[len(requests)-1].Encryption.KeyURI)
+	}
+	episode := f.publish(t, f.createEpisode(t, 720))
+	keyID := protect(episode)
+	draft, err := f.episodes.GetByIDWithDrama(f.createEpisode(t, 720).ID)
+	require.NoError(t, err)
+	draftKeyID := protect(draft)
+
+	t.Run("受保护的剧集只返回 HLS 播放源", func(t *testing.T) {
+		sources, err := playback.Sources(episode, viewer)
+		require.NoError(t, err)
+		require.Len(t, sources, 1)
+		assert.Equal(t, models.PlaybackTypeHLS, sources[0].Type)
+	})
+
+	t.Run("检查观看权限后返回密钥", func(t *testing.T) {
+		key, err := playback.ContentKey(episode.ID, keyID, viewer, Visibility{})
+		require.NoError(t, err)
+		assert.Len(t, key, 16)
+
+		_, err = playback.ContentKey(episode.ID, keyID, PlaybackViewer{}, Visibility{})
+		assert.ErrorIs(t, err, ErrPlaybackLoginRequired)
+		_, err = playback.ContentKey(draft.ID, draftKeyID, viewer, Visibility{})
+		assert.ErrorIs(t, err, ErrPlaybackForbidden, "未发布的剧集不返回密钥")
+		_, err = playback.ContentKey(draft.ID, draftKeyID, PlaybackViewer{UserID: 1, Role: "admin"}, AdminVisibility(1))
+		assert.NoError(t, err, "管理员可以预览未发布的剧集")
+	})
+
+	t.Run("审核人员通过预览令牌播放受保护的草稿", func(t *testing.T) {
+		preview := NewPreviewService(repository.NewDramaRepository(f.db), f.episodes, utils.NewJWTManager("test-secret", time.Hour), "https://api.example.com", time.Hour, nil)
+		token, err := preview.IssueToken(1, models.ReviewEntityEpisode, draft.ID)
+		require.NoError(t, err)
+		visibility, err := preview.Visibility(token.Token)
+		require.NoError(t, err)
+
+		playlist, err := playback.MasterPlaylist(draft.ID, "", "", visibility)
+		require.NoError(t, err)
+		assert.Contains(t, playlist, "720p/index.m3u8")
+		key, err := playback.ContentKey(draft.ID, draftKeyID, PlaybackViewer{}, visibility)
+		require.NoError(t, err, "未登录的审核人员同样可以获取密钥")
+		assert.Len(t, key, 16)
+
+		_, err = playback.ContentKey(episode.ID, keyID, PlaybackViewer{}, visibility)
+		assert.ErrorIs(t, err, ErrPlaybackLoginRequired, "预览令牌只覆盖指定的剧集")
+	})
+
+	t.Run("未开启签名时不公开受保护剧集的原始视频", func(t *testing.T) {
+		dramas := NewDramaService(repository.NewDramaRepository(f.db), f.episodes, NewMemoryCacheService(100), playback, nil)
+		result, err := dramas.GetEpisodesByDramaID(episode.DramaID, 1, 20)
+		require.NoError(t, err)
+		require.Len(t, result.Episodes, 1)
+		assert.Empty(t, result.Episodes[0].VideoURL)
+		drama, err := dramas.GetDramaWithEpisodes(episode.DramaID)
+		require.NoError(t, err)
+		require.Len(t, drama.Episodes, 1)
+		assert.Empty(t, drama.Episodes[0].VideoURL)
+
+		protected, err := playback.ProtectedSource(episode.VideoURL)
+		require.NoError(t, err)
+		assert.True(t, protected, "受保护剧集的原始视频不能通过 /uploads 直接访问")
+		protected, err = playback.ProtectedSource(f.createEpisode(t, 720).VideoURL)
+		require.NoError(t, err)
+		assert.False(t, protected)
+		protected, err = playback.ProtectedSource("images/cover.jpg")
+		require.NoError(t, err)
+		assert.False(t, protected)
+	})
+
+	t.Run("密钥不属于该剧集", func(t *testing.T) {
+		_, err := playback.ContentKey(episode.ID, draftKeyID, viewer, Visibility{})
+		assert.ErrorIs(t, err, ErrContentKeyNotFound)
+		_, err = playback.ContentKey(99999, keyID, viewer, Visibility{})
+		assert.ErrorIs(t, err, ErrContentKeyNotFound)
+	})
+}
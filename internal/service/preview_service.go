@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/utils"
+)
+
+// ErrInvalidPreviewToken 预览令牌无效或已过期
+var ErrInvalidPreviewToken = errors.New("预览令牌无效或已过期")
+
+// Visibility 调用方在前台接口中可以查看的内容范围，零值为公开访问：只能查看已发布的短剧与剧集
+// 短剧列表、搜索与热门榜单始终只包含已发布的短剧
+type Visibility struct {
+	// All 管理员可以查看所有状态的短剧与剧集
+	All bool
+	// DramaID 预览令牌允许查看的短剧及其所有剧集
+	DramaID uint
+	// EpisodeID 预览令牌允许查看的剧集
+	EpisodeID uint
+	// AdminID 管理员或签发预览令牌的管理员，预览未发布剧集的播放地址签发给该管理员
+	AdminID uint
+}
+
+// AdminVisibility 管理员的可见范围
+func AdminVisibility(adminID uint) Visibility {
+	return Visibility{All: true, AdminID: adminID}
+}
+
+// Public 是否只能查看已发布的内容
+func (v Visibility) Public() bool {
+	return !v.All && v.DramaID == 0 && v.EpisodeID == 0
+}
+
+// coversDrama 是否可以查看短剧及其所有剧集，不论状态
+func (v Visibility) coversDrama(id uint) bool {
+	return v.All || (v.DramaID != 0 && v.DramaID == id)
+}
+
+// coversEpisode 是否可以查看剧集，不论剧集与所属短剧的状态
+func (v Visibility) coversEpisode(episode *models.Episode) bool {
+	return v.coversDrama(episode.DramaID) || (v.EpisodeID != 0 && v.EpisodeID == episode.ID)
+}
+
+// PreviewService 内容预览服务接口
+// 管理员为未发布的短剧或剧集签发预览令牌，审核人员无需登录即可通过前台接口查看
+type PreviewService interface {
+	// WithContext 返回绑定请求上下文的服务
+	WithContext(ctx context.Context) PreviewService
+	// IssueToken 为短剧（含所有剧集）或剧集签发预览令牌，内容不存在时返回 ErrContentNotFound
+	IssueToken(adminID uint, entityType string, id uint) (*models.PreviewTokenResponse, error)
+	// Visibility 校验预览令牌并返回令牌允许查看的范围，令牌无效时返回 ErrInvalidPreviewToken
+	Visibility(token string) (Visibility, error)
+}
+
+// previewService 内容预览服务实现
+type previewService struct {
+	dramaRepo   repository.DramaRepository
+	episodeRepo repository.EpisodeRepository
+	jwtManager  *utils.JWTManager
+	baseURL     string
+	ttl         time.Duration
+	logger      *slog.Logger
+	ctx         context.Context
+}
+
+// NewPreviewService 创建内容预览服务，预览令牌的有效期为 ttl，log 为 nil 时使用全局默认 Logger
+func NewPreviewService(
+	dramaRepo repository.DramaRepository,
+	episodeRepo repository.EpisodeRepository,
+	jwtManager *utils.JWTManager,
+	baseURL string,
+	ttl time.Duration,
+	log *slog.Logger,
+) PreviewService {
+	return &previewService{
+		dramaRepo:   dramaRepo,
+		episodeRepo: episodeRepo,
+		jwtManager:  jwtManager,
+		baseURL:     baseURL,
+		ttl:         ttl,
+		logger:      logger.OrDefault(log),
+		ctx:         context.Background(),
+	}
+}
+
+// WithContext 返回绑定请求上下文的内容预览服务
+func (s *previewService) WithContext(ctx context.Context) PreviewService {
+	scoped := *s
+	scoped.ctx = ctx
+	scoped.dramaRepo = s.dramaRepo.WithContext(ctx)
+	scoped.episodeRepo = s.episodeRepo.WithContext(ctx)
+	return &scoped
+}
+
+// IssueToken 签发预览令牌，短剧的预览地址为短剧及剧集列表，剧集的预览地址为剧集详情
+func (s *previewService) IssueToken(adminID uint, entityType string, id uint) (*models.PreviewTokenResponse, error) {
+	var dramaID, episodeID uint
+	var path string
+	switch entityType {
+	case models.ReviewEntityDrama:
+		drama, err := s.dramaRepo.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("查询短剧失败: %w", err)
+		}
+		if drama == nil {
+			return nil, ErrContentNotFound
+		}
+		dramaID, path = id, fmt.Sprintf("/api/dramas/%d/episodes", id)
+	case models.ReviewEntityEpisode:
+		episode, err := s.episodeRepo.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("查询剧集失败: %w", err)
+		}
+		if episode == nil {
+			return nil, ErrContentNotFound
+		}
+		episodeID, path = id, fmt.Sprintf("/api/episodes/%d", id)
+	default:
+		return nil, fmt.Errorf("不支持的内容类型: %s", entityType)
+	}
+
+	token, expiresAt, err := s.jwtManager.GeneratePreviewToken(adminID, dramaID, episodeID, s.ttl)
+	if err != nil {
+		return nil, fmt.Errorf("签发预览令牌失败: %w", err)
+	}
+
+	s.logger.InfoContext(s.ctx, "预览令牌已签发", slog.String("entity_type", entityType), slog.Any("entity_id", id),
+		slog.Any("admin_id", adminID), slog.Time("expires_at", expiresAt))
+	return &models.PreviewTokenResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		URL:       s.baseURL + path + "?preview_token=" + url.QueryEscape(token),
+	}, nil
+}
+
+// Visibility 校验预览令牌
+func (s *previewService) Visibility(token string) (Visibility, error) {
+	claims, err := s.jwtManager.VerifyPreviewToken(token)
+	if err != nil {
+		return Visibility{}, fmt.Errorf("%w: %v", ErrInvalidPreviewToken, err)
+	}
+	return Visibility{DramaID: claims.DramaID, EpisodeID: claims.EpisodeID, AdminID: claims.AdminID}, nil
+}
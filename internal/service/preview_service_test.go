@@ -0,0 +1,111 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewService_IssueToken(t *testing.T) {
+	f := newPublishFixture(t)
+	previews := NewPreviewService(f.dramas, f.episodes, utils.NewJWTManager("test-secret", time.Hour), "https://example.com", time.Hour, nil)
+	drama := f.createDrama(t, models.StatusDraft, nil)
+	episode := f.createEpisodes(t, drama.ID, 1)[0]
+
+	t.Run("签发与校验短剧预览令牌", func(t *testing.T) {
+		token, err := previews.IssueToken(3, models.ReviewEntityDrama, drama.ID)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(token.URL, "https://example.com/api/dramas/"), token.URL)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), token.ExpiresAt, time.Minute)
+
+		visibility, err := previews.Visibility(token.Token)
+		require.NoError(t, err)
+		assert.Equal(t, Visibility{DramaID: drama.ID, AdminID: 3}, visibility)
+		assert.False(t, visibility.Public())
+	})
+
+	t.Run("签发剧集预览令牌", func(t *testing.T) {
+		token, err := previews.IssueToken(3, models.ReviewEntityEpisode, episode.ID)
+		require.NoError(t, err)
+		visibility, err := previews.Visibility(token.Token)
+		require.NoError(t, err)
+		assert.Equal(t, Visibility{EpisodeID: episode.ID, AdminID: 3}, visibility)
+	})
+
+	t.Run("内容不存在或令牌无效", func(t *testing.T) {
+		_, err := previews.IssueToken(3, models.ReviewEntityDrama, 99999)
+		assert.ErrorIs(t, err, ErrContentNotFound)
+
+		_, err = previews.Visibility("invalid.token")
+		assert.ErrorIs(t, err, ErrInvalidPreviewToken)
+		login, err := utils.NewJWTManager("test-secret", time.Hour).GenerateToken(3, "admin", "admin")
+		require.NoError(t, err)
+		_, err = previews.Visibility(login)
+		assert.ErrorIs(t, err, ErrInvalidPreviewToken, "登录令牌不能当作预览令牌")
+	})
+}
+
+func TestDramaService_WithVisibility(t *testing.T) {
+	f := newPublishFixture(t)
+	dramas := NewDramaService(f.dramas, f.episodes, NewMemoryCacheService(100), nil, nil)
+	draft := f.createDrama(t, models.StatusDraft, nil)
+	draftEpisode := f.createEpisodes(t, draft.ID, 1)[0]
+	published := f.createDrama(t, models.StatusPublished, nil)
+	episodes := f.createEpisodes(t, published.ID, 2)
+	require.NoError(t, f.db.Model(episodes[0]).Update("status", models.StatusPublished).Error)
+
+	t.Run("公开访问只能查看已发布的内容", func(t *testing.T) {
+		_, err := dramas.GetDramaByID(draft.ID)
+		assert.Error(t, err)
+		_, err = dramas.GetEpisodeByID(draftEpisode.ID, PlaybackViewer{})
+		assert.Error(t, err)
+
+		list, err := dramas.GetEpisodesByDramaID(published.ID, 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), list.Total)
+		_, err = dramas.GetEpisodeByID(episodes[1].ID, PlaybackViewer{})
+		assert.Error(t, err, "已发布短剧中的草稿剧集")
+	})
+
+	t.Run("管理员可以查看所有内容且不使用公开缓存", func(t *testing.T) {
+		admin := dramas.WithVisibility(AdminVisibility(1))
+		drama, err := admin.GetDramaByID(draft.ID)
+		require.NoError(t, err)
+		assert.Equal(t, draft.ID, drama.ID)
+
+		list, err := admin.GetEpisodesByDramaID(published.ID, 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), list.Total)
+
+		_, err = dramas.GetDramaByID(draft.ID)
+		assert.Error(t, err, "管理员的查询结果不写入公开缓存")
+	})
+
+	t.Run("预览令牌只能查看指定的内容", func(t *testing.T) {
+		preview := dramas.WithVisibility(Visibility{DramaID: draft.ID, AdminID: 1})
+		drama, err := preview.GetDramaWithEpisodes(draft.ID)
+		require.NoError(t, err)
+		require.Len(t, drama.Episodes, 1)
+		detail, err := preview.GetEpisodeByID(draftEpisode.ID, PlaybackViewer{})
+		require.NoError(t, err)
+		assert.Equal(t, draftEpisode.ID, detail.ID)
+
+		list, err := preview.GetEpisodesByDramaID(published.ID, 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), list.Total, "其他短剧仍只返回已发布的剧集")
+
+		episodePreview := dramas.WithVisibility(Visibility{EpisodeID: draftEpisode.ID, AdminID: 1})
+		_, err = episodePreview.GetEpisodeByID(draftEpisode.ID, PlaybackViewer{})
+		require.NoError(t, err)
+		_, err = episodePreview.GetDramaByID(draft.ID)
+		assert.Error(t, err, "剧集预览令牌不能查看未发布的短剧")
+		_, err = episodePreview.GetEpisodeByID(episodes[1].ID, PlaybackViewer{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/notify"
+)
+
+// 发布通知的事件类型
+const (
+	EventDramaPublished   = "drama.published"
+	EventEpisodePublished = "episode.published"
+)
+
+const (
+	// publishBatchSize 每批发布的数量
+	publishBatchSize = 100
+	// maxCalendarDays 发布日历一次最多查询的天数
+	maxCalendarDays = 92
+	// calendarDateLayout 排期与发布日历的日期格式
+	calendarDateLayout = "2006-01-02"
+)
+
+// PublishService 定时发布服务接口
+type PublishService interface {
+	// WithContext 返回绑定请求上下文的服务
+	WithContext(ctx context.Context) PublishService
+	// PublishDue 发布到期的短剧与剧集，失效相关缓存并发送通知，返回发布的数量
+	// 多个实例同时执行时每个条目只会被其中一个实例发布
+	PublishDue() (int, error)
+	// ScheduleEpisodes 由管理员按每天固定时间批量排期短剧的剧集，只能排期审核通过或已排期的剧集
+	ScheduleEpisodes(dramaID, adminID uint, req models.ScheduleEpisodesRequest) ([]models.Episode, error)
+	// Calendar 获取 [from, to] 日期内已排期与已发布的短剧和剧集，日期为空时从今天起查询 7 天
+	Calendar(from, to string) (*models.ReleaseCalendar, error)
+}
+
+// publishService 定时发布服务实现
+type publishService struct {
+	adminRepo    repository.AdminRepository
+	dramaRepo    repository.DramaRepository
+	episodeRepo  repository.EpisodeRepository
+	reviewRepo   repository.ReviewRepository
+	cacheService CacheService
+	notifier     notify.Notifier
+	loc          *time.Location
+	logger       *slog.Logger
+	ctx          context.Context
+}
+
+// NewPublishService 创建定时发布服务，loc 为排期与发布日历使用的时区
+// cacheService 为 nil 时不失效缓存，notifier 为 nil 时不发送通知；log 为 nil 时使用全局默认 Logger
+func NewPublishService(
+	adminRepo repository.AdminRepository,
+	dramaRepo repository.DramaRepository,
+	episodeRepo repository.EpisodeRepository,
+	reviewRepo repository.ReviewRepository,
+	cacheService CacheService,
+	notifier notify.Notifier,
+	loc *time.Location,
+	log *slog.Logger,
+) PublishService {
+	return &publishService{
+		adminRepo:    adminRepo,
+		dramaRepo:    dramaRepo,
+		episodeRepo:  episodeRepo,
+		reviewRepo:   reviewRepo,
+		cacheService: cacheService,
+		notifier:     notifier,
+		loc:          loc,
+		logger:       logger.OrDefault(log),
+		ctx:          context.Background(),
+	}
+}
+
+// WithContext 返回绑定请求上下文的定时发布服务
+func (s *publishService) WithContext(ctx context.Context) PublishService {
+	scoped := *s
+	scoped.ctx = ctx
+	scoped.adminRepo = s.adminRepo.WithContext(ctx)
+	scoped.dramaRepo = s.dramaRepo.WithContext(ctx)
+	scoped.episodeRepo = s.episodeRepo.WithContext(ctx)
+	scoped.reviewRepo = s.reviewRepo.WithContext(ctx)
+	if s.cacheService != nil {
+		scoped.cacheService = s.cacheService.WithContext(ctx)
+	}
+	return &scoped
+}
+
+// PublishDue 先发布短剧再发布剧集，同一时间上线的短剧与剧集同时可见
+func (s *publishService) PublishDue() (int, error) {
+	now := time.Now()
+	published := 0
+
+	for s.ctx.Err() == nil {
+		dramas, err := s.dramaRepo.ListDue(now, publishBatchSize)
+		if err != nil {
+			return published, fmt.Errorf("查询到期的短剧失败: %w", err)
+		}
+		for i := range dramas {
+			drama := &dramas[i]
+			ok, err := s.dramaRepo.Publish(drama.ID)
+			if err != nil {
+				return published, fmt.Errorf("发布短剧失败: %w", err)
+			}
+			if !ok {
+				continue
+			}
+			published++
+			drama.Status = models.StatusPublished
+			s.invalidateCache(TagDrama(drama.ID), TagDramaList, TagCategory(drama.Category))
+			s.logger.InfoContext(s.ctx, "短剧已定时发布", slog.Any("drama_id", drama.ID))
+			s.notify(EventDramaPublished, dramaReleaseItem(drama))
+		}
+		if len(dramas) < publishBatchSize {
+			break
+		}
+	}
+
+	for s.ctx.Err() == nil {
+		episodes, err := s.episodeRepo.ListDue(now, publishBatchSize)
+		if err != nil {
+			return published, fmt.Errorf("查询到期的剧集失败: %w", err)
+		}
+		for i := range episodes {
+			episode := &episodes[i]
+			ok, err := s.episodeRepo.Publish(episode.ID)
+			if err != nil {
+				return published, fmt.Errorf("发布剧集失败: %w", err)
+			}
+			if !ok {
+				continue
+			}
+			published++
+			episode.Status = models.StatusPublished
+			s.invalidateCache(TagEpisode(episode.ID), TagDrama(episode.DramaID))
+			s.logger.InfoContext(s.ctx, "剧集已定时发布", slog.Any("episode_id", episode.ID), slog.Any("drama_id", episode.DramaID))
+			s.notify(EventEpisodePublished, episodeReleaseItem(episode))
+		}
+		if len(episodes) < publishBatchSize {
+			break
+		}
+	}
+	return published, nil
+}
+
+// ScheduleEpisodes 第 i 个剧集（按剧集号排列）在第 i/per_day 个发布日的指定时间发布
+// 所有剧集在一个事务中排期并记录状态流转，任一剧集的状态已被其他操作变更时全部不排期
+func (s *publishService) ScheduleEpisodes(dramaID, adminID uint, req models.ScheduleEpisodesRequest) ([]models.Episode, error) {
+	admin, err := loadReviewer(s.adminRepo, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTransition(admin.Role, models.StatusApproved, models.StatusScheduled); err != nil {
+		return nil, err
+	}
+
+	drama, err := s.dramaRepo.GetByID(dramaID)
+	if err != nil {
+		return nil, fmt.Errorf("查询短剧失败: %w", err)
+	}
+	if drama == nil {
+		return nil, errors.New("短剧不存在")
+	}
+
+	start, err := time.ParseInLocation(calendarDateLayout+" 15:04", req.StartDate+" "+req.Time, s.loc)
+	if err != nil {
+		return nil, errors.New("start_date 或 time 格式错误")
+	}
+	if !start.After(time.Now()) {
+		return nil, errors.New("第一次发布时间必须晚于当前时间")
+	}
+	interval := req.IntervalDays
+	if interval <= 0 {
+		interval = 1
+	}
+
+	episodes, err := s.episodeRepo.ListByEpisodeNumRange(dramaID, req.FromEpisode, req.ToEpisode)
+	if err != nil {
+		return nil, fmt.Errorf("查询剧集失败: %w", err)
+	}
+	if len(episodes) == 0 {
+		return nil, fmt.Errorf("第 %d-%d 集不存在", req.FromEpisode, req.ToEpisode)
+	}
+	var unapproved []string
+	for _, episode := range episodes {
+		if episode.Status != models.StatusApproved && episode.Status != models.StatusScheduled {
+			unapproved = append(unapproved, strconv.Itoa(episode.EpisodeNum))
+		}
+	}
+	if len(unapproved) > 0 {
+		return nil, fmt.Errorf("第 %s 集不是审核通过或已排期的状态，不能排期", strings.Join(unapproved, "、"))
+	}
+
+	tags := []string{TagDrama(dramaID)}
+	records := make([]models.StatusTransition, len(episodes))
+	for i := range episodes {
+		// AddDate 按日历日计算，夏令时切换前后仍在当地的同一时刻发布
+		publishAt := start.AddDate(0, 0, i/req.PerDay*interval).UTC()
+		records[i] = models.StatusTransition{
+			EntityType: models.ReviewEntityEpisode,
+			EntityID:   episodes[i].ID,
+			FromStatus: episodes[i].Status,
+			ToStatus:   models.StatusScheduled,
+			Comment:    "批量排期",
+			PublishAt:  &publishAt,
+			AdminID:    &admin.ID,
+			AdminName:  admin.Username,
+		}
+		episodes[i].Status = models.StatusScheduled
+		episodes[i].PublishAt = &publishAt
+		tags = append(tags, TagEpisode(episodes[i].ID))
+	}
+	ok, err := s.reviewRepo.Transition(records)
+	if err != nil {
+		return nil, fmt.Errorf("保存排期失败: %w", err)
+	}
+	if !ok {
+		return nil, ErrStatusConflict
+	}
+	s.invalidateCache(tags...)
+
+	s.logger.InfoContext(s.ctx, "剧集已批量排期", slog.Any("drama_id", dramaID), slog.Int("count", len(episodes)),
+		slog.Time("first", *episodes[0].PublishAt), slog.Time("last", *episodes[len(episodes)-1].PublishAt))
+	return episodes, nil
+}
+
+// Calendar 按发布日期分组，同一时刻的短剧排在剧集之前
+func (s *publishService) Calendar(from, to string) (*models.ReleaseCalendar, error) {
+	start, end, err := s.calendarRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	dramas, err := s.dramaRepo.ListPublishBetween(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("查询短剧排期失败: %w", err)
+	}
+	episodes, err := s.episodeRepo.ListPublishBetween(start, end)
+	if err != nil {
+		return nil, fmt.Errorf("查询剧集排期失败: %w", err)
+	}
+
+	items := make([]models.ReleaseItem, 0, len(dramas)+len(episodes))
+	for i := range dramas {
+		items = append(items, dramaReleaseItem(&dramas[i]))
+	}
+	for i := range episodes {
+		items = append(items, episodeReleaseItem(&episodes[i]))
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].PublishAt.Before(items[j].PublishAt) })
+
+	calendar := &models.ReleaseCalendar{Timezone: s.loc.String(), Days: []models.ReleaseDay{}}
+	for _, item := range items {
+		item.PublishAt = item.PublishAt.In(s.loc)
+		date := item.PublishAt.Format(calendarDateLayout)
+		if n := len(calendar.Days); n == 0 || calendar.Days[n-1].Date != date {
+			calendar.Days = append(calendar.Days, models.ReleaseDay{Date: date})
+		}
+		day := &calendar.Days[len(calendar.Days)-1]
+		day.Items = append(day.Items, item)
+	}
+	return calendar, nil
+}
+
+// calendarRange 将日期范围转换为 [start, end) 时间范围
+func (s *publishService) calendarRange(from, to string) (time.Time, time.Time, error) {
+	var start time.Time
+	if from == "" {
+		now := time.Now().In(s.loc)
+		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.loc)
+	} else {
+		var err error
+		if start, err = time.ParseInLocation(calendarDateLayout, from, s.loc); err != nil {
+			return time.Time{}, time.Time{}, errors.New("from 格式必须为 2006-01-02")
+		}
+	}
+
+	last := start.AddDate(0, 0, 6)
+	if to != "" {
+		var err error
+		if last, err = time.ParseInLocation(calendarDateLayout, to, s.loc); err != nil {
+			return time.Time{}, time.Time{}, errors.New("to 格式必须为 2006-01-02")
+		}
+	}
+	if last.Before(start) {
+		return time.Time{}, time.Time{}, errors.New("to 不能早于 from")
+	}
+	end := last.AddDate(0, 0, 1)
+	if end.After(start.AddDate(0, 0, maxCalendarDays)) {
+		return time.Time{}, time.Time{}, fmt.Errorf("一次最多查询 %d 天", maxCalendarDays)
+	}
+	return start, end, nil
+}
+
+// notify 发送发布通知，失败只记录日志
+func (s *publishService) notify(eventType string, item models.ReleaseItem) {
+	if s.notifier == nil {
+		return
+	}
+	event := notify.Event{Type: eventType, OccurredAt: time.Now().UTC(), Data: item}
+	if err := s.notifier.Notify(s.ctx, event); err != nil {
+		s.logger.WarnContext(s.ctx, "发送发布通知失败", slog.String("type", eventType), slog.Any("id", item.ID), slog.String("error", err.Error()))
+	}
+}
+
+// invalidateCache 失效缓存标签，失败只记录日志：缓存会在 TTL 到期后自然过期
+func (s *publishService) invalidateCache(tags ...string) {
+	if s.cacheService == nil {
+		return
+	}
+	if err := s.cacheService.InvalidateTag(tags...); err != nil {
+		s.logger.WarnContext(s.ctx, "缓存失效失败", slog.Any("tags", tags), slog.String("error", err.Error()))
+	}
+}
+
+// dramaReleaseItem 短剧的发布日历条目
+func dramaReleaseItem(drama *models.Drama) models.ReleaseItem {
+	item := models.ReleaseItem{
+		Type:       models.ReleaseTypeDrama,
+		ID:         drama.ID,
+		DramaID:    drama.ID,
+		DramaTitle: drama.Title,
+		Title:      drama.Title,
+		Status:     drama.Status,
+	}
+	if drama.PublishAt != nil {
+		item.PublishAt = *drama.PublishAt
+	}
+	return item
+}
+
+// episodeReleaseItem 剧集的发布日历条目，需要预加载短剧
+func episodeReleaseItem(episode *models.Episode) models.ReleaseItem {
+	item := models.ReleaseItem{
+		Type:       models.ReleaseTypeEpisode,
+		ID:         episode.ID,
+		DramaID:    episode.DramaID,
+		DramaTitle: episode.Drama.Title,
+		Title:      episode.Title,
+		EpisodeNum: episode.EpisodeNum,
+		Status:     episode.Status,
+	}
+	if episode.PublishAt != nil {
+		item.PublishAt = *episode.PublishAt
+	}
+	return item
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/internal/testutil"
+	"gin-mysql-api/pkg/notify"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// recordingNotifier 记录发送的事件
+type recordingNotifier struct {
+	mu     sync.Mutex
+	events []notify.Event
+	err    error
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, event notify.Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.events = append(n.events, event)
+	return n.err
+}
+
+// publishFixture 定时发布服务测试依赖
+type publishFixture struct {
+	db       *gorm.DB
+	admins   repository.AdminRepository
+	dramas   repository.DramaRepository
+	episodes repository.EpisodeRepository
+	reviews  repository.ReviewRepository
+}
+
+func newPublishFixture(t *testing.T) *publishFixture {
+	t.Helper()
+	db := testutil.SetupTestDB()
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return &publishFixture{
+		db:       db,
+		admins:   repository.NewAdminRepository(db),
+		dramas:   repository.NewDramaRepository(db),
+		episodes: repository.NewEpisodeRepository(db),
+		reviews:  repository.NewReviewRepository(db),
+	}
+}
+
+// publisher 创建使用测试仓库的定时发布服务
+func (f *publishFixture) publisher(cacheService CacheService, notifier notify.Notifier, loc *time.Location) PublishService {
+	return NewPublishService(f.admins, f.dramas, f.episodes, f.reviews, cacheService, notifier, loc, nil)
+}
+
+// createAdmin 创建指定角色的管理员
+func (f *publishFixture) createAdmin(t *testing.T, username, role string) *models.Admin {
+	t.Helper()
+	admin := testutil.NewAdminFactory().CreateAdmin(func(a *models.Admin) {
+		a.Username = username
+		a.Email = username + "@example.com"
+		a.Role = role
+	})
+	require.NoError(t, f.admins.Create(admin))
+	return admin
+}
+
+// createDrama 创建指定状态与发布时间的短剧
+func (f *publishFixture) createDrama(t *testing.T, status string, publishAt *time.Time) *models.Drama {
+	t.Helper()
+	drama := testutil.NewDramaFactory().CreateDrama(func(d *models.Drama) {
+		d.Status = status
+		d.PublishAt = publishAt
+	})
+	require.NoError(t, f.dramas.Create(drama))
+	return drama
+}
+
+// createEpisodes 为短剧创建第 1-count 集草稿
+func (f *publishFixture) createEpisodes(t *testing.T, dramaID uint, count int) []*models.Episode {
+	t.Helper()
+	episodes := testutil.NewEpisodeFactory().CreateEpisodes(dramaID, count)
+	for _, episode := range episodes {
+		episode.Status = "draft"
+		require.NoError(t, f.episodes.Create(episode))
+	}
+	return episodes
+}
+
+func TestPublishService_PublishDue(t *testing.T) {
+	f := newPublishFixture(t)
+	cached := NewMemoryCacheService(100)
+	notifier := &recordingNotifier{err: errors.New("webhook 不可用")}
+	publisher := f.publisher(cached, notifier, time.UTC)
+
+	past := time.Now().Add(-time.Minute).UTC()
+	future := time.Now().Add(time.Hour).UTC()
+	due := f.createDrama(t, "scheduled", &past)
+	later := f.createDrama(t, "scheduled", &future)
+	episodes := f.createEpisodes(t, due.ID, 2)
+	require.NoError(t, f.db.Model(episodes[0]).Updates(map[string]interface{}{"status": "scheduled", "publish_at": past}).Error)
+	require.NoError(t, f.db.Model(episodes[1]).Updates(map[string]interface{}{"status": "scheduled", "publish_at": future}).Error)
+	require.NoError(t, cached.SetJSONWithTags("drama", due, time.Minute, TagDrama(due.ID)))
+
+	t.Run("发布到期的短剧与剧集", func(t *testing.T) {
+		published, err := publisher.PublishDue()
+		require.NoError(t, err, "通知失败不影响发布")
+		assert.Equal(t, 2, published)
+
+		drama, err := f.dramas.GetByID(due.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "published", drama.Status)
+		drama, err = f.dramas.GetByID(later.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "scheduled", drama.Status, "未到发布时间")
+		episode, err := f.episodes.GetByID(episodes[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "published", episode.Status)
+		episode, err = f.episodes.GetByID(episodes[1].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "scheduled", episode.Status)
+
+		var value models.Drama
+		assert.Error(t, cached.GetJSON("drama", &value), "发布后失效短剧缓存")
+
+		require.Len(t, notifier.events, 2)
+		assert.Equal(t, EventDramaPublished, notifier.events[0].Type)
+		assert.Equal(t, EventEpisodePublished, notifier.events[1].Type)
+		item := notifier.events[1].Data.(models.ReleaseItem)
+		assert.Equal(t, episodes[0].ID, item.ID)
+		assert.Equal(t, due.Title, item.DramaTitle)
+	})
+
+	t.Run("已发布的条目不重复发布", func(t *testing.T) {
+		published, err := publisher.PublishDue()
+		require.NoError(t, err)
+		assert.Zero(t, published)
+		assert.Len(t, notifier.events, 2)
+	})
+}
+
+func TestPublishService_ScheduleEpisodes(t *testing.T) {
+	f := newPublishFixture(t)
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	publisher := f.publisher(nil, nil, loc)
+	admin := f.createAdmin(t, "reviewer", models.AdminRoleAdmin)
+	editor := f.createAdmin(t, "editor", models.AdminRoleEditor)
+	drama := f.createDrama(t, "published", nil)
+	episodes := f.createEpisodes(t, drama.ID, 10)
+	require.NoError(t, f.db.Model(&models.Episode{}).Where("drama_id = ? AND episode_num < ?", drama.ID, 10).
+		Update("status", models.StatusApproved).Error)
+	startDate := time.Now().In(loc).AddDate(0, 0, 1).Format(calendarDateLayout)
+
+	t.Run("每天固定时间发布多集", func(t *testing.T) {
+		scheduled, err := publisher.ScheduleEpisodes(drama.ID, admin.ID, models.ScheduleEpisodesRequest{
+			FromEpisode: 3, ToEpisode: 9, StartDate: startDate, Time: "20:00", PerDay: 3,
+		})
+		require.NoError(t, err)
+		require.Len(t, scheduled, 7)
+
+		start, _ := time.ParseInLocation("2006-01-02 15:04", startDate+" 20:00", loc)
+		for i, episode := range scheduled {
+			assert.Equal(t, 3+i, episode.EpisodeNum)
+			assert.Equal(t, "scheduled", episode.Status)
+			assert.True(t, start.AddDate(0, 0, i/3).Equal(*episode.PublishAt), "第 %d 集", episode.EpisodeNum)
+		}
+
+		saved, err := f.episodes.GetByID(episodes[8].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "scheduled", saved.Status)
+		assert.True(t, start.AddDate(0, 0, 2).Equal(*saved.PublishAt))
+		saved, err = f.episodes.GetByID(episodes[9].ID)
+		require.NoError(t, err)
+		assert.Equal(t, "draft", saved.Status, "范围外的剧集不排期")
+
+		history, err := f.reviews.ListTransitions(models.ReviewEntityEpisode, episodes[8].ID)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, models.StatusApproved, history[0].FromStatus)
+		assert.Equal(t, admin.ID, *history[0].AdminID)
+	})
+
+	t.Run("按间隔天数发布", func(t *testing.T) {
+		scheduled, err := publisher.ScheduleEpisodes(drama.ID, admin.ID, models.ScheduleEpisodesRequest{
+			FromEpisode: 1, ToEpisode: 3, StartDate: startDate, Time: "08:30", PerDay: 1, IntervalDays: 7,
+		})
+		require.NoError(t, err)
+		require.Len(t, scheduled, 3)
+		assert.Equal(t, 14*24*time.Hour, scheduled[2].PublishAt.Sub(*scheduled[0].PublishAt))
+	})
+
+	t.Run("无法排期", func(t *testing.T) {
+		req := models.ScheduleEpisodesRequest{FromEpisode: 8, ToEpisode: 10, StartDate: startDate, Time: "20:00", PerDay: 1}
+		_, err := publisher.ScheduleEpisodes(drama.ID, admin.ID, req)
+		assert.EqualError(t, err, "第 10 集不是审核通过或已排期的状态，不能排期")
+
+		_, err = publisher.ScheduleEpisodes(drama.ID, editor.ID, req)
+		assert.ErrorIs(t, err, ErrReviewForbidden, "编辑不能排期")
+
+		_, err = publisher.ScheduleEpisodes(99999, admin.ID, req)
+		assert.EqualError(t, err, "短剧不存在")
+
+		req = models.ScheduleEpisodesRequest{FromEpisode: 50, ToEpisode: 60, StartDate: startDate, Time: "20:00", PerDay: 1}
+		_, err = publisher.ScheduleEpisodes(drama.ID, admin.ID, req)
+		assert.EqualError(t, err, "第 50-60 集不存在")
+
+		req = models.ScheduleEpisodesRequest{FromEpisode: 1, ToEpisode: 2, StartDate: "2020-01-01", Time: "20:00", PerDay: 1}
+		_, err = publisher.ScheduleEpisodes(drama.ID, admin.ID, req)
+		assert.EqualError(t, err, "第一次发布时间必须晚于当前时间")
+	})
+}
+
+func TestPublishService_Calendar(t *testing.T) {
+	f := newPublishFixture(t)
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	publisher := f.publisher(nil, nil, loc)
+
+	// 北京时间 2030-01-01 23:30 与 2030-01-02 00:30，UTC 为同一天
+	first := time.Date(2030, 1, 1, 23, 30, 0, 0, loc).UTC()
+	second := time.Date(2030, 1, 2, 0, 30, 0, 0, loc).UTC()
+	drama := f.createDrama(t, "scheduled", &second)
+	episodes := f.createEpisodes(t, drama.ID, 3)
+	require.NoError(t, f.db.Model(episodes[0]).Updates(map[string]interface{}{"status": "scheduled", "publish_at": first}).Error)
+	require.NoError(t, f.db.Model(episodes[1]).Updates(map[string]interface{}{"status": "scheduled", "publish_at": second}).Error)
+
+	t.Run("按当地日期分组", func(t *testing.T) {
+		calendar, err := publisher.Calendar("2030-01-01", "2030-01-07")
+		require.NoError(t, err)
+		assert.Equal(t, "Asia/Shanghai", calendar.Timezone)
+		require.Len(t, calendar.Days, 2)
+
+		assert.Equal(t, "2030-01-01", calendar.Days[0].Date)
+		require.Len(t, calendar.Days[0].Items, 1)
+		assert.Equal(t, episodes[0].ID, calendar.Days[0].Items[0].ID)
+		assert.Equal(t, drama.Title, calendar.Days[0].Items[0].DramaTitle)
+
+		assert.Equal(t, "2030-01-02", calendar.Days[1].Date)
+		require.Len(t, calendar.Days[1].Items, 2)
+		assert.Equal(t, models.ReleaseTypeDrama, calendar.Days[1].Items[0].Type, "同一时刻短剧排在剧集之前")
+		assert.Equal(t, models.ReleaseTypeEpisode, calendar.Days[1].Items[1].Type)
+		assert.Equal(t, "2030-01-02T00:30:00+08:00", calendar.Days[1].Items[1].PublishAt.Format(time.RFC3339))
+	})
+
+	t.Run("日期范围", func(t *testing.T) {
+		calendar, err := publisher.Calendar("2030-01-02", "2030-01-02")
+		require.NoError(t, err)
+		require.Len(t, calendar.Days, 1)
+
+		calendar, err = publisher.Calendar("", "")
+		require.NoError(t, err)
+		assert.Empty(t, calendar.Days)
+
+		_, err = publisher.Calendar("2030-01-02", "2030-01-01")
+		assert.EqualError(t, err, "to 不能早于 from")
+		_, err = publisher.Calendar("2030-01-01", "2030-06-01")
+		assert.EqualError(t, err, "一次最多查询 92 天")
+		_, err = publisher.Calendar("01/01/2030", "")
+		assert.EqualError(t, err, "from 格式必须为 2006-01-02")
+	})
+}
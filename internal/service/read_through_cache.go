@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/metrics"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// defaultTTLJitter 缓存过期时间的随机抖动比例，避免同一批写入的缓存同时过期
+const defaultTTLJitter = 0.1
+
+// cachePolicy 读穿缓存策略
+type cachePolicy struct {
+	TTL         time.Duration // 新鲜期，期间直接返回缓存
+	StaleTTL    time.Duration // 新鲜期过后仍可返回旧值的时长，期间由一个协程在后台刷新
+	NegativeTTL time.Duration // 不存在结果的缓存时长，0 表示不缓存
+}
+
+// cacheEnvelope 读穿缓存在缓存中的存储格式
+type cacheEnvelope struct {
+	Data       json.RawMessage `json:"data,omitempty"`
+	NotFound   bool            `json:"notFound,omitempty"`
+	FreshUntil int64           `json:"freshUntil"` // Unix 毫秒时间戳
+}
+
+// readThroughCache 读穿缓存辅助工具
+// 并发未命中通过 singleflight 合并为一次加载；过期但仍在容忍期内的数据直接返回并在后台刷新；
+// 不存在的记录也会短暂缓存，避免无效 ID 扫描直接打到数据库
+type readThroughCache struct {
+	cache  CacheService
+	group  *singleflight.Group
+	jitter float64
+	now    func() time.Time
+	logger *slog.Logger
+	ctx    context.Context
+}
+
+// newReadThroughCache 创建读穿缓存辅助工具，cache 为 nil 时仅合并并发加载
+func newReadThroughCache(cache CacheService, log *slog.Logger) *readThroughCache {
+	return &readThroughCache{
+		cache:  cache,
+		group:  &singleflight.Group{},
+		jitter: defaultTTLJitter,
+		now:    time.Now,
+		logger: logger.OrDefault(log),
+		ctx:    context.Background(),
+	}
+}
+
+// withContext 返回绑定请求上下文并使用指定缓存服务的副本，与原实例共享 singleflight 分组
+func (r *readThroughCache) withContext(ctx context.Context, cache CacheService) *readThroughCache {
+	scoped := *r
+	scoped.cache = cache
+	scoped.ctx = ctx
+	return &scoped
+}
+
+// cacheLoader 缓存加载函数，返回 nil 值表示记录不存在，tags 为写入缓存时登记的标签
+type cacheLoader[T any] func() (value *T, tags []string, err error)
+
+// readThrough 通过读穿缓存获取数据，记录不存在时返回 (nil, nil)
+// 并发调用方可能共享同一个返回值，调用方不应修改返回的对象
+func readThrough[T any](r *readThroughCache, key string, policy cachePolicy, load cacheLoader[T]) (*T, error) {
+	if r.cache != nil {
+		var envelope cacheEnvelope
+		if err := r.cache.GetJSON(key, &envelope); err == nil {
+			if value, ok := decodeEnvelope[T](envelope); ok {
+				metrics.RecordCacheLookup(lookupResult(envelope, r.now()))
+				if r.now().UnixMilli() >= envelope.FreshUntil {
+					// 数据已过新鲜期：返回旧值，同一时刻只有一个协程在后台刷新
+					ch := r.group.DoChan(key, func() (interface{}, error) {
+						return refresh(r, key, policy, load)
+					})
+					go r.logRefreshError(key, ch)
+				}
+				return value, nil
+			}
+		}
+	}
+
+	metrics.RecordCacheLookup(metrics.CacheMiss)
+	result, err, _ := r.group.Do(key, func() (interface{}, error) {
+		return refresh(r, key, policy, load)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*T), nil
+}
+
+// refresh 调用加载函数并写回缓存
+func refresh[T any](r *readThroughCache, key string, policy cachePolicy, load cacheLoader[T]) (interface{}, error) {
+	value, tags, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		r.storeNotFound(key, policy, tags)
+	} else {
+		r.store(key, value, policy, tags)
+	}
+	return value, nil
+}
+
+// store 写入缓存，过期时间为新鲜期加上旧值容忍期；写入失败不影响本次读取结果
+func (r *readThroughCache) store(key string, value interface{}, policy cachePolicy, tags []string) {
+	if r.cache == nil {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	fresh := r.jitterTTL(policy.TTL)
+	envelope := cacheEnvelope{
+		Data:       data,
+		FreshUntil: r.now().Add(fresh).UnixMilli(),
+	}
+	r.cache.SetJSONWithTags(key, envelope, fresh+policy.StaleTTL, tags...)
+}
+
+// storeNotFound 写入不存在结果的缓存
+func (r *readThroughCache) storeNotFound(key string, policy cachePolicy, tags []string) {
+	if r.cache == nil || policy.NegativeTTL <= 0 {
+		return
+	}
+
+	fresh := r.jitterTTL(policy.NegativeTTL)
+	envelope := cacheEnvelope{
+		NotFound:   true,
+		FreshUntil: r.now().Add(fresh).UnixMilli(),
+	}
+	r.cache.SetJSONWithTags(key, envelope, fresh, tags...)
+}
+
+// jitterTTL 在过期时间上增加 [0, jitter*ttl] 的随机抖动
+func (r *readThroughCache) jitterTTL(ttl time.Duration) time.Duration {
+	max := int64(float64(ttl) * r.jitter)
+	if max <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Int63n(max+1))
+}
+
+// decodeEnvelope 解析缓存内容，无法识别的内容（如旧格式缓存）按未命中处理
+func decodeEnvelope[T any](envelope cacheEnvelope) (*T, bool) {
+	if envelope.NotFound {
+		return nil, true
+	}
+	if len(envelope.Data) == 0 {
+		return nil, false
+	}
+
+	value := new(T)
+	if err := json.Unmarshal(envelope.Data, value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// lookupResult 缓存命中的类型
+func lookupResult(envelope cacheEnvelope, now time.Time) string {
+	switch {
+	case envelope.NotFound:
+		return metrics.CacheNegativeHit
+	case now.UnixMilli() >= envelope.FreshUntil:
+		return metrics.CacheStale
+	default:
+		return metrics.CacheHit
+	}
+}
+
+// logRefreshError 记录后台刷新失败，失败时继续使用旧值直到其过期
+func (r *readThroughCache) logRefreshError(key string, ch <-chan singleflight.Result) {
+	if result := <-ch; result.Err != nil {
+		r.logger.WarnContext(r.ctx, "后台刷新缓存失败", slog.String("key", key), slog.String("error", result.Err.Error()))
+	}
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cachedItem struct {
+	Name string `json:"name"`
+}
+
+func newTestReadThrough() (*readThroughCache, *memoryCache, *time.Time) {
+	cache := newMemoryCache(100)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	r := newReadThroughCache(cache, nil)
+	r.now = func() time.Time { return now }
+	return r, cache, &now
+}
+
+func TestReadThrough_CollapsesConcurrentMisses(t *testing.T) {
+	r, _, _ := newTestReadThrough()
+	policy := cachePolicy{TTL: time.Minute}
+
+	var calls int32
+	release := make(chan struct{})
+	load := func() (*cachedItem, []string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return &cachedItem{Name: "value"}, nil, nil
+	}
+
+	const callers = 20
+	var wg sync.WaitGroup
+	results := make([]*cachedItem, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = readThrough(r, "item:1", policy, load)
+		}(i)
+	}
+
+	// 等待所有调用方进入 singleflight 后再放行加载
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, result := range results {
+		assert.Equal(t, "value", result.Name)
+	}
+
+	// 后续读取直接命中缓存
+	result, err := readThrough(r, "item:1", policy, load)
+	assert.NoError(t, err)
+	assert.Equal(t, "value", result.Name)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestReadThrough_ServesStaleWhileRefreshing(t *testing.T) {
+	r, _, now := newTestReadThrough()
+	r.jitter = 0
+	policy := cachePolicy{TTL: time.Minute, StaleTTL: time.Minute}
+
+	version := "v1"
+	refreshed := make(chan struct{}, 1)
+	load := func() (*cachedItem, []string, error) {
+		defer func() {
+			select {
+			case refreshed <- struct{}{}:
+			default:
+			}
+		}()
+		return &cachedItem{Name: version}, nil, nil
+	}
+
+	result, err := readThrough(r, "item:1", policy, load)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", result.Name)
+	<-refreshed
+
+	t.Run("过期后返回旧值并在后台刷新", func(t *testing.T) {
+		version = "v2"
+		*now = now.Add(90 * time.Second)
+
+		result, err := readThrough(r, "item:1", policy, load)
+		assert.NoError(t, err)
+		assert.Equal(t, "v1", result.Name)
+
+		select {
+		case <-refreshed:
+		case <-time.After(time.Second):
+			t.Fatal("后台刷新未执行")
+		}
+		assert.Eventually(t, func() bool {
+			result, _ := readThrough(r, "item:1", policy, load)
+			return result.Name == "v2"
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("超过容忍期后同步加载", func(t *testing.T) {
+		version = "v3"
+		*now = now.Add(3 * time.Minute)
+
+		result, err := readThrough(r, "item:1", policy, load)
+		assert.NoError(t, err)
+		assert.Equal(t, "v3", result.Name)
+	})
+}
+
+func TestReadThrough_NegativeCaching(t *testing.T) {
+	r, cache, now := newTestReadThrough()
+	r.jitter = 0
+	policy := cachePolicy{TTL: time.Minute, NegativeTTL: 10 * time.Second}
+
+	var calls int32
+	load := func() (*cachedItem, []string, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, []string{TagDrama(999)}, nil
+	}
+
+	t.Run("不存在的记录被缓存", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			result, err := readThrough(r, "drama:999", policy, load)
+			assert.NoError(t, err)
+			assert.Nil(t, result)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("空结果缓存按 NegativeTTL 过期", func(t *testing.T) {
+		*now = now.Add(11 * time.Second)
+
+		readThrough(r, "drama:999", policy, load)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("标签失效后重新加载", func(t *testing.T) {
+		cache.InvalidateTag(TagDrama(999))
+
+		readThrough(r, "drama:999", policy, load)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestReadThrough_ErrorsAreNotCached(t *testing.T) {
+	r, _, _ := newTestReadThrough()
+	policy := cachePolicy{TTL: time.Minute, NegativeTTL: time.Minute}
+
+	var calls int32
+	load := func() (*cachedItem, []string, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, nil, errors.New("数据库不可用")
+	}
+
+	_, err := readThrough(r, "item:1", policy, load)
+	assert.Error(t, err)
+	_, err = readThrough(r, "item:1", policy, load)
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestReadThrough_IgnoresLegacyEntries(t *testing.T) {
+	r, cache, _ := newTestReadThrough()
+	cache.SetJSON("item:1", cachedItem{Name: "legacy"}, time.Minute)
+
+	result, err := readThrough(r, "item:1", cachePolicy{TTL: time.Minute}, func() (*cachedItem, []string, error) {
+		return &cachedItem{Name: "fresh"}, nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "fresh", result.Name)
+}
+
+func TestReadThroughCache_JitterTTL(t *testing.T) {
+	r := newReadThroughCache(nil, nil)
+
+	for i := 0; i < 100; i++ {
+		ttl := r.jitterTTL(time.Minute)
+		assert.GreaterOrEqual(t, ttl, time.Minute)
+		assert.LessOrEqual(t, ttl, 66*time.Second)
+	}
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/logger"
+)
+
+var (
+	// ErrReviewForbidden 管理员角色不能执行该状态流转
+	ErrReviewForbidden = errors.New("没有权限执行该操作")
+	// ErrStatusConflict 状态已被其他请求变更
+	ErrStatusConflict = errors.New("状态已被其他操作变更，请刷新后重试")
+	// ErrContentNotFound 短剧或剧集不存在
+	ErrContentNotFound = errors.New("内容不存在")
+)
+
+// reviewTransitions 允许的状态流转及所需的最低角色
+// 编辑提交审核、撤回与重新编辑，管理员审核、发布、排期与下架
+var reviewTransitions = map[string]map[string]string{
+	models.StatusDraft: {
+		models.StatusInReview: models.AdminRoleEditor,
+	},
+	models.StatusInReview: {
+		models.StatusApproved: models.AdminRoleAdmin,
+		models.StatusRejected: models.AdminRoleAdmin,
+		models.StatusDraft:    models.AdminRoleEditor,
+	},
+	models.StatusRejected: {
+		models.StatusInReview: models.AdminRoleEditor,
+		models.StatusDraft:    models.AdminRoleEditor,
+	},
+	models.StatusApproved: {
+		models.StatusPublished: models.AdminRoleAdmin,
+		models.StatusScheduled: models.AdminRoleAdmin,
+		models.StatusDraft:     models.AdminRoleEditor,
+	},
+	models.StatusScheduled: {
+		models.StatusScheduled: models.AdminRoleAdmin, // 修改发布时间
+		models.StatusPublished: models.AdminRoleAdmin,
+		models.StatusApproved:  models.AdminRoleAdmin, // 取消排期
+	},
+	models.StatusPublished: {
+		models.StatusArchived: models.AdminRoleAdmin,
+	},
+	models.StatusArchived: {
+		models.StatusPublished: models.AdminRoleAdmin,
+		models.StatusDraft:     models.AdminRoleEditor,
+	},
+}
+
+// adminRoleRank 管理员角色的权限等级
+var adminRoleRank = map[string]int{
+	models.AdminRoleEditor:     1,
+	models.AdminRoleAdmin:      2,
+	models.AdminRoleSuperAdmin: 3,
+}
+
+// ReviewService 审核流程服务接口
+// 短剧与剧集的状态只能通过状态流转变更，每次变更都会记录操作人与审核意见
+type ReviewService interface {
+	// WithContext 返回绑定请求上下文的服务
+	WithContext(ctx context.Context) ReviewService
+	// TransitionDrama 变更短剧状态，返回变更后的短剧
+	TransitionDrama(id, adminID uint, req models.TransitionRequest) (*models.Drama, error)
+	// TransitionEpisode 变更剧集状态，返回变更后的剧集
+	TransitionEpisode(id, adminID uint, req models.TransitionRequest) (*models.Episode, error)
+	// History 按时间顺序获取短剧或剧集的状态流转记录
+	History(entityType string, id uint) ([]models.StatusTransition, error)
+}
+
+// reviewService 审核流程服务实现
+type reviewService struct {
+	adminRepo    repository.AdminRepository
+	dramaRepo    repository.DramaRepository
+	episodeRepo  repository.EpisodeRepository
+	reviewRepo   repository.ReviewRepository
+	cacheService CacheService
+	logger       *slog.Logger
+	ctx          context.Context
+}
+
+// NewReviewService 创建审核流程服务，cacheService 为 nil 时不失效缓存；log 为 nil 时使用全局默认 Logger
+func NewReviewService(
+	adminRepo repository.AdminRepository,
+	dramaRepo repository.DramaRepository,
+	episodeRepo repository.EpisodeRepository,
+	reviewRepo repository.ReviewRepository,
+	cacheService CacheService,
+	log *slog.Logger,
+) ReviewService {
+	return &reviewService{
+		adminRepo:    adminRepo,
+		dramaRepo:    dramaRepo,
+		episodeRepo:  episodeRepo,
+		reviewRepo:   reviewRepo,
+		cacheService: cacheService,
+		logger:       logger.OrDefault(log),
+		ctx:          context.Background(),
+	}
+}
+
+// WithContext 返回绑定请求上下文的审核流程服务
+func (s *reviewService) WithContext(ctx context.Context) ReviewService {
+	scoped := *s
+	scoped.ctx = ctx
+	scoped.adminRepo = s.adminRepo.WithContext(ctx)
+	scoped.dramaRepo = s.dramaRepo.WithContext(ctx)
+	scoped.episodeRepo = s.episodeRepo.WithContext(ctx)
+	scoped.reviewRepo = s.reviewRepo.WithContext(ctx)
+	if s.cacheService != nil {
+		scoped.cacheService = s.cacheService.WithContext(ctx)
+	}
+	return &scoped
+}
+
+// TransitionDrama 检查权限与流转规则后按状态条件更新短剧
+func (s *reviewService) TransitionDrama(id, adminID uint, req models.TransitionRequest) (*models.Drama, error) {
+	admin, err := loadReviewer(s.adminRepo, adminID)
+	if err != nil {
+		return nil, err
+	}
+	drama, err := s.dramaRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("查询短剧失败: %w", err)
+	}
+	if drama == nil {
+		return nil, ErrContentNotFound
+	}
+
+	record, err := newTransition(admin, models.ReviewEntityDrama, id, drama.Status, drama.PublishAt, req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(record); err != nil {
+		return nil, err
+	}
+	drama.Status, drama.PublishAt = record.ToStatus, record.PublishAt
+
+	s.invalidateCache(TagDrama(id), TagDramaList, TagCategory(drama.Category))
+	s.logger.InfoContext(s.ctx, "短剧状态已变更", slog.Any("drama_id", id), slog.String("from", record.FromStatus),
+		slog.String("to", record.ToStatus), slog.Any("admin_id", adminID))
+	return drama, nil
+}
+
+// TransitionEpisode 检查权限与流转规则后按状态条件更新剧集
+func (s *reviewService) TransitionEpisode(id, adminID uint, req models.TransitionRequest) (*models.Episode, error) {
+	admin, err := loadReviewer(s.adminRepo, adminID)
+	if err != nil {
+		return nil, err
+	}
+	episode, err := s.episodeRepo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("查询剧集失败: %w", err)
+	}
+	if episode == nil {
+		return nil, ErrContentNotFound
+	}
+
+	record, err := newTransition(admin, models.ReviewEntityEpisode, id, episode.Status, episode.PublishAt, req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(record); err != nil {
+		return nil, err
+	}
+	episode.Status, episode.PublishAt = record.ToStatus, record.PublishAt
+
+	s.invalidateCache(TagEpisode(id), TagDrama(episode.DramaID))
+	s.logger.InfoContext(s.ctx, "剧集状态已变更", slog.Any("episode_id", id), slog.String("from", record.FromStatus),
+		slog.String("to", record.ToStatus), slog.Any("admin_id", adminID))
+	return episode, nil
+}
+
+// History 获取状态流转记录
+func (s *reviewService) History(entityType string, id uint) ([]models.StatusTransition, error) {
+	records, err := s.reviewRepo.ListTransitions(entityType, id)
+	if err != nil {
+		return nil, fmt.Errorf("查询状态流转记录失败: %w", err)
+	}
+	return records, nil
+}
+
+// apply 保存状态流转，状态已被其他请求变更时返回 ErrStatusConflict
+func (s *reviewService) apply(record *models.StatusTransition) error {
+	ok, err := s.reviewRepo.Transition([]models.StatusTransition{*record})
+	if err != nil {
+		return fmt.Errorf("变更状态失败: %w", err)
+	}
+	if !ok {
+		return ErrStatusConflict
+	}
+	return nil
+}
+
+// invalidateCache 失效缓存标签，失败只记录日志：缓存会在 TTL 到期后自然过期
+func (s *reviewService) invalidateCache(tags ...string) {
+	if s.cacheService == nil {
+		return
+	}
+	if err := s.cacheService.InvalidateTag(tags...); err != nil {
+		s.logger.WarnContext(s.ctx, "缓存失效失败", slog.Any("tags", tags), slog.String("error", err.Error()))
+	}
+}
+
+// loadReviewer 获取执行状态流转的管理员，不存在或已禁用时返回 ErrReviewForbidden
+func loadReviewer(adminRepo repository.AdminRepository, adminID uint) (*models.Admin, error) {
+	admin, err := adminRepo.GetByID(adminID)
+	if err != nil {
+		return nil, fmt.Errorf("查询管理员失败: %w", err)
+	}
+	if admin == nil || !admin.IsActive() {
+		return nil, fmt.Errorf("%w: 管理员不存在或已禁用", ErrReviewForbidden)
+	}
+	return admin, nil
+}
+
+// checkTransition 检查角色是否可以将状态从 from 变更为 to
+func checkTransition(role, from, to string) error {
+	required, ok := reviewTransitions[from][to]
+	if !ok {
+		return fmt.Errorf("状态不能从 %s 变更为 %s", from, to)
+	}
+	if adminRoleRank[role] < adminRoleRank[required] {
+		return fmt.Errorf("%w: 将状态从 %s 变更为 %s 需要 %s 角色", ErrReviewForbidden, from, to, required)
+	}
+	return nil
+}
+
+// newTransition 校验流转规则并生成流转记录，记录的 PublishAt 为变更后的发布时间：
+// 排期时为请求的时间（UTC），发布时为当前时间，下架时保留原发布时间，其余状态清除
+func newTransition(admin *models.Admin, entityType string, id uint, from string, publishAt *time.Time, req models.TransitionRequest, now time.Time) (*models.StatusTransition, error) {
+	to := req.Status
+	if err := checkTransition(admin.Role, from, to); err != nil {
+		return nil, err
+	}
+	comment := strings.TrimSpace(req.Comment)
+	if to == models.StatusRejected && comment == "" {
+		return nil, errors.New("审核不通过时必须填写审核意见")
+	}
+	if req.PublishAt != nil && to != models.StatusScheduled {
+		return nil, errors.New("只有定时发布（status 为 scheduled）可以设置 publish_at")
+	}
+
+	record := &models.StatusTransition{
+		EntityType: entityType,
+		EntityID:   id,
+		FromStatus: from,
+		ToStatus:   to,
+		Comment:    comment,
+		AdminID:    &admin.ID,
+		AdminName:  admin.Username,
+	}
+	switch to {
+	case models.StatusScheduled:
+		if req.PublishAt == nil {
+			return nil, errors.New("定时发布需要填写 publish_at")
+		}
+		if !req.PublishAt.After(now) {
+			return nil, errors.New("定时发布时间必须晚于当前时间")
+		}
+		at := req.PublishAt.UTC()
+		record.PublishAt = &at
+	case models.StatusPublished:
+		at := now.UTC()
+		record.PublishAt = &at
+	case models.StatusArchived:
+		record.PublishAt = publishAt
+	}
+	return record, nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewService_TransitionDrama(t *testing.T) {
+	f := newPublishFixture(t)
+	reviews := NewReviewService(f.admins, f.dramas, f.episodes, f.reviews, nil, nil)
+	editor := f.createAdmin(t, "editor", models.AdminRoleEditor)
+	admin := f.createAdmin(t, "reviewer", models.AdminRoleAdmin)
+	drama := f.createDrama(t, models.StatusDraft, nil)
+
+	transition := func(adminID uint, status, comment string) (*models.Drama, error) {
+		return reviews.TransitionDrama(drama.ID, adminID, models.TransitionRequest{Status: status, Comment: comment})
+	}
+
+	t.Run("编辑提交审核，管理员审核后发布", func(t *testing.T) {
+		updated, err := transition(editor.ID, models.StatusInReview, "")
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusInReview, updated.Status)
+
+		_, err = transition(editor.ID, models.StatusApproved, "")
+		assert.ErrorIs(t, err, ErrReviewForbidden, "编辑不能审核")
+
+		_, err = transition(admin.ID, models.StatusRejected, " ")
+		assert.EqualError(t, err, "审核不通过时必须填写审核意见")
+
+		_, err = transition(admin.ID, models.StatusRejected, "封面不清晰")
+		require.NoError(t, err)
+		_, err = transition(editor.ID, models.StatusInReview, "已更换封面")
+		require.NoError(t, err)
+		_, err = transition(admin.ID, models.StatusApproved, "")
+		require.NoError(t, err)
+
+		_, err = transition(editor.ID, models.StatusPublished, "")
+		assert.ErrorIs(t, err, ErrReviewForbidden, "编辑不能发布")
+		updated, err = transition(admin.ID, models.StatusPublished, "")
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusPublished, updated.Status)
+		require.NotNil(t, updated.PublishAt)
+
+		saved, err := f.dramas.GetPublishedByID(drama.ID)
+		require.NoError(t, err)
+		require.NotNil(t, saved, "发布后公开查询可见")
+	})
+
+	t.Run("不允许的状态流转", func(t *testing.T) {
+		_, err := transition(admin.ID, models.StatusApproved, "")
+		assert.EqualError(t, err, "状态不能从 published 变更为 approved")
+
+		_, err = reviews.TransitionDrama(99999, admin.ID, models.TransitionRequest{Status: models.StatusInReview})
+		assert.ErrorIs(t, err, ErrContentNotFound)
+		_, err = transition(99999, models.StatusArchived, "")
+		assert.ErrorIs(t, err, ErrReviewForbidden, "管理员不存在")
+	})
+
+	t.Run("按时间顺序记录状态流转", func(t *testing.T) {
+		history, err := reviews.History(models.ReviewEntityDrama, drama.ID)
+		require.NoError(t, err)
+		require.Len(t, history, 5)
+		assert.Equal(t, models.StatusDraft, history[0].FromStatus)
+		assert.Equal(t, editor.ID, *history[0].AdminID)
+		assert.Equal(t, models.StatusRejected, history[1].ToStatus)
+		assert.Equal(t, "封面不清晰", history[1].Comment)
+		assert.Equal(t, "reviewer", history[1].AdminName)
+		assert.Equal(t, models.StatusPublished, history[4].ToStatus)
+	})
+}
+
+func TestReviewService_TransitionEpisode(t *testing.T) {
+	f := newPublishFixture(t)
+	reviews := NewReviewService(f.admins, f.dramas, f.episodes, f.reviews, nil, nil)
+	admin := f.createAdmin(t, "reviewer", models.AdminRoleAdmin)
+	drama := f.createDrama(t, models.StatusPublished, nil)
+	episode := f.createEpisodes(t, drama.ID, 1)[0]
+	require.NoError(t, f.db.Model(episode).Update("status", models.StatusApproved).Error)
+
+	t.Run("排期后取消", func(t *testing.T) {
+		publishAt := time.Now().Add(time.Hour)
+		updated, err := reviews.TransitionEpisode(episode.ID, admin.ID, models.TransitionRequest{
+			Status: models.StatusScheduled, PublishAt: &publishAt,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusScheduled, updated.Status)
+
+		published, err := f.episodes.GetPublishedByIDWithDrama(episode.ID)
+		require.NoError(t, err)
+		assert.Nil(t, published, "排期的剧集不对外可见")
+
+		updated, err = reviews.TransitionEpisode(episode.ID, admin.ID, models.TransitionRequest{Status: models.StatusApproved})
+		require.NoError(t, err)
+		assert.Nil(t, updated.PublishAt, "取消排期时清除发布时间")
+	})
+}
+
+func TestNewTransition(t *testing.T) {
+	now := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	local := future.In(time.FixedZone("CST", 8*3600))
+	admin := &models.Admin{ID: 1, Username: "reviewer", Role: models.AdminRoleAdmin}
+
+	tests := []struct {
+		name         string
+		status       string
+		publishAt    *time.Time
+		reqStatus    string
+		reqPublishAt *time.Time
+		wantAt       *time.Time
+		wantErr      string
+	}{
+		{name: "定时发布时间保存为 UTC", status: "approved", reqStatus: "scheduled", reqPublishAt: &local, wantAt: &future},
+		{name: "定时发布时间必须晚于当前时间", status: "approved", reqStatus: "scheduled", reqPublishAt: &past, wantErr: "定时发布时间必须晚于当前时间"},
+		{name: "定时发布需要发布时间", status: "approved", reqStatus: "scheduled", wantErr: "定时发布需要填写 publish_at"},
+		{name: "修改定时发布时间", status: "scheduled", publishAt: &past, reqStatus: "scheduled", reqPublishAt: &future, wantAt: &future},
+		{name: "非定时发布不能设置发布时间", status: "approved", reqStatus: "published", reqPublishAt: &future, wantErr: "只有定时发布（status 为 scheduled）可以设置 publish_at"},
+		{name: "手动发布记录当前时间", status: "scheduled", publishAt: &future, reqStatus: "published", wantAt: &now},
+		{name: "取消排期时清除发布时间", status: "scheduled", publishAt: &future, reqStatus: "approved"},
+		{name: "下架时保留发布时间", status: "published", publishAt: &past, reqStatus: "archived", wantAt: &past},
+		{name: "草稿不能直接发布", status: "draft", reqStatus: "published", wantErr: "状态不能从 draft 变更为 published"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := models.TransitionRequest{Status: tt.reqStatus, PublishAt: tt.reqPublishAt}
+			record, err := newTransition(admin, models.ReviewEntityDrama, 1, tt.status, tt.publishAt, req, now)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, record.FromStatus)
+			assert.Equal(t, tt.reqStatus, record.ToStatus)
+			if tt.wantAt == nil {
+				assert.Nil(t, record.PublishAt)
+				return
+			}
+			if assert.NotNil(t, record.PublishAt) {
+				assert.True(t, tt.wantAt.Equal(*record.PublishAt))
+				assert.Equal(t, time.UTC, record.PublishAt.Location(), "发布时间保存为 UTC")
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/logger"
+)
+
+// ErrRevisionNotFound 修订版本不存在
+var ErrRevisionNotFound = errors.New("修订版本不存在")
+
+// RevisionService 修订版本服务接口
+// 短剧与剧集每次更新后保存一个内容快照，可以比较任意两个版本并回滚到历史版本
+type RevisionService interface {
+	// WithContext 返回绑定请求上下文的服务
+	WithContext(ctx context.Context) RevisionService
+	// List 按版本号倒序分页获取短剧或剧集的修订版本
+	List(entityType string, id uint, page, pageSize int) (*models.PaginatedRevisions, error)
+	// Diff 比较两个修订版本，任一版本不存在时返回 ErrRevisionNotFound
+	Diff(entityType string, id uint, from, to int) (*models.RevisionDiff, error)
+	// RollbackDrama 将短剧的可编辑字段恢复为指定版本的内容，并以 adminID 为作者保存为新版本
+	RollbackDrama(id uint, version int, adminID uint) (*models.Drama, error)
+	// RollbackEpisode 将剧集的可编辑字段恢复为指定版本的内容，并以 adminID 为作者保存为新版本
+	RollbackEpisode(id uint, version int, adminID uint) (*models.Episode, error)
+}
+
+// revisionService 修订版本服务实现
+type revisionService struct {
+	revisionRepo repository.RevisionRepository
+	adminService AdminService
+	logger       *slog.Logger
+	ctx          context.Context
+}
+
+// NewRevisionService 创建修订版本服务，回滚通过 adminService 更新内容，与手动更新一样关联媒体文件、重新转码并失效缓存
+// log 为 nil 时使用全局默认 Logger
+func NewRevisionService(revisionRepo repository.RevisionRepository, adminService AdminService, log *slog.Logger) RevisionService {
+	return &revisionService{
+		revisionRepo: revisionRepo,
+		adminService: adminService,
+		logger:       logger.OrDefault(log),
+		ctx:          context.Background(),
+	}
+}
+
+// WithContext 返回绑定请求上下文的修订版本服务
+func (s *revisionService) WithContext(ctx context.Context) RevisionService {
+	scoped := *s
+	scoped.ctx = ctx
+	scoped.revisionRepo = s.revisionRepo.WithContext(ctx)
+	scoped.adminService = s.adminService.WithContext(ctx)
+	return &scoped
+}
+
+// List 分页获取修订版本
+func (s *revisionService) List(entityType string, id uint, page, pageSize int) (*models.PaginatedRevisions, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	revisions, total, err := s.revisionRepo.List(entityType, id, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("查询修订版本失败: %w", err)
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	return &models.PaginatedRevisions{
+		Items:       revisions,
+		Total:       total,
+		Page:        page,
+		PageSize:    pageSize,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	}, nil
+}
+
+// Diff 逐字段比较两个版本的快照，按字段名排序返回有变化的字段
+func (s *revisionService) Diff(entityType string, id uint, from, to int) (*models.RevisionDiff, error) {
+	fromRevision, err := s.get(entityType, id, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := s.get(entityType, id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]struct{}, len(toRevision.Snapshot))
+	for field := range fromRevision.Snapshot {
+		fields[field] = struct{}{}
+	}
+	for field := range toRevision.Snapshot {
+		fields[field] = struct{}{}
+	}
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	diff := &models.RevisionDiff{EntityType: entityType, EntityID: id, FromVersion: from, ToVersion: to, Changes: []models.FieldChange{}}
+	for _, field := range names {
+		oldValue, newValue := fromRevision.Snapshot[field], toRevision.Snapshot[field]
+		if !reflect.DeepEqual(oldValue, newValue) {
+			diff.Changes = append(diff.Changes, models.FieldChange{Field: field, From: oldValue, To: newValue})
+		}
+	}
+	return diff, nil
+}
+
+// RollbackDrama 以指定版本的全部字段更新短剧
+func (s *revisionService) RollbackDrama(id uint, version int, adminID uint) (*models.Drama, error) {
+	revision, err := s.get(models.ReviewEntityDrama, id, version)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot models.DramaSnapshot
+	if err := decodeSnapshot(revision.Snapshot, &snapshot); err != nil {
+		return nil, err
+	}
+
+	drama, err := s.adminService.UpdateDrama(id, adminID, models.UpdateDramaRequest{
+		Title:       &snapshot.Title,
+		Description: &snapshot.Description,
+		CoverImage:  &snapshot.CoverImage,
+		Director:    &snapshot.Director,
+		Actors:      &snapshot.Actors,
+		Category:    &snapshot.Category,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(s.ctx, "短剧已回滚", slog.Any("drama_id", id), slog.Int("version", version), slog.Any("admin_id", adminID))
+	return drama, nil
+}
+
+// RollbackEpisode 以指定版本的全部字段更新剧集，剧集号已被其他剧集使用时不能回滚
+func (s *revisionService) RollbackEpisode(id uint, version int, adminID uint) (*models.Episode, error) {
+	revision, err := s.get(models.ReviewEntityEpisode, id, version)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot models.EpisodeSnapshot
+	if err := decodeSnapshot(revision.Snapshot, &snapshot); err != nil {
+		return nil, err
+	}
+
+	episode, err := s.adminService.UpdateEpisode(id, adminID, models.UpdateEpisodeRequest{
+		Title:      &snapshot.Title,
+		EpisodeNum: &snapshot.EpisodeNum,
+		Duration:   &snapshot.Duration,
+		VideoURL:   &snapshot.VideoURL,
+		Thumbnail:  &snapshot.Thumbnail,
+		Protected:  &snapshot.Protected,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(s.ctx, "剧集已回滚", slog.Any("episode_id", id), slog.Int("version", version), slog.Any("admin_id", adminID))
+	return episode, nil
+}
+
+// get 获取修订版本，不存在时返回 ErrRevisionNotFound
+func (s *revisionService) get(entityType string, id uint, version int) (*models.ContentRevision, error) {
+	revision, err := s.revisionRepo.Get(entityType, id, version)
+	if err != nil {
+		return nil, fmt.Errorf("查询修订版本失败: %w", err)
+	}
+	if revision == nil {
+		return nil, fmt.Errorf("%w: 版本 %d", ErrRevisionNotFound, version)
+	}
+	return revision, nil
+}
+
+// dramaSnapshot 短剧可编辑字段的快照
+func dramaSnapshot(drama *models.Drama) models.DramaSnapshot {
+	return models.DramaSnapshot{
+		Title:       drama.Title,
+		Description: drama.Description,
+		CoverImage:  drama.CoverImage,
+		Director:    drama.Director,
+		Actors:      drama.Actors,
+		Category:    drama.Category,
+	}
+}
+
+// episodeSnapshot 剧集可编辑字段的快照
+func episodeSnapshot(episode *models.Episode) models.EpisodeSnapshot {
+	return models.EpisodeSnapshot{
+		Title:      episode.Title,
+		EpisodeNum: episode.EpisodeNum,
+		Duration:   episode.Duration,
+		VideoURL:   episode.VideoURL,
+		Thumbnail:  episode.Thumbnail,
+		Protected:  episode.Protected,
+	}
+}
+
+// snapshotFields 将快照转换为按 JSON 字段名索引的字段表，与从数据库读出的快照格式一致，便于逐字段比较
+func snapshotFields(snapshot interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// decodeSnapshot 将字段表解析为快照结构
+func decodeSnapshot(fields map[string]interface{}, snapshot interface{}) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("解析修订版本失败: %w", err)
+	}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return fmt.Errorf("解析修订版本失败: %w", err)
+	}
+	return nil
+}
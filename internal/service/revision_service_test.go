@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// strPtr 返回字符串指针，用于构造 PATCH 请求
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestRevisionService_Drama(t *testing.T) {
+	f := newPublishFixture(t)
+	revisionRepo := repository.NewRevisionRepository(f.db)
+	admins := NewAdminService(f.admins, f.dramas, f.episodes, nil, nil, nil, nil, revisionRepo, nil)
+	revisions := NewRevisionService(revisionRepo, admins, nil)
+	editor := f.createAdmin(t, "editor", models.AdminRoleEditor)
+	drama := f.createDrama(t, models.StatusDraft, nil)
+	original := drama.Description
+
+	t.Run("更新时保存修订版本，空字符串清空字段", func(t *testing.T) {
+		updated, err := admins.UpdateDrama(drama.ID, editor.ID, models.UpdateDramaRequest{
+			Description: strPtr(""),
+			Director:    strPtr("新导演"),
+		})
+		require.NoError(t, err)
+		assert.Empty(t, updated.Description)
+		assert.Equal(t, "新导演", updated.Director)
+		assert.Equal(t, drama.Title, updated.Title, "未提供的字段保持不变")
+
+		list, err := revisions.List(models.ReviewEntityDrama, drama.ID, 1, 20)
+		require.NoError(t, err)
+		require.Len(t, list.Items, 2, "首次更新时同时保存更新前的内容")
+		assert.Equal(t, 2, list.Items[0].Version)
+		assert.Equal(t, editor.ID, *list.Items[0].AdminID)
+		assert.Equal(t, "editor", list.Items[0].AdminName)
+		assert.Nil(t, list.Items[1].AdminID)
+		assert.Equal(t, original, list.Items[1].Snapshot["description"])
+	})
+
+	t.Run("内容没有变化时不保存", func(t *testing.T) {
+		_, err := admins.UpdateDrama(drama.ID, editor.ID, models.UpdateDramaRequest{Director: strPtr("新导演")})
+		require.NoError(t, err)
+		list, err := revisions.List(models.ReviewEntityDrama, drama.ID, 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), list.Total)
+	})
+
+	t.Run("比较两个版本", func(t *testing.T) {
+		diff, err := revisions.Diff(models.ReviewEntityDrama, drama.ID, 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []models.FieldChange{
+			{Field: "description", From: original, To: ""},
+			{Field: "director", From: drama.Director, To: "新导演"},
+		}, diff.Changes)
+
+		_, err = revisions.Diff(models.ReviewEntityDrama, drama.ID, 1, 9)
+		assert.ErrorIs(t, err, ErrRevisionNotFound)
+	})
+
+	t.Run("回滚到历史版本并保存为新版本", func(t *testing.T) {
+		restored, err := revisions.RollbackDrama(drama.ID, 1, editor.ID)
+		require.NoError(t, err)
+		assert.Equal(t, original, restored.Description)
+		assert.Equal(t, drama.Director, restored.Director)
+
+		list, err := revisions.List(models.ReviewEntityDrama, drama.ID, 1, 20)
+		require.NoError(t, err)
+		require.Len(t, list.Items, 3)
+		assert.Equal(t, list.Items[2].Snapshot, list.Items[0].Snapshot)
+
+		_, err = revisions.RollbackDrama(drama.ID, 9, editor.ID)
+		assert.ErrorIs(t, err, ErrRevisionNotFound)
+	})
+
+	t.Run("短剧不存在", func(t *testing.T) {
+		_, err := admins.UpdateDrama(99999, editor.ID, models.UpdateDramaRequest{Title: strPtr("标题")})
+		assert.ErrorIs(t, err, ErrContentNotFound)
+	})
+}
+
+func TestRevisionService_RollbackEpisode(t *testing.T) {
+	f := newPublishFixture(t)
+	revisionRepo := repository.NewRevisionRepository(f.db)
+	admins := NewAdminService(f.admins, f.dramas, f.episodes, nil, nil, nil, nil, revisionRepo, nil)
+	revisions := NewRevisionService(revisionRepo, admins, nil)
+	editor := f.createAdmin(t, "editor", models.AdminRoleEditor)
+	drama := f.createDrama(t, models.StatusDraft, nil)
+	episodes := f.createEpisodes(t, drama.ID, 2)
+
+	num := 3
+	updated, err := admins.UpdateEpisode(episodes[0].ID, editor.ID, models.UpdateEpisodeRequest{EpisodeNum: &num, Thumbnail: strPtr("")})
+	require.NoError(t, err)
+	assert.Empty(t, updated.Thumbnail)
+
+	t.Run("剧集号已被使用时不能回滚", func(t *testing.T) {
+		num := 1
+		_, err := admins.UpdateEpisode(episodes[1].ID, editor.ID, models.UpdateEpisodeRequest{EpisodeNum: &num})
+		require.NoError(t, err)
+
+		_, err = revisions.RollbackEpisode(episodes[0].ID, 1, editor.ID)
+		assert.EqualError(t, err, "该剧集编号已存在")
+	})
+
+	t.Run("回滚恢复剧集号与缩略图", func(t *testing.T) {
+		num := 2
+		_, err := admins.UpdateEpisode(episodes[1].ID, editor.ID, models.UpdateEpisodeRequest{EpisodeNum: &num})
+		require.NoError(t, err)
+
+		restored, err := revisions.RollbackEpisode(episodes[0].ID, 1, editor.ID)
+		require.NoError(t, err)
+		assert.Equal(t, episodes[0].EpisodeNum, restored.EpisodeNum)
+		assert.Equal(t, episodes[0].Thumbnail, restored.Thumbnail)
+		assert.Equal(t, episodes[0].Duration, restored.Duration)
+	})
+}
+
+func TestRevisionService_ConcurrentUpdates(t *testing.T) {
+	f := newPublishFixture(t)
+	revisionRepo := repository.NewRevisionRepository(f.db)
+	admins := NewAdminService(f.admins, f.dramas, f.episodes, nil, nil, nil, nil, revisionRepo, nil)
+	revisions := NewRevisionService(revisionRepo, admins, nil)
+	editor := f.createAdmin(t, "editor", models.AdminRoleEditor)
+	drama := f.createDrama(t, models.StatusDraft, nil)
+
+	const updates = 8
+	var wg sync.WaitGroup
+	errs := make([]error, updates)
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = admins.UpdateDrama(drama.ID, editor.ID, models.UpdateDramaRequest{Director: strPtr(fmt.Sprintf("导演%d", i))})
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	list, err := revisions.List(models.ReviewEntityDrama, drama.ID, 1, 100)
+	require.NoError(t, err)
+	require.Len(t, list.Items, updates+1, "每次更新都保存了修订版本，首次更新前的内容只保存一次")
+	for i, item := range list.Items {
+		assert.Equal(t, updates+1-i, item.Version)
+	}
+	current, err := f.dramas.GetByID(drama.ID)
+	require.NoError(t, err)
+	assert.Equal(t, current.Director, list.Items[0].Snapshot["director"], "最新版本与当前内容一致")
+}
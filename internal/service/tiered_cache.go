@@ -0,0 +1,486 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/logger"
+)
+
+// 缓存驱动
+const (
+	CacheDriverRedis  = "redis"
+	CacheDriverMemory = "memory"
+	CacheDriverTiered = "tiered"
+)
+
+// TieredCacheConfig 分层缓存配置
+type TieredCacheConfig struct {
+	// L1Enabled 为 true 时本地缓存作为一级缓存常驻；为 false 时仅在 Redis 不可用时启用
+	L1Enabled         bool
+	MaxEntries        int
+	LocalTTL          time.Duration
+	ReconnectInterval time.Duration
+	// Logger 记录降级与恢复事件，为 nil 时使用全局默认 Logger
+	Logger *slog.Logger
+}
+
+// tieredCache 本地 L1 + Redis L2 缓存服务实现
+// Redis 出现连接错误时自动降级为纯本地缓存，并在后台定期尝试重连；
+// 降级期间的删除与标签失效操作会被记录，重连成功后回放到 Redis，避免读到陈旧数据
+type tieredCache struct {
+	*tieredState
+	remote CacheService
+}
+
+// tieredState 分层缓存的共享状态，WithContext 派生的实例与原实例共用同一份
+type tieredState struct {
+	client            *redis.Client
+	local             *memoryCache
+	l1Enabled         bool
+	localTTL          time.Duration
+	reconnectInterval time.Duration
+	logger            *slog.Logger
+
+	healthy atomic.Bool
+
+	pendingMu       sync.Mutex
+	pendingKeys     []string
+	pendingPatterns []string
+	pendingTags     []string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewCacheServiceWithConfig 根据配置创建缓存服务
+// client 可以为 nil（仅 memory 驱动时允许），redis 与 tiered 驱动均带有自动降级能力
+func NewCacheServiceWithConfig(cfg *config.CacheConfig, client *redis.Client, log *slog.Logger) CacheService {
+	if cfg.GetDriver() == CacheDriverMemory || client == nil {
+		return NewMemoryCacheService(cfg.GetMaxEntries())
+	}
+
+	return NewTieredCacheService(client, TieredCacheConfig{
+		L1Enabled:         cfg.GetDriver() == CacheDriverTiered,
+		MaxEntries:        cfg.GetMaxEntries(),
+		LocalTTL:          cfg.GetLocalTTL(),
+		ReconnectInterval: cfg.GetReconnectInterval(),
+		Logger:            log,
+	})
+}
+
+// NewTieredCacheService 创建新的分层缓存服务，并启动后台重连协程
+// 使用完毕后应调用 Close 停止后台协程
+func NewTieredCacheService(client *redis.Client, cfg TieredCacheConfig) CacheService {
+	c := newTieredCache(client, cfg)
+
+	// 启动时探测一次 Redis，不可用则直接进入降级模式
+	if err := c.ping(); err != nil {
+		c.markDown(err)
+	}
+
+	go c.reconnectLoop()
+	return c
+}
+
+func newTieredCache(client *redis.Client, cfg TieredCacheConfig) *tieredCache {
+	if cfg.LocalTTL <= 0 {
+		cfg.LocalTTL = 30 * time.Second
+	}
+	if cfg.ReconnectInterval <= 0 {
+		cfg.ReconnectInterval = 10 * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &tieredCache{
+		tieredState: &tieredState{
+			client:            client,
+			local:             newMemoryCache(cfg.MaxEntries),
+			l1Enabled:         cfg.L1Enabled,
+			localTTL:          cfg.LocalTTL,
+			reconnectInterval: cfg.ReconnectInterval,
+			logger:            logger.OrDefault(cfg.Logger),
+			ctx:               ctx,
+			cancel:            cancel,
+			done:              make(chan struct{}),
+		},
+		remote: NewCacheService(client),
+	}
+	c.healthy.Store(true)
+	return c
+}
+
+// Close 停止后台重连协程
+func (c *tieredCache) Close() error {
+	c.cancel()
+	<-c.done
+	return nil
+}
+
+// WithContext 返回绑定上下文的分层缓存，与原实例共享本地缓存与降级状态
+func (c *tieredCache) WithContext(ctx context.Context) CacheService {
+	return &tieredCache{
+		tieredState: c.tieredState,
+		remote:      c.remote.WithContext(ctx),
+	}
+}
+
+// Healthy Redis 当前是否可用
+func (c *tieredCache) Healthy() bool {
+	return c.healthy.Load()
+}
+
+// Set 设置缓存值
+func (c *tieredCache) Set(key string, value interface{}, expiration time.Duration) error {
+	if !c.Healthy() {
+		return c.local.Set(key, value, expiration)
+	}
+
+	if err := c.remote.Set(key, value, expiration); err != nil {
+		if c.handleError(err) {
+			return c.local.Set(key, value, expiration)
+		}
+		return err
+	}
+
+	if c.l1Enabled {
+		return c.local.Set(key, value, c.l1TTL(expiration))
+	}
+	return nil
+}
+
+// Get 获取缓存值
+func (c *tieredCache) Get(key string) (string, error) {
+	if c.l1Enabled || !c.Healthy() {
+		if value, err := c.local.Get(key); err == nil {
+			return value, nil
+		}
+	}
+	if !c.Healthy() {
+		return "", redis.Nil
+	}
+
+	value, err := c.remote.Get(key)
+	if err != nil {
+		if c.handleError(err) {
+			return c.local.Get(key)
+		}
+		return "", err
+	}
+
+	if c.l1Enabled {
+		c.local.Set(key, value, c.localTTL)
+	}
+	return value, nil
+}
+
+// Delete 删除缓存
+func (c *tieredCache) Delete(key string) error {
+	c.local.Delete(key)
+
+	if !c.Healthy() {
+		c.recordPending(key, "")
+		return nil
+	}
+
+	if err := c.remote.Delete(key); err != nil {
+		if c.handleError(err) {
+			c.recordPending(key, "")
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// Exists 检查缓存是否存在
+func (c *tieredCache) Exists(key string) (bool, error) {
+	if c.l1Enabled || !c.Healthy() {
+		if exists, _ := c.local.Exists(key); exists {
+			return true, nil
+		}
+	}
+	if !c.Healthy() {
+		return false, nil
+	}
+
+	exists, err := c.remote.Exists(key)
+	if err != nil {
+		if c.handleError(err) {
+			return c.local.Exists(key)
+		}
+		return false, err
+	}
+	return exists, nil
+}
+
+// SetJSON 设置 JSON 格式的缓存
+func (c *tieredCache) SetJSON(key string, value interface{}, expiration time.Duration) error {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.Set(key, jsonData, expiration)
+}
+
+// GetJSON 获取 JSON 格式的缓存
+func (c *tieredCache) GetJSON(key string, dest interface{}) error {
+	jsonData, err := c.Get(key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(jsonData), dest)
+}
+
+// DeletePattern 根据模式删除缓存
+func (c *tieredCache) DeletePattern(pattern string) error {
+	c.local.DeletePattern(pattern)
+
+	if !c.Healthy() {
+		c.recordPending("", pattern)
+		return nil
+	}
+
+	if err := c.remote.DeletePattern(pattern); err != nil {
+		if c.handleError(err) {
+			c.recordPending("", pattern)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// SetJSONWithTags 设置 JSON 格式的缓存并登记到标签
+func (c *tieredCache) SetJSONWithTags(key string, value interface{}, expiration time.Duration, tags ...string) error {
+	if !c.Healthy() {
+		return c.local.SetJSONWithTags(key, value, expiration, tags...)
+	}
+
+	if err := c.remote.SetJSONWithTags(key, value, expiration, tags...); err != nil {
+		if c.handleError(err) {
+			return c.local.SetJSONWithTags(key, value, expiration, tags...)
+		}
+		return err
+	}
+
+	if c.l1Enabled {
+		return c.local.SetJSONWithTags(key, value, c.l1TTL(expiration), tags...)
+	}
+	return nil
+}
+
+// InvalidateTag 删除标签下登记的全部缓存
+func (c *tieredCache) InvalidateTag(tags ...string) error {
+	c.local.InvalidateTag(tags...)
+
+	if !c.Healthy() {
+		c.recordPendingTags(tags)
+		return nil
+	}
+
+	// 从 Redis 回填到 L1 的条目没有标签信息，需按 Redis 中登记的成员清理
+	if c.l1Enabled {
+		for _, tag := range tags {
+			keys, err := c.client.SMembers(c.ctx, tagKey(tag)).Result()
+			if err != nil {
+				break
+			}
+			for _, key := range keys {
+				c.local.Delete(key)
+			}
+		}
+	}
+
+	if err := c.remote.InvalidateTag(tags...); err != nil {
+		if c.handleError(err) {
+			c.recordPendingTags(tags)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// Increment 递增计数器
+// 计数器以 Redis 为准，不写入 L1，避免多实例之间计数分叉
+func (c *tieredCache) Increment(key string) (int64, error) {
+	if !c.Healthy() {
+		return c.local.Increment(key)
+	}
+
+	value, err := c.remote.Increment(key)
+	if err != nil {
+		if c.handleError(err) {
+			return c.local.Increment(key)
+		}
+		return 0, err
+	}
+
+	if c.l1Enabled {
+		c.local.Delete(key)
+	}
+	return value, nil
+}
+
+// Expire 设置过期时间
+func (c *tieredCache) Expire(key string, expiration time.Duration) error {
+	if c.l1Enabled || !c.Healthy() {
+		c.local.Expire(key, c.l1TTL(expiration))
+	}
+	if !c.Healthy() {
+		return nil
+	}
+
+	if err := c.remote.Expire(key, expiration); err != nil {
+		if c.handleError(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// l1TTL 计算本地缓存的过期时间，不超过配置的 LocalTTL
+func (c *tieredCache) l1TTL(expiration time.Duration) time.Duration {
+	if expiration <= 0 || expiration > c.localTTL {
+		return c.localTTL
+	}
+	return expiration
+}
+
+// handleError 处理 Redis 错误，连接类错误会触发降级并返回 true
+func (c *tieredCache) handleError(err error) bool {
+	if !isConnectionError(err) {
+		return false
+	}
+	c.markDown(err)
+	return true
+}
+
+// markDown 标记 Redis 不可用
+func (c *tieredCache) markDown(err error) {
+	if c.healthy.CompareAndSwap(true, false) {
+		c.logger.Warn("Redis 不可用，缓存降级为本地模式", slog.String("error", err.Error()))
+	}
+}
+
+// recordPending 记录降级期间的删除操作，等待重连后回放
+func (c *tieredCache) recordPending(key, pattern string) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	if key != "" {
+		c.pendingKeys = append(c.pendingKeys, key)
+	}
+	if pattern != "" {
+		c.pendingPatterns = append(c.pendingPatterns, pattern)
+	}
+}
+
+// recordPendingTags 记录降级期间的标签失效操作，等待重连后回放
+func (c *tieredCache) recordPendingTags(tags []string) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	c.pendingTags = append(c.pendingTags, tags...)
+}
+
+// reconnectLoop 后台定期探测 Redis，恢复后切回正常模式
+func (c *tieredCache) reconnectLoop() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.reconnectInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			if !c.Healthy() {
+				c.tryReconnect()
+			}
+		}
+	}
+}
+
+// tryReconnect 尝试重连 Redis，成功后回放降级期间的删除操作
+func (c *tieredCache) tryReconnect() bool {
+	if err := c.ping(); err != nil {
+		return false
+	}
+
+	c.pendingMu.Lock()
+	keys, patterns, tags := c.pendingKeys, c.pendingPatterns, c.pendingTags
+	c.pendingKeys, c.pendingPatterns, c.pendingTags = nil, nil, nil
+	c.pendingMu.Unlock()
+
+	if len(keys) > 0 {
+		if err := c.client.Del(c.ctx, keys...).Err(); err != nil {
+			c.requeue(keys, patterns, tags)
+			return false
+		}
+	}
+	if len(tags) > 0 {
+		if err := c.remote.InvalidateTag(tags...); err != nil {
+			c.requeue(nil, patterns, tags)
+			return false
+		}
+	}
+	for i, pattern := range patterns {
+		if err := c.remote.DeletePattern(pattern); err != nil {
+			c.requeue(nil, patterns[i:], nil)
+			return false
+		}
+	}
+
+	// 降级期间本地缓存充当唯一缓存，恢复后其内容可能与 Redis 不一致，直接清空
+	c.local.Flush()
+	c.healthy.Store(true)
+	c.logger.Info("Redis 连接已恢复，缓存切回正常模式", slog.String("mode", c.mode()))
+	return true
+}
+
+// requeue 回放失败时将未完成的操作放回队列
+func (c *tieredCache) requeue(keys, patterns, tags []string) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	c.pendingKeys = append(keys, c.pendingKeys...)
+	c.pendingPatterns = append(patterns, c.pendingPatterns...)
+	c.pendingTags = append(tags, c.pendingTags...)
+}
+
+// ping 探测 Redis 连接
+func (c *tieredCache) ping() error {
+	ctx, cancel := context.WithTimeout(c.ctx, 2*time.Second)
+	defer cancel()
+	return c.client.Ping(ctx).Err()
+}
+
+// mode 返回当前缓存模式名称
+func (c *tieredCache) mode() string {
+	if c.l1Enabled {
+		return CacheDriverTiered
+	}
+	return CacheDriverRedis
+}
+
+// isConnectionError 判断是否为连接类错误（非 redis.Nil、非 Redis 返回的业务错误）
+func isConnectionError(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return false
+	}
+	var replyErr redis.Error
+	return !errors.As(err, &replyErr)
+}
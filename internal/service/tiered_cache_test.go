@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/go-redis/redismock/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+var errConnRefused = errors.New("dial tcp 127.0.0.1:6379: connect: connection refused")
+
+func TestTieredCache_L1(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	cache := newTieredCache(db, TieredCacheConfig{L1Enabled: true, MaxEntries: 10, LocalTTL: time.Minute})
+
+	t.Run("L2 命中后回填 L1", func(t *testing.T) {
+		mock.ExpectGet("drama:1").SetVal("value")
+
+		value, err := cache.Get("drama:1")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+
+		// 第二次读取直接命中 L1，不再访问 Redis
+		value, err = cache.Get("drama:1")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("写入同时更新两级缓存，L1 过期时间不超过 LocalTTL", func(t *testing.T) {
+		mock.ExpectSet("drama:2", "value", time.Hour).SetVal("OK")
+
+		err := cache.Set("drama:2", "value", time.Hour)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		value, err := cache.local.Get("drama:2")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+		assert.Equal(t, time.Minute, cache.l1TTL(time.Hour))
+	})
+
+	t.Run("删除同时清理两级缓存", func(t *testing.T) {
+		mock.ExpectDel("drama:2").SetVal(1)
+
+		err := cache.Delete("drama:2")
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		exists, _ := cache.local.Exists("drama:2")
+		assert.False(t, exists)
+	})
+}
+
+func TestTieredCache_Fallback(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	cache := newTieredCache(db, TieredCacheConfig{MaxEntries: 10})
+
+	t.Run("Redis 正常时不使用本地缓存", func(t *testing.T) {
+		mock.ExpectSet("drama:1", "value", time.Hour).SetVal("OK")
+
+		err := cache.Set("drama:1", "value", time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, cache.local.Len())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("业务错误不会触发降级", func(t *testing.T) {
+		mock.ExpectGet("missing").SetErr(redis.Nil)
+
+		_, err := cache.Get("missing")
+		assert.Equal(t, redis.Nil, err)
+		assert.True(t, cache.Healthy())
+	})
+
+	t.Run("连接错误时降级为本地缓存", func(t *testing.T) {
+		mock.ExpectSet("drama:2", "value", time.Hour).SetErr(errConnRefused)
+
+		err := cache.Set("drama:2", "value", time.Hour)
+		assert.NoError(t, err)
+		assert.False(t, cache.Healthy())
+
+		// 降级后读写均走本地缓存
+		value, err := cache.Get("drama:2")
+		assert.NoError(t, err)
+		assert.Equal(t, "value", value)
+
+		err = cache.DeletePattern("dramas:page:*")
+		assert.NoError(t, err)
+		err = cache.InvalidateTag(TagDramaList)
+		assert.NoError(t, err)
+		err = cache.Delete("drama:1")
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("重连失败保持降级", func(t *testing.T) {
+		mock.ExpectPing().SetErr(errConnRefused)
+
+		assert.False(t, cache.tryReconnect())
+		assert.False(t, cache.Healthy())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("重连成功后回放删除操作并清空本地缓存", func(t *testing.T) {
+		mock.ExpectPing().SetVal("PONG")
+		mock.ExpectDel("drama:1").SetVal(1)
+		mock.ExpectTxPipeline()
+		mock.ExpectSMembers("tag:drama-list").SetVal([]string{})
+		mock.ExpectDel("tag:drama-list").SetVal(0)
+		mock.ExpectTxPipelineExec()
+		mock.ExpectScan(0, "dramas:page:*", scanBatchSize).SetVal([]string{}, 0)
+
+		assert.True(t, cache.tryReconnect())
+		assert.True(t, cache.Healthy())
+		assert.Equal(t, 0, cache.local.Len())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestNewTieredCacheService_StartsDegraded(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	mock.ExpectPing().SetErr(errConnRefused)
+
+	cacheService := NewTieredCacheService(db, TieredCacheConfig{MaxEntries: 10, ReconnectInterval: time.Hour})
+	cache := cacheService.(*tieredCache)
+	defer cache.Close()
+
+	assert.False(t, cache.Healthy())
+
+	err := cacheService.Set("drama:1", "value", time.Hour)
+	assert.NoError(t, err)
+
+	value, err := cacheService.Get("drama:1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,493 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/storage"
+	"gin-mysql-api/pkg/transcode"
+)
+
+// hlsPrefix HLS 转码结果在存储中的目录，结果保存在 hls/<剧集 ID>/<任务 ID>/<档位>/ 下
+const hlsPrefix = "hls"
+
+// errVideoChanged 转码期间剧集已删除或更换了视频，转码结果作废
+var errVideoChanged = errors.New("剧集视频已变更")
+
+// TranscodeService 视频转码服务接口
+// 剧集关联上传的视频后创建转码任务，后台任务按码率阶梯转码为 HLS 并保存到存储，
+// 播放时由 PlaybackService 根据转码结果生成主播放列表
+type TranscodeService interface {
+	// WithContext 返回绑定上下文的服务，ctx 取消时正在执行的转码会被终止
+	WithContext(ctx context.Context) TranscodeService
+	// Enqueue 剧集创建或更换视频后调用：删除旧视频的转码结果，剧集关联了上传的视频时创建转码任务
+	Enqueue(episode *models.Episode) error
+	// Retranscode 保留当前的转码结果并创建新的转码任务，完成后替换；用于轮换受保护剧集的内容密钥
+	Retranscode(episode *models.Episode) error
+	// ProcessPending 依次处理待处理的任务直到没有任务，返回处理的任务数
+	// 单个任务失败只记录在任务上，未达到最大尝试次数时等待下次重试
+	ProcessPending() (int, error)
+}
+
+// TranscodeServiceConfig 视频转码配置
+type TranscodeServiceConfig struct {
+	Renditions  []transcode.Rendition
+	JobTimeout  time.Duration
+	MaxAttempts int
+	// WorkDir 下载原视频与转码输出的临时目录，为空时使用系统临时目录
+	WorkDir string
+}
+
+// NewTranscodeServiceConfig 根据应用配置生成视频转码配置
+func NewTranscodeServiceConfig(cfg *config.Config) TranscodeServiceConfig {
+	ladder := cfg.Transcode.GetRenditions()
+	renditions := make([]transcode.Rendition, 0, len(ladder))
+	for _, r := range ladder {
+		renditions = append(renditions, transcode.Rendition{
+			Name:         r.Name,
+			Height:       r.Height,
+			VideoBitrate: r.VideoBitrate,
+			AudioBitrate: r.AudioBitrate,
+		})
+	}
+	return TranscodeServiceConfig{
+		Renditions:  renditions,
+		JobTimeout:  cfg.Transcode.GetJobTimeout(),
+		MaxAttempts: cfg.Transcode.GetMaxAttempts(),
+	}
+}
+
+// transcodeService 视频转码服务实现
+type transcodeService struct {
+	repo         repository.TranscodeRepository
+	episodes     repository.EpisodeRepository
+	assets       repository.MediaAssetRepository
+	store        storage.Storage
+	executor     transcode.Executor
+	keys         ContentKeyService
+	cacheService CacheService
+	conf         TranscodeServiceConfig
+	now          func() time.Time
+	logger       *slog.Logger
+	ctx          context.Context
+}
+
+// NewTranscodeService 创建视频转码服务，executor 执行实际的转码（如 transcode.FFmpeg）
+// keys 为受保护的剧集生成内容密钥，为 nil 时受保护剧集的转码任务失败；log 为 nil 时使用全局默认 Logger
+func NewTranscodeService(
+	repo repository.TranscodeRepository,
+	episodes repository.EpisodeRepository,
+	assets repository.MediaAssetRepository,
+	store storage.Storage,
+	executor transcode.Executor,
+	keys ContentKeyService,
+	cacheService CacheService,
+	conf TranscodeServiceConfig,
+	log *slog.Logger,
+) TranscodeService {
+	if conf.JobTimeout <= 0 {
+		conf.JobTimeout = time.Hour
+	}
+	if conf.MaxAttempts <= 0 {
+		conf.MaxAttempts = 1
+	}
+	return &transcodeService{
+		repo:         repo,
+		episodes:     episodes,
+		assets:       assets,
+		store:        store,
+		executor:     executor,
+		keys:         keys,
+		cacheService: cacheService,
+		conf:         conf,
+		now:          time.Now,
+		logger:       logger.OrDefault(log),
+		ctx:          context.Background(),
+	}
+}
+
+// WithContext 返回绑定上下文的视频转码服务
+func (s *transcodeService) WithContext(ctx context.Context) TranscodeService {
+	scoped := *s
+	scoped.ctx = ctx
+	scoped.repo = s.repo.WithContext(ctx)
+	scoped.episodes = s.episodes.WithContext(ctx)
+	scoped.assets = s.assets.WithContext(ctx)
+	if s.keys != nil {
+		scoped.keys = s.keys.WithContext(ctx)
+	}
+	if s.cacheService != nil {
+		scoped.cacheService = s.cacheService.WithContext(ctx)
+	}
+	return &scoped
+}
+
+// Enqueue 删除旧视频的转码结果并创建转码任务，引用外部地址的剧集只删除旧结果
+func (s *transcodeService) Enqueue(episode *models.Episode) error {
+	old, err := s.repo.ReplaceRenditions(episode.ID, nil)
+	if err != nil {
+		return fmt.Errorf("删除旧的转码结果失败: %w", err)
+	}
+	if len(old) > 0 {
+		s.deleteRenditionFiles(old)
+		s.invalidateCache(episode.ID)
+	}
+	if episode.VideoAssetID == nil {
+		s.pruneKeys(episode.ID, 0)
+		return nil
+	}
+	return s.createJob(episode)
+}
+
+// Retranscode 为关联了上传视频的剧集创建转码任务，不删除当前的转码结果
+func (s *transcodeService) Retranscode(episode *models.Episode) error {
+	if episode.VideoAssetID == nil {
+		return errors.New("剧集没有关联上传的视频")
+	}
+	return s.createJob(episode)
+}
+
+// createJob 为剧集当前的视频创建转码任务
+func (s *transcodeService) createJob(episode *models.Episode) error {
+	asset, err := s.assets.GetByID(*episode.VideoAssetID)
+	if err != nil {
+		return fmt.Errorf("查询视频文件失败: %w", err)
+	}
+	if asset == nil {
+		return errors.New("视频文件不存在")
+	}
+
+	job := &models.TranscodeJob{
+		EpisodeID:     episode.ID,
+		SourceAssetID: asset.ID,
+		SourceKey:     asset.StorageKey,
+		Status:        models.TranscodeStatusPending,
+	}
+	if err := s.repo.CreateJob(job); err != nil {
+		return fmt.Errorf("创建转码任务失败: %w", err)
+	}
+
+	s.logger.InfoContext(s.ctx, "转码任务已创建", slog.Any("job_id", job.ID), slog.Any("episode_id", episode.ID))
+	return nil
+}
+
+// ProcessPending 依次领取并处理任务，ctx 取消时停止
+func (s *transcodeService) ProcessPending() (int, error) {
+	processed := 0
+	for s.ctx.Err() == nil {
+		job, err := s.repo.ClaimNextJob(s.now(), s.conf.JobTimeout, s.conf.MaxAttempts)
+		if err != nil {
+			return processed, fmt.Errorf("领取转码任务失败: %w", err)
+		}
+		if job == nil {
+			break
+		}
+		s.process(job)
+		processed++
+	}
+	return processed, nil
+}
+
+// process 处理任务并记录结果
+func (s *transcodeService) process(job *models.TranscodeJob) {
+	log := s.logger.With(slog.Any("job_id", job.ID), slog.Any("episode_id", job.EpisodeID))
+	start := s.now()
+	err := s.run(job)
+	now := s.now()
+
+	repo := s.repo
+	switch {
+	case err == nil:
+		job.Status = models.TranscodeStatusCompleted
+		job.Error = ""
+		job.FinishedAt = &now
+		log.InfoContext(s.ctx, "视频转码完成", slog.Duration("elapsed", now.Sub(start)))
+	case s.ctx.Err() != nil:
+		// 服务关闭导致的中断不计入尝试次数，由下次启动的实例重新处理
+		repo = s.repo.WithContext(context.WithoutCancel(s.ctx))
+		job.Status = models.TranscodeStatusPending
+		job.Attempts--
+		log.WarnContext(s.ctx, "视频转码已中断", slog.String("error", err.Error()))
+	case errors.Is(err, errVideoChanged) || errors.Is(err, ErrEncryptionDisabled) || job.Attempts >= s.conf.MaxAttempts:
+		job.Status = models.TranscodeStatusFailed
+		job.Error = truncateError(err)
+		job.FinishedAt = &now
+		log.ErrorContext(s.ctx, "视频转码失败", slog.Int("attempts", job.Attempts), slog.String("error", err.Error()))
+	default:
+		job.Status = models.TranscodeStatusPending
+		job.Error = truncateError(err)
+		log.WarnContext(s.ctx, "视频转码失败，等待重试", slog.Int("attempts", job.Attempts), slog.String("error", err.Error()))
+	}
+
+	if err := repo.UpdateJob(job); err != nil {
+		log.ErrorContext(s.ctx, "更新转码任务失败", slog.String("error", err.Error()))
+	}
+}
+
+// run 下载原视频、转码、上传转码结果并替换剧集的旧结果，受保护的剧集使用新的内容密钥加密分片
+func (s *transcodeService) run(job *models.TranscodeJob) error {
+	episode, err := s.source(job)
+	if err != nil {
+		return err
+	}
+	asset, err := s.assets.GetByID(job.SourceAssetID)
+	if err != nil {
+		return fmt.Errorf("查询视频文件失败: %w", err)
+	}
+	if asset == nil {
+		return errVideoChanged
+	}
+
+	workDir, err := os.MkdirTemp(s.conf.WorkDir, "transcode-*")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	input := filepath.Join(workDir, "source"+path.Ext(job.SourceKey))
+	if err := s.download(job.SourceKey, input); err != nil {
+		return err
+	}
+
+	var encryption *transcode.Encryption
+	if episode.Protected {
+		if s.keys == nil {
+			return ErrEncryptionDisabled
+		}
+		if encryption, err = s.keys.Create(job.EpisodeID, job.ID); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.conf.JobTimeout)
+	defer cancel()
+	outputDir := filepath.Join(workDir, "out")
+	outputs, err := s.executor.Transcode(ctx, transcode.Request{
+		Input:      input,
+		OutputDir:  outputDir,
+		Width:      asset.Width,
+		Height:     asset.Height,
+		HasAudio:   asset.AudioCodec != "",
+		Renditions: transcode.SelectRenditions(s.conf.Renditions, asset.Height),
+		Encryption: encryption,
+	})
+	if err != nil {
+		return fmt.Errorf("转码失败: %w", err)
+	}
+
+	prefix := fmt.Sprintf("%s/%d/%d", hlsPrefix, job.EpisodeID, job.ID)
+	uploaded, err := s.upload(outputDir, prefix)
+	if err != nil {
+		s.deleteObjects(uploaded)
+		return err
+	}
+
+	// 转码耗时较长，期间剧集可能更换了视频
+	if err := s.checkSource(job); err != nil {
+		s.deleteObjects(uploaded)
+		return err
+	}
+
+	renditions := make([]models.EpisodeRendition, 0, len(outputs))
+	for _, output := range outputs {
+		renditions = append(renditions, models.EpisodeRendition{
+			EpisodeID:   job.EpisodeID,
+			JobID:       job.ID,
+			Name:        output.Name,
+			Width:       output.Width,
+			Height:      output.Height,
+			Bitrate:     output.Bandwidth,
+			Codecs:      output.Codecs,
+			PlaylistKey: prefix + "/" + output.Playlist,
+		})
+	}
+	old, err := s.repo.ReplaceRenditions(job.EpisodeID, renditions)
+	if err != nil {
+		s.deleteObjects(uploaded)
+		return fmt.Errorf("保存转码结果失败: %w", err)
+	}
+	s.deleteRenditionFiles(old)
+	s.pruneKeys(job.EpisodeID, job.ID)
+	s.invalidateCache(job.EpisodeID)
+	return nil
+}
+
+// source 获取剧集并检查是否仍然使用任务的原视频
+func (s *transcodeService) source(job *models.TranscodeJob) (*models.Episode, error) {
+	episode, err := s.episodes.GetByID(job.EpisodeID)
+	if err != nil {
+		return nil, fmt.Errorf("查询剧集失败: %w", err)
+	}
+	if episode == nil || episode.VideoAssetID == nil || *episode.VideoAssetID != job.SourceAssetID {
+		return nil, errVideoChanged
+	}
+	return episode, nil
+}
+
+// checkSource 检查剧集是否仍然使用任务的原视频
+func (s *transcodeService) checkSource(job *models.TranscodeJob) error {
+	_, err := s.source(job)
+	return err
+}
+
+// pruneKeys 删除不再被转码结果引用的内容密钥，失败时只记录日志
+func (s *transcodeService) pruneKeys(episodeID, keepJobID uint) {
+	if s.keys == nil {
+		return
+	}
+	if err := s.keys.Prune(episodeID, keepJobID); err != nil {
+		s.logger.WarnContext(s.ctx, "删除内容密钥失败", slog.Any("episode_id", episodeID), slog.String("error", err.Error()))
+	}
+}
+
+// download 将原视频下载到本地文件，ffmpeg 需要可随机访问的输入
+func (s *transcodeService) download(key, dst string) error {
+	r, _, err := s.store.Get(s.ctx, key)
+	if err != nil {
+		return fmt.Errorf("读取原视频失败: %w", err)
+	}
+	defer r.Close()
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("下载原视频失败: %w", err)
+	}
+	return f.Close()
+}
+
+// upload 将输出目录中的所有文件上传到 prefix 下，返回已上传的对象
+func (s *transcodeService) upload(dir, prefix string) ([]string, error) {
+	var uploaded []string
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		key := prefix + "/" + filepath.ToSlash(rel)
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if err := s.store.Put(s.ctx, key, f, info.Size(), hlsContentType(key)); err != nil {
+			return err
+		}
+		uploaded = append(uploaded, key)
+		return nil
+	})
+	if err != nil {
+		return uploaded, fmt.Errorf("上传转码结果失败: %w", err)
+	}
+	return uploaded, nil
+}
+
+// deleteRenditionFiles 删除转码结果的播放列表及其引用的分片，失败时只记录日志
+func (s *transcodeService) deleteRenditionFiles(renditions []models.EpisodeRendition) {
+	deleteRenditionFiles(s.ctx, s.store, s.logger, renditions)
+}
+
+// deleteObjects 删除存储中的对象，失败时只记录日志
+func (s *transcodeService) deleteObjects(keys []string) {
+	deleteObjects(s.ctx, s.store, s.logger, keys)
+}
+
+// deleteRenditionFiles 删除转码结果的播放列表及其引用的分片，失败时只记录日志
+func deleteRenditionFiles(ctx context.Context, store storage.Storage, log *slog.Logger, renditions []models.EpisodeRendition) {
+	for _, rendition := range renditions {
+		segments, err := playlistSegments(ctx, store, rendition.PlaylistKey)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.WarnContext(ctx, "读取播放列表失败", slog.String("path", rendition.PlaylistKey), slog.String("error", err.Error()))
+			continue
+		}
+		deleteObjects(ctx, store, log, append(segments, rendition.PlaylistKey))
+	}
+}
+
+// playlistSegments 获取媒体播放列表引用的分片的存储路径
+func playlistSegments(ctx context.Context, store storage.Storage, key string) ([]string, error) {
+	r, _, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var segments []string
+	dir := path.Dir(key)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.Contains(line, "://") {
+			continue
+		}
+		if segment, err := storage.CleanKey(path.Join(dir, line)); err == nil && strings.HasPrefix(segment, dir+"/") {
+			segments = append(segments, segment)
+		}
+	}
+	return segments, scanner.Err()
+}
+
+// deleteObjects 删除存储中的对象，失败时只记录日志
+func deleteObjects(ctx context.Context, store storage.Storage, log *slog.Logger, keys []string) {
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			log.WarnContext(ctx, "删除存储对象失败", slog.String("path", key), slog.String("error", err.Error()))
+		}
+	}
+}
+
+// invalidateCache 转码结果变更后失效剧集的缓存，播放源随之更新
+func (s *transcodeService) invalidateCache(episodeID uint) {
+	if s.cacheService == nil {
+		return
+	}
+	if err := s.cacheService.InvalidateTag(TagEpisode(episodeID)); err != nil {
+		s.logger.WarnContext(s.ctx, "缓存失效失败", slog.Any("episode_id", episodeID), slog.String("error", err.Error()))
+	}
+}
+
+// hlsContentType HLS 文件的内容类型
+func hlsContentType(key string) string {
+	switch path.Ext(key) {
+	case ".m3u8":
+		return hlsMimeType
+	case ".ts":
+		return "video/mp2t"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// truncateError 截断错误信息以适应任务的 error 字段
+func truncateError(err error) string {
+	msg := err.Error()
+	if len(msg) > 1000 {
+		msg = strings.ToValidUTF8(msg[:1000], "")
+	}
+	return msg
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/internal/testutil"
+	"gin-mysql-api/pkg/keyring"
+	"gin-mysql-api/pkg/storage"
+	"gin-mysql-api/pkg/transcode"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// fakeExecutor 不调用 ffmpeg 的转码执行器，为每档写入包含两个分片的媒体播放列表
+type fakeExecutor struct {
+	requests []transcode.Request
+	failures int // 前 failures 次调用返回错误
+}
+
+func (e *fakeExecutor) Transcode(_ context.Context, req transcode.Request) ([]transcode.Output, error) {
+	e.requests = append(e.requests, req)
+	if len(e.requests) <= e.failures {
+		return nil, errors.New("ffmpeg: exit status 1")
+	}
+
+	var outputs []transcode.Output
+	for _, r := range req.Renditions {
+		dir := filepath.Join(req.OutputDir, r.Name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+		playlist := "#EXTM3U\n#EXT-X-TARGETDURATION:6\n#EXTINF:6.0,\nsegment_0000.ts\n#EXTINF:4.0,\nsegment_0001.ts\n#EXT-X-ENDLIST\n"
+		files := map[string]string{"index.m3u8": playlist, "segment_0000.ts": "a", "segment_0001.ts": "b"}
+		for name, content := range files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				return nil, err
+			}
+		}
+		outputs = append(outputs, transcode.Output{
+			Name:      r.Name,
+			Width:     transcode.ScaledWidth(req.Width, req.Height, r.Height),
+			Height:    r.Height,
+			Bandwidth: int64(r.VideoBitrate) * 1000,
+			Codecs:    "avc1.4d401f",
+			Playlist:  path.Join(r.Name, transcode.PlaylistName),
+		})
+	}
+	return outputs, nil
+}
+
+// transcodeFixture 转码与播放服务测试的依赖
+type transcodeFixture struct {
+	db       *gorm.DB
+	store    storage.Storage
+	repo     repository.TranscodeRepository
+	episodes repository.EpisodeRepository
+	assets   repository.MediaAssetRepository
+	keys     ContentKeyService
+	executor *fakeExecutor
+	service  *transcodeService
+}
+
+// newTranscodeFixture 基于测试数据库与本地存储创建转码服务，码率阶梯为 360p 与 720p，最多尝试 2 次
+func newTranscodeFixture(t *testing.T) *transcodeFixture {
+	t.Helper()
+	db := testutil.SetupTestDB()
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+
+	f := &transcodeFixture{
+		db:       db,
+		store:    storage.NewLocal(t.TempDir()),
+		repo:     repository.NewTranscodeRepository(db),
+		episodes: repository.NewEpisodeRepository(db),
+		assets:   repository.NewMediaAssetRepository(db),
+		executor: &fakeExecutor{},
+	}
+	ring, err := keyring.New([]keyring.MasterKey{{ID: "m1", Secret: []byte(strings.Repeat("m", 32))}})
+	require.NoError(t, err)
+	f.keys = NewContentKeyService(f.repo, ring, "https://api.example.com/", nil)
+	f.service = NewTranscodeService(f.repo, f.episodes, f.assets, f.store, f.executor, f.keys, nil, TranscodeServiceConfig{
+		Renditions: []transcode.Rendition{
+			{Name: "360p", Height: 360, VideoBitrate: 800, AudioBitrate: 96},
+			{Name: "720p", Height: 720, VideoBitrate: 2800, AudioBitrate: 128},
+		},
+		MaxAttempts: 2,
+		WorkDir:     t.TempDir(),
+	}, nil).(*transcodeService)
+	return f
+}
+
+// createEpisode 上传视频并创建引用该视频的剧集
+func (f *transcodeFixture) createEpisode(t *testing.T, height int) *models.Episode {
+	t.Helper()
+	drama := &models.Drama{Title: "短剧"}
+	require.NoError(t, f.db.Create(drama).Error)
+	key := fmt.Sprintf("videos/%d.mp4", time.Now().UnixNano())
+	asset := createTestAsset(t, f.assets, f.store, &models.MediaAsset{
+		OwnerRole: "admin", Type: "video", StorageKey: key, ContentType: "video/mp4",
+		Width: height * 16 / 9, Height: height, AudioCodec: "mp4a", Bitrate: 1000000,
+	})
+	episode := &models.Episode{DramaID: drama.ID, Title: "第一集", EpisodeNum: 1, Duration: 10, VideoURL: key, VideoAssetID: &asset.ID}
+	require.NoError(t, f.db.Create(episode).Error)
+	return episode
+}
+
+// job 获取剧集最近的转码任务
+func (f *transcodeFixture) job(t *testing.T, episodeID uint) models.TranscodeJob {
+	t.Helper()
+	var job models.TranscodeJob
+	require.NoError(t, f.db.Where("episode_id = ?", episodeID).Order("id DESC").First(&job).Error)
+	return job
+}
+
+// exists 存储中是否存在对象
+func (f *transcodeFixture) exists(key string) bool {
+	_, err := f.store.Stat(context.Background(), key)
+	return err == nil
+}
+
+func TestTranscodeService(t *testing.T) {
+	t.Run("转码并保存各档位", func(t *testing.T) {
+		f := newTranscodeFixture(t)
+		episode := f.createEpisode(t, 720)
+		require.NoError(t, f.service.Enqueue(episode))
+
+		processed, err := f.service.ProcessPending()
+		require.NoError(t, err)
+		assert.Equal(t, 1, processed)
+
+		job := f.job(t, episode.ID)
+		assert.Equal(t, models.TranscodeStatusCompleted, job.Status)
+		assert.NotNil(t, job.FinishedAt)
+
+		require.Len(t, f.executor.requests, 1)
+		req := f.executor.requests[0]
+		assert.True(t, req.HasAudio)
+		assert.Equal(t, 1280, req.Width)
+		assert.Len(t, req.Renditions, 2)
+
+		renditions, err := f.repo.ListRenditions(episode.ID)
+		require.NoError(t, err)
+		require.Len(t, renditions, 2)
+		assert.Equal(t, fmt.Sprintf("hls/%d/%d/360p/index.m3u8", episode.ID, job.ID), renditions[0].PlaylistKey)
+		assert.Equal(t, 640, renditions[0].Width)
+		assert.True(t, f.exists(renditions[1].PlaylistKey))
+		assert.True(t, f.exists(path.Join(path.Dir(renditions[1].PlaylistKey), "segment_0001.ts")))
+	})
+
+	t.Run("跳过高于原视频分辨率的档位", func(t *testing.T) {
+		f := newTranscodeFixture(t)
+		episode := f.createEpisode(t, 480)
+		require.NoError(t, f.service.Enqueue(episode))
+
+		_, err := f.service.ProcessPending()
+		require.NoError(t, err)
+		require.Len(t, f.executor.requests, 1)
+		require.Len(t, f.executor.requests[0].Renditions, 1)
+		assert.Equal(t, "360p", f.executor.requests[0].Renditions[0].Name)
+	})
+
+	t.Run("失败后重试到最大尝试次数", func(t *testing.T) {
+		f := newTranscodeFixture(t)
+		f.executor.failures = 2
+		episode := f.createEpisode(t, 720)
+		require.NoError(t, f.service.Enqueue(episode))
+
+		_, err := f.service.ProcessPending()
+		require.NoError(t, err)
+		job := f.job(t, episode.ID)
+		assert.Equal(t, models.TranscodeStatusFailed, job.Status)
+		assert.Equal(t, 2, job.Attempts)
+		assert.Contains(t, job.Error, "exit status 1")
+		assert.Len(t, f.executor.requests, 2)
+
+		renditions, err := f.repo.ListRenditions(episode.ID)
+		require.NoError(t, err)
+		assert.Empty(t, renditions)
+	})
+
+	t.Run("更换视频后删除旧的转码结果", func(t *testing.T) {
+		f := newTranscodeFixture(t)
+		episode := f.createEpisode(t, 720)
+		require.NoError(t, f.service.Enqueue(episode))
+		_, err := f.service.ProcessPending()
+		require.NoError(t, err)
+		old, err := f.repo.ListRenditions(episode.ID)
+		require.NoError(t, err)
+		require.NotEmpty(t, old)
+
+		// 改为引用外部地址
+		episode.VideoAssetID = nil
+		episode.VideoURL = "https://example.com/video.mp4"
+		require.NoError(t, f.db.Save(episode).Error)
+		require.NoError(t, f.service.Enqueue(episode))
+
+		renditions, err := f.repo.ListRenditions(episode.ID)
+		require.NoError(t, err)
+		assert.Empty(t, renditions)
+		for _, r := range old {
+			assert.False(t, f.exists(r.PlaylistKey), r.PlaylistKey)
+			assert.False(t, f.exists(path.Join(path.Dir(r.PlaylistKey), "segment_0000.ts")))
+		}
+	})
+
+	t.Run("转码期间剧集更换了视频", func(t *testing.T) {
+		f := newTranscodeFixture(t)
+		episode := f.createEpisode(t, 720)
+		require.NoError(t, f.service.Enqueue(episode))
+		require.NoError(t, f.db.Model(episode).Update("video_asset_id", nil).Error)
+
+		_, err := f.service.ProcessPending()
+		require.NoError(t, err)
+		job := f.job(t, episode.ID)
+		assert.Equal(t, models.TranscodeStatusFailed, job.Status)
+		assert.Equal(t, 1, job.Attempts)
+		assert.Empty(t, f.executor.requests)
+	})
+
+	t.Run("受保护的剧集加密分片并轮换密钥", func(t *testing.T) {
+		f := newTranscodeFixture(t)
+		episode := f.createEpisode(t, 720)
+		episode.Protected = true
+		require.NoError(t, f.db.Save(episode).Error)
+		require.NoError(t, f.service.Enqueue(episode))
+		_, err := f.service.ProcessPending()
+		require.NoError(t, err)
+
+		require.Len(t, f.executor.requests, 1)
+		first := f.executor.requests[0].Encryption
+		require.NotNil(t, first)
+		assert.Len(t, first.Key, 16)
+		assert.True(t, strings.HasPrefix(first.KeyURI, fmt.Sprintf("https://api.example.com/api/episodes/%d/keys/", episode.ID)), first.KeyURI)
+		firstKeyID := keyIDFromURI(t, first.KeyURI)
+		key, err := f.keys.Key(episode.ID, firstKeyID)
+		require.NoError(t, err)
+		assert.Equal(t, first.Key, key)
+
+		// 轮换密钥：新任务完成前保留当前的转码结果与密钥
+		require.NoError(t, f.service.Retranscode(episode))
+		renditions, err := f.repo.ListRenditions(episode.ID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, renditions)
+		_, err = f.service.ProcessPending()
+		require.NoError(t, err)
+
+		require.Len(t, f.executor.requests, 2)
+		second := f.executor.requests[1].Encryption
+		require.NotNil(t, second)
+		assert.NotEqual(t, first.Key, second.Key)
+		assert.NotEqual(t, first.KeyURI, second.KeyURI)
+		_, err = f.keys.Key(episode.ID, firstKeyID)
+		assert.ErrorIs(t, err, ErrContentKeyNotFound, "替换转码结果后删除旧密钥")
+	})
+
+	t.Run("未配置主密钥时受保护剧集转码失败", func(t *testing.T) {
+		f := newTranscodeFixture(t)
+		f.service.keys = NewContentKeyService(f.repo, nil, "https://api.example.com", nil)
+		episode := f.createEpisode(t, 720)
+		episode.Protected = true
+		require.NoError(t, f.db.Save(episode).Error)
+		require.NoError(t, f.service.Enqueue(episode))
+
+		_, err := f.service.ProcessPending()
+		require.NoError(t, err)
+		job := f.job(t, episode.ID)
+		assert.Equal(t, models.TranscodeStatusFailed, job.Status)
+		assert.Equal(t, 1, job.Attempts, "配置错误不重试")
+		assert.Empty(t, f.executor.requests)
+	})
+
+	t.Run("服务关闭时任务回到待处理状态", func(t *testing.T) {
+		f := newTranscodeFixture(t)
+		episode := f.createEpisode(t, 720)
+		require.NoError(t, f.service.Enqueue(episode))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		scoped := f.service.WithContext(ctx).(*transcodeService)
+		job, err := f.repo.ClaimNextJob(time.Now(), time.Hour, 2)
+		require.NoError(t, err)
+		cancel()
+		scoped.process(job)
+
+		job2 := f.job(t, episode.ID)
+		assert.Equal(t, models.TranscodeStatusPending, job2.Status)
+		assert.Equal(t, 0, job2.Attempts)
+	})
+}
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/storage"
+)
+
+// ErrRestoreConflict 恢复后会与现有内容冲突
+var ErrRestoreConflict = errors.New("无法恢复")
+
+// trashBatchSize 清理回收站每批处理的数量
+const trashBatchSize = 100
+
+// TrashService 回收站服务接口
+// 删除的短剧与剧集进入回收站，可以恢复或永久删除，超过保留期后由 PurgeExpired 永久删除
+type TrashService interface {
+	// WithContext 返回绑定请求上下文的服务
+	WithContext(ctx context.Context) TrashService
+	// List 按删除时间倒序分页获取回收站中的短剧或剧集，随短剧删除的剧集不单独列出
+	List(entityType string, page, pageSize int) (*models.PaginatedTrash, error)
+	// Restore 恢复短剧（连同与其一同删除的剧集）或剧集，不在回收站中时返回 ErrContentNotFound
+	// 剧集所属短剧仍在回收站，或剧集号已被其他剧集使用时返回 ErrRestoreConflict
+	Restore(entityType string, id uint) error
+	// Purge 永久删除回收站中的短剧（含所有剧集）或剧集，同时删除转码结果文件与不再被引用的媒体文件
+	Purge(entityType string, id uint) error
+	// PurgeExpired 永久删除超过保留期的短剧与剧集，返回删除的数量
+	PurgeExpired() (int, error)
+}
+
+// trashService 回收站服务实现
+type trashService struct {
+	trashRepo    repository.TrashRepository
+	dramaRepo    repository.DramaRepository
+	episodeRepo  repository.EpisodeRepository
+	mediaService MediaService
+	store        storage.Storage
+	cacheService CacheService
+	retention    time.Duration
+	now          func() time.Time
+	logger       *slog.Logger
+	ctx          context.Context
+}
+
+// NewTrashService 创建回收站服务，retention 为回收站的保留期
+// mediaService 为 nil 时不立即删除媒体文件，由媒体文件回收任务在保留期后删除；
+// cacheService 为 nil 时不失效缓存；log 为 nil 时使用全局默认 Logger
+func NewTrashService(
+	trashRepo repository.TrashRepository,
+	dramaRepo repository.DramaRepository,
+	episodeRepo repository.EpisodeRepository,
+	mediaService MediaService,
+	store storage.Storage,
+	cacheService CacheService,
+	retention time.Duration,
+	log *slog.Logger,
+) TrashService {
+	return &trashService{
+		trashRepo:    trashRepo,
+		dramaRepo:    dramaRepo,
+		episodeRepo:  episodeRepo,
+		mediaService: mediaService,
+		store:        store,
+		cacheService: cacheService,
+		retention:    retention,
+		now:          time.Now,
+		logger:       logger.OrDefault(log),
+		ctx:          context.Background(),
+	}
+}
+
+// WithContext 返回绑定请求上下文的回收站服务
+func (s *trashService) WithContext(ctx context.Context) TrashService {
+	scoped := *s
+	scoped.ctx = ctx
+	scoped.trashRepo = s.trashRepo.WithContext(ctx)
+	scoped.dramaRepo = s.dramaRepo.WithContext(ctx)
+	scoped.episodeRepo = s.episodeRepo.WithContext(ctx)
+	if s.mediaService != nil {
+		scoped.mediaService = s.mediaService.WithContext(ctx)
+	}
+	if s.cacheService != nil {
+		scoped.cacheService = s.cacheService.WithContext(ctx)
+	}
+	return &scoped
+}
+
+// List 获取回收站列表，每一项附带超过保留期后永久删除的时间
+func (s *trashService) List(entityType string, page, pageSize int) (*models.PaginatedTrash, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	var items []models.TrashItem
+	var total int64
+	switch entityType {
+	case models.ReviewEntityDrama:
+		dramas, count, err := s.trashRepo.ListDramas(offset, pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("获取回收站短剧失败: %w", err)
+		}
+		items, total = make([]models.TrashItem, 0, len(dramas)), count
+		for _, drama := range dramas {
+			items = append(items, s.item(models.TrashItem{
+				Type: entityType, ID: drama.ID, Title: drama.Title, Status: drama.Status, DeletedAt: drama.DeletedAt.Time,
+			}))
+		}
+	case models.ReviewEntityEpisode:
+		episodes, count, err := s.trashRepo.ListEpisodes(offset, pageSize)
+		if err != nil {
+			return nil, fmt.Errorf("获取回收站剧集失败: %w", err)
+		}
+		items, total = make([]models.TrashItem, 0, len(episodes)), count
+		for _, episode := range episodes {
+			items = append(items, s.item(models.TrashItem{
+				Type: entityType, ID: episode.ID, DramaID: episode.DramaID, Title: episode.Title,
+				EpisodeNum: episode.EpisodeNum, Status: episode.Status, DeletedAt: episode.DeletedAt.Time,
+			}))
+		}
+	default:
+		return nil, fmt.Errorf("不支持的内容类型: %s", entityType)
+	}
+
+	totalPages := (int(total) + pageSize - 1) / pageSize
+	return &models.PaginatedTrash{
+		Items:       items,
+		Total:       total,
+		Page:        page,
+		PageSize:    pageSize,
+		TotalPages:  totalPages,
+		HasNext:     page < totalPages,
+		HasPrevious: page > 1,
+	}, nil
+}
+
+// item 补充永久删除的时间
+func (s *trashService) item(item models.TrashItem) models.TrashItem {
+	item.PurgeAt = item.DeletedAt.Add(s.retention)
+	return item
+}
+
+// Restore 恢复短剧或剧集并失效相关缓存
+func (s *trashService) Restore(entityType string, id uint) error {
+	switch entityType {
+	case models.ReviewEntityDrama:
+		return s.restoreDrama(id)
+	case models.ReviewEntityEpisode:
+		return s.restoreEpisode(id)
+	default:
+		return fmt.Errorf("不支持的内容类型: %s", entityType)
+	}
+}
+
+// restoreDrama 恢复短剧及与其一同删除的剧集
+func (s *trashService) restoreDrama(id uint) error {
+	drama, err := s.trashRepo.GetDrama(id)
+	if err != nil {
+		return fmt.Errorf("查询短剧失败: %w", err)
+	}
+	if drama == nil {
+		return ErrContentNotFound
+	}
+
+	ok, err := s.trashRepo.RestoreDrama(id)
+	if err != nil {
+		return fmt.Errorf("恢复短剧失败: %w", err)
+	}
+	if !ok {
+		return ErrContentNotFound
+	}
+
+	s.invalidateCache(TagDrama(id), TagDramaList, TagCategory(drama.Category))
+	s.logger.InfoContext(s.ctx, "短剧已恢复", slog.Any("drama_id", id))
+	return nil
+}
+
+// restoreEpisode 恢复剧集，所属短剧必须未删除且剧集号未被其他剧集使用
+func (s *trashService) restoreEpisode(id uint) error {
+	episode, err := s.trashRepo.GetEpisode(id)
+	if err != nil {
+		return fmt.Errorf("查询剧集失败: %w", err)
+	}
+	if episode == nil {
+		return ErrContentNotFound
+	}
+
+	drama, err := s.dramaRepo.GetByID(episode.DramaID)
+	if err != nil {
+		return fmt.Errorf("查询短剧失败: %w", err)
+	}
+	if drama == nil {
+		return fmt.Errorf("%w: 所属短剧已删除，请先恢复短剧", ErrRestoreConflict)
+	}
+	exists, err := s.episodeRepo.ExistsByDramaIDAndEpisodeNum(episode.DramaID, episode.EpisodeNum)
+	if err != nil {
+		return fmt.Errorf("检查剧集号失败: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("%w: 第 %d 集已存在", ErrRestoreConflict, episode.EpisodeNum)
+	}
+
+	ok, err := s.trashRepo.RestoreEpisode(id)
+	if err != nil {
+		return fmt.Errorf("恢复剧集失败: %w", err)
+	}
+	if !ok {
+		return ErrContentNotFound
+	}
+
+	s.invalidateCache(TagEpisode(id), TagDrama(episode.DramaID))
+	s.logger.InfoContext(s.ctx, "剧集已恢复", slog.Any("episode_id", id), slog.Any("drama_id", episode.DramaID))
+	return nil
+}
+
+// Purge 永久删除回收站中的短剧或剧集
+func (s *trashService) Purge(entityType string, id uint) error {
+	switch entityType {
+	case models.ReviewEntityDrama:
+		drama, err := s.trashRepo.GetDrama(id)
+		if err != nil {
+			return fmt.Errorf("查询短剧失败: %w", err)
+		}
+		if drama == nil {
+			return ErrContentNotFound
+		}
+		return s.purgeDrama(drama)
+	case models.ReviewEntityEpisode:
+		episode, err := s.trashRepo.GetEpisode(id)
+		if err != nil {
+			return fmt.Errorf("查询剧集失败: %w", err)
+		}
+		if episode == nil {
+			return ErrContentNotFound
+		}
+		return s.purgeEpisode(episode)
+	default:
+		return fmt.Errorf("不支持的内容类型: %s", entityType)
+	}
+}
+
+// PurgeExpired 先删除短剧（连同其所有剧集）再删除单独删除的剧集
+func (s *trashService) PurgeExpired() (int, error) {
+	cutoff := s.now().Add(-s.retention)
+	purged := 0
+
+	for s.ctx.Err() == nil {
+		dramas, err := s.trashRepo.ListDramasDeletedBefore(cutoff, trashBatchSize)
+		if err != nil {
+			return purged, fmt.Errorf("查询过期的短剧失败: %w", err)
+		}
+		for i := range dramas {
+			if err := s.purgeDrama(&dramas[i]); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+		if len(dramas) < trashBatchSize {
+			break
+		}
+	}
+
+	for s.ctx.Err() == nil {
+		episodes, err := s.trashRepo.ListEpisodesDeletedBefore(cutoff, trashBatchSize)
+		if err != nil {
+			return purged, fmt.Errorf("查询过期的剧集失败: %w", err)
+		}
+		for i := range episodes {
+			if err := s.purgeEpisode(&episodes[i]); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+		if len(episodes) < trashBatchSize {
+			break
+		}
+	}
+
+	if purged > 0 {
+		s.logger.InfoContext(s.ctx, "已清理过期的回收站内容", slog.Int("count", purged))
+	}
+	return purged, nil
+}
+
+// purgeDrama 永久删除短剧及其所有剧集，再删除转码结果文件与媒体文件
+func (s *trashService) purgeDrama(drama *models.Drama) error {
+	episodes, err := s.trashRepo.ListEpisodesByDramaID(drama.ID)
+	if err != nil {
+		return fmt.Errorf("查询剧集失败: %w", err)
+	}
+	assets := assetIDs(drama.CoverAssetID)
+	for _, episode := range episodes {
+		assets = append(assets, assetIDs(episode.VideoAssetID, episode.ThumbnailAssetID)...)
+	}
+
+	renditions, err := s.trashRepo.PurgeDrama(drama.ID)
+	if err != nil {
+		return fmt.Errorf("永久删除短剧失败: %w", err)
+	}
+	s.cleanup(renditions, assets)
+
+	s.invalidateCache(TagDrama(drama.ID))
+	s.logger.InfoContext(s.ctx, "短剧已永久删除", slog.Any("drama_id", drama.ID))
+	return nil
+}
+
+// purgeEpisode 永久删除剧集，再删除转码结果文件与媒体文件
+func (s *trashService) purgeEpisode(episode *models.Episode) error {
+	renditions, err := s.trashRepo.PurgeEpisode(episode.ID)
+	if err != nil {
+		return fmt.Errorf("永久删除剧集失败: %w", err)
+	}
+	s.cleanup(renditions, assetIDs(episode.VideoAssetID, episode.ThumbnailAssetID))
+
+	s.invalidateCache(TagEpisode(episode.ID))
+	s.logger.InfoContext(s.ctx, "剧集已永久删除", slog.Any("episode_id", episode.ID), slog.Any("drama_id", episode.DramaID))
+	return nil
+}
+
+// cleanup 删除转码结果文件与不再被引用的媒体文件，失败时只记录日志，媒体文件由回收任务兜底删除
+func (s *trashService) cleanup(renditions []models.EpisodeRendition, assets []uint) {
+	deleteRenditionFiles(s.ctx, s.store, s.logger, renditions)
+	if s.mediaService == nil || len(assets) == 0 {
+		return
+	}
+	if _, err := s.mediaService.Release(assets); err != nil {
+		s.logger.WarnContext(s.ctx, "删除媒体文件失败", slog.Any("asset_ids", assets), slog.String("error", err.Error()))
+	}
+}
+
+// invalidateCache 失效相关缓存
+func (s *trashService) invalidateCache(tags ...string) {
+	if s.cacheService == nil {
+		return
+	}
+	if err := s.cacheService.InvalidateTag(tags...); err != nil {
+		s.logger.WarnContext(s.ctx, "缓存失效失败", slog.Any("tags", tags), slog.String("error", err.Error()))
+	}
+}
+
+// assetIDs 收集非空的媒体文件 ID
+func assetIDs(ids ...*uint) []uint {
+	var result []uint
+	for _, id := range ids {
+		if id != nil {
+			result = append(result, *id)
+		}
+	}
+	return result
+}
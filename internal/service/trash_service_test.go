@@ -0,0 +1,115 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashService_Restore(t *testing.T) {
+	f := newPublishFixture(t)
+	trash := NewTrashService(repository.NewTrashRepository(f.db), f.dramas, f.episodes, nil, storage.NewLocal(t.TempDir()), nil, 24*time.Hour, nil)
+	drama := f.createDrama(t, models.StatusPublished, nil)
+	episodes := f.createEpisodes(t, drama.ID, 2)
+
+	t.Run("所属短剧在回收站中时不能单独恢复剧集", func(t *testing.T) {
+		require.NoError(t, f.dramas.Delete(drama.ID))
+		err := trash.Restore(models.ReviewEntityEpisode, episodes[0].ID)
+		assert.ErrorIs(t, err, ErrRestoreConflict)
+
+		require.NoError(t, trash.Restore(models.ReviewEntityDrama, drama.ID))
+		list, err := f.episodes.ListByDramaID(drama.ID)
+		require.NoError(t, err)
+		assert.Len(t, list, 2)
+	})
+
+	t.Run("剧集号已被使用时不能恢复", func(t *testing.T) {
+		require.NoError(t, f.episodes.Delete(episodes[0].ID))
+		replacement := &models.Episode{DramaID: drama.ID, Title: "重新上传", EpisodeNum: episodes[0].EpisodeNum, Duration: 60}
+		require.NoError(t, f.episodes.Create(replacement))
+
+		err := trash.Restore(models.ReviewEntityEpisode, episodes[0].ID)
+		assert.ErrorIs(t, err, ErrRestoreConflict)
+		assert.EqualError(t, err, "无法恢复: 第 1 集已存在")
+
+		list, err := trash.List(models.ReviewEntityEpisode, 1, 20)
+		require.NoError(t, err)
+		require.Len(t, list.Items, 1)
+		assert.Equal(t, episodes[0].ID, list.Items[0].ID)
+		assert.Equal(t, list.Items[0].DeletedAt.Add(24*time.Hour), list.Items[0].PurgeAt)
+	})
+
+	t.Run("不在回收站中的内容", func(t *testing.T) {
+		assert.ErrorIs(t, trash.Restore(models.ReviewEntityDrama, drama.ID), ErrContentNotFound)
+		assert.ErrorIs(t, trash.Purge(models.ReviewEntityEpisode, episodes[1].ID), ErrContentNotFound)
+	})
+}
+
+func TestTrashService_PurgeExpired(t *testing.T) {
+	f := newPublishFixture(t)
+	ctx := context.Background()
+	store := storage.NewLocal(t.TempDir())
+	assets := repository.NewMediaAssetRepository(f.db)
+	media := NewMediaService(assets, store, nil, 24*time.Hour, nil)
+	trash := NewTrashService(repository.NewTrashRepository(f.db), f.dramas, f.episodes, media, store, nil, 24*time.Hour, nil).(*trashService)
+
+	put := func(key, content string) {
+		require.NoError(t, store.Put(ctx, key, bytes.NewReader([]byte(content)), int64(len(content)), ""))
+	}
+	createAsset := func(key string) *models.MediaAsset {
+		put(key, "x")
+		asset := &models.MediaAsset{OwnerRole: "admin", Type: "video", StorageKey: key, ContentType: "video/mp4", Size: 1}
+		require.NoError(t, assets.Create(asset))
+		return asset
+	}
+
+	drama := f.createDrama(t, models.StatusPublished, nil)
+	episode := f.createEpisodes(t, drama.ID, 1)[0]
+	video := createAsset("videos/1_a.mp4")
+	shared := createAsset("videos/1_b.mp4")
+	other := f.createEpisodes(t, f.createDrama(t, models.StatusPublished, nil).ID, 1)[0]
+	require.NoError(t, f.db.Model(episode).Updates(map[string]interface{}{"video_asset_id": video.ID, "thumbnail_asset_id": shared.ID}).Error)
+	require.NoError(t, f.db.Model(other).Update("video_asset_id", shared.ID).Error)
+
+	playlist := "hls/1/1/720p/index.m3u8"
+	put(playlist, "#EXTM3U\nseg0.ts\n")
+	put("hls/1/1/720p/seg0.ts", "ts")
+	require.NoError(t, f.db.Create(&models.EpisodeRendition{EpisodeID: episode.ID, Name: "720p", PlaylistKey: playlist}).Error)
+
+	require.NoError(t, f.dramas.Delete(drama.ID))
+
+	t.Run("未超过保留期时保留", func(t *testing.T) {
+		purged, err := trash.PurgeExpired()
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+	})
+
+	t.Run("超过保留期后永久删除并清理文件", func(t *testing.T) {
+		trash.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+		purged, err := trash.PurgeExpired()
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged, "剧集随短剧一起删除")
+
+		var count int64
+		require.NoError(t, f.db.Unscoped().Model(&models.Episode{}).Where("id = ?", episode.ID).Count(&count).Error)
+		assert.Zero(t, count)
+		for _, key := range []string{playlist, "hls/1/1/720p/seg0.ts", video.StorageKey} {
+			_, err := store.Stat(ctx, key)
+			assert.ErrorIs(t, err, storage.ErrNotFound, key)
+		}
+
+		saved, err := assets.GetByID(shared.ID)
+		require.NoError(t, err)
+		assert.NotNil(t, saved, "仍被其他剧集引用的文件保留")
+		_, err = store.Stat(ctx, shared.StorageKey)
+		assert.NoError(t, err)
+	})
+}
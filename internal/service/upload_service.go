@@ -0,0 +1,501 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/media"
+	"gin-mysql-api/pkg/metrics"
+	"gin-mysql-api/pkg/storage"
+)
+
+var (
+	// ErrUploadSessionNotFound 上传会话不存在、已过期或不属于当前用户
+	ErrUploadSessionNotFound = errors.New("上传会话不存在或已过期")
+	// ErrUploadSessionClosed 上传会话已完成或正在合并，不能再上传分片
+	ErrUploadSessionClosed = errors.New("上传会话已完成或正在合并")
+	// ErrUploadIncomplete 仍有分片未上传
+	ErrUploadIncomplete = errors.New("分片未全部上传")
+	// ErrInvalidPart 分片号或分片大小不正确
+	ErrInvalidPart = errors.New("无效的分片")
+	// ErrChecksumMismatch 分片或文件的 SHA-256 与客户端声明的不一致
+	ErrChecksumMismatch = errors.New("校验和不匹配")
+)
+
+// uploadPartPrefix 分片在存储中的临时目录
+const uploadPartPrefix = "_resumable"
+
+// cleanupBatchSize 每次清理的过期会话数量
+const cleanupBatchSize = 100
+
+// UploadService 分片断点续传服务接口
+// 流程：CreateSession 创建会话 → UploadPart 逐个上传分片（可重复上传、断线后通过 GetSession 查询已上传分片续传）→ Complete 合并
+type UploadService interface {
+	// WithContext 返回绑定请求上下文的服务，日志会带上请求的链路信息
+	WithContext(ctx context.Context) UploadService
+	CreateSession(owner UploadOwner, req *models.CreateUploadSessionRequest) (*models.UploadSessionResponse, error)
+	GetSession(owner UploadOwner, id string) (*models.UploadSessionResponse, error)
+	UploadPart(owner UploadOwner, id string, partNumber int, r io.Reader, size int64, checksum string) (*models.UploadSessionResponse, error)
+	Complete(owner UploadOwner, id string) (*models.UploadSessionResponse, error)
+	Abort(owner UploadOwner, id string) error
+	// CleanupExpired 清理过期的会话及其分片，返回清理的会话数量
+	CleanupExpired() (int, error)
+}
+
+// UploadServiceConfig 分片上传配置
+type UploadServiceConfig struct {
+	ChunkSize    int64
+	MaxSize      int64
+	SessionTTL   time.Duration
+	AllowedTypes []string
+}
+
+// NewUploadServiceConfig 根据应用配置生成分片上传配置
+func NewUploadServiceConfig(cfg *config.Config) UploadServiceConfig {
+	return UploadServiceConfig{
+		ChunkSize:    cfg.Upload.Resumable.GetChunkSizeBytes(),
+		MaxSize:      cfg.Upload.Resumable.GetMaxSizeBytes(),
+		SessionTTL:   cfg.Upload.Resumable.GetSessionTTL(),
+		AllowedTypes: cfg.Upload.AllowedTypes,
+	}
+}
+
+// uploadService 分片断点续传服务实现
+// 会话状态保存在数据库、分片保存在存储中，多实例部署时任意实例都可以接收分片
+type uploadService struct {
+	repo   repository.UploadSessionRepository
+	store  storage.Storage
+	files  FileService
+	conf   UploadServiceConfig
+	now    func() time.Time
+	logger *slog.Logger
+	ctx    context.Context
+}
+
+// NewUploadService 创建分片断点续传服务，log 为 nil 时使用全局默认 Logger
+func NewUploadService(repo repository.UploadSessionRepository, store storage.Storage, files FileService, conf UploadServiceConfig, log *slog.Logger) UploadService {
+	return &uploadService{
+		repo:   repo,
+		store:  store,
+		files:  files,
+		conf:   conf,
+		now:    time.Now,
+		logger: logger.OrDefault(log),
+		ctx:    context.Background(),
+	}
+}
+
+// WithContext 返回绑定请求上下文的分片上传服务
+func (s *uploadService) WithContext(ctx context.Context) UploadService {
+	scoped := *s
+	scoped.ctx = ctx
+	scoped.repo = s.repo.WithContext(ctx)
+	scoped.files = s.files.WithContext(ctx)
+	return &scoped
+}
+
+// CreateSession 创建上传会话
+func (s *uploadService) CreateSession(owner UploadOwner, req *models.CreateUploadSessionRequest) (*models.UploadSessionResponse, error) {
+	if req.Size > s.conf.MaxSize {
+		return nil, fmt.Errorf("文件大小超过限制，最大允许 %d MB", s.conf.MaxSize/(1024*1024))
+	}
+	if !s.files.ValidateFileType(req.Filename, s.conf.AllowedTypes) {
+		return nil, fmt.Errorf("不支持的文件类型，允许的类型: %s", strings.Join(s.conf.AllowedTypes, ", "))
+	}
+
+	id, err := newUploadSessionID()
+	if err != nil {
+		return nil, fmt.Errorf("生成上传会话ID失败: %w", err)
+	}
+
+	session := &models.UploadSession{
+		ID:          id,
+		OwnerID:     owner.ID,
+		OwnerRole:   owner.Role,
+		Filename:    req.Filename,
+		UploadType:  normalizeUploadType(req.Type),
+		Size:        req.Size,
+		ChunkSize:   s.conf.ChunkSize,
+		TotalChunks: int((req.Size + s.conf.ChunkSize - 1) / s.conf.ChunkSize),
+		Checksum:    strings.ToLower(req.SHA256),
+		Status:      models.UploadStatusUploading,
+		ExpiresAt:   s.now().Add(s.conf.SessionTTL),
+	}
+	if err := s.repo.Create(session); err != nil {
+		return nil, fmt.Errorf("创建上传会话失败: %w", err)
+	}
+
+	s.logger.InfoContext(s.ctx, "上传会话已创建",
+		slog.String("session_id", id),
+		slog.String("filename", req.Filename),
+		slog.Int64("size", req.Size),
+		slog.Int("chunks", session.TotalChunks),
+	)
+	return s.toResponse(session), nil
+}
+
+// GetSession 获取上传会话状态
+func (s *uploadService) GetSession(owner UploadOwner, id string) (*models.UploadSessionResponse, error) {
+	session, err := s.getSession(owner, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(session), nil
+}
+
+// UploadPart 上传分片，边写入存储边计算 SHA-256，不在内存中缓存分片
+func (s *uploadService) UploadPart(owner UploadOwner, id string, partNumber int, r io.Reader, size int64, checksum string) (*models.UploadSessionResponse, error) {
+	session, err := s.getSession(owner, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != models.UploadStatusUploading {
+		return nil, ErrUploadSessionClosed
+	}
+	if partNumber < 1 || partNumber > session.TotalChunks {
+		return nil, fmt.Errorf("%w: 分片号必须在 1 到 %d 之间", ErrInvalidPart, session.TotalChunks)
+	}
+	if expected := session.PartSize(partNumber); size != expected {
+		return nil, fmt.Errorf("%w: 分片 %d 的大小应为 %d 字节，实际为 %d 字节", ErrInvalidPart, partNumber, expected, size)
+	}
+	checksum = strings.ToLower(checksum)
+	if len(checksum) != sha256.Size*2 {
+		return nil, fmt.Errorf("%w: 缺少分片的 SHA-256", ErrInvalidPart)
+	}
+
+	// 第一个分片包含文件头，提前校验文件内容，避免上传完整个文件后才被拒绝
+	if partNumber == 1 {
+		head, body, err := media.Peek(r)
+		if err != nil {
+			return nil, fmt.Errorf("读取分片失败: %w", err)
+		}
+		if _, err := s.files.ValidateContent(session.Filename, session.UploadType, head); err != nil {
+			return nil, err
+		}
+		r = body
+	}
+
+	key := uploadPartKey(id, partNumber)
+	counter := &hashCounter{hash: sha256.New()}
+	if err := s.store.Put(s.ctx, key, io.TeeReader(r, counter), size, "application/octet-stream"); err != nil {
+		return nil, fmt.Errorf("保存分片失败: %w", err)
+	}
+	if counter.n != size || counter.sum() != checksum {
+		s.deleteObject(key)
+		return nil, fmt.Errorf("%w: 分片 %d", ErrChecksumMismatch, partNumber)
+	}
+
+	part := models.UploadPart{SessionID: id, PartNumber: partNumber, Size: size, Checksum: checksum, CreatedAt: s.now()}
+	if err := s.repo.SavePart(&part); err != nil {
+		return nil, fmt.Errorf("保存分片记录失败: %w", err)
+	}
+
+	// 每次上传分片后重新计算过期时间，长时间上传的大文件不会中途过期
+	session.ExpiresAt = s.now().Add(s.conf.SessionTTL)
+	if err := s.repo.Update(session); err != nil {
+		return nil, fmt.Errorf("更新上传会话失败: %w", err)
+	}
+
+	session.Parts = upsertPart(session.Parts, part)
+	return s.toResponse(session), nil
+}
+
+// Complete 按顺序合并所有分片到最终文件，流式写入存储
+func (s *uploadService) Complete(owner UploadOwner, id string) (resp *models.UploadSessionResponse, err error) {
+	session, err := s.getSession(owner, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status == models.UploadStatusCompleted {
+		return s.toResponse(session), nil
+	}
+	if missing := missingParts(session); len(missing) > 0 {
+		return nil, fmt.Errorf("%w: 缺少分片 %v", ErrUploadIncomplete, missing)
+	}
+
+	// 标记为合并中，防止并发的合并请求与分片上传
+	ok, err := s.repo.UpdateStatus(id, models.UploadStatusUploading, models.UploadStatusCompleting)
+	if err != nil {
+		return nil, fmt.Errorf("更新上传会话失败: %w", err)
+	}
+	if !ok {
+		return nil, ErrUploadSessionClosed
+	}
+
+	defer func() {
+		metrics.RecordUpload(uploadSubDir(session.UploadType), err)
+		if err != nil {
+			// 合并失败时恢复为上传中，客户端可以重新上传分片后再次合并
+			if _, restoreErr := s.repo.UpdateStatus(id, models.UploadStatusCompleting, models.UploadStatusUploading); restoreErr != nil {
+				s.logger.ErrorContext(s.ctx, "恢复上传会话状态失败", slog.String("session_id", id), slog.String("error", restoreErr.Error()))
+			}
+			s.logger.WarnContext(s.ctx, "分片合并失败", slog.String("session_id", id), slog.String("error", err.Error()))
+		}
+	}()
+
+	parts := &partsReader{ctx: s.ctx, store: s.store, sessionID: id, total: session.TotalChunks, next: 1}
+	defer parts.Close()
+	counter := &hashCounter{hash: sha256.New()}
+	file, err := s.files.SaveFile(owner, io.TeeReader(parts, counter), session.Filename, session.Size, session.UploadType)
+	if err != nil {
+		return nil, fmt.Errorf("合并分片失败: %w", err)
+	}
+	if counter.n != session.Size || (session.Checksum != "" && counter.sum() != session.Checksum) {
+		s.discardFile(owner, file)
+		return nil, fmt.Errorf("%w: 合并后的文件与声明的不一致", ErrChecksumMismatch)
+	}
+
+	session.Status = models.UploadStatusCompleted
+	session.Path = file.Path
+	if err = s.repo.Update(session); err != nil {
+		s.discardFile(owner, file)
+		return nil, fmt.Errorf("更新上传会话失败: %w", err)
+	}
+	s.deleteParts(session)
+
+	s.logger.InfoContext(s.ctx, "分片上传完成",
+		slog.String("session_id", id),
+		slog.String("path", file.Path),
+		slog.Int64("size", session.Size),
+	)
+	return s.toResponse(session), nil
+}
+
+// Abort 取消上传并删除已上传的分片
+func (s *uploadService) Abort(owner UploadOwner, id string) error {
+	session, err := s.getSession(owner, id)
+	if err != nil {
+		return err
+	}
+	if session.Status == models.UploadStatusCompleting {
+		return ErrUploadSessionClosed
+	}
+
+	s.deleteParts(session)
+	if err := s.repo.Delete(id); err != nil {
+		return fmt.Errorf("删除上传会话失败: %w", err)
+	}
+	return nil
+}
+
+// CleanupExpired 清理过期的会话：删除未完成会话的分片，已完成会话只删除记录
+func (s *uploadService) CleanupExpired() (int, error) {
+	cleaned := 0
+	for {
+		sessions, err := s.repo.ListExpired(s.now(), cleanupBatchSize)
+		if err != nil {
+			return cleaned, fmt.Errorf("查询过期上传会话失败: %w", err)
+		}
+
+		for i := range sessions {
+			session := &sessions[i]
+			if session.Status != models.UploadStatusCompleted {
+				s.deleteParts(session)
+			}
+			if err := s.repo.Delete(session.ID); err != nil {
+				return cleaned, fmt.Errorf("删除上传会话失败: %w", err)
+			}
+			cleaned++
+		}
+
+		if len(sessions) < cleanupBatchSize {
+			break
+		}
+	}
+
+	if cleaned > 0 {
+		s.logger.InfoContext(s.ctx, "已清理过期上传会话", slog.Int("count", cleaned))
+	}
+	return cleaned, nil
+}
+
+// getSession 获取属于 owner 且未过期的会话
+func (s *uploadService) getSession(owner UploadOwner, id string) (*models.UploadSession, error) {
+	session, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("获取上传会话失败: %w", err)
+	}
+	if session == nil || session.OwnerID != owner.ID || session.OwnerRole != owner.Role {
+		return nil, ErrUploadSessionNotFound
+	}
+	if session.Status != models.UploadStatusCompleted && session.IsExpired(s.now()) {
+		return nil, ErrUploadSessionNotFound
+	}
+	return session, nil
+}
+
+// deleteParts 删除会话的所有分片，失败时只记录日志，残留的分片不影响使用
+func (s *uploadService) deleteParts(session *models.UploadSession) {
+	for n := 1; n <= session.TotalChunks; n++ {
+		s.deleteObject(uploadPartKey(session.ID, n))
+	}
+}
+
+// deleteObject 删除存储中的对象，失败时只记录日志
+func (s *uploadService) deleteObject(key string) {
+	if err := s.store.Delete(s.ctx, key); err != nil {
+		s.logger.WarnContext(s.ctx, "删除存储对象失败", slog.String("path", key), slog.String("error", err.Error()))
+	}
+}
+
+// discardFile 删除合并出的文件，复用的已有文件不删除，失败时只记录日志
+func (s *uploadService) discardFile(owner UploadOwner, file *models.FileUploadResponse) {
+	if file.Deduplicated {
+		return
+	}
+	if err := s.files.DeleteFile(owner, file.Path); err != nil {
+		s.logger.WarnContext(s.ctx, "删除文件失败", slog.String("path", file.Path), slog.String("error", err.Error()))
+	}
+}
+
+// toResponse 转换为会话状态响应
+func (s *uploadService) toResponse(session *models.UploadSession) *models.UploadSessionResponse {
+	resp := &models.UploadSessionResponse{
+		ID:            session.ID,
+		Filename:      session.Filename,
+		Type:          session.UploadType,
+		Status:        session.Status,
+		Size:          session.Size,
+		ChunkSize:     session.ChunkSize,
+		TotalChunks:   session.TotalChunks,
+		UploadedParts: make([]int, 0, len(session.Parts)),
+		ExpiresAt:     session.ExpiresAt,
+	}
+	for _, part := range session.Parts {
+		resp.UploadedParts = append(resp.UploadedParts, part.PartNumber)
+		resp.UploadedSize += part.Size
+	}
+
+	if session.Status == models.UploadStatusCompleted {
+		resp.UploadedSize = session.Size
+		resp.File = &models.FileUploadResponse{
+			URL:      s.files.GetFileURL(session.Path),
+			Path:     session.Path,
+			Filename: session.Path[strings.LastIndex(session.Path, "/")+1:],
+			Size:     session.Size,
+		}
+	}
+	return resp
+}
+
+// missingParts 获取尚未上传的分片号
+func missingParts(session *models.UploadSession) []int {
+	uploaded := make(map[int]bool, len(session.Parts))
+	for _, part := range session.Parts {
+		uploaded[part.PartNumber] = true
+	}
+
+	var missing []int
+	for n := 1; n <= session.TotalChunks; n++ {
+		if !uploaded[n] {
+			missing = append(missing, n)
+		}
+	}
+	return missing
+}
+
+// upsertPart 将分片加入列表并保持分片号有序
+func upsertPart(parts []models.UploadPart, part models.UploadPart) []models.UploadPart {
+	for i := range parts {
+		if parts[i].PartNumber == part.PartNumber {
+			parts[i] = part
+			return parts
+		}
+		if parts[i].PartNumber > part.PartNumber {
+			return append(parts[:i], append([]models.UploadPart{part}, parts[i:]...)...)
+		}
+	}
+	return append(parts, part)
+}
+
+// uploadPartKey 获取分片在存储中的对象键
+func uploadPartKey(sessionID string, partNumber int) string {
+	return fmt.Sprintf("%s/%s/%05d", uploadPartPrefix, sessionID, partNumber)
+}
+
+// newUploadSessionID 生成不可猜测的会话ID
+func newUploadSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashCounter 统计写入的字节数并计算摘要
+type hashCounter struct {
+	hash hash.Hash
+	n    int64
+}
+
+// Write 实现 io.Writer 接口
+func (h *hashCounter) Write(p []byte) (int, error) {
+	h.n += int64(len(p))
+	return h.hash.Write(p)
+}
+
+// sum 获取十六进制摘要
+func (h *hashCounter) sum() string {
+	return hex.EncodeToString(h.hash.Sum(nil))
+}
+
+// partsReader 按分片号顺序读取所有分片，同一时间只打开一个分片
+type partsReader struct {
+	ctx       context.Context
+	store     storage.Storage
+	sessionID string
+	total     int
+	next      int
+	current   io.ReadCloser
+}
+
+// Read 实现 io.Reader 接口，当前分片读完后自动打开下一个分片
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if r.next > r.total {
+				return 0, io.EOF
+			}
+			reader, _, err := r.store.Get(r.ctx, uploadPartKey(r.sessionID, r.next))
+			if err != nil {
+				return 0, fmt.Errorf("读取分片 %d 失败: %w", r.next, err)
+			}
+			r.current = reader
+			r.next++
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Close 关闭当前打开的分片
+func (r *partsReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
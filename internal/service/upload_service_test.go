@@ -0,0 +1,223 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/internal/testutil"
+	"gin-mysql-api/pkg/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sha256Hex 计算十六进制 SHA-256
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// testChunkSize 测试使用的分片大小，需要能容纳识别文件类型的文件头
+const testChunkSize = 256
+
+// newTestUploadService 基于测试数据库与本地存储创建分片上传服务
+func newTestUploadService(t *testing.T) (*uploadService, repository.UploadSessionRepository, storage.Storage) {
+	t.Helper()
+	db := testutil.SetupTestDB()
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+
+	repo := repository.NewUploadSessionRepository(db)
+	store := storage.NewLocal(t.TempDir())
+	files := NewFileService(store, repository.NewMediaAssetRepository(db), nil, FileServiceConfig{
+		BaseURL:      "http://localhost:1800",
+		AllowedTypes: []string{"mp4"},
+	}, nil)
+	service := NewUploadService(repo, store, files, UploadServiceConfig{
+		ChunkSize:    testChunkSize,
+		MaxSize:      1024,
+		SessionTTL:   time.Hour,
+		AllowedTypes: []string{"mp4"},
+	}, nil).(*uploadService)
+	return service, repo, store
+}
+
+// uploadParts 将数据按分片大小切分后上传指定的分片
+func uploadParts(t *testing.T, service UploadService, owner UploadOwner, id string, data []byte, parts ...int) {
+	t.Helper()
+	for _, n := range parts {
+		start := (n - 1) * testChunkSize
+		end := min(start+testChunkSize, len(data))
+		chunk := data[start:end]
+		_, err := service.UploadPart(owner, id, n, bytes.NewReader(chunk), int64(len(chunk)), sha256Hex(chunk))
+		require.NoError(t, err)
+	}
+}
+
+func TestUploadService(t *testing.T) {
+	owner := UploadOwner{ID: 1, Role: "user"}
+	data := testutil.NewMP4(testutil.MP4Options{Size: 2*testChunkSize + 9})
+
+	t.Run("断点续传并合并", func(t *testing.T) {
+		service, _, store := newTestUploadService(t)
+
+		session, err := service.CreateSession(owner, &models.CreateUploadSessionRequest{
+			Filename: "episode.mp4", Size: int64(len(data)), Type: "video", SHA256: sha256Hex(data),
+		})
+		require.NoError(t, err)
+		assert.Len(t, session.ID, 32)
+		assert.Equal(t, 3, session.TotalChunks)
+		assert.Equal(t, models.UploadStatusUploading, session.Status)
+
+		// 上传部分分片后断开，重新查询会话得到已上传的分片
+		uploadParts(t, service, owner, session.ID, data, 3, 1)
+		session, err = service.GetSession(owner, session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []int{1, 3}, session.UploadedParts)
+		assert.Equal(t, int64(testChunkSize+9), session.UploadedSize)
+
+		_, err = service.Complete(owner, session.ID)
+		assert.ErrorIs(t, err, ErrUploadIncomplete)
+
+		uploadParts(t, service, owner, session.ID, data, 2)
+		session, err = service.Complete(owner, session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.UploadStatusCompleted, session.Status)
+		require.NotNil(t, session.File)
+		assert.Regexp(t, `^videos/\d+_[A-Za-z0-9]{16}\.mp4$`, session.File.Path)
+		assert.Equal(t, "http://localhost:1800/uploads/"+session.File.Path, session.File.URL)
+
+		reader, info, err := store.Get(context.Background(), session.File.Path)
+		require.NoError(t, err)
+		defer reader.Close()
+		merged, _ := io.ReadAll(reader)
+		assert.Equal(t, data, merged)
+		assert.Equal(t, "video/mp4", info.ContentType)
+
+		// 合并后删除分片，重复合并返回同一结果
+		_, err = store.Stat(context.Background(), uploadPartKey(session.ID, 1))
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+		again, err := service.Complete(owner, session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, session.File.Path, again.File.Path)
+	})
+
+	t.Run("分片校验", func(t *testing.T) {
+		service, _, store := newTestUploadService(t)
+		session, err := service.CreateSession(owner, &models.CreateUploadSessionRequest{Filename: "a.mp4", Size: int64(len(data))})
+		require.NoError(t, err)
+
+		first := data[:testChunkSize]
+		_, err = service.UploadPart(owner, session.ID, 1, bytes.NewReader(first), testChunkSize, sha256Hex([]byte("xxxx")))
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+		_, err = store.Stat(context.Background(), uploadPartKey(session.ID, 1))
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+
+		_, err = service.UploadPart(owner, session.ID, 3, bytes.NewReader(first), testChunkSize, sha256Hex(first))
+		assert.ErrorIs(t, err, ErrInvalidPart)
+		_, err = service.UploadPart(owner, session.ID, 4, strings.NewReader("89"), 2, sha256Hex([]byte("89")))
+		assert.ErrorIs(t, err, ErrInvalidPart)
+		_, err = service.UploadPart(owner, session.ID, 1, bytes.NewReader(first), testChunkSize, "")
+		assert.ErrorIs(t, err, ErrInvalidPart)
+
+		// 请求体比声明的短
+		_, err = service.UploadPart(owner, session.ID, 2, strings.NewReader("01"), testChunkSize, sha256Hex([]byte("01")))
+		assert.Error(t, err)
+
+		// 第一个分片的文件头不是视频
+		script := append([]byte("#!/bin/sh\nrm -rf /\n"), make([]byte, testChunkSize)...)[:testChunkSize]
+		_, err = service.UploadPart(owner, session.ID, 1, bytes.NewReader(script), testChunkSize, sha256Hex(script))
+		assert.ErrorIs(t, err, ErrUnsupportedContent)
+	})
+
+	t.Run("完整文件校验失败后可以重新上传", func(t *testing.T) {
+		service, _, _ := newTestUploadService(t)
+		session, err := service.CreateSession(owner, &models.CreateUploadSessionRequest{
+			Filename: "a.mp4", Size: int64(len(data)), SHA256: sha256Hex([]byte("9876543210")),
+		})
+		require.NoError(t, err)
+		uploadParts(t, service, owner, session.ID, data, 1, 2, 3)
+
+		_, err = service.Complete(owner, session.ID)
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+
+		session, err = service.GetSession(owner, session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.UploadStatusUploading, session.Status)
+	})
+
+	t.Run("其他用户无法访问会话", func(t *testing.T) {
+		service, _, _ := newTestUploadService(t)
+		session, err := service.CreateSession(owner, &models.CreateUploadSessionRequest{Filename: "a.mp4", Size: 1})
+		require.NoError(t, err)
+
+		_, err = service.GetSession(UploadOwner{ID: 2, Role: "user"}, session.ID)
+		assert.ErrorIs(t, err, ErrUploadSessionNotFound)
+		_, err = service.GetSession(UploadOwner{ID: 1, Role: "admin"}, session.ID)
+		assert.ErrorIs(t, err, ErrUploadSessionNotFound)
+		assert.ErrorIs(t, service.Abort(UploadOwner{ID: 2, Role: "user"}, session.ID), ErrUploadSessionNotFound)
+	})
+
+	t.Run("创建会话校验", func(t *testing.T) {
+		service, _, _ := newTestUploadService(t)
+
+		_, err := service.CreateSession(owner, &models.CreateUploadSessionRequest{Filename: "a.mp4", Size: 2048})
+		assert.ErrorContains(t, err, "文件大小超过限制")
+		_, err = service.CreateSession(owner, &models.CreateUploadSessionRequest{Filename: "a.exe", Size: 1})
+		assert.ErrorContains(t, err, "不支持的文件类型")
+	})
+
+	t.Run("取消上传", func(t *testing.T) {
+		service, repo, store := newTestUploadService(t)
+		session, err := service.CreateSession(owner, &models.CreateUploadSessionRequest{Filename: "a.mp4", Size: int64(len(data))})
+		require.NoError(t, err)
+		uploadParts(t, service, owner, session.ID, data, 1)
+
+		require.NoError(t, service.Abort(owner, session.ID))
+		stored, err := repo.GetByID(session.ID)
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+		_, err = store.Stat(context.Background(), uploadPartKey(session.ID, 1))
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	})
+
+	t.Run("过期会话被清理", func(t *testing.T) {
+		service, repo, store := newTestUploadService(t)
+		session, err := service.CreateSession(owner, &models.CreateUploadSessionRequest{Filename: "a.mp4", Size: int64(len(data))})
+		require.NoError(t, err)
+		uploadParts(t, service, owner, session.ID, data, 1)
+		active, err := service.CreateSession(owner, &models.CreateUploadSessionRequest{Filename: "b.mp4", Size: int64(len(data))})
+		require.NoError(t, err)
+
+		// 上传分片会延长过期时间
+		service.now = func() time.Time { return time.Now().Add(30 * time.Minute) }
+		uploadParts(t, service, owner, active.ID, data, 2)
+
+		service.now = func() time.Time { return time.Now().Add(time.Hour + time.Minute) }
+		_, err = service.GetSession(owner, session.ID)
+		assert.ErrorIs(t, err, ErrUploadSessionNotFound)
+
+		cleaned, err := service.CleanupExpired()
+		require.NoError(t, err)
+		assert.Equal(t, 1, cleaned)
+
+		stored, err := repo.GetByID(session.ID)
+		require.NoError(t, err)
+		assert.Nil(t, stored)
+		_, err = store.Stat(context.Background(), uploadPartKey(session.ID, 1))
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+
+		_, err = service.GetSession(owner, active.ID)
+		assert.NoError(t, err)
+	})
+}
@@ -1,16 +1,22 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 
 	"gin-mysql-api/internal/models"
 	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/metrics"
 	"gin-mysql-api/pkg/utils"
 )
 
 // UserService 用户服务接口
 type UserService interface {
+	// WithContext 返回绑定请求上下文的服务，日志与查询会带上请求的链路信息
+	WithContext(ctx context.Context) UserService
 	Register(req models.RegisterRequest) (*models.User, error)
 	Login(req models.LoginRequest) (*models.LoginResponse, error)
 	GetProfile(userID uint) (*models.User, error)
@@ -23,18 +29,39 @@ type UserService interface {
 
 // userService 用户服务实现
 type userService struct {
-	userRepo   repository.UserRepository
-	jwtManager *utils.JWTManager
+	userRepo     repository.UserRepository
+	jwtManager   *utils.JWTManager
+	mediaService MediaService
+	logger       *slog.Logger
+	ctx          context.Context
 }
 
-// NewUserService 创建新的用户服务
-func NewUserService(userRepo repository.UserRepository, jwtManager *utils.JWTManager) UserService {
+// NewUserService 创建新的用户服务，log 为 nil 时使用全局默认 Logger
+// mediaService 为 nil 时头像地址不关联媒体文件
+func NewUserService(userRepo repository.UserRepository, jwtManager *utils.JWTManager, mediaService MediaService, log *slog.Logger) UserService {
 	return &userService{
-		userRepo:   userRepo,
-		jwtManager: jwtManager,
+		userRepo:     userRepo,
+		jwtManager:   jwtManager,
+		mediaService: mediaService,
+		logger:       logger.OrDefault(log),
+		ctx:          context.Background(),
 	}
 }
 
+// WithContext 返回绑定请求上下文的用户服务
+func (s *userService) WithContext(ctx context.Context) UserService {
+	scoped := &userService{
+		userRepo:   s.userRepo.WithContext(ctx),
+		jwtManager: s.jwtManager,
+		logger:     s.logger,
+		ctx:        ctx,
+	}
+	if s.mediaService != nil {
+		scoped.mediaService = s.mediaService.WithContext(ctx)
+	}
+	return scoped
+}
+
 // Register 用户注册
 func (s *userService) Register(req models.RegisterRequest) (*models.User, error) {
 	// 检查用户名是否已存在
@@ -74,6 +101,8 @@ func (s *userService) Register(req models.RegisterRequest) (*models.User, error)
 	if err != nil {
 		return nil, fmt.Errorf("用户创建失败: %w", err)
 	}
+	metrics.RecordRegistration()
+	s.logger.InfoContext(s.ctx, "用户注册成功", slog.Any("new_user_id", user.ID), slog.String("username", user.Username))
 
 	// 清除密码字段
 	user.Password = ""
@@ -81,28 +110,39 @@ func (s *userService) Register(req models.RegisterRequest) (*models.User, error)
 }
 
 // Login 用户登录
-func (s *userService) Login(req models.LoginRequest) (*models.LoginResponse, error) {
+func (s *userService) Login(req models.LoginRequest) (resp *models.LoginResponse, err error) {
+	defer func() { metrics.RecordLogin("user", err == nil) }()
+
 	// 根据邮箱查找用户
 	user, err := s.userRepo.GetByEmail(req.Email)
 	if err != nil {
+		s.logger.ErrorContext(s.ctx, "查询登录用户失败", slog.String("error", err.Error()))
+		return nil, errors.New("用户名或密码错误")
+	}
+	if user == nil {
+		s.logger.WarnContext(s.ctx, "用户登录失败", slog.String("reason", "user_not_found"))
 		return nil, errors.New("用户名或密码错误")
 	}
 
 	// 检查用户是否激活
 	if !user.IsActive {
+		s.logger.WarnContext(s.ctx, "用户登录失败", slog.String("reason", "inactive"), slog.Any("login_user_id", user.ID))
 		return nil, errors.New("用户账户已被禁用")
 	}
 
 	// 验证密码
 	if !utils.VerifyPassword(user.Password, req.Password) {
+		s.logger.WarnContext(s.ctx, "用户登录失败", slog.String("reason", "invalid_password"), slog.Any("login_user_id", user.ID))
 		return nil, errors.New("用户名或密码错误")
 	}
 
 	// 生成 JWT token
 	token, err := s.jwtManager.GenerateToken(user.ID, user.Username, "user")
 	if err != nil {
+		s.logger.ErrorContext(s.ctx, "生成登录令牌失败", slog.String("error", err.Error()))
 		return nil, fmt.Errorf("令牌生成失败: %w", err)
 	}
+	s.logger.InfoContext(s.ctx, "用户登录成功", slog.Any("login_user_id", user.ID))
 
 	// 清除密码字段
 	user.Password = ""
@@ -151,6 +191,14 @@ func (s *userService) UpdateProfile(userID uint, req models.UpdateProfileRequest
 		user.Phone = req.Phone
 	}
 	if req.Avatar != "" {
+		if s.mediaService != nil {
+			// 头像只能使用自己上传的图片
+			avatarAssetID, err := s.mediaService.ResolveReference(UploadOwner{ID: userID, Role: "user"}, req.Avatar, "avatar")
+			if err != nil {
+				return nil, err
+			}
+			user.AvatarAssetID = avatarAssetID
+		}
 		user.Avatar = req.Avatar
 	}
 
@@ -210,6 +258,7 @@ func (s *userService) DeleteUser(userID uint) error {
 	if err != nil {
 		return fmt.Errorf("删除用户失败: %w", err)
 	}
+	s.logger.InfoContext(s.ctx, "用户已删除", slog.Any("target_user_id", userID))
 
 	return nil
 }
@@ -226,6 +275,7 @@ func (s *userService) ActivateUser(userID uint) error {
 	if err != nil {
 		return fmt.Errorf("激活用户失败: %w", err)
 	}
+	s.logger.InfoContext(s.ctx, "用户已激活", slog.Any("target_user_id", userID))
 
 	return nil
 }
@@ -242,6 +292,7 @@ func (s *userService) DeactivateUser(userID uint) error {
 	if err != nil {
 		return fmt.Errorf("禁用用户失败: %w", err)
 	}
+	s.logger.InfoContext(s.ctx, "用户已禁用", slog.Any("target_user_id", userID))
 
 	return nil
 }
\ No newline at end of file
@@ -1,11 +1,13 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
 	"gin-mysql-api/internal/models"
+	"gin-mysql-api/internal/repository"
 	"gin-mysql-api/pkg/utils"
 
 	"github.com/stretchr/testify/assert"
@@ -17,6 +19,10 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
+func (m *MockUserRepository) WithContext(ctx context.Context) repository.UserRepository {
+	return m
+}
+
 func (m *MockUserRepository) Create(user *models.User) error {
 	args := m.Called(user)
 	return args.Error(0)
@@ -71,7 +77,7 @@ func (m *MockUserRepository) ExistsByUsername(username string) (bool, error) {
 func TestUserService_Register(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	jwtManager := utils.NewJWTManager("test-secret", time.Hour)
-	userService := NewUserService(mockRepo, jwtManager)
+	userService := NewUserService(mockRepo, jwtManager, nil, nil)
 
 	t.Run("成功注册用户", func(t *testing.T) {
 		req := models.RegisterRequest{
@@ -139,7 +145,7 @@ func TestUserService_Register(t *testing.T) {
 func TestUserService_Login(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	jwtManager := utils.NewJWTManager("test-secret", time.Hour)
-	userService := NewUserService(mockRepo, jwtManager)
+	userService := NewUserService(mockRepo, jwtManager, nil, nil)
 
 	t.Run("成功登录", func(t *testing.T) {
 		req := models.LoginRequest{
@@ -215,7 +221,7 @@ func TestUserService_Login(t *testing.T) {
 	t.Run("用户已被禁用", func(t *testing.T) {
 		// 重新创建 mock 以避免之前的调用影响
 		mockRepo := new(MockUserRepository)
-		userService := NewUserService(mockRepo, jwtManager)
+		userService := NewUserService(mockRepo, jwtManager, nil, nil)
 		
 		req := models.LoginRequest{
 			Email:    "test@example.com",
@@ -1,13 +1,20 @@
 package testutil
 
 import (
+	"os"
 	"time"
 
 	"gin-mysql-api/pkg/config"
 )
 
 // GetTestConfig 获取测试配置
+// 默认使用 SQLite 内存数据库，设置 TEST_DB_DRIVER=mysql 可切换到本地 MySQL
 func GetTestConfig() *config.Config {
+	driver := os.Getenv("TEST_DB_DRIVER")
+	if driver == "" {
+		driver = "sqlite"
+	}
+
 	return &config.Config{
 		Server: config.ServerConfig{
 			Host: "localhost",
@@ -15,6 +22,8 @@ func GetTestConfig() *config.Config {
 			Mode: "test",
 		},
 		Database: config.DatabaseConfig{
+			Driver:          driver,
+			Path:            ":memory:",
 			Host:            "localhost",
 			Port:            3306,
 			Username:        "test",
@@ -49,5 +58,11 @@ func GetTestConfig() *config.Config {
 			Output:   "stdout",
 			Filename: "",
 		},
+		Metrics: config.MetricsConfig{
+			Enabled:    true,
+			AllowedIPs: []string{"10.0.0.0/8"},
+			Username:   "metrics",
+			Password:   "metrics-secret",
+		},
 	}
 }
@@ -5,36 +5,65 @@ import (
 	"log"
 
 	"gin-mysql-api/internal/models"
+	"gin-mysql-api/pkg/database"
+	"gin-mysql-api/pkg/metrics"
+	"gin-mysql-api/pkg/tracing"
 
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
 // SetupTestDB 设置测试数据库
+// 默认每次调用都会得到一个全新的 SQLite 内存数据库，测试套件之间互不影响
 func SetupTestDB() *gorm.DB {
 	cfg := GetTestConfig()
-	
-	// 构建数据库连接字符串
-	dsn := cfg.Database.GetDSN()
-	
+
+	dialector, err := database.NewDialector(&cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to create test database dialector: %v", err)
+	}
+
 	// 配置 GORM
 	gormConfig := &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Silent), // 测试时使用静默模式
 	}
 
 	// 连接数据库
-	db, err := gorm.Open(mysql.Open(dsn), gormConfig)
+	db, err := gorm.Open(dialector, gormConfig)
 	if err != nil {
 		log.Fatalf("Failed to connect to test database: %v", err)
 	}
 
+	// 与生产环境一致地记录查询指标与链路追踪
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		log.Fatalf("Failed to register metrics plugin: %v", err)
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		log.Fatalf("Failed to register tracing plugin: %v", err)
+	}
+
+	if cfg.Database.GetDriver() == database.DriverSQLite {
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("Failed to get test sql.DB: %v", err)
+		}
+		database.ConfigureSQLitePool(sqlDB, &cfg.Database)
+	}
+
 	// 自动迁移
 	err = db.AutoMigrate(
 		&models.User{},
 		&models.Drama{},
 		&models.Episode{},
 		&models.Admin{},
+		&models.UploadSession{},
+		&models.UploadPart{},
+		&models.MediaAsset{},
+		&models.TranscodeJob{},
+		&models.EpisodeRendition{},
+		&models.EpisodeKey{},
+		&models.StatusTransition{},
+		&models.ContentRevision{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to migrate test database: %v", err)
@@ -45,24 +74,41 @@ func SetupTestDB() *gorm.DB {
 
 // CleanupTestDB 清理测试数据库
 func CleanupTestDB(db *gorm.DB) {
+	tables := []string{"content_revisions", "status_transitions", "episode_keys", "episode_renditions", "transcode_jobs", "media_assets", "upload_parts", "upload_sessions", "episodes", "dramas", "users", "admins"}
+
 	// 删除所有测试数据
-	db.Exec("DELETE FROM episodes")
-	db.Exec("DELETE FROM dramas")
-	db.Exec("DELETE FROM users")
-	db.Exec("DELETE FROM admins")
-	
+	for _, table := range tables {
+		db.Exec(fmt.Sprintf("DELETE FROM %s", table))
+	}
+
 	// 重置自增ID
-	db.Exec("ALTER TABLE episodes AUTO_INCREMENT = 1")
-	db.Exec("ALTER TABLE dramas AUTO_INCREMENT = 1")
-	db.Exec("ALTER TABLE users AUTO_INCREMENT = 1")
-	db.Exec("ALTER TABLE admins AUTO_INCREMENT = 1")
+	for _, table := range tables {
+		resetAutoIncrement(db, table)
+	}
 }
 
 // TruncateTable 清空指定表
 func TruncateTable(db *gorm.DB, tableName string) {
+	if db.Dialector.Name() == database.DriverSQLite {
+		db.Exec(fmt.Sprintf("DELETE FROM %s", tableName))
+		resetAutoIncrement(db, tableName)
+		return
+	}
 	db.Exec(fmt.Sprintf("TRUNCATE TABLE %s", tableName))
 }
 
+// resetAutoIncrement 重置表的自增ID
+func resetAutoIncrement(db *gorm.DB, tableName string) {
+	if db.Dialector.Name() == database.DriverSQLite {
+		// sqlite_sequence 仅在使用 AUTOINCREMENT 的表存在时才会创建
+		if db.Migrator().HasTable("sqlite_sequence") {
+			db.Exec("DELETE FROM sqlite_sequence WHERE name = ?", tableName)
+		}
+		return
+	}
+	db.Exec(fmt.Sprintf("ALTER TABLE %s AUTO_INCREMENT = 1", tableName))
+}
+
 // BeginTransaction 开始事务
 func BeginTransaction(db *gorm.DB) *gorm.DB {
 	return db.Begin()
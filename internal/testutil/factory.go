@@ -66,8 +66,8 @@ func (f *DramaFactory) CreateDrama(overrides ...func(*models.Drama)) *models.Dra
 		CoverImage:  "https://example.com/cover.jpg",
 		Director:    "测试导演",
 		Actors:      "测试演员1, 测试演员2",
-		Genre:       "喜剧",
-		Status:      "active",
+		Category:    "喜剧",
+		Status:      "published",
 		ViewCount:   0,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
@@ -109,7 +109,7 @@ func (f *EpisodeFactory) CreateEpisode(dramaID uint, overrides ...func(*models.E
 		Duration:    30, // 30分钟
 		VideoURL:    "https://example.com/video.mp4",
 		Thumbnail:   "https://example.com/thumbnail.jpg",
-		Status:      "active",
+		Status:      "published",
 		ViewCount:   0,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
@@ -152,7 +152,7 @@ func (f *AdminFactory) CreateAdmin(overrides ...func(*models.Admin)) *models.Adm
 		Email:     "admin@example.com",
 		Password:  hashedPassword,
 		Role:      "admin",
-		IsActive:  true,
+		Status:    "active",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
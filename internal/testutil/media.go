@@ -0,0 +1,80 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// MP4Options 测试视频的参数
+type MP4Options struct {
+	// DurationMs 时长（毫秒），默认 12500
+	DurationMs uint32
+	// Width、Height 分辨率，默认 1280x720
+	Width  uint32
+	Height uint32
+	// VideoCodec 视频编码，默认 avc1
+	VideoCodec string
+	// AudioCodec 音频编码，为空时没有音频轨道
+	AudioCodec string
+	// Size 文件大小，不为 0 时在 ftyp 后插入 free 盒子补齐到该大小
+	Size int
+}
+
+// NewMP4 构造只有元数据、没有媒体数据的 MP4 文件，moov 位于文件末尾
+func NewMP4(opts MP4Options) []byte {
+	if opts.DurationMs == 0 {
+		opts.DurationMs = 12500
+	}
+	if opts.Width == 0 || opts.Height == 0 {
+		opts.Width, opts.Height = 1280, 720
+	}
+	if opts.VideoCodec == "" {
+		opts.VideoCodec = "avc1"
+	}
+
+	ftyp := mp4Box("ftyp", []byte("isom"), mp4Uint32(0x200), []byte("isomavc1"))
+	mvhd := mp4FullBox("mvhd", mp4Uint32(0), mp4Uint32(0), mp4Uint32(1000), mp4Uint32(opts.DurationMs), make([]byte, 80))
+	tracks := [][]byte{mvhd, mp4Track("vide", opts.VideoCodec, opts.Width, opts.Height)}
+	if opts.AudioCodec != "" {
+		tracks = append(tracks, mp4Track("soun", opts.AudioCodec, 0, 0))
+	}
+	moov := mp4Box("moov", tracks...)
+
+	var padding []byte
+	if pad := opts.Size - len(ftyp) - len(moov); pad >= 8 {
+		padding = mp4Box("free", make([]byte, pad-8))
+	}
+	return bytes.Join([][]byte{ftyp, padding, moov}, nil)
+}
+
+// mp4Track 构造视频或音频轨道
+func mp4Track(handler, codec string, width, height uint32) []byte {
+	tkhd := make([]byte, 80)
+	binary.BigEndian.PutUint32(tkhd[72:], width<<16)
+	binary.BigEndian.PutUint32(tkhd[76:], height<<16)
+
+	return mp4Box("trak",
+		mp4FullBox("tkhd", tkhd),
+		mp4Box("mdia",
+			mp4FullBox("hdlr", mp4Uint32(0), []byte(handler), make([]byte, 12)),
+			mp4Box("minf", mp4Box("stbl", mp4FullBox("stsd", mp4Uint32(1), mp4Box(codec, make([]byte, 70))))),
+		),
+	)
+}
+
+// mp4Box 构造 MP4 盒子
+func mp4Box(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(out, typ...), body...)
+}
+
+// mp4FullBox 构造版本为 0 的 MP4 完整盒子
+func mp4FullBox(typ string, payload ...[]byte) []byte {
+	return mp4Box(typ, append([]byte{0, 0, 0, 0}, bytes.Join(payload, nil)...))
+}
+
+// mp4Uint32 大端序 32 位整数
+func mp4Uint32(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -10,12 +12,27 @@ import (
 
 // Config 应用配置结构
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
-	Upload   UploadConfig   `mapstructure:"upload"`
-	Logging  LoggingConfig  `mapstructure:"logging"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	JWT       JWTConfig       `mapstructure:"jwt"`
+	Upload    UploadConfig    `mapstructure:"upload"`
+	Storage   StorageConfig   `mapstructure:"storage"`
+	Transcode TranscodeConfig `mapstructure:"transcode"`
+	Playback  PlaybackConfig  `mapstructure:"playback"`
+	Publish   PublishConfig   `mapstructure:"publish"`
+	Trash     TrashConfig     `mapstructure:"trash"`
+	Logging   LoggingConfig   `mapstructure:"logging"`
+	Metrics   MetricsConfig   `mapstructure:"metrics"`
+	Tracing   TracingConfig   `mapstructure:"tracing"`
+	Health    HealthConfig    `mapstructure:"health"`
+	CORS      CORSConfig      `mapstructure:"cors"`
+	RateLimit RateLimitConfig `mapstructure:"rateLimit"`
+	Security  SecurityConfig  `mapstructure:"security"`
+
+	// Profile 生效的环境 profile（来自 APP_ENV），为空时只加载基础配置
+	Profile string `mapstructure:"-"`
 }
 
 // ServerConfig 服务器配置
@@ -23,11 +40,17 @@ type ServerConfig struct {
 	Host       string   `mapstructure:"host"`
 	Port       int      `mapstructure:"port"`
 	Mode       string   `mapstructure:"mode"`
+	BaseURL    string   `mapstructure:"baseURL"`
 	AllowedIPs []string `mapstructure:"allowedIPs"`
+	// TrustedProxies 受信任的反向代理（IP 或 CIDR），只有来自这些地址的请求才使用 X-Forwarded-For 等请求头确定客户端 IP
+	// 留空时不信任任何代理，客户端 IP 取 TCP 连接的对端地址，防止伪造请求头绕过 IP 白名单
+	TrustedProxies []string `mapstructure:"trustedProxies"`
 }
 
 // DatabaseConfig 数据库配置
 type DatabaseConfig struct {
+	Driver          string        `mapstructure:"driver"`
+	Path            string        `mapstructure:"path"`
 	Host            string        `mapstructure:"host"`
 	Port            int           `mapstructure:"port"`
 	Username        string        `mapstructure:"username"`
@@ -39,6 +62,7 @@ type DatabaseConfig struct {
 	MaxIdleConns    int           `mapstructure:"maxIdleConns"`
 	MaxOpenConns    int           `mapstructure:"maxOpenConns"`
 	ConnMaxLifetime time.Duration `mapstructure:"connMaxLifetime"`
+	SlowThreshold   time.Duration `mapstructure:"slowThreshold"`
 }
 
 // RedisConfig Redis配置
@@ -50,6 +74,14 @@ type RedisConfig struct {
 	PoolSize int    `mapstructure:"poolSize"`
 }
 
+// CacheConfig 缓存配置
+type CacheConfig struct {
+	Driver            string        `mapstructure:"driver"`
+	MaxEntries        int           `mapstructure:"maxEntries"`
+	LocalTTL          time.Duration `mapstructure:"localTTL"`
+	ReconnectInterval time.Duration `mapstructure:"reconnectInterval"`
+}
+
 // JWTConfig JWT配置
 type JWTConfig struct {
 	Secret     string        `mapstructure:"secret"`
@@ -61,80 +93,429 @@ type UploadConfig struct {
 	MaxSize      int      `mapstructure:"maxSize"`
 	AllowedTypes []string `mapstructure:"allowedTypes"`
 	UploadPath   string   `mapstructure:"uploadPath"`
+	// ContentTypes 各上传类型（avatar、cover、video、thumbnail、others）允许的内容类型，按文件头识别
+	ContentTypes map[string][]string `mapstructure:"contentTypes"`
+	Resumable    ResumableConfig     `mapstructure:"resumable"`
+	GC           MediaGCConfig       `mapstructure:"gc"`
+	Image        ImageConfig         `mapstructure:"image"`
+	Video        VideoConfig         `mapstructure:"video"`
+}
+
+// UploadTypes 支持的上传类型
+var UploadTypes = []string{"avatar", "cover", "video", "thumbnail", "others"}
+
+var (
+	imageContentTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+	videoContentTypes = []string{"video/mp4", "video/quicktime", "video/x-msvideo", "video/webm", "video/x-matroska"}
+)
+
+// defaultContentTypes 未配置时各上传类型允许的内容类型
+var defaultContentTypes = map[string][]string{
+	"avatar":    imageContentTypes,
+	"cover":     imageContentTypes,
+	"thumbnail": imageContentTypes,
+	"video":     videoContentTypes,
+	"others":    append(append([]string{}, imageContentTypes...), videoContentTypes...),
+}
+
+// ImageConfig 图片处理配置：头像、封面、缩略图上传后按规格生成居中裁剪的缩放图
+type ImageConfig struct {
+	// Variants 各上传类型（avatar、cover、thumbnail）生成的规格，未配置的上传类型使用默认规格，配置为空列表时不生成
+	Variants map[string][]ImageVariant `mapstructure:"variants"`
+	// Quality JPEG 编码质量（1-100，默认 85）
+	Quality int `mapstructure:"quality"`
+	// Workers 异步生成规格图的并发数（默认 2）
+	Workers int `mapstructure:"workers"`
+	// MaxPixels 允许解码的最大像素数（默认 5000 万），防止解压炸弹
+	MaxPixels int `mapstructure:"maxPixels"`
+}
+
+// ImageVariant 图片规格
+type ImageVariant struct {
+	Name   string `mapstructure:"name"`
+	Width  int    `mapstructure:"width"`
+	Height int    `mapstructure:"height"`
+}
+
+// VideoConfig 视频元数据校验配置：上传的 MP4/MOV 必须能解析出时长，且编码在允许的范围内
+type VideoConfig struct {
+	// VideoCodecs 允许的视频编码，取采样描述中的四字符标识（默认 avc1、avc3，即 H.264）
+	VideoCodecs []string `mapstructure:"videoCodecs"`
+	// AudioCodecs 允许的音频编码（默认 mp4a，即 AAC），没有音频轨道的视频不受限制
+	AudioCodecs []string `mapstructure:"audioCodecs"`
+}
+
+// ImageUploadTypes 支持生成规格图的上传类型
+var ImageUploadTypes = []string{"avatar", "cover", "thumbnail"}
+
+// defaultImageVariants 未配置时各上传类型生成的规格
+var defaultImageVariants = map[string][]ImageVariant{
+	"avatar":    {{Name: "small", Width: 128, Height: 128}},
+	"cover":     {{Name: "small", Width: 300, Height: 400}, {Name: "large", Width: 600, Height: 800}},
+	"thumbnail": {{Name: "small", Width: 320, Height: 180}},
+}
+
+// MediaGCConfig 媒体文件回收配置：未被短剧、剧集或用户引用的文件超过保留期后删除
+type MediaGCConfig struct {
+	RetentionDays int           `mapstructure:"retentionDays"`
+	Interval      time.Duration `mapstructure:"interval"`
+}
+
+// ResumableConfig 分片断点续传配置，用于超过 upload.maxSize 的大文件（如剧集视频）
+type ResumableConfig struct {
+	ChunkSizeMB     int           `mapstructure:"chunkSizeMB"`
+	MaxSizeMB       int           `mapstructure:"maxSizeMB"`
+	SessionTTL      time.Duration `mapstructure:"sessionTTL"`
+	CleanupInterval time.Duration `mapstructure:"cleanupInterval"`
+}
+
+// StorageConfig 对象存储配置
+// local 驱动把文件保存在 upload.uploadPath 并由服务自身在 /uploads 下提供访问，多实例部署时应使用 s3 驱动
+type StorageConfig struct {
+	Driver        string        `mapstructure:"driver"`
+	PublicURL     string        `mapstructure:"publicURL"`
+	PresignExpiry time.Duration `mapstructure:"presignExpiry"`
+	S3            S3Config      `mapstructure:"s3"`
+}
+
+// S3Config S3 兼容对象存储配置（AWS S3、MinIO、OSS、COS 等）
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"accessKey"`
+	SecretKey string `mapstructure:"secretKey"`
+	UseSSL    bool   `mapstructure:"useSSL"`
+	PathStyle bool   `mapstructure:"pathStyle"`
+	Prefix    string `mapstructure:"prefix"`
+}
+
+// TranscodeConfig 剧集视频转码配置：上传的视频按码率阶梯转码为 HLS，播放时根据网络自适应切换
+type TranscodeConfig struct {
+	// Enabled 是否启用转码，需要安装 ffmpeg；未启用时剧集只提供原始 MP4 播放源
+	Enabled    bool   `mapstructure:"enabled"`
+	FFmpegPath string `mapstructure:"ffmpegPath"`
+	// Workers 并发处理转码任务的数量（默认 1）
+	Workers int `mapstructure:"workers"`
+	// PollInterval 检查待处理任务的间隔（秒，默认 10）
+	PollInterval time.Duration `mapstructure:"pollInterval"`
+	// JobTimeout 单个任务的超时时间（秒，默认 3600），超时仍未完成的任务会被重新领取
+	JobTimeout time.Duration `mapstructure:"jobTimeout"`
+	// MaxAttempts 任务失败后的最大尝试次数（默认 3）
+	MaxAttempts int `mapstructure:"maxAttempts"`
+	// SegmentSeconds HLS 分片时长（秒，默认 6）
+	SegmentSeconds int `mapstructure:"segmentSeconds"`
+	// Renditions 码率阶梯，高于原视频分辨率的档位会被跳过，未配置时使用默认阶梯
+	Renditions []RenditionConfig `mapstructure:"renditions"`
+}
+
+// RenditionConfig 码率阶梯中的一档，宽度按原视频宽高比计算
+type RenditionConfig struct {
+	Name string `mapstructure:"name"`
+	// Height 输出高度，必须为偶数
+	Height int `mapstructure:"height"`
+	// VideoBitrate、AudioBitrate 视频与音频码率（kbps）
+	VideoBitrate int `mapstructure:"videoBitrate"`
+	AudioBitrate int `mapstructure:"audioBitrate"`
+}
+
+// defaultRenditions 未配置时使用的码率阶梯
+var defaultRenditions = []RenditionConfig{
+	{Name: "360p", Height: 360, VideoBitrate: 800, AudioBitrate: 96},
+	{Name: "480p", Height: 480, VideoBitrate: 1400, AudioBitrate: 128},
+	{Name: "720p", Height: 720, VideoBitrate: 2800, AudioBitrate: 128},
+	{Name: "1080p", Height: 1080, VideoBitrate: 5000, AudioBitrate: 192},
+}
+
+// PlaybackConfig 剧集播放地址配置
+type PlaybackConfig struct {
+	// Signed 是否签发带签名的播放地址，开启后上传的视频与 HLS 文件只能通过签名地址访问
+	Signed bool `mapstructure:"signed"`
+	// Keys 签名密钥，第一个未失效的密钥用于签名，其余只用于校验
+	// 轮换时把新密钥放在最前面，并为旧密钥设置 expiresAt，已签发的地址在宽限期内仍然有效
+	Keys []SigningKeyConfig `mapstructure:"keys"`
+	// TTL 播放地址有效期（秒，默认 7200），需要覆盖整集的播放时长
+	TTL time.Duration `mapstructure:"ttl"`
+	// PreviewTTL 管理员预览地址有效期（秒，默认 604800）
+	PreviewTTL time.Duration `mapstructure:"previewTTL"`
+	// BindIP 播放地址是否绑定客户端 IP，移动网络切换时 IP 变化会导致地址失效
+	BindIP bool `mapstructure:"bindIP"`
+	// RequireLogin 是否只向登录用户签发播放地址
+	RequireLogin bool `mapstructure:"requireLogin"`
+	// Encryption 受保护剧集的 HLS 分片加密
+	Encryption EncryptionConfig `mapstructure:"encryption"`
+}
+
+// EncryptionConfig HLS 分片加密配置
+// 受保护的剧集转码时为每个任务生成 AES-128 内容密钥，内容密钥使用主密钥加密后保存在数据库中
+type EncryptionConfig struct {
+	// MasterKeys 主密钥，第一个用于加密新的内容密钥，其余只用于解密
+	// 轮换时把新主密钥放在最前面，服务启动时会用新主密钥重新加密所有内容密钥，之后即可删除旧主密钥
+	MasterKeys []MasterKeyConfig `mapstructure:"masterKeys"`
+}
+
+// MasterKeyConfig 加密内容密钥的主密钥
+type MasterKeyConfig struct {
+	// ID 主密钥标识，保存在内容密钥记录中
+	ID     string `mapstructure:"id"`
+	Secret string `mapstructure:"secret"`
+}
+
+// SigningKeyConfig 播放地址签名密钥
+type SigningKeyConfig struct {
+	// ID 密钥标识，会出现在播放地址中
+	ID     string `mapstructure:"id"`
+	Secret string `mapstructure:"secret"`
+	// ExpiresAt 密钥失效时间（RFC 3339），为空表示长期有效
+	ExpiresAt string `mapstructure:"expiresAt"`
+}
+
+// PublishConfig 定时发布配置：到期的短剧与剧集由后台任务发布，发布后通过 Webhook 通知
+type PublishConfig struct {
+	// Interval 检查到期发布的间隔（秒，默认 30）
+	Interval time.Duration `mapstructure:"interval"`
+	// Timezone 批量排期与发布日历使用的时区（默认 Asia/Shanghai）
+	Timezone string `mapstructure:"timezone"`
+	// Webhook 发布通知，URL 为空时不通知
+	Webhook WebhookConfig `mapstructure:"webhook"`
+}
+
+// TrashConfig 回收站配置：删除的短剧与剧集超过保留期后由后台任务永久删除
+type TrashConfig struct {
+	// RetentionDays 回收站保留天数（默认 30）
+	RetentionDays int `mapstructure:"retentionDays"`
+	// Interval 清理任务的执行间隔（秒，默认 3600）
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// WebhookConfig Webhook 通知配置
+type WebhookConfig struct {
+	URL string `mapstructure:"url"`
+	// Secret 非空时请求头 X-Signature 携带请求体的 HMAC-SHA256 签名
+	Secret string `mapstructure:"secret"`
+	// Timeout 请求超时时间（秒，默认 5）
+	Timeout time.Duration `mapstructure:"timeout"`
 }
 
 // LoggingConfig 日志配置
 type LoggingConfig struct {
-	Level    string `mapstructure:"level"`
-	Format   string `mapstructure:"format"`
-	Output   string `mapstructure:"output"`
-	Filename string `mapstructure:"filename"`
+	Level      string `mapstructure:"level"`
+	Format     string `mapstructure:"format"`
+	Output     string `mapstructure:"output"`
+	Filename   string `mapstructure:"filename"`
+	MaxSizeMB  int    `mapstructure:"maxSizeMB"`
+	MaxBackups int    `mapstructure:"maxBackups"`
 }
 
-// LoadConfig 加载指定路径的配置文件
-func LoadConfig(configFile string) (*Config, error) {
-	viper.SetConfigFile(configFile)
+// MetricsConfig 监控指标配置
+// AllowedIPs 与用户名密码均未配置时 /metrics 不做访问限制
+type MetricsConfig struct {
+	Enabled    bool     `mapstructure:"enabled"`
+	Path       string   `mapstructure:"path"`
+	AllowedIPs []string `mapstructure:"allowedIPs"`
+	Username   string   `mapstructure:"username"`
+	Password   string   `mapstructure:"password"`
+}
 
-	// 设置环境变量前缀
-	viper.SetEnvPrefix("APP")
-	viper.AutomaticEnv()
+// TracingConfig 链路追踪配置
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	ServiceName string  `mapstructure:"serviceName"`
+	Exporter    string  `mapstructure:"exporter"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	Sampler     string  `mapstructure:"sampler"`
+	SampleRatio float64 `mapstructure:"sampleRatio"`
+}
 
-	// 设置环境变量键名替换
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+// HealthConfig 健康检查配置
+type HealthConfig struct {
+	CacheTTL      time.Duration `mapstructure:"cacheTTL"`
+	Timeout       time.Duration `mapstructure:"timeout"`
+	MinFreeDiskMB int           `mapstructure:"minFreeDiskMB"`
+}
 
-	// 读取配置文件
-	if err := viper.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
-	}
+// CORSConfig 跨域配置，AllowOrigins 修改后热更新生效
+type CORSConfig struct {
+	AllowOrigins     []string `mapstructure:"allowOrigins"`
+	AllowCredentials bool     `mapstructure:"allowCredentials"`
+}
 
-	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
-	}
+// SecurityConfig HTTP 安全策略配置，各环境通过 profile 选择不同的策略
+// IP 白名单使用 server.allowedIPs
+type SecurityConfig struct {
+	ContentSecurityPolicy string        `mapstructure:"contentSecurityPolicy"`
+	FrameOptions          string        `mapstructure:"frameOptions"`
+	HSTSMaxAge            int           `mapstructure:"hstsMaxAge"`
+	BlockedUserAgents     []string      `mapstructure:"blockedUserAgents"`
+	MaxBodySizeMB         int           `mapstructure:"maxBodySizeMB"`
+	RequestTimeout        time.Duration `mapstructure:"requestTimeout"`
+}
 
-	// 转换时间单位
-	config.Database.ConnMaxLifetime *= time.Second
-	config.JWT.Expiration *= time.Hour
+// DefaultContentSecurityPolicy 默认内容安全策略：脚本只允许同源，不允许 eval
+const DefaultContentSecurityPolicy = "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data: https:; media-src 'self' blob: https:; font-src 'self' data: https:; connect-src 'self'"
 
-	return &config, nil
+// RateLimitConfig 限流配置，修改后热更新生效
+type RateLimitConfig struct {
+	MaxRequests int           `mapstructure:"maxRequests"`
+	Window      time.Duration `mapstructure:"window"`
 }
 
-// Load 加载配置文件
-func Load(configPath string) (*Config, error) {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
+// EnvProfile 选择环境 profile 的环境变量，如 APP_ENV=production 时在基础配置上叠加 config.production.yaml
+const EnvProfile = "APP_ENV"
+
+// DefaultJWTSecret 示例配置中的 JWT 密钥，release 模式下禁止使用
+const DefaultJWTSecret = "hajimi"
+
+// secretKeys 支持从文件读取的敏感配置项
+// 通过 APP_<KEY>_FILE 环境变量指定文件路径（如 APP_JWT_SECRET_FILE=/run/secrets/jwt），文件内容优先于配置文件与环境变量
+var secretKeys = []string{
+	"database.password",
+	"redis.password",
+	"jwt.secret",
+	"metrics.password",
+	"storage.s3.secretKey",
+	"publish.webhook.secret",
+}
+
+// LoadConfig 加载指定路径的配置文件
+// 加载顺序：基础配置文件、APP_ENV 对应的 profile 文件、APP_ 前缀的环境变量、APP_*_FILE 指定的密钥文件，加载后进行校验
+func LoadConfig(configFile string) (*Config, error) {
+	return load(configFile, os.Getenv(EnvProfile))
+}
 
+// Load 在目录中查找并加载 config.yaml，configPath 为空时依次查找 ./configs 与当前目录
+func Load(configPath string) (*Config, error) {
+	dirs := []string{"./configs", "."}
 	if configPath != "" {
-		viper.AddConfigPath(configPath)
-	} else {
-		viper.AddConfigPath("./configs")
-		viper.AddConfigPath(".")
+		dirs = []string{configPath}
+	}
+
+	for _, dir := range dirs {
+		configFile := filepath.Join(dir, "config.yaml")
+		if _, err := os.Stat(configFile); err == nil {
+			return LoadConfig(configFile)
+		}
 	}
+	return nil, fmt.Errorf("failed to read config file: config.yaml not found in %s", strings.Join(dirs, ", "))
+}
+
+// ProfileFile 获取 profile 配置文件路径，如 configs/config.yaml 的 production profile 为 configs/config.production.yaml
+func ProfileFile(configFile, profile string) string {
+	ext := filepath.Ext(configFile)
+	return strings.TrimSuffix(configFile, ext) + "." + profile + ext
+}
+
+// load 按层次加载配置，每次使用独立的 viper 实例，便于热更新时重新加载
+func load(configFile, profile string) (*Config, error) {
+	v := viper.New()
+	v.SetConfigFile(configFile)
 
 	// 设置环境变量前缀
-	viper.SetEnvPrefix("APP")
-	viper.AutomaticEnv()
+	v.SetEnvPrefix("APP")
+	v.AutomaticEnv()
 
 	// 设置环境变量键名替换
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	// 读取配置文件
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	// 叠加 profile 配置，profile 文件不存在时仅使用基础配置与环境变量
+	if profile != "" {
+		profileFile := ProfileFile(configFile, profile)
+		if _, err := os.Stat(profileFile); err == nil {
+			v.SetConfigFile(profileFile)
+			if err := v.MergeInConfig(); err != nil {
+				return nil, fmt.Errorf("failed to merge profile config %s: %w", profileFile, err)
+			}
+		} else if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read profile config %s: %w", profileFile, err)
+		}
+	}
+
+	if err := loadSecretFiles(v); err != nil {
+		return nil, err
+	}
+
 	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
+	if err := v.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
+	config.Profile = profile
 
 	// 转换时间单位
 	config.Database.ConnMaxLifetime *= time.Second
+	config.Database.SlowThreshold *= time.Millisecond
 	config.JWT.Expiration *= time.Hour
+	config.Cache.LocalTTL *= time.Second
+	config.Cache.ReconnectInterval *= time.Second
+	config.Health.CacheTTL *= time.Second
+	config.Health.Timeout *= time.Second
+	config.RateLimit.Window *= time.Second
+	config.Security.RequestTimeout *= time.Second
+	config.Storage.PresignExpiry *= time.Second
+	config.Upload.Resumable.SessionTTL *= time.Second
+	config.Upload.Resumable.CleanupInterval *= time.Second
+	config.Upload.GC.Interval *= time.Second
+	config.Transcode.PollInterval *= time.Second
+	config.Transcode.JobTimeout *= time.Second
+	config.Playback.TTL *= time.Second
+	config.Playback.PreviewTTL *= time.Second
+	config.Publish.Interval *= time.Second
+	config.Publish.Webhook.Timeout *= time.Second
+	config.Trash.Interval *= time.Second
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 
 	return &config, nil
 }
 
+// loadSecretFiles 读取 APP_<KEY>_FILE 指定的密钥文件，去除首尾空白后覆盖对应配置项
+func loadSecretFiles(v *viper.Viper) error {
+	for _, key := range secretKeys {
+		env := "APP_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_")) + "_FILE"
+		path := os.Getenv(env)
+		if path == "" {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read secret file %s for %s: %w", path, key, err)
+		}
+		v.Set(key, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// GetDriver 获取数据库驱动名称（默认为 mysql）
+func (c *DatabaseConfig) GetDriver() string {
+	if c.Driver == "" {
+		return "mysql"
+	}
+	return strings.ToLower(c.Driver)
+}
+
+// IsInMemory 是否为 SQLite 内存数据库
+func (c *DatabaseConfig) IsInMemory() bool {
+	return c.GetDriver() == "sqlite" && (c.Path == "" || c.Path == ":memory:")
+}
+
+// GetSQLiteDSN 获取 SQLite 连接字符串
+func (c *DatabaseConfig) GetSQLiteDSN() string {
+	path := c.Path
+	if path == "" {
+		path = ":memory:"
+	}
+	return fmt.Sprintf("%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)", path)
+}
+
 // GetDSN 获取数据库连接字符串
 func (c *DatabaseConfig) GetDSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=%t&loc=%s&collation=utf8mb4_unicode_ci",
@@ -149,6 +530,411 @@ func (c *DatabaseConfig) GetDSN() string {
 	)
 }
 
+// GetSlowThreshold 获取慢查询阈值（默认 200 毫秒）
+func (c *DatabaseConfig) GetSlowThreshold() time.Duration {
+	if c.SlowThreshold <= 0 {
+		return 200 * time.Millisecond
+	}
+	return c.SlowThreshold
+}
+
+// GetContentTypes 获取上传类型允许的内容类型，未配置时头像、封面、缩略图只允许图片，视频只允许视频
+func (c *UploadConfig) GetContentTypes(uploadType string) []string {
+	if types, ok := c.ContentTypes[uploadType]; ok && len(types) > 0 {
+		return types
+	}
+	return defaultContentTypes[uploadType]
+}
+
+// GetVariants 获取上传类型生成的图片规格
+func (c *ImageConfig) GetVariants(uploadType string) []ImageVariant {
+	if variants, ok := c.Variants[uploadType]; ok {
+		return variants
+	}
+	return defaultImageVariants[uploadType]
+}
+
+// GetQuality 获取 JPEG 编码质量（默认 85）
+func (c *ImageConfig) GetQuality() int {
+	if c.Quality <= 0 {
+		return 85
+	}
+	return c.Quality
+}
+
+// GetWorkers 获取异步生成规格图的并发数（默认 2）
+func (c *ImageConfig) GetWorkers() int {
+	if c.Workers <= 0 {
+		return 2
+	}
+	return c.Workers
+}
+
+// GetMaxPixels 获取允许解码的最大像素数（默认 5000 万）
+func (c *ImageConfig) GetMaxPixels() int {
+	if c.MaxPixels <= 0 {
+		return 50_000_000
+	}
+	return c.MaxPixels
+}
+
+// GetVideoCodecs 获取允许的视频编码（默认 avc1、avc3）
+func (c *VideoConfig) GetVideoCodecs() []string {
+	if len(c.VideoCodecs) == 0 {
+		return []string{"avc1", "avc3"}
+	}
+	return c.VideoCodecs
+}
+
+// GetAudioCodecs 获取允许的音频编码（默认 mp4a）
+func (c *VideoConfig) GetAudioCodecs() []string {
+	if len(c.AudioCodecs) == 0 {
+		return []string{"mp4a"}
+	}
+	return c.AudioCodecs
+}
+
+// GetChunkSizeBytes 获取分片大小（默认 8MB），除最后一片外每个分片必须等于该大小
+func (c *ResumableConfig) GetChunkSizeBytes() int64 {
+	if c.ChunkSizeMB <= 0 {
+		return 8 * 1024 * 1024
+	}
+	return int64(c.ChunkSizeMB) * 1024 * 1024
+}
+
+// GetMaxSizeBytes 获取分片上传的文件大小上限（默认 2GB）
+func (c *ResumableConfig) GetMaxSizeBytes() int64 {
+	if c.MaxSizeMB <= 0 {
+		return 2048 * 1024 * 1024
+	}
+	return int64(c.MaxSizeMB) * 1024 * 1024
+}
+
+// GetSessionTTL 获取上传会话的有效期（默认 24 小时），每次上传分片后重新计时
+func (c *ResumableConfig) GetSessionTTL() time.Duration {
+	if c.SessionTTL <= 0 {
+		return 24 * time.Hour
+	}
+	return c.SessionTTL
+}
+
+// GetCleanupInterval 获取过期会话的清理间隔（默认 10 分钟）
+func (c *ResumableConfig) GetCleanupInterval() time.Duration {
+	if c.CleanupInterval <= 0 {
+		return 10 * time.Minute
+	}
+	return c.CleanupInterval
+}
+
+// GetRetention 获取未引用文件的保留期（默认 7 天）
+func (c *MediaGCConfig) GetRetention() time.Duration {
+	if c.RetentionDays <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
+// GetInterval 获取回收任务的执行间隔（默认 1 小时）
+func (c *MediaGCConfig) GetInterval() time.Duration {
+	if c.Interval <= 0 {
+		return time.Hour
+	}
+	return c.Interval
+}
+
+// GetDriver 获取对象存储驱动名称（local | s3，默认为 local）
+func (c *StorageConfig) GetDriver() string {
+	if c.Driver == "" {
+		return "local"
+	}
+	return strings.ToLower(c.Driver)
+}
+
+// GetPublicURL 获取 CDN 等公开访问地址，配置后文件 URL 直接拼接该地址而不再签名
+func (c *StorageConfig) GetPublicURL() string {
+	return strings.TrimSuffix(c.PublicURL, "/")
+}
+
+// GetPresignExpiry 获取预签名 URL 的有效期（默认 15 分钟）
+func (c *StorageConfig) GetPresignExpiry() time.Duration {
+	if c.PresignExpiry <= 0 {
+		return 15 * time.Minute
+	}
+	return c.PresignExpiry
+}
+
+// GetFFmpegPath 获取 ffmpeg 可执行文件路径（默认从 PATH 查找 ffmpeg）
+func (c *TranscodeConfig) GetFFmpegPath() string {
+	if c.FFmpegPath == "" {
+		return "ffmpeg"
+	}
+	return c.FFmpegPath
+}
+
+// GetWorkers 获取并发处理转码任务的数量（默认 1）
+func (c *TranscodeConfig) GetWorkers() int {
+	if c.Workers <= 0 {
+		return 1
+	}
+	return c.Workers
+}
+
+// GetPollInterval 获取检查待处理任务的间隔（默认 10 秒）
+func (c *TranscodeConfig) GetPollInterval() time.Duration {
+	if c.PollInterval <= 0 {
+		return 10 * time.Second
+	}
+	return c.PollInterval
+}
+
+// GetJobTimeout 获取单个任务的超时时间（默认 1 小时）
+func (c *TranscodeConfig) GetJobTimeout() time.Duration {
+	if c.JobTimeout <= 0 {
+		return time.Hour
+	}
+	return c.JobTimeout
+}
+
+// GetMaxAttempts 获取任务的最大尝试次数（默认 3）
+func (c *TranscodeConfig) GetMaxAttempts() int {
+	if c.MaxAttempts <= 0 {
+		return 3
+	}
+	return c.MaxAttempts
+}
+
+// GetSegmentSeconds 获取 HLS 分片时长（默认 6 秒）
+func (c *TranscodeConfig) GetSegmentSeconds() int {
+	if c.SegmentSeconds <= 0 {
+		return 6
+	}
+	return c.SegmentSeconds
+}
+
+// GetRenditions 获取码率阶梯
+func (c *TranscodeConfig) GetRenditions() []RenditionConfig {
+	if len(c.Renditions) == 0 {
+		return defaultRenditions
+	}
+	return c.Renditions
+}
+
+// GetTTL 获取播放地址有效期（默认为 2 小时）
+func (c *PlaybackConfig) GetTTL() time.Duration {
+	if c.TTL <= 0 {
+		return 2 * time.Hour
+	}
+	return c.TTL
+}
+
+// GetPreviewTTL 获取管理员预览地址有效期（默认为 7 天）
+func (c *PlaybackConfig) GetPreviewTTL() time.Duration {
+	if c.PreviewTTL <= 0 {
+		return 7 * 24 * time.Hour
+	}
+	return c.PreviewTTL
+}
+
+// GetInterval 获取检查到期发布的间隔（默认 30 秒）
+func (c *PublishConfig) GetInterval() time.Duration {
+	if c.Interval <= 0 {
+		return 30 * time.Second
+	}
+	return c.Interval
+}
+
+// GetTimezone 获取排期使用的时区名称（默认 Asia/Shanghai）
+func (c *PublishConfig) GetTimezone() string {
+	if c.Timezone == "" {
+		return "Asia/Shanghai"
+	}
+	return c.Timezone
+}
+
+// GetLocation 获取排期使用的时区，时区不存在时返回 UTC
+func (c *PublishConfig) GetLocation() *time.Location {
+	loc, err := time.LoadLocation(c.GetTimezone())
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// GetRetention 获取回收站的保留期（默认 30 天）
+func (c *TrashConfig) GetRetention() time.Duration {
+	if c.RetentionDays <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
+// GetInterval 获取清理任务的执行间隔（默认 1 小时）
+func (c *TrashConfig) GetInterval() time.Duration {
+	if c.Interval <= 0 {
+		return time.Hour
+	}
+	return c.Interval
+}
+
+// GetTimeout 获取 Webhook 请求超时时间（默认 5 秒）
+func (c *WebhookConfig) GetTimeout() time.Duration {
+	if c.Timeout <= 0 {
+		return 5 * time.Second
+	}
+	return c.Timeout
+}
+
+// GetExpiresAt 获取密钥失效时间，未配置或格式错误时返回零值
+func (c *SigningKeyConfig) GetExpiresAt() time.Time {
+	expiresAt, _ := time.Parse(time.RFC3339, c.ExpiresAt)
+	return expiresAt
+}
+
+// GetRegion 获取 S3 区域（默认为 us-east-1）
+func (c *S3Config) GetRegion() string {
+	if c.Region == "" {
+		return "us-east-1"
+	}
+	return c.Region
+}
+
+// GetFormat 获取日志格式（json | text，默认为 json）
+func (c *LoggingConfig) GetFormat() string {
+	if c.Format == "" {
+		return "json"
+	}
+	return strings.ToLower(c.Format)
+}
+
+// GetOutput 获取日志输出（stdout | stderr | file，默认为 stdout）
+func (c *LoggingConfig) GetOutput() string {
+	if c.Output == "" {
+		return "stdout"
+	}
+	return strings.ToLower(c.Output)
+}
+
+// GetFilename 获取日志文件路径（默认为 logs/app.log）
+func (c *LoggingConfig) GetFilename() string {
+	if c.Filename == "" {
+		return "logs/app.log"
+	}
+	return c.Filename
+}
+
+// GetMaxSizeBytes 获取单个日志文件的最大大小（默认 100MB）
+func (c *LoggingConfig) GetMaxSizeBytes() int64 {
+	if c.MaxSizeMB <= 0 {
+		return 100 * 1024 * 1024
+	}
+	return int64(c.MaxSizeMB) * 1024 * 1024
+}
+
+// GetMaxBackups 获取保留的历史日志文件数（默认 5）
+func (c *LoggingConfig) GetMaxBackups() int {
+	if c.MaxBackups <= 0 {
+		return 5
+	}
+	return c.MaxBackups
+}
+
+// GetDriver 获取缓存驱动名称（redis | memory | tiered，默认为 redis）
+func (c *CacheConfig) GetDriver() string {
+	if c.Driver == "" {
+		return "redis"
+	}
+	return strings.ToLower(c.Driver)
+}
+
+// GetMaxEntries 获取本地缓存最大条目数（默认 10000）
+func (c *CacheConfig) GetMaxEntries() int {
+	if c.MaxEntries <= 0 {
+		return 10000
+	}
+	return c.MaxEntries
+}
+
+// GetLocalTTL 获取分层模式下本地缓存的最长存活时间（默认 30 秒）
+func (c *CacheConfig) GetLocalTTL() time.Duration {
+	if c.LocalTTL <= 0 {
+		return 30 * time.Second
+	}
+	return c.LocalTTL
+}
+
+// GetReconnectInterval 获取 Redis 断线后的重连间隔（默认 10 秒）
+func (c *CacheConfig) GetReconnectInterval() time.Duration {
+	if c.ReconnectInterval <= 0 {
+		return 10 * time.Second
+	}
+	return c.ReconnectInterval
+}
+
+// GetCacheTTL 获取探测结果的缓存时间（默认 5 秒）
+func (c *HealthConfig) GetCacheTTL() time.Duration {
+	if c.CacheTTL <= 0 {
+		return 5 * time.Second
+	}
+	return c.CacheTTL
+}
+
+// GetTimeout 获取单个探测的超时时间（默认 2 秒）
+func (c *HealthConfig) GetTimeout() time.Duration {
+	if c.Timeout <= 0 {
+		return 2 * time.Second
+	}
+	return c.Timeout
+}
+
+// GetMinFreeDiskBytes 获取上传目录所在磁盘的最小剩余空间（默认 100MB）
+func (c *HealthConfig) GetMinFreeDiskBytes() uint64 {
+	if c.MinFreeDiskMB <= 0 {
+		return 100 * 1024 * 1024
+	}
+	return uint64(c.MinFreeDiskMB) * 1024 * 1024
+}
+
+// GetPath 获取指标暴露路径（默认为 /metrics）
+func (c *MetricsConfig) GetPath() string {
+	if c.Path == "" {
+		return "/metrics"
+	}
+	return c.Path
+}
+
+// GetServiceName 获取上报的服务名称（默认为 gin-mysql-api）
+func (c *TracingConfig) GetServiceName() string {
+	if c.ServiceName == "" {
+		return "gin-mysql-api"
+	}
+	return c.ServiceName
+}
+
+// GetExporter 获取导出器类型（otlp | stdout，默认为 otlp）
+func (c *TracingConfig) GetExporter() string {
+	if c.Exporter == "" {
+		return "otlp"
+	}
+	return strings.ToLower(c.Exporter)
+}
+
+// GetEndpoint 获取 OTLP HTTP 接收地址（默认为 localhost:4318）
+func (c *TracingConfig) GetEndpoint() string {
+	if c.Endpoint == "" {
+		return "localhost:4318"
+	}
+	return c.Endpoint
+}
+
+// GetSampler 获取采样策略（always_on | always_off | ratio，默认为 always_on）
+// 均以上游传入的采样决定为准，仅对新建的链路生效
+func (c *TracingConfig) GetSampler() string {
+	if c.Sampler == "" {
+		return "always_on"
+	}
+	return strings.ToLower(c.Sampler)
+}
+
 // GetRedisAddr 获取Redis连接地址
 func (c *RedisConfig) GetRedisAddr() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
@@ -158,3 +944,64 @@ func (c *RedisConfig) GetRedisAddr() string {
 func (c *ServerConfig) GetServerAddr() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
+
+// IsRelease 是否为生产（release）模式
+func (c *ServerConfig) IsRelease() bool {
+	return c.Mode == "release"
+}
+
+// GetBaseURL 获取服务对外访问地址，用于生成文件 URL（默认为 http://localhost:<port>）
+func (c *ServerConfig) GetBaseURL() string {
+	if c.BaseURL == "" {
+		return fmt.Sprintf("http://localhost:%d", c.Port)
+	}
+	return strings.TrimSuffix(c.BaseURL, "/")
+}
+
+// GetAllowOrigins 获取允许跨域访问的来源（默认允许所有来源）
+func (c *CORSConfig) GetAllowOrigins() []string {
+	if len(c.AllowOrigins) == 0 {
+		return []string{"*"}
+	}
+	return c.AllowOrigins
+}
+
+// GetContentSecurityPolicy 获取 Content-Security-Policy 头（默认为 DefaultContentSecurityPolicy）
+func (c *SecurityConfig) GetContentSecurityPolicy() string {
+	if c.ContentSecurityPolicy == "" {
+		return DefaultContentSecurityPolicy
+	}
+	return c.ContentSecurityPolicy
+}
+
+// GetFrameOptions 获取 X-Frame-Options 头（DENY | SAMEORIGIN，默认为 DENY）
+func (c *SecurityConfig) GetFrameOptions() string {
+	if c.FrameOptions == "" {
+		return "DENY"
+	}
+	return strings.ToUpper(c.FrameOptions)
+}
+
+// GetMaxBodySizeBytes 获取普通请求的请求体大小上限（默认 10MB），上传接口使用 upload.maxSize
+func (c *SecurityConfig) GetMaxBodySizeBytes() int64 {
+	if c.MaxBodySizeMB <= 0 {
+		return 10 * 1024 * 1024
+	}
+	return int64(c.MaxBodySizeMB) * 1024 * 1024
+}
+
+// GetMaxRequests 获取单个客户端在时间窗口内的最大请求数（默认 100）
+func (c *RateLimitConfig) GetMaxRequests() int {
+	if c.MaxRequests <= 0 {
+		return 100
+	}
+	return c.MaxRequests
+}
+
+// GetWindow 获取限流时间窗口（默认 60 秒）
+func (c *RateLimitConfig) GetWindow() time.Duration {
+	if c.Window <= 0 {
+		return time.Minute
+	}
+	return c.Window
+}
@@ -0,0 +1,498 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const baseYAML = `
+server:
+  port: 1800
+  mode: "debug"
+database:
+  driver: "sqlite"
+cache:
+  driver: "memory"
+jwt:
+  secret: "hajimi"
+  expiration: 24
+upload:
+  maxSize: 100
+  uploadPath: "./uploads"
+logging:
+  level: "info"
+rateLimit:
+  maxRequests: 100
+  window: 60
+`
+
+// writeFile 写入测试文件
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+// validConfig 返回一份可通过校验的配置
+func validConfig() *Config {
+	return &Config{
+		Server:   ServerConfig{Port: 1800, Mode: "debug"},
+		Database: DatabaseConfig{Driver: "sqlite"},
+		Cache:    CacheConfig{Driver: "memory"},
+		JWT:      JWTConfig{Secret: "hajimi", Expiration: time.Hour},
+		Upload:   UploadConfig{MaxSize: 100, UploadPath: "./uploads"},
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("加载基础配置并转换时间单位", func(t *testing.T) {
+		t.Setenv(EnvProfile, "")
+		configFile := filepath.Join(t.TempDir(), "config.yaml")
+		writeFile(t, configFile, baseYAML)
+
+		cfg, err := LoadConfig(configFile)
+		require.NoError(t, err)
+		assert.Equal(t, 24*time.Hour, cfg.JWT.Expiration)
+		assert.Equal(t, time.Minute, cfg.RateLimit.Window)
+		assert.Equal(t, "http://localhost:1800", cfg.Server.GetBaseURL())
+		assert.Empty(t, cfg.Profile)
+	})
+
+	t.Run("叠加 profile 配置与环境变量", func(t *testing.T) {
+		dir := t.TempDir()
+		configFile := filepath.Join(dir, "config.yaml")
+		writeFile(t, configFile, baseYAML)
+		writeFile(t, filepath.Join(dir, "config.staging.yaml"), `
+server:
+  baseURL: "https://staging.example.com/"
+rateLimit:
+  maxRequests: 10
+`)
+		t.Setenv(EnvProfile, "staging")
+		t.Setenv("APP_LOGGING_LEVEL", "debug")
+
+		cfg, err := LoadConfig(configFile)
+		require.NoError(t, err)
+		assert.Equal(t, "staging", cfg.Profile)
+		assert.Equal(t, "https://staging.example.com", cfg.Server.GetBaseURL())
+		assert.Equal(t, 10, cfg.RateLimit.MaxRequests)
+		assert.Equal(t, time.Minute, cfg.RateLimit.Window, "未覆盖的配置沿用基础配置")
+		assert.Equal(t, "debug", cfg.Logging.Level)
+	})
+
+	t.Run("profile 文件不存在时只使用基础配置", func(t *testing.T) {
+		configFile := filepath.Join(t.TempDir(), "config.yaml")
+		writeFile(t, configFile, baseYAML)
+		t.Setenv(EnvProfile, "qa")
+
+		cfg, err := LoadConfig(configFile)
+		require.NoError(t, err)
+		assert.Equal(t, "qa", cfg.Profile)
+		assert.Equal(t, 100, cfg.RateLimit.MaxRequests)
+	})
+
+	t.Run("从文件读取密钥", func(t *testing.T) {
+		t.Setenv(EnvProfile, "")
+		dir := t.TempDir()
+		configFile := filepath.Join(dir, "config.yaml")
+		writeFile(t, configFile, baseYAML)
+		secretFile := filepath.Join(dir, "jwt_secret")
+		writeFile(t, secretFile, "from-secret-file\n")
+		t.Setenv("APP_JWT_SECRET_FILE", secretFile)
+
+		cfg, err := LoadConfig(configFile)
+		require.NoError(t, err)
+		assert.Equal(t, "from-secret-file", cfg.JWT.Secret)
+	})
+
+	t.Run("密钥文件不存在时返回错误", func(t *testing.T) {
+		t.Setenv(EnvProfile, "")
+		configFile := filepath.Join(t.TempDir(), "config.yaml")
+		writeFile(t, configFile, baseYAML)
+		t.Setenv("APP_JWT_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+
+		_, err := LoadConfig(configFile)
+		assert.ErrorContains(t, err, "jwt.secret")
+	})
+
+	t.Run("release 模式拒绝默认 JWT 密钥", func(t *testing.T) {
+		t.Setenv(EnvProfile, "")
+		t.Setenv("APP_SERVER_MODE", "release")
+		configFile := filepath.Join(t.TempDir(), "config.yaml")
+		writeFile(t, configFile, baseYAML)
+
+		_, err := LoadConfig(configFile)
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		assert.Contains(t, err.Error(), "jwt.secret must not use the shipped default")
+	})
+
+	t.Run("仓库自带配置通过校验", func(t *testing.T) {
+		t.Setenv(EnvProfile, "")
+		for _, name := range []string{"config.yaml", "config-simple.yaml"} {
+			_, err := LoadConfig(filepath.Join("..", "..", "configs", name))
+			assert.NoError(t, err, name)
+		}
+	})
+
+	t.Run("生产 profile 要求注入 JWT 密钥", func(t *testing.T) {
+		configFile := filepath.Join("..", "..", "configs", "config.yaml")
+		t.Setenv(EnvProfile, "production")
+
+		_, err := LoadConfig(configFile)
+		assert.ErrorContains(t, err, "jwt.secret must not be empty")
+
+		t.Setenv("APP_JWT_SECRET", "0123456789abcdef0123456789abcdef")
+		t.Setenv("APP_STORAGE_S3_ACCESSKEY", "access")
+		t.Setenv("APP_STORAGE_S3_SECRETKEY", "secret")
+		cfg, err := LoadConfig(configFile)
+		require.NoError(t, err)
+		assert.True(t, cfg.Server.IsRelease())
+		assert.Equal(t, "https://api.example.com", cfg.Server.GetBaseURL())
+		assert.Equal(t, "s3", cfg.Storage.GetDriver())
+		assert.Equal(t, "secret", cfg.Storage.S3.SecretKey)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("合法配置", func(t *testing.T) {
+		assert.NoError(t, validConfig().Validate())
+	})
+
+	t.Run("列出所有不合法的配置项", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Server.Port = 0
+		cfg.Server.Mode = "prod"
+		cfg.JWT.Secret = ""
+		cfg.Logging.Format = "xml"
+		cfg.Tracing.SampleRatio = 2
+
+		err := cfg.Validate()
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		assert.Len(t, validationErr.Problems, 5)
+		assert.Contains(t, err.Error(), "server.port")
+		assert.Contains(t, err.Error(), "server.mode")
+		assert.Contains(t, err.Error(), "jwt.secret must not be empty")
+		assert.Contains(t, err.Error(), "logging.format")
+		assert.Contains(t, err.Error(), "tracing.sampleRatio")
+	})
+
+	t.Run("release 模式要求足够长的 JWT 密钥", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Server.Mode = "release"
+		cfg.JWT.Secret = "short-secret"
+		assert.ErrorContains(t, cfg.Validate(), "at least 32 characters")
+
+		cfg.JWT.Secret = "0123456789abcdef0123456789abcdef"
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("mysql 与 redis 需要连接地址", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Database.Driver = "mysql"
+		cfg.Cache.Driver = "redis"
+
+		err := cfg.Validate()
+		assert.ErrorContains(t, err, "database.host")
+		assert.ErrorContains(t, err, "database.dbname")
+		assert.ErrorContains(t, err, "redis.host")
+	})
+
+	t.Run("baseURL 必须为绝对地址", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Server.BaseURL = "localhost:1800"
+		assert.ErrorContains(t, cfg.Validate(), "server.baseURL")
+	})
+
+	t.Run("安全策略配置", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Server.AllowedIPs = []string{"10.0.0.0/8", "127.0.0.1", "10.0.0.0/33"}
+		cfg.Metrics.AllowedIPs = []string{"localhost"}
+		cfg.CORS = CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true}
+		cfg.Security.FrameOptions = "allow-from"
+
+		err := cfg.Validate()
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		assert.Len(t, validationErr.Problems, 4)
+		assert.Contains(t, err.Error(), `server.allowedIPs contains invalid IP or CIDR "10.0.0.0/33"`)
+		assert.Contains(t, err.Error(), `metrics.allowedIPs contains invalid IP or CIDR "localhost"`)
+		assert.Contains(t, err.Error(), "cors.allowCredentials")
+		assert.Contains(t, err.Error(), "security.frameOptions")
+	})
+}
+
+func TestValidateStorage(t *testing.T) {
+	t.Run("默认使用本地存储", func(t *testing.T) {
+		cfg := validConfig()
+		assert.NoError(t, cfg.Validate())
+		assert.Equal(t, "local", cfg.Storage.GetDriver())
+		assert.Equal(t, 15*time.Minute, cfg.Storage.GetPresignExpiry())
+	})
+
+	t.Run("s3 需要连接信息", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Storage.Driver = "s3"
+		cfg.Storage.S3.Endpoint = "https://s3.amazonaws.com"
+
+		err := cfg.Validate()
+		assert.ErrorContains(t, err, "storage.s3.bucket")
+		assert.ErrorContains(t, err, "storage.s3.accessKey")
+		assert.ErrorContains(t, err, "without scheme")
+	})
+
+	t.Run("CDN 地址与签名有效期", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Storage.PublicURL = "cdn.example.com"
+		cfg.Storage.PresignExpiry = 8 * 24 * time.Hour
+
+		err := cfg.Validate()
+		assert.ErrorContains(t, err, "storage.publicURL")
+		assert.ErrorContains(t, err, "storage.presignExpiry")
+	})
+}
+
+func TestUploadContentTypes(t *testing.T) {
+	t.Run("默认按上传类型区分图片与视频", func(t *testing.T) {
+		cfg := validConfig()
+		assert.Contains(t, cfg.Upload.GetContentTypes("avatar"), "image/png")
+		assert.NotContains(t, cfg.Upload.GetContentTypes("avatar"), "video/mp4")
+		assert.Equal(t, []string{"video/mp4", "video/quicktime", "video/x-msvideo", "video/webm", "video/x-matroska"}, cfg.Upload.GetContentTypes("video"))
+		assert.Contains(t, cfg.Upload.GetContentTypes("others"), "video/mp4")
+	})
+
+	t.Run("配置覆盖默认值", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Upload.ContentTypes = map[string][]string{"cover": {"image/png"}}
+		assert.Equal(t, []string{"image/png"}, cfg.Upload.GetContentTypes("cover"))
+		assert.Contains(t, cfg.Upload.GetContentTypes("avatar"), "image/jpeg")
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("未知的上传类型", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Upload.ContentTypes = map[string][]string{"banner": {"image/png"}}
+		assert.ErrorContains(t, cfg.Validate(), `upload.contentTypes key must be one of avatar, cover, video, thumbnail, others, got "banner"`)
+	})
+}
+
+func TestValidateResumableUpload(t *testing.T) {
+	t.Run("默认值", func(t *testing.T) {
+		cfg := validConfig()
+		assert.Equal(t, int64(8*1024*1024), cfg.Upload.Resumable.GetChunkSizeBytes())
+		assert.Equal(t, int64(2048*1024*1024), cfg.Upload.Resumable.GetMaxSizeBytes())
+		assert.Equal(t, 24*time.Hour, cfg.Upload.Resumable.GetSessionTTL())
+		assert.Equal(t, 10*time.Minute, cfg.Upload.Resumable.GetCleanupInterval())
+	})
+
+	t.Run("分片大小不能超过单次请求上限", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Upload.Resumable.ChunkSizeMB = cfg.Upload.MaxSize + 1
+		cfg.Upload.Resumable.SessionTTL = -time.Second
+
+		err := cfg.Validate()
+		assert.ErrorContains(t, err, "upload.resumable.chunkSizeMB must not exceed upload.maxSize")
+		assert.ErrorContains(t, err, "upload.resumable.sessionTTL")
+	})
+}
+
+func TestMediaGC(t *testing.T) {
+	cfg := validConfig()
+	assert.Equal(t, 7*24*time.Hour, cfg.Upload.GC.GetRetention())
+	assert.Equal(t, time.Hour, cfg.Upload.GC.GetInterval())
+
+	cfg.Upload.GC.RetentionDays = 3
+	assert.Equal(t, 72*time.Hour, cfg.Upload.GC.GetRetention())
+
+	cfg.Upload.GC.RetentionDays = -1
+	assert.ErrorContains(t, cfg.Validate(), "upload.gc.retentionDays")
+}
+
+func TestVideoCodecs(t *testing.T) {
+	cfg := validConfig()
+	assert.Equal(t, []string{"avc1", "avc3"}, cfg.Upload.Video.GetVideoCodecs())
+	assert.Equal(t, []string{"mp4a"}, cfg.Upload.Video.GetAudioCodecs())
+
+	cfg.Upload.Video.VideoCodecs = []string{"avc1", "hvc1"}
+	assert.Equal(t, []string{"avc1", "hvc1"}, cfg.Upload.Video.GetVideoCodecs())
+
+	cfg.Upload.Video.AudioCodecs = []string{"aac"}
+	assert.ErrorContains(t, cfg.Validate(), `upload.video codecs must be four-character codes, got "aac"`)
+}
+
+func TestTranscode(t *testing.T) {
+	cfg := validConfig()
+	assert.Equal(t, "ffmpeg", cfg.Transcode.GetFFmpegPath())
+	assert.Equal(t, 1, cfg.Transcode.GetWorkers())
+	assert.Equal(t, 10*time.Second, cfg.Transcode.GetPollInterval())
+	assert.Equal(t, time.Hour, cfg.Transcode.GetJobTimeout())
+	assert.Equal(t, 3, cfg.Transcode.GetMaxAttempts())
+	assert.Equal(t, 6, cfg.Transcode.GetSegmentSeconds())
+	assert.Len(t, cfg.Transcode.GetRenditions(), 4)
+
+	cfg.Transcode.Renditions = []RenditionConfig{
+		{Name: "720p", Height: 720, VideoBitrate: 2800, AudioBitrate: 128},
+		{Name: "720p", Height: 721, VideoBitrate: 2800, AudioBitrate: 128},
+		{Name: "HD", Height: 1080, VideoBitrate: 0, AudioBitrate: 128},
+	}
+	cfg.Transcode.Workers = -1
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, `transcode.renditions name "720p" is duplicated`)
+	assert.ErrorContains(t, err, "transcode.renditions.720p height must be an even number")
+	assert.ErrorContains(t, err, `transcode.renditions name must be lowercase letters or digits, got "HD"`)
+	assert.ErrorContains(t, err, "transcode.renditions.HD videoBitrate and audioBitrate must be positive")
+	assert.ErrorContains(t, err, "transcode.workers")
+}
+
+func TestPlayback(t *testing.T) {
+	cfg := validConfig()
+	assert.Equal(t, 2*time.Hour, cfg.Playback.GetTTL())
+	assert.Equal(t, 7*24*time.Hour, cfg.Playback.GetPreviewTTL())
+	assert.NoError(t, cfg.Validate(), "未开启签名时不校验密钥")
+
+	secret := strings.Repeat("s", 32)
+	cfg.Playback.Signed = true
+	assert.ErrorContains(t, cfg.Validate(), "playback.keys must not be empty")
+
+	cfg.Playback.Keys = []SigningKeyConfig{
+		{ID: "k2", Secret: secret},
+		{ID: "k1", Secret: secret, ExpiresAt: "2030-01-01T00:00:00Z"},
+	}
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), cfg.Playback.Keys[1].GetExpiresAt())
+	assert.True(t, cfg.Playback.Keys[0].GetExpiresAt().IsZero())
+
+	cfg.Playback.Keys = []SigningKeyConfig{
+		{ID: "k.1", Secret: "short", ExpiresAt: "2020-01-01T00:00:00Z"},
+		{ID: "k.1", Secret: secret, ExpiresAt: "tomorrow"},
+	}
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, `playback.keys id must be 1-32 letters, digits, '_' or '-', got "k.1"`)
+	assert.ErrorContains(t, err, `playback.keys id "k.1" is duplicated`)
+	assert.ErrorContains(t, err, "playback.keys.k.1 secret must be at least 32 characters")
+	assert.ErrorContains(t, err, `playback.keys.k.1 expiresAt must be an RFC 3339 time, got "tomorrow"`)
+	assert.ErrorContains(t, err, "playback.keys must contain a key that has not expired")
+
+	t.Run("HLS 加密主密钥", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Playback.Encryption.MasterKeys = []MasterKeyConfig{{ID: "m2", Secret: secret}, {ID: "m1", Secret: secret}}
+		require.NoError(t, cfg.Validate())
+
+		cfg.Playback.Encryption.MasterKeys = []MasterKeyConfig{{ID: "m1", Secret: secret}, {ID: "m1", Secret: "short"}}
+		err := cfg.Validate()
+		assert.ErrorContains(t, err, `playback.encryption.masterKeys id "m1" is duplicated`)
+		assert.ErrorContains(t, err, "playback.encryption.masterKeys.m1 secret must be at least 32 characters")
+	})
+
+	t.Run("定时发布", func(t *testing.T) {
+		cfg := validConfig()
+		assert.Equal(t, "Asia/Shanghai", cfg.Publish.GetLocation().String())
+		assert.Equal(t, 30*time.Second, cfg.Publish.GetInterval())
+
+		cfg.Publish.Timezone = "Mars/Olympus"
+		cfg.Publish.Webhook.URL = "hooks.example.com"
+		err := cfg.Validate()
+		assert.ErrorContains(t, err, `publish.timezone must be an IANA time zone, got "Mars/Olympus"`)
+		assert.ErrorContains(t, err, `publish.webhook.url must be an absolute http(s) URL, got "hooks.example.com"`)
+		assert.Equal(t, time.UTC, cfg.Publish.GetLocation())
+	})
+
+	t.Run("回收站", func(t *testing.T) {
+		cfg := validConfig()
+		assert.Equal(t, 30*24*time.Hour, cfg.Trash.GetRetention())
+		assert.Equal(t, time.Hour, cfg.Trash.GetInterval())
+
+		cfg.Trash.RetentionDays = -1
+		assert.ErrorContains(t, cfg.Validate(), "trash.retentionDays and trash.interval must not be negative")
+	})
+}
+
+func TestImageVariants(t *testing.T) {
+	t.Run("默认规格", func(t *testing.T) {
+		cfg := validConfig()
+		assert.Equal(t, []ImageVariant{{Name: "small", Width: 128, Height: 128}}, cfg.Upload.Image.GetVariants("avatar"))
+		assert.Len(t, cfg.Upload.Image.GetVariants("cover"), 2)
+		assert.Empty(t, cfg.Upload.Image.GetVariants("video"))
+		assert.Equal(t, 85, cfg.Upload.Image.GetQuality())
+	})
+
+	t.Run("从配置文件加载", func(t *testing.T) {
+		t.Setenv(EnvProfile, "")
+		configFile := filepath.Join(t.TempDir(), "config.yaml")
+		writeFile(t, configFile, strings.Replace(baseYAML, "upload:\n", `upload:
+  image:
+    variants:
+      cover:
+        - {name: "hd", width: 720, height: 960}
+      avatar: []
+`, 1))
+
+		cfg, err := LoadConfig(configFile)
+		require.NoError(t, err)
+		assert.Equal(t, []ImageVariant{{Name: "hd", Width: 720, Height: 960}}, cfg.Upload.Image.GetVariants("cover"))
+		assert.Empty(t, cfg.Upload.Image.GetVariants("avatar"))
+		assert.Len(t, cfg.Upload.Image.GetVariants("thumbnail"), 1)
+	})
+
+	t.Run("校验规格", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.Upload.Image.Variants = map[string][]ImageVariant{
+			"cover": {{Name: "Small", Width: 300, Height: 400}, {Name: "big", Width: 5000, Height: 400}},
+			"video": {{Name: "small", Width: 1, Height: 1}},
+		}
+		cfg.Upload.Image.Quality = 101
+
+		err := cfg.Validate()
+		assert.ErrorContains(t, err, `upload.image.variants.cover name must be lowercase letters or digits, got "Small"`)
+		assert.ErrorContains(t, err, "upload.image.variants.cover.big size must be between 1 and 4096")
+		assert.ErrorContains(t, err, `upload.image.variants key must be one of avatar, cover, thumbnail, got "video"`)
+		assert.ErrorContains(t, err, "upload.image.quality")
+	})
+}
+
+func TestWatcher(t *testing.T) {
+	t.Setenv(EnvProfile, "")
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, configFile, baseYAML)
+
+	cfg, err := LoadConfig(configFile)
+	require.NoError(t, err)
+
+	watcher, err := NewWatcher(configFile, cfg)
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	changes := make(chan *Config, 4)
+	watcher.OnChange(func(c *Config) { changes <- c })
+
+	t.Run("配置变更后通知订阅者", func(t *testing.T) {
+		writeFile(t, configFile, baseYAML+"\ncors:\n  allowOrigins: [\"https://example.com\"]\n")
+
+		select {
+		case changed := <-changes:
+			assert.Equal(t, []string{"https://example.com"}, changed.CORS.AllowOrigins)
+			assert.Equal(t, changed, watcher.Config())
+		case <-time.After(5 * time.Second):
+			t.Fatal("未收到配置变更通知")
+		}
+	})
+
+	t.Run("校验失败时保留当前配置", func(t *testing.T) {
+		current := watcher.Config()
+		writeFile(t, configFile, strings.Replace(baseYAML, "port: 1800", "port: 0", 1))
+
+		select {
+		case <-changes:
+			t.Fatal("非法配置不应通知订阅者")
+		case <-time.After(time.Second):
+		}
+		assert.Equal(t, current, watcher.Config())
+	})
+}
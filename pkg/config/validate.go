@@ -0,0 +1,278 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ValidationError 配置校验错误，列出所有不合法的配置项
+type ValidationError struct {
+	Problems []string
+}
+
+// Error 实现 error 接口，每行一个问题
+func (e *ValidationError) Error() string {
+	return "invalid config:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// minReleaseJWTSecretLen release 模式下 JWT 密钥的最小长度（HS256 建议至少 256 位）
+const minReleaseJWTSecretLen = 32
+
+// maxPresignExpiry 预签名 URL 的最长有效期（S3 签名 V4 的上限为 7 天）
+const maxPresignExpiry = 7 * 24 * time.Hour
+
+// maxVariantSize 图片规格的最大宽高
+const maxVariantSize = 4096
+
+// minSigningSecretLen 播放地址签名密钥的最小长度
+const minSigningSecretLen = 32
+
+// signingKeyIDPattern 签名密钥标识，会出现在播放地址中
+var signingKeyIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// variantNamePattern 图片规格名称，会出现在存储路径与 URL 中
+var variantNamePattern = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// Validate 校验配置，一次性返回所有不合法的配置项，便于启动时一次修正
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	// 服务器
+	v.check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port must be between 1 and 65535, got %d", c.Server.Port)
+	v.oneOf("server.mode", c.Server.Mode, "", "debug", "release", "test")
+	if c.Server.BaseURL != "" {
+		v.check(isHTTPURL(c.Server.BaseURL), "server.baseURL must be an absolute http(s) URL, got %q", c.Server.BaseURL)
+	}
+
+	// 数据库
+	v.oneOf("database.driver", c.Database.GetDriver(), "mysql", "sqlite")
+	if c.Database.GetDriver() == "mysql" {
+		v.check(c.Database.Host != "", "database.host is required for mysql")
+		v.check(c.Database.Port > 0 && c.Database.Port <= 65535, "database.port must be between 1 and 65535, got %d", c.Database.Port)
+		v.check(c.Database.DBName != "", "database.dbname is required for mysql")
+	}
+
+	// 缓存与 Redis
+	v.oneOf("cache.driver", c.Cache.GetDriver(), "redis", "memory", "tiered")
+	if c.Cache.GetDriver() != "memory" {
+		v.check(c.Redis.Host != "", "redis.host is required when cache.driver is %s", c.Cache.GetDriver())
+		v.check(c.Redis.Port > 0 && c.Redis.Port <= 65535, "redis.port must be between 1 and 65535, got %d", c.Redis.Port)
+	}
+
+	// JWT
+	v.check(c.JWT.Secret != "", "jwt.secret must not be empty")
+	v.check(c.JWT.Expiration > 0, "jwt.expiration must be positive")
+	if c.Server.IsRelease() && c.JWT.Secret != "" {
+		v.check(c.JWT.Secret != DefaultJWTSecret, "jwt.secret must not use the shipped default in release mode")
+		v.check(len(c.JWT.Secret) >= minReleaseJWTSecretLen,
+			"jwt.secret must be at least %d characters in release mode", minReleaseJWTSecretLen)
+	}
+
+	// 文件上传
+	v.check(c.Upload.MaxSize > 0, "upload.maxSize must be positive")
+	v.check(c.Upload.UploadPath != "", "upload.uploadPath must not be empty")
+	for uploadType := range c.Upload.ContentTypes {
+		v.oneOf("upload.contentTypes key", uploadType, UploadTypes...)
+	}
+	v.check(c.Upload.Resumable.ChunkSizeMB >= 0 && c.Upload.Resumable.MaxSizeMB >= 0,
+		"upload.resumable.chunkSizeMB and upload.resumable.maxSizeMB must not be negative")
+	v.check(c.Upload.Resumable.GetChunkSizeBytes() <= int64(c.Upload.MaxSize)*1024*1024,
+		"upload.resumable.chunkSizeMB must not exceed upload.maxSize")
+	v.check(c.Upload.Resumable.SessionTTL >= 0 && c.Upload.Resumable.CleanupInterval >= 0,
+		"upload.resumable.sessionTTL and upload.resumable.cleanupInterval must not be negative")
+	v.check(c.Upload.GC.RetentionDays >= 0 && c.Upload.GC.Interval >= 0,
+		"upload.gc.retentionDays and upload.gc.interval must not be negative")
+	for uploadType, variants := range c.Upload.Image.Variants {
+		v.oneOf("upload.image.variants key", uploadType, ImageUploadTypes...)
+		names := make(map[string]bool, len(variants))
+		for _, variant := range variants {
+			v.check(variantNamePattern.MatchString(variant.Name),
+				"upload.image.variants.%s name must be lowercase letters or digits, got %q", uploadType, variant.Name)
+			v.check(!names[variant.Name], "upload.image.variants.%s name %q is duplicated", uploadType, variant.Name)
+			v.check(variant.Width > 0 && variant.Width <= maxVariantSize && variant.Height > 0 && variant.Height <= maxVariantSize,
+				"upload.image.variants.%s.%s size must be between 1 and %d", uploadType, variant.Name, maxVariantSize)
+			names[variant.Name] = true
+		}
+	}
+	v.check(c.Upload.Image.Quality >= 0 && c.Upload.Image.Quality <= 100, "upload.image.quality must be between 0 and 100")
+	v.check(c.Upload.Image.Workers >= 0 && c.Upload.Image.MaxPixels >= 0,
+		"upload.image.workers and upload.image.maxPixels must not be negative")
+	for _, codecs := range [][]string{c.Upload.Video.VideoCodecs, c.Upload.Video.AudioCodecs} {
+		for _, codec := range codecs {
+			v.check(len(codec) == 4, "upload.video codecs must be four-character codes, got %q", codec)
+		}
+	}
+
+	// 对象存储
+	v.oneOf("storage.driver", c.Storage.GetDriver(), "local", "s3")
+	if c.Storage.PublicURL != "" {
+		v.check(isHTTPURL(c.Storage.PublicURL), "storage.publicURL must be an absolute http(s) URL, got %q", c.Storage.PublicURL)
+	}
+	v.check(c.Storage.PresignExpiry >= 0 && c.Storage.PresignExpiry <= maxPresignExpiry,
+		"storage.presignExpiry must be between 0 and %d seconds", int(maxPresignExpiry.Seconds()))
+	v.check(c.Transcode.Workers >= 0 && c.Transcode.MaxAttempts >= 0 && c.Transcode.SegmentSeconds >= 0,
+		"transcode.workers, transcode.maxAttempts and transcode.segmentSeconds must not be negative")
+	v.check(c.Transcode.PollInterval >= 0 && c.Transcode.JobTimeout >= 0,
+		"transcode.pollInterval and transcode.jobTimeout must not be negative")
+	renditions := make(map[string]bool)
+	for _, rendition := range c.Transcode.Renditions {
+		v.check(variantNamePattern.MatchString(rendition.Name),
+			"transcode.renditions name must be lowercase letters or digits, got %q", rendition.Name)
+		v.check(!renditions[rendition.Name], "transcode.renditions name %q is duplicated", rendition.Name)
+		renditions[rendition.Name] = true
+		v.check(rendition.Height > 0 && rendition.Height%2 == 0 && rendition.Height <= maxVariantSize,
+			"transcode.renditions.%s height must be an even number between 2 and %d", rendition.Name, maxVariantSize)
+		v.check(rendition.VideoBitrate > 0 && rendition.AudioBitrate > 0,
+			"transcode.renditions.%s videoBitrate and audioBitrate must be positive", rendition.Name)
+	}
+	v.check(c.Playback.TTL >= 0 && c.Playback.PreviewTTL >= 0, "playback.ttl and playback.previewTTL must not be negative")
+	if c.Playback.Signed {
+		v.check(len(c.Playback.Keys) > 0, "playback.keys must not be empty when playback.signed is enabled")
+		validateSigningKeys(v, c.Playback.Keys)
+	}
+	validateMasterKeys(v, c.Playback.Encryption.MasterKeys)
+	v.check(c.Publish.Interval >= 0 && c.Publish.Webhook.Timeout >= 0, "publish.interval and publish.webhook.timeout must not be negative")
+	_, err := time.LoadLocation(c.Publish.GetTimezone())
+	v.check(err == nil, "publish.timezone must be an IANA time zone, got %q", c.Publish.Timezone)
+	if c.Publish.Webhook.URL != "" {
+		v.check(isHTTPURL(c.Publish.Webhook.URL), "publish.webhook.url must be an absolute http(s) URL, got %q", c.Publish.Webhook.URL)
+	}
+	v.check(c.Trash.RetentionDays >= 0 && c.Trash.Interval >= 0, "trash.retentionDays and trash.interval must not be negative")
+	if c.Storage.GetDriver() == "s3" {
+		v.check(c.Storage.S3.Endpoint != "", "storage.s3.endpoint is required for s3")
+		v.check(c.Storage.S3.Bucket != "", "storage.s3.bucket is required for s3")
+		v.check(c.Storage.S3.AccessKey != "" && c.Storage.S3.SecretKey != "", "storage.s3.accessKey and storage.s3.secretKey are required for s3")
+		v.check(!strings.Contains(c.Storage.S3.Endpoint, "://"), "storage.s3.endpoint must be host[:port] without scheme, got %q", c.Storage.S3.Endpoint)
+	}
+
+	// 日志
+	v.oneOf("logging.level", strings.ToLower(c.Logging.Level), "", "debug", "info", "warn", "warning", "error")
+	v.oneOf("logging.format", c.Logging.GetFormat(), "json", "text")
+	v.oneOf("logging.output", c.Logging.GetOutput(), "stdout", "stderr", "file")
+
+	// 监控与链路追踪
+	v.check(strings.HasPrefix(c.Metrics.GetPath(), "/"), "metrics.path must start with /, got %q", c.Metrics.Path)
+	v.check(c.Metrics.Username == "" || c.Metrics.Password != "", "metrics.password is required when metrics.username is set")
+	v.oneOf("tracing.exporter", c.Tracing.GetExporter(), "otlp", "stdout")
+	v.oneOf("tracing.sampler", c.Tracing.GetSampler(), "always_on", "always_off", "ratio")
+	v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sampleRatio must be between 0 and 1, got %v", c.Tracing.SampleRatio)
+
+	// 跨域与限流
+	for _, origin := range c.CORS.AllowOrigins {
+		v.check(strings.TrimSpace(origin) != "", "cors.allowOrigins must not contain empty entries")
+		v.check(!(origin == "*" && c.CORS.AllowCredentials), "cors.allowCredentials cannot be used with allowOrigins \"*\"")
+	}
+	v.check(c.RateLimit.MaxRequests >= 0, "rateLimit.maxRequests must not be negative")
+	v.check(c.RateLimit.Window >= 0, "rateLimit.window must not be negative")
+
+	// 安全策略
+	for _, entry := range c.Server.AllowedIPs {
+		v.check(validIPEntry(entry), "server.allowedIPs contains invalid IP or CIDR %q", entry)
+	}
+	for _, entry := range c.Server.TrustedProxies {
+		v.check(entry != "*" && validIPEntry(entry), "server.trustedProxies contains invalid IP or CIDR %q", entry)
+	}
+	for _, entry := range c.Metrics.AllowedIPs {
+		v.check(validIPEntry(entry), "metrics.allowedIPs contains invalid IP or CIDR %q", entry)
+	}
+	v.oneOf("security.frameOptions", c.Security.GetFrameOptions(), "DENY", "SAMEORIGIN")
+	v.check(c.Security.HSTSMaxAge >= 0, "security.hstsMaxAge must not be negative")
+	v.check(c.Security.MaxBodySizeMB >= 0, "security.maxBodySizeMB must not be negative")
+	v.check(c.Security.RequestTimeout >= 0, "security.requestTimeout must not be negative")
+
+	return v.err()
+}
+
+// isHTTPURL 是否为绝对的 http(s) 地址
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// validIPEntry 是否为合法的 IP、CIDR 或通配符 *
+func validIPEntry(entry string) bool {
+	entry = strings.TrimSpace(entry)
+	if entry == "*" {
+		return true
+	}
+	if strings.Contains(entry, "/") {
+		_, _, err := net.ParseCIDR(entry)
+		return err == nil
+	}
+	return net.ParseIP(entry) != nil
+}
+
+// validator 收集校验问题
+type validator struct {
+	problems []string
+}
+
+// validateMasterKeys 校验加密内容密钥的主密钥
+func validateMasterKeys(v *validator, keys []MasterKeyConfig) {
+	ids := make(map[string]bool)
+	for _, key := range keys {
+		v.check(signingKeyIDPattern.MatchString(key.ID),
+			"playback.encryption.masterKeys id must be 1-32 letters, digits, '_' or '-', got %q", key.ID)
+		v.check(!ids[key.ID], "playback.encryption.masterKeys id %q is duplicated", key.ID)
+		ids[key.ID] = true
+		v.check(len(key.Secret) >= minSigningSecretLen,
+			"playback.encryption.masterKeys.%s secret must be at least %d characters", key.ID, minSigningSecretLen)
+	}
+}
+
+// validateSigningKeys 校验签名密钥，至少需要一个未失效的密钥用于签名
+func validateSigningKeys(v *validator, keys []SigningKeyConfig) {
+	ids := make(map[string]bool)
+	active := false
+	for _, key := range keys {
+		v.check(signingKeyIDPattern.MatchString(key.ID),
+			"playback.keys id must be 1-32 letters, digits, '_' or '-', got %q", key.ID)
+		v.check(!ids[key.ID], "playback.keys id %q is duplicated", key.ID)
+		ids[key.ID] = true
+		v.check(len(key.Secret) >= minSigningSecretLen,
+			"playback.keys.%s secret must be at least %d characters", key.ID, minSigningSecretLen)
+		if key.ExpiresAt == "" {
+			active = true
+			continue
+		}
+		expiresAt, err := time.Parse(time.RFC3339, key.ExpiresAt)
+		v.check(err == nil, "playback.keys.%s expiresAt must be an RFC 3339 time, got %q", key.ID, key.ExpiresAt)
+		active = active || (err == nil && expiresAt.After(time.Now()))
+	}
+	v.check(len(keys) == 0 || active, "playback.keys must contain a key that has not expired")
+}
+
+// check 条件不满足时记录问题
+func (v *validator) check(ok bool, format string, args ...interface{}) {
+	if !ok {
+		v.problems = append(v.problems, fmt.Sprintf(format, args...))
+	}
+}
+
+// oneOf 校验取值是否在允许范围内
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	names := make([]string, 0, len(allowed))
+	for _, a := range allowed {
+		if a != "" {
+			names = append(names, a)
+		}
+	}
+	v.problems = append(v.problems, fmt.Sprintf("%s must be one of %s, got %q", key, strings.Join(names, ", "), value))
+}
+
+// err 有问题时返回 ValidationError
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
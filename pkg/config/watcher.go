@@ -0,0 +1,127 @@
+package config
+
+import (
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// defaultReloadDebounce 合并短时间内的多次文件事件（编辑器保存通常会触发多次写入）
+const defaultReloadDebounce = 200 * time.Millisecond
+
+// Watcher 监听配置文件与 profile 文件的变化，重新加载并校验后通知订阅者
+// 新配置校验失败时继续使用当前配置；订阅者只应应用支持热更新的配置项（限流、CORS 来源、日志级别）
+type Watcher struct {
+	configFile string
+	profile    string
+	debounce   time.Duration
+	fsWatcher  *fsnotify.Watcher
+	done       chan struct{}
+
+	mu       sync.RWMutex
+	current  *Config
+	handlers []func(*Config)
+}
+
+// NewWatcher 创建配置监听器，cfg 为当前生效的配置
+func NewWatcher(configFile string, cfg *Config) (*Watcher, error) {
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	// 监听所在目录而不是文件本身：编辑器和 Kubernetes ConfigMap 通过重命名替换文件，直接监听文件会丢失后续事件
+	if err := fsWatcher.Add(filepath.Dir(configFile)); err != nil {
+		fsWatcher.Close()
+		return nil, err
+	}
+
+	w := &Watcher{
+		configFile: filepath.Clean(configFile),
+		profile:    cfg.Profile,
+		debounce:   defaultReloadDebounce,
+		fsWatcher:  fsWatcher,
+		done:       make(chan struct{}),
+		current:    cfg,
+	}
+	go w.run()
+	return w, nil
+}
+
+// OnChange 注册配置变更回调，回调在监听协程中按注册顺序执行
+func (w *Watcher) OnChange(fn func(*Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers = append(w.handlers, fn)
+}
+
+// Config 获取当前生效的配置
+func (w *Watcher) Config() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Close 停止监听
+func (w *Watcher) Close() error {
+	err := w.fsWatcher.Close()
+	<-w.done
+	return err
+}
+
+// run 处理文件事件，防抖后重新加载
+func (w *Watcher) run() {
+	defer close(w.done)
+
+	var reload <-chan time.Time
+	for {
+		select {
+		case event, ok := <-w.fsWatcher.Events:
+			if !ok {
+				return
+			}
+			if w.isConfigFile(event.Name) && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				reload = time.After(w.debounce)
+			}
+		case err, ok := <-w.fsWatcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Warn("配置文件监听出错", slog.String("error", err.Error()))
+		case <-reload:
+			reload = nil
+			w.reload()
+		}
+	}
+}
+
+// isConfigFile 是否为基础配置文件或当前 profile 的配置文件
+func (w *Watcher) isConfigFile(name string) bool {
+	name = filepath.Clean(name)
+	if name == w.configFile {
+		return true
+	}
+	return w.profile != "" && name == ProfileFile(w.configFile, w.profile)
+}
+
+// reload 重新加载配置并通知订阅者
+func (w *Watcher) reload() {
+	cfg, err := load(w.configFile, w.profile)
+	if err != nil {
+		slog.Error("配置重新加载失败，继续使用当前配置", slog.String("file", w.configFile), slog.String("error", err.Error()))
+		return
+	}
+
+	w.mu.Lock()
+	w.current = cfg
+	handlers := append([]func(*Config){}, w.handlers...)
+	w.mu.Unlock()
+
+	slog.Info("配置已重新加载", slog.String("file", w.configFile), slog.String("profile", w.profile))
+	for _, fn := range handlers {
+		fn(cfg)
+	}
+}
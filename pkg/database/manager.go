@@ -1,10 +1,12 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/health"
 )
 
 // Manager 数据库管理器
@@ -21,23 +23,23 @@ func NewManager(cfg *config.Config) *Manager {
 
 // Initialize 初始化所有数据库连接
 func (m *Manager) Initialize() error {
-	// 初始化 MySQL 连接
-	if err := InitMySQL(&m.config.Database); err != nil {
-		return fmt.Errorf("failed to initialize MySQL: %w", err)
+	// 初始化数据库连接
+	if err := InitDB(&m.config.Database); err != nil {
+		return fmt.Errorf("failed to initialize %s: %w", m.config.Database.GetDriver(), err)
 	}
-	log.Println("MySQL 数据库连接成功")
+	slog.Info("数据库连接成功", slog.String("driver", m.config.Database.GetDriver()))
 
 	// 初始化 Redis 连接
 	if err := InitRedis(&m.config.Redis); err != nil {
 		return fmt.Errorf("failed to initialize Redis: %w", err)
 	}
-	log.Println("Redis 连接成功")
+	slog.Info("Redis 连接成功")
 
 	// 初始化数据库表结构和数据
 	if err := InitDatabase(DB); err != nil {
 		return fmt.Errorf("failed to initialize database schema: %w", err)
 	}
-	log.Println("数据库初始化完成")
+	slog.Info("数据库初始化完成")
 
 	return nil
 }
@@ -60,34 +62,17 @@ func (m *Manager) Close() error {
 		return fmt.Errorf("errors occurred while closing databases: %v", errors)
 	}
 
-	log.Println("所有数据库连接已关闭")
+	slog.Info("所有数据库连接已关闭")
 	return nil
 }
 
 // HealthCheck 检查数据库连接健康状态
 func (m *Manager) HealthCheck() error {
-	// 检查 MySQL 连接
-	if DB == nil {
-		return fmt.Errorf("MySQL connection is nil")
-	}
-
-	sqlDB, err := DB.DB()
-	if err != nil {
-		return fmt.Errorf("failed to get MySQL connection: %w", err)
-	}
-
-	if err := sqlDB.Ping(); err != nil {
-		return fmt.Errorf("MySQL ping failed: %w", err)
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.Health.GetTimeout())
+	defer cancel()
 
-	// 检查 Redis 连接
-	if RedisClient == nil {
-		return fmt.Errorf("Redis connection is nil")
+	if err := health.DatabaseCheck(DB)(ctx); err != nil {
+		return err
 	}
-
-	if err := RedisClient.Ping(RedisClient.Context()).Err(); err != nil {
-		return fmt.Errorf("Redis ping failed: %w", err)
-	}
-
-	return nil
-}
\ No newline at end of file
+	return health.RedisCheck(RedisClient)(ctx)
+}
@@ -16,6 +16,14 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.Admin{},
 		&models.Drama{},
 		&models.Episode{},
+		&models.UploadSession{},
+		&models.UploadPart{},
+		&models.MediaAsset{},
+		&models.TranscodeJob{},
+		&models.EpisodeRendition{},
+		&models.EpisodeKey{},
+		&models.StatusTransition{},
+		&models.ContentRevision{},
 	}
 
 	// 执行自动迁移
@@ -30,35 +38,33 @@ func AutoMigrate(db *gorm.DB) error {
 
 // CreateIndexes 创建数据库索引
 func CreateIndexes(db *gorm.DB) error {
-	// 用户表索引
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_users_username ON users(username)").Error; err != nil {
-		return fmt.Errorf("failed to create username index: %w", err)
-	}
-
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_users_email ON users(email)").Error; err != nil {
-		return fmt.Errorf("failed to create email index: %w", err)
-	}
-
-	// 短剧表索引
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_dramas_title ON dramas(title)").Error; err != nil {
-		return fmt.Errorf("failed to create drama title index: %w", err)
-	}
-
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_dramas_category ON dramas(category)").Error; err != nil {
-		return fmt.Errorf("failed to create drama category index: %w", err)
-	}
-
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_dramas_status ON dramas(status)").Error; err != nil {
-		return fmt.Errorf("failed to create drama status index: %w", err)
-	}
-
-	// 剧集表索引
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_episodes_drama_id ON episodes(drama_id)").Error; err != nil {
-		return fmt.Errorf("failed to create episode drama_id index: %w", err)
-	}
-
-	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_episodes_episode_number ON episodes(episode_number)").Error; err != nil {
-		return fmt.Errorf("failed to create episode number index: %w", err)
+	indexes := []struct {
+		model   interface{}
+		table   string
+		name    string
+		columns string
+	}{
+		// 用户表索引
+		{&models.User{}, "users", "idx_users_username", "username"},
+		{&models.User{}, "users", "idx_users_email", "email"},
+		// 短剧表索引
+		{&models.Drama{}, "dramas", "idx_dramas_title", "title"},
+		{&models.Drama{}, "dramas", "idx_dramas_category", "category"},
+		{&models.Drama{}, "dramas", "idx_dramas_status", "status"},
+		// 剧集表索引
+		{&models.Episode{}, "episodes", "idx_episodes_drama_id", "drama_id"},
+		{&models.Episode{}, "episodes", "idx_episodes_episode_number", "episode_num"},
+	}
+
+	// CREATE INDEX IF NOT EXISTS 不被 MySQL 支持，先通过 Migrator 检查索引是否存在
+	for _, idx := range indexes {
+		if db.Migrator().HasIndex(idx.model, idx.name) {
+			continue
+		}
+		sql := fmt.Sprintf("CREATE INDEX %s ON %s(%s)", idx.name, idx.table, idx.columns)
+		if err := db.Exec(sql).Error; err != nil {
+			return fmt.Errorf("failed to create index %s: %w", idx.name, err)
+		}
 	}
 
 	return nil
@@ -99,7 +105,7 @@ func SeedData(db *gorm.DB) error {
 			Description: "这是一个示例短剧，用于测试系统功能",
 			Category:    "爱情",
 			CoverImage:  "/static/images/sample-cover.jpg",
-			Status:      "active",
+			Status:      "published",
 			ViewCount:   0,
 		}
 
@@ -114,6 +120,7 @@ func SeedData(db *gorm.DB) error {
 			EpisodeNum: 1,
 			VideoURL:   "/static/videos/sample-episode.mp4",
 			Duration:   300, // 5分钟
+			Status:     "published",
 			ViewCount:  0,
 		}
 
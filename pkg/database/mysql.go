@@ -5,9 +5,11 @@ import (
 	"time"
 
 	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/logger"
+	"gin-mysql-api/pkg/metrics"
+	"gin-mysql-api/pkg/tracing"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 // DB 全局数据库实例
@@ -15,7 +17,36 @@ var DB *gorm.DB
 
 // NewConnection 创建新的数据库连接
 func NewConnection(cfg *config.Config) (*gorm.DB, error) {
-	return InitMySQLWithConfig(&cfg.Database)
+	return InitWithConfig(&cfg.Database)
+}
+
+// InitWithConfig 根据配置的驱动初始化数据库连接
+func InitWithConfig(cfg *config.DatabaseConfig) (*gorm.DB, error) {
+	var (
+		db  *gorm.DB
+		err error
+	)
+	switch cfg.GetDriver() {
+	case DriverMySQL:
+		db, err = InitMySQLWithConfig(cfg)
+	case DriverSQLite:
+		db, err = InitSQLiteWithConfig(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", cfg.Driver)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 注册查询耗时指标与链路追踪
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register metrics plugin: %w", err)
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
+	return db, nil
 }
 
 // InitMySQLWithConfig 使用配置初始化 MySQL 数据库连接
@@ -25,7 +56,7 @@ func InitMySQLWithConfig(cfg *config.DatabaseConfig) (*gorm.DB, error) {
 	
 	// 配置 GORM
 	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: logger.NewGormLogger(nil, cfg.GetSlowThreshold()),
 		NowFunc: func() time.Time {
 			return time.Now().Local()
 		},
@@ -63,7 +94,7 @@ func InitMySQL(cfg *config.DatabaseConfig) error {
 	
 	// 配置 GORM
 	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: logger.NewGormLogger(nil, cfg.GetSlowThreshold()),
 		NowFunc: func() time.Time {
 			return time.Now().Local()
 		},
@@ -95,6 +126,17 @@ func InitMySQL(cfg *config.DatabaseConfig) error {
 	return nil
 }
 
+// InitDB 根据配置的驱动初始化全局数据库连接
+func InitDB(cfg *config.DatabaseConfig) error {
+	db, err := InitWithConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	DB = db
+	return nil
+}
+
 // GetDB 获取数据库实例
 func GetDB() *gorm.DB {
 	return DB
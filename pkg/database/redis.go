@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/metrics"
+	"gin-mysql-api/pkg/tracing"
 	"github.com/go-redis/redis/v8"
 )
 
@@ -17,15 +19,23 @@ func NewRedisConnection(cfg *config.Config) (*redis.Client, error) {
 	return InitRedisWithConfig(&cfg.Redis)
 }
 
-// InitRedisWithConfig 使用配置初始化 Redis 连接
-func InitRedisWithConfig(cfg *config.RedisConfig) (*redis.Client, error) {
-	// 创建 Redis 客户端
-	rdb := redis.NewClient(&redis.Options{
+// NewRedisClient 创建 Redis 客户端（不检测连接，首次使用时才会建立连接）
+func NewRedisClient(cfg *config.RedisConfig) *redis.Client {
+	client := redis.NewClient(&redis.Options{
 		Addr:     cfg.GetRedisAddr(),
 		Password: cfg.Password,
 		DB:       cfg.DB,
 		PoolSize: cfg.PoolSize,
 	})
+	client.AddHook(metrics.NewRedisHook())
+	client.AddHook(tracing.NewRedisHook())
+	return client
+}
+
+// InitRedisWithConfig 使用配置初始化 Redis 连接
+func InitRedisWithConfig(cfg *config.RedisConfig) (*redis.Client, error) {
+	// 创建 Redis 客户端
+	rdb := NewRedisClient(cfg)
 
 	// 测试连接
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/logger"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// 支持的数据库驱动
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+)
+
+// NewDialector 根据配置的驱动创建 GORM 方言
+func NewDialector(cfg *config.DatabaseConfig) (gorm.Dialector, error) {
+	switch cfg.GetDriver() {
+	case DriverMySQL:
+		return mysql.Open(cfg.GetDSN()), nil
+	case DriverSQLite:
+		return sqlite.Open(cfg.GetSQLiteDSN()), nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", cfg.Driver)
+	}
+}
+
+// InitSQLiteWithConfig 使用配置初始化 SQLite 数据库连接
+func InitSQLiteWithConfig(cfg *config.DatabaseConfig) (*gorm.DB, error) {
+	// 确保数据库文件所在目录存在
+	if !cfg.IsInMemory() {
+		if err := os.MkdirAll(filepath.Dir(cfg.Path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create sqlite directory: %w", err)
+		}
+	}
+
+	gormConfig := &gorm.Config{
+		Logger: logger.NewGormLogger(nil, cfg.GetSlowThreshold()),
+		NowFunc: func() time.Time {
+			return time.Now().Local()
+		},
+	}
+
+	db, err := gorm.Open(sqlite.Open(cfg.GetSQLiteDSN()), gormConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+
+	ConfigureSQLitePool(sqlDB, cfg)
+
+	if err := sqlDB.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return db, nil
+}
+
+// ConfigureSQLitePool 配置 SQLite 连接池
+// 内存数据库的每个连接都是独立的库，因此必须固定为单连接且不过期
+func ConfigureSQLitePool(sqlDB *sql.DB, cfg *config.DatabaseConfig) {
+	if cfg.IsInMemory() {
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		return
+	}
+
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+}
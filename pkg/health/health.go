@@ -0,0 +1,195 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// 探测状态
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// 整体就绪状态
+const (
+	StatusReady    = "ready"
+	StatusDegraded = "degraded"
+	StatusNotReady = "not_ready"
+)
+
+// CheckFunc 探测函数，返回 nil 表示依赖可用
+type CheckFunc func(ctx context.Context) error
+
+// Probe 依赖探测
+// Critical 为 true 的探测失败时服务不可就绪，否则只标记为降级
+type Probe struct {
+	Name     string
+	Critical bool
+	Timeout  time.Duration
+	Check    CheckFunc
+}
+
+// Result 单个探测结果
+type Result struct {
+	Status     string `json:"status"`
+	Critical   bool   `json:"critical"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// Report 探测报告
+type Report struct {
+	Status    string            `json:"status"`
+	Checks    map[string]Result `json:"checks"`
+	CheckedAt time.Time         `json:"checkedAt"`
+}
+
+// Ready 关键依赖是否全部可用
+func (r Report) Ready() bool {
+	return r.Status != StatusNotReady
+}
+
+// Registry 健康检查注册表
+// 探测结果会缓存 cacheTTL，并发请求共享同一次探测，避免健康检查把数据库打垮
+type Registry struct {
+	mu       sync.Mutex
+	probes   []Probe
+	cacheTTL time.Duration
+	timeout  time.Duration
+	cached   *Report
+	group    singleflight.Group
+	now      func() time.Time
+}
+
+// NewRegistry 创建健康检查注册表，timeout 为未指定超时的探测的默认超时
+func NewRegistry(cacheTTL, timeout time.Duration) *Registry {
+	return &Registry{
+		cacheTTL: cacheTTL,
+		timeout:  timeout,
+		now:      time.Now,
+	}
+}
+
+// Register 注册探测，同名探测会被替换
+func (r *Registry) Register(probe Probe) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.probes {
+		if existing.Name == probe.Name {
+			r.probes[i] = probe
+			r.cached = nil
+			return
+		}
+	}
+	r.probes = append(r.probes, probe)
+	r.cached = nil
+}
+
+// Check 执行所有探测，缓存未过期时直接返回缓存结果
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mu.Lock()
+	if r.cached != nil && r.now().Sub(r.cached.CheckedAt) < r.cacheTTL {
+		report := *r.cached
+		r.mu.Unlock()
+		return report
+	}
+	r.mu.Unlock()
+
+	// 探测与发起请求的客户端解耦，客户端断开不影响共享同一次探测的其他请求
+	value, _, _ := r.group.Do("check", func() (interface{}, error) {
+		report := r.run(context.WithoutCancel(ctx))
+
+		r.mu.Lock()
+		r.cached = &report
+		r.mu.Unlock()
+		return report, nil
+	})
+	return value.(Report)
+}
+
+// run 并发执行所有探测
+func (r *Registry) run(ctx context.Context) Report {
+	r.mu.Lock()
+	probes := make([]Probe, len(r.probes))
+	copy(probes, r.probes)
+	r.mu.Unlock()
+
+	results := make([]Result, len(probes))
+	var wg sync.WaitGroup
+	for i, probe := range probes {
+		wg.Add(1)
+		go func(i int, probe Probe) {
+			defer wg.Done()
+			results[i] = r.runProbe(ctx, probe)
+		}(i, probe)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:    StatusReady,
+		Checks:    make(map[string]Result, len(probes)),
+		CheckedAt: r.now(),
+	}
+	for i, probe := range probes {
+		result := results[i]
+		report.Checks[probe.Name] = result
+		if result.Status == StatusOK {
+			continue
+		}
+		if probe.Critical {
+			report.Status = StatusNotReady
+		} else if report.Status == StatusReady {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// runProbe 在超时限制内执行单个探测
+func (r *Registry) runProbe(ctx context.Context, probe Probe) Result {
+	timeout := probe.Timeout
+	if timeout <= 0 {
+		timeout = r.timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errCh <- fmt.Errorf("探测异常: %v", recovered)
+			}
+		}()
+		errCh <- probe.Check(ctx)
+	}()
+
+	// 探测函数不响应 ctx 时也要按时返回
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = fmt.Errorf("探测超时: %w", ctx.Err())
+	}
+
+	result := Result{
+		Status:     StatusOK,
+		Critical:   probe.Critical,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,166 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/storage"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func okCheck(context.Context) error { return nil }
+
+func failCheck(context.Context) error { return errors.New("connection refused") }
+
+func TestRegistryCheck(t *testing.T) {
+	t.Run("全部通过时就绪", func(t *testing.T) {
+		registry := NewRegistry(0, time.Second)
+		registry.Register(Probe{Name: "database", Critical: true, Check: okCheck})
+		registry.Register(Probe{Name: "redis", Check: okCheck})
+
+		report := registry.Check(context.Background())
+		assert.Equal(t, StatusReady, report.Status)
+		assert.True(t, report.Ready())
+		assert.Equal(t, StatusOK, report.Checks["database"].Status)
+		assert.Equal(t, StatusOK, report.Checks["redis"].Status)
+	})
+
+	t.Run("非关键依赖失败时降级", func(t *testing.T) {
+		registry := NewRegistry(0, time.Second)
+		registry.Register(Probe{Name: "database", Critical: true, Check: okCheck})
+		registry.Register(Probe{Name: "redis", Check: failCheck})
+
+		report := registry.Check(context.Background())
+		assert.Equal(t, StatusDegraded, report.Status)
+		assert.True(t, report.Ready())
+		assert.Equal(t, StatusFail, report.Checks["redis"].Status)
+		assert.Contains(t, report.Checks["redis"].Error, "connection refused")
+	})
+
+	t.Run("关键依赖失败时未就绪", func(t *testing.T) {
+		registry := NewRegistry(0, time.Second)
+		registry.Register(Probe{Name: "database", Critical: true, Check: failCheck})
+		registry.Register(Probe{Name: "redis", Check: failCheck})
+
+		report := registry.Check(context.Background())
+		assert.Equal(t, StatusNotReady, report.Status)
+		assert.False(t, report.Ready())
+	})
+
+	t.Run("探测超时", func(t *testing.T) {
+		registry := NewRegistry(0, time.Second)
+		registry.Register(Probe{
+			Name:     "slow",
+			Critical: true,
+			Timeout:  20 * time.Millisecond,
+			Check: func(context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			},
+		})
+
+		start := time.Now()
+		report := registry.Check(context.Background())
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		assert.Equal(t, StatusFail, report.Checks["slow"].Status)
+		assert.Contains(t, report.Checks["slow"].Error, "探测超时")
+	})
+
+	t.Run("探测 panic 视为失败", func(t *testing.T) {
+		registry := NewRegistry(0, time.Second)
+		registry.Register(Probe{Name: "broken", Check: func(context.Context) error { panic("boom") }})
+
+		report := registry.Check(context.Background())
+		assert.Equal(t, StatusFail, report.Checks["broken"].Status)
+	})
+}
+
+func TestRegistryCache(t *testing.T) {
+	var calls int32
+	registry := NewRegistry(time.Minute, time.Second)
+	registry.Register(Probe{
+		Name:     "database",
+		Critical: true,
+		Check: func(context.Context) error {
+			atomic.AddInt32(&calls, 1)
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		},
+	})
+
+	t.Run("并发请求共享同一次探测", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				registry.Check(context.Background())
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("缓存期内不重复探测", func(t *testing.T) {
+		registry.Check(context.Background())
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("缓存过期后重新探测", func(t *testing.T) {
+		registry.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+		registry.Check(context.Background())
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestProbes(t *testing.T) {
+	t.Run("数据库探测", func(t *testing.T) {
+		db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		require.NoError(t, err)
+		assert.NoError(t, DatabaseCheck(db)(context.Background()))
+
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+		assert.Error(t, DatabaseCheck(db)(context.Background()))
+		assert.Error(t, DatabaseCheck(nil)(context.Background()))
+	})
+
+	t.Run("Redis 未初始化", func(t *testing.T) {
+		assert.Error(t, RedisCheck(nil)(context.Background()))
+	})
+
+	t.Run("目录可写", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, WritableDirCheck(dir)(context.Background()))
+	})
+
+	t.Run("磁盘空间", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, DiskSpaceCheck(dir, 1)(context.Background()))
+
+		err := DiskSpaceCheck(dir, ^uint64(0))(context.Background())
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "磁盘剩余空间不足")
+	})
+
+	t.Run("对象存储探测", func(t *testing.T) {
+		assert.NoError(t, StorageCheck(storage.NewLocal(t.TempDir()))(context.Background()), "探测对象不存在也视为可访问")
+		assert.Error(t, StorageCheck(nil)(context.Background()))
+
+		unreachable, err := storage.NewS3(&config.S3Config{Endpoint: "127.0.0.1:1", Bucket: "media", AccessKey: "a", SecretKey: "b"})
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		assert.Error(t, StorageCheck(unreachable)(ctx))
+	})
+}
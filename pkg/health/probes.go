@@ -0,0 +1,89 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"gin-mysql-api/pkg/storage"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// DatabaseCheck 通过 Ping 检查数据库连接
+func DatabaseCheck(db *gorm.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		if db == nil {
+			return fmt.Errorf("数据库连接未初始化")
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return fmt.Errorf("获取数据库连接失败: %w", err)
+		}
+		if err := sqlDB.PingContext(ctx); err != nil {
+			return fmt.Errorf("数据库 Ping 失败: %w", err)
+		}
+		return nil
+	}
+}
+
+// RedisCheck 通过 PING 命令检查 Redis 连接
+func RedisCheck(client *redis.Client) CheckFunc {
+	return func(ctx context.Context) error {
+		if client == nil {
+			return fmt.Errorf("Redis 连接未初始化")
+		}
+		if err := client.Ping(ctx).Err(); err != nil {
+			return fmt.Errorf("Redis Ping 失败: %w", err)
+		}
+		return nil
+	}
+}
+
+// WritableDirCheck 通过写入临时文件检查目录是否可写
+func WritableDirCheck(dir string) CheckFunc {
+	return func(ctx context.Context) error {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建目录失败: %w", err)
+		}
+		file, err := os.CreateTemp(dir, ".health-*")
+		if err != nil {
+			return fmt.Errorf("目录不可写: %w", err)
+		}
+		name := file.Name()
+		file.Close()
+		return os.Remove(name)
+	}
+}
+
+// DiskSpaceCheck 检查目录所在磁盘的剩余空间不低于 minFreeBytes
+func DiskSpaceCheck(dir string, minFreeBytes uint64) CheckFunc {
+	return func(ctx context.Context) error {
+		free, err := freeDiskSpace(dir)
+		if err != nil {
+			return fmt.Errorf("获取磁盘空间失败: %w", err)
+		}
+		if free < minFreeBytes {
+			return fmt.Errorf("磁盘剩余空间不足: 剩余 %dMB，至少需要 %dMB", free/1024/1024, minFreeBytes/1024/1024)
+		}
+		return nil
+	}
+}
+
+// storageProbeKey 存储探测使用的对象键，对象不存在也说明存储可以访问
+const storageProbeKey = ".health"
+
+// StorageCheck 通过获取对象元数据检查对象存储是否可以访问
+func StorageCheck(store storage.Storage) CheckFunc {
+	return func(ctx context.Context) error {
+		if store == nil {
+			return fmt.Errorf("对象存储未初始化")
+		}
+		if _, err := store.Stat(ctx, storageProbeKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("对象存储不可访问: %w", err)
+		}
+		return nil
+	}
+}
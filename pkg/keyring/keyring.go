@@ -0,0 +1,102 @@
+// Package keyring 使用主密钥加密保存内容密钥（信封加密）
+// 主密钥由配置提供，派生为 AES-256-GCM 密钥；密文记录加密所用的主密钥标识，轮换主密钥后可以重新加密
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"gin-mysql-api/pkg/config"
+)
+
+var (
+	// ErrNoKey 没有配置主密钥
+	ErrNoKey = errors.New("keyring: no master key configured")
+	// ErrUnknownKey 密文使用的主密钥不在配置中
+	ErrUnknownKey = errors.New("keyring: unknown master key")
+	// ErrDecrypt 密文损坏或主密钥不匹配
+	ErrDecrypt = errors.New("keyring: decryption failed")
+)
+
+// MasterKey 主密钥
+type MasterKey struct {
+	ID     string
+	Secret []byte
+}
+
+// MasterKeys 根据加密配置生成主密钥
+func MasterKeys(cfg *config.EncryptionConfig) []MasterKey {
+	keys := make([]MasterKey, 0, len(cfg.MasterKeys))
+	for _, key := range cfg.MasterKeys {
+		keys = append(keys, MasterKey{ID: key.ID, Secret: []byte(key.Secret)})
+	}
+	return keys
+}
+
+// Keyring 主密钥集合，第一个主密钥用于加密，所有主密钥都可以解密
+type Keyring struct {
+	ids   []string
+	aeads map[string]cipher.AEAD
+}
+
+// New 创建主密钥集合，keys 为空时返回 ErrNoKey
+func New(keys []MasterKey) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKey
+	}
+	k := &Keyring{aeads: make(map[string]cipher.AEAD, len(keys))}
+	for _, key := range keys {
+		sum := sha256.Sum256(key.Secret)
+		block, err := aes.NewCipher(sum[:])
+		if err != nil {
+			return nil, fmt.Errorf("keyring: %w", err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: %w", err)
+		}
+		k.ids = append(k.ids, key.ID)
+		k.aeads[key.ID] = aead
+	}
+	return k, nil
+}
+
+// Primary 用于加密的主密钥标识
+func (k *Keyring) Primary() string {
+	return k.ids[0]
+}
+
+// Seal 使用第一个主密钥加密，返回主密钥标识与 base64 编码的密文，主密钥标识参与认证
+func (k *Keyring) Seal(plaintext []byte) (keyID, sealed string, err error) {
+	keyID = k.Primary()
+	aead := k.aeads[keyID]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", fmt.Errorf("keyring: %w", err)
+	}
+	out := aead.Seal(nonce, nonce, plaintext, []byte(keyID))
+	return keyID, base64.StdEncoding.EncodeToString(out), nil
+}
+
+// Open 使用 keyID 对应的主密钥解密
+func (k *Keyring) Open(keyID, sealed string) ([]byte, error) {
+	aead, ok := k.aeads[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(keyID))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
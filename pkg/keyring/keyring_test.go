@@ -0,0 +1,72 @@
+package keyring
+
+import (
+	"strings"
+	"testing"
+
+	"gin-mysql-api/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyring(t *testing.T) {
+	m1 := MasterKey{ID: "m1", Secret: []byte(strings.Repeat("a", 32))}
+	m2 := MasterKey{ID: "m2", Secret: []byte(strings.Repeat("b", 32))}
+	plaintext := []byte("0123456789abcdef")
+
+	t.Run("加密与解密", func(t *testing.T) {
+		ring, err := New([]MasterKey{m1})
+		require.NoError(t, err)
+		keyID, sealed, err := ring.Seal(plaintext)
+		require.NoError(t, err)
+		assert.Equal(t, "m1", keyID)
+		assert.NotContains(t, sealed, string(plaintext))
+
+		_, again, _ := ring.Seal(plaintext)
+		assert.NotEqual(t, sealed, again, "每次加密使用随机 nonce")
+
+		opened, err := ring.Open(keyID, sealed)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, opened)
+	})
+
+	t.Run("轮换主密钥", func(t *testing.T) {
+		old, _ := New([]MasterKey{m1})
+		keyID, sealed, _ := old.Seal(plaintext)
+
+		ring, err := New([]MasterKey{m2, m1})
+		require.NoError(t, err)
+		assert.Equal(t, "m2", ring.Primary())
+		opened, err := ring.Open(keyID, sealed)
+		require.NoError(t, err, "旧主密钥仍可解密")
+		assert.Equal(t, plaintext, opened)
+
+		newID, _, _ := ring.Seal(plaintext)
+		assert.Equal(t, "m2", newID)
+
+		withoutOld, _ := New([]MasterKey{m2})
+		_, err = withoutOld.Open(keyID, sealed)
+		assert.ErrorIs(t, err, ErrUnknownKey)
+	})
+
+	t.Run("密文损坏或主密钥不匹配", func(t *testing.T) {
+		ring, _ := New([]MasterKey{m1, m2})
+		keyID, sealed, _ := ring.Seal(plaintext)
+
+		_, err := ring.Open("m2", sealed)
+		assert.ErrorIs(t, err, ErrDecrypt)
+		_, err = ring.Open(keyID, "!"+sealed)
+		assert.ErrorIs(t, err, ErrDecrypt)
+		_, err = ring.Open(keyID, "")
+		assert.ErrorIs(t, err, ErrDecrypt)
+	})
+
+	t.Run("没有主密钥", func(t *testing.T) {
+		_, err := New(MasterKeys(&config.EncryptionConfig{}))
+		assert.ErrorIs(t, err, ErrNoKey)
+
+		keys := MasterKeys(&config.EncryptionConfig{MasterKeys: []config.MasterKeyConfig{{ID: "m1", Secret: "secret"}}})
+		assert.Equal(t, []MasterKey{{ID: "m1", Secret: []byte("secret")}}, keys)
+	})
+}
@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"gin-mysql-api/pkg/config"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// 日志格式
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// 日志输出
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+)
+
+// level 由 New 创建的 Logger 共享的日志级别，可通过 SetLevel 在运行时调整
+var level = new(slog.LevelVar)
+
+// New 根据配置创建结构化日志记录器
+// 返回的 Closer 用于在退出时关闭日志文件，标准输出时为空操作
+func New(cfg *config.LoggingConfig) (*slog.Logger, io.Closer, error) {
+	parsed, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		writer io.Writer = os.Stdout
+		closer io.Closer = nopCloser{}
+	)
+	switch cfg.GetOutput() {
+	case OutputStdout:
+	case OutputStderr:
+		writer = os.Stderr
+	case OutputFile:
+		file, err := NewRotatingFile(cfg.GetFilename(), cfg.GetMaxSizeBytes(), cfg.GetMaxBackups())
+		if err != nil {
+			return nil, nil, err
+		}
+		writer, closer = file, file
+	default:
+		return nil, nil, fmt.Errorf("unsupported logging output: %s", cfg.Output)
+	}
+
+	handler, err := newFormatHandler(cfg.GetFormat(), writer, level)
+	if err != nil {
+		closer.Close()
+		return nil, nil, err
+	}
+	level.Set(parsed)
+	return slog.New(NewHandler(handler)), closer, nil
+}
+
+// SetLevel 调整日志级别，配置热更新时调用
+func SetLevel(name string) error {
+	parsed, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(parsed)
+	return nil
+}
+
+// ParseLevel 解析日志级别（debug | info | warn | error，默认为 info）
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unsupported logging level: %s", level)
+	}
+}
+
+// newFormatHandler 根据日志格式创建底层 Handler
+func newFormatHandler(format string, w io.Writer, level slog.Leveler) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case FormatJSON:
+		return slog.NewJSONHandler(w, opts), nil
+	case FormatText:
+		return slog.NewTextHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("unsupported logging format: %s", format)
+	}
+}
+
+// ctxKey 上下文中日志字段的键
+type ctxKey struct{}
+
+// WithAttrs 返回附带日志字段的上下文，之后使用该上下文输出的日志都会带上这些字段
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing := attrsFromContext(ctx)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	merged = append(merged, existing...)
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, ctxKey{}, merged)
+}
+
+// attrsFromContext 获取上下文中的日志字段
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(ctxKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler 从上下文中补充请求 ID、用户 ID 与链路 ID 的 Handler
+type contextHandler struct {
+	slog.Handler
+}
+
+// NewHandler 包装 Handler，使 *Context 系列方法自动附带上下文中的日志字段
+func NewHandler(handler slog.Handler) slog.Handler {
+	return contextHandler{Handler: handler}
+}
+
+// Handle 输出日志前追加上下文字段
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	record.AddAttrs(attrsFromContext(ctx)...)
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs 保持包装，确保派生的 Logger 仍会补充上下文字段
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup 保持包装
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// OrDefault 为 nil 时返回全局默认 Logger
+func OrDefault(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return slog.Default()
+	}
+	return l
+}
+
+// nopCloser 空操作的 Closer
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,217 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gin-mysql-api/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// newJSONLogger 创建写入缓冲区的 JSON Logger
+func newJSONLogger(buf *bytes.Buffer, level slog.Level) *slog.Logger {
+	return slog.New(NewHandler(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: level})))
+}
+
+// decodeLines 解析缓冲区中的每行 JSON 日志
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestParseLevel(t *testing.T) {
+	t.Run("支持的级别", func(t *testing.T) {
+		cases := map[string]slog.Level{
+			"debug":   slog.LevelDebug,
+			"":        slog.LevelInfo,
+			"INFO":    slog.LevelInfo,
+			"warn":    slog.LevelWarn,
+			"warning": slog.LevelWarn,
+			"error":   slog.LevelError,
+		}
+		for input, expected := range cases {
+			level, err := ParseLevel(input)
+			assert.NoError(t, err, input)
+			assert.Equal(t, expected, level, input)
+		}
+	})
+
+	t.Run("未知级别返回错误", func(t *testing.T) {
+		_, err := ParseLevel("verbose")
+		assert.Error(t, err)
+	})
+}
+
+func TestNew(t *testing.T) {
+	t.Run("文件输出按级别过滤", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "logs", "app.log")
+		log, closer, err := New(&config.LoggingConfig{Level: "warn", Format: "json", Output: "file", Filename: filename})
+		require.NoError(t, err)
+
+		log.Info("被过滤")
+		log.Warn("已记录", slog.String("key", "value"))
+		require.NoError(t, closer.Close())
+
+		data, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "被过滤")
+		assert.Contains(t, string(data), `"msg":"已记录"`)
+		assert.Contains(t, string(data), `"key":"value"`)
+	})
+
+	t.Run("无效配置返回错误", func(t *testing.T) {
+		_, _, err := New(&config.LoggingConfig{Format: "xml"})
+		assert.Error(t, err)
+
+		_, _, err = New(&config.LoggingConfig{Output: "syslog"})
+		assert.Error(t, err)
+
+		_, _, err = New(&config.LoggingConfig{Level: "verbose"})
+		assert.Error(t, err)
+	})
+}
+
+func TestContextHandler(t *testing.T) {
+	t.Run("附带上下文字段", func(t *testing.T) {
+		var buf bytes.Buffer
+		log := newJSONLogger(&buf, slog.LevelInfo)
+
+		ctx := WithAttrs(context.Background(), slog.String("request_id", "req-1"))
+		ctx = WithAttrs(ctx, slog.Uint64("user_id", 42))
+		log.With(slog.String("component", "test")).InfoContext(ctx, "hello")
+		log.Info("no context")
+
+		lines := decodeLines(t, &buf)
+		require.Len(t, lines, 2)
+		assert.Equal(t, "req-1", lines[0]["request_id"])
+		assert.Equal(t, float64(42), lines[0]["user_id"])
+		assert.Equal(t, "test", lines[0]["component"])
+		assert.NotContains(t, lines[1], "request_id")
+	})
+
+	t.Run("附带链路ID", func(t *testing.T) {
+		var buf bytes.Buffer
+		log := newJSONLogger(&buf, slog.LevelInfo)
+
+		traceID := trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+		spanContext := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: trace.SpanID{1}})
+		ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+		log.InfoContext(ctx, "traced")
+
+		lines := decodeLines(t, &buf)
+		require.Len(t, lines, 1)
+		assert.Equal(t, traceID.String(), lines[0]["trace_id"])
+	})
+}
+
+func TestRotatingFile(t *testing.T) {
+	t.Run("超过大小后滚动并保留有限备份", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "app.log")
+		file, err := NewRotatingFile(filename, 10, 2)
+		require.NoError(t, err)
+
+		for _, line := range []string{"first-001\n", "second-02\n", "third-003\n", "fourth-04\n"} {
+			_, err := file.Write([]byte(line))
+			require.NoError(t, err)
+		}
+		require.NoError(t, file.Close())
+
+		current, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		assert.Equal(t, "fourth-04\n", string(current))
+
+		backup1, err := os.ReadFile(filename + ".1")
+		require.NoError(t, err)
+		assert.Equal(t, "third-003\n", string(backup1))
+
+		backup2, err := os.ReadFile(filename + ".2")
+		require.NoError(t, err)
+		assert.Equal(t, "second-02\n", string(backup2))
+
+		_, err = os.Stat(filename + ".3")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("关闭后写入返回错误", func(t *testing.T) {
+		file, err := NewRotatingFile(filepath.Join(t.TempDir(), "app.log"), 0, 0)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		_, err = file.Write([]byte("late"))
+		assert.ErrorIs(t, err, os.ErrClosed)
+	})
+}
+
+func TestGormLogger(t *testing.T) {
+	sqlFn := func() (string, int64) { return "SELECT 1", 1 }
+
+	t.Run("普通 SQL 为 debug 级别", func(t *testing.T) {
+		var buf bytes.Buffer
+		gormLog := NewGormLogger(newJSONLogger(&buf, slog.LevelDebug), time.Second)
+
+		gormLog.Trace(context.Background(), time.Now(), sqlFn, nil)
+
+		lines := decodeLines(t, &buf)
+		require.Len(t, lines, 1)
+		assert.Equal(t, "DEBUG", lines[0]["level"])
+		assert.Equal(t, "SELECT 1", lines[0]["sql"])
+	})
+
+	t.Run("info 级别下不输出普通 SQL", func(t *testing.T) {
+		var buf bytes.Buffer
+		gormLog := NewGormLogger(newJSONLogger(&buf, slog.LevelInfo), time.Second)
+
+		gormLog.Trace(context.Background(), time.Now(), sqlFn, nil)
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("慢查询为 warn 级别", func(t *testing.T) {
+		var buf bytes.Buffer
+		gormLog := NewGormLogger(newJSONLogger(&buf, slog.LevelInfo), time.Millisecond)
+
+		gormLog.Trace(context.Background(), time.Now().Add(-time.Second), sqlFn, nil)
+
+		lines := decodeLines(t, &buf)
+		require.Len(t, lines, 1)
+		assert.Equal(t, "WARN", lines[0]["level"])
+		assert.Equal(t, "slow sql", lines[0]["msg"])
+	})
+
+	t.Run("执行错误为 error 级别，记录不存在除外", func(t *testing.T) {
+		var buf bytes.Buffer
+		gormLog := NewGormLogger(newJSONLogger(&buf, slog.LevelInfo), time.Second)
+
+		gormLog.Trace(context.Background(), time.Now(), sqlFn, gorm.ErrRecordNotFound)
+		assert.Empty(t, buf.String())
+
+		ctx := WithAttrs(context.Background(), slog.String("request_id", "req-1"))
+		gormLog.Trace(ctx, time.Now(), sqlFn, errors.New("deadlock"))
+
+		lines := decodeLines(t, &buf)
+		require.Len(t, lines, 1)
+		assert.Equal(t, "ERROR", lines[0]["level"])
+		assert.Equal(t, "deadlock", lines[0]["error"])
+		assert.Equal(t, "req-1", lines[0]["request_id"])
+	})
+}
@@ -0,0 +1,284 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+)
+
+// ErrImageTooLarge 图片像素数超过上限
+var ErrImageTooLarge = errors.New("media: image too large")
+
+// DecodeImage 解码 JPEG、PNG、GIF（取第一帧）图片，JPEG 按 EXIF 方向旋转为正常显示的方向
+// maxPixels 为允许解码的最大像素数，先读取尺寸再解码，防止解压炸弹
+func DecodeImage(data []byte, maxPixels int) (image.Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("media: decode image config: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("media: decode image: %w", err)
+	}
+	if format == "jpeg" {
+		img = Orient(img, Orientation(data))
+	}
+	return img, nil
+}
+
+// VariantType 获取规格图的内容类型与扩展名：JPEG 原图生成 JPEG，其余格式生成 PNG 以保留透明度
+func VariantType(contentType string) (variantType, ext string) {
+	if contentType == TypeJPEG {
+		return TypeJPEG, ".jpg"
+	}
+	return TypePNG, ".png"
+}
+
+// EncodeImage 按 VariantType 返回的内容类型编码图片，不写入 EXIF、ICC、注释等任何元数据
+func EncodeImage(w io.Writer, img image.Image, contentType string, quality int) error {
+	if contentType == TypeJPEG {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	}
+	return png.Encode(w, img)
+}
+
+// Orientation 读取 JPEG 的 EXIF 方向（1-8），没有 EXIF 或无法解析时返回 1
+func Orientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	// 依次遍历 JPEG 段，EXIF 位于图像数据之前的 APP1 段
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		if marker == 0xD8 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 || marker == 0xFF {
+			pos += 2
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation 从 TIFF 结构的 IFD0 中读取 Orientation（0x0112）标签
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != 0x0112 {
+			continue
+		}
+		// SHORT 类型，值直接保存在条目的前两个字节
+		if value := int(order.Uint16(tiff[entry+8:])); value >= 1 && value <= 8 {
+			return value
+		}
+		return 1
+	}
+	return 1
+}
+
+// Orient 按 EXIF 方向把图片转换为正常显示的方向
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	src := toRGBA(img)
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for dy := 0; dy < dh; dy++ {
+		for dx := 0; dx < dw; dx++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // 水平翻转
+				sx, sy = w-1-dx, dy
+			case 3: // 旋转 180°
+				sx, sy = w-1-dx, h-1-dy
+			case 4: // 垂直翻转
+				sx, sy = dx, h-1-dy
+			case 5: // 沿主对角线翻转
+				sx, sy = dy, dx
+			case 6: // 顺时针旋转 90°
+				sx, sy = dy, h-1-dx
+			case 7: // 沿副对角线翻转
+				sx, sy = w-1-dy, h-1-dx
+			case 8: // 逆时针旋转 90°
+				sx, sy = w-1-dy, dx
+			}
+			si := sy*src.Stride + sx*4
+			di := dy*dst.Stride + dx*4
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
+
+// Thumbnail 居中裁剪为目标宽高比后缩放到 width x height，缩小时使用区域平均采样
+func Thumbnail(img image.Image, width, height int) *image.RGBA {
+	src := toRGBA(img)
+	sw, sh := float64(src.Bounds().Dx()), float64(src.Bounds().Dy())
+
+	// 计算与目标宽高比一致的最大居中裁剪区域
+	cropW, cropH := sw, sh
+	if sw*float64(height) > sh*float64(width) {
+		cropW = sh * float64(width) / float64(height)
+	} else {
+		cropH = sw * float64(height) / float64(width)
+	}
+	x0, y0 := (sw-cropW)/2, (sh-cropH)/2
+
+	xWeights := resampleWeights(x0, cropW, width, src.Bounds().Dx())
+	yWeights := resampleWeights(y0, cropH, height, src.Bounds().Dy())
+
+	// 先水平缩放每一行，再垂直缩放每一列
+	rows := src.Bounds().Dy()
+	tmp := make([]float64, width*rows*4)
+	for y := 0; y < rows; y++ {
+		line := src.Pix[y*src.Stride:]
+		for x, weights := range xWeights {
+			var r, g, b, a float64
+			for _, w := range weights {
+				p := line[w.index*4:]
+				r += float64(p[0]) * w.weight
+				g += float64(p[1]) * w.weight
+				b += float64(p[2]) * w.weight
+				a += float64(p[3]) * w.weight
+			}
+			t := (y*width + x) * 4
+			tmp[t], tmp[t+1], tmp[t+2], tmp[t+3] = r, g, b, a
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y, weights := range yWeights {
+		for x := 0; x < width; x++ {
+			var r, g, b, a float64
+			for _, w := range weights {
+				t := (w.index*width + x) * 4
+				r += tmp[t] * w.weight
+				g += tmp[t+1] * w.weight
+				b += tmp[t+2] * w.weight
+				a += tmp[t+3] * w.weight
+			}
+			d := y*dst.Stride + x*4
+			dst.Pix[d], dst.Pix[d+1], dst.Pix[d+2], dst.Pix[d+3] = clamp8(r), clamp8(g), clamp8(b), clamp8(a)
+		}
+	}
+	return dst
+}
+
+// sampleWeight 源像素及其权重
+type sampleWeight struct {
+	index  int
+	weight float64
+}
+
+// resampleWeights 计算一个方向上每个目标像素覆盖的源像素及权重
+// 源区间为 [start, start+length)，目标像素 i 覆盖源区间中等宽的一段，权重为重叠长度
+func resampleWeights(start, length float64, size, limit int) [][]sampleWeight {
+	scale := length / float64(size)
+	weights := make([][]sampleWeight, size)
+	for i := range weights {
+		lo := start + float64(i)*scale
+		hi := lo + scale
+		if scale < 1 {
+			// 放大时以目标像素中心为准，在相邻两个源像素间线性插值
+			center := lo + scale/2 - 0.5
+			left := math.Floor(center)
+			frac := center - left
+			weights[i] = []sampleWeight{
+				{index: clampIndex(int(left), limit), weight: 1 - frac},
+				{index: clampIndex(int(left)+1, limit), weight: frac},
+			}
+			continue
+		}
+
+		var total float64
+		for j := int(math.Floor(lo)); float64(j) < hi; j++ {
+			overlap := math.Min(hi, float64(j+1)) - math.Max(lo, float64(j))
+			if overlap <= 0 {
+				continue
+			}
+			weights[i] = append(weights[i], sampleWeight{index: clampIndex(j, limit), weight: overlap})
+			total += overlap
+		}
+		for k := range weights[i] {
+			weights[i][k].weight /= total
+		}
+	}
+	return weights
+}
+
+// clampIndex 把下标限制在 [0, limit)
+func clampIndex(i, limit int) int {
+	return min(max(i, 0), limit-1)
+}
+
+// clamp8 四舍五入并限制在 0-255
+func clamp8(v float64) uint8 {
+	return uint8(min(max(math.Round(v), 0), 255))
+}
+
+// toRGBA 转换为原点在 (0, 0) 的 RGBA 图片
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
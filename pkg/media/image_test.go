@@ -0,0 +1,155 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withOrientation 在 JPEG 的 SOI 之后插入带 Orientation 标签的 EXIF 段
+func withOrientation(t *testing.T, data []byte, orientation uint16, order binary.ByteOrder) []byte {
+	t.Helper()
+	tiff := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)       // 条目数
+	order.PutUint16(tiff[10:], 0x0112) // Orientation
+	order.PutUint16(tiff[12:], 3)      // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+// encodeJPEG 编码测试用的 JPEG 图片
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+	return buf.Bytes()
+}
+
+func TestOrientation(t *testing.T) {
+	plain := encodeJPEG(t, image.NewGray(image.Rect(0, 0, 4, 2)))
+
+	assert.Equal(t, 1, Orientation(plain))
+	assert.Equal(t, 6, Orientation(withOrientation(t, plain, 6, binary.BigEndian)))
+	assert.Equal(t, 8, Orientation(withOrientation(t, plain, 8, binary.LittleEndian)))
+	assert.Equal(t, 1, Orientation(withOrientation(t, plain, 9, binary.BigEndian)))
+	assert.Equal(t, 1, Orientation([]byte("\x89PNG\r\n\x1a\n")))
+	assert.Equal(t, 1, Orientation([]byte("\xFF\xD8\xFF\xE1\xFF\xFF")))
+}
+
+func TestOrient(t *testing.T) {
+	// 2x1 图片：左红右蓝
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	src.Set(0, 0, red)
+	src.Set(1, 0, blue)
+
+	cases := map[int][]color.RGBA{
+		2: {blue, red},
+		3: {blue, red},
+		6: {red, blue}, // 顺时针旋转后为 1x2，上红下蓝
+		8: {blue, red}, // 逆时针旋转后为 1x2，上蓝下红
+	}
+	for orientation, want := range cases {
+		out := Orient(src, orientation)
+		b := out.Bounds()
+		if orientation >= 5 {
+			require.Equal(t, image.Rect(0, 0, 1, 2), b)
+			assert.Equal(t, want, []color.RGBA{out.At(0, 0).(color.RGBA), out.At(0, 1).(color.RGBA)}, orientation)
+		} else {
+			require.Equal(t, image.Rect(0, 0, 2, 1), b)
+			assert.Equal(t, want, []color.RGBA{out.At(0, 0).(color.RGBA), out.At(1, 0).(color.RGBA)}, orientation)
+		}
+	}
+	assert.Same(t, src, Orient(src, 1))
+}
+
+func TestThumbnail(t *testing.T) {
+	t.Run("居中裁剪", func(t *testing.T) {
+		// 30x10 图片分为红、绿、蓝三段，裁剪为正方形时只保留中间的绿色
+		src := image.NewRGBA(image.Rect(0, 0, 30, 10))
+		for x := 0; x < 30; x++ {
+			c := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}}[x/10]
+			for y := 0; y < 10; y++ {
+				src.Set(x, y, c)
+			}
+		}
+
+		out := Thumbnail(src, 4, 4)
+		assert.Equal(t, image.Rect(0, 0, 4, 4), out.Bounds())
+		for x := 0; x < 4; x++ {
+			assert.Equal(t, color.RGBA{0, 255, 0, 255}, out.RGBAAt(x, 2))
+		}
+	})
+
+	t.Run("缩小时取区域平均值", func(t *testing.T) {
+		src := image.NewGray(image.Rect(0, 0, 4, 4))
+		for i := range src.Pix {
+			if i%2 == 0 {
+				src.Pix[i] = 255
+			}
+		}
+		out := Thumbnail(src, 1, 1)
+		assert.InDelta(t, 128, int(out.RGBAAt(0, 0).R), 1)
+	})
+
+	t.Run("放大", func(t *testing.T) {
+		out := Thumbnail(image.NewGray(image.Rect(0, 0, 2, 3)), 20, 30)
+		assert.Equal(t, image.Rect(0, 0, 20, 30), out.Bounds())
+	})
+}
+
+func TestDecodeAndEncodeImage(t *testing.T) {
+	t.Run("按 EXIF 方向旋转并去除元数据", func(t *testing.T) {
+		data := withOrientation(t, encodeJPEG(t, image.NewGray(image.Rect(0, 0, 40, 20))), 6, binary.BigEndian)
+
+		img, err := DecodeImage(data, 1000)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 20, 40), img.Bounds())
+
+		contentType, ext := VariantType(TypeJPEG)
+		assert.Equal(t, ".jpg", ext)
+		var buf bytes.Buffer
+		require.NoError(t, EncodeImage(&buf, Thumbnail(img, 10, 10), contentType, 85))
+		assert.Equal(t, TypeJPEG, DetectContentType(buf.Bytes()))
+		assert.NotContains(t, buf.String(), "Exif")
+	})
+
+	t.Run("PNG 与 GIF 生成 PNG", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 2, 2))))
+		_, err := DecodeImage(buf.Bytes(), 1000)
+		require.NoError(t, err)
+
+		contentType, ext := VariantType(TypeGIF)
+		assert.Equal(t, TypePNG, contentType)
+		assert.Equal(t, ".png", ext)
+	})
+
+	t.Run("像素数超过上限", func(t *testing.T) {
+		_, err := DecodeImage(encodeJPEG(t, image.NewGray(image.Rect(0, 0, 40, 20))), 799)
+		assert.ErrorIs(t, err, ErrImageTooLarge)
+	})
+}
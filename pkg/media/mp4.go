@@ -0,0 +1,337 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidVideo 视频文件损坏或不是完整的 MP4/MOV 文件
+var ErrInvalidVideo = errors.New("media: invalid video")
+
+// DefaultMaxMoovSize MP4Probe 默认允许的 moov 盒子大小上限
+const DefaultMaxMoovSize = 64 * 1024 * 1024
+
+// VideoInfo MP4/MOV 视频的元数据
+type VideoInfo struct {
+	Duration time.Duration
+	// Width、Height 视频轨道的显示宽高
+	Width  int
+	Height int
+	// VideoCodec、AudioCodec 采样描述中的编码标识（如 avc1、hvc1、mp4a），没有音频轨道时 AudioCodec 为空
+	VideoCodec string
+	AudioCodec string
+	// Bitrate 按文件大小与时长计算的平均码率（bit/s）
+	Bitrate int64
+}
+
+// Seconds 四舍五入后的时长（秒），不足 1 秒按 1 秒计算
+func (v *VideoInfo) Seconds() int {
+	seconds := int((v.Duration + time.Second/2) / time.Second)
+	if seconds == 0 && v.Duration > 0 {
+		return 1
+	}
+	return seconds
+}
+
+// MP4Probe 流式解析 MP4/MOV 的顶层盒子，只缓存 moov 盒子，其余内容（如 mdat）直接跳过
+// 配合 io.TeeReader 在写入存储的同时解析，moov 位于文件末尾时也不需要再次读取文件
+type MP4Probe struct {
+	// MaxMoovSize moov 盒子的大小上限，为 0 时使用 DefaultMaxMoovSize
+	MaxMoovSize int64
+
+	header []byte // 尚未读完的盒子头
+	remain int64  // 当前盒子剩余的字节数，-1 表示延续到文件末尾
+	inBox  bool
+	inMoov bool
+	moov   []byte
+	total  int64
+	err    error
+}
+
+// Write 实现 io.Writer 接口，解析错误记录下来由 Info 返回，不会中断写入
+func (p *MP4Probe) Write(b []byte) (int, error) {
+	n := len(b)
+	p.total += int64(n)
+	for len(b) > 0 && p.err == nil {
+		if !p.inBox {
+			b = p.readHeader(b)
+			continue
+		}
+
+		chunk := len(b)
+		if p.remain >= 0 && int64(chunk) > p.remain {
+			chunk = int(p.remain)
+		}
+		if p.inMoov {
+			p.moov = append(p.moov, b[:chunk]...)
+		}
+		b = b[chunk:]
+		if p.remain >= 0 {
+			p.remain -= int64(chunk)
+			if p.remain == 0 {
+				p.inBox, p.inMoov = false, false
+			}
+		}
+	}
+	return n, nil
+}
+
+// readHeader 读取顶层盒子头，返回剩余的数据
+func (p *MP4Probe) readHeader(b []byte) []byte {
+	need := 8
+	if len(p.header) >= 8 && binary.BigEndian.Uint32(p.header) == 1 {
+		need = 16 // 64 位大小
+	}
+	take := min(need-len(p.header), len(b))
+	p.header = append(p.header, b[:take]...)
+	b = b[take:]
+	if len(p.header) < need {
+		return b
+	}
+	if need == 8 && binary.BigEndian.Uint32(p.header) == 1 {
+		return b // 继续读取 64 位大小
+	}
+
+	size := int64(binary.BigEndian.Uint32(p.header))
+	boxType := string(p.header[4:8])
+	if size == 1 {
+		size = int64(binary.BigEndian.Uint64(p.header[8:16]))
+	}
+	headerLen := int64(len(p.header))
+	p.header = p.header[:0]
+
+	switch {
+	case size == 0:
+		p.remain = -1 // 延续到文件末尾
+	case size < headerLen:
+		p.err = fmt.Errorf("%w: box %q size %d", ErrInvalidVideo, boxType, size)
+		return nil
+	default:
+		p.remain = size - headerLen
+	}
+
+	if boxType == "moov" {
+		if p.moov != nil {
+			p.err = fmt.Errorf("%w: duplicate moov box", ErrInvalidVideo)
+			return nil
+		}
+		limit := p.MaxMoovSize
+		if limit <= 0 {
+			limit = DefaultMaxMoovSize
+		}
+		if p.remain < 0 || p.remain > limit {
+			p.err = fmt.Errorf("%w: moov box too large", ErrInvalidVideo)
+			return nil
+		}
+		p.moov = make([]byte, 0, p.remain)
+		p.inMoov = true
+	}
+	p.inBox = p.remain != 0
+	return b
+}
+
+// Info 解析 moov 盒子，获取视频元数据，需要在写入完整文件后调用
+func (p *MP4Probe) Info() (*VideoInfo, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if len(p.header) > 0 || (p.inBox && p.remain > 0) {
+		return nil, fmt.Errorf("%w: truncated file", ErrInvalidVideo)
+	}
+	if p.moov == nil {
+		return nil, fmt.Errorf("%w: moov box not found", ErrInvalidVideo)
+	}
+
+	info, err := parseMoov(p.moov)
+	if err != nil {
+		return nil, err
+	}
+	if seconds := info.Duration.Seconds(); seconds > 0 {
+		info.Bitrate = int64(float64(p.total*8) / seconds)
+	}
+	return info, nil
+}
+
+// mp4Box 盒子的类型与内容（不含盒子头）
+type mp4Box struct {
+	typ  string
+	data []byte
+}
+
+// readBoxes 解析连续排列的子盒子
+func readBoxes(data []byte) ([]mp4Box, error) {
+	var boxes []mp4Box
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("%w: truncated box header", ErrInvalidVideo)
+		}
+		size := uint64(binary.BigEndian.Uint32(data))
+		typ := string(data[4:8])
+		headerLen := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("%w: truncated box header", ErrInvalidVideo)
+			}
+			size = binary.BigEndian.Uint64(data[8:])
+			headerLen = 16
+		}
+		if size < headerLen || size > uint64(len(data)) {
+			return nil, fmt.Errorf("%w: box %q size %d", ErrInvalidVideo, typ, size)
+		}
+		boxes = append(boxes, mp4Box{typ: typ, data: data[headerLen:size]})
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+// findBox 按路径查找第一个匹配的子盒子
+func findBox(data []byte, path ...string) ([]byte, bool, error) {
+	for _, typ := range path {
+		boxes, err := readBoxes(data)
+		if err != nil {
+			return nil, false, err
+		}
+		found := false
+		for _, box := range boxes {
+			if box.typ == typ {
+				data, found = box.data, true
+				break
+			}
+		}
+		if !found {
+			return nil, false, nil
+		}
+	}
+	return data, true, nil
+}
+
+// parseMoov 解析 moov 盒子：时长取自 mvhd（分片 MP4 取自 mvex/mehd），宽高与编码取自各轨道
+func parseMoov(moov []byte) (*VideoInfo, error) {
+	boxes, err := readBoxes(moov)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &VideoInfo{}
+	var timescale uint32
+	var duration uint64
+	for _, box := range boxes {
+		switch box.typ {
+		case "mvhd":
+			if timescale, duration, err = parseMovieHeader(box.data); err != nil {
+				return nil, err
+			}
+		case "trak":
+			if err := parseTrak(box.data, info); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if timescale == 0 {
+		return nil, fmt.Errorf("%w: mvhd box not found", ErrInvalidVideo)
+	}
+
+	if duration == 0 {
+		// 分片 MP4 的 mvhd 时长可能为 0，总时长记录在 mvex/mehd 中
+		mehd, ok, err := findBox(moov, "mvex", "mehd")
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			if duration, err = parseFragmentDuration(mehd); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if duration == 0 {
+		return nil, fmt.Errorf("%w: unknown duration", ErrInvalidVideo)
+	}
+	if info.VideoCodec == "" {
+		return nil, fmt.Errorf("%w: video track not found", ErrInvalidVideo)
+	}
+
+	info.Duration = time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+	return info, nil
+}
+
+// parseFragmentDuration 解析 mehd 盒子中的分片总时长，版本 1 为 64 位
+func parseFragmentDuration(mehd []byte) (uint64, error) {
+	if len(mehd) >= 12 && mehd[0] == 1 {
+		return binary.BigEndian.Uint64(mehd[4:]), nil
+	}
+	if len(mehd) >= 8 && mehd[0] == 0 {
+		return uint64(binary.BigEndian.Uint32(mehd[4:])), nil
+	}
+	return 0, fmt.Errorf("%w: truncated mehd box", ErrInvalidVideo)
+}
+
+// parseMovieHeader 解析 mvhd 盒子中的时间刻度与时长，版本 1 的时间字段为 64 位
+func parseMovieHeader(mvhd []byte) (uint32, uint64, error) {
+	if len(mvhd) >= 32 && mvhd[0] == 1 {
+		return binary.BigEndian.Uint32(mvhd[20:]), binary.BigEndian.Uint64(mvhd[24:]), nil
+	}
+	if len(mvhd) >= 20 && mvhd[0] == 0 {
+		return binary.BigEndian.Uint32(mvhd[12:]), uint64(binary.BigEndian.Uint32(mvhd[16:])), nil
+	}
+	return 0, 0, fmt.Errorf("%w: truncated mvhd box", ErrInvalidVideo)
+}
+
+// parseTrak 解析轨道的类型、编码与宽高，只记录第一个视频轨道与第一个音频轨道
+func parseTrak(trak []byte, info *VideoInfo) error {
+	hdlr, ok, err := findBox(trak, "mdia", "hdlr")
+	if err != nil || !ok {
+		return err
+	}
+	if len(hdlr) < 12 {
+		return fmt.Errorf("%w: truncated hdlr box", ErrInvalidVideo)
+	}
+	handler := string(hdlr[8:12])
+	if handler != "vide" && handler != "soun" {
+		return nil
+	}
+
+	stsd, ok, err := findBox(trak, "mdia", "minf", "stbl", "stsd")
+	if err != nil {
+		return err
+	}
+	if !ok || len(stsd) < 16 || binary.BigEndian.Uint32(stsd[4:]) == 0 {
+		return fmt.Errorf("%w: missing sample description", ErrInvalidVideo)
+	}
+	entry := stsd[8:]
+	codec := string(entry[4:8])
+
+	if handler == "soun" {
+		if info.AudioCodec == "" {
+			info.AudioCodec = codec
+		}
+		return nil
+	}
+	if info.VideoCodec != "" {
+		return nil
+	}
+	info.VideoCodec = codec
+
+	// tkhd 记录显示宽高（16.16 定点数），为 0 时使用采样描述中的编码宽高
+	if tkhd, ok, err := findBox(trak, "tkhd"); err != nil {
+		return err
+	} else if ok {
+		offset := 76
+		if len(tkhd) > 0 && tkhd[0] == 1 {
+			offset = 88
+		}
+		if len(tkhd) >= offset+8 {
+			info.Width = int(binary.BigEndian.Uint32(tkhd[offset:]) >> 16)
+			info.Height = int(binary.BigEndian.Uint32(tkhd[offset+4:]) >> 16)
+		}
+	}
+	if (info.Width == 0 || info.Height == 0) && len(entry) >= 36 {
+		info.Width = int(binary.BigEndian.Uint16(entry[32:]))
+		info.Height = int(binary.BigEndian.Uint16(entry[34:]))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// box 构造 MP4 盒子
+func box(typ string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(out, typ...), body...)
+}
+
+// fullBox 构造带版本与标志的 MP4 盒子
+func fullBox(typ string, version byte, payload ...[]byte) []byte {
+	return box(typ, append([]byte{version, 0, 0, 0}, bytes.Join(payload, nil)...))
+}
+
+// u32 大端序 32 位整数
+func u32(v uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, v)
+}
+
+// track 构造轨道，width、height 为 0 时不写入显示宽高
+func track(handler, codec string, width, height uint32) []byte {
+	tkhd := make([]byte, 80)
+	binary.BigEndian.PutUint32(tkhd[72:], width<<16)
+	binary.BigEndian.PutUint32(tkhd[76:], height<<16)
+
+	entry := make([]byte, 70)
+	return box("trak",
+		fullBox("tkhd", 0, tkhd),
+		box("mdia",
+			fullBox("hdlr", 0, u32(0), []byte(handler), make([]byte, 12)),
+			box("minf", box("stbl", fullBox("stsd", 0, u32(1), box(codec, entry)))),
+		),
+	)
+}
+
+// movie 构造 moov 盒子，时间刻度为 1000
+func movie(durationMs uint32, tracks ...[]byte) []byte {
+	mvhd := fullBox("mvhd", 0, u32(0), u32(0), u32(1000), u32(durationMs), make([]byte, 80))
+	return box("moov", append([][]byte{mvhd}, tracks...)...)
+}
+
+// probe 以 chunk 字节为单位分块写入后解析
+func probe(data []byte, chunk int) (*VideoInfo, error) {
+	p := &MP4Probe{}
+	for len(data) > 0 {
+		n := min(chunk, len(data))
+		p.Write(data[:n])
+		data = data[n:]
+	}
+	return p.Info()
+}
+
+func TestMP4Probe(t *testing.T) {
+	ftyp := box("ftyp", []byte("isom"), u32(0x200), []byte("isomavc1"))
+	mdat := box("mdat", make([]byte, 4096))
+	moov := movie(12500, track("vide", "avc1", 1280, 720), track("soun", "mp4a", 0, 0))
+
+	t.Run("moov 位于文件末尾", func(t *testing.T) {
+		data := bytes.Join([][]byte{ftyp, mdat, moov}, nil)
+		for _, chunk := range []int{1, 7, 512, len(data)} {
+			info, err := probe(data, chunk)
+			require.NoError(t, err, chunk)
+			assert.Equal(t, 12500*time.Millisecond, info.Duration)
+			assert.Equal(t, 13, info.Seconds())
+			assert.Equal(t, 1280, info.Width)
+			assert.Equal(t, 720, info.Height)
+			assert.Equal(t, "avc1", info.VideoCodec)
+			assert.Equal(t, "mp4a", info.AudioCodec)
+			assert.Equal(t, int64(len(data))*8*1000/12500, info.Bitrate)
+		}
+	})
+
+	t.Run("moov 位于文件开头与 64 位盒子大小", func(t *testing.T) {
+		large := binary.BigEndian.AppendUint32(nil, 1)
+		large = append(large, "mdat"...)
+		large = binary.BigEndian.AppendUint64(large, 16+100)
+		large = append(large, make([]byte, 100)...)
+
+		info, err := probe(bytes.Join([][]byte{ftyp, moov, large}, nil), 10)
+		require.NoError(t, err)
+		assert.Equal(t, "avc1", info.VideoCodec)
+	})
+
+	t.Run("分片 MP4 从 mehd 读取时长", func(t *testing.T) {
+		fragmented := movie(0, track("vide", "hvc1", 640, 360), box("mvex", fullBox("mehd", 0, u32(3000))))
+		info, err := probe(bytes.Join([][]byte{ftyp, fragmented}, nil), 64)
+		require.NoError(t, err)
+		assert.Equal(t, 3*time.Second, info.Duration)
+		assert.Equal(t, "hvc1", info.VideoCodec)
+		assert.Empty(t, info.AudioCodec)
+	})
+
+	t.Run("损坏的文件", func(t *testing.T) {
+		full := bytes.Join([][]byte{ftyp, mdat, moov}, nil)
+		cases := map[string][]byte{
+			"缺少 moov":   bytes.Join([][]byte{ftyp, mdat}, nil),
+			"文件被截断":     full[:len(full)-10],
+			"没有视频轨道":    bytes.Join([][]byte{ftyp, movie(1000, track("soun", "mp4a", 0, 0))}, nil),
+			"时长为 0":     bytes.Join([][]byte{ftyp, movie(0, track("vide", "avc1", 1, 1))}, nil),
+			"盒子大小错误":    append(bytes.Clone(ftyp), 0, 0, 0, 4, 'f', 'r', 'e', 'e'),
+			"子盒子越界":     bytes.Join([][]byte{ftyp, box("moov", u32(100), []byte("mvhd"))}, nil),
+			"不是 MP4 文件": []byte("\xFF\xD8\xFF\xE0\x00\x10JFIF\x00"),
+		}
+		for name, data := range cases {
+			_, err := probe(data, 16)
+			assert.ErrorIs(t, err, ErrInvalidVideo, name)
+		}
+	})
+
+	t.Run("moov 超过大小上限", func(t *testing.T) {
+		p := &MP4Probe{MaxMoovSize: 16}
+		p.Write(bytes.Join([][]byte{ftyp, moov}, nil))
+		_, err := p.Info()
+		assert.ErrorIs(t, err, ErrInvalidVideo)
+	})
+}
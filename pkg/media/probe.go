@@ -0,0 +1,40 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"  // 注册 GIF 解码器
+	_ "image/jpeg" // 注册 JPEG 解码器
+	_ "image/png"  // 注册 PNG 解码器
+)
+
+// ProbeLen 解析图片尺寸等元数据时保留的文件头长度，JPEG 的 EXIF 段可能较长
+const ProbeLen = 256 * 1024
+
+// HeadBuffer 保留写入内容的前 Limit 个字节，配合 io.TeeReader 在流式写入存储时截取文件头
+type HeadBuffer struct {
+	Limit int
+	buf   []byte
+}
+
+// Write 实现 io.Writer 接口，超出 Limit 的部分直接丢弃
+func (b *HeadBuffer) Write(p []byte) (int, error) {
+	if remaining := b.Limit - len(b.buf); remaining > 0 {
+		b.buf = append(b.buf, p[:min(remaining, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// Bytes 获取已保留的文件头
+func (b *HeadBuffer) Bytes() []byte {
+	return b.buf
+}
+
+// ImageSize 根据文件头解析 JPEG、PNG、GIF 图片的宽高，无法解析时 ok 为 false
+func ImageSize(head []byte) (width, height int, ok bool) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(head))
+	if err != nil {
+		return 0, 0, false
+	}
+	return cfg.Width, cfg.Height, true
+}
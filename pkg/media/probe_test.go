@@ -0,0 +1,38 @@
+package media
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadBuffer(t *testing.T) {
+	head := &HeadBuffer{Limit: 4}
+	n, err := io.Copy(head, strings.NewReader("abcdefgh"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), n)
+	assert.Equal(t, "abcd", string(head.Bytes()))
+}
+
+func TestImageSize(t *testing.T) {
+	t.Run("PNG", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 30, 20))))
+
+		width, height, ok := ImageSize(buf.Bytes())
+		assert.True(t, ok)
+		assert.Equal(t, 30, width)
+		assert.Equal(t, 20, height)
+	})
+
+	t.Run("无法解析", func(t *testing.T) {
+		_, _, ok := ImageSize([]byte("\x00\x00\x00\x20ftypisom"))
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,93 @@
+// Package media 提供媒体文件的内容识别，上传时根据文件头而不是扩展名判断文件类型
+package media
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// SniffLen 识别文件类型需要读取的文件头长度
+const SniffLen = 512
+
+// 支持识别的媒体类型
+const (
+	TypeJPEG      = "image/jpeg"
+	TypePNG       = "image/png"
+	TypeGIF       = "image/gif"
+	TypeWebP      = "image/webp"
+	TypeMP4       = "video/mp4"
+	TypeQuickTime = "video/quicktime"
+	TypeAVI       = "video/x-msvideo"
+	TypeWebM      = "video/webm"
+	TypeMatroska  = "video/x-matroska"
+)
+
+// extensionTypes 扩展名对应的媒体类型
+var extensionTypes = map[string]string{
+	"jpg":  TypeJPEG,
+	"jpeg": TypeJPEG,
+	"png":  TypePNG,
+	"gif":  TypeGIF,
+	"webp": TypeWebP,
+	"mp4":  TypeMP4,
+	"m4v":  TypeMP4,
+	"mov":  TypeQuickTime,
+	"avi":  TypeAVI,
+	"webm": TypeWebM,
+	"mkv":  TypeMatroska,
+}
+
+// TypeByExtension 获取扩展名（不含点号，不区分大小写）对应的媒体类型，未知扩展名返回空字符串
+func TypeByExtension(ext string) string {
+	return extensionTypes[strings.ToLower(strings.TrimPrefix(ext, "."))]
+}
+
+// DetectContentType 根据文件头识别媒体类型
+// 先匹配支持的图片与视频格式，其余交给 http.DetectContentType，无法识别时返回 application/octet-stream
+func DetectContentType(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}):
+		return TypeJPEG
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return TypePNG
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return TypeGIF
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return TypeWebP
+	case len(head) >= 12 && string(head[:4]) == "RIFF" && string(head[8:12]) == "AVI ":
+		return TypeAVI
+	case len(head) >= 12 && string(head[4:8]) == "ftyp":
+		return isoBMFFType(string(head[8:12]))
+	case bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		// EBML 头中的 DocType 区分 WebM 与 Matroska
+		if bytes.Contains(head, []byte("webm")) {
+			return TypeWebM
+		}
+		return TypeMatroska
+	}
+	return http.DetectContentType(head)
+}
+
+// isoBMFFType 根据 ftyp 盒子的主品牌区分 QuickTime 与 MP4，HEIF/AVIF 等图片格式不作为视频处理
+func isoBMFFType(brand string) string {
+	switch brand {
+	case "qt  ":
+		return TypeQuickTime
+	case "heic", "heix", "mif1", "msf1", "avif", "avis":
+		return "application/octet-stream"
+	}
+	return TypeMP4
+}
+
+// Peek 读取用于识别类型的文件头，返回的 Reader 会重新输出已读取的文件头，调用方可以继续流式读取完整内容
+func Peek(r io.Reader) ([]byte, io.Reader, error) {
+	head := make([]byte, SniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, nil, err
+	}
+	head = head[:n]
+	return head, io.MultiReader(bytes.NewReader(head), r), nil
+}
@@ -0,0 +1,65 @@
+package media
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectContentType(t *testing.T) {
+	cases := map[string]struct {
+		head string
+		want string
+	}{
+		"JPEG":      {"\xFF\xD8\xFF\xE0\x00\x10JFIF", TypeJPEG},
+		"PNG":       {"\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", TypePNG},
+		"GIF":       {"GIF89a\x01\x00\x01\x00", TypeGIF},
+		"WebP":      {"RIFF\x24\x00\x00\x00WEBPVP8 ", TypeWebP},
+		"AVI":       {"RIFF\x24\x00\x00\x00AVI LIST", TypeAVI},
+		"MP4":       {"\x00\x00\x00\x20ftypisom\x00\x00\x02\x00", TypeMP4},
+		"QuickTime": {"\x00\x00\x00\x14ftypqt  \x00\x00\x02\x00", TypeQuickTime},
+		"HEIC 图片":   {"\x00\x00\x00\x18ftypheic\x00\x00\x00\x00", "application/octet-stream"},
+		"WebM":      {"\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01\x42\x82\x84webm", TypeWebM},
+		"Matroska":  {"\x1A\x45\xDF\xA3\x9F\x42\x86\x81\x01\x42\x82\x88matroska", TypeMatroska},
+		"脚本":        {"#!/bin/sh\necho hi\n", "text/plain; charset=utf-8"},
+		"空文件":       {"", "text/plain; charset=utf-8"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, DetectContentType([]byte(tc.head)))
+		})
+	}
+}
+
+func TestTypeByExtension(t *testing.T) {
+	assert.Equal(t, TypeJPEG, TypeByExtension(".JPG"))
+	assert.Equal(t, TypeJPEG, TypeByExtension("jpeg"))
+	assert.Equal(t, TypeQuickTime, TypeByExtension("mov"))
+	assert.Empty(t, TypeByExtension("exe"))
+}
+
+func TestPeek(t *testing.T) {
+	t.Run("读取文件头后保留完整内容", func(t *testing.T) {
+		content := "GIF89a" + strings.Repeat("x", 1000)
+		head, r, err := Peek(strings.NewReader(content))
+		require.NoError(t, err)
+		assert.Len(t, head, SniffLen)
+		assert.Equal(t, TypeGIF, DetectContentType(head))
+
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	})
+
+	t.Run("短文件", func(t *testing.T) {
+		head, r, err := Peek(strings.NewReader("\xFF\xD8\xFF"))
+		require.NoError(t, err)
+		assert.Equal(t, TypeJPEG, DetectContentType(head))
+		data, _ := io.ReadAll(r)
+		assert.Len(t, data, 3)
+	})
+}
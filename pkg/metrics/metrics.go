@@ -0,0 +1,171 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// 缓存查询结果
+const (
+	CacheHit         = "hit"          // 命中新鲜数据
+	CacheStale       = "stale"        // 命中旧值（后台刷新）
+	CacheNegativeHit = "negative_hit" // 命中不存在结果的缓存
+	CacheMiss        = "miss"         // 未命中
+)
+
+// Registry 应用指标注册表
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP 请求总数",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP 请求耗时",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "数据库查询耗时",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table", "status"})
+
+	redisCommandsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "redis_commands_total",
+		Help: "Redis 命令总数，status 为 ok、miss 或 error",
+	}, []string{"command", "status"})
+
+	redisCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "redis_command_duration_seconds",
+		Help:    "Redis 命令耗时",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5},
+	}, []string{"command"})
+
+	cacheLookupsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_lookups_total",
+		Help: "读穿缓存查询总数",
+	}, []string{"result"})
+
+	userRegistrationsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "user_registrations_total",
+		Help: "用户注册总数",
+	})
+
+	loginsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "logins_total",
+		Help: "登录次数，role 为 user 或 admin，result 为 success 或 failure",
+	}, []string{"role", "result"})
+
+	fileUploadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "file_uploads_total",
+		Help: "文件上传次数",
+	}, []string{"type", "result"})
+
+	dramaViewsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "drama_views_total",
+		Help: "短剧观看次数",
+	})
+
+	episodeViewsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "episode_views_total",
+		Help: "剧集观看次数",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestsTotal,
+		httpRequestDuration,
+		dbQueryDuration,
+		redisCommandsTotal,
+		redisCommandDuration,
+		cacheLookupsTotal,
+		userRegistrationsTotal,
+		loginsTotal,
+		fileUploadsTotal,
+		dramaViewsTotal,
+		episodeViewsTotal,
+	)
+}
+
+// Handler 返回 Prometheus 文本格式的指标处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDBStats 注册数据库连接池指标，重复注册同名连接池时忽略
+func RegisterDBStats(db *sql.DB, name string) error {
+	err := Registry.Register(collectors.NewDBStatsCollector(db, name))
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) {
+		return nil
+	}
+	return err
+}
+
+// ObserveHTTPRequest 记录 HTTP 请求，route 为路由模板（如 /api/dramas/:id）
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequestsTotal.WithLabelValues(method, route, code).Inc()
+	httpRequestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ObserveDBQuery 记录数据库查询
+func ObserveDBQuery(operation, table string, err error, duration time.Duration) {
+	dbQueryDuration.WithLabelValues(operation, table, resultStatus(err)).Observe(duration.Seconds())
+}
+
+// RecordCacheLookup 记录读穿缓存查询结果
+func RecordCacheLookup(result string) {
+	cacheLookupsTotal.WithLabelValues(result).Inc()
+}
+
+// RecordRegistration 记录用户注册
+func RecordRegistration() {
+	userRegistrationsTotal.Inc()
+}
+
+// RecordLogin 记录登录结果
+func RecordLogin(role string, success bool) {
+	result := "success"
+	if !success {
+		result = "failure"
+	}
+	loginsTotal.WithLabelValues(role, result).Inc()
+}
+
+// RecordUpload 记录文件上传结果
+func RecordUpload(uploadType string, err error) {
+	fileUploadsTotal.WithLabelValues(uploadType, resultStatus(err)).Inc()
+}
+
+// RecordDramaView 记录短剧观看
+func RecordDramaView() {
+	dramaViewsTotal.Inc()
+}
+
+// RecordEpisodeView 记录剧集观看
+func RecordEpisodeView() {
+	episodeViewsTotal.Inc()
+}
+
+// resultStatus 将错误转换为 status 标签
+func resultStatus(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
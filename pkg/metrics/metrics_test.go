@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestHandler(t *testing.T) {
+	ObserveHTTPRequest("GET", "/api/dramas/:id", 200, 10*time.Millisecond)
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `http_requests_total{method="GET",route="/api/dramas/:id",status="200"}`)
+}
+
+func TestBusinessCounters(t *testing.T) {
+	t.Run("登录结果", func(t *testing.T) {
+		before := testutil.ToFloat64(loginsTotal.WithLabelValues("admin", "failure"))
+		RecordLogin("admin", false)
+		assert.Equal(t, before+1, testutil.ToFloat64(loginsTotal.WithLabelValues("admin", "failure")))
+	})
+
+	t.Run("上传结果", func(t *testing.T) {
+		before := testutil.ToFloat64(fileUploadsTotal.WithLabelValues("videos", "error"))
+		RecordUpload("videos", errors.New("保存文件失败"))
+		assert.Equal(t, before+1, testutil.ToFloat64(fileUploadsTotal.WithLabelValues("videos", "error")))
+	})
+}
+
+func TestRedisStatus(t *testing.T) {
+	assert.Equal(t, "ok", redisStatus(nil))
+	assert.Equal(t, "miss", redisStatus(redis.Nil))
+	assert.Equal(t, "error", redisStatus(errors.New("connection refused")))
+}
+
+// histogramCount 获取数据库查询耗时直方图的样本数
+func histogramCount(t *testing.T, labels ...string) uint64 {
+	var metric dto.Metric
+	require.NoError(t, dbQueryDuration.WithLabelValues(labels...).(prometheus.Histogram).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestGormPlugin(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(GormPlugin{}))
+
+	type metricsRecord struct {
+		ID   uint
+		Name string
+	}
+	require.NoError(t, db.AutoMigrate(&metricsRecord{}))
+
+	require.NoError(t, db.Create(&metricsRecord{Name: "test"}).Error)
+	var record metricsRecord
+	require.NoError(t, db.First(&record).Error)
+	// 记录不存在不计为错误
+	assert.ErrorIs(t, db.First(&record, 999).Error, gorm.ErrRecordNotFound)
+
+	assert.Equal(t, uint64(1), histogramCount(t, "create", "metrics_records", "ok"))
+	assert.Equal(t, uint64(2), histogramCount(t, "query", "metrics_records", "ok"))
+	assert.Equal(t, uint64(0), histogramCount(t, "query", "metrics_records", "error"))
+
+	t.Run("重复注册连接池指标不报错", func(t *testing.T) {
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+
+		assert.NoError(t, RegisterDBStats(sqlDB, "test"))
+		assert.NoError(t, RegisterDBStats(sqlDB, "test"))
+	})
+}
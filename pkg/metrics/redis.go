@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type redisStartKey struct{}
+
+// redisHook 记录 Redis 命令次数与耗时的钩子
+type redisHook struct{}
+
+// NewRedisHook 创建 Redis 指标钩子
+func NewRedisHook() redis.Hook {
+	return redisHook{}
+}
+
+// BeforeProcess 记录命令开始时间
+func (redisHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, redisStartKey{}, time.Now()), nil
+}
+
+// AfterProcess 记录单条命令
+func (redisHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	if start, ok := ctx.Value(redisStartKey{}).(time.Time); ok {
+		redisCommandDuration.WithLabelValues(cmd.Name()).Observe(time.Since(start).Seconds())
+	}
+	redisCommandsTotal.WithLabelValues(cmd.Name(), redisStatus(cmd.Err())).Inc()
+	return nil
+}
+
+// BeforeProcessPipeline 记录管道开始时间
+func (redisHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, redisStartKey{}, time.Now()), nil
+}
+
+// AfterProcessPipeline 记录管道内每条命令，耗时按整个管道记录
+func (redisHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	if start, ok := ctx.Value(redisStartKey{}).(time.Time); ok {
+		redisCommandDuration.WithLabelValues("pipeline").Observe(time.Since(start).Seconds())
+	}
+	for _, cmd := range cmds {
+		redisCommandsTotal.WithLabelValues(cmd.Name(), redisStatus(cmd.Err())).Inc()
+	}
+	return nil
+}
+
+// redisStatus 将命令结果转换为 status 标签，redis.Nil 记为 miss
+func redisStatus(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, redis.Nil):
+		return "miss"
+	default:
+		return "error"
+	}
+}
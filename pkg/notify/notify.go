@@ -0,0 +1,86 @@
+// Package notify 发送业务事件通知
+// Webhook 以 JSON 请求体 POST 到配置的地址，配置了密钥时请求头 X-Signature 携带请求体的 HMAC-SHA256 签名，接收方据此校验来源
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"gin-mysql-api/pkg/config"
+)
+
+// Event 业务事件
+type Event struct {
+	// Type 事件类型，如 episode.published
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// Notifier 事件通知接口
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Webhook 通过 HTTP 回调发送事件
+type Webhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// New 根据 Webhook 配置创建通知器，未配置地址时返回 nil
+func New(cfg *config.WebhookConfig) Notifier {
+	if cfg.URL == "" {
+		return nil
+	}
+	return NewWebhook(cfg.URL, cfg.Secret, cfg.GetTimeout())
+}
+
+// NewWebhook 创建 Webhook 通知器，secret 为空时不签名
+func NewWebhook(url, secret string, timeout time.Duration) *Webhook {
+	return &Webhook{url: url, secret: []byte(secret), client: &http.Client{Timeout: timeout}}
+}
+
+// Notify 发送事件，响应状态码不是 2xx 时返回错误
+func (w *Webhook) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("notify: encode event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", event.Type)
+	if len(w.secret) > 0 {
+		req.Header.Set("X-Signature", "sha256="+Sign(w.secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notify: webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign 计算请求体的 HMAC-SHA256 签名（十六进制）
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
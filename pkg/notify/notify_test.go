@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gin-mysql-api/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook(t *testing.T) {
+	event := Event{
+		Type:       "episode.published",
+		OccurredAt: time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC),
+		Data:       map[string]interface{}{"id": 7},
+	}
+
+	t.Run("发送签名的事件", func(t *testing.T) {
+		var body []byte
+		var header http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ = io.ReadAll(r.Body)
+			header = r.Header
+		}))
+		defer server.Close()
+
+		require.NoError(t, NewWebhook(server.URL, "secret", time.Second).Notify(context.Background(), event))
+		assert.Equal(t, "episode.published", header.Get("X-Event-Type"))
+		assert.Equal(t, "application/json", header.Get("Content-Type"))
+		assert.Equal(t, "sha256="+Sign([]byte("secret"), body), header.Get("X-Signature"))
+
+		var received map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &received))
+		assert.Equal(t, "episode.published", received["type"])
+		assert.Equal(t, "2026-10-20T12:00:00Z", received["occurred_at"])
+		assert.Equal(t, map[string]interface{}{"id": float64(7)}, received["data"])
+	})
+
+	t.Run("未配置密钥时不签名", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(t, r.Header.Get("X-Signature"))
+		}))
+		defer server.Close()
+		assert.NoError(t, NewWebhook(server.URL, "", time.Second).Notify(context.Background(), event))
+	})
+
+	t.Run("响应失败", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+		err := NewWebhook(server.URL, "", time.Second).Notify(context.Background(), event)
+		assert.ErrorContains(t, err, "status 502")
+	})
+
+	t.Run("未配置地址", func(t *testing.T) {
+		assert.Nil(t, New(&config.WebhookConfig{}))
+		assert.NotNil(t, New(&config.WebhookConfig{URL: "https://hooks.example.com"}))
+	})
+}
@@ -0,0 +1,174 @@
+// Package signing 签发与校验 HMAC-SHA256 签名的播放令牌
+// 令牌只包含 URL 安全的字符，可以放在 URL 路径中，使 HLS 播放列表中相对路径的分片地址同样带上令牌
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gin-mysql-api/pkg/config"
+)
+
+var (
+	// ErrInvalidToken 令牌格式错误、签名不匹配或绑定的 IP 不一致
+	ErrInvalidToken = errors.New("signing: invalid token")
+	// ErrExpired 令牌已过期
+	ErrExpired = errors.New("signing: token expired")
+	// ErrUnknownKey 签名密钥不存在或已失效
+	ErrUnknownKey = errors.New("signing: unknown or expired key")
+	// ErrNoKey 没有可用于签名的密钥
+	ErrNoKey = errors.New("signing: no active signing key")
+)
+
+// Key 签名密钥，ExpiresAt 之后不再接受该密钥签发的令牌，零值表示长期有效
+type Key struct {
+	ID        string
+	Secret    []byte
+	ExpiresAt time.Time
+}
+
+// Keys 根据播放配置生成签名密钥
+func Keys(cfg *config.PlaybackConfig) []Key {
+	keys := make([]Key, 0, len(cfg.Keys))
+	for _, key := range cfg.Keys {
+		keys = append(keys, Key{ID: key.ID, Secret: []byte(key.Secret), ExpiresAt: key.GetExpiresAt()})
+	}
+	return keys
+}
+
+// Claims 令牌绑定的内容
+type Claims struct {
+	// Episode 剧集 ID
+	Episode uint
+	// Scope 可访问的对象键，以 / 结尾时表示该前缀下的所有对象
+	Scope string
+	// IP 绑定的客户端 IP，为空表示不绑定；IP 不写入令牌，只参与签名
+	IP      string
+	Expires time.Time
+}
+
+// Allows 对象键是否在令牌的访问范围内
+func (c *Claims) Allows(key string) bool {
+	if strings.HasSuffix(c.Scope, "/") {
+		return strings.HasPrefix(key, c.Scope)
+	}
+	return key == c.Scope
+}
+
+// Signer 签发与校验令牌，密钥可以在运行时替换
+type Signer struct {
+	mu   sync.RWMutex
+	keys []Key
+	now  func() time.Time
+}
+
+// NewSigner 创建令牌签发器，第一个未失效的密钥用于签名，其余密钥只用于校验
+func NewSigner(keys []Key) *Signer {
+	return &Signer{keys: keys, now: time.Now}
+}
+
+// SetKeys 替换密钥，用于配置热更新时轮换密钥
+func (s *Signer) SetKeys(keys []Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+// Sign 签发令牌，格式为 <密钥标识>.<内容>.<签名>
+func (s *Signer) Sign(claims Claims) (string, error) {
+	key, ok := s.activeKey()
+	if !ok {
+		return "", ErrNoKey
+	}
+
+	values := url.Values{}
+	values.Set("e", strconv.FormatUint(uint64(claims.Episode), 10))
+	values.Set("x", strconv.FormatInt(claims.Expires.Unix(), 10))
+	values.Set("k", claims.Scope)
+	if claims.IP != "" {
+		values.Set("i", "1")
+	}
+	payload := base64.RawURLEncoding.EncodeToString([]byte(values.Encode()))
+	return key.ID + "." + payload + "." + sign(key, payload, claims.IP), nil
+}
+
+// Verify 校验令牌，ip 为请求的客户端 IP，令牌绑定了 IP 时必须一致
+func (s *Signer) Verify(token, ip string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	key, ok := s.key(parts[0])
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	values, err := url.ParseQuery(string(data))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	claims := &Claims{Scope: values.Get("k")}
+	if values.Get("i") == "1" {
+		claims.IP = ip
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(sign(key, parts[1], claims.IP))) {
+		return nil, ErrInvalidToken
+	}
+
+	episode, err := strconv.ParseUint(values.Get("e"), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	expires, err := strconv.ParseInt(values.Get("x"), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	claims.Episode, claims.Expires = uint(episode), time.Unix(expires, 0)
+	if !s.now().Before(claims.Expires) {
+		return nil, ErrExpired
+	}
+	return claims, nil
+}
+
+// activeKey 获取用于签名的密钥
+func (s *Signer) activeKey() (Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := s.now()
+	for _, key := range s.keys {
+		if key.ExpiresAt.IsZero() || now.Before(key.ExpiresAt) {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// key 根据标识获取未失效的密钥
+func (s *Signer) key(id string) (Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, key := range s.keys {
+		if key.ID == id {
+			return key, key.ExpiresAt.IsZero() || s.now().Before(key.ExpiresAt)
+		}
+	}
+	return Key{}, false
+}
+
+// sign 计算签名，密钥标识与绑定的 IP 参与签名
+func sign(key Key, payload, ip string) string {
+	mac := hmac.New(sha256.New, key.Secret)
+	mac.Write([]byte(key.ID + "." + payload + "\n" + ip))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,111 @@
+package signing
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gin-mysql-api/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestSigner 创建使用固定时间的签发器
+func newTestSigner(keys ...Key) *Signer {
+	s := NewSigner(keys)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestSigner(t *testing.T) {
+	k1 := Key{ID: "k1", Secret: []byte(strings.Repeat("a", 32))}
+	claims := Claims{Episode: 12, Scope: "hls/12/", Expires: now.Add(time.Hour)}
+
+	t.Run("签发并校验令牌", func(t *testing.T) {
+		s := newTestSigner(k1)
+		token, err := s.Sign(claims)
+		require.NoError(t, err)
+		assert.Regexp(t, `^k1\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+$`, token, "令牌可以放在 URL 路径中")
+
+		verified, err := s.Verify(token, "10.0.0.1")
+		require.NoError(t, err)
+		assert.Equal(t, claims.Episode, verified.Episode)
+		assert.True(t, verified.Expires.Equal(claims.Expires))
+		assert.Empty(t, verified.IP)
+		assert.True(t, verified.Allows("hls/12/34/360p/segment_0000.ts"))
+		assert.False(t, verified.Allows("hls/13/34/360p/index.m3u8"))
+	})
+
+	t.Run("篡改或过期的令牌", func(t *testing.T) {
+		s := newTestSigner(k1)
+		token, _ := s.Sign(claims)
+		parts := strings.Split(token, ".")
+
+		forged, _ := newTestSigner(Key{ID: "k1", Secret: []byte("other")}).Sign(Claims{Episode: 13, Scope: "hls/13/", Expires: claims.Expires})
+		_, err := s.Verify(parts[0]+"."+strings.Split(forged, ".")[1]+"."+parts[2], "")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		_, err = s.Verify(forged, "")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		_, err = s.Verify("k2."+parts[1]+"."+parts[2], "")
+		assert.ErrorIs(t, err, ErrUnknownKey)
+		_, err = s.Verify("garbage", "")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+
+		s.now = func() time.Time { return now.Add(2 * time.Hour) }
+		_, err = s.Verify(token, "")
+		assert.ErrorIs(t, err, ErrExpired)
+	})
+
+	t.Run("绑定客户端 IP", func(t *testing.T) {
+		s := newTestSigner(k1)
+		bound := claims
+		bound.IP = "10.0.0.1"
+		token, err := s.Sign(bound)
+		require.NoError(t, err)
+		assert.NotContains(t, token, "10.0.0.1")
+
+		verified, err := s.Verify(token, "10.0.0.1")
+		require.NoError(t, err)
+		assert.Equal(t, "10.0.0.1", verified.IP)
+		_, err = s.Verify(token, "10.0.0.2")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("轮换密钥", func(t *testing.T) {
+		s := newTestSigner(k1)
+		old, _ := s.Sign(claims)
+
+		k2 := Key{ID: "k2", Secret: []byte(strings.Repeat("b", 32))}
+		graced := k1
+		graced.ExpiresAt = now.Add(30 * time.Minute)
+		s.SetKeys([]Key{k2, graced})
+
+		token, err := s.Sign(claims)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(token, "k2."))
+		_, err = s.Verify(old, "")
+		assert.NoError(t, err, "宽限期内旧密钥签发的令牌仍然有效")
+
+		s.now = func() time.Time { return now.Add(45 * time.Minute) }
+		_, err = s.Verify(old, "")
+		assert.ErrorIs(t, err, ErrUnknownKey)
+		_, err = s.Verify(token, "")
+		assert.NoError(t, err)
+
+		s.SetKeys([]Key{graced})
+		_, err = s.Sign(claims)
+		assert.ErrorIs(t, err, ErrNoKey)
+	})
+
+	t.Run("从配置生成密钥", func(t *testing.T) {
+		keys := Keys(&config.PlaybackConfig{Keys: []config.SigningKeyConfig{
+			{ID: "k1", Secret: "secret", ExpiresAt: "2030-01-01T00:00:00Z"},
+		}})
+		require.Len(t, keys, 1)
+		assert.Equal(t, []byte("secret"), keys[0].Secret)
+		assert.Equal(t, 2030, keys[0].ExpiresAt.Year())
+	})
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// localStorage 本地磁盘存储，对象键映射为根目录下的相对路径
+type localStorage struct {
+	root string
+}
+
+// NewLocal 创建本地磁盘存储，目录在首次写入时创建
+func NewLocal(root string) Storage {
+	return &localStorage{root: root}
+}
+
+// resolve 获取规范化的对象键与对应的文件路径
+func (s *localStorage) resolve(key string) (string, string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	return key, filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// Put 写入对象，先写临时文件再重命名，避免读到写了一半的文件
+func (s *localStorage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, fullPath, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(fullPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("storage: create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("storage: create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("storage: write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("storage: write file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("storage: chmod file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		return fmt.Errorf("storage: rename file: %w", err)
+	}
+	return nil
+}
+
+// Get 读取对象
+func (s *localStorage) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	key, fullPath, err := s.resolve(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return nil, nil, localError(err)
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, localError(err)
+	}
+	if fi.IsDir() {
+		file.Close()
+		return nil, nil, ErrNotFound
+	}
+	return file, localObjectInfo(key, fi), nil
+}
+
+// GetRange 读取对象的一部分
+func (s *localStorage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	file, _, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.(*os.File).Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, localError(err)
+	}
+	return limitedReadCloser{Reader: io.LimitReader(file, length), Closer: file}, nil
+}
+
+// limitedReadCloser 只读取部分内容，关闭时关闭底层文件
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Delete 删除对象
+func (s *localStorage) Delete(ctx context.Context, key string) error {
+	_, fullPath, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("storage: delete file: %w", err)
+	}
+	return nil
+}
+
+// Stat 获取对象元数据
+func (s *localStorage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	key, fullPath, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, localError(err)
+	}
+	if fi.IsDir() {
+		return nil, ErrNotFound
+	}
+	return localObjectInfo(key, fi), nil
+}
+
+// PresignGet 本地磁盘不支持预签名，文件通过服务的 /uploads 路径访问
+func (s *localStorage) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+// PresignPut 本地磁盘不支持预签名上传
+func (s *localStorage) PresignPut(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+// localObjectInfo 根据文件信息生成对象元数据，ETag 由修改时间与大小生成
+func localObjectInfo(key string, fi os.FileInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Key:          key,
+		Size:         fi.Size(),
+		ContentType:  mime.TypeByExtension(path.Ext(key)),
+		ETag:         fmt.Sprintf("%x-%x", fi.ModTime().UnixNano(), fi.Size()),
+		LastModified: fi.ModTime(),
+	}
+}
+
+// localError 将文件不存在转换为 ErrNotFound
+func localError(err error) error {
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return fmt.Errorf("storage: %w", err)
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// DefaultChunkSize Open 每次从存储读取的最大字节数
+const DefaultChunkSize int64 = 4 << 20
+
+// Open 打开对象用于随机读取，返回的 ReadSeekCloser 可以直接交给 http.ServeContent
+// Seek 不访问存储，读取时按 chunkSize 分段获取，只传输实际读取的范围；chunkSize 不大于 0 时使用 DefaultChunkSize
+func Open(ctx context.Context, s Storage, key string, chunkSize int64) (io.ReadSeekCloser, *ObjectInfo, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	return &objectReader{ctx: ctx, store: s, key: info.Key, size: info.Size, chunkSize: chunkSize}, info, nil
+}
+
+// objectReader 分段读取对象，body 为当前分段的内容，读完后按 offset 获取下一段
+type objectReader struct {
+	ctx       context.Context
+	store     Storage
+	key       string
+	size      int64
+	chunkSize int64
+
+	offset  int64
+	body    io.ReadCloser
+	bodyEnd int64
+}
+
+// Read 读取当前位置的内容，当前分段读完时获取下一段
+func (r *objectReader) Read(p []byte) (int, error) {
+	for {
+		if r.offset >= r.size {
+			return 0, io.EOF
+		}
+		if r.body == nil {
+			length := min(r.chunkSize, r.size-r.offset)
+			body, err := r.store.GetRange(r.ctx, r.key, r.offset, length)
+			if err != nil {
+				return 0, err
+			}
+			r.body, r.bodyEnd = body, r.offset+length
+		}
+
+		n, err := r.body.Read(p)
+		r.offset += int64(n)
+		if errors.Is(err, io.EOF) {
+			r.closeBody()
+			if r.offset < r.bodyEnd {
+				// 对象在打开后被截断
+				return n, io.ErrUnexpectedEOF
+			}
+			err = nil
+		}
+		if n > 0 || err != nil {
+			return n, err
+		}
+	}
+}
+
+// Seek 移动读取位置，位置改变时丢弃当前分段
+func (r *objectReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("storage: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("storage: negative position")
+	}
+
+	if offset != r.offset {
+		r.closeBody()
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+// Close 关闭当前分段
+func (r *objectReader) Close() error {
+	r.closeBody()
+	return nil
+}
+
+// closeBody 关闭并丢弃当前分段
+func (r *objectReader) closeBody() {
+	if r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+}
@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gin-mysql-api/pkg/config"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// s3Storage S3 兼容对象存储（AWS S3、MinIO、OSS、COS 等），对象键会加上配置的前缀
+type s3Storage struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3 创建 S3 兼容对象存储，不会发起网络请求，存储桶需要预先创建
+func NewS3(cfg *config.S3Config) (Storage, error) {
+	lookup := minio.BucketLookupAuto
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       cfg.UseSSL,
+		Region:       cfg.GetRegion(),
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: create s3 client: %w", err)
+	}
+
+	prefix := strings.Trim(cfg.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return &s3Storage{
+		client: client,
+		bucket: cfg.Bucket,
+		prefix: prefix,
+	}, nil
+}
+
+// objectName 获取对象在存储桶中的名称
+func (s *s3Storage) objectName(key string) (string, string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	return key, s.prefix + key, nil
+}
+
+// Put 写入对象
+func (s *s3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, name, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutObject(ctx, s.bucket, name, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return s3Error(err)
+}
+
+// Get 读取对象，先获取元数据以便对象不存在时立即返回 ErrNotFound
+func (s *s3Storage) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	key, name, err := s.objectName(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	object, err := s.client.GetObject(ctx, s.bucket, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, s3Error(err)
+	}
+	info, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, nil, s3Error(err)
+	}
+	return object, s3ObjectInfo(key, info), nil
+}
+
+// GetRange 读取对象的一部分，只从存储传输请求的范围
+func (s *s3Storage) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	_, name, err := s.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+	if length <= 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetRange(offset, offset+length-1); err != nil {
+		return nil, fmt.Errorf("storage: %w", err)
+	}
+	// minio.Object 在获取元数据后会去掉 Range 请求头，直接发起带范围的请求
+	body, _, _, err := minio.Core{Client: s.client}.GetObject(ctx, s.bucket, name, opts)
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	return body, nil
+}
+
+// Delete 删除对象
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	_, name, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+
+	err = s.client.RemoveObject(ctx, s.bucket, name, minio.RemoveObjectOptions{})
+	if err := s3Error(err); err != nil && err != ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// Stat 获取对象元数据
+func (s *s3Storage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	key, name, err := s.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := s.client.StatObject(ctx, s.bucket, name, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	return s3ObjectInfo(key, info), nil
+}
+
+// PresignGet 生成限时有效的下载 URL
+func (s *s3Storage) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	_, name, err := s.objectName(key)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, name, expires, nil)
+	if err != nil {
+		return "", s3Error(err)
+	}
+	return u.String(), nil
+}
+
+// PresignPut 生成限时有效的上传 URL
+func (s *s3Storage) PresignPut(ctx context.Context, key string, expires time.Duration) (string, error) {
+	_, name, err := s.objectName(key)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := s.client.PresignedPutObject(ctx, s.bucket, name, expires)
+	if err != nil {
+		return "", s3Error(err)
+	}
+	return u.String(), nil
+}
+
+// s3ObjectInfo 转换对象元数据
+func s3ObjectInfo(key string, info minio.ObjectInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Key:          key,
+		Size:         info.Size,
+		ContentType:  info.ContentType,
+		ETag:         info.ETag,
+		LastModified: info.LastModified,
+	}
+}
+
+// s3Error 将对象不存在的错误转换为 ErrNotFound
+func s3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	resp := minio.ToErrorResponse(err)
+	if resp.Code == "NoSuchKey" || (resp.StatusCode == http.StatusNotFound && resp.Code != "NoSuchBucket") {
+		return ErrNotFound
+	}
+	return fmt.Errorf("storage: %w", err)
+}
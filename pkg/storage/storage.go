@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"gin-mysql-api/pkg/config"
+)
+
+// 支持的存储驱动
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+var (
+	// ErrNotFound 对象不存在
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrInvalidKey 对象键为空或试图访问存储根目录之外的路径
+	ErrInvalidKey = errors.New("storage: invalid object key")
+	// ErrPresignNotSupported 驱动不支持预签名 URL（如本地磁盘）
+	ErrPresignNotSupported = errors.New("storage: presigned URLs are not supported by this driver")
+)
+
+// ObjectInfo 对象元数据
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// Storage 对象存储接口，对象键统一使用 / 分隔的相对路径（如 covers/1700000000_abc.jpg）
+type Storage interface {
+	// Put 写入对象，size 未知时传 -1
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，调用方负责关闭返回的 ReadCloser
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// GetRange 读取从 offset 开始的 length 字节，范围超出对象末尾时只返回到末尾的部分
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// Stat 获取对象元数据
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	// PresignGet 生成限时有效的下载 URL
+	PresignGet(ctx context.Context, key string, expires time.Duration) (string, error)
+	// PresignPut 生成限时有效的上传 URL，客户端可直接 PUT 到存储
+	PresignPut(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+// New 根据配置创建存储驱动，local 驱动使用 localRoot 作为根目录
+func New(cfg *config.StorageConfig, localRoot string) (Storage, error) {
+	switch cfg.GetDriver() {
+	case DriverLocal:
+		return NewLocal(localRoot), nil
+	case DriverS3:
+		return NewS3(&cfg.S3)
+	default:
+		return nil, fmt.Errorf("storage: unsupported driver %q", cfg.Driver)
+	}
+}
+
+// CleanKey 规范化对象键：统一使用 /，去掉开头的 /，拒绝空键与包含 .. 的路径
+func CleanKey(key string) (string, error) {
+	key = strings.ReplaceAll(key, "\\", "/")
+	for _, segment := range strings.Split(key, "/") {
+		if segment == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+
+	key = strings.TrimPrefix(path.Clean("/"+key), "/")
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	return key, nil
+}
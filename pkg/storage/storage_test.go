@@ -0,0 +1,391 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gin-mysql-api/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 S3 兼容服务的本地替身，只实现对象的增删查与预签名访问
+type fakeS3 struct {
+	accessKey string
+
+	mu      sync.Mutex
+	objects map[string]fakeObject
+	// rangeRequests 每次 GET 请求的 Range 请求头
+	rangeRequests []string
+}
+
+// fakeObject 替身中保存的对象
+type fakeObject struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+// newFakeS3 启动 S3 替身，返回服务与指向它的配置
+func newFakeS3(t *testing.T) (*fakeS3, *config.S3Config) {
+	t.Helper()
+	fake := &fakeS3{accessKey: "test-access", objects: map[string]fakeObject{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	return fake, &config.S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Bucket:    "media",
+		AccessKey: fake.accessKey,
+		SecretKey: "test-secret",
+		PathStyle: true,
+		Prefix:    "app",
+	}
+}
+
+// ServeHTTP 处理 path-style 的对象请求：/<bucket>/<key>
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	credential := r.URL.Query().Get("X-Amz-Credential") + r.Header.Get("Authorization")
+	if !strings.Contains(credential, f.accessKey+"/") {
+		f.writeError(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body := io.Reader(r.Body)
+		if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+			body = decodeAWSChunked(r.Body)
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			f.writeError(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.objects[name] = fakeObject{data: data, contentType: r.Header.Get("Content-Type"), modTime: time.Now()}
+		w.Header().Set("ETag", fmt.Sprintf("%q", strconv.Itoa(len(data))))
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		object, ok := f.objects[name]
+		if !ok {
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			f.writeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		data, status := object.data, http.StatusOK
+		if start, end, ok := parseRange(r.Header.Get("Range"), len(data)); ok {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end-1, len(data)))
+			data, status = data[start:end], http.StatusPartialContent
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("ETag", fmt.Sprintf("%q", strconv.Itoa(len(object.data))))
+		w.Header().Set("Last-Modified", object.modTime.UTC().Format(http.TimeFormat))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			f.rangeRequests = append(f.rangeRequests, r.Header.Get("Range"))
+			w.Write(data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// writeError 返回 S3 格式的错误
+func (f *fakeS3) writeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: code, Message: code})
+}
+
+// object 获取替身中保存的对象
+func (f *fakeS3) object(name string) (fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	object, ok := f.objects[name]
+	return object, ok
+}
+
+// parseRange 解析 bytes=start-end 形式的范围，返回的 end 不包含在范围内
+func parseRange(header string, size int) (int, int, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, 0, false
+	}
+	first, last, _ := strings.Cut(spec, "-")
+	start, err := strconv.Atoi(first)
+	if err != nil || start >= size {
+		return 0, 0, false
+	}
+	end := size
+	if n, err := strconv.Atoi(last); err == nil && n+1 < size {
+		end = n + 1
+	}
+	return start, end, true
+}
+
+// decodeAWSChunked 解码流式签名的 aws-chunked 请求体：<size 十六进制>;chunk-signature=...\r\n<data>\r\n
+func decodeAWSChunked(r io.Reader) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+			size, err := strconv.ParseInt(sizeHex, 16, 64)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if size == 0 {
+				pw.Close()
+				return
+			}
+			if _, err := io.CopyN(pw, br, size); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			br.ReadString('\n')
+		}
+	}()
+	return pr
+}
+
+// testStorage 各驱动共同遵守的行为
+func testStorage(t *testing.T, store Storage) {
+	ctx := context.Background()
+
+	t.Run("写入并读取对象", func(t *testing.T) {
+		content := "fake video data"
+		require.NoError(t, store.Put(ctx, "videos/a.mp4", strings.NewReader(content), int64(len(content)), "video/mp4"))
+
+		reader, info, err := store.Get(ctx, "videos/a.mp4")
+		require.NoError(t, err)
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+
+		assert.Equal(t, content, string(data))
+		assert.Equal(t, "videos/a.mp4", info.Key)
+		assert.Equal(t, int64(len(content)), info.Size)
+		assert.Equal(t, "video/mp4", info.ContentType)
+		assert.NotEmpty(t, info.ETag)
+		assert.False(t, info.LastModified.IsZero())
+	})
+
+	t.Run("按范围读取", func(t *testing.T) {
+		reader, err := store.GetRange(ctx, "videos/a.mp4", 5, 5)
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		require.NoError(t, err)
+		assert.Equal(t, "video", string(data))
+
+		reader, err = store.GetRange(ctx, "videos/a.mp4", 10, 100)
+		require.NoError(t, err)
+		data, err = io.ReadAll(reader)
+		reader.Close()
+		require.NoError(t, err)
+		assert.Equal(t, " data", string(data), "超出末尾的部分被忽略")
+
+		_, err = store.GetRange(ctx, "videos/missing.mp4", 0, 1)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("分段随机读取", func(t *testing.T) {
+		reader, info, err := Open(ctx, store, "videos/a.mp4", 4)
+		require.NoError(t, err)
+		defer reader.Close()
+		assert.Equal(t, int64(15), info.Size)
+
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "fake video data", string(data))
+
+		offset, err := reader.Seek(-4, io.SeekEnd)
+		require.NoError(t, err)
+		assert.Equal(t, int64(11), offset)
+		data, err = io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(data))
+
+		_, _, err = Open(ctx, store, "videos/missing.mp4", 0)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("获取元数据", func(t *testing.T) {
+		info, err := store.Stat(ctx, "/videos\\a.mp4")
+		require.NoError(t, err)
+		assert.Equal(t, "videos/a.mp4", info.Key, "对象键会被规范化")
+		assert.Equal(t, int64(15), info.Size)
+	})
+
+	t.Run("对象不存在", func(t *testing.T) {
+		_, err := store.Stat(ctx, "videos/missing.mp4")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		_, _, err = store.Get(ctx, "videos/missing.mp4")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("删除对象", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, "videos/a.mp4"))
+		_, err := store.Stat(ctx, "videos/a.mp4")
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		assert.NoError(t, store.Delete(ctx, "videos/a.mp4"), "重复删除不报错")
+	})
+
+	t.Run("拒绝非法对象键", func(t *testing.T) {
+		for _, key := range []string{"", "/", "../secret", "covers/../../secret"} {
+			err := store.Put(ctx, key, strings.NewReader("x"), 1, "text/plain")
+			assert.ErrorIs(t, err, ErrInvalidKey, key)
+		}
+	})
+}
+
+func TestLocalStorage(t *testing.T) {
+	root := t.TempDir()
+	store := NewLocal(root)
+
+	testStorage(t, store)
+
+	t.Run("文件保存在根目录下", func(t *testing.T) {
+		require.NoError(t, store.Put(context.Background(), "covers/b.jpg", strings.NewReader("img"), 3, "image/jpeg"))
+
+		data, err := os.ReadFile(filepath.Join(root, "covers", "b.jpg"))
+		require.NoError(t, err)
+		assert.Equal(t, "img", string(data))
+	})
+
+	t.Run("不支持预签名", func(t *testing.T) {
+		_, err := store.PresignGet(context.Background(), "covers/b.jpg", time.Minute)
+		assert.ErrorIs(t, err, ErrPresignNotSupported)
+		_, err = store.PresignPut(context.Background(), "covers/b.jpg", time.Minute)
+		assert.ErrorIs(t, err, ErrPresignNotSupported)
+	})
+}
+
+func TestS3Storage(t *testing.T) {
+	fake, cfg := newFakeS3(t)
+	store, err := NewS3(cfg)
+	require.NoError(t, err)
+
+	testStorage(t, store)
+
+	t.Run("对象键带上前缀", func(t *testing.T) {
+		require.NoError(t, store.Put(context.Background(), "covers/b.jpg", strings.NewReader("img"), 3, "image/jpeg"))
+
+		object, ok := fake.object("media/app/covers/b.jpg")
+		require.True(t, ok)
+		assert.Equal(t, "img", string(object.data))
+		assert.Equal(t, "image/jpeg", object.contentType)
+	})
+
+	t.Run("分段读取只请求所需范围", func(t *testing.T) {
+		content := "0123456789"
+		require.NoError(t, store.Put(context.Background(), "videos/c.mp4", strings.NewReader(content), 10, "video/mp4"))
+		fake.mu.Lock()
+		fake.rangeRequests = nil
+		fake.mu.Unlock()
+
+		reader, _, err := Open(context.Background(), store, "videos/c.mp4", 4)
+		require.NoError(t, err)
+		defer reader.Close()
+		_, err = reader.Seek(3, io.SeekStart)
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "3456789", string(data))
+
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		assert.Equal(t, []string{"bytes=3-6", "bytes=7-9"}, fake.rangeRequests)
+	})
+
+	t.Run("预签名下载地址", func(t *testing.T) {
+		signed, err := store.PresignGet(context.Background(), "covers/b.jpg", 10*time.Minute)
+		require.NoError(t, err)
+
+		u, err := url.Parse(signed)
+		require.NoError(t, err)
+		assert.Equal(t, "/media/app/covers/b.jpg", u.Path)
+		assert.Equal(t, "600", u.Query().Get("X-Amz-Expires"))
+		assert.NotEmpty(t, u.Query().Get("X-Amz-Signature"))
+
+		resp, err := http.Get(signed)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "img", string(data))
+	})
+
+	t.Run("预签名上传地址", func(t *testing.T) {
+		signed, err := store.PresignPut(context.Background(), "videos/c.mp4", time.Minute)
+		require.NoError(t, err)
+
+		req, err := http.NewRequest(http.MethodPut, signed, strings.NewReader("direct upload"))
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		info, err := store.Stat(context.Background(), "videos/c.mp4")
+		require.NoError(t, err)
+		assert.Equal(t, int64(len("direct upload")), info.Size)
+	})
+}
+
+func TestNew(t *testing.T) {
+	t.Run("默认使用本地存储", func(t *testing.T) {
+		store, err := New(&config.StorageConfig{}, t.TempDir())
+		require.NoError(t, err)
+		assert.IsType(t, &localStorage{}, store)
+	})
+
+	t.Run("s3 驱动", func(t *testing.T) {
+		_, cfg := newFakeS3(t)
+		store, err := New(&config.StorageConfig{Driver: "S3", S3: *cfg}, "")
+		require.NoError(t, err)
+		assert.IsType(t, &s3Storage{}, store)
+	})
+
+	t.Run("不支持的驱动", func(t *testing.T) {
+		_, err := New(&config.StorageConfig{Driver: "ftp"}, "")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,121 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"gin-mysql-api/pkg/config"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// useRecorder 将全局 TracerProvider 替换为记录器，测试结束后恢复
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func TestInit(t *testing.T) {
+	t.Run("未启用时返回空操作", func(t *testing.T) {
+		shutdown, err := Init(&config.TracingConfig{})
+		require.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+	})
+
+	t.Run("不支持的导出器", func(t *testing.T) {
+		_, err := Init(&config.TracingConfig{Enabled: true, Exporter: "zipkin"})
+		assert.Error(t, err)
+	})
+
+	t.Run("采样比例越界", func(t *testing.T) {
+		_, err := Init(&config.TracingConfig{Enabled: true, Exporter: ExporterStdout, Sampler: SamplerRatio, SampleRatio: 1.5})
+		assert.Error(t, err)
+	})
+
+	t.Run("不支持的采样策略", func(t *testing.T) {
+		_, err := Init(&config.TracingConfig{Enabled: true, Exporter: ExporterStdout, Sampler: "sometimes"})
+		assert.Error(t, err)
+	})
+}
+
+func TestGormPlugin(t *testing.T) {
+	recorder := useRecorder(t)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(GormPlugin{}))
+
+	type item struct {
+		ID   uint
+		Name string
+	}
+	require.NoError(t, db.AutoMigrate(&item{}))
+
+	t.Run("无父 Span 时不创建", func(t *testing.T) {
+		before := len(recorder.Ended())
+		require.NoError(t, db.Create(&item{Name: "a"}).Error)
+		assert.Len(t, recorder.Ended(), before)
+	})
+
+	t.Run("在父 Span 下创建子 Span", func(t *testing.T) {
+		ctx, parent := Tracer().Start(context.Background(), "parent")
+		var items []item
+		require.NoError(t, db.WithContext(ctx).Find(&items).Error)
+		parent.End()
+
+		var found bool
+		for _, span := range recorder.Ended() {
+			if span.Name() != "gorm.query" {
+				continue
+			}
+			found = true
+			assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+			assert.Contains(t, span.Attributes(), attribute.String("db.sql.table", "items"))
+			assert.Contains(t, span.Attributes(), attribute.String("db.system", "sqlite"))
+		}
+		assert.True(t, found)
+	})
+}
+
+func TestRedisHook(t *testing.T) {
+	recorder := useRecorder(t)
+	hook := NewRedisHook()
+
+	t.Run("无父 Span 时不创建", func(t *testing.T) {
+		cmd := redis.NewStringCmd(context.Background(), "get", "key")
+		ctx, err := hook.BeforeProcess(context.Background(), cmd)
+		require.NoError(t, err)
+		require.NoError(t, hook.AfterProcess(ctx, cmd))
+		assert.Empty(t, recorder.Ended())
+	})
+
+	t.Run("未命中不视为错误", func(t *testing.T) {
+		parentCtx, parent := Tracer().Start(context.Background(), "parent")
+		defer parent.End()
+
+		cmd := redis.NewStringCmd(parentCtx, "get", "key")
+		ctx, err := hook.BeforeProcess(parentCtx, cmd)
+		require.NoError(t, err)
+		cmd.SetErr(redis.Nil)
+		require.NoError(t, hook.AfterProcess(ctx, cmd))
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		assert.Equal(t, "redis.get", spans[0].Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+		assert.Contains(t, spans[0].Attributes(), attribute.Bool("cache.hit", false))
+		assert.Empty(t, spans[0].Events())
+	})
+}
@@ -0,0 +1,173 @@
+package transcode
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 输出文件名
+const (
+	PlaylistName   = "index.m3u8"
+	segmentPattern = "segment_%04d.ts"
+	keyInfoName    = "key.info"
+	keyName        = "key.bin"
+)
+
+// Runner 执行外部命令
+type Runner interface {
+	Run(ctx context.Context, name string, args ...string) error
+}
+
+// ExecRunner 使用 os/exec 执行命令，失败时错误中包含标准错误输出的末尾部分
+type ExecRunner struct{}
+
+// Run 执行命令并等待结束，ctx 取消时终止进程
+func (ExecRunner) Run(ctx context.Context, name string, args ...string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 500 {
+			msg = msg[len(msg)-500:]
+		}
+		return fmt.Errorf("transcode: %s: %w: %s", name, err, msg)
+	}
+	return nil
+}
+
+// FFmpeg 调用 ffmpeg 将视频转码为 H.264/AAC 的 HLS 媒体播放列表，每档执行一次 ffmpeg
+type FFmpeg struct {
+	Path string
+	// SegmentSeconds 分片时长，各档位在相同时间点强制插入关键帧，保证切换清晰度时分片对齐
+	SegmentSeconds int
+	Runner         Runner
+}
+
+// NewFFmpeg 创建 ffmpeg 执行器
+func NewFFmpeg(path string, segmentSeconds int) *FFmpeg {
+	return &FFmpeg{Path: path, SegmentSeconds: segmentSeconds, Runner: ExecRunner{}}
+}
+
+// Transcode 依次转码各档位
+func (f *FFmpeg) Transcode(ctx context.Context, req Request) ([]Output, error) {
+	if len(req.Renditions) == 0 {
+		return nil, ErrNoRenditions
+	}
+	keyInfo := ""
+	if req.Encryption != nil {
+		dir, err := writeKeyInfo(req.Encryption)
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		keyInfo = filepath.Join(dir, keyInfoName)
+	}
+
+	outputs := make([]Output, 0, len(req.Renditions))
+	for _, r := range req.Renditions {
+		dir := filepath.Join(req.OutputDir, r.Name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("transcode: %w", err)
+		}
+		if err := f.Runner.Run(ctx, f.Path, f.args(req, r, dir, keyInfo)...); err != nil {
+			return nil, err
+		}
+
+		bandwidth := int64(r.VideoBitrate) * 1000
+		codecs := videoCodec(r.Height)
+		if req.HasAudio {
+			bandwidth += int64(r.AudioBitrate) * 1000
+			codecs += ",mp4a.40.2"
+		}
+		outputs = append(outputs, Output{
+			Name:      r.Name,
+			Width:     ScaledWidth(req.Width, req.Height, r.Height),
+			Height:    r.Height,
+			Bandwidth: bandwidth,
+			Codecs:    codecs,
+			Playlist:  path.Join(r.Name, PlaylistName),
+		})
+	}
+	return outputs, nil
+}
+
+// writeKeyInfo 将密钥与 ffmpeg 的 key info 文件写入临时目录，返回该目录
+// key info 文件不指定 IV，ffmpeg 使用分片序号作为 IV
+func writeKeyInfo(enc *Encryption) (string, error) {
+	if len(enc.Key) != 16 {
+		return "", ErrInvalidKey
+	}
+	dir, err := os.MkdirTemp("", "hls-key-*")
+	if err != nil {
+		return "", fmt.Errorf("transcode: %w", err)
+	}
+	keyFile := filepath.Join(dir, keyName)
+	err = os.WriteFile(keyFile, enc.Key, 0o600)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, keyInfoName), []byte(enc.KeyURI+"\n"+keyFile+"\n"), 0o600)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("transcode: %w", err)
+	}
+	return dir, nil
+}
+
+// args 构造 ffmpeg 参数，码率上限等于目标码率，播放列表中的 BANDWIDTH 即为峰值码率
+// keyInfo 不为空时使用该 key info 文件加密分片
+func (f *FFmpeg) args(req Request, r Rendition, dir, keyInfo string) []string {
+	width := "-2"
+	if w := ScaledWidth(req.Width, req.Height, r.Height); w > 0 {
+		width = strconv.Itoa(w)
+	}
+	segment := max(f.SegmentSeconds, 1)
+	bitrate := strconv.Itoa(r.VideoBitrate) + "k"
+
+	args := []string{
+		"-hide_banner", "-loglevel", "error", "-y",
+		"-i", req.Input,
+		"-vf", "scale=" + width + ":" + strconv.Itoa(r.Height),
+		"-c:v", "libx264", "-profile:v", "main", "-level:v", videoLevel(r.Height), "-preset", "veryfast",
+		"-b:v", bitrate, "-maxrate", bitrate, "-bufsize", strconv.Itoa(r.VideoBitrate*2) + "k",
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", segment), "-sc_threshold", "0",
+	}
+	if req.HasAudio {
+		args = append(args, "-c:a", "aac", "-b:a", strconv.Itoa(r.AudioBitrate)+"k", "-ac", "2")
+	} else {
+		args = append(args, "-an")
+	}
+	if keyInfo != "" {
+		args = append(args, "-hls_key_info_file", keyInfo)
+	}
+	return append(args,
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(segment),
+		"-hls_playlist_type", "vod",
+		"-hls_segment_filename", filepath.Join(dir, segmentPattern),
+		filepath.Join(dir, PlaylistName),
+	)
+}
+
+// videoLevel H.264 级别，720p 及以下使用 3.1，更高分辨率使用 4.0
+func videoLevel(height int) string {
+	if height <= 720 {
+		return "3.1"
+	}
+	return "4.0"
+}
+
+// videoCodec 与 videoLevel 对应的 RFC 6381 编码标识（Main Profile）
+func videoCodec(height int) string {
+	if height <= 720 {
+		return "avc1.4d401f"
+	}
+	return "avc1.4d4028"
+}
@@ -0,0 +1,153 @@
+package transcode
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRunner 记录执行的命令，不调用 ffmpeg
+type fakeRunner struct {
+	calls [][]string
+	err   error
+}
+
+func (r *fakeRunner) Run(_ context.Context, name string, args ...string) error {
+	r.calls = append(r.calls, append([]string{name}, args...))
+	return r.err
+}
+
+// runnerFunc 将函数适配为 Runner
+type runnerFunc func(ctx context.Context, name string, args ...string) error
+
+func (f runnerFunc) Run(ctx context.Context, name string, args ...string) error {
+	return f(ctx, name, args...)
+}
+
+var ladder = []Rendition{
+	{Name: "360p", Height: 360, VideoBitrate: 800, AudioBitrate: 96},
+	{Name: "720p", Height: 720, VideoBitrate: 2800, AudioBitrate: 128},
+	{Name: "1080p", Height: 1080, VideoBitrate: 5000, AudioBitrate: 192},
+}
+
+func TestSelectRenditions(t *testing.T) {
+	t.Run("跳过高于原视频的档位", func(t *testing.T) {
+		selected := SelectRenditions(ladder, 720)
+		require.Len(t, selected, 2)
+		assert.Equal(t, "720p", selected[1].Name)
+	})
+
+	t.Run("原视频低于最低档位", func(t *testing.T) {
+		selected := SelectRenditions(ladder, 241)
+		require.Len(t, selected, 1)
+		assert.Equal(t, "360p", selected[0].Name)
+		assert.Equal(t, 240, selected[0].Height)
+	})
+
+	t.Run("分辨率未知", func(t *testing.T) {
+		assert.Empty(t, SelectRenditions(ladder, 0))
+	})
+
+	t.Run("按宽高比计算宽度", func(t *testing.T) {
+		assert.Equal(t, 640, ScaledWidth(1920, 1080, 360))
+		assert.Equal(t, 202, ScaledWidth(720, 1280, 360))
+		assert.Equal(t, 0, ScaledWidth(0, 0, 360))
+	})
+}
+
+func TestFFmpeg(t *testing.T) {
+	t.Run("每档执行一次 ffmpeg", func(t *testing.T) {
+		runner := &fakeRunner{}
+		f := &FFmpeg{Path: "/usr/bin/ffmpeg", SegmentSeconds: 4, Runner: runner}
+		dir := t.TempDir()
+
+		outputs, err := f.Transcode(context.Background(), Request{
+			Input: "in.mp4", OutputDir: dir, Width: 1920, Height: 1080, HasAudio: true, Renditions: ladder[:2],
+		})
+		require.NoError(t, err)
+		require.Len(t, runner.calls, 2)
+		assert.Equal(t, []Output{
+			{Name: "360p", Width: 640, Height: 360, Bandwidth: 896000, Codecs: "avc1.4d401f,mp4a.40.2", Playlist: "360p/index.m3u8"},
+			{Name: "720p", Width: 1280, Height: 720, Bandwidth: 2928000, Codecs: "avc1.4d401f,mp4a.40.2", Playlist: "720p/index.m3u8"},
+		}, outputs)
+
+		args := strings.Join(runner.calls[1], " ")
+		assert.True(t, strings.HasPrefix(args, "/usr/bin/ffmpeg "))
+		assert.Contains(t, args, "-i in.mp4")
+		assert.Contains(t, args, "-vf scale=1280:720")
+		assert.Contains(t, args, "-b:v 2800k -maxrate 2800k -bufsize 5600k")
+		assert.Contains(t, args, "-force_key_frames expr:gte(t,n_forced*4)")
+		assert.Contains(t, args, "-c:a aac -b:a 128k")
+		assert.Contains(t, args, "-hls_time 4")
+		assert.Contains(t, args, "-hls_segment_filename "+filepath.Join(dir, "720p", "segment_%04d.ts"))
+		assert.True(t, strings.HasSuffix(args, filepath.Join(dir, "720p", "index.m3u8")))
+		assert.DirExists(t, filepath.Join(dir, "720p"))
+	})
+
+	t.Run("没有音频轨道", func(t *testing.T) {
+		runner := &fakeRunner{}
+		f := &FFmpeg{Path: "ffmpeg", SegmentSeconds: 6, Runner: runner}
+
+		outputs, err := f.Transcode(context.Background(), Request{
+			Input: "in.mp4", OutputDir: t.TempDir(), Width: 1920, Height: 1080, Renditions: ladder[2:],
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5000000), outputs[0].Bandwidth)
+		assert.Equal(t, "avc1.4d4028", outputs[0].Codecs)
+		assert.Contains(t, runner.calls[0], "-an")
+		assert.NotContains(t, runner.calls[0], "aac")
+	})
+
+	t.Run("加密分片", func(t *testing.T) {
+		var keyInfo []string
+		runner := &fakeRunner{}
+		f := &FFmpeg{Path: "ffmpeg", SegmentSeconds: 6, Runner: runnerFunc(func(ctx context.Context, name string, args ...string) error {
+			// 执行期间 key info 文件存在，第一行为播放列表中的密钥地址，第二行为密钥文件
+			for i, arg := range args {
+				if arg == "-hls_key_info_file" {
+					content, err := os.ReadFile(args[i+1])
+					require.NoError(t, err)
+					keyInfo = strings.Split(strings.TrimSpace(string(content)), "\n")
+					key, err := os.ReadFile(keyInfo[1])
+					require.NoError(t, err)
+					assert.Equal(t, []byte("0123456789abcdef"), key)
+				}
+			}
+			return runner.Run(ctx, name, args...)
+		})}
+		dir := t.TempDir()
+
+		_, err := f.Transcode(context.Background(), Request{
+			Input: "in.mp4", OutputDir: dir, Width: 1280, Height: 720, Renditions: ladder[:1],
+			Encryption: &Encryption{Key: []byte("0123456789abcdef"), KeyURI: "https://api.example.com/api/episodes/1/keys/2"},
+		})
+		require.NoError(t, err)
+		require.Len(t, keyInfo, 2)
+		assert.Equal(t, "https://api.example.com/api/episodes/1/keys/2", keyInfo[0])
+		assert.False(t, strings.HasPrefix(keyInfo[1], dir), "密钥不能写入输出目录")
+		assert.NoFileExists(t, keyInfo[1], "转码结束后删除密钥文件")
+
+		_, err = f.Transcode(context.Background(), Request{
+			OutputDir: dir, Renditions: ladder[:1], Encryption: &Encryption{Key: []byte("short")},
+		})
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+
+	t.Run("ffmpeg 执行失败", func(t *testing.T) {
+		runner := &fakeRunner{err: errors.New("exit status 1")}
+		f := &FFmpeg{Path: "ffmpeg", Runner: runner}
+
+		_, err := f.Transcode(context.Background(), Request{OutputDir: t.TempDir(), Renditions: ladder})
+		assert.EqualError(t, err, "exit status 1")
+		assert.Len(t, runner.calls, 1)
+
+		_, err = f.Transcode(context.Background(), Request{OutputDir: t.TempDir()})
+		assert.ErrorIs(t, err, ErrNoRenditions)
+	})
+}
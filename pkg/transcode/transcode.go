@@ -0,0 +1,93 @@
+package transcode
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoRenditions 码率阶梯为空或原视频分辨率未知
+var ErrNoRenditions = errors.New("transcode: no renditions to produce")
+
+// ErrInvalidKey 加密密钥不是 16 字节
+var ErrInvalidKey = errors.New("transcode: encryption key must be 16 bytes")
+
+// Rendition 码率阶梯中的一档
+type Rendition struct {
+	Name   string
+	Height int
+	// VideoBitrate、AudioBitrate 视频与音频码率（kbps）
+	VideoBitrate int
+	AudioBitrate int
+}
+
+// Request 转码请求，输出文件写入 OutputDir/<rendition name>/ 目录
+type Request struct {
+	Input     string
+	OutputDir string
+	// Width、Height 原视频的宽高，用于按宽高比计算输出宽度
+	Width  int
+	Height int
+	// HasAudio 原视频是否有音频轨道
+	HasAudio   bool
+	Renditions []Rendition
+	// Encryption 不为 nil 时使用 AES-128 加密分片
+	Encryption *Encryption
+}
+
+// Encryption HLS 分片加密参数
+// 媒体播放列表的 #EXT-X-KEY 指向 KeyURI，播放器从该地址获取密钥；密钥不会写入 OutputDir
+type Encryption struct {
+	// Key 16 字节的 AES-128 密钥
+	Key    []byte
+	KeyURI string
+}
+
+// Output 一档转码结果
+type Output struct {
+	Name   string
+	Width  int
+	Height int
+	// Bandwidth 峰值码率（bit/s），写入主播放列表的 BANDWIDTH
+	Bandwidth int64
+	// Codecs RFC 6381 编码标识（如 avc1.4d401f,mp4a.40.2）
+	Codecs string
+	// Playlist 媒体播放列表相对 OutputDir 的路径（如 720p/index.m3u8）
+	Playlist string
+}
+
+// Executor 转码执行器，默认实现为调用 ffmpeg 的 FFmpeg，测试中可替换为不依赖 ffmpeg 的实现
+type Executor interface {
+	Transcode(ctx context.Context, req Request) ([]Output, error)
+}
+
+// SelectRenditions 选取不高于原视频分辨率的档位，避免放大画面；原视频低于最低档位时按原分辨率输出最低档位
+func SelectRenditions(ladder []Rendition, sourceHeight int) []Rendition {
+	if len(ladder) == 0 || sourceHeight <= 0 {
+		return nil
+	}
+
+	var selected []Rendition
+	lowest := ladder[0]
+	for _, r := range ladder {
+		if r.Height <= sourceHeight {
+			selected = append(selected, r)
+		}
+		if r.Height < lowest.Height {
+			lowest = r
+		}
+	}
+	if len(selected) == 0 {
+		lowest.Height = max(sourceHeight&^1, 2)
+		selected = append(selected, lowest)
+	}
+	return selected
+}
+
+// ScaledWidth 按原视频宽高比计算输出宽度，H.264 要求宽度为偶数
+func ScaledWidth(width, height, targetHeight int) int {
+	if width <= 0 || height <= 0 {
+		return 0
+	}
+	w := (width*targetHeight + height/2) / height
+	return max(w&^1, 2)
+}
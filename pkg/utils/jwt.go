@@ -81,4 +81,65 @@ func (manager *JWTManager) RefreshToken(tokenString string) (string, error) {
 
 	// 生成新的 token
 	return manager.GenerateToken(claims.UserID, claims.Username, claims.Role)
-}
\ No newline at end of file
+}
+
+// previewKeySuffix 预览令牌使用由密钥派生的独立签名密钥，预览令牌不能当作登录令牌使用，反之亦然
+const previewKeySuffix = ":preview"
+
+// PreviewClaims 内容预览令牌声明，持有者可以查看指定短剧（含所有剧集）或剧集的未发布内容
+type PreviewClaims struct {
+	AdminID   uint `json:"admin_id"`
+	DramaID   uint `json:"drama_id,omitempty"`
+	EpisodeID uint `json:"episode_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// GeneratePreviewToken 生成内容预览令牌，返回令牌与过期时间
+func (manager *JWTManager) GeneratePreviewToken(adminID, dramaID, episodeID uint, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := PreviewClaims{
+		AdminID:   adminID,
+		DramaID:   dramaID,
+		EpisodeID: episodeID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(manager.previewKey())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// VerifyPreviewToken 验证内容预览令牌
+func (manager *JWTManager) VerifyPreviewToken(tokenString string) (*PreviewClaims, error) {
+	token, err := jwt.ParseWithClaims(
+		tokenString,
+		&PreviewClaims{},
+		func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, errors.New("意外的签名方法")
+			}
+			return manager.previewKey(), nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*PreviewClaims)
+	if !ok || (claims.DramaID == 0 && claims.EpisodeID == 0) {
+		return nil, errors.New("无法解析预览令牌声明")
+	}
+	return claims, nil
+}
+
+// previewKey 预览令牌的签名密钥
+func (manager *JWTManager) previewKey() []byte {
+	return []byte(manager.secretKey + previewKeySuffix)
+}
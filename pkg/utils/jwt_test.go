@@ -72,4 +72,38 @@ func TestJWTManager(t *testing.T) {
 		_, err = shortManager.VerifyToken(token)
 		assert.Error(t, err)
 	})
-}
\ No newline at end of file
+}
+func TestJWTManager_PreviewToken(t *testing.T) {
+	manager := NewJWTManager("test-secret-key", time.Hour)
+
+	t.Run("生成和验证预览令牌", func(t *testing.T) {
+		token, expiresAt, err := manager.GeneratePreviewToken(1, 5, 0, 10*time.Minute)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), expiresAt, time.Second)
+
+		claims, err := manager.VerifyPreviewToken(token)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), claims.AdminID)
+		assert.Equal(t, uint(5), claims.DramaID)
+		assert.Zero(t, claims.EpisodeID)
+	})
+
+	t.Run("预览令牌与登录令牌不能互换使用", func(t *testing.T) {
+		preview, _, err := manager.GeneratePreviewToken(1, 0, 7, time.Hour)
+		assert.NoError(t, err)
+		_, err = manager.VerifyToken(preview)
+		assert.Error(t, err)
+
+		login, err := manager.GenerateToken(1, "admin", "admin")
+		assert.NoError(t, err)
+		_, err = manager.VerifyPreviewToken(login)
+		assert.Error(t, err)
+	})
+
+	t.Run("过期的预览令牌", func(t *testing.T) {
+		token, _, err := manager.GeneratePreviewToken(1, 5, 0, -time.Minute)
+		assert.NoError(t, err)
+		_, err = manager.VerifyPreviewToken(token)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,66 @@
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// 构建信息，发布构建时通过 -ldflags "-X gin-mysql-api/pkg/version.Version=..." 注入
+var (
+	Version   = "1.0.0"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// startTime 进程启动时间
+var startTime = time.Now()
+
+// Info 构建信息
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get 获取构建信息
+// 未通过 ldflags 注入提交号时，回退到 Go 工具链记录的 VCS 信息
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if buildInfo, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range buildInfo.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildTime == "" {
+					info.BuildTime = setting.Value
+				}
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	return info
+}
+
+// StartTime 获取进程启动时间
+func StartTime() time.Time {
+	return startTime
+}
+
+// Uptime 获取进程已运行时长
+func Uptime() time.Duration {
+	return time.Since(startTime)
+}
@@ -11,6 +11,7 @@ CREATE TABLE IF NOT EXISTS users (
     email VARCHAR(100) NOT NULL UNIQUE,
     password VARCHAR(255) NOT NULL,
     avatar VARCHAR(255) DEFAULT '',
+    avatar_asset_id BIGINT UNSIGNED NULL,
     phone VARCHAR(20) DEFAULT '',
     status ENUM('active', 'inactive', 'banned') DEFAULT 'active',
     last_login_at TIMESTAMP NULL,
@@ -21,7 +22,8 @@ CREATE TABLE IF NOT EXISTS users (
     INDEX idx_username (username),
     INDEX idx_email (email),
     INDEX idx_status (status),
-    INDEX idx_created_at (created_at)
+    INDEX idx_created_at (created_at),
+    INDEX idx_users_avatar_asset_id (avatar_asset_id)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- 创建管理员表
@@ -50,12 +52,14 @@ CREATE TABLE IF NOT EXISTS dramas (
     title VARCHAR(200) NOT NULL,
     description TEXT,
     cover_image VARCHAR(255) DEFAULT '',
+    cover_asset_id BIGINT UNSIGNED NULL,
     category VARCHAR(50) DEFAULT '',
     tags JSON,
     director VARCHAR(100) DEFAULT '',
     actors JSON,
     release_date DATE,
-    status ENUM('draft', 'published', 'archived') DEFAULT 'draft',
+    status ENUM('draft', 'in_review', 'approved', 'rejected', 'scheduled', 'published', 'archived') DEFAULT 'draft',
+    publish_at TIMESTAMP NULL, -- 定时发布时间，发布后为实际发布时间
     view_count BIGINT UNSIGNED DEFAULT 0,
     like_count BIGINT UNSIGNED DEFAULT 0,
     rating DECIMAL(3,2) DEFAULT 0.00,
@@ -69,8 +73,10 @@ CREATE TABLE IF NOT EXISTS dramas (
     INDEX idx_category (category),
     INDEX idx_status (status),
     INDEX idx_release_date (release_date),
+    INDEX idx_dramas_publish_at (publish_at),
     INDEX idx_view_count (view_count),
     INDEX idx_created_at (created_at),
+    INDEX idx_dramas_cover_asset_id (cover_asset_id),
     FULLTEXT idx_search (title, description)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
@@ -83,10 +89,14 @@ CREATE TABLE IF NOT EXISTS episodes (
     episode_num INT UNSIGNED NOT NULL,
     video_url VARCHAR(500) DEFAULT '',
     thumbnail VARCHAR(255) DEFAULT '',
+    video_asset_id BIGINT UNSIGNED NULL,
+    thumbnail_asset_id BIGINT UNSIGNED NULL,
     duration INT UNSIGNED DEFAULT 0, -- 时长（秒）
-    status ENUM('draft', 'published', 'archived') DEFAULT 'draft',
+    status ENUM('draft', 'in_review', 'approved', 'rejected', 'scheduled', 'published', 'archived') DEFAULT 'draft',
+    publish_at TIMESTAMP NULL, -- 定时发布时间，发布后为实际发布时间
     view_count BIGINT UNSIGNED DEFAULT 0,
     like_count BIGINT UNSIGNED DEFAULT 0,
+    protected BOOLEAN DEFAULT FALSE, -- 是否使用 AES-128 加密 HLS 分片
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
     deleted_at TIMESTAMP NULL,
@@ -95,11 +105,156 @@ CREATE TABLE IF NOT EXISTS episodes (
     INDEX idx_drama_id (drama_id),
     INDEX idx_episode_num (episode_num),
     INDEX idx_status (status),
+    INDEX idx_episodes_publish_at (publish_at),
     INDEX idx_view_count (view_count),
     INDEX idx_created_at (created_at),
+    INDEX idx_episodes_video_asset_id (video_asset_id),
+    INDEX idx_episodes_thumbnail_asset_id (thumbnail_asset_id),
     UNIQUE KEY uk_drama_episode (drama_id, episode_num)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
+-- 创建分片上传会话表
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id VARCHAR(32) PRIMARY KEY,
+    owner_id BIGINT UNSIGNED NOT NULL,
+    owner_role VARCHAR(20) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    upload_type VARCHAR(20) NOT NULL,
+    size BIGINT NOT NULL,
+    chunk_size BIGINT NOT NULL,
+    total_chunks INT NOT NULL,
+    checksum VARCHAR(64) DEFAULT '', -- 完整文件的 SHA-256（可选）
+    status VARCHAR(20) DEFAULT 'uploading',
+    path VARCHAR(500) DEFAULT '', -- 合并后的文件路径
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_upload_sessions_owner (owner_id, owner_role),
+    INDEX idx_upload_sessions_status (status),
+    INDEX idx_upload_sessions_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建上传分片表
+CREATE TABLE IF NOT EXISTS upload_parts (
+    session_id VARCHAR(32) NOT NULL,
+    part_number INT NOT NULL,
+    size BIGINT NOT NULL,
+    checksum VARCHAR(64) NOT NULL, -- 分片的 SHA-256
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (session_id, part_number),
+    FOREIGN KEY (session_id) REFERENCES upload_sessions(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建媒体文件表（记录上传者，普通用户只能删除自己上传的文件）
+CREATE TABLE IF NOT EXISTS media_assets (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    owner_id BIGINT UNSIGNED NOT NULL,
+    owner_role VARCHAR(20) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    storage_key VARCHAR(500) NOT NULL,
+    filename VARCHAR(255) DEFAULT '',
+    size BIGINT NOT NULL,
+    content_type VARCHAR(100) DEFAULT '',
+    checksum VARCHAR(64) DEFAULT '',
+    width INT DEFAULT 0,
+    height INT DEFAULT 0,
+    duration INT DEFAULT 0, -- 视频时长（秒）
+    video_codec VARCHAR(20) DEFAULT '',
+    audio_codec VARCHAR(20) DEFAULT '',
+    bitrate BIGINT DEFAULT 0, -- 平均码率（bit/s）
+    unreferenced_since TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_media_assets_storage_key (storage_key),
+    INDEX idx_media_assets_owner (owner_id, owner_role),
+    INDEX idx_media_assets_checksum (checksum),
+    INDEX idx_media_assets_unreferenced_since (unreferenced_since)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建视频转码任务表
+CREATE TABLE IF NOT EXISTS transcode_jobs (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    episode_id BIGINT UNSIGNED NOT NULL,
+    source_asset_id BIGINT UNSIGNED NOT NULL,
+    source_key VARCHAR(500) NOT NULL,
+    status VARCHAR(20) DEFAULT 'pending', -- pending, running, completed, failed
+    attempts INT DEFAULT 0,
+    error VARCHAR(1000) DEFAULT '',
+    started_at TIMESTAMP NULL,
+    finished_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_transcode_jobs_episode_id (episode_id),
+    INDEX idx_transcode_jobs_status (status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建剧集 HLS 转码结果表
+CREATE TABLE IF NOT EXISTS episode_renditions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    episode_id BIGINT UNSIGNED NOT NULL,
+    job_id BIGINT UNSIGNED NOT NULL,
+    name VARCHAR(20) NOT NULL,
+    width INT DEFAULT 0,
+    height INT DEFAULT 0,
+    bitrate BIGINT DEFAULT 0, -- 峰值码率（bit/s）
+    codecs VARCHAR(100) DEFAULT '',
+    playlist_key VARCHAR(500) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_episode_renditions_episode_id (episode_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建剧集 HLS 内容密钥表（密钥使用主密钥加密后保存）
+CREATE TABLE IF NOT EXISTS episode_keys (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    episode_id BIGINT UNSIGNED NOT NULL,
+    job_id BIGINT UNSIGNED NOT NULL,
+    master_key_id VARCHAR(32) NOT NULL,
+    encrypted_key VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    INDEX idx_episode_keys_episode_id (episode_id),
+    INDEX idx_episode_keys_job_id (job_id),
+    INDEX idx_episode_keys_master_key_id (master_key_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建短剧与剧集状态流转记录表（审核流程历史）
+CREATE TABLE IF NOT EXISTS status_transitions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL, -- drama, episode
+    entity_id BIGINT UNSIGNED NOT NULL,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    comment VARCHAR(500),
+    publish_at TIMESTAMP NULL,
+    admin_id BIGINT UNSIGNED NULL, -- 为空表示由系统执行（定时发布）
+    admin_name VARCHAR(50),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    INDEX idx_status_transitions_entity (entity_type, entity_id),
+    INDEX idx_status_transitions_admin_id (admin_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- 创建短剧与剧集修订版本表（每次更新后的内容快照）
+CREATE TABLE IF NOT EXISTS content_revisions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL, -- drama, episode
+    entity_id BIGINT UNSIGNED NOT NULL,
+    version BIGINT NOT NULL,
+    snapshot TEXT NOT NULL, -- 可编辑字段的 JSON 快照
+    admin_id BIGINT UNSIGNED NULL, -- 为空表示首次更新前已有的内容
+    admin_name VARCHAR(50),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE INDEX idx_content_revisions_version (entity_type, entity_id, version),
+    INDEX idx_content_revisions_admin_id (admin_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
 -- 创建用户观看历史表
 CREATE TABLE IF NOT EXISTS user_watch_history (
     id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
@@ -2,12 +2,19 @@ package tests
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/jpeg"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -15,13 +22,12 @@ import (
 	"github.com/stretchr/testify/suite"
 	"gorm.io/gorm"
 
-	"gin-mysql-api/internal/handler"
 	"gin-mysql-api/internal/models"
 	"gin-mysql-api/internal/repository"
-	"gin-mysql-api/internal/router"
 	"gin-mysql-api/internal/service"
+	"gin-mysql-api/internal/testutil"
 	"gin-mysql-api/pkg/config"
-	"gin-mysql-api/pkg/database"
+	"gin-mysql-api/pkg/utils"
 )
 
 type AdminIntegrationTestSuite struct {
@@ -38,54 +44,18 @@ func (suite *AdminIntegrationTestSuite) SetupSuite() {
 	// 设置测试环境
 	gin.SetMode(gin.TestMode)
 
-	// 加载测试配置 (使用开发配置 + 环境变量覆盖)
-	cfg, err := config.LoadConfig("../configs/config.yaml")
-	suite.Require().NoError(err)
-
-	// 覆盖测试专用配置
-	cfg.Database.DBName = "hajimi_test"
-	cfg.Redis.DB = 1
-	cfg.Server.Port = 8081
-	cfg.JWT.Secret = "test-secret-key"
-	cfg.Logging.Level = "debug"
-
+	// 测试配置默认使用 SQLite 内存数据库
+	cfg := testutil.GetTestConfig()
+	cfg.Upload.UploadPath = suite.T().TempDir()
 	suite.config = cfg
 
-	// 连接测试数据库
-	db, err := database.NewConnection(cfg)
-	suite.Require().NoError(err)
-	suite.db = db
-
-	// 迁移数据库
-	err = db.AutoMigrate(&models.User{}, &models.Admin{}, &models.Drama{}, &models.Episode{})
-	suite.Require().NoError(err)
-
-	// 初始化仓储层
-	userRepo := repository.NewUserRepository(db)
-	adminRepo := repository.NewAdminRepository(db)
-	dramaRepo := repository.NewDramaRepository(db)
-	episodeRepo := repository.NewEpisodeRepository(db)
-
-	suite.adminRepo = adminRepo
-	suite.dramaRepo = dramaRepo
-
-	// 初始化服务层
-	userService := service.NewUserService(userRepo, cfg)
-	adminService := service.NewAdminService(adminRepo, cfg)
-	dramaService := service.NewDramaService(dramaRepo, episodeRepo, nil, cfg)
-	fileService := service.NewFileService(cfg)
-
-	// 初始化处理器
-	container := &handler.Container{
-		UserService:  userService,
-		AdminService: adminService,
-		DramaService: dramaService,
-		FileService:  fileService,
-		Config:       cfg,
-	}
+	// 连接测试数据库（已自动迁移）
+	suite.db = testutil.SetupTestDB()
+	suite.adminRepo = repository.NewAdminRepository(suite.db)
+	suite.dramaRepo = repository.NewDramaRepository(suite.db)
 
 	// 设置路由
-	suite.router = router.SetupRouter(container)
+	suite.router = setupTestRouter(suite.db, cfg)
 
 	// 创建测试管理员
 	suite.createTestAdmin()
@@ -93,19 +63,12 @@ func (suite *AdminIntegrationTestSuite) SetupSuite() {
 
 func (suite *AdminIntegrationTestSuite) TearDownSuite() {
 	// 清理测试数据
-	suite.db.Exec("DELETE FROM episodes")
-	suite.db.Exec("DELETE FROM dramas")
-	suite.db.Exec("DELETE FROM admins")
+	testutil.CleanupTestDB(suite.db)
 }
 
 func (suite *AdminIntegrationTestSuite) createTestAdmin() {
-	// 创建测试管理员
-	testAdmin := &models.Admin{
-		Username: "testadmin",
-		Email:    "admin@example.com",
-		Password: "admin123",
-		Role:     "admin",
-	}
+	// 创建测试管理员（密码为 admin123）
+	testAdmin := testutil.NewAdminFactory().CreateAdmin()
 	err := suite.adminRepo.Create(testAdmin)
 	suite.Require().NoError(err)
 
@@ -116,7 +79,7 @@ func (suite *AdminIntegrationTestSuite) createTestAdmin() {
 	}
 	loginJSON, _ := json.Marshal(loginData)
 
-	req, _ := http.NewRequest("POST", "/admin/login", bytes.NewBuffer(loginJSON))
+	req, _ := http.NewRequest("POST", "/api/auth/admin/login", bytes.NewBuffer(loginJSON))
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
@@ -130,6 +93,7 @@ func (suite *AdminIntegrationTestSuite) createTestAdmin() {
 			suite.adminToken = token
 		}
 	}
+	suite.Require().NotEmpty(suite.adminToken)
 }
 
 // 测试管理员认证API
@@ -142,7 +106,7 @@ func (suite *AdminIntegrationTestSuite) TestAdminAuthAPI() {
 		}
 		loginJSON, _ := json.Marshal(loginData)
 
-		req, _ := http.NewRequest("POST", "/admin/login", bytes.NewBuffer(loginJSON))
+		req, _ := http.NewRequest("POST", "/api/auth/admin/login", bytes.NewBuffer(loginJSON))
 		req.Header.Set("Content-Type", "application/json")
 
 		w := httptest.NewRecorder()
@@ -153,18 +117,17 @@ func (suite *AdminIntegrationTestSuite) TestAdminAuthAPI() {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), "success", response["status"])
+		assert.Equal(suite.T(), true, response["success"])
 	})
 
-	// 测试管理员仪表板
-	suite.Run("管理员仪表板", func() {
-		req, _ := http.NewRequest("GET", "/admin/dashboard", nil)
-		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+	// 测试未携带token访问管理接口
+	suite.Run("未授权访问", func() {
+		req, _ := http.NewRequest("GET", "/api/admin/dramas", nil)
 
 		w := httptest.NewRecorder()
 		suite.router.ServeHTTP(w, req)
 
-		assert.Equal(suite.T(), http.StatusOK, w.Code)
+		assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
 	})
 }
 
@@ -179,26 +142,26 @@ func (suite *AdminIntegrationTestSuite) TestDramaManagementAPI() {
 			"description": "这是一个测试短剧",
 			"category":    "爱情",
 			"tags":        []string{"浪漫", "都市"},
-			"status":      "published",
 		}
 		dramaJSON, _ := json.Marshal(dramaData)
 
-		req, _ := http.NewRequest("POST", "/admin/api/dramas", bytes.NewBuffer(dramaJSON))
+		req, _ := http.NewRequest("POST", "/api/admin/dramas", bytes.NewBuffer(dramaJSON))
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
 
 		w := httptest.NewRecorder()
 		suite.router.ServeHTTP(w, req)
 
-		assert.Equal(suite.T(), http.StatusCreated, w.Code)
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
 
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), "success", response["status"])
+		assert.Equal(suite.T(), true, response["success"])
 
 		// 保存短剧ID用于后续测试
 		if data, ok := response["data"].(map[string]interface{}); ok {
+			assert.Equal(suite.T(), "draft", data["status"], "新建的短剧为草稿")
 			if id, ok := data["id"].(float64); ok {
 				dramaID = uint(id)
 			}
@@ -207,7 +170,7 @@ func (suite *AdminIntegrationTestSuite) TestDramaManagementAPI() {
 
 	// 测试获取短剧列表
 	suite.Run("获取短剧列表", func() {
-		req, _ := http.NewRequest("GET", "/admin/api/dramas?page=1&limit=10", nil)
+		req, _ := http.NewRequest("GET", "/api/admin/dramas?page=1&page_size=10", nil)
 		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
 
 		w := httptest.NewRecorder()
@@ -218,7 +181,7 @@ func (suite *AdminIntegrationTestSuite) TestDramaManagementAPI() {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), "success", response["status"])
+		assert.Equal(suite.T(), true, response["success"])
 	})
 
 	// 测试更新短剧
@@ -235,7 +198,7 @@ func (suite *AdminIntegrationTestSuite) TestDramaManagementAPI() {
 		}
 		updateJSON, _ := json.Marshal(updateData)
 
-		req, _ := http.NewRequest("PUT", fmt.Sprintf("/admin/api/dramas/%d", dramaID), bytes.NewBuffer(updateJSON))
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/admin/dramas/%d", dramaID), bytes.NewBuffer(updateJSON))
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
 
@@ -247,7 +210,7 @@ func (suite *AdminIntegrationTestSuite) TestDramaManagementAPI() {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), "success", response["status"])
+		assert.Equal(suite.T(), true, response["success"])
 	})
 
 	// 测试删除短剧
@@ -257,7 +220,7 @@ func (suite *AdminIntegrationTestSuite) TestDramaManagementAPI() {
 			return
 		}
 
-		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/admin/api/dramas/%d", dramaID), nil)
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("/api/admin/dramas/%d", dramaID), nil)
 		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
 
 		w := httptest.NewRecorder()
@@ -268,7 +231,7 @@ func (suite *AdminIntegrationTestSuite) TestDramaManagementAPI() {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), "success", response["status"])
+		assert.Equal(suite.T(), true, response["success"])
 	})
 }
 
@@ -283,8 +246,8 @@ func (suite *AdminIntegrationTestSuite) TestFileUploadAPI() {
 		fileWriter, err := writer.CreateFormFile("file", "test.jpg")
 		assert.NoError(suite.T(), err)
 
-		// 写入模拟的图片数据
-		fileWriter.Write([]byte("fake image data"))
+		// 写入模拟的图片数据（文件头为 JPEG）
+		fileWriter.Write([]byte("\xFF\xD8\xFF\xE0\x00\x10JFIF\x00fake image data"))
 
 		// 添加其他表单字段
 		writer.WriteField("type", "cover")
@@ -292,7 +255,7 @@ func (suite *AdminIntegrationTestSuite) TestFileUploadAPI() {
 
 		writer.Close()
 
-		req, _ := http.NewRequest("POST", "/admin/api/upload", body)
+		req, _ := http.NewRequest("POST", "/api/upload", body)
 		req.Header.Set("Content-Type", writer.FormDataContentType())
 		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
 
@@ -302,6 +265,221 @@ func (suite *AdminIntegrationTestSuite) TestFileUploadAPI() {
 		// 文件上传可能因为目录不存在而失败，这是正常的
 		assert.True(suite.T(), w.Code == http.StatusOK || w.Code == http.StatusBadRequest || w.Code == http.StatusInternalServerError)
 	})
+
+	suite.Run("分片上传", func() {
+		content := testutil.NewMP4(testutil.MP4Options{AudioCodec: "mp4a"})
+		sum := sha256.Sum256(content)
+		checksum := hex.EncodeToString(sum[:])
+
+		// 创建会话
+		sessionJSON, _ := json.Marshal(map[string]interface{}{
+			"filename": "episode.mp4",
+			"size":     len(content),
+			"type":     "video",
+			"sha256":   checksum,
+		})
+		req, _ := http.NewRequest("POST", "/api/upload/sessions", bytes.NewBuffer(sessionJSON))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		var created struct {
+			Data models.UploadSessionResponse `json:"data"`
+		}
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(suite.T(), 1, created.Data.TotalChunks)
+		partURL := "/api/upload/sessions/" + created.Data.ID + "/parts/1"
+
+		// 未声明长度的分片被拒绝
+		req, _ = http.NewRequest("PUT", partURL, bytes.NewReader(content))
+		req.ContentLength = -1
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		w = httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusLengthRequired, w.Code)
+
+		// 校验和错误
+		tampered := append(bytes.Clone(content[:len(content)-1]), '!')
+		req, _ = http.NewRequest("PUT", partURL, bytes.NewReader(tampered))
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		req.Header.Set("X-Chunk-SHA256", checksum)
+		w = httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusUnprocessableEntity, w.Code)
+
+		req, _ = http.NewRequest("PUT", partURL, bytes.NewReader(content))
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		req.Header.Set("X-Chunk-SHA256", checksum)
+		w = httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		// 合并
+		req, _ = http.NewRequest("POST", "/api/upload/sessions/"+created.Data.ID+"/complete", nil)
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		w = httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		var completed struct {
+			Data models.UploadSessionResponse `json:"data"`
+		}
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &completed))
+		assert.Equal(suite.T(), models.UploadStatusCompleted, completed.Data.Status)
+		suite.Require().NotNil(completed.Data.File)
+		stored, err := os.ReadFile(filepath.Join(suite.config.Upload.UploadPath, completed.Data.File.Path))
+		suite.Require().NoError(err)
+		assert.Equal(suite.T(), content, stored)
+
+		// 不存在的会话
+		req, _ = http.NewRequest("GET", "/api/upload/sessions/missing", nil)
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		w = httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	})
+
+	suite.Run("封面引用上传的文件", func() {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		fileWriter, err := writer.CreateFormFile("file", "cover.jpg")
+		suite.Require().NoError(err)
+		fileWriter.Write([]byte("\xFF\xD8\xFF\xE0\x00\x10JFIF\x00referenced cover"))
+		writer.WriteField("type", "cover")
+		writer.Close()
+
+		req, _ := http.NewRequest("POST", "/api/upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		var uploaded struct {
+			Data models.FileUploadResponse `json:"data"`
+		}
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &uploaded))
+
+		// 使用上传返回的 URL 作为封面，短剧关联到该文件
+		dramaJSON, _ := json.Marshal(map[string]interface{}{"title": "引用封面", "category": "爱情", "cover_image": uploaded.Data.URL})
+		req, _ = http.NewRequest("POST", "/api/admin/dramas", bytes.NewBuffer(dramaJSON))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		w = httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		var created struct {
+			Data models.Drama `json:"data"`
+		}
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &created))
+		assert.NotNil(suite.T(), created.Data.CoverAssetID)
+
+		// 被引用的文件不能删除
+		req, _ = http.NewRequest("DELETE", "/api/upload?path="+uploaded.Data.Path, nil)
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		w = httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusConflict, w.Code)
+	})
+
+	suite.Run("图片规格图", func() {
+		var img bytes.Buffer
+		suite.Require().NoError(jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 900, 1200)), nil))
+
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		fileWriter, err := writer.CreateFormFile("file", "poster.jpg")
+		suite.Require().NoError(err)
+		fileWriter.Write(img.Bytes())
+		writer.WriteField("type", "cover")
+		writer.Close()
+
+		req, _ := http.NewRequest("POST", "/api/upload", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		var uploaded struct {
+			Data models.FileUploadResponse `json:"data"`
+		}
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &uploaded))
+		suite.Require().Contains(uploaded.Data.Variants, "small")
+		suite.Require().Contains(uploaded.Data.Variants, "large")
+
+		// 规格图地址重定向到存储中的规格图
+		req, _ = http.NewRequest("GET", "/api/media/variants/small/"+uploaded.Data.Path, nil)
+		w = httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		suite.Require().Equal(http.StatusFound, w.Code, w.Body.String())
+		assert.Contains(suite.T(), w.Header().Get("Location"), strings.TrimSuffix(uploaded.Data.Path, ".jpg")+"_small.jpg")
+
+		req, _ = http.NewRequest("GET", "/api/media/variants/huge/"+uploaded.Data.Path, nil)
+		w = httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	})
+
+	suite.Run("按范围读取上传的文件", func() {
+		content := testutil.NewMP4(testutil.MP4Options{AudioCodec: "mp4a"})
+		dir := filepath.Join(suite.config.Upload.UploadPath, "videos")
+		suite.Require().NoError(os.MkdirAll(dir, 0o755))
+		suite.Require().NoError(os.WriteFile(filepath.Join(dir, "range.mp4"), content, 0o644))
+
+		serve := func(method string, header map[string]string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest(method, "/uploads/videos/range.mp4", nil)
+			for k, v := range header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			suite.router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := serve("GET", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(suite.T(), content, w.Body.Bytes())
+		assert.Equal(suite.T(), "video/mp4", w.Header().Get("Content-Type"))
+		assert.Equal(suite.T(), "bytes", w.Header().Get("Accept-Ranges"))
+		assert.NotEmpty(suite.T(), w.Header().Get("Last-Modified"))
+		assert.NotEmpty(suite.T(), w.Header().Get("Cache-Control"))
+		etag := w.Header().Get("ETag")
+		suite.Require().True(strings.HasPrefix(etag, `"`), etag)
+
+		// 部分内容
+		w = serve("GET", map[string]string{"Range": "bytes=4-11"})
+		assert.Equal(suite.T(), http.StatusPartialContent, w.Code)
+		assert.Equal(suite.T(), content[4:12], w.Body.Bytes())
+		assert.Equal(suite.T(), fmt.Sprintf("bytes 4-11/%d", len(content)), w.Header().Get("Content-Range"))
+
+		// 条件请求
+		assert.Equal(suite.T(), http.StatusNotModified, serve("GET", map[string]string{"If-None-Match": etag}).Code)
+		w = serve("GET", map[string]string{"Range": "bytes=4-11", "If-Range": etag})
+		assert.Equal(suite.T(), http.StatusPartialContent, w.Code)
+		w = serve("GET", map[string]string{"Range": "bytes=4-11", "If-Range": `"stale"`})
+		assert.Equal(suite.T(), http.StatusOK, w.Code, "文件已变化时返回完整内容")
+		assert.Equal(suite.T(), content, w.Body.Bytes())
+
+		w = serve("GET", map[string]string{"Range": fmt.Sprintf("bytes=%d-", len(content)+10)})
+		assert.Equal(suite.T(), http.StatusRequestedRangeNotSatisfiable, w.Code)
+
+		w = serve("HEAD", nil)
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+		assert.Equal(suite.T(), strconv.Itoa(len(content)), w.Header().Get("Content-Length"))
+		assert.Empty(suite.T(), w.Body.Bytes())
+
+		// 不存在的文件与分片上传的临时分片
+		for _, path := range []string{"/uploads/videos/missing.mp4", "/uploads/_resumable/abc/00001"} {
+			req, _ := http.NewRequest("GET", path, nil)
+			w = httptest.NewRecorder()
+			suite.router.ServeHTTP(w, req)
+			assert.Equal(suite.T(), http.StatusNotFound, w.Code, path)
+		}
+	})
 }
 
 // 测试剧集管理API
@@ -326,23 +504,23 @@ func (suite *AdminIntegrationTestSuite) TestEpisodeManagementAPI() {
 			"description": "测试剧集描述",
 			"episode_num": 1,
 			"duration":    1800, // 30分钟
-			"status":      "published",
+			"video_url":   "https://cdn.example.com/episode1.mp4",
 		}
 		episodeJSON, _ := json.Marshal(episodeData)
 
-		req, _ := http.NewRequest("POST", "/admin/api/episodes", bytes.NewBuffer(episodeJSON))
+		req, _ := http.NewRequest("POST", "/api/admin/episodes", bytes.NewBuffer(episodeJSON))
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
 
 		w := httptest.NewRecorder()
 		suite.router.ServeHTTP(w, req)
 
-		assert.Equal(suite.T(), http.StatusCreated, w.Code)
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
 
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), "success", response["status"])
+		assert.Equal(suite.T(), true, response["success"])
 
 		// 保存剧集ID用于后续测试
 		if data, ok := response["data"].(map[string]interface{}); ok {
@@ -354,7 +532,7 @@ func (suite *AdminIntegrationTestSuite) TestEpisodeManagementAPI() {
 
 	// 测试获取剧集列表
 	suite.Run("获取剧集列表", func() {
-		req, _ := http.NewRequest("GET", fmt.Sprintf("/admin/api/dramas/%d/episodes", drama.ID), nil)
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/admin/dramas/%d/episodes", drama.ID), nil)
 		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
 
 		w := httptest.NewRecorder()
@@ -365,7 +543,7 @@ func (suite *AdminIntegrationTestSuite) TestEpisodeManagementAPI() {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), "success", response["status"])
+		assert.Equal(suite.T(), true, response["success"])
 	})
 
 	// 测试更新剧集
@@ -382,7 +560,7 @@ func (suite *AdminIntegrationTestSuite) TestEpisodeManagementAPI() {
 		}
 		updateJSON, _ := json.Marshal(updateData)
 
-		req, _ := http.NewRequest("PUT", fmt.Sprintf("/admin/api/episodes/%d", episodeID), bytes.NewBuffer(updateJSON))
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/admin/episodes/%d", episodeID), bytes.NewBuffer(updateJSON))
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
 
@@ -394,15 +572,603 @@ func (suite *AdminIntegrationTestSuite) TestEpisodeManagementAPI() {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), "success", response["status"])
+		assert.Equal(suite.T(), true, response["success"])
+	})
+
+	// 测试审核并发布剧集
+	suite.Run("审核并发布剧集", func() {
+		if episodeID == 0 {
+			suite.T().Skip("跳过审核测试，因为没有创建剧集")
+			return
+		}
+
+		for _, status := range []string{"in_review", "approved", "published"} {
+			body, _ := json.Marshal(map[string]string{"status": status})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/api/admin/episodes/%d/transitions", episodeID), bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+
+			w := httptest.NewRecorder()
+			suite.router.ServeHTTP(w, req)
+			suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		}
+	})
+
+	// 测试剧集详情返回播放源
+	suite.Run("获取剧集播放源", func() {
+		if episodeID == 0 {
+			suite.T().Skip("跳过播放源测试，因为没有创建剧集")
+			return
+		}
+
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/api/episodes/%d", episodeID), nil)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		suite.Require().Equal(http.StatusOK, w.Code)
+
+		var response struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		assert.NotContains(suite.T(), response.Data, "video_url")
+		assert.Equal(suite.T(), []interface{}{map[string]interface{}{
+			"type":      "mp4",
+			"mime_type": "video/mp4",
+			"url":       "https://cdn.example.com/episode1.mp4",
+		}}, response.Data["sources"])
+
+		// 视频尚未转码，没有主播放列表
+		req, _ = http.NewRequest("GET", fmt.Sprintf("/api/episodes/%d/master.m3u8", episodeID), nil)
+		w = httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
 	})
 }
 
-func TestAdminIntegrationTestSuite(t *testing.T) {
-	// 跳过集成测试，如果没有设置测试环境
-	if os.Getenv("INTEGRATION_TEST") != "true" {
-		t.Skip("跳过集成测试，设置 INTEGRATION_TEST=true 来运行")
+// 测试签名播放地址
+func (suite *AdminIntegrationTestSuite) TestSignedPlaybackAPI() {
+	cfg := *suite.config
+	cfg.Playback = config.PlaybackConfig{
+		Signed: true,
+		Keys:   []config.SigningKeyConfig{{ID: "k1", Secret: strings.Repeat("s", 32)}},
+	}
+	signed := setupTestRouter(suite.db, &cfg)
+
+	// 准备已上传的视频与已发布、未发布的剧集
+	content := testutil.NewMP4(testutil.MP4Options{AudioCodec: "mp4a"})
+	dir := filepath.Join(cfg.Upload.UploadPath, "videos")
+	suite.Require().NoError(os.MkdirAll(dir, 0o755))
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, "signed.mp4"), content, 0o644))
+	asset := &models.MediaAsset{
+		OwnerRole: "admin", Type: "video", StorageKey: "videos/signed.mp4", Size: int64(len(content)), ContentType: "video/mp4",
+	}
+	suite.Require().NoError(repository.NewMediaAssetRepository(suite.db).Create(asset))
+
+	drama := &models.Drama{Title: "签名播放", Status: "published"}
+	suite.Require().NoError(suite.dramaRepo.Create(drama))
+	newEpisode := func(num int, status string) *models.Episode {
+		episode := &models.Episode{
+			DramaID: drama.ID, Title: fmt.Sprintf("第%d集", num), EpisodeNum: num,
+			VideoURL: asset.StorageKey, VideoAssetID: &asset.ID, Status: status,
+		}
+		suite.Require().NoError(suite.db.Create(episode).Error)
+		return episode
 	}
+	published := newEpisode(1, "published")
+	draft := newEpisode(2, "draft")
 
+	send := func(method, path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		signed.ServeHTTP(w, req)
+		return w
+	}
+	// sourcePath 解析响应中第一个播放源的路径
+	sourcePath := func(w *httptest.ResponseRecorder) string {
+		var response struct {
+			Data struct {
+				Sources []models.PlaybackSource `json:"sources"`
+			} `json:"data"`
+		}
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		suite.Require().Len(response.Data.Sources, 1)
+		return strings.TrimPrefix(response.Data.Sources[0].URL, cfg.Server.GetBaseURL())
+	}
+
+	suite.Run("通过签名地址播放已发布的剧集", func() {
+		w := send("GET", fmt.Sprintf("/api/episodes/%d", published.ID), "")
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		path := sourcePath(w)
+		suite.Require().True(strings.HasPrefix(path, "/media/k1."), path)
+		suite.Require().True(strings.HasSuffix(path, "/"+asset.StorageKey), path)
+
+		w = send("GET", path, "")
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+		assert.Equal(suite.T(), content, w.Body.Bytes())
+		assert.Equal(suite.T(), "private, max-age=3600", w.Header().Get("Cache-Control"))
+
+		tampered := strings.Replace(path, "/media/k1.", "/media/k1.x", 1)
+		assert.Equal(suite.T(), http.StatusForbidden, send("GET", tampered, "").Code)
+		other := strings.TrimSuffix(path, asset.StorageKey) + "videos/other.mp4"
+		assert.Equal(suite.T(), http.StatusForbidden, send("GET", other, "").Code)
+		assert.Equal(suite.T(), http.StatusForbidden, send("GET", "/uploads/"+asset.StorageKey, "").Code, "视频不能绕过签名直接访问")
+	})
+
+	suite.Run("未发布的剧集不签发播放地址", func() {
+		assert.Equal(suite.T(), http.StatusNotFound, send("GET", fmt.Sprintf("/api/episodes/%d", draft.ID), "").Code)
+	})
+
+	suite.Run("列表不返回原始视频地址", func() {
+		w := send("GET", fmt.Sprintf("/api/dramas/%d/episodes/list", drama.ID), "")
+		suite.Require().Equal(http.StatusOK, w.Code)
+		assert.NotContains(suite.T(), w.Body.String(), asset.StorageKey)
+	})
+
+	suite.Run("管理员预览未发布的剧集", func() {
+		assert.Equal(suite.T(), http.StatusUnauthorized, send("GET", fmt.Sprintf("/api/admin/episodes/%d/preview", draft.ID), "").Code)
+
+		w := send("GET", fmt.Sprintf("/api/admin/episodes/%d/preview", draft.ID), suite.adminToken)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		var response struct {
+			Data []models.PlaybackSource `json:"data"`
+		}
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+		suite.Require().Len(response.Data, 1)
+		path := strings.TrimPrefix(response.Data[0].URL, cfg.Server.GetBaseURL())
+		assert.Equal(suite.T(), http.StatusOK, send("HEAD", path, "").Code)
+
+		assert.Equal(suite.T(), http.StatusNotFound, send("GET", "/api/admin/episodes/99999/preview", suite.adminToken).Code)
+	})
+}
+
+// 测试 HLS 内容密钥接口
+func (suite *AdminIntegrationTestSuite) TestContentKeyAPI() {
+	cfg := *suite.config
+	cfg.Playback = config.PlaybackConfig{
+		Encryption: config.EncryptionConfig{MasterKeys: []config.MasterKeyConfig{{ID: "m1", Secret: strings.Repeat("m", 32)}}},
+	}
+	router := setupTestRouter(suite.db, &cfg)
+	keys := service.NewContentKeyService(repository.NewTranscodeRepository(suite.db), service.NewContentKeyring(&cfg), cfg.Server.GetBaseURL(), nil)
+
+	drama := &models.Drama{Title: "加密播放", Status: "published"}
+	suite.Require().NoError(suite.dramaRepo.Create(drama))
+	// newKey 创建受保护的剧集并生成内容密钥，返回密钥接口地址与密钥
+	newKey := func(num int, status string) (string, []byte) {
+		episode := &models.Episode{DramaID: drama.ID, Title: fmt.Sprintf("第%d集", num), EpisodeNum: num, Status: status, Protected: true}
+		suite.Require().NoError(suite.db.Create(episode).Error)
+		encryption, err := keys.Create(episode.ID, 1)
+		suite.Require().NoError(err)
+		return strings.TrimPrefix(encryption.KeyURI, cfg.Server.GetBaseURL()), encryption.Key
+	}
+	publishedKeyPath, publishedKey := newKey(1, "published")
+	draftKeyPath, draftKey := newKey(2, "draft")
+
+	userToken, err := utils.NewJWTManager(cfg.JWT.Secret, cfg.JWT.Expiration).GenerateToken(1, "viewer", "user")
+	suite.Require().NoError(err)
+	send := func(path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	suite.Run("登录用户获取已发布剧集的密钥", func() {
+		assert.Equal(suite.T(), http.StatusUnauthorized, send(publishedKeyPath, "").Code)
+
+		w := send(publishedKeyPath, userToken)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(suite.T(), publishedKey, w.Body.Bytes())
+		assert.Equal(suite.T(), "application/octet-stream", w.Header().Get("Content-Type"))
+		assert.Equal(suite.T(), "private, no-store", w.Header().Get("Cache-Control"))
+	})
+
+	suite.Run("未发布剧集的密钥只有管理员可以获取", func() {
+		assert.Equal(suite.T(), http.StatusForbidden, send(draftKeyPath, userToken).Code)
+
+		w := send(draftKeyPath, suite.adminToken)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(suite.T(), draftKey, w.Body.Bytes())
+	})
+
+	suite.Run("审核人员通过预览令牌获取草稿的密钥", func() {
+		draftID := strings.Split(strings.TrimPrefix(draftKeyPath, "/api/episodes/"), "/")[0]
+		req, _ := http.NewRequest("POST", "/api/admin/episodes/"+draftID+"/preview-token", nil)
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		var response struct {
+			Data models.PreviewTokenResponse `json:"data"`
+		}
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &response))
+
+		req, _ = http.NewRequest("GET", draftKeyPath, nil)
+		req.Header.Set("X-Preview-Token", response.Data.Token)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(suite.T(), draftKey, w.Body.Bytes())
+
+		assert.Equal(suite.T(), http.StatusUnauthorized, send(publishedKeyPath+"?preview_token="+response.Data.Token, "").Code, "预览令牌只覆盖指定的剧集")
+		assert.Equal(suite.T(), http.StatusForbidden, send(draftKeyPath+"?preview_token=invalid", "").Code)
+	})
+
+	suite.Run("密钥不属于该剧集", func() {
+		keyID := draftKeyPath[strings.LastIndex(draftKeyPath, "/")+1:]
+		mismatched := publishedKeyPath[:strings.LastIndex(publishedKeyPath, "/")+1] + keyID
+		assert.Equal(suite.T(), http.StatusNotFound, send(mismatched, userToken).Code)
+		assert.Equal(suite.T(), http.StatusBadRequest, send("/api/episodes/1/keys/abc", userToken).Code)
+	})
+
+	suite.Run("轮换不存在的剧集的密钥", func() {
+		req, _ := http.NewRequest("POST", "/api/admin/episodes/99999/rotate-key", nil)
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	})
+}
+
+func (suite *AdminIntegrationTestSuite) TestScheduledPublishingAPI() {
+	drama := &models.Drama{Title: "定时发布", Status: "published"}
+	suite.Require().NoError(suite.dramaRepo.Create(drama))
+	for num := 1; num <= 5; num++ {
+		episode := &models.Episode{DramaID: drama.ID, Title: fmt.Sprintf("第%d集", num), EpisodeNum: num, Status: "approved"}
+		suite.Require().NoError(suite.db.Create(episode).Error)
+	}
+	send := func(method, path string, body interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	suite.Run("批量排期剧集", func() {
+		w, response := send("POST", fmt.Sprintf("/api/admin/dramas/%d/schedule", drama.ID), map[string]interface{}{
+			"from_episode": 2, "to_episode": 5, "start_date": "2031-03-01", "time": "20:00", "per_day": 2,
+		})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		episodes := response["data"].([]interface{})
+		suite.Require().Len(episodes, 4)
+		assert.Equal(suite.T(), "scheduled", episodes[0].(map[string]interface{})["status"])
+		assert.Equal(suite.T(), "2031-03-01T12:00:00Z", episodes[1].(map[string]interface{})["publish_at"])
+		assert.Equal(suite.T(), "2031-03-02T12:00:00Z", episodes[2].(map[string]interface{})["publish_at"])
+	})
+
+	suite.Run("排期参数错误", func() {
+		w, _ := send("POST", fmt.Sprintf("/api/admin/dramas/%d/schedule", drama.ID), map[string]interface{}{
+			"from_episode": 5, "to_episode": 2, "start_date": "2031/03/01", "time": "20:00", "per_day": 2,
+		})
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	})
+
+	suite.Run("发布日历", func() {
+		w, response := send("GET", "/api/admin/calendar?from=2031-03-01&to=2031-03-07", nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := response["data"].(map[string]interface{})
+		assert.Equal(suite.T(), suite.config.Publish.GetTimezone(), data["timezone"])
+		days := data["days"].([]interface{})
+		suite.Require().Len(days, 2)
+		assert.Equal(suite.T(), "2031-03-01", days[0].(map[string]interface{})["date"])
+		assert.Len(suite.T(), days[1].(map[string]interface{})["items"], 2)
+
+		w, _ = send("GET", "/api/admin/calendar?from=2031-03-07&to=2031-03-01", nil)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+	})
+}
+
+func (suite *AdminIntegrationTestSuite) TestReviewWorkflowAPI() {
+	// 创建编辑角色的管理员并登录
+	editor := testutil.NewAdminFactory().CreateAdmin(func(a *models.Admin) {
+		a.Username, a.Email, a.Role = "workflow_editor", "editor@example.com", models.AdminRoleEditor
+	})
+	suite.Require().NoError(suite.adminRepo.Create(editor))
+	loginJSON, _ := json.Marshal(map[string]string{"username": editor.Username, "password": "admin123"})
+	req, _ := http.NewRequest("POST", "/api/auth/admin/login", bytes.NewBuffer(loginJSON))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+	suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+	var loginResp struct {
+		Data struct {
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &loginResp))
+	editorToken := loginResp.Data.Token
+
+	drama := &models.Drama{Title: "审核流程", Status: models.StatusDraft}
+	suite.Require().NoError(suite.dramaRepo.Create(drama))
+	transitionsPath := fmt.Sprintf("/api/admin/dramas/%d/transitions", drama.ID)
+	send := func(method, path, token string, body interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	suite.Run("编辑提交审核但不能审核", func() {
+		w, _ := send("POST", transitionsPath, editorToken, map[string]string{"status": "in_review"})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		w, _ = send("POST", transitionsPath, editorToken, map[string]string{"status": "approved"})
+		assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+	})
+
+	suite.Run("审核不通过必须填写意见", func() {
+		w, _ := send("POST", transitionsPath, suite.adminToken, map[string]string{"status": "rejected"})
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+
+		w, _ = send("POST", transitionsPath, suite.adminToken, map[string]string{"status": "rejected", "comment": "简介过短"})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		w, _ = send("POST", transitionsPath, suite.adminToken, map[string]string{"status": "published"})
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "审核不通过的短剧不能直接发布")
+	})
+
+	suite.Run("发布前对外不可见", func() {
+		publicPath := fmt.Sprintf("/api/dramas/%d", drama.ID)
+		w, _ := send("GET", publicPath, "", nil)
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+
+		for _, step := range []struct{ token, status string }{
+			{editorToken, "in_review"}, {suite.adminToken, "approved"}, {suite.adminToken, "published"},
+		} {
+			w, _ = send("POST", transitionsPath, step.token, map[string]string{"status": step.status})
+			suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		}
+		w, _ = send("GET", publicPath, "", nil)
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+	})
+
+	suite.Run("状态流转记录", func() {
+		w, response := send("GET", transitionsPath, editorToken, nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		records := response["data"].([]interface{})
+		suite.Require().Len(records, 5)
+		rejected := records[1].(map[string]interface{})
+		assert.Equal(suite.T(), "rejected", rejected["to_status"])
+		assert.Equal(suite.T(), "简介过短", rejected["comment"])
+		assert.Equal(suite.T(), "testadmin", rejected["admin_name"])
+
+		w, _ = send("POST", fmt.Sprintf("/api/admin/dramas/%d/transitions", 99999), suite.adminToken, map[string]string{"status": "in_review"})
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	})
+}
+
+func (suite *AdminIntegrationTestSuite) TestContentVisibilityAPI() {
+	drama := &models.Drama{Title: "未发布短剧", Status: models.StatusDraft}
+	suite.Require().NoError(suite.dramaRepo.Create(drama))
+	episode := &models.Episode{DramaID: drama.ID, Title: "第1集", EpisodeNum: 1, VideoURL: "https://cdn.example.com/draft.mp4", Status: models.StatusDraft}
+	suite.Require().NoError(suite.db.Create(episode).Error)
+	send := func(method, path, token string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req, _ := http.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	dramaPath := fmt.Sprintf("/api/dramas/%d", drama.ID)
+	episodePath := fmt.Sprintf("/api/episodes/%d", episode.ID)
+
+	suite.Run("匿名用户看不到未发布的内容", func() {
+		for _, path := range []string{dramaPath, dramaPath + "/episodes", dramaPath + "/episodes/list", episodePath} {
+			w, response := send("GET", path, "")
+			assert.Equal(suite.T(), http.StatusNotFound, w.Code, path)
+			assert.NotContains(suite.T(), w.Body.String(), episode.VideoURL, path)
+			assert.Nil(suite.T(), response["data"], path)
+		}
+	})
+
+	suite.Run("管理员可以查看未发布的内容", func() {
+		w, _ := send("GET", dramaPath, suite.adminToken)
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+		w, _ = send("GET", episodePath, suite.adminToken)
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+	})
+
+	suite.Run("通过预览令牌查看", func() {
+		w, response := send("POST", fmt.Sprintf("/api/admin/dramas/%d/preview-token", drama.ID), suite.adminToken)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		token := response["data"].(map[string]interface{})["token"].(string)
+
+		w, response = send("GET", dramaPath+"/episodes?preview_token="+token, "")
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		assert.Len(suite.T(), response["data"].(map[string]interface{})["episodes"], 1)
+
+		req, _ := http.NewRequest("GET", episodePath, nil)
+		req.Header.Set("X-Preview-Token", token)
+		w = httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusOK, w.Code, "短剧的预览令牌可以查看其中的剧集")
+
+		w, _ = send("GET", dramaPath+"?preview_token=invalid", "")
+		assert.Equal(suite.T(), http.StatusForbidden, w.Code)
+		w, _ = send("POST", "/api/admin/episodes/99999/preview-token", suite.adminToken)
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	})
+}
+
+func (suite *AdminIntegrationTestSuite) TestTrashAPI() {
+	drama := &models.Drama{Title: "回收站短剧", Status: models.StatusPublished}
+	suite.Require().NoError(suite.dramaRepo.Create(drama))
+	episodes := make([]*models.Episode, 2)
+	for i := range episodes {
+		episodes[i] = &models.Episode{DramaID: drama.ID, Title: fmt.Sprintf("第%d集", i+1), EpisodeNum: i + 1, Duration: 60, Status: models.StatusPublished}
+		suite.Require().NoError(suite.db.Create(episodes[i]).Error)
+	}
+	send := func(method, path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	trashIDs := func(entityType string) []float64 {
+		w, response := send("GET", "/api/admin/trash?type="+entityType+"&page_size=100")
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		var ids []float64
+		for _, item := range response["data"].(map[string]interface{})["items"].([]interface{}) {
+			ids = append(ids, item.(map[string]interface{})["id"].(float64))
+		}
+		return ids
+	}
+
+	suite.Run("删除短剧时剧集一起移入回收站", func() {
+		w, _ := send("DELETE", fmt.Sprintf("/api/admin/dramas/%d", drama.ID))
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		w, _ = send("GET", fmt.Sprintf("/api/episodes/%d", episodes[0].ID))
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+		assert.Contains(suite.T(), trashIDs(models.ReviewEntityDrama), float64(drama.ID))
+		assert.NotContains(suite.T(), trashIDs(models.ReviewEntityEpisode), float64(episodes[0].ID), "随短剧删除的剧集不单独列出")
+
+		w, _ = send("POST", fmt.Sprintf("/api/admin/trash/episode/%d/restore", episodes[0].ID))
+		assert.Equal(suite.T(), http.StatusConflict, w.Code, "所属短剧仍在回收站")
+
+		body := fmt.Sprintf(`{"drama_id":%d,"title":"第3集","episode_num":3,"duration":60}`, drama.ID)
+		req, _ := http.NewRequest("POST", "/api/admin/episodes", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		req.Header.Set("Content-Type", "application/json")
+		w = httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code, "回收站中的短剧下不能创建剧集")
+		var count int64
+		suite.Require().NoError(suite.db.Unscoped().Model(&models.Episode{}).Where("drama_id = ?", drama.ID).Count(&count).Error)
+		assert.Equal(suite.T(), int64(2), count)
+	})
+
+	suite.Run("恢复短剧时一起恢复剧集", func() {
+		w, _ := send("POST", fmt.Sprintf("/api/admin/trash/drama/%d/restore", drama.ID))
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+
+		w, response := send("GET", fmt.Sprintf("/api/dramas/%d/episodes", drama.ID))
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		assert.Len(suite.T(), response["data"].(map[string]interface{})["episodes"], 2)
+
+		w, _ = send("POST", fmt.Sprintf("/api/admin/trash/drama/%d/restore", drama.ID))
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code, "已恢复的短剧不在回收站中")
+	})
+
+	suite.Run("永久删除剧集", func() {
+		w, _ := send("DELETE", fmt.Sprintf("/api/admin/episodes/%d", episodes[1].ID))
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(suite.T(), trashIDs(models.ReviewEntityEpisode), float64(episodes[1].ID))
+
+		w, _ = send("DELETE", fmt.Sprintf("/api/admin/trash/episode/%d", episodes[1].ID))
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		var count int64
+		suite.Require().NoError(suite.db.Unscoped().Model(&models.Episode{}).Where("id = ?", episodes[1].ID).Count(&count).Error)
+		assert.Zero(suite.T(), count)
+
+		w, _ = send("DELETE", fmt.Sprintf("/api/admin/trash/episode/%d", episodes[1].ID))
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	})
+
+	suite.Run("无效的请求", func() {
+		w, _ := send("GET", "/api/admin/trash?type=user")
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+		w, _ = send("DELETE", "/api/admin/dramas/99999")
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+		w, _ = send("DELETE", "/api/admin/episodes/99999")
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	})
+}
+
+func (suite *AdminIntegrationTestSuite) TestRevisionAPI() {
+	drama := &models.Drama{Title: "修订短剧", Description: "原始简介", CoverImage: "https://cdn.example.com/cover.jpg", Category: "剧情", Status: models.StatusDraft}
+	suite.Require().NoError(suite.dramaRepo.Create(drama))
+	send := func(method, path string, body interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+		var reader *bytes.Reader
+		if body != nil {
+			data, _ := json.Marshal(body)
+			reader = bytes.NewReader(data)
+		} else {
+			reader = bytes.NewReader(nil)
+		}
+		req, _ := http.NewRequest(method, path, reader)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+suite.adminToken)
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+		var response map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	suite.Run("PATCH 清空字段并保存修订版本", func() {
+		w, response := send("PATCH", fmt.Sprintf("/api/admin/dramas/%d", drama.ID), map[string]interface{}{"description": "", "cover_image": ""})
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		data := response["data"].(map[string]interface{})
+		assert.Empty(suite.T(), data["description"])
+		assert.Empty(suite.T(), data["cover_image"])
+		assert.Equal(suite.T(), "修订短剧", data["title"], "未提供的字段保持不变")
+
+		w, _ = send("PATCH", fmt.Sprintf("/api/admin/dramas/%d", drama.ID), map[string]interface{}{"title": ""})
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code, "标题不能清空")
+
+		w, response = send("GET", fmt.Sprintf("/api/admin/dramas/%d/revisions", drama.ID), nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		items := response["data"].(map[string]interface{})["items"].([]interface{})
+		suite.Require().Len(items, 2)
+		assert.Equal(suite.T(), "testadmin", items[0].(map[string]interface{})["admin_name"])
+	})
+
+	suite.Run("比较版本并回滚", func() {
+		w, response := send("GET", fmt.Sprintf("/api/admin/dramas/%d/revisions/diff?from=1&to=2", drama.ID), nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		changes := response["data"].(map[string]interface{})["changes"].([]interface{})
+		suite.Require().Len(changes, 2)
+		assert.Equal(suite.T(), "cover_image", changes[0].(map[string]interface{})["field"])
+		assert.Equal(suite.T(), "原始简介", changes[1].(map[string]interface{})["from"])
+
+		w, response = send("POST", fmt.Sprintf("/api/admin/dramas/%d/revisions/1/rollback", drama.ID), nil)
+		suite.Require().Equal(http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(suite.T(), "原始简介", response["data"].(map[string]interface{})["description"])
+		assert.Equal(suite.T(), "https://cdn.example.com/cover.jpg", response["data"].(map[string]interface{})["cover_image"])
+	})
+
+	suite.Run("无效的请求", func() {
+		w, _ := send("POST", fmt.Sprintf("/api/admin/dramas/%d/revisions/99/rollback", drama.ID), nil)
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+		w, _ = send("GET", fmt.Sprintf("/api/admin/dramas/%d/revisions/diff?from=1", drama.ID), nil)
+		assert.Equal(suite.T(), http.StatusBadRequest, w.Code)
+		w, _ = send("PATCH", "/api/admin/episodes/99999", map[string]interface{}{"title": "不存在"})
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAdminIntegrationTestSuite(t *testing.T) {
 	suite.Run(t, new(AdminIntegrationTestSuite))
 }
@@ -5,110 +5,74 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"os"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"gorm.io/gorm"
 
-	"gin-mysql-api/internal/handler"
-	"gin-mysql-api/internal/models"
 	"gin-mysql-api/internal/repository"
-	"gin-mysql-api/internal/router"
-	"gin-mysql-api/internal/service"
+	"gin-mysql-api/internal/testutil"
 	"gin-mysql-api/pkg/config"
-	"gin-mysql-api/pkg/database"
 )
 
 type IntegrationTestSuite struct {
 	suite.Suite
-	router     *gin.Engine
-	db         *gorm.DB
-	config     *config.Config
-	userRepo   repository.UserRepository
-	authToken  string
-	adminToken string
+	router    *gin.Engine
+	db        *gorm.DB
+	config    *config.Config
+	userRepo  repository.UserRepository
+	authToken string
+	dramaID   uint
 }
 
 func (suite *IntegrationTestSuite) SetupSuite() {
 	// 设置测试环境
 	gin.SetMode(gin.TestMode)
 
-	// 加载测试配置 (使用开发配置 + 环境变量覆盖)
-	cfg, err := config.LoadConfig("../configs/config.yaml")
-	suite.Require().NoError(err)
-
-	// 覆盖测试专用配置
-	cfg.Database.DBName = "hajimi_test"
-	cfg.Redis.DB = 1
-	cfg.Server.Port = 8081
-	cfg.JWT.Secret = "test-secret-key"
-	cfg.Logging.Level = "debug"
-
+	// 测试配置默认使用 SQLite 内存数据库
+	cfg := testutil.GetTestConfig()
 	suite.config = cfg
 
-	// 连接测试数据库
-	db, err := database.NewConnection(cfg)
-	suite.Require().NoError(err)
-	suite.db = db
-
-	// 迁移数据库
-	err = db.AutoMigrate(&models.User{}, &models.Admin{}, &models.Drama{}, &models.Episode{})
-	suite.Require().NoError(err)
-
-	// 初始化仓储层
-	userRepo := repository.NewUserRepository(db)
-	adminRepo := repository.NewAdminRepository(db)
-	dramaRepo := repository.NewDramaRepository(db)
-	episodeRepo := repository.NewEpisodeRepository(db)
-
-	suite.userRepo = userRepo
-
-	// 初始化服务层
-	userService := service.NewUserService(userRepo, cfg)
-	adminService := service.NewAdminService(adminRepo, cfg)
-	dramaService := service.NewDramaService(dramaRepo, episodeRepo, nil, cfg) // Redis为nil用于测试
-	fileService := service.NewFileService(cfg)
-
-	// 初始化处理器
-	container := &handler.Container{
-		UserService:  userService,
-		AdminService: adminService,
-		DramaService: dramaService,
-		FileService:  fileService,
-		Config:       cfg,
-	}
+	// 连接测试数据库（已自动迁移）
+	suite.db = testutil.SetupTestDB()
+	suite.userRepo = repository.NewUserRepository(suite.db)
 
 	// 设置路由
-	suite.router = router.SetupRouter(container)
+	suite.router = setupTestRouter(suite.db, cfg)
 
-	// 创建测试用户和管理员
+	// 创建测试用户和短剧
 	suite.createTestData()
 }
 
 func (suite *IntegrationTestSuite) TearDownSuite() {
 	// 清理测试数据
-	suite.db.Exec("DELETE FROM episodes")
-	suite.db.Exec("DELETE FROM dramas")
-	suite.db.Exec("DELETE FROM users")
-	suite.db.Exec("DELETE FROM admins")
+	testutil.CleanupTestDB(suite.db)
 }
 
 func (suite *IntegrationTestSuite) createTestData() {
-	// 创建测试用户
-	testUser := &models.User{
-		Username: "testuser",
-		Email:    "test@example.com",
-		Password: "password123",
-	}
+	factory := testutil.NewFactory()
+
+	// 创建测试用户（密码为 password123）
+	testUser := factory.User.CreateUser()
 	err := suite.userRepo.Create(testUser)
 	suite.Require().NoError(err)
 
+	// 创建已发布的测试短剧及剧集
+	drama := factory.Drama.CreateDrama()
+	suite.Require().NoError(suite.db.Create(drama).Error)
+	suite.Require().NoError(suite.db.Create(factory.Episode.CreateEpisode(drama.ID)).Error)
+	suite.dramaID = drama.ID
+
 	// 获取用户认证token
 	loginData := map[string]string{
-		"username": "testuser",
+		"email":    "test@example.com",
 		"password": "password123",
 	}
 	loginJSON, _ := json.Marshal(loginData)
@@ -127,6 +91,7 @@ func (suite *IntegrationTestSuite) createTestData() {
 			suite.authToken = token
 		}
 	}
+	suite.Require().NotEmpty(suite.authToken)
 }
 
 // 测试用户认证API
@@ -146,18 +111,18 @@ func (suite *IntegrationTestSuite) TestUserAuthAPI() {
 		w := httptest.NewRecorder()
 		suite.router.ServeHTTP(w, req)
 
-		assert.Equal(suite.T(), http.StatusCreated, w.Code)
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
 
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), "success", response["status"])
+		assert.Equal(suite.T(), true, response["success"])
 	})
 
 	// 测试用户登录
 	suite.Run("用户登录", func() {
 		loginData := map[string]string{
-			"username": "testuser",
+			"email":    "test@example.com",
 			"password": "password123",
 		}
 		loginJSON, _ := json.Marshal(loginData)
@@ -173,12 +138,29 @@ func (suite *IntegrationTestSuite) TestUserAuthAPI() {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), "success", response["status"])
+		assert.Equal(suite.T(), true, response["success"])
 
 		data := response["data"].(map[string]interface{})
 		assert.NotEmpty(suite.T(), data["token"])
 	})
 
+	// 测试错误密码登录
+	suite.Run("错误密码登录", func() {
+		loginData := map[string]string{
+			"email":    "test@example.com",
+			"password": "wrongpassword",
+		}
+		loginJSON, _ := json.Marshal(loginData)
+
+		req, _ := http.NewRequest("POST", "/api/auth/login", bytes.NewBuffer(loginJSON))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	})
+
 	// 测试获取用户信息
 	suite.Run("获取用户信息", func() {
 		req, _ := http.NewRequest("GET", "/api/user/profile", nil)
@@ -192,7 +174,7 @@ func (suite *IntegrationTestSuite) TestUserAuthAPI() {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), "success", response["status"])
+		assert.Equal(suite.T(), true, response["success"])
 	})
 }
 
@@ -200,7 +182,7 @@ func (suite *IntegrationTestSuite) TestUserAuthAPI() {
 func (suite *IntegrationTestSuite) TestDramaAPI() {
 	// 测试获取短剧列表
 	suite.Run("获取短剧列表", func() {
-		req, _ := http.NewRequest("GET", "/api/dramas?page=1&limit=10", nil)
+		req, _ := http.NewRequest("GET", "/api/dramas?page=1&page_size=10", nil)
 
 		w := httptest.NewRecorder()
 		suite.router.ServeHTTP(w, req)
@@ -210,12 +192,12 @@ func (suite *IntegrationTestSuite) TestDramaAPI() {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), "success", response["status"])
+		assert.Equal(suite.T(), true, response["success"])
 	})
 
 	// 测试搜索短剧
 	suite.Run("搜索短剧", func() {
-		req, _ := http.NewRequest("GET", "/api/dramas/search?keyword=test&page=1&limit=10", nil)
+		req, _ := http.NewRequest("GET", "/api/dramas/search?keyword=test&page=1&page_size=10", nil)
 
 		w := httptest.NewRecorder()
 		suite.router.ServeHTTP(w, req)
@@ -225,29 +207,37 @@ func (suite *IntegrationTestSuite) TestDramaAPI() {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), "success", response["status"])
+		assert.Equal(suite.T(), true, response["success"])
 	})
 
 	// 测试获取短剧详情
 	suite.Run("获取短剧详情", func() {
-		req, _ := http.NewRequest("GET", "/api/dramas/1", nil)
+		req, _ := http.NewRequest("GET", "/api/dramas/"+strconv.FormatUint(uint64(suite.dramaID), 10), nil)
 
 		w := httptest.NewRecorder()
 		suite.router.ServeHTTP(w, req)
 
-		// 如果短剧不存在，应该返回404
-		assert.True(suite.T(), w.Code == http.StatusOK || w.Code == http.StatusNotFound)
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
 	})
 
 	// 测试获取剧集列表
 	suite.Run("获取剧集列表", func() {
-		req, _ := http.NewRequest("GET", "/api/dramas/1/episodes", nil)
+		req, _ := http.NewRequest("GET", "/api/dramas/"+strconv.FormatUint(uint64(suite.dramaID), 10)+"/episodes", nil)
 
 		w := httptest.NewRecorder()
 		suite.router.ServeHTTP(w, req)
 
-		// 如果短剧不存在，应该返回404
-		assert.True(suite.T(), w.Code == http.StatusOK || w.Code == http.StatusNotFound)
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+	})
+
+	// 测试获取不存在的短剧
+	suite.Run("获取不存在的短剧", func() {
+		req, _ := http.NewRequest("GET", "/api/dramas/99999", nil)
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusNotFound, w.Code)
 	})
 }
 
@@ -264,15 +254,115 @@ func (suite *IntegrationTestSuite) TestHealthAPI() {
 		var response map[string]interface{}
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		assert.NoError(suite.T(), err)
-		assert.Equal(suite.T(), "healthy", response["status"])
+
+		data := response["data"].(map[string]interface{})
+		assert.Equal(suite.T(), "ok", data["status"])
+	})
+
+	suite.Run("就绪检查探测数据库", func() {
+		req, _ := http.NewRequest("GET", "/ready", nil)
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(suite.T(), err)
+
+		data := response["data"].(map[string]interface{})
+		assert.Equal(suite.T(), "ready", data["status"])
+		checks := data["checks"].(map[string]interface{})
+		database := checks["database"].(map[string]interface{})
+		assert.Equal(suite.T(), "ok", database["status"])
 	})
 }
 
-func TestIntegrationTestSuite(t *testing.T) {
-	// 跳过集成测试，如果没有设置测试环境
-	if os.Getenv("INTEGRATION_TEST") != "true" {
-		t.Skip("跳过集成测试，设置 INTEGRATION_TEST=true 来运行")
+// 测试监控指标API
+func (suite *IntegrationTestSuite) TestMetricsAPI() {
+	// 先产生一次业务请求
+	req, _ := http.NewRequest("GET", "/api/dramas", nil)
+	suite.router.ServeHTTP(httptest.NewRecorder(), req)
+
+	suite.Run("未授权访问被拒绝", func() {
+		req, _ := http.NewRequest("GET", "/metrics", nil)
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+		assert.Contains(suite.T(), w.Header().Get("WWW-Authenticate"), "Basic")
+	})
+
+	suite.Run("白名单网段内的 IP 可直接访问", func() {
+		req, _ := http.NewRequest("GET", "/metrics", nil)
+		req.RemoteAddr = "10.1.2.3:12345"
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+	})
+
+	suite.Run("未配置受信任代理时伪造 X-Forwarded-For 无法绕过白名单", func() {
+		req, _ := http.NewRequest("GET", "/metrics", nil)
+		req.RemoteAddr = "203.0.113.5:12345"
+		req.Header.Set("X-Forwarded-For", "10.1.2.3")
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusUnauthorized, w.Code)
+	})
+
+	suite.Run("Basic Auth 访问返回 Prometheus 指标", func() {
+		req, _ := http.NewRequest("GET", "/metrics", nil)
+		req.SetBasicAuth("metrics", "metrics-secret")
+
+		w := httptest.NewRecorder()
+		suite.router.ServeHTTP(w, req)
+
+		assert.Equal(suite.T(), http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(suite.T(), body, `http_requests_total{method="GET",route="/api/dramas",status="200"}`)
+		assert.Contains(suite.T(), body, "http_request_duration_seconds_bucket")
+		assert.Contains(suite.T(), body, `db_query_duration_seconds_count{operation="query",status="ok",table="dramas"}`)
+		assert.Contains(suite.T(), body, `logins_total{result="success",role="user"}`)
+	})
+}
+
+func (suite *IntegrationTestSuite) TestTracingAPI() {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(prev)
+
+	req, _ := http.NewRequest("GET", "/api/dramas", nil)
+	w := httptest.NewRecorder()
+	suite.router.ServeHTTP(w, req)
+
+	assert.Equal(suite.T(), http.StatusOK, w.Code)
+	traceID := w.Header().Get("X-Trace-ID")
+	assert.NotEmpty(suite.T(), traceID)
+
+	// 数据库查询应作为请求 Span 的子 Span 出现在同一条链路中
+	var serverSpans, dbSpans int
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() != traceID {
+			continue
+		}
+		switch {
+		case span.Name() == "GET /api/dramas":
+			serverSpans++
+		case strings.HasPrefix(span.Name(), "gorm."):
+			dbSpans++
+		}
 	}
+	assert.Equal(suite.T(), 1, serverSpans)
+	assert.Greater(suite.T(), dbSpans, 0)
+}
 
+func TestIntegrationTestSuite(t *testing.T) {
 	suite.Run(t, new(IntegrationTestSuite))
 }
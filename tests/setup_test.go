@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"gin-mysql-api/internal/repository"
+	"gin-mysql-api/internal/router"
+	"gin-mysql-api/internal/service"
+	"gin-mysql-api/pkg/config"
+	"gin-mysql-api/pkg/health"
+	"gin-mysql-api/pkg/storage"
+	"gin-mysql-api/pkg/utils"
+)
+
+// setupTestRouter 基于测试数据库组装完整的服务与路由（不依赖 Redis）
+func setupTestRouter(db *gorm.DB, cfg *config.Config) *gin.Engine {
+	repos := repository.NewRepository(db)
+	jwtManager := utils.NewJWTManager(cfg.JWT.Secret, cfg.JWT.Expiration)
+
+	store := storage.NewLocal(cfg.Upload.UploadPath)
+	imageService := service.NewImageService(repos.Media, store, service.NewImageServiceConfig(cfg), nil)
+	fileService := service.NewFileService(store, repos.Media, imageService, service.NewFileServiceConfig(cfg), nil)
+	mediaService := service.NewMediaService(repos.Media, store, imageService, cfg.Upload.GC.GetRetention(), nil)
+	contentKeyService := service.NewContentKeyService(repos.Transcode, service.NewContentKeyring(cfg), cfg.Server.GetBaseURL(), nil)
+	playbackService := service.NewPlaybackService(repos.Transcode, repos.Episode, repos.Media, fileService, contentKeyService, nil, service.NewPlaybackSigner(cfg), service.NewPlaybackServiceConfig(cfg), nil)
+
+	adminService := service.NewAdminService(repos.Admin, repos.Drama, repos.Episode, jwtManager, nil, mediaService, nil, repos.Revision, nil)
+
+	services := &service.Container{
+		UserService:   service.NewUserService(repos.User, jwtManager, mediaService, nil),
+		AdminService:  adminService,
+		DramaService:  service.NewDramaService(repos.Drama, repos.Episode, nil, playbackService, nil),
+		FileService:   fileService,
+		UploadService: service.NewUploadService(repos.Upload, store, fileService, service.NewUploadServiceConfig(cfg), nil),
+		MediaService:  mediaService,
+		ImageService:  imageService,
+		AuthService:   service.NewAuthService(repos.User, repos.Admin, jwtManager, nil),
+
+		PlaybackService:   playbackService,
+		ContentKeyService: contentKeyService,
+		PublishService:    service.NewPublishService(repos.Admin, repos.Drama, repos.Episode, repos.Review, nil, nil, cfg.Publish.GetLocation(), nil),
+		ReviewService:     service.NewReviewService(repos.Admin, repos.Drama, repos.Episode, repos.Review, nil, nil),
+		PreviewService:    service.NewPreviewService(repos.Drama, repos.Episode, jwtManager, cfg.Server.GetBaseURL(), cfg.Playback.GetPreviewTTL(), nil),
+		TrashService:      service.NewTrashService(repos.Trash, repos.Drama, repos.Episode, mediaService, store, nil, cfg.Trash.GetRetention(), nil),
+		RevisionService:   service.NewRevisionService(repos.Revision, adminService, nil),
+	}
+
+	registry := health.NewRegistry(cfg.Health.GetCacheTTL(), cfg.Health.GetTimeout())
+	registry.Register(health.Probe{Name: "database", Critical: true, Check: health.DatabaseCheck(db)})
+
+	return router.NewRouter(jwtManager, services).
+		WithConfig(cfg).
+		WithMetrics(cfg.Metrics).
+		WithHealth(registry).
+		Setup()
+}